@@ -0,0 +1,209 @@
+// Command loadtest drives the web API with concurrent POST /analyze and
+// POST /balance requests against a fake Riot API filled with a synthetic
+// community (riottest.Generate), then reports latency percentiles and
+// throughput per endpoint. Use it to compare scaling changes (rank workers,
+// limiter settings, caches) on the same reproducible dataset:
+//
+//	go run ./cmd/loadtest [-players 200] [-concurrency 8] [-duration 30s] [-balance-ratio 0.5]
+//
+// Lobbies are drawn at random from the dataset, so repeated players hit the
+// match cache the way a real community does.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/httpapi"
+	"lol_custom_skill_matching/internal/riot"
+	"lol_custom_skill_matching/internal/riot/riottest"
+	"lol_custom_skill_matching/internal/store"
+)
+
+// endpoint collects the outcome of every request sent to one route.
+type endpoint struct {
+	name string
+
+	mu        sync.Mutex
+	latencies []time.Duration
+	errors    int
+	lastErr   string
+}
+
+func (e *endpoint) record(d time.Duration, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.latencies = append(e.latencies, d)
+	if err != nil {
+		e.errors++
+		e.lastErr = err.Error()
+	}
+}
+
+func (e *endpoint) report(elapsed time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	n := len(e.latencies)
+	if n == 0 {
+		fmt.Printf("%-8s no requests\n", e.name)
+		return
+	}
+	slices.Sort(e.latencies)
+	pct := func(p float64) float64 {
+		return e.latencies[min(n-1, int(p*float64(n)))].Seconds() * 1000
+	}
+	fmt.Printf("%-8s %6d req %5d err %8.1f req/s   p50 %7.1fms  p90 %7.1fms  p99 %7.1fms  max %7.1fms\n",
+		e.name, n, e.errors, float64(n)/elapsed.Seconds(), pct(0.5), pct(0.9), pct(0.99), pct(1))
+	if e.lastErr != "" {
+		fmt.Printf("         last error: %s\n", e.lastErr)
+	}
+}
+
+// post sends body as JSON and fails on any status but 200.
+func post(url string, body any) error {
+	b, _ := json.Marshal(body)
+	resp, err := http.Post(url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d: %s", resp.StatusCode, bytes.TrimSpace(raw))
+	}
+	return nil
+}
+
+// lobby draws size distinct players.
+func lobby(rng *rand.Rand, players []riottest.Player, size int) []riottest.Player {
+	out := make([]riottest.Player, 0, size)
+	for _, i := range rng.Perm(len(players))[:size] {
+		out = append(out, players[i])
+	}
+	return out
+}
+
+// ratedPlayer mirrors the /balance request's player.
+type ratedPlayer struct {
+	Name      string   `json:"name"`
+	Score     int      `json:"score"`
+	MainLanes []string `json:"mainLanes,omitempty"`
+}
+
+// rated scores p from its solo rank (unranked players get silver) and gives it
+// one random main lane.
+func rated(rng *rand.Rand, p riottest.Player) ratedPlayer {
+	score := riot.RankScore("SILVER", "IV", 0)
+	if p.Tier != "" {
+		div := p.Division
+		if div == "" {
+			div = "I" // apex tiers have no division
+		}
+		score = riot.RankScore(p.Tier, div, p.LP)
+	}
+	return ratedPlayer{Name: p.RiotID(), Score: score, MainLanes: []string{riottest.Lanes[rng.IntN(len(riottest.Lanes))]}}
+}
+
+func main() {
+	players := flag.Int("players", 200, "size of the synthetic community")
+	matches := flag.Int("matches", 20, "recent matches generated per player")
+	size := flag.Int("lobby", 10, "players per request")
+	concurrency := flag.Int("concurrency", 8, "concurrent clients")
+	duration := flag.Duration("duration", 30*time.Second, "how long to send requests")
+	requests := flag.Int("requests", 0, "stop after this many requests instead of -duration")
+	balanceRatio := flag.Float64("balance-ratio", 0.5, "fraction of requests sent to /balance")
+	preset := flag.String("preset", analyzer.PresetStandard, "analysis preset for /analyze")
+	matchLimit := flag.Int("match-limit", 10, "server MATCH_LIMIT")
+	rankWorkers := flag.Int("rank-workers", 4, "analyzer lobby-rank workers")
+	latency := flag.Duration("riot-latency", 20*time.Millisecond, "fake Riot API response time")
+	seed := flag.Uint64("seed", 1, "dataset and traffic seed")
+	verbose := flag.Bool("v", false, "show the server's logs")
+	flag.Parse()
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+	if *size < 2 || *size > *players {
+		fmt.Fprintf(os.Stderr, "-lobby must be between 2 and -players (%d)\n", *players)
+		os.Exit(2)
+	}
+
+	fake := riottest.NewServer()
+	defer fake.Close()
+	defer fake.ServeDataDragon()()
+	start := time.Now()
+	community := fake.Generate(riottest.GenOptions{Players: *players, Matches: *matches, Seed: *seed})
+	fmt.Printf("generated %d players (%d matches each) in %.1fs\n", len(community), *matches, time.Since(start).Seconds())
+	fake.Latency = *latency
+	rc := fake.Client()
+	srv := &httpapi.Server{Analyzer: analyzer.New(rc, *rankWorkers), Store: store.NewMemory(), MatchLimit: *matchLimit}
+	api := httptest.NewServer(srv.Handler())
+	defer api.Close()
+	http.DefaultTransport.(*http.Transport).MaxIdleConnsPerHost = *concurrency
+
+	analyze, bal := &endpoint{name: "analyze"}, &endpoint{name: "balance"}
+	var sent atomic.Int64
+	deadline := time.Now().Add(*duration)
+	more := func() bool {
+		if *requests > 0 {
+			return sent.Add(1) <= int64(*requests)
+		}
+		return time.Now().Before(deadline)
+	}
+	start = time.Now()
+	var wg sync.WaitGroup
+	for w := 0; w < *concurrency; w++ {
+		wg.Add(1)
+		go func(rng *rand.Rand) {
+			defer wg.Done()
+			for more() {
+				picked := lobby(rng, community, *size)
+				t := time.Now()
+				if rng.Float64() < *balanceRatio {
+					body := struct {
+						Players []ratedPlayer `json:"players"`
+					}{}
+					for _, p := range picked {
+						body.Players = append(body.Players, rated(rng, p))
+					}
+					err := post(api.URL+"/balance", body)
+					bal.record(time.Since(t), err)
+					continue
+				}
+				body := struct {
+					Players []analyzer.Player `json:"players"`
+					Preset  string            `json:"preset"`
+				}{Preset: *preset}
+				for _, p := range picked {
+					body.Players = append(body.Players, analyzer.Player{GameName: p.GameName, TagLine: p.TagLine})
+				}
+				err := post(api.URL+"/analyze", body)
+				analyze.record(time.Since(t), err)
+			}
+		}(rand.New(rand.NewPCG(*seed, uint64(w))))
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	fmt.Printf("%d clients for %.1fs\n", *concurrency, elapsed.Seconds())
+	analyze.report(elapsed)
+	bal.report(elapsed)
+	ls := rc.Limiter.Stats()
+	fmt.Printf("riot     %6d req (%d matches) %5d throttled, limiter waited %.1fs\n",
+		fake.Hits(""), fake.Hits(riottest.RouteMatch), ls.Throttled, float64(ls.WaitedMs)/1000)
+	if analyze.errors+bal.errors > 0 {
+		os.Exit(1)
+	}
+}
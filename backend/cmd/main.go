@@ -1,11 +1,15 @@
 package main
 
 import (
+	"context"
+	"errors"
     "encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
@@ -13,135 +17,23 @@ import (
 	"time"
 
 	"github.com/joho/godotenv"
-)
-
-// Tier/Rankを数値化するマップ
-var tierToInt = map[string]int{
-	"IRON":        1,
-	"BRONZE":      2,
-	"SILVER":      3,
-	"GOLD":        4,
-	"PLATINUM":    5,
-	"EMERALD":     6,
-	"DIAMOND":     7,
-	"MASTER":      8,
-	"GRANDMASTER": 9,
-	"CHALLENGER":  10,
-}
-var intToTier = map[int]string{
-	1:  "IRON",
-	2:  "BRONZE",
-	3:  "SILVER",
-	4:  "GOLD",
-	5:  "PLATINUM",
-	6:  "EMERALD",
-	7:  "DIAMOND",
-	8:  "MASTER",
-	9:  "GRANDMASTER",
-	10: "CHALLENGER",
-}
-var rankToInt = map[string]int{
-	"IV":  1,
-	"III": 2,
-	"II":  3,
-	"I":   4,
-}
-var intToRank = map[int]string{
-	1: "IV",
-	2: "III",
-	3: "II",
-	4: "I",
-}
-
-// Tier/Rank/LPを一意のスコアに変換
-func rankScore(tier, rank string, lp int) int {
-	t := tierToInt[tier]
-	r := rankToInt[rank]
-	return ((t-1)*4+(r-1))*100 + lp
-}
 
-// スコアからTier/Rank/LPに逆変換
-func scoreToRank(score int) (string, string, int) {
-	tierIdx := score/400 + 1
-	rankIdx := (score%400)/100 + 1
-	lp := score % 100
-	tier := intToTier[tierIdx]
-	rank := intToRank[rankIdx]
-	return tier, rank, lp
-}
-
-type Account struct {
-	PUUID    string `json:"puuid"`
-	GameName string `json:"gameName"`
-	TagLine  string `json:"tagLine"`
-}
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/clock"
+	"lol_custom_skill_matching/internal/paths"
+	"lol_custom_skill_matching/internal/riot"
+	"lol_custom_skill_matching/internal/store"
+)
 
 type Player struct {
 	GameName string `json:"gameName"`
 	TagLine  string `json:"tagLine"`
+	// Platform/Region: このプレイヤーだけ別のサーバー（例: na1, euw1, kr）で取得する（省略時は -platform / -region）
+	Platform string `json:"platform,omitempty"`
+	Region   string `json:"region,omitempty"`
 }
 
-// -------- レートリミット/進捗管理 --------
-type RiotLimiter struct {
-	mu     sync.Mutex
-	secWin []time.Time
-	twoMin []time.Time
-}
-
-func NewRiotLimiter() *RiotLimiter { return &RiotLimiter{} }
-
-// Wait blocks until a request is permitted under 20req/s and 100req/120s.
-// Returns total sleep time spent inside the call.
-func (r *RiotLimiter) Wait() time.Duration {
-	var slept time.Duration
-	for {
-		r.mu.Lock()
-		now := time.Now()
-		// prune windows
-		cutoff1 := now.Add(-1 * time.Second)
-		for len(r.secWin) > 0 && r.secWin[0].Before(cutoff1) {
-			r.secWin = r.secWin[1:]
-		}
-		cutoff2 := now.Add(-120 * time.Second)
-		for len(r.twoMin) > 0 && r.twoMin[0].Before(cutoff2) {
-			r.twoMin = r.twoMin[1:]
-		}
-		// if allowed now
-		if len(r.secWin) < 20 && len(r.twoMin) < 100 {
-			// record send time
-			r.secWin = append(r.secWin, now)
-			r.twoMin = append(r.twoMin, now)
-			r.mu.Unlock()
-			return slept
-		}
-		// compute sleep needed to satisfy both limits
-		wait1 := time.Duration(0)
-		if len(r.secWin) >= 20 {
-			w := r.secWin[0].Add(1 * time.Second).Sub(now)
-			if w > wait1 {
-				wait1 = w
-			}
-		}
-		wait2 := time.Duration(0)
-		if len(r.twoMin) >= 100 {
-			w := r.twoMin[0].Add(120 * time.Second).Sub(now)
-			if w > wait2 {
-				wait2 = w
-			}
-		}
-		sleepFor := wait1
-		if wait2 > sleepFor {
-			sleepFor = wait2
-		}
-		if sleepFor < 10*time.Millisecond {
-			sleepFor = 10 * time.Millisecond
-		}
-		r.mu.Unlock()
-		time.Sleep(sleepFor)
-		slept += sleepFor
-	}
-}
-
+// -------- 進捗管理 --------
 type Counters struct {
 	mu        sync.Mutex
 	players   int
@@ -149,13 +41,15 @@ type Counters struct {
 	attempts  int
 	completed int
 	retries   int
+	clock     clock.Clock
 	start     time.Time
 	waitRL    time.Duration
 	wait429   time.Duration
 }
 
-func NewCounters(players int) *Counters {
-	return &Counters{players: players, start: time.Now()}
+func NewCounters(players int, clk clock.Clock) *Counters {
+	clk = clock.Or(clk)
+	return &Counters{players: players, clock: clk, start: clk.Now()}
 }
 func (c *Counters) AddPlanned(n int) {
 	c.mu.Lock()
@@ -199,7 +93,7 @@ func (c *Counters) Snapshot() (players, planned, attempts, completed, retries in
 	attempts = c.attempts
 	completed = c.completed
 	retries = c.retries
-	elapsed = time.Since(c.start)
+	elapsed = clock.Since(c.clock, c.start)
 	waitRL = c.waitRL
 	wait429 = c.wait429
 	remain := planned - completed
@@ -228,100 +122,85 @@ func (c *Counters) PrintEstimate(prefix string) {
 		p, cm, pl, at, rt, durStr(el), durStr(wrl), durStr(w429), durStr(eta), note)
 }
 
-// 改良版リトライ付きAPIリクエスト（429はRetry-Afterに従い無制限リトライ）
-func doRequestWithRetry(req *http.Request, client *http.Client, limiter *RiotLimiter, counters *Counters, maxRetry int) (*http.Response, error) {
-	// SKIPフラグ取得
-	skipOnLimit := os.Getenv("SKIP") == "true"
+// Data Dragonのチャンピオン名（ID→名前）。取得はリトライ付きで1回だけ行い、
+// CDN障害時は前回保存した champion.json（CHAMPION_CACHE、既定はユーザーのキャッシュディレクトリの champion_cache.json）を使う
+var championNames map[int]string
 
-	backoff := 1 * time.Second
-	var lastStatus int
-	tries := 0
-	for {
-		// Acquire under rate limits (メイン側でETA表示)
-		slept := limiter.Wait()
-		counters.AddRateWait(slept)
-		counters.RecordAttempt()
-		resp, err := client.Do(req)
-		tries++
-		if err == nil && resp != nil && resp.StatusCode == 200 {
-			counters.RecordCompleted()
-			return resp, nil
-		}
-		if resp != nil {
-			lastStatus = resp.StatusCode
-			// 404は正常扱い（アンランク等）
-			if resp.StatusCode == 404 {
-				counters.RecordCompleted()
-				return resp, nil
-			}
-			// 429: Retry-Afterに従って必ずリトライ
-			if resp.StatusCode == 429 {
-				counters.RecordRetry()
-				ra := strings.TrimSpace(resp.Header.Get("Retry-After"))
-				resp.Body.Close()
-				var wait time.Duration
-				if ra != "" {
-					if v, err := strconv.Atoi(ra); err == nil {
-						wait = time.Duration(v) * time.Second
-					}
-				}
-				if wait == 0 {
-					// Fallback: 2分窓のペース配分に合わせる
-					wait = 2 * time.Second
-				}
-				fmt.Printf("[情報] 429 Too Many Requests: %s 待機\n", durStr(wait))
-				counters.Add429Wait(wait)
-				if skipOnLimit {
-					// SKIP=trueなら無視して次へ
-					return nil, nil
-				}
-				time.Sleep(wait)
-				continue // 無制限リトライ
-			}
-			// 一時的なサーバーエラー（5xx）は指数バックオフでリトライ
-			if resp.StatusCode >= 500 && resp.StatusCode < 600 {
-				resp.Body.Close()
-				if skipOnLimit {
-					return nil, nil
-				}
-				if maxRetry > 0 && tries >= maxRetry {
-					break
-				}
-				time.Sleep(backoff)
-				if backoff < 30*time.Second {
-					backoff *= 2
-				}
-				continue
-			}
-			// それ以外のステータスはエラー扱い
-			resp.Body.Close()
-		}
-		// ネットワークエラー等
-		if skipOnLimit {
-			return nil, nil
+func loadChampionNames() map[int]string {
+	if championNames != nil {
+		return championNames
+	}
+	cacheFile := os.Getenv("CHAMPION_CACHE")
+	if cacheFile == "" {
+		cacheFile = paths.CacheFile("champion_cache.json")
+	}
+	c, err := riot.LoadChampions(context.Background(), http.DefaultClient, riot.DataDragonVersion, riot.DataDragonLocale, cacheFile)
+	if err != nil {
+		log.Printf("チャンピオンデータ取得失敗: %v", err)
+		return map[int]string{}
+	}
+	championNames = c.ByID
+	return championNames
+}
+
+func main() {
+	// -skip-lobby-rank: 平均マッチランク(参加者ランク取得)を省略し、ランク+マスタリー+勝率のみでスコア算出
+	skipLobbyRank := flag.Bool("skip-lobby-rank", false, "平均マッチランクの算出を省略してリクエスト数を大幅に削減する")
+	// -preset: quick/standard/deep の試合数・対象キュー・期間・平均マッチランクの有無と参加者の選び方をまとめて指定（MATCH_LIMIT・-skip-lobby-rank が優先）
+	presetName := flag.String("preset", "", "解析プリセット（"+strings.Join(analyzer.PresetNames(), "/")+"）")
+	// -server: Riot API を直接呼ばず、Web API サーバーに解析を依頼して結果を表示（ANALYZE_SERVER でも指定可）
+	serverURL := flag.String("server", "", "解析を依頼する Web API サーバーの URL（例: http://localhost:8080）")
+	// -platform / -region: 既定のサーバー（jp1）とリージョン（asia）を変える（RIOT_PLATFORM / RIOT_REGION でも指定可）
+	platformID := flag.String("platform", "", "プレイヤーのサーバー（jp1/kr/na1/euw1 など、既定 jp1）")
+	regionID := flag.String("region", "", "試合データのリージョン（"+strings.Join(riot.Regions, "/")+"、既定はサーバーに対応するもの）")
+	flag.Parse()
+
+	matchLimit := 10
+	// 試合の絞り込み（キュー・期間）と窓の拡大。プリセットなしでは従来どおり既定のキュー・全参加者
+	var opts analyzer.Options
+	var sampler analyzer.ParticipantSampler = analyzer.AllParticipants{}
+	if *presetName != "" {
+		preset, err := analyzer.LookupPreset(*presetName)
+		if err != nil {
+			log.Fatal(err)
 		}
-		if maxRetry > 0 && tries >= maxRetry {
-			break
+		// パッチの絞り込み・重み付けはスコア算出の一部でローカル解析にはない。HistoryLimit はサーバーが
+		// バックフィルした試合を足すもので、ローカル解析には足す試合がない
+		if preset.Patch != "" || preset.PatchDecay > 0 {
+			log.Fatalf("プリセット %s のパッチ指定はローカル解析では使えません（-server でサーバーに依頼してください）", preset.Name)
 		}
-		time.Sleep(backoff)
-		if backoff < 30*time.Second {
-			backoff *= 2
+		if err := preset.Apply(&opts); err != nil {
+			log.Fatal(err)
 		}
+		matchLimit = preset.MatchLimit
+		*skipLobbyRank = *skipLobbyRank || preset.SkipLobbyRank
+		sampler = opts.Sampler
 	}
-	return nil, fmt.Errorf("APIリクエスト失敗（リトライ上限, status=%d）", lastStatus)
-}
 
-func main() {
 	godotenv.Load()
+	godotenv.Load(filepath.Join(paths.ConfigDir(), ".env")) // ダブルクリック起動など、作業ディレクトリに .env がない場合
+	if *serverURL == "" {
+		*serverURL = os.Getenv("ANALYZE_SERVER")
+	}
+	if *platformID == "" {
+		*platformID = os.Getenv("RIOT_PLATFORM")
+	}
+	if *regionID == "" {
+		*regionID = os.Getenv("RIOT_REGION")
+	}
 	apiKey := os.Getenv("RIOT_API_KEY")
-	if apiKey == "" {
+	if apiKey == "" && *serverURL == "" {
 		log.Fatal("RIOT_API_KEYが設定されていません")
 	}
 
 	// 複数プレイヤー対応: プレイヤー名リストをJSONから読み込み
 	playersPath := os.Getenv("PLAYERS_FILE")
 	if playersPath == "" {
-		playersPath = "players.json" // backend直下を想定
+		// 作業ディレクトリ → 実行ファイルと同じフォルダ → データディレクトリの順に探す
+		var tried []string
+		if playersPath, tried = paths.Find("players.json"); playersPath == "" {
+			log.Fatalf("プレイヤーリストJSONが見つかりません (探した場所: %s)", strings.Join(tried, ", "))
+		}
 	}
 	var players []Player
 	if b, err := os.ReadFile(playersPath); err != nil {
@@ -334,20 +213,84 @@ func main() {
 	if len(players) == 0 {
 		log.Fatalf("プレイヤーリストが空です (%s)", playersPath)
 	}
+	home := Player{Platform: *platformID, Region: *regionID}
+	if home.Platform == "" && home.Region != "" {
+		home.Platform = riot.DefaultPlatform
+	}
+	for _, p := range append([]Player{home}, players...) {
+		if p.Platform == "" && p.Region == "" {
+			continue
+		}
+		if _, err := riot.LookupPlatform(p.Platform, p.Region); err != nil {
+			log.Fatalf("サーバー指定が不正です (%s#%s): %v", p.GameName, p.TagLine, err)
+		}
+	}
+	if *serverURL != "" {
+		if err := analyzeOnServer(*serverURL, players, home, *presetName, *skipLobbyRank); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
-	// レートリミット/進捗管理の初期化
-	limiter := NewRiotLimiter()
-	counters := NewCounters(len(players))
+	// レートリミット/進捗管理の初期化（Riot API の呼び出しはWebサーバーと共通の riot.Client を使う）
+	counters := NewCounters(len(players), clock.Real)
+	rc := riot.NewClient(apiKey, riot.NewLimiter())
+	rc.SkipOnLimit = os.Getenv("SKIP") == "true" // SKIP=trueなら429等で待たずにそのリクエストを諦める
+	if home.Platform != "" {
+		p, _ := riot.LookupPlatform(home.Platform, home.Region)
+		rc.SetHome(p)
+	}
+	rc.Hooks = riot.Hooks{
+		Attempt: func(waited time.Duration) {
+			counters.AddRateWait(waited)
+			counters.RecordAttempt()
+		},
+		Done: counters.RecordCompleted,
+		Throttled: func(wait time.Duration) {
+			counters.RecordRetry()
+			counters.Add429Wait(wait)
+			fmt.Printf("[情報] 429 Too Many Requests: %s 待機\n", durStr(wait))
+		},
+	}
+	// 試合詳細はプレイヤーごとに何度も参照するため実行中はメモリに、SQLite 対応ビルド（-tags sqlite）では
+	// ファイル（MATCH_CACHE、既定はキャッシュディレクトリの match_cache.db）にも保存し、次回以降は取得済みの試合に Riot API を使わない
+	rc.Cache = riot.NewMemoryCache()
+	rc.CacheTTLs = map[string]time.Duration{riot.EndpointMatch: 24 * time.Hour}
+	matchCachePath := os.Getenv("MATCH_CACHE")
+	if matchCachePath == "" && store.HasDriver(store.DriverSQLite) {
+		matchCachePath = paths.CacheFile("match_cache.db")
+	}
+	if matchCachePath != "" && matchCachePath != "none" {
+		mc, err := store.OpenMatchCache(matchCachePath)
+		if err != nil {
+			log.Fatalf("試合キャッシュを開けません (%s): %v", matchCachePath, err)
+		}
+		defer mc.Close()
+		rc.Matches = mc
+	}
+	api := rc.API()
+	ctx := context.Background()
+	// 試合詳細・参加者ランクは並列に取得する（レート制限は全ワーカーで共有。Web API と同じ RANK_WORKERS / MATCH_WORKERS）
+	rankWorkers := riot.DefaultWorkers
+	if n, err := strconv.Atoi(os.Getenv("RANK_WORKERS")); err == nil && n > 0 {
+		rankWorkers = n
+	}
+	matchWorkers := rankWorkers
+	if n, err := strconv.Atoi(os.Getenv("MATCH_WORKERS")); err == nil && n > 0 {
+		matchWorkers = n
+	}
 	// 概算の案内
-	matchLimit := 10
 	if ml := os.Getenv("MATCH_LIMIT"); ml != "" {
 		if n, err := strconv.Atoi(ml); err == nil && n > 0 {
 			matchLimit = n
 		}
 	}
 	approxPerPlayer := 4 + 12*matchLimit // account(1), matchlist(1), matchdetail*2(matchLimit*2), rank(1), mastery(1), participants rank(~matchLimit*10)
+	if *skipLobbyRank {
+		approxPerPlayer = 4 + 2*matchLimit // 参加者収集・参加者ランク取得なし
+	}
 	fmt.Printf("対象プレイヤー数: %d\n", len(players))
-	fmt.Printf("レート制限: 20 req/s, 100 req/120s (理論最大≒50 req/分)\n")
+	fmt.Printf("レート制限: 20 req/s, 100 req/120s (理論最大≒50 req/分、本番キーなら Riot の応答ヘッダーの上限に自動で合わせます)\n")
 	fmt.Printf("MATCH_LIMIT: %d\n", matchLimit)
 	fmt.Printf("1人あたり想定Riotリクエスト(概算): %d 件\n", approxPerPlayer)
 	fmt.Printf("理論最短所要時間(概算): 約 %.1f 分\n", float64(approxPerPlayer*len(players))*1.2/60.0)
@@ -363,61 +306,38 @@ func main() {
 			gameName := player.GameName // ゲーム名
 			tagLine := player.TagLine   // タグライン
 
-			url := fmt.Sprintf("https://asia.api.riotgames.com/riot/account/v1/accounts/by-riot-id/%s/%s", gameName, tagLine)
-			req, err := http.NewRequest("GET", url, nil)
-			if err != nil {
-				log.Fatal(err)
-			}
-			req.Header.Set("X-Riot-Token", apiKey)
-
-			client := &http.Client{}
 			counters.AddPlanned(1) // account by riot-id
-			resp, err := doRequestWithRetry(req, client, limiter, counters, 3)
-			if err != nil {
-				log.Fatalf("APIリクエスト失敗: %v", err)
-			}
-			if resp == nil {
+			account, found, err := api.Account.ByRiotID(ctx, gameName, tagLine)
+			if errors.Is(err, riot.ErrSkipped) {
 				continue
 			}
-			defer resp.Body.Close()
-
-			if resp.StatusCode != 200 {
-				log.Fatalf("APIリクエスト失敗: %s", resp.Status)
+			if err != nil {
+				log.Fatalf("APIリクエスト失敗: %v", err)
 			}
-
-			var account Account
-			if err := json.NewDecoder(resp.Body).Decode(&account); err != nil {
-				log.Fatal(err)
+			if !found {
+				log.Fatalf("APIリクエスト失敗: %s#%s が見つかりません", gameName, tagLine)
 			}
 
 			fmt.Printf("ゲーム名: %s#%s\nPUUID: %s\n", account.GameName, account.TagLine, account.PUUID)
+			if player.Platform != "" {
+				p, _ := riot.LookupPlatform(player.Platform, player.Region)
+				rc.Pin(account.PUUID, p) // 以降のランク・マスタリー・試合はこのプレイヤーのサーバーから取得
+			}
 
 			// 2. PUUIDからマッチIDリストを取得
 			fmt.Printf("[開始] %s#%s: マッチリスト取得\n", player.GameName, player.TagLine)
-			matchListUrl := fmt.Sprintf("https://asia.api.riotgames.com/lol/match/v5/matches/by-puuid/%s/ids?start=0&count=100", account.PUUID)
-			matchReq, err := http.NewRequest("GET", matchListUrl, nil)
-			if err != nil {
-				log.Fatal(err)
-			}
-			matchReq.Header.Set("X-Riot-Token", apiKey)
-
 			counters.AddPlanned(1) // match list
-			matchResp, err := doRequestWithRetry(matchReq, client, limiter, counters, 3)
-			if err != nil {
-				log.Fatalf("マッチリストAPIリクエスト失敗: %v", err)
+			var matchIDs []string
+			if opts.Since.IsZero() {
+				matchIDs, err = api.Match.IDs(ctx, account.PUUID, 0, 100)
+			} else {
+				matchIDs, err = api.Match.IDsSince(ctx, account.PUUID, opts.Since, 0, 100) // -preset の期間より前の試合は取得しない
 			}
-			if matchResp == nil {
+			if errors.Is(err, riot.ErrSkipped) {
 				continue
 			}
-			defer matchResp.Body.Close()
-
-			if matchResp.StatusCode != 200 {
-				log.Fatalf("マッチリストAPIリクエスト失敗: %s", matchResp.Status)
-			}
-
-			var matchIDs []string
-			if err := json.NewDecoder(matchResp.Body).Decode(&matchIDs); err != nil {
-				log.Fatal(err)
+			if err != nil {
+				log.Fatalf("マッチリストAPIリクエスト失敗: %v", err)
 			}
 
 			fmt.Printf("取得したマッチID数: %d\n", len(matchIDs))
@@ -428,114 +348,76 @@ func main() {
 			// 3. 各マッチIDから詳細を取得し、使ったチャンピオンを集計
 			championCount := make(map[int]int)
 			laneCount := make(map[string]int) // レーン集計用
-			maxMatches := 10                  // デフォルト: 10試合分集計
-			if ml := os.Getenv("MATCH_LIMIT"); ml != "" {
-				if n, err := strconv.Atoi(ml); err == nil && n > 0 {
-					maxMatches = n
-				}
-			}
+			maxMatches := matchLimit          // デフォルト: 10試合分集計
 			if len(matchIDs) < maxMatches {
 				maxMatches = len(matchIDs)
 			}
+			// 集計対象が -preset の MinGames に満たない間は MaxMatchLimit まで試合を足す（サーバーの解析と同じ）
+			maxWindow := min(max(opts.MaxMatchLimit, maxMatches), len(matchIDs))
+			var countedIDs []string // 集計した試合（参加者収集にも使う）
 			// ランク戦回数・勝利数
 			rankedCount := 0
 			rankedWin := 0
 			fmt.Printf("[開始] %s#%s: マッチ詳細(使用チャンプ/レーン) 取得 %d件\n", player.GameName, player.TagLine, maxMatches)
-			// 使うマッチ詳細(1回目)
-			counters.AddPlanned(maxMatches)
-			for i := 0; i < maxMatches; i++ {
-				matchID := matchIDs[i]
-				matchDetailUrl := fmt.Sprintf("https://asia.api.riotgames.com/lol/match/v5/matches/%s", matchID)
-				matchDetailReq, err := http.NewRequest("GET", matchDetailUrl, nil)
-				if err != nil {
-					log.Fatal(err)
-				}
-				matchDetailReq.Header.Set("X-Riot-Token", apiKey)
-
-				matchDetailResp, err := doRequestWithRetry(matchDetailReq, client, limiter, counters, 3)
-				if err != nil {
-					log.Fatalf("マッチ詳細APIリクエスト失敗: %v", err)
-				}
-				if matchDetailResp == nil {
-					continue
-				}
-				defer matchDetailResp.Body.Close()
-
-				if matchDetailResp.StatusCode != 200 {
-					log.Printf("マッチ詳細APIリクエスト失敗: %s", matchDetailResp.Status)
-					continue
-				}
-
-				var matchDetail struct {
-					Info struct {
-						QueueID      int `json:"queueId"`
-						Participants []struct {
-							PUUID        string `json:"puuid"`
-							ChampionID   int    `json:"championId"`
-							TeamPosition string `json:"teamPosition"`
-							Win          bool   `json:"win"`
-						} `json:"participants"`
-					} `json:"info"`
-				}
-				if err := json.NewDecoder(matchDetailResp.Body).Decode(&matchDetail); err != nil {
-					log.Printf("マッチ詳細デコード失敗: %v", err)
-					continue
-				}
-
-				// アリーナ(1700), クイックプレイ(490), ARAM(450)は無視
-				if matchDetail.Info.QueueID == 1700 || matchDetail.Info.QueueID == 490 || matchDetail.Info.QueueID == 450 {
-					continue
-				}
-				// ノーマル(400, 430)とランク(420)のみ集計
-				if matchDetail.Info.QueueID != 400 && matchDetail.Info.QueueID != 430 && matchDetail.Info.QueueID != 420 {
-					continue
-				}
+			// 使うマッチ詳細(1回目): matchWorkers 並列でまとめて取得し、新しい順に集計（2回目・3回目はキャッシュから読む）
+			type fetchedMatch struct {
+				m   *riot.Match
+				err error
+			}
+			for fetched := 0; ; {
+				counters.AddPlanned(maxMatches - fetched)
+				details := riot.FanOut(ctx, matchWorkers, matchIDs[fetched:maxMatches], func(ctx context.Context, id string) fetchedMatch {
+					m, err := api.Match.Get(ctx, id)
+					return fetchedMatch{m, err}
+				})
+				for i, d := range details {
+					matchID := matchIDs[fetched+i]
+					matchDetail, err := d.m, d.err
+					if errors.Is(err, riot.ErrSkipped) {
+						continue
+					}
+					if err != nil {
+						log.Fatalf("マッチ詳細APIリクエスト失敗: %v", err)
+					}
+					if matchDetail == nil {
+						log.Printf("マッチ詳細APIリクエスト失敗: %s が見つかりません", matchID)
+						continue
+					}
 
-				for _, p := range matchDetail.Info.Participants {
-					if p.PUUID == account.PUUID {
-						championCount[p.ChampionID]++
-						lane := p.TeamPosition
-						if lane == "" {
-							lane = "UNKNOWN"
-						}
-						laneCount[lane]++
-						// ランク戦判定
-						if matchDetail.Info.QueueID == 420 {
-							rankedCount++
-							if p.Win {
-								rankedWin++
+					// 既定ではノーマル(ドラフト・ブラインド)とランクソロのみ集計（アリーナ・クイックプレイ・ARAMは無視）。-preset のキュー・期間で絞る
+					if !opts.Counts(matchDetail.Info.QueueID, matchDetail.Info.GameCreation) {
+						continue
+					}
+					countedIDs = append(countedIDs, matchID)
+
+					for _, p := range matchDetail.Info.Participants {
+						if p.PUUID == account.PUUID {
+							championCount[p.ChampionID]++
+							lane := p.TeamPosition
+							if lane == "" {
+								lane = "UNKNOWN"
+							}
+							laneCount[lane]++
+							// ランク戦判定
+							if matchDetail.Info.QueueID == riot.QueueRankedSolo {
+								rankedCount++
+								if p.Win {
+									rankedWin++
+								}
 							}
 						}
 					}
 				}
-				// API制限対策（RiotLimiterで吸収）
+				fetched = maxMatches
+				if len(countedIDs) >= opts.MinGames || maxMatches >= maxWindow {
+					break
+				}
+				maxMatches = min(maxWindow, maxMatches+analyzer.NextBatch(opts.MinGames-len(countedIDs), len(countedIDs), fetched))
+				fmt.Printf("[情報] %s#%s: 集計対象が %d件のため %d件まで取得\n", player.GameName, player.TagLine, len(countedIDs), maxMatches)
 			}
 
 			// Data DragonからチャンピオンID→名前のマップを取得
-			championIDToName := make(map[int]string)
-			championDataURL := "https://ddragon.leagueoflegends.com/cdn/15.14.1/data/ja_JP/champion.json"
-			championResp, err := http.Get(championDataURL)
-			if err != nil {
-				log.Printf("チャンピオンデータ取得失敗: %v", err)
-			} else {
-				defer championResp.Body.Close()
-				var champData struct {
-					Data map[string]struct {
-						Key  string `json:"key"`
-						Name string `json:"name"`
-					} `json:"data"`
-				}
-				if err := json.NewDecoder(championResp.Body).Decode(&champData); err != nil {
-					log.Printf("チャンピオンデータデコード失敗: %v", err)
-				} else {
-					for _, v := range champData.Data {
-						// keyはstring型の数字
-						var id int
-						fmt.Sscanf(v.Key, "%d", &id)
-						championIDToName[id] = v.Name
-					}
-				}
-			}
+			championIDToName := loadChampionNames()
 
 			// 4. チャンピオンIDごとに多い順で出力
 			fmt.Println("\n使ったチャンピオンランキング（多い順）:")
@@ -578,79 +460,31 @@ func main() {
 
 			// ランク情報取得（by-puuid版）
 			fmt.Printf("[開始] %s#%s: ランク情報取得\n", player.GameName, player.TagLine)
-			rankUrl := fmt.Sprintf("https://jp1.api.riotgames.com/lol/league/v4/entries/by-puuid/%s", account.PUUID)
-			rankReq, err := http.NewRequest("GET", rankUrl, nil)
-			if err != nil {
-				log.Fatal(err)
-			}
-			rankReq.Header.Set("X-Riot-Token", apiKey)
-
 			counters.AddPlanned(1) // rank (by puuid)
-			rankResp, err := doRequestWithRetry(rankReq, client, limiter, counters, 3)
-			if err != nil {
-				log.Fatalf("ランク情報取得APIリクエスト失敗: %v", err)
-			}
-			if rankResp == nil {
+			rankData, err := api.League.Entries(ctx, account.PUUID)
+			if errors.Is(err, riot.ErrSkipped) {
 				continue
 			}
-			defer rankResp.Body.Close()
-
-			if rankResp.StatusCode != 200 {
-				log.Fatalf("ランク情報取得APIリクエスト失敗: %s", rankResp.Status)
-			}
-
-			var rankData []struct {
-				QueueType    string `json:"queueType"`
-				Tier         string `json:"tier"`
-				Rank         string `json:"rank"`
-				LeaguePoints int    `json:"leaguePoints"`
-			}
-			if err := json.NewDecoder(rankResp.Body).Decode(&rankData); err != nil {
-				log.Fatal(err)
+			if err != nil {
+				log.Fatalf("ランク情報取得APIリクエスト失敗: %v", err)
 			}
 
 			fmt.Println("\nランク情報:")
-			found := false
-			for _, entry := range rankData {
-				if entry.QueueType == "RANKED_SOLO_5x5" {
-					fmt.Printf("ソロランク: %s %s %dLP\n", entry.Tier, entry.Rank, entry.LeaguePoints)
-					found = true
-				}
-			}
-			if !found {
+			if entry, ok := riot.SoloEntry(rankData); ok {
+				fmt.Printf("ソロランク: %s %s %dLP\n", entry.Tier, entry.Rank, entry.LeaguePoints)
+			} else {
 				fmt.Println("ソロランク: ランクなし")
 			}
 
 			// マスタリーAPI取得（by-puuid版）
 			fmt.Printf("[開始] %s#%s: マスタリー取得\n", player.GameName, player.TagLine)
-			masteryUrl := fmt.Sprintf("https://jp1.api.riotgames.com/lol/champion-mastery/v4/champion-masteries/by-puuid/%s", account.PUUID)
-			masteryReq, err := http.NewRequest("GET", masteryUrl, nil)
-			if err != nil {
-				log.Fatal(err)
-			}
-			masteryReq.Header.Set("X-Riot-Token", apiKey)
-
 			counters.AddPlanned(1) // mastery (by puuid)
-			masteryResp, err := doRequestWithRetry(masteryReq, client, limiter, counters, 3)
-			if err != nil {
-				log.Fatalf("マスタリーAPIリクエスト失敗: %v", err)
-			}
-			if masteryResp == nil {
+			masteries, err := api.Mastery.ByPUUID(ctx, account.PUUID)
+			if errors.Is(err, riot.ErrSkipped) {
 				continue
 			}
-			defer masteryResp.Body.Close()
-
-			if masteryResp.StatusCode != 200 {
-				log.Fatalf("マスタリーAPIリクエスト失敗: %s", masteryResp.Status)
-			}
-
-			var masteries []struct {
-				ChampionID     int `json:"championId"`
-				ChampionLevel  int `json:"championLevel"`
-				ChampionPoints int `json:"championPoints"`
-			}
-			if err := json.NewDecoder(masteryResp.Body).Decode(&masteries); err != nil {
-				log.Fatal(err)
+			if err != nil {
+				log.Fatalf("マスタリーAPIリクエスト失敗: %v", err)
 			}
 
 			fmt.Println("\nチャンピオンマスタリー:")
@@ -663,117 +497,80 @@ func main() {
 			}
 
 			// --- 平均マッチランク計算 ---
-			fmt.Println("\n直近試合の平均マッチランク計算中...")
-			fmt.Printf("[開始] %s#%s: 参加者収集 %d件\n", player.GameName, player.TagLine, maxMatches)
-			puuidSet := make(map[string]struct{})
-			maxMatches = 10 // デフォルト: 10試合分のみ集計
-			if ml := os.Getenv("MATCH_LIMIT"); ml != "" {
-				if n, err := strconv.Atoi(ml); err == nil && n > 0 {
-					maxMatches = n
-				}
-			}
-			if len(matchIDs) < maxMatches {
-				maxMatches = len(matchIDs)
+			var lobbies [][]string // 試合ごとの参加者（-preset の抽出方法で選ぶ）
+			botsSkipped := 0
+			// -skip-lobby-rank 時は参加者を収集しない（＝参加者ランク取得も0件）
+			participantMatches := countedIDs
+			if *skipLobbyRank {
+				participantMatches = nil
+			} else {
+				fmt.Println("\n直近試合の平均マッチランク計算中...")
+				fmt.Printf("[開始] %s#%s: 参加者収集 %d件\n", player.GameName, player.TagLine, len(participantMatches))
 			}
 			// 使うマッチ詳細(2回目: 参加者収集)
-			counters.AddPlanned(maxMatches)
-			for i := 0; i < maxMatches; i++ {
-				matchID := matchIDs[i]
-				matchDetailUrl := fmt.Sprintf("https://asia.api.riotgames.com/lol/match/v5/matches/%s", matchID)
-				matchDetailReq, err := http.NewRequest("GET", matchDetailUrl, nil)
-				if err != nil {
-					log.Fatal(err)
+			counters.AddPlanned(len(participantMatches))
+			for _, matchID := range participantMatches {
+				matchDetail, err := api.Match.Get(ctx, matchID)
+				if errors.Is(err, riot.ErrSkipped) {
+					continue
 				}
-				matchDetailReq.Header.Set("X-Riot-Token", apiKey)
-
-				matchDetailResp, err := doRequestWithRetry(matchDetailReq, client, limiter, counters, 3)
 				if err != nil {
 					log.Fatalf("マッチ詳細APIリクエスト失敗: %v", err)
 				}
-				if matchDetailResp == nil {
-					continue
-				}
-				defer matchDetailResp.Body.Close()
-
-				if matchDetailResp.StatusCode != 200 {
-					log.Printf("マッチ詳細APIリクエスト失敗: %s", matchDetailResp.Status)
-					continue
-				}
-
-				var matchDetail struct {
-					Info struct {
-						Participants []struct {
-							PUUID string `json:"puuid"`
-						} `json:"participants"`
-					} `json:"info"`
-				}
-				if err := json.NewDecoder(matchDetailResp.Body).Decode(&matchDetail); err != nil {
-					log.Printf("マッチ詳細デコード失敗: %v", err)
+				if matchDetail == nil {
+					log.Printf("マッチ詳細APIリクエスト失敗: %s が見つかりません", matchID)
 					continue
 				}
+				var lobby []string
 				for _, p := range matchDetail.Info.Participants {
-					puuidSet[p.PUUID] = struct{}{}
+					// ボット（カスタム/Co-op の AI）はPUUIDが空または"BOT"。ランク取得の対象外
+					if riot.IsBotPUUID(p.PUUID) {
+						botsSkipped++
+						continue
+					}
+					lobby = append(lobby, p.PUUID)
 				}
-				// API制限対策（RiotLimiterで吸収）
+				lobbies = append(lobbies, lobby)
 			}
 
-			// 全PUUIDのランクを取得
+			// 選んだ参加者のランクを取得
 			var totalScore, count int
-			puuidList := make([]string, 0, len(puuidSet))
-			for puuid := range puuidSet {
-				puuidList = append(puuidList, puuid)
+			puuidList := sampler.Sample(account.PUUID, lobbies)
+			if botsSkipped > 0 {
+				fmt.Printf("[情報] %s#%s: ボット参加者 %d件をスキップ\n", player.GameName, player.TagLine, botsSkipped)
 			}
 			fmt.Printf("[開始] %s#%s: 参加者ランク取得 %d人\n", player.GameName, player.TagLine, len(puuidList))
 			// ここで参加者ランク問い合わせの総数が確定
 			counters.AddPlanned(len(puuidList))
-			for _, puuid := range puuidList {
-				rankUrl := fmt.Sprintf("https://jp1.api.riotgames.com/lol/league/v4/entries/by-puuid/%s", puuid)
-				rankReq, err := http.NewRequest("GET", rankUrl, nil)
-				if err != nil {
-					log.Printf("ランクリクエスト作成失敗: %v", err)
-					continue
-				}
-				rankReq.Header.Set("X-Riot-Token", apiKey)
-
-				rankResp, err := doRequestWithRetry(rankReq, client, limiter, counters, 3)
-				if err != nil {
-					log.Printf("ランクAPIリクエスト失敗: %v", err)
-					continue
-				}
-				if rankResp == nil {
-					continue
-				}
-				defer rankResp.Body.Close()
-
-				if rankResp.StatusCode != 200 {
-					log.Printf("ランクAPIリクエスト失敗: %s", rankResp.Status)
+			type soloScore struct {
+				score int
+				ok    bool
+				err   error
+			}
+			scores := riot.FanOut(ctx, rankWorkers, puuidList, func(ctx context.Context, puuid string) soloScore {
+				score, ok, err := api.League.SoloScore(ctx, puuid)
+				return soloScore{score, ok, err}
+			})
+			for _, s := range scores {
+				if errors.Is(s.err, riot.ErrSkipped) {
 					continue
 				}
-
-				var rankData []struct {
-					QueueType    string `json:"queueType"`
-					Tier         string `json:"tier"`
-					Rank         string `json:"rank"`
-					LeaguePoints int    `json:"leaguePoints"`
-				}
-				if err := json.NewDecoder(rankResp.Body).Decode(&rankData); err != nil {
-					log.Printf("ランクデコード失敗: %v", err)
+				if s.err != nil {
+					log.Printf("ランクAPIリクエスト失敗: %v", s.err)
 					continue
 				}
-				for _, entry := range rankData {
-					if entry.QueueType == "RANKED_SOLO_5x5" {
-						score := rankScore(entry.Tier, entry.Rank, entry.LeaguePoints)
-						totalScore += score
-						count++
-						break
-					}
+				if s.ok {
+					totalScore += s.score
+					count++
 				}
 				// 進捗表示はメインgoroutineで実施
 			}
 			if count > 0 {
 				avgScore := totalScore / count
-				tier, rank, lp := scoreToRank(avgScore)
+				tier, rank, lp := riot.ScoreToRank(avgScore)
 				fmt.Printf("\n直近10試合の平均マッチランク: %s %s %dLP（%d人分）\n", tier, rank, lp, count)
+			} else if *skipLobbyRank {
+				fmt.Println("\n平均マッチランク: 省略 (-skip-lobby-rank)")
 			} else {
 				fmt.Println("\n平均マッチランク: データなし")
 			}
@@ -787,13 +584,7 @@ func main() {
 
 			// --- スキルスコア算出 ---
 			// 現在のランクスコア
-			currentRankScore := 0
-			for _, entry := range rankData {
-				if entry.QueueType == "RANKED_SOLO_5x5" {
-					currentRankScore = rankScore(entry.Tier, entry.Rank, entry.LeaguePoints)
-					break
-				}
-			}
+			currentRankScore, _ := riot.SoloScore(rankData)
 			// 平均マッチランクスコア
 			avgRankScore := 0
 			if count > 0 {
@@ -811,6 +602,10 @@ func main() {
 			}
 			// 仮のスキルスコア計算（重み付けは調整可）
 			skillScore := currentRankScore*2 + avgRankScore + topMastery/1000
+			if *skipLobbyRank {
+				// 平均マッチランクの代わりに勝率補正した現在ランクを使う
+				skillScore = currentRankScore*2 + analyzer.WinrateAdjustedRank(currentRankScore, rankedWin, rankedCount) + topMastery/1000
+			}
 
 			// --- 得意レーン・チャンピオン抽出 ---
 			// レーン
@@ -887,42 +682,18 @@ func main() {
 			counters.AddPlanned(maxMatches)
 			for i := 0; i < maxMatches; i++ {
 				matchID := matchIDs[i]
-				matchDetailUrl := fmt.Sprintf("https://asia.api.riotgames.com/lol/match/v5/matches/%s", matchID)
-				matchDetailReq, err := http.NewRequest("GET", matchDetailUrl, nil)
+				matchDetail, err := api.Match.Get(ctx, matchID)
 				if err != nil {
+					if !errors.Is(err, riot.ErrSkipped) {
+						log.Printf("レーンチャンピオンリクエスト失敗: %v", err)
+					}
 					continue
 				}
-				matchDetailReq.Header.Set("X-Riot-Token", apiKey)
-				matchDetailResp, err := doRequestWithRetry(matchDetailReq, client, limiter, counters, 3)
-				if err != nil {
-					log.Printf("レーンチャンピオンリクエスト失敗: %v", err)
-					continue
-				}
-				if matchDetailResp == nil {
-					continue
-				}
-				defer matchDetailResp.Body.Close()
-				if matchDetailResp.StatusCode != 200 {
-					continue
-				}
-				var matchDetail struct {
-					Info struct {
-						QueueID      int `json:"queueId"`
-						Participants []struct {
-							PUUID        string `json:"puuid"`
-							ChampionID   int    `json:"championId"`
-							TeamPosition string `json:"teamPosition"`
-						} `json:"participants"`
-					} `json:"info"`
-				}
-				if err := json.NewDecoder(matchDetailResp.Body).Decode(&matchDetail); err != nil {
+				if matchDetail == nil {
 					continue
 				}
 				// アリーナ・クイックプレイ・ARAMは無視
-				if matchDetail.Info.QueueID == 1700 || matchDetail.Info.QueueID == 490 || matchDetail.Info.QueueID == 450 {
-					continue
-				}
-				if matchDetail.Info.QueueID != 400 && matchDetail.Info.QueueID != 430 && matchDetail.Info.QueueID != 420 {
+				if !analyzer.QualifyingQueue(matchDetail.Info.QueueID) {
 					continue
 				}
 				for _, p := range matchDetail.Info.Participants {
@@ -1068,11 +839,12 @@ AFTER_ASYNC:
 	if err != nil {
 		log.Fatal(err)
 	}
-	err = os.WriteFile("team_result.json", jsonResult, 0644)
+	resultPath := paths.DataFile("team_result.json")
+	err = os.WriteFile(resultPath, jsonResult, 0644)
 	if err != nil {
 		log.Fatalf("ファイル出力失敗: %v", err)
 	}
-	fmt.Println("\nチーム分け結果を team_result.json に出力しました")
+	fmt.Printf("\nチーム分け結果を %s に出力しました\n", resultPath)
 
     // Discord Webhook 通知は無効化（要求により削除）
 
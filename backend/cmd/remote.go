@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/balance"
+	"lol_custom_skill_matching/internal/paths"
+)
+
+// remoteTimeout は解析の待ち時間の上限（大人数・deep は数分かかる）
+const remoteTimeout = 30 * time.Minute
+
+// remoteResult は /analyze のレスポンス（チーム分け + meta）
+type remoteResult struct {
+	analyzer.TeamSplit
+	Meta struct {
+		ResultID string              `json:"result_id"`
+		Cost     analyzer.CostReport `json:"cost"`
+	} `json:"meta"`
+}
+
+// analyzeOnServer は Riot API を直接呼ばず、サーバー（-server / ANALYZE_SERVER）の
+// POST /analyze にプレイヤー一覧を送って結果を表示し、team_result.json に保存する。
+// Riot API キーやキャッシュはサーバー側のものを使う。home の platform/region は指定のないプレイヤーの既定。
+func analyzeOnServer(server string, players []Player, home Player, preset string, skipLobbyRank bool) error {
+	req := map[string]any{"players": players}
+	if home.Platform != "" {
+		req["platform"], req["region"] = home.Platform, home.Region
+	}
+	if preset != "" {
+		req["preset"] = preset
+	}
+	if n, err := strconv.Atoi(os.Getenv("MATCH_LIMIT")); err == nil && n > 0 {
+		req["matchLimit"] = n
+	}
+	if skipLobbyRank {
+		req["includeLobbyRank"] = false
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	url := strings.TrimRight(server, "/") + "/analyze"
+	fmt.Printf("対象プレイヤー数: %d\n", len(players))
+	fmt.Printf("%s に解析を依頼しています...\n", url)
+	start := time.Now()
+	client := &http.Client{Timeout: remoteTimeout}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("サーバーに接続できません: %w", err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("サーバーがエラーを返しました (%s): %s", resp.Status, strings.TrimSpace(string(raw)))
+	}
+	var res remoteResult
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return fmt.Errorf("レスポンスを読めません: %w", err)
+	}
+	cost := res.Meta.Cost
+	if cost.AccuracyTier != "" {
+		fmt.Printf("解析完了（%s、Riotリクエスト %d 件、キャッシュヒット率 %.0f%%、精度 %s）\n",
+			durStr(time.Since(start)), cost.RiotCalls, cost.CacheHitRate*100, cost.AccuracyTier)
+	} else {
+		fmt.Printf("解析完了（%s）\n", durStr(time.Since(start)))
+	}
+
+	fmt.Println("\n=== チーム分け結果 ===")
+	printRemoteTeam(teamLabel(res.Teams[0], "A"), res.SumA, res.TeamA)
+	printRemoteTeam(teamLabel(res.Teams[1], "B"), res.SumB, res.TeamB)
+	for _, rs := range []struct {
+		title string
+		split *balance.RoleSplit
+	}{{"レーン被りなしチーム分け", res.LaneUnique}, {"レーン優先チーム分け", res.RolesFirst}} {
+		if rs.split == nil {
+			continue
+		}
+		fmt.Printf("\n=== %s ===\n", rs.title)
+		printRemoteSlots(teamLabel(res.Teams[0], "A"), rs.split.SumA, rs.split.TeamA)
+		printRemoteSlots(teamLabel(res.Teams[1], "B"), rs.split.SumB, rs.split.TeamB)
+	}
+	if len(res.WinPredictions) > 0 {
+		fmt.Println("\n=== 予測勝率（ブルー / レッド） ===")
+		for _, wp := range res.WinPredictions {
+			fmt.Printf("  %s: %.1f%% / %.1f%%\n", wp.Split, wp.BlueWinPct, wp.RedWinPct)
+		}
+	}
+
+	resultPath := paths.DataFile("team_result.json")
+	if err := os.WriteFile(resultPath, raw, 0644); err != nil {
+		return fmt.Errorf("ファイル出力失敗: %w", err)
+	}
+	fmt.Printf("\nチーム分け結果を %s に出力しました", resultPath)
+	if res.Meta.ResultID != "" {
+		fmt.Printf("（サーバーの結果 ID: %s）", res.Meta.ResultID)
+	}
+	fmt.Println()
+	return nil
+}
+
+// teamLabel は「Aチーム（Blue・ブルーサイド）」のような見出し
+func teamLabel(t analyzer.TeamInfo, letter string) string {
+	side := "ブルーサイド"
+	if t.Side == analyzer.SideRed {
+		side = "レッドサイド"
+	}
+	return fmt.Sprintf("%sチーム（%s・%s）", letter, t.Name, side)
+}
+
+func printRemoteTeam(label string, sum int, team []analyzer.Profile) {
+	fmt.Printf("%s 合計スキル: %d\n", label, sum)
+	for _, p := range team {
+		fmt.Printf("  %s スキル:%d メインレーン:%v\n", p.Name, p.SkillScore, p.MainLanes)
+	}
+}
+
+func printRemoteSlots(label string, sum int, slots []balance.Slot) {
+	fmt.Printf("%s 合計スキル: %d\n", label, sum)
+	for _, s := range slots {
+		fmt.Printf("  %s スキル:%d レーン:%s\n", s.Name, s.Skill, s.Role)
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/joho/godotenv"
+
+	"lol_custom_skill_matching/internal/app"
+	"lol_custom_skill_matching/internal/paths"
+)
+
+func main() {
+	env := os.Environ() // before .env is applied, so a reload reads it again
+	// Load env from .env (cwd=backend via Makefile). Fallback to backend/.env when executed from repo root,
+	// then the per-user one; variables already set win.
+	if err := godotenv.Load(); err != nil {
+		_ = godotenv.Load("backend/.env")
+	}
+	_ = godotenv.Load(filepath.Join(paths.ConfigDir(), ".env"))
+
+	// optional: log to file if LOG_FILE is set
+	if lf := os.Getenv("LOG_FILE"); lf != "" {
+		if f, err := os.OpenFile(lf, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644); err == nil {
+			log.Printf("logging to %s", lf)
+			log.SetOutput(f)
+		} else {
+			log.Printf("failed to open LOG_FILE=%s: %v", lf, err)
+		}
+	}
+
+	backupTo := flag.String("backup", "", "write a backup archive (tar.gz) of the store and cache files to this path and exit")
+	restoreFrom := flag.String("restore", "", "restore the store and cache files from this backup archive and exit")
+	local := flag.Bool("local", false, "desktop mode: serve the API and the embedded UI on localhost, open the browser and keep data in the user's data directory")
+	noBrowser := flag.Bool("no-browser", false, "with -local, don't open the browser")
+	check := flag.Bool("healthcheck", false, "exit 0 if the server on PORT is ready (for container healthchecks)")
+	calibrate := flag.Bool("calibrate", false, "print how stored results' predicted win chances compare with their outcomes (JSON) and exit")
+	unverified := flag.Bool("unverified", false, "with -calibrate, also use outcomes entered by hand")
+	// "serve" and "calibrate" are accepted as subcommand names: `server serve --local`
+	if len(os.Args) > 1 && (os.Args[1] == "serve" || os.Args[1] == "calibrate") {
+		if os.Args[1] == "calibrate" {
+			*calibrate = true
+		}
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+	flag.Parse()
+	cfg := app.ConfigFromEnv()
+	switch {
+	case *check:
+		healthcheck(cfg.Port)
+		return
+	case *backupTo != "":
+		m, err := app.Backup(cfg, *backupTo)
+		if err != nil {
+			log.Fatalf("backup: %v", err)
+		}
+		log.Printf("wrote %s (records=%v files=%d)", *backupTo, m.Records, len(m.Files))
+		return
+	case *calibrate:
+		rep, err := app.Calibrate(cfg, *unverified)
+		if err != nil {
+			log.Fatalf("calibrate: %v", err)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(rep)
+		return
+	case *restoreFrom != "":
+		m, err := app.Restore(cfg, *restoreFrom)
+		if err != nil {
+			log.Fatalf("restore: %v", err)
+		}
+		log.Printf("restored %s from backup taken %s (records=%v)", *restoreFrom, m.CreatedAt.Format("2006-01-02 15:04:05"), m.Records)
+		return
+	}
+
+	if *local {
+		serveLocal(cfg, !*noBrowser)
+		return
+	}
+
+	serve(cfg, env)
+}
+
+// serveLocal runs app.ServeLocal until Ctrl+C (or the console window is
+// closed). Errors wait for Enter so a double-clicked console doesn't vanish
+// before the message can be read.
+func serveLocal(cfg app.Config, openBrowser bool) {
+	fail := func(err error) {
+		log.Print(err)
+		fmt.Fprintln(os.Stderr, "Enter で終了します")
+		_, _ = bufio.NewReader(os.Stdin).ReadString('\n')
+		os.Exit(1)
+	}
+	if cfg.APIKey == "" && !cfg.DemoMode {
+		key, err := promptAPIKey()
+		if err != nil {
+			fail(err)
+		}
+		cfg.APIKey = key
+	}
+	a, err := app.New(cfg)
+	if err != nil {
+		fail(err)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := a.ServeLocal(ctx, openBrowser); err != nil {
+		fail(err)
+	}
+}
+
+// promptAPIKey asks for the Riot API key and saves it to the per-user .env,
+// which is read on the next start.
+func promptAPIKey() (string, error) {
+	envFile := filepath.Join(paths.ConfigDir(), ".env")
+	fmt.Print("Riot API キーを入力してください（https://developer.riotgames.com で取得）: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	key := strings.TrimSpace(line)
+	if key == "" {
+		return "", fmt.Errorf("RIOT_API_KEY is required (set it in %s): %v", envFile, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(envFile), 0o700); err != nil {
+		return "", err
+	}
+	f, err := os.OpenFile(envFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintf(f, "RIOT_API_KEY=%s\n", key); err != nil {
+		return "", err
+	}
+	log.Printf("saved the API key to %s", envFile)
+	return key, nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"lol_custom_skill_matching/internal/app"
+)
+
+// readyFD is the pipe a successor started by reload reports readiness on;
+// handoffFD is the one it learns on that its predecessor drained and saved
+// its unfinished jobs (JOB_CHECKPOINT_FILE), so it resumes them only then.
+const (
+	readyFD   = 3
+	handoffFD = 4
+)
+
+// successorTimeout bounds how long a reload waits for the new process
+// (loading a large store included) before giving up and keeping this one.
+const successorTimeout = time.Minute
+
+// serve runs the server until SIGINT/SIGTERM, draining in-flight requests.
+// With REUSE_PORT=true, SIGHUP starts a fresh copy of the process (which
+// reads .env and the environment again) on the same port and drains this one
+// once the copy is ready, so a config change needs no downtime.
+func serve(cfg app.Config, env []string) {
+	a, err := app.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ln, err := a.Listen()
+	if err != nil {
+		log.Fatal(err)
+	}
+	a.HandedOver = awaitHandoff()
+	notifyReady()
+
+	ctx, stop := context.WithCancel(context.Background())
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		for sig := range sigs {
+			if sig != syscall.SIGHUP {
+				log.Printf("%s: shutting down", sig)
+				stop()
+				return
+			}
+			if !cfg.ReusePort {
+				log.Printf("SIGHUP ignored: reloading needs REUSE_PORT=true")
+				continue
+			}
+			handoff, err := startSuccessor(env)
+			if err != nil {
+				log.Printf("reload failed, still serving: %v", err)
+				continue
+			}
+			log.Printf("reload: new process is ready, draining this one")
+			a.Drained = func() {
+				fmt.Fprintln(handoff, "drained")
+				handoff.Close()
+			}
+			stop()
+			return
+		}
+	}()
+	if err := a.Serve(ctx, ln); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// startSuccessor starts this binary again with the original arguments and env
+// and waits until it listens. It returns the pipe to tell the successor the
+// jobs are handed over on; writing to it, or closing it (even by exiting),
+// lets it resume them.
+func startSuccessor(env []string) (*os.File, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	hr, hw, err := os.Pipe()
+	if err != nil {
+		w.Close()
+		return nil, err
+	}
+	defer hr.Close()
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Env = append(env, fmt.Sprintf("READY_FD=%d", readyFD), fmt.Sprintf("HANDOFF_FD=%d", handoffFD))
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	cmd.ExtraFiles = []*os.File{w, hr} // fds 3 and 4 in the child
+	err = cmd.Start()
+	w.Close()
+	if err != nil {
+		hw.Close()
+		return nil, err
+	}
+	go cmd.Wait() // reap it if it fails; a ready successor outlives us
+
+	ready := make(chan error, 1)
+	go func() {
+		line, err := bufio.NewReader(r).ReadString('\n')
+		if err == nil && line != "ready\n" {
+			err = fmt.Errorf("unexpected %q", line)
+		}
+		ready <- err
+	}()
+	select {
+	case err := <-ready:
+		if err != nil {
+			hw.Close()
+			return nil, fmt.Errorf("new process exited before it was ready: %w", err)
+		}
+		return hw, nil
+	case <-time.After(successorTimeout):
+		cmd.Process.Kill()
+		hw.Close()
+		return nil, errors.New("new process not ready in time")
+	}
+}
+
+// awaitHandoff is closed once the process that started this one (see
+// startSuccessor) handed its jobs over; nil when nothing started it.
+func awaitHandoff() <-chan struct{} {
+	if os.Getenv("HANDOFF_FD") != fmt.Sprint(handoffFD) {
+		return nil
+	}
+	os.Unsetenv("HANDOFF_FD")
+	f := os.NewFile(handoffFD, "handoff")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer f.Close()
+		// a line, or EOF when the predecessor exited without one
+		bufio.NewReader(f).ReadString('\n')
+	}()
+	return done
+}
+
+// notifyReady tells the process that started this one (see startSuccessor)
+// that it is listening.
+func notifyReady() {
+	if os.Getenv("READY_FD") != fmt.Sprint(readyFD) {
+		return
+	}
+	os.Unsetenv("READY_FD")
+	f := os.NewFile(readyFD, "ready")
+	fmt.Fprintln(f, "ready")
+	f.Close()
+}
+
+// healthcheck exits 0 when the server on port is ready, for container
+// HEALTHCHECKs in images without curl or wget.
+func healthcheck(port string) {
+	c := &http.Client{Timeout: 5 * time.Second}
+	resp, err := c.Get("http://127.0.0.1:" + port + "/readyz")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintln(os.Stderr, "not ready:", resp.Status)
+		os.Exit(1)
+	}
+}
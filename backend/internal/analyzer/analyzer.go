@@ -0,0 +1,778 @@
+// Package analyzer turns Riot data into player skill profiles.
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"lol_custom_skill_matching/internal/assets"
+	"lol_custom_skill_matching/internal/cache"
+	"lol_custom_skill_matching/internal/riot"
+	"lol_custom_skill_matching/internal/scoring"
+)
+
+// Analyzer runs the analysis pipeline against the Riot API.
+type Analyzer struct {
+	Riot *riot.Client
+	// RankWorkers is the size of the participant rank lookup pool, MatchWorkers
+	// that of the match detail fetches of each player. The shared limiter
+	// still gates every request, so more workers only help hide latency.
+	RankWorkers  int
+	MatchWorkers int
+	// ChampionCacheFile keeps the last good champion.json on disk ("" = memory only).
+	ChampionCacheFile string
+	// History supplies backfilled match summaries for Options.HistoryLimit (nil = none).
+	History HistorySource
+	// ScoreFormula replaces the built-in skill score formula (nil = built-in). A
+	// player it fails for (e.g. division by zero) keeps the built-in score.
+	ScoreFormula *scoring.Expr
+	// WinScale shapes the predicted win chances of a split (0 = DefaultWinScale;
+	// see WinProbability).
+	WinScale float64
+	// PlayerTimeout bounds the analysis of each player (0 = none). A player
+	// still being analyzed when it runs out, say through a storm of 429s,
+	// gets a profile from what was fetched by then, marked Partial, and the
+	// lobby carries on.
+	PlayerTimeout time.Duration
+
+	champions *cache.TTL[string, *riot.Champions]
+	matches   *cache.LRU[string, *riot.Match]        // match id -> details, see matchTTL
+	leagues   *cache.LRU[string, []riot.LeagueEntry] // puuid -> ranked entries, see SetLeagueCache
+	mu        sync.Mutex
+	lastGood  *riot.Champions
+	active    atomic.Int32
+	phases    phaseStats
+}
+
+// HistorySource returns stored match summaries for a player, newest first.
+type HistorySource interface {
+	Matches(gameName, tagLine string) []MatchSummary
+}
+
+// Active is the number of Analyze calls in flight; background work yields to them.
+func (a *Analyzer) Active() int { return int(a.active.Load()) }
+
+func New(client *riot.Client, rankWorkers int) *Analyzer {
+	if rankWorkers <= 0 {
+		rankWorkers = riot.DefaultWorkers
+	}
+	return &Analyzer{
+		Riot: client, RankWorkers: rankWorkers, MatchWorkers: rankWorkers,
+		champions: cache.NewTTL[string, *riot.Champions](24 * time.Hour),
+		matches:   cache.NewLRU[string, *riot.Match](matchCacheSize, matchTTL),
+		leagues:   cache.NewLRU[string, []riot.LeagueEntry](DefaultLeagueCacheSize, DefaultLeagueCacheTTL),
+	}
+}
+
+// Defaults of SetLeagueCache. A lobby's ten participants recur across the
+// recent matches of a community's players, so most rank lookups of an
+// analysis are repeats.
+const (
+	DefaultLeagueCacheSize = 20000
+	DefaultLeagueCacheTTL  = time.Hour
+)
+
+// SetLeagueCache resizes the cache of ranked entries by puuid, which serves
+// both the analyzed players' ranks and their participants' (ttl <= 0
+// disables it). Call it before the first analysis.
+func (a *Analyzer) SetLeagueCache(size int, ttl time.Duration) {
+	if ttl <= 0 || size <= 0 {
+		a.leagues = nil
+		return
+	}
+	a.leagues = cache.NewLRU[string, []riot.LeagueEntry](size, ttl)
+}
+
+// leagueEntries returns a puuid's ranked entries from the cache or Riot,
+// reporting the lookup to the context's riot.Usage.
+func (a *Analyzer) leagueEntries(ctx context.Context, puuid string) ([]riot.LeagueEntry, error) {
+	if a.leagues == nil {
+		return a.Riot.LeagueEntries(ctx, puuid)
+	}
+	if e, ok := a.leagues.Get(puuid); ok {
+		riot.CountCache(ctx, true)
+		return e, nil
+	}
+	if a.Riot.Cache == nil {
+		riot.CountCache(ctx, false) // else the client reports its own lookup
+	}
+	e, err := a.Riot.LeagueEntries(ctx, puuid)
+	if err == nil {
+		a.leagues.Set(puuid, e)
+	}
+	return e, err
+}
+
+// ForgetLeagues drops ranked entries from the in-process cache (no puuids =
+// all), so the next analysis looks the ranks up again.
+func (a *Analyzer) ForgetLeagues(puuids ...string) {
+	if a.leagues == nil {
+		return
+	}
+	if len(puuids) == 0 {
+		a.leagues.Clear()
+	}
+	for _, p := range puuids {
+		a.leagues.Delete(p)
+	}
+}
+
+// RefreshLeagues looks a puuid's ranked entries up again and keeps them in the
+// in-process cache, so the next analysis of the player starts warm.
+func (a *Analyzer) RefreshLeagues(ctx context.Context, puuid string) ([]riot.LeagueEntry, error) {
+	a.ForgetLeagues(puuid)
+	return a.leagueEntries(ctx, puuid)
+}
+
+// matchTTL is how long match details are kept, and matchCacheSize how many.
+// Finished matches never change; the bounds only cap memory (a few MB at
+// most). Players of one community share many matches, so a re-analysis of a
+// lobby mostly reads from here.
+const (
+	matchTTL       = 6 * time.Hour
+	matchCacheSize = 2000
+)
+
+// match returns match details from the cache or Riot, reporting the lookup to
+// the context's riot.Usage.
+func (a *Analyzer) match(ctx context.Context, matchID string) (*riot.Match, error) {
+	if a.matches == nil {
+		return a.Riot.Match(ctx, matchID)
+	}
+	if m, ok := a.matches.Get(matchID); ok {
+		riot.CountCache(ctx, true)
+		return m, nil
+	}
+	if a.Riot.Cache == nil {
+		riot.CountCache(ctx, false) // else the client reports its own lookup
+	}
+	m, err := a.Riot.Match(ctx, matchID)
+	if err == nil && m != nil {
+		a.matches.Set(matchID, m)
+	}
+	return m, err
+}
+
+// ForgetMatches drops match details from the in-process cache (no ids = all),
+// so the next analysis fetches them again.
+func (a *Analyzer) ForgetMatches(ids ...string) {
+	if a.matches == nil {
+		return
+	}
+	if len(ids) == 0 {
+		a.matches.Clear()
+	}
+	for _, id := range ids {
+		a.matches.Delete(id)
+	}
+}
+
+// Champions returns the Data Dragon registry, cached for a day. When the CDN is
+// down the last registry that loaded (in memory, then on disk) is reused; only
+// when there has never been one is an empty registry returned.
+func (a *Analyzer) Champions(ctx context.Context) *riot.Champions {
+	key := riot.DataDragonVersion + "/" + riot.DataDragonLocale
+	if c, ok := a.champions.Get(key); ok {
+		return c
+	}
+	c, err := a.RefreshChampions(ctx)
+	if err != nil {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		if a.lastGood != nil {
+			log.Printf("data dragon: %v; reusing last loaded champions", err)
+			return a.lastGood
+		}
+		log.Printf("data dragon: %v; champion names unavailable", err)
+		return riot.EmptyChampions()
+	}
+	return c
+}
+
+// RefreshChampions loads the registry Champions serves even while the cached
+// one is fresh, so a caller refreshing it ahead of its day (the static data
+// loader) keeps analyses from ever waiting on the CDN.
+func (a *Analyzer) RefreshChampions(ctx context.Context) (*riot.Champions, error) {
+	c, err := riot.LoadChampions(ctx, http.DefaultClient, riot.DataDragonVersion, riot.DataDragonLocale, a.ChampionCacheFile)
+	if err != nil {
+		return nil, err
+	}
+	a.mu.Lock()
+	a.lastGood = c
+	a.mu.Unlock()
+	a.champions.Set(riot.DataDragonVersion+"/"+riot.DataDragonLocale, c)
+	return c, nil
+}
+
+// ChampionsIn returns the registry with names in another Data Dragon locale,
+// cached for a day. Unlike Champions it has no fallback: analyses never need
+// it, so a CDN failure is returned.
+func (a *Analyzer) ChampionsIn(ctx context.Context, locale string) (*riot.Champions, error) {
+	if locale == riot.DataDragonLocale {
+		return a.Champions(ctx), nil
+	}
+	key := riot.DataDragonVersion + "/" + locale
+	if c, ok := a.champions.Get(key); ok {
+		return c, nil
+	}
+	c, err := riot.LoadChampions(ctx, http.DefaultClient, riot.DataDragonVersion, locale, "")
+	if err != nil {
+		return nil, err
+	}
+	a.champions.Set(key, c)
+	return c, nil
+}
+
+// Analyze builds a profile for every player whose Riot ID resolves.
+func (a *Analyzer) Analyze(ctx context.Context, players []Player, opts Options) ([]Profile, error) {
+	if len(players) < 2 {
+		return nil, fmt.Errorf("need at least 2 players")
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	a.active.Add(1)
+	defer a.active.Add(-1)
+	champs := a.Champions(ctx)
+	profiles := make([]Profile, 0, len(players))
+	for _, player := range players {
+		p, err := a.analyzeOne(ctx, champs, player, opts)
+		if errors.Is(err, ErrPlayerNotFound) {
+			continue
+		}
+		if errors.Is(err, ErrPlayerTimeout) {
+			log.Printf("analyze: leaving out %v", err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if p != nil {
+			profiles = append(profiles, *p)
+		}
+	}
+	return profiles, nil
+}
+
+// AnalyzeEach is Analyze that carries on past failing players: it returns the
+// profiles it built and why every other player has none. Unknown Riot IDs
+// and skipped lookups are failures here rather than silently left out.
+func (a *Analyzer) AnalyzeEach(ctx context.Context, players []Player, opts Options) ([]Profile, []PlayerFailure, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, nil, err
+	}
+	a.active.Add(1)
+	defer a.active.Add(-1)
+	champs := a.Champions(ctx)
+	var profiles []Profile
+	var failures []PlayerFailure
+	for _, player := range players {
+		p, err := a.analyzeOne(ctx, champs, player, opts)
+		if err == nil && p == nil {
+			err = riot.ErrSkipped // account lookup hit the quota with SkipOnLimit
+		}
+		if err != nil {
+			failures = append(failures, PlayerFailure{Player: player, Failure: Classify(err)})
+			continue
+		}
+		profiles = append(profiles, *p)
+	}
+	return profiles, failures, nil
+}
+
+// analyzeOne analyzes a player, counting the Riot requests it took and
+// timing its phases (see WithTrace and PhaseStats), within PlayerTimeout.
+func (a *Analyzer) analyzeOne(ctx context.Context, champs *riot.Champions, player Player, opts Options) (*Profile, error) {
+	pctx, usage := riot.WithUsage(ctx)
+	if a.PlayerTimeout > 0 {
+		var cancel context.CancelFunc
+		pctx, cancel = context.WithTimeoutCause(pctx, a.PlayerTimeout, ErrPlayerTimeout)
+		defer cancel()
+	}
+	timer := newPhaseTimer(ctx, player)
+	p, err := a.analyzePlayer(pctx, champs, player, opts, timer)
+	a.finishTimer(ctx, timer)
+	if playerTimedOut(pctx) {
+		if err != nil {
+			return nil, fmt.Errorf("%w after %s: %s: %v", ErrPlayerTimeout, a.PlayerTimeout, player.RiotID(), err)
+		}
+		if p != nil {
+			log.Printf("analyze: %s timed out after %s; profile built from partial data", player.RiotID(), a.PlayerTimeout)
+			p.Partial = true
+			p.UncertainRating = true
+			p.UncertainReasons = append(p.UncertainReasons, fmt.Sprintf("partial data: analysis cut short after %s", a.PlayerTimeout))
+		}
+	}
+	if p != nil {
+		p.RiotCalls = usage.Calls()
+	}
+	return p, err
+}
+
+// playerTimedOut reports whether ctx ended on the player's own deadline
+// rather than with the analysis (or request) it is part of.
+func playerTimedOut(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), ErrPlayerTimeout)
+}
+
+// SummarizeMatch extracts the player's view of a match; ok=false when the
+// player did not take part.
+func SummarizeMatch(matchID string, m *riot.Match, puuid string, champs *riot.Champions) (MatchSummary, bool) {
+	for _, p := range m.Info.Participants {
+		if p.PUUID != puuid {
+			continue
+		}
+		lane := p.TeamPosition
+		if lane == "" {
+			lane = "UNKNOWN"
+		}
+		return MatchSummary{
+			MatchID: matchID, QueueID: m.Info.QueueID, GameCreation: m.Info.GameCreation, GameDuration: m.Info.GameDuration,
+			ChampionID: p.ChampionID, Champion: champs.Name(p.ChampionID), Lane: lane, Win: p.Win,
+			Kills: p.Kills, Deaths: p.Deaths, Assists: p.Assists, CS: p.TotalMinionsKilled + p.NeutralMinionsKilled,
+			Patch: riot.PatchOf(m.Info.GameVersion), Queue: riot.QueueName(m.Info.QueueID),
+		}, true
+	}
+	return MatchSummary{}, false
+}
+
+// matchDigest is what an analysis keeps of a match's details: a deep analysis
+// holds one per match instead of the decoded match.
+type matchDigest struct {
+	patch        string
+	creation     int64
+	queue        int
+	participants []string // puuids, bots left out
+	bots         int
+	summary      MatchSummary // the player's view, when played
+	played       bool
+	opponent     string // the player's lane opponent ("" = none)
+}
+
+// digestMatch boils m down to what the analysis of puuid reads.
+func digestMatch(matchID string, m *riot.Match, puuid string, champs *riot.Champions) *matchDigest {
+	d := &matchDigest{
+		patch: riot.PatchOf(m.Info.GameVersion), creation: m.Info.GameCreation, queue: m.Info.QueueID,
+		participants: make([]string, 0, len(m.Info.Participants)),
+	}
+	for _, p := range m.Info.Participants {
+		if riot.IsBotPUUID(p.PUUID) {
+			d.bots++
+			continue
+		}
+		d.participants = append(d.participants, p.PUUID)
+	}
+	d.summary, d.played = SummarizeMatch(matchID, m, puuid, champs)
+	d.opponent, _ = laneOpponent(m, puuid)
+	return d
+}
+
+// QualifyingQueue reports whether a queue counts toward the profile without
+// a queue filter: DefaultQueues. Arena/quickplay/ARAM are ignored.
+func QualifyingQueue(q int) bool { return slices.Contains(DefaultQueues, q) }
+
+// analyzePlayer returns ErrPlayerNotFound when the Riot ID doesn't exist and
+// nil (no error) when the account lookup was skipped.
+func (a *Analyzer) analyzePlayer(ctx context.Context, champs *riot.Champions, player Player, opts Options, timer *phaseTimer) (*Profile, error) {
+	// 1) account by riot-id
+	end := timer.begin(PhaseAccount)
+	account, found, err := a.Riot.AccountByRiotID(ctx, player.GameName, player.TagLine)
+	end()
+	if errors.Is(err, riot.ErrSkipped) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("account lookup failed for %s#%s: %w", player.GameName, player.TagLine, err)
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrPlayerNotFound, player.RiotID())
+	}
+
+	if player.Platform != "" {
+		p, err := riot.LookupPlatform(player.Platform, player.Region)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", player.RiotID(), err)
+		}
+		a.Riot.Pin(account.PUUID, p)
+	}
+
+	// 1b) mastery by puuid. None on the default platform usually means the
+	// account plays on another shard (KR/NA expats); find it so everything
+	// below is fetched from there.
+	end = timer.begin(PhaseMastery)
+	masteries, _ := a.Riot.Masteries(ctx, account.PUUID)
+	if len(masteries) == 0 && player.Platform == "" {
+		if _, moved, err := a.Riot.FindPlatform(ctx, account.PUUID); err != nil {
+			log.Printf("platform probe for %s: %v", player.RiotID(), err)
+		} else if moved {
+			masteries, _ = a.Riot.Masteries(ctx, account.PUUID)
+		}
+	}
+	end()
+
+	// 2) match list by puuid
+	end = timer.begin(PhaseMatchList)
+	var matchIDs []string
+	if opts.Since.IsZero() {
+		matchIDs, err = a.Riot.MatchIDs(ctx, account.PUUID, 0, 100)
+	} else {
+		matchIDs, err = a.Riot.MatchIDsSince(ctx, account.PUUID, opts.Since, 0, 100)
+	}
+	end()
+	if err != nil && !playerTimedOut(ctx) {
+		return nil, fmt.Errorf("failed to get matches for %s: %w", account.PUUID, err)
+	}
+	matchLimit := opts.MatchLimit
+	if matchLimit <= 0 || matchLimit > len(matchIDs) {
+		matchLimit = len(matchIDs)
+	}
+
+	championCount := map[int]int{}
+	laneCount := map[string]int{}
+	// champion usage for proficiency, discounted by patch age (see
+	// Options.PatchDecay) from the patch and time of the newest match fetched
+	usage := map[int]*champUsage{}
+	laneUsage := map[string]map[int]*champUsage{} // lane -> champId -> usage
+	currentPatch, currentAt := "", int64(0)
+	now := time.Now()
+	rankedCount, rankedWin, gamesAnalyzed := 0, 0, 0
+	matchParticipants := [][]string{} // participant PUUIDs per qualifying match
+	laneOpponents := []string{}       // the direct lane opponent of each match that had one
+	botsSkipped := 0
+	summaries := []MatchSummary{}
+	tally := func(m MatchSummary) {
+		championCount[m.ChampionID]++
+		laneCount[m.Lane]++
+		gamesAnalyzed++
+		w := opts.patchWeight(m, currentPatch, currentAt)
+		if usage[m.ChampionID] == nil {
+			usage[m.ChampionID] = &champUsage{}
+		}
+		usage[m.ChampionID].add(m, now, w)
+		if laneUsage[m.Lane] == nil {
+			laneUsage[m.Lane] = map[int]*champUsage{}
+		}
+		if laneUsage[m.Lane][m.ChampionID] == nil {
+			laneUsage[m.Lane][m.ChampionID] = &champUsage{}
+		}
+		laneUsage[m.Lane][m.ChampionID].add(m, now, w)
+		if m.QueueID == riot.QueueRankedSolo {
+			rankedCount++
+			if m.Win {
+				rankedWin++
+			}
+		}
+		summaries = append(summaries, m)
+	}
+
+	// 3) details: count champs and lanes, track ranked matches. A batch is
+	// fetched together, each match boiled down to a digest as it arrives, and
+	// the digests read in order, newest first. While fewer than MinGames
+	// count (a history of mostly ARAM or Arena), further batches widen the
+	// window up to MaxMatchLimit.
+	seen := map[string]struct{}{}
+	maxWindow := min(max(opts.MaxMatchLimit, matchLimit), len(matchIDs))
+	window, fetched := matchLimit, 0
+	end = timer.begin(PhaseDetails)
+	for {
+		digests := riot.FanOut(ctx, a.MatchWorkers, matchIDs[fetched:window], func(ctx context.Context, mid string) *matchDigest {
+			m, err := a.match(ctx, mid)
+			if err != nil || m == nil {
+				return nil
+			}
+			return digestMatch(mid, m, account.PUUID, champs)
+		})
+		for _, d := range digests {
+			if d == nil {
+				continue
+			}
+			if currentAt == 0 {
+				currentPatch, currentAt = d.patch, d.creation
+			}
+			if !opts.Counts(d.queue, d.creation) || !opts.onPatch(d.patch, currentPatch) {
+				continue
+			}
+			botsSkipped += d.bots
+			if d.played {
+				tally(d.summary)
+				seen[d.summary.MatchID] = struct{}{}
+			}
+			if d.opponent != "" {
+				laneOpponents = append(laneOpponents, d.opponent)
+			}
+			matchParticipants = append(matchParticipants, d.participants)
+			// a player off the default platform played with others from there
+			a.Riot.ShareShard(account.PUUID, d.participants)
+		}
+		fetched = window
+		if gamesAnalyzed >= opts.MinGames || window >= maxWindow || ctx.Err() != nil {
+			break
+		}
+		window = min(maxWindow, window+NextBatch(opts.MinGames-gamesAnalyzed, gamesAnalyzed, fetched))
+	}
+	if window > matchLimit {
+		log.Printf("analyze: %s: match window widened from %d to %d for %d qualifying games", player.RiotID(), matchLimit, window, gamesAnalyzed)
+	}
+	end()
+
+	// 3b) backfilled history extends the lane/champion/ranked sample beyond the recent matches
+	historyGames := 0
+	if opts.HistoryLimit > 0 && a.History != nil {
+		for _, m := range a.History.Matches(player.GameName, player.TagLine) {
+			if historyGames >= opts.HistoryLimit {
+				break
+			}
+			if _, ok := seen[m.MatchID]; ok || !opts.Counts(m.QueueID, m.GameCreation) || !opts.onPatch(m.Patch, currentPatch) {
+				continue
+			}
+			tally(m)
+			historyGames++
+		}
+	}
+
+	// rank by puuid (current)
+	currentRankScore := 0
+	rank := assets.Unranked()
+	end = timer.begin(PhaseRanks)
+	if entries, err := a.leagueEntries(ctx, account.PUUID); err == nil {
+		if e, ok := riot.SoloEntry(entries); ok {
+			currentRankScore = riot.RankScore(e.Tier, e.Rank, e.LeaguePoints)
+			rank = assets.RankOf(e.Tier, e.Rank, e.LeaguePoints)
+		}
+	}
+	end()
+
+	// mastery top3 sum
+	sort.Slice(masteries, func(i, j int) bool { return masteries[i].ChampionPoints > masteries[j].ChampionPoints })
+	topMastery := 0
+	for i := 0; i < 3 && i < len(masteries); i++ {
+		topMastery += masteries[i].ChampionPoints
+	}
+
+	// lanes
+	type laneStat struct {
+		Lane  string
+		Count int
+	}
+	var laneStats []laneStat
+	for k, v := range laneCount {
+		laneStats = append(laneStats, laneStat{k, v})
+	}
+	sort.Slice(laneStats, func(i, j int) bool { return laneStats[i].Count > laneStats[j].Count })
+	// a lane played once is a sub lane at most, however few games there are
+	mainLanes, subLanes := []string{}, []string{}
+	for i := 0; i < 4 && i < len(laneStats); i++ {
+		if len(mainLanes) < 2 && laneStats[i].Count >= minMainLaneGames {
+			mainLanes = append(mainLanes, laneStats[i].Lane)
+		} else if len(subLanes) < 2 {
+			subLanes = append(subLanes, laneStats[i].Lane)
+		}
+	}
+	laneConfidence := LaneConfidenceHigh
+	if gamesAnalyzed < opts.minLaneGames() {
+		laneConfidence = LaneConfidenceLow
+	}
+
+	// main champs (top by proficiency, max 6)
+	proficiency := rankProficiency(masteries, usage, champs)
+	mainChamps := []string{}
+	for _, c := range proficiency[:min(6, len(proficiency))] {
+		mainChamps = append(mainChamps, c.Champion)
+	}
+
+	// Average match rank score across participants of recent matches (fanned out, limiter-gated)
+	avgRankScore, rated := 0, 0
+	laneOppScore, laneOppRated := 0, 0
+	var lobbySample *LobbySampleReport
+	if !opts.SkipLobbyRank {
+		end = timer.begin(PhaseLobbyRank)
+		sampler := opts.Sampler
+		if sampler == nil {
+			sampler = AllParticipants{}
+		}
+		puuids := sampler.Sample(account.PUUID, matchParticipants)
+		scores := a.fanOutSoloScores(ctx, puuids)
+		avgRankScore, lobbySample = summarizeLobbySample(sampler.Name(), len(uniquePUUIDs(matchParticipants)), len(puuids), slices.Collect(maps.Values(scores)))
+		rated = len(scores)
+		lobbySample.BotsSkipped = botsSkipped
+
+		// Lane opponents the sample left out are looked up on their own; one
+		// met in several matches counts once per match.
+		asked := map[string]bool{}
+		for _, p := range puuids {
+			asked[p] = true
+		}
+		var extra []string
+		for _, p := range uniquePUUIDs([][]string{laneOpponents}) {
+			if !asked[p] {
+				extra = append(extra, p)
+			}
+		}
+		maps.Copy(scores, a.fanOutSoloScores(ctx, extra))
+		sum := 0
+		for _, p := range laneOpponents {
+			if s, ok := scores[p]; ok {
+				sum += s
+				laneOppRated++
+			}
+		}
+		if laneOppRated > 0 {
+			laneOppScore = sum / laneOppRated
+		}
+		end()
+	}
+
+	in := scoreInputs{
+		currentRank: currentRankScore, avgLobbyRank: avgRankScore, avgLaneOpponent: laneOppScore, topMastery: topMastery,
+		rankedGames: rankedCount, rankedWins: rankedWin, games: gamesAnalyzed, lobbyRated: rated,
+		skipLobbyRank: opts.SkipLobbyRank,
+	}
+	skillScore := in.defaultScore()
+	if a.ScoreFormula != nil {
+		if v, err := a.ScoreFormula.Eval(in.features()); err != nil {
+			log.Printf("score formula for %s: %v; using the default score", player.RiotID(), err)
+		} else {
+			skillScore = v
+		}
+	}
+
+	// lane-specific sub champions (top by proficiency in the lane, then
+	// champions not played there by mastery, those whose primary positions
+	// include the lane first)
+	laneChampions := func(lane string) []string {
+		ranked := rankProficiency(masteries, laneUsage[lane], champs)
+		result := []string{}
+		for _, c := range ranked {
+			if c.Games > 0 && len(result) < 3 {
+				result = append(result, c.Champion)
+			}
+		}
+		for _, fits := range []bool{true, false} {
+			for _, c := range ranked {
+				if c.Games == 0 && len(result) < 3 && champs.PlaysPosition(c.id, lane) == fits {
+					result = append(result, c.Champion)
+				}
+			}
+		}
+		return result
+	}
+	mainLaneChamps := map[string][]string{}
+	for _, lane := range mainLanes {
+		mainLaneChamps[lane] = laneChampions(lane)
+	}
+	subLaneChamps := map[string][]string{}
+	for _, lane := range subLanes {
+		subLaneChamps[lane] = laneChampions(lane)
+	}
+
+	// declared pool overrides inferred main champions
+	poolSource := "inferred"
+	inferredChamps := mainChamps
+	if len(player.Champions) > 0 {
+		mainChamps = champs.Resolve(player.Champions)
+		poolSource = "declared"
+	}
+
+	p := &Profile{
+		Name:               player.RiotID(),
+		PUUID:              account.PUUID,
+		Platform:           a.Riot.PlatformOf(account.PUUID),
+		SkillScore:         skillScore,
+		CurrentRankScore:   currentRankScore,
+		Rank:               &rank,
+		AvgMatchRankScore:  avgRankScore,
+		LaneOpponentScore:  laneOppScore,
+		LaneOpponentsRated: laneOppRated,
+		LobbyRankSkipped:   opts.SkipLobbyRank,
+		MainLanes:          mainLanes,
+		MainSublanes:       subLanes,
+		LaneConfidence:     laneConfidence,
+		MainChampions:      mainChamps,
+		InferredChampions:  inferredChamps,
+		Proficiency:        proficiency[:min(10, len(proficiency))],
+		ChampionPoolSource: poolSource,
+		MainLaneChampions:  mainLaneChamps,
+		SublaneChampions:   subLaneChamps,
+		MasteryTop3:        topMastery,
+		RankedRecentCount:  rankedCount,
+		RankedRecentWins:   rankedWin,
+		GamesAnalyzed:      gamesAnalyzed,
+		SkillInterval:      skillInterval(skillScore, gamesAnalyzed, rated, currentRankScore == 0, 0),
+		LobbyRankSample:    lobbySample,
+		HistoryGames:       historyGames,
+		LatestPatch:        currentPatch,
+		MatchWindow:        fetched,
+		Matches:            summaries,
+	}
+	if opts.BalanceOn == BalanceOnConservative {
+		p.BalanceScore = p.SkillInterval.Low
+	}
+	if opts.IncludeRaw {
+		p.Raw = newRawAggregates(championCount, laneCount, summaries, champs.Name)
+	}
+	return p, nil
+}
+
+// NextBatch is how many more matches to fetch for need more qualifying
+// games, given that counted of the fetched so far did: enough at the rate
+// seen, or as many again when none qualified.
+func NextBatch(need, counted, fetched int) int {
+	if counted == 0 {
+		return max(fetched, need)
+	}
+	return max((need*fetched+counted-1)/counted, 1)
+}
+
+// fanOutSoloScores looks up solo ranks for many participants through a small worker pool
+// (RankWorkers); the caller gets the scores of ranked participants by puuid.
+func (a *Analyzer) fanOutSoloScores(ctx context.Context, puuids []string) map[string]int {
+	type rankResult struct {
+		score int
+		ok    bool
+	}
+	results := riot.FanOut(ctx, a.RankWorkers, puuids, func(ctx context.Context, puuid string) rankResult {
+		entries, err := a.leagueEntries(ctx, puuid)
+		if err != nil {
+			return rankResult{}
+		}
+		s, ok := riot.SoloScore(entries)
+		return rankResult{s, ok}
+	})
+	scores := map[string]int{}
+	for i, r := range results {
+		if r.ok {
+			scores[puuids[i]] = r.score
+		}
+	}
+	return scores
+}
+
+// laneOpponent finds who played puuid's position on the other team of m, the
+// player they actually competed against. Matches without positions (ARAM,
+// many customs) have none.
+func laneOpponent(m *riot.Match, puuid string) (string, bool) {
+	var self *riot.Participant
+	for i, p := range m.Info.Participants {
+		if p.PUUID == puuid {
+			self = &m.Info.Participants[i]
+		}
+	}
+	if self == nil || self.TeamPosition == "" {
+		return "", false
+	}
+	for _, p := range m.Info.Participants {
+		if p.TeamID != self.TeamID && p.TeamPosition == self.TeamPosition && !riot.IsBotPUUID(p.PUUID) {
+			return p.PUUID, true
+		}
+	}
+	return "", false
+}
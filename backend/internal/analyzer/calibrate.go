@@ -0,0 +1,283 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// calibrationFeatures are the score components a calibration reweighs, with
+// the weights of the built-in formula (mastery_top3 counts per 1000 points).
+var calibrationFeatures = []struct {
+	name    string
+	weight  float64
+	divisor float64
+}{
+	{"current_rank", 2, 1},
+	{"avg_lobby_rank", 1, 1},
+	{"mastery_top3", 1, 1000},
+}
+
+// minCalibrationGames is how many games a calibration needs before it suggests
+// changes; fewer say more about luck than about the model.
+const minCalibrationGames = 10
+
+// calibrationPrior is how many games' worth of evidence the current model
+// counts for when fitting, so a handful of upsets can't swing the suggestion.
+const calibrationPrior = 10.0
+
+// CalibrationGame is a decided game of a stored split, seen from team A.
+type CalibrationGame struct {
+	Diff int // team A's score sum minus team B's, as balanced
+	// Features are team A's sums minus team B's of current_rank,
+	// avg_lobby_rank and mastery_top3 (see calibrationFeatures).
+	Features [3]float64
+	AWon     bool
+}
+
+// CalibrationGameOf reads a split with a known winner.
+func CalibrationGameOf(ts TeamSplit, aWon bool) CalibrationGame {
+	g := CalibrationGame{Diff: ts.SumA - ts.SumB, AWon: aWon}
+	add := func(ps []Profile, sign float64) {
+		for _, p := range ps {
+			g.Features[0] += sign * float64(p.CurrentRankScore)
+			g.Features[1] += sign * float64(p.AvgMatchRankScore)
+			g.Features[2] += sign * float64(p.MasteryTop3)
+		}
+	}
+	add(ts.TeamA, 1)
+	add(ts.TeamB, -1)
+	return g
+}
+
+// ReliabilityBucket compares, for games whose favorite was given a chance in
+// [Low, High), the mean predicted chance with how often the favorite won.
+type ReliabilityBucket struct {
+	Low       float64 `json:"low"`
+	High      float64 `json:"high"`
+	Games     int     `json:"games"`
+	Predicted float64 `json:"predicted"`
+	Actual    float64 `json:"actual"`
+}
+
+// CalibrationReport says how well predicted win chances matched outcomes and
+// what would have matched better.
+type CalibrationReport struct {
+	Games int     `json:"games"`
+	Scale float64 `json:"scale"`
+	// Brier is the mean squared error of the predicted chances (0 is perfect,
+	// 0.25 is what always saying 50% scores).
+	Brier       float64             `json:"brier"`
+	Reliability []ReliabilityBucket `json:"reliability"`
+	// SuggestedScale is the WIN_PROB_SCALE that fits the outcomes best, and
+	// SuggestedBrier its Brier score on them.
+	SuggestedScale float64 `json:"suggested_scale,omitempty"`
+	SuggestedBrier float64 `json:"suggested_brier,omitempty"`
+	// SuggestedWeights reweigh the built-in formula's components at the same
+	// scale; SuggestedFormula is them as a SCORE_FORMULA.
+	SuggestedWeights map[string]float64 `json:"suggested_weights,omitempty"`
+	SuggestedFormula string             `json:"suggested_formula,omitempty"`
+	Note             string             `json:"note,omitempty"`
+}
+
+// Calibrate replays games through WinProbability at scale (0 =
+// DefaultWinScale) and fits a better scale and formula weights. Both fits
+// start from the current model and are pulled towards it, so they only move
+// as far as the outcomes justify.
+func Calibrate(games []CalibrationGame, scale float64) CalibrationReport {
+	if scale <= 0 {
+		scale = DefaultWinScale
+	}
+	rep := CalibrationReport{Games: len(games), Scale: scale, Reliability: []ReliabilityBucket{}}
+	if len(games) == 0 {
+		rep.Note = "no decided games to compare with"
+		return rep
+	}
+	diffs := make([][]float64, len(games))
+	for i, g := range games {
+		diffs[i] = []float64{float64(g.Diff)}
+	}
+	rep.Brier = round4(brier(games, diffs, []float64{1 / scale}))
+	rep.Reliability = reliability(games, scale)
+	if len(games) < minCalibrationGames {
+		rep.Note = fmt.Sprintf("need at least %d decided games to suggest changes", minCalibrationGames)
+		return rep
+	}
+
+	k := fitLogistic(games, diffs, []float64{1 / scale})
+	if k[0] > 0 {
+		rep.SuggestedScale = math.Round(1 / k[0])
+		rep.SuggestedBrier = round4(brier(games, diffs, k))
+	} else {
+		rep.Note = "the stronger team on paper won less than half the games; the score doesn't predict outcomes"
+	}
+
+	xs := make([][]float64, len(games))
+	prior := make([]float64, len(calibrationFeatures))
+	for i, g := range games {
+		xs[i] = make([]float64, len(calibrationFeatures))
+		for j, f := range calibrationFeatures {
+			xs[i][j] = g.Features[j] / f.divisor
+		}
+	}
+	for j, f := range calibrationFeatures {
+		prior[j] = f.weight / scale
+	}
+	w := fitLogistic(games, xs, prior)
+	rep.SuggestedWeights = map[string]float64{}
+	terms := []string{}
+	for j, f := range calibrationFeatures {
+		wt := math.Round(w[j]*scale*100) / 100
+		rep.SuggestedWeights[f.name] = wt
+		term := fmt.Sprintf("%s*%g", f.name, wt)
+		if f.divisor != 1 {
+			term += fmt.Sprintf("/%g", f.divisor)
+		}
+		terms = append(terms, term)
+	}
+	rep.SuggestedFormula = strings.Join(terms, " + ")
+	return rep
+}
+
+// fitLogistic fits P(A wins) = 1/(1+exp(-w·x)) by Newton's method, with a
+// Gaussian prior around prior worth calibrationPrior games.
+func fitLogistic(games []CalibrationGame, xs [][]float64, prior []float64) []float64 {
+	n := len(prior)
+	w := append([]float64{}, prior...)
+	for iter := 0; iter < 50; iter++ {
+		grad := make([]float64, n)
+		hess := make([][]float64, n)
+		for j := range hess {
+			hess[j] = make([]float64, n)
+		}
+		for i, g := range games {
+			p := sigmoid(dot(w, xs[i]))
+			y := 0.0
+			if g.AWon {
+				y = 1
+			}
+			for j := 0; j < n; j++ {
+				grad[j] += (y - p) * xs[i][j]
+				for l := 0; l < n; l++ {
+					hess[j][l] -= p * (1 - p) * xs[i][j] * xs[i][l]
+				}
+			}
+		}
+		for j := 0; j < n; j++ {
+			if prior[j] == 0 {
+				continue
+			}
+			// moving a weight by its own size costs calibrationPrior/2 of log-likelihood
+			lambda := calibrationPrior / (prior[j] * prior[j])
+			grad[j] -= lambda * (w[j] - prior[j])
+			hess[j][j] -= lambda
+		}
+		step, ok := solve(hess, grad)
+		if !ok {
+			break
+		}
+		done := true
+		for j := range w {
+			w[j] -= step[j]
+			if math.Abs(step[j]) > 1e-9*math.Max(1, math.Abs(w[j])) {
+				done = false
+			}
+		}
+		if done {
+			break
+		}
+	}
+	return w
+}
+
+// solve returns x with a·x = b by Gaussian elimination (ok=false when a is singular).
+func solve(a [][]float64, b []float64) ([]float64, bool) {
+	n := len(b)
+	m := make([][]float64, n)
+	for i := range a {
+		m[i] = append(append([]float64{}, a[i]...), b[i])
+	}
+	for c := 0; c < n; c++ {
+		piv := c
+		for r := c + 1; r < n; r++ {
+			if math.Abs(m[r][c]) > math.Abs(m[piv][c]) {
+				piv = r
+			}
+		}
+		if m[piv][c] == 0 {
+			return nil, false
+		}
+		m[c], m[piv] = m[piv], m[c]
+		for r := c + 1; r < n; r++ {
+			f := m[r][c] / m[c][c]
+			for k := c; k <= n; k++ {
+				m[r][k] -= f * m[c][k]
+			}
+		}
+	}
+	x := make([]float64, n)
+	for r := n - 1; r >= 0; r-- {
+		s := m[r][n]
+		for k := r + 1; k < n; k++ {
+			s -= m[r][k] * x[k]
+		}
+		x[r] = s / m[r][r]
+	}
+	return x, true
+}
+
+func brier(games []CalibrationGame, xs [][]float64, w []float64) float64 {
+	sum := 0.0
+	for i, g := range games {
+		p := sigmoid(dot(w, xs[i]))
+		if g.AWon {
+			p = 1 - p
+		}
+		sum += p * p
+	}
+	return sum / float64(len(games))
+}
+
+// reliability buckets games by the favorite's predicted chance, 50-60% up to 90-100%.
+func reliability(games []CalibrationGame, scale float64) []ReliabilityBucket {
+	buckets := make([]ReliabilityBucket, 5)
+	wins := make([]int, 5)
+	for i := range buckets {
+		buckets[i].Low, buckets[i].High = 0.5+0.1*float64(i), 0.6+0.1*float64(i)
+	}
+	for _, g := range games {
+		p, favWon := WinProbability(g.Diff, scale), g.AWon
+		if p < 0.5 {
+			p, favWon = 1-p, !favWon
+		}
+		i := min(int((p-0.5)*10), 4)
+		buckets[i].Games++
+		buckets[i].Predicted += p
+		if favWon {
+			wins[i]++
+		}
+	}
+	out := []ReliabilityBucket{}
+	for i, b := range buckets {
+		if b.Games == 0 {
+			continue
+		}
+		b.Low, b.High = round4(b.Low), round4(b.High)
+		b.Predicted = round4(b.Predicted / float64(b.Games))
+		b.Actual = round4(float64(wins[i]) / float64(b.Games))
+		out = append(out, b)
+	}
+	return out
+}
+
+func sigmoid(x float64) float64 { return 1 / (1 + math.Exp(-x)) }
+
+func dot(a, b []float64) float64 {
+	s := 0.0
+	for i := range a {
+		s += a[i] * b[i]
+	}
+	return s
+}
+
+func round4(x float64) float64 { return math.Round(x*10000) / 10000 }
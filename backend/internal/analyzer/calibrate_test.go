@@ -0,0 +1,92 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+)
+
+// games are n decided games at score differences spread over ±3000, the
+// built-in formula's doing (current_rank counts twice); won says whether
+// team A won the i-th.
+func games(n int, won func(i, diff int) bool) []CalibrationGame {
+	out := make([]CalibrationGame, n)
+	for i := range out {
+		d := (i%13 - 6) * 500
+		out[i] = CalibrationGame{Diff: d, Features: [3]float64{float64(d) / 2}, AWon: won(i, d)}
+	}
+	return out
+}
+
+func TestCalibrateTooFewGames(t *testing.T) {
+	rep := Calibrate(nil, 0)
+	if rep.Games != 0 || rep.Scale != DefaultWinScale || rep.Note == "" {
+		t.Errorf("no games: %+v, want the default scale and a note", rep)
+	}
+	rep = Calibrate(games(minCalibrationGames-1, func(_, d int) bool { return d > 0 }), 0)
+	if rep.Brier == 0 || rep.SuggestedScale != 0 || rep.SuggestedFormula != "" || !strings.Contains(rep.Note, "at least") {
+		t.Errorf("%d games: %+v, want a Brier score but no suggestion", minCalibrationGames-1, rep)
+	}
+	n := 0
+	for _, b := range rep.Reliability {
+		n += b.Games
+		if b.Predicted < b.Low || b.Predicted >= b.High {
+			t.Errorf("bucket %+v predicts outside its range", b)
+		}
+	}
+	if n != rep.Games {
+		t.Errorf("buckets hold %d games, want %d", n, rep.Games)
+	}
+}
+
+func TestCalibrateScale(t *testing.T) {
+	tests := []struct {
+		name  string
+		won   func(i, diff int) bool
+		check func(t *testing.T, rep CalibrationReport)
+	}{
+		{"favorite always wins", func(_, d int) bool { return d > 0 }, func(t *testing.T, rep CalibrationReport) {
+			if rep.SuggestedScale <= 0 || rep.SuggestedScale >= rep.Scale {
+				t.Errorf("suggested scale %g, want sharper than %g", rep.SuggestedScale, rep.Scale)
+			}
+			if rep.SuggestedBrier >= rep.Brier {
+				t.Errorf("suggested Brier %g, want better than %g", rep.SuggestedBrier, rep.Brier)
+			}
+		}},
+		{"coin flips", func(i, _ int) bool { return i%2 == 0 }, func(t *testing.T, rep CalibrationReport) {
+			if rep.SuggestedScale != 0 && rep.SuggestedScale <= rep.Scale {
+				t.Errorf("suggested scale %g, want flatter than %g", rep.SuggestedScale, rep.Scale)
+			}
+		}},
+		{"underdog always wins", func(_, d int) bool { return d < 0 }, func(t *testing.T, rep CalibrationReport) {
+			if rep.SuggestedScale != 0 || !strings.Contains(rep.Note, "doesn't predict") {
+				t.Errorf("suggested scale %g, note %q; want none and a warning", rep.SuggestedScale, rep.Note)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rep := Calibrate(games(130, tt.won), 0)
+			if rep.Games != 130 {
+				t.Fatalf("games = %d", rep.Games)
+			}
+			tt.check(t, rep)
+			for _, f := range calibrationFeatures {
+				if _, ok := rep.SuggestedWeights[f.name]; !ok || !strings.Contains(rep.SuggestedFormula, f.name+"*") {
+					t.Errorf("no suggested weight for %s: %v, %q", f.name, rep.SuggestedWeights, rep.SuggestedFormula)
+				}
+			}
+		})
+	}
+}
+
+func TestCalibrationGameOf(t *testing.T) {
+	ts := TeamSplit{
+		TeamA: []Profile{{CurrentRankScore: 1200, AvgMatchRankScore: 1100, MasteryTop3: 300000}},
+		TeamB: []Profile{{CurrentRankScore: 1000, AvgMatchRankScore: 1150, MasteryTop3: 100000}},
+		SumA:  3800, SumB: 3400,
+	}
+	g := CalibrationGameOf(ts, false)
+	if want := (CalibrationGame{Diff: 400, Features: [3]float64{200, -50, 200000}}); g != want {
+		t.Errorf("CalibrationGameOf = %+v, want %+v", g, want)
+	}
+}
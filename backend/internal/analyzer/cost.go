@@ -0,0 +1,146 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+
+	"lol_custom_skill_matching/internal/riot"
+)
+
+// Accuracy tiers, from the mean skill_interval margin of the analyzed players.
+const (
+	AccuracyHigh   = "high"   // margin up to 200 (two divisions)
+	AccuracyMedium = "medium" // up to 400
+	AccuracyLow    = "low"
+	// AccuracyStale marks analyses served from stored profiles while Riot was down.
+	AccuracyStale = "stale"
+)
+
+// CostReport is what an analysis spent and how much data it got for it, so
+// organizers can see what a cheaper preset gives up.
+type CostReport struct {
+	RiotCalls    int64   `json:"riot_calls"` // requests sent, retries included
+	CacheHits    int64   `json:"cache_hits"` // match details served from memory
+	CacheMisses  int64   `json:"cache_misses"`
+	CacheHitRate float64 `json:"cache_hit_rate"` // 0-1
+	// AccuracyTier rates the mean margin of the players' skill intervals.
+	AccuracyTier string `json:"accuracy_tier"`
+	MeanMargin   int    `json:"mean_margin"`
+	// Tradeoffs lists what the preset gives up next to "standard".
+	Tradeoffs []string     `json:"tradeoffs"`
+	Players   []PlayerCost `json:"players"`
+}
+
+// PlayerCost is one player's share of the cost and the completeness of the
+// data behind their score.
+type PlayerCost struct {
+	Name      string `json:"name"`
+	RiotCalls int64  `json:"riot_calls"`
+	// Completeness averages the parts of the score that had data: recent
+	// matches (of the match limit), solo rank, mastery and rated lobby
+	// participants (of those sampled). 0-1.
+	Completeness float64  `json:"completeness"`
+	Missing      []string `json:"missing,omitempty"`
+}
+
+// NewCostReport summarizes an analysis made with p; u counted its requests.
+func NewCostReport(profiles []Profile, u *riot.Usage, p Preset) CostReport {
+	r := CostReport{
+		RiotCalls: u.Calls(), CacheHits: u.CacheHits(), CacheMisses: u.CacheMisses(),
+		Tradeoffs: tradeoffs(p), Players: make([]PlayerCost, 0, len(profiles)),
+	}
+	if n := r.CacheHits + r.CacheMisses; n > 0 {
+		r.CacheHitRate = round2(float64(r.CacheHits) / float64(n))
+	}
+	margins := 0
+	for _, pr := range profiles {
+		r.Players = append(r.Players, playerCost(pr, p.MatchLimit))
+		margins += pr.SkillInterval.Margin
+	}
+	if len(profiles) > 0 {
+		r.MeanMargin = margins / len(profiles)
+	}
+	switch {
+	case len(profiles) == 0 || r.MeanMargin > 400:
+		r.AccuracyTier = AccuracyLow
+	case r.MeanMargin > 200:
+		r.AccuracyTier = AccuracyMedium
+	default:
+		r.AccuracyTier = AccuracyHigh
+	}
+	return r
+}
+
+func playerCost(pr Profile, matchLimit int) PlayerCost {
+	c := PlayerCost{Name: pr.Name, RiotCalls: pr.RiotCalls}
+	if pr.Partial {
+		c.Missing = append(c.Missing, "analysis timed out; partial data")
+	}
+	var parts []float64
+	if matchLimit > 0 {
+		recent := pr.GamesAnalyzed - pr.HistoryGames
+		part := math.Min(1, float64(recent)/float64(matchLimit))
+		if part < 1 {
+			c.Missing = append(c.Missing, fmt.Sprintf("only %d of %d recent matches counted", recent, matchLimit))
+		}
+		parts = append(parts, part)
+	}
+	if pr.LaneConfidence == LaneConfidenceLow {
+		c.Missing = append(c.Missing, fmt.Sprintf("lanes from only %d games; any role", pr.GamesAnalyzed))
+	}
+	if pr.CurrentRankScore > 0 {
+		parts = append(parts, 1)
+	} else {
+		parts = append(parts, 0)
+		c.Missing = append(c.Missing, "no solo rank")
+	}
+	if pr.MasteryTop3 > 0 {
+		parts = append(parts, 1)
+	} else {
+		parts = append(parts, 0)
+		c.Missing = append(c.Missing, "no champion mastery")
+	}
+	switch s := pr.LobbyRankSample; {
+	case pr.LobbyRankSkipped:
+		parts = append(parts, 0)
+		c.Missing = append(c.Missing, "lobby rank skipped")
+	case s == nil || s.Sampled == 0:
+		parts = append(parts, 0)
+		c.Missing = append(c.Missing, "no lobby participants to rate")
+	default:
+		part := float64(s.Rated) / float64(s.Sampled)
+		if part < 1 {
+			c.Missing = append(c.Missing, fmt.Sprintf("%d of %d lobby participants unranked", s.Sampled-s.Rated, s.Sampled))
+		}
+		parts = append(parts, part)
+	}
+	sum := 0.0
+	for _, v := range parts {
+		sum += v
+	}
+	c.Completeness = round2(sum / float64(len(parts)))
+	return c
+}
+
+// tradeoffs describes how p falls short of the standard preset.
+func tradeoffs(p Preset) []string {
+	std := Presets[PresetStandard]
+	out := []string{}
+	if p.MatchLimit < std.MatchLimit {
+		out = append(out, fmt.Sprintf("%d recent matches instead of %d", p.MatchLimit, std.MatchLimit))
+	}
+	if p.MaxMatchLimit < std.MaxMatchLimit {
+		out = append(out, fmt.Sprintf("window widened to at most %d matches instead of %d for players with few qualifying games", max(p.MaxMatchLimit, p.MatchLimit), std.MaxMatchLimit))
+	}
+	if p.SkipLobbyRank {
+		out = append(out, "average lobby rank skipped; recent ranked winrate stands in for it")
+	} else if p.Sampling.Strategy != "" && p.Sampling.Strategy != std.Sampling.Strategy {
+		out = append(out, fmt.Sprintf("lobby rank estimated from a sample (%s)", p.Sampling.Strategy))
+	}
+	if p.MaxAgeDays > 0 {
+		out = append(out, fmt.Sprintf("matches older than %d days ignored", p.MaxAgeDays))
+	}
+	return out
+}
+
+func round2(v float64) float64 { return math.Round(v*100) / 100 }
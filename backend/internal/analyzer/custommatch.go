@@ -0,0 +1,163 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"lol_custom_skill_matching/internal/riot"
+)
+
+// CustomQueueID is the queue match-v5 reports for custom games.
+const CustomQueueID = riot.QueueCustom
+
+// matchIndex finds split players among a match's participants, by Riot ID
+// (case-insensitively, as the Riot client does) and by PUUID when known.
+type matchIndex struct {
+	byName  map[string]riot.Participant
+	byPUUID map[string]riot.Participant
+}
+
+func riotIDFold(s string) string { return strings.ToLower(strings.TrimSpace(s)) }
+
+func indexMatch(m *riot.Match) matchIndex {
+	ix := matchIndex{byName: map[string]riot.Participant{}, byPUUID: map[string]riot.Participant{}}
+	for _, p := range m.Info.Participants {
+		ix.byName[riotIDFold(p.RiotIDGameName+"#"+p.RiotIDTagline)] = p
+		ix.byPUUID[p.PUUID] = p
+	}
+	return ix
+}
+
+func (ix matchIndex) find(pr Profile) (riot.Participant, bool) {
+	p, ok := ix.byName[riotIDFold(pr.Name)]
+	if !ok && pr.PUUID != "" {
+		p, ok = ix.byPUUID[pr.PUUID]
+	}
+	return p, ok
+}
+
+// MatchOutcome checks that m is the custom game played with split ts: every
+// participant is a player of ts and each team of ts sat on one side. It
+// reports whether team A won.
+func MatchOutcome(ts TeamSplit, m *riot.Match) (teamAWon bool, err error) {
+	if m.Info.QueueID != CustomQueueID {
+		return false, fmt.Errorf("match %s is not a custom game (queue %d)", m.Metadata.MatchID, m.Info.QueueID)
+	}
+	if n := len(ts.TeamA) + len(ts.TeamB); n != len(m.Info.Participants) {
+		return false, fmt.Errorf("match has %d players, the split %d", len(m.Info.Participants), n)
+	}
+	ix := indexMatch(m)
+	side := func(team []Profile, label string) (teamID int, won bool, err error) {
+		for i, pr := range team {
+			p, ok := ix.find(pr)
+			if !ok {
+				return 0, false, fmt.Errorf("%s did not play in match %s", pr.Name, m.Metadata.MatchID)
+			}
+			if i == 0 {
+				teamID, won = p.TeamID, p.Win
+			} else if p.TeamID != teamID {
+				return 0, false, fmt.Errorf("team %s was split across both sides (%s)", label, pr.Name)
+			}
+		}
+		return teamID, won, nil
+	}
+	sideA, wonA, err := side(ts.TeamA, "A")
+	if err != nil {
+		return false, err
+	}
+	sideB, _, err := side(ts.TeamB, "B")
+	if err != nil {
+		return false, err
+	}
+	if sideA == sideB {
+		return false, fmt.Errorf("teams A and B played on the same side")
+	}
+	return wonA, nil
+}
+
+// Performance is one player's showing in a verified custom match.
+type Performance struct {
+	Name        string  `json:"name"`
+	Team        string  `json:"team"` // "A" or "B"
+	Win         bool    `json:"win"`
+	Kills       int     `json:"kills"`
+	Deaths      int     `json:"deaths"`
+	Assists     int     `json:"assists"`
+	KDA         float64 `json:"kda"`          // (K+A)/max(D,1)
+	DamageShare float64 `json:"damage_share"` // of the team's damage to champions
+	VisionScore int     `json:"vision_score"`
+	// Rating weighs KDA (40%), damage share (40%) and vision (20%), each
+	// relative to the best in the match, on a 0-10 scale.
+	Rating float64 `json:"rating"`
+}
+
+// MatchAwards are the titles of a verified custom match: MVP is the best
+// rated player of the winners, Ace the best of the losers.
+type MatchAwards struct {
+	MVP     string        `json:"mvp"`
+	Ace     string        `json:"ace"`
+	Players []Performance `json:"players"` // best rated first
+}
+
+// Awards rates every player of ts in m. Call it after MatchOutcome accepted m.
+func Awards(ts TeamSplit, m *riot.Match) MatchAwards {
+	ix := indexMatch(m)
+	teamDamage := map[int]int{}
+	for _, p := range m.Info.Participants {
+		teamDamage[p.TeamID] += p.TotalDamageDealtToChampions
+	}
+	var perfs []Performance
+	add := func(team []Profile, label string) {
+		for _, pr := range team {
+			p, ok := ix.find(pr)
+			if !ok {
+				continue
+			}
+			perf := Performance{
+				Name: pr.Name, Team: label, Win: p.Win,
+				Kills: p.Kills, Deaths: p.Deaths, Assists: p.Assists,
+				KDA:         float64(p.Kills+p.Assists) / float64(max(p.Deaths, 1)),
+				VisionScore: p.VisionScore,
+			}
+			if d := teamDamage[p.TeamID]; d > 0 {
+				perf.DamageShare = float64(p.TotalDamageDealtToChampions) / float64(d)
+			}
+			perfs = append(perfs, perf)
+		}
+	}
+	add(ts.TeamA, "A")
+	add(ts.TeamB, "B")
+
+	var bestKDA, bestShare, bestVision float64
+	for _, p := range perfs {
+		bestKDA = max(bestKDA, p.KDA)
+		bestShare = max(bestShare, p.DamageShare)
+		bestVision = max(bestVision, float64(p.VisionScore))
+	}
+	rel := func(v, best float64) float64 {
+		if best <= 0 {
+			return 0
+		}
+		return v / best
+	}
+	for i := range perfs {
+		p := &perfs[i]
+		r := 4*rel(p.KDA, bestKDA) + 4*rel(p.DamageShare, bestShare) + 2*rel(float64(p.VisionScore), bestVision)
+		p.Rating = float64(int(r*10+0.5)) / 10
+		p.KDA = float64(int(p.KDA*100+0.5)) / 100
+		p.DamageShare = float64(int(p.DamageShare*1000+0.5)) / 1000
+	}
+	sort.SliceStable(perfs, func(i, j int) bool { return perfs[i].Rating > perfs[j].Rating })
+
+	a := MatchAwards{Players: perfs}
+	for _, p := range perfs {
+		if p.Win && a.MVP == "" {
+			a.MVP = p.Name
+		}
+		if !p.Win && a.Ace == "" {
+			a.Ace = p.Name
+		}
+	}
+	return a
+}
@@ -0,0 +1,65 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+
+	"lol_custom_skill_matching/internal/riot"
+)
+
+// ErrPlayerNotFound is returned for a Riot ID that doesn't resolve to an account.
+var ErrPlayerNotFound = errors.New("riot id not found")
+
+// ErrPlayerTimeout is returned for a player Analyzer.PlayerTimeout ran out on
+// before the account lookup answered, leaving nothing to build a profile from.
+var ErrPlayerTimeout = errors.New("player analysis timed out")
+
+// ErrorCategory groups analysis failures by what can be done about them.
+type ErrorCategory string
+
+const (
+	// ErrorTransientRiot is Riot being down, throttling or slow; the same
+	// request is likely to work later.
+	ErrorTransientRiot ErrorCategory = "transient_riot"
+	// ErrorPermanentRiot is Riot refusing the request (rejected key,
+	// unreadable answer); retrying won't help until something is fixed.
+	ErrorPermanentRiot ErrorCategory = "permanent_riot"
+	// ErrorInvalidInput is a problem with the request itself (unknown Riot ID).
+	ErrorInvalidInput ErrorCategory = "invalid_input"
+	// ErrorInternal is anything else.
+	ErrorInternal ErrorCategory = "internal"
+)
+
+// Failure is a classified analysis error.
+type Failure struct {
+	Category  ErrorCategory `json:"category"`
+	Retryable bool          `json:"retryable"`
+	Message   string        `json:"error"`
+}
+
+// PlayerFailure is why a player has no profile.
+type PlayerFailure struct {
+	Player Player `json:"player"`
+	Failure
+}
+
+// Classify sorts err into an ErrorCategory; only transient Riot errors are
+// retryable.
+func Classify(err error) Failure {
+	f := Failure{Category: ErrorInternal, Message: err.Error()}
+	var netErr net.Error
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, ErrPlayerNotFound):
+		f.Category = ErrorInvalidInput
+	case errors.Is(err, riot.ErrKeyInvalid), errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		f.Category = ErrorPermanentRiot
+	case errors.Is(err, riot.ErrUnavailable), errors.Is(err, riot.ErrSkipped), errors.Is(err, riot.ErrRetriesExhausted),
+		errors.Is(err, ErrPlayerTimeout), errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr):
+		f.Category, f.Retryable = ErrorTransientRiot, true
+	}
+	return f
+}
@@ -0,0 +1,61 @@
+package analyzer
+
+import (
+	"lol_custom_skill_matching/internal/scoring"
+)
+
+// ScoreFeatures are the names a custom score formula (Analyzer.ScoreFormula) can use.
+var ScoreFeatures = []string{
+	"current_rank",       // solo queue rank score (0 = unranked)
+	"avg_lobby_rank",     // average rank of recent lobbies (0 when skipped)
+	"avg_lane_opponent",  // average rank of the direct lane opponents (0 when skipped)
+	"winrate_rank",       // current rank shifted by recent ranked winrate
+	"mastery_top3",       // total mastery points of the top 3 champions
+	"ranked_games",       // recent ranked games analyzed
+	"ranked_wins",        // of ranked_games, wins
+	"games_analyzed",     // recent (and backfilled) games analyzed
+	"lobby_rated",        // lobby participants whose rank was found
+	"lobby_rank_skipped", // 1 in quick mode, else 0
+	"default_score",      // the built-in formula's result
+}
+
+// CompileScoreFormula parses a formula over ScoreFeatures.
+func CompileScoreFormula(src string) (*scoring.Expr, error) {
+	return scoring.Compile(src, ScoreFeatures)
+}
+
+type scoreInputs struct {
+	currentRank, avgLobbyRank, topMastery      int
+	avgLaneOpponent                            int
+	rankedGames, rankedWins, games, lobbyRated int
+	skipLobbyRank                              bool
+}
+
+// defaultScore is the built-in formula: current rank counts double, plus the
+// lobby average (or a winrate-adjusted rank in quick mode) and mastery/1000.
+func (in scoreInputs) defaultScore() int {
+	if in.skipLobbyRank {
+		return in.currentRank*2 + WinrateAdjustedRank(in.currentRank, in.rankedWins, in.rankedGames) + in.topMastery/1000
+	}
+	return in.currentRank*2 + in.avgLobbyRank + in.topMastery/1000
+}
+
+func (in scoreInputs) features() scoring.Features {
+	skipped := 0.0
+	if in.skipLobbyRank {
+		skipped = 1
+	}
+	return scoring.Features{
+		"current_rank":       float64(in.currentRank),
+		"avg_lobby_rank":     float64(in.avgLobbyRank),
+		"avg_lane_opponent":  float64(in.avgLaneOpponent),
+		"winrate_rank":       float64(WinrateAdjustedRank(in.currentRank, in.rankedWins, in.rankedGames)),
+		"mastery_top3":       float64(in.topMastery),
+		"ranked_games":       float64(in.rankedGames),
+		"ranked_wins":        float64(in.rankedWins),
+		"games_analyzed":     float64(in.games),
+		"lobby_rated":        float64(in.lobbyRated),
+		"lobby_rank_skipped": skipped,
+		"default_score":      float64(in.defaultScore()),
+	}
+}
@@ -0,0 +1,71 @@
+package analyzer
+
+import (
+	"math"
+	"time"
+)
+
+// staleMarginPerWeek and maxStaleMargin widen the band of an older profile:
+// the player kept playing since it was computed.
+const (
+	staleMarginPerWeek = 50.0
+	maxStaleMargin     = 400.0
+)
+
+// skillInterval estimates a confidence band around a skill score from how much data backs it
+// and how old it is (age, 0 for a fresh analysis).
+// Margins are in rank-score units (100 = one division):
+//   - few analyzed games widen the band (300/sqrt(games), 300 with no games)
+//   - unranked players lose the 2x weighted current-rank term, so they get +400
+//   - no rated lobby participants means the lobby-average term is a guess (+200)
+//   - every week of age adds 50, up to +400 (see staleMargin)
+func skillInterval(skill, gamesAnalyzed, lobbySamples int, unranked bool, age time.Duration) Interval {
+	m := 300.0
+	if gamesAnalyzed > 0 {
+		m = 300.0 / math.Sqrt(float64(gamesAnalyzed))
+	}
+	if unranked {
+		m += 400
+	}
+	if lobbySamples == 0 {
+		m += 200
+	}
+	m += staleMargin(age)
+	margin := int(math.Round(m))
+	low := skill - margin
+	if low < 0 {
+		low = 0
+	}
+	return Interval{Low: low, High: skill + margin, Margin: margin}
+}
+
+// staleMargin is how much wider the band of a profile computed age ago is.
+func staleMargin(age time.Duration) float64 {
+	if age <= 0 {
+		return 0
+	}
+	return min(staleMarginPerWeek*age.Hours()/(7*24), maxStaleMargin)
+}
+
+// Age widens the skill interval of a profile stored age ago (see
+// skillInterval) for reuse without a new analysis. Stored profiles only
+// keep the band, so the widening is added to both of its ends.
+func (p *Profile) Age(age time.Duration) {
+	extra := int(math.Round(staleMargin(age)))
+	p.SkillInterval.Low = max(p.SkillInterval.Low-extra, 0)
+	p.SkillInterval.High += extra
+	p.SkillInterval.Margin += extra
+}
+
+// WinrateAdjustedRank stands in for the average lobby rank when that phase is skipped:
+// the current rank shifted by recent ranked winrate (every 10% above/below 50% = 100 points).
+func WinrateAdjustedRank(currentRankScore, wins, games int) int {
+	if games == 0 {
+		return currentRankScore
+	}
+	adj := currentRankScore + (wins*2-games)*500/games
+	if adj < 0 {
+		adj = 0
+	}
+	return adj
+}
@@ -0,0 +1,44 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSkillIntervalAge(t *testing.T) {
+	const week = 7 * 24 * time.Hour
+	tests := []struct {
+		name       string
+		age        time.Duration
+		wantMargin int
+	}{
+		{"fresh", 0, 100},
+		{"a day", 24 * time.Hour, 107},
+		{"a week", week, 150},
+		{"four weeks", 4 * week, 300},
+		{"capped", 52 * week, 500},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 9 games and a rated lobby: 300/sqrt(9) = 100 before aging
+			got := skillInterval(1500, 9, 5, false, tt.age)
+			want := Interval{Low: 1500 - tt.wantMargin, High: 1500 + tt.wantMargin, Margin: tt.wantMargin}
+			if got != want {
+				t.Errorf("skillInterval(age %s) = %+v, want %+v", tt.age, got, want)
+			}
+		})
+	}
+}
+
+func TestProfileAge(t *testing.T) {
+	p := Profile{SkillScore: 100, SkillInterval: Interval{Low: 0, High: 250, Margin: 125}}
+	p.Age(2 * 7 * 24 * time.Hour)
+	if want := (Interval{Low: 0, High: 350, Margin: 225}); p.SkillInterval != want {
+		t.Errorf("aged interval = %+v, want %+v", p.SkillInterval, want)
+	}
+	fresh := Profile{SkillScore: 1200, SkillInterval: Interval{Low: 1100, High: 1300, Margin: 100}}
+	fresh.Age(0)
+	if want := (Interval{Low: 1100, High: 1300, Margin: 100}); fresh.SkillInterval != want {
+		t.Errorf("fresh interval = %+v, want %+v", fresh.SkillInterval, want)
+	}
+}
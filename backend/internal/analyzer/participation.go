@@ -0,0 +1,34 @@
+package analyzer
+
+import (
+	"fmt"
+	"time"
+)
+
+// MinCommunityGames is how many stored community games a player needs before
+// their rating stops being labelled uncertain.
+const MinCommunityGames = 3
+
+// Participation is a member's attendance over the community's game nights
+// (days with a lobby or stored result).
+type Participation struct {
+	Sessions      int       `json:"sessions"`       // game nights attended
+	Games         int       `json:"games"`          // stored results played
+	CurrentStreak int       `json:"current_streak"` // consecutive latest game nights attended
+	BestStreak    int       `json:"best_streak"`
+	LastPlayed    time.Time `json:"last_played"`
+}
+
+// MarkParticipation attaches each profile's participation (looked up by
+// Riot ID) and labels ratings built on too few community games as uncertain.
+func MarkParticipation(profiles []Profile, of func(riotID string) Participation) {
+	for i := range profiles {
+		p := of(profiles[i].Name)
+		profiles[i].Participation = &p
+		if p.Games < MinCommunityGames {
+			profiles[i].UncertainRating = true
+			profiles[i].UncertainReasons = append(profiles[i].UncertainReasons,
+				fmt.Sprintf("few community games (%d of %d)", p.Games, MinCommunityGames))
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package analyzer
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"lol_custom_skill_matching/internal/riot"
+)
+
+// Preset names.
+const (
+	PresetQuick    = "quick"
+	PresetStandard = "standard"
+	PresetDeep     = "deep"
+)
+
+// Preset bundles the cost/accuracy knobs of an analysis under a name.
+type Preset struct {
+	Name string `json:"name"`
+	// MatchLimit is how many recent matches are fetched per player, up to
+	// MaxMatchLimit while fewer than MinGames of them count (see Options).
+	MatchLimit    int `json:"match_limit"`
+	MinGames      int `json:"min_games,omitempty"`
+	MaxMatchLimit int `json:"max_match_limit,omitempty"`
+	// SkipLobbyRank drops the participant-rank phase; otherwise Sampling picks
+	// which participants are rated.
+	SkipLobbyRank bool          `json:"skip_lobby_rank"`
+	Sampling      LobbySampling `json:"lobby_rank_sampling"`
+	// Queues are the queue ids that count (see DefaultQueues).
+	Queues []int `json:"queues"`
+	// MaxAgeDays ignores matches older than this (0 = no limit).
+	MaxAgeDays int `json:"max_age_days,omitempty"`
+	// HistoryLimit adds up to this many backfilled matches.
+	HistoryLimit int `json:"history_limit,omitempty"`
+	// Patch and PatchDecay segment matches by patch (see Options).
+	Patch      string  `json:"patch,omitempty"`
+	PatchDecay float64 `json:"patch_decay,omitempty"`
+}
+
+// DefaultQueues are normals (draft, blind) and ranked solo.
+var DefaultQueues = []int{riot.QueueDraft, riot.QueueRankedSolo, riot.QueueBlind}
+
+// Presets are the built-in presets. "standard" is what an analysis without a
+// preset does.
+var Presets = map[string]Preset{
+	PresetQuick: {
+		Name: PresetQuick, MatchLimit: 5, MinGames: 3, MaxMatchLimit: 10, SkipLobbyRank: true,
+		Queues: DefaultQueues, MaxAgeDays: 60,
+	},
+	PresetStandard: {
+		Name: PresetStandard, MatchLimit: 10, MinGames: 5, MaxMatchLimit: 30,
+		Sampling: LobbySampling{Strategy: "all"}, Queues: DefaultQueues,
+	},
+	PresetDeep: {
+		Name: PresetDeep, MatchLimit: 30, MinGames: 15, MaxMatchLimit: 100,
+		Sampling: LobbySampling{Strategy: "all"}, Queues: DefaultQueues,
+		MaxAgeDays: 365, HistoryLimit: 200,
+	},
+}
+
+// PresetNames lists the presets alphabetically, for error messages and help.
+func PresetNames() []string {
+	names := make([]string, 0, len(Presets))
+	for n := range Presets {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupPreset returns the named preset.
+func LookupPreset(name string) (Preset, error) {
+	p, ok := Presets[name]
+	if !ok {
+		return Preset{}, fmt.Errorf("unknown preset %q (%v)", name, PresetNames())
+	}
+	p.Queues = slices.Clone(p.Queues)
+	return p, nil
+}
+
+// Apply copies the preset's knobs into opts; sampler is built from Sampling.
+func (p Preset) Apply(opts *Options) error {
+	sampler, err := NewParticipantSampler(p.Sampling)
+	if err != nil {
+		return err
+	}
+	opts.MatchLimit = p.MatchLimit
+	opts.MinGames, opts.MaxMatchLimit = p.MinGames, p.MaxMatchLimit
+	opts.SkipLobbyRank = p.SkipLobbyRank
+	opts.Sampler = sampler
+	opts.Queues = p.Queues
+	opts.Since = time.Time{}
+	if p.MaxAgeDays > 0 {
+		opts.Since = time.Now().AddDate(0, 0, -p.MaxAgeDays)
+	}
+	opts.HistoryLimit = p.HistoryLimit
+	opts.Patch = p.Patch
+	opts.PatchDecay = p.PatchDecay
+	return nil
+}
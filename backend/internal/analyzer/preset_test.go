@@ -0,0 +1,128 @@
+package analyzer
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"lol_custom_skill_matching/internal/riot"
+)
+
+func TestLookupPreset(t *testing.T) {
+	if _, err := LookupPreset("turbo"); err == nil || !strings.Contains(err.Error(), strings.Join(PresetNames(), " ")) {
+		t.Errorf("unknown preset: %v, want the known names", err)
+	}
+	p, err := LookupPreset(PresetQuick)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Queues[0] = riot.QueueARAM
+	if Presets[PresetQuick].Queues[0] == riot.QueueARAM {
+		t.Error("changing a looked-up preset's queues changed the built-in one")
+	}
+}
+
+func TestPresetApply(t *testing.T) {
+	for _, name := range PresetNames() {
+		t.Run(name, func(t *testing.T) {
+			p, _ := LookupPreset(name)
+			opts := Options{Mode: ModeRolesFirst, Since: time.Unix(1, 0), Patch: "14.1"}
+			if err := p.Apply(&opts); err != nil {
+				t.Fatal(err)
+			}
+			if opts.MatchLimit != p.MatchLimit || opts.MinGames != p.MinGames || opts.MaxMatchLimit != p.MaxMatchLimit ||
+				opts.SkipLobbyRank != p.SkipLobbyRank || opts.HistoryLimit != p.HistoryLimit || !slices.Equal(opts.Queues, p.Queues) {
+				t.Errorf("options %+v don't carry preset %+v", opts, p)
+			}
+			if opts.Mode != ModeRolesFirst {
+				t.Errorf("mode %q, want the request's kept", opts.Mode)
+			}
+			if opts.Patch != p.Patch {
+				t.Errorf("patch %q, want the preset's %q", opts.Patch, p.Patch)
+			}
+			if p.MaxAgeDays == 0 && !opts.Since.IsZero() {
+				t.Errorf("since %s without an age limit", opts.Since)
+			}
+			if p.MaxAgeDays > 0 {
+				if age := time.Since(opts.Since); age < time.Duration(p.MaxAgeDays)*24*time.Hour-time.Minute || age > time.Duration(p.MaxAgeDays)*24*time.Hour+time.Hour {
+					t.Errorf("since %s, want %d days ago", opts.Since, p.MaxAgeDays)
+				}
+			}
+			if want := p.Sampling.Strategy; opts.Sampler == nil || want != "" && opts.Sampler.Name() != want {
+				t.Errorf("sampler %v, want %q", opts.Sampler, want)
+			}
+		})
+	}
+	bad := Preset{Name: "bad", MatchLimit: 10, Sampling: LobbySampling{Strategy: "some"}}
+	if err := bad.Apply(&Options{}); err == nil {
+		t.Error("unknown sampling strategy applied")
+	}
+}
+
+func TestOptionsCounts(t *testing.T) {
+	now := time.Now()
+	old := now.AddDate(0, 0, -90).UnixMilli()
+	tests := []struct {
+		name    string
+		opts    Options
+		queue   int
+		created int64
+		want    bool
+	}{
+		{"default queue", Options{}, riot.QueueDraft, old, true},
+		{"default skips ARAM", Options{}, riot.QueueARAM, old, false},
+		{"preset queue", Options{Queues: []int{riot.QueueARAM}}, riot.QueueARAM, old, true},
+		{"outside preset queues", Options{Queues: []int{riot.QueueARAM}}, riot.QueueDraft, old, false},
+		{"too old", Options{Since: now.AddDate(0, 0, -60)}, riot.QueueDraft, old, false},
+		{"recent enough", Options{Since: now.AddDate(0, 0, -120)}, riot.QueueDraft, old, true},
+		{"unknown date", Options{Since: now.AddDate(0, 0, -60)}, riot.QueueDraft, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.Counts(tt.queue, tt.created); got != tt.want {
+				t.Errorf("Counts = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextBatch(t *testing.T) {
+	tests := []struct{ need, counted, fetched, want int }{
+		{5, 0, 10, 10}, // none counted: as many again
+		{15, 0, 5, 15}, // or what's needed, if more
+		{5, 5, 10, 10}, // half counted: twice what's needed
+		{1, 9, 10, 2},  // rounded up
+		{1, 10, 10, 1},
+	}
+	for _, tt := range tests {
+		if got := NextBatch(tt.need, tt.counted, tt.fetched); got != tt.want {
+			t.Errorf("NextBatch(%d, %d, %d) = %d, want %d", tt.need, tt.counted, tt.fetched, got, tt.want)
+		}
+	}
+}
+
+func TestParticipantSamplers(t *testing.T) {
+	matches := [][]string{
+		{"me", "a", "b", "c", "d", "e", "f", "g", "h", "i"},
+		{"me", "a", "j", "k", "l", "m", "n", "o", "p", "q"},
+	}
+	all, _ := NewParticipantSampler(LobbySampling{})
+	if got := all.Sample("me", matches); len(got) != 18 || !slices.Contains(got, "me") {
+		t.Errorf("all = %v, want the 18 unique participants", got)
+	}
+	s, err := NewParticipantSampler(LobbySampling{Strategy: "per_match", PerMatch: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := s.Sample("me", matches)
+	if len(got) < 5 || len(got) > 6 || slices.Contains(got, "me") {
+		t.Errorf("per_match = %v, want 3 others per match", got)
+	}
+	if again := s.Sample("me", matches); !slices.Equal(got, again) {
+		t.Errorf("resampling = %v, want the same %v", again, got)
+	}
+	if def, _ := NewParticipantSampler(LobbySampling{Strategy: "per_match"}); def.(PerMatchSample).N != 4 {
+		t.Errorf("default per match = %+v, want 4", def)
+	}
+}
@@ -0,0 +1,104 @@
+package analyzer
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"lol_custom_skill_matching/internal/riot"
+)
+
+// Recent usage counts fully for proficiencyRecent, half until
+// proficiencyWindow and a little beyond: a champion played this month says
+// more than one mained two seasons ago.
+const (
+	proficiencyRecent = 30 * 24 * time.Hour
+	proficiencyWindow = 90 * 24 * time.Hour
+	staleGameWeight   = 0.1
+	// proficiencyMasteryUnit is the mastery that counts like one recent game
+	// on a log scale (100k points ~ 2.4 games, 1M ~ 4.6).
+	proficiencyMasteryUnit = 10000.0
+)
+
+// ChampionProficiency is how well a player is expected to play a champion.
+type ChampionProficiency struct {
+	Champion      string  `json:"champion"`
+	MasteryPoints int     `json:"mastery_points"`
+	Games         int     `json:"games"`     // analyzed games on it
+	Games30       int     `json:"games_30d"` // of those, in the last 30 days
+	Games90       int     `json:"games_90d"`
+	Wins90        int     `json:"wins_90d"`
+	Score         float64 `json:"score"`
+
+	id int // numeric champion id, while analyzing
+}
+
+// champUsage accumulates a champion's analyzed games.
+type champUsage struct {
+	games, games30, games90, wins90 int
+	recent                          float64 // games weighted by age and patch
+}
+
+func (u *champUsage) add(m MatchSummary, now time.Time, weight float64) {
+	u.games++
+	age := now.Sub(time.UnixMilli(m.GameCreation))
+	switch {
+	case age <= proficiencyRecent:
+		u.games30++
+		u.games90++
+		u.recent += weight
+	case age <= proficiencyWindow:
+		u.games90++
+		u.recent += weight / 2
+	default:
+		u.recent += weight * staleGameWeight
+	}
+	if m.Win && age <= proficiencyWindow {
+		u.wins90++
+	}
+}
+
+// proficiencyScore blends mastery with recent usage, scaled by the smoothed
+// 90-day winrate (0.5x for all losses up to 1.5x for all wins).
+func proficiencyScore(points int, u champUsage) float64 {
+	winrate := float64(u.wins90+1) / float64(u.games90+2)
+	return math.Log1p(float64(points)/proficiencyMasteryUnit) + u.recent*(0.5+winrate)
+}
+
+// rankProficiency scores every champion with mastery or usage, best first.
+func rankProficiency(masteries []riot.Mastery, usage map[int]*champUsage, champs *riot.Champions) []ChampionProficiency {
+	points := map[int]int{}
+	for _, m := range masteries {
+		points[m.ChampionID] = m.ChampionPoints
+	}
+	ids := map[int]struct{}{}
+	for id := range points {
+		ids[id] = struct{}{}
+	}
+	for id := range usage {
+		ids[id] = struct{}{}
+	}
+	out := make([]ChampionProficiency, 0, len(ids))
+	for id := range ids {
+		name := champs.Name(id)
+		if name == "" {
+			continue
+		}
+		var u champUsage
+		if usage[id] != nil {
+			u = *usage[id]
+		}
+		out = append(out, ChampionProficiency{
+			Champion: name, id: id, MasteryPoints: points[id],
+			Games: u.games, Games30: u.games30, Games90: u.games90, Wins90: u.wins90,
+			Score: math.Round(proficiencyScore(points[id], u)*100) / 100,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].Champion < out[j].Champion
+	})
+	return out
+}
@@ -0,0 +1,115 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"lol_custom_skill_matching/internal/riot"
+)
+
+// AlgorithmVersion identifies the team splitting algorithms. Bump it with
+// any change that can split the same profiles differently.
+const AlgorithmVersion = "2026.10.1"
+
+// ScoreFormulaVersion identifies the built-in skill score formula. Bump it
+// with any change to how a profile's score is computed from its data.
+const ScoreFormulaVersion = "builtin-1"
+
+// Provenance records what produced a split, so two splits of the same roster
+// can be told apart: same InputHash but a different ModelHash means the
+// server's scoring changed, a different DataDragon the champion data, and
+// all equal means the players' own match data did.
+type Provenance struct {
+	Algorithm string `json:"algorithm_version"`
+	// ScoreFormula is ScoreFormulaVersion, or "custom" with SCORE_FORMULA.
+	ScoreFormula string `json:"score_formula_version"`
+	// ModelHash covers everything turning match data into scores and win
+	// chances: the formula (its source when custom) and the win scale.
+	ModelHash  string `json:"model_hash"`
+	DataDragon string `json:"data_dragon_version"`
+	// InputHash covers the roster (order and case ignored) and the options
+	// that change what is analyzed or how it is split.
+	InputHash string `json:"input_hash"`
+}
+
+// Provenance stamps a split of players analyzed with opts.
+func (a *Analyzer) Provenance(players []Player, opts Options) *Provenance {
+	p := &Provenance{Algorithm: AlgorithmVersion, ScoreFormula: ScoreFormulaVersion, DataDragon: riot.DataDragonVersion}
+	formula := ScoreFormulaVersion
+	if a.ScoreFormula != nil {
+		p.ScoreFormula = "custom"
+		formula = a.ScoreFormula.String()
+	}
+	scale := a.WinScale
+	if scale <= 0 {
+		scale = DefaultWinScale
+	}
+	p.ModelHash = hashJSON(struct {
+		Formula  string   `json:"formula"`
+		Features []string `json:"features"`
+		WinScale float64  `json:"win_scale"`
+	}{formula, ScoreFeatures, scale})
+	p.InputHash = InputHash(players, opts)
+	return p
+}
+
+// InputHash hashes a roster and the options that shape its split; see
+// Provenance.InputHash.
+func InputHash(players []Player, opts Options) string {
+	ids := make([]string, len(players))
+	for i, pl := range players {
+		ids[i] = strings.ToLower(pl.RiotID())
+	}
+	slices.Sort(ids)
+	// Since is usually relative to now (a preset's max age); hash it in days
+	sinceDays := 0
+	if !opts.Since.IsZero() {
+		sinceDays = int(math.Round(time.Since(opts.Since).Hours() / 24))
+	}
+	captains := make([]Captain, len(opts.Captains))
+	for i, c := range opts.Captains {
+		captains[i] = Captain{Player: strings.ToLower(strings.TrimSpace(c.Player)), Role: c.Role}
+	}
+	var tagGroups []TagGroup
+	for _, g := range opts.TagGroups {
+		players := make([]string, len(g.Players))
+		for i, p := range g.Players {
+			players[i] = strings.ToLower(strings.TrimSpace(p))
+		}
+		slices.Sort(players)
+		tagGroups = append(tagGroups, TagGroup{TagRule: TagRule{Tag: strings.ToLower(g.Tag), Rule: g.Rule}, Players: players})
+	}
+	return hashJSON(struct {
+		Players       []string   `json:"players"`
+		MatchLimit    int        `json:"match_limit"`
+		MinGames      int        `json:"min_games,omitempty"`
+		MaxMatchLimit int        `json:"max_match_limit,omitempty"`
+		HistoryLimit  int        `json:"history_limit"`
+		SkipLobbyRank bool       `json:"skip_lobby_rank"`
+		Queues        []int      `json:"queues"`
+		SinceDays     int        `json:"since_days"`
+		Patch         string     `json:"patch"`
+		PatchDecay    float64    `json:"patch_decay"`
+		Mode          string     `json:"mode"`
+		BalanceOn     string     `json:"balance_on"`
+		Objective     string     `json:"objective"`
+		MaxLaneGap    int        `json:"max_lane_gap"`
+		MinLaneGames  int        `json:"min_lane_games,omitempty"`
+		LaneWeight    int        `json:"lane_weight,omitempty"`
+		Captains      []Captain  `json:"captains"`
+		TagGroups     []TagGroup `json:"tag_groups,omitempty"`
+	}{ids, opts.MatchLimit, opts.MinGames, opts.MaxMatchLimit, opts.HistoryLimit, opts.SkipLobbyRank, opts.Queues, sinceDays, opts.Patch, opts.PatchDecay,
+		opts.Mode, opts.BalanceOn, opts.Objective, opts.MaxLaneGap, opts.MinLaneGames, opts.LaneWeight, captains, tagGroups})
+}
+
+// hashJSON is the hex SHA-256 of v's JSON, shortened to 16 characters.
+func hashJSON(v any) string {
+	b, _ := json.Marshal(v)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,75 @@
+package analyzer
+
+import (
+	"slices"
+
+	"lol_custom_skill_matching/internal/riot"
+)
+
+// MatchSummary is the per-match view of the analyzed player, attached with includeRaw
+// so the frontend can chart rank/lane/champion trends without extra endpoints, and
+// exported as JSON lines from /players/{riotId}/matches.jsonl.
+type MatchSummary struct {
+	MatchID      string `json:"match_id"`
+	QueueID      int    `json:"queue_id"`
+	GameCreation int64  `json:"game_creation"` // unix ms
+	GameDuration int    `json:"game_duration"` // seconds
+	ChampionID   int    `json:"champion_id"`
+	Champion     string `json:"champion"`
+	Lane         string `json:"lane"`
+	Win          bool   `json:"win"`
+	Kills        int    `json:"kills"`
+	Deaths       int    `json:"deaths"`
+	Assists      int    `json:"assists"`
+	CS           int    `json:"cs"`
+	// Patch is the "major.minor" patch the match was played on ("" for
+	// summaries stored before patches were recorded).
+	Patch string `json:"patch,omitempty"`
+	// Queue is the name of QueueID (see riot.QueueName; "" for summaries
+	// stored before queues were named).
+	Queue string `json:"queue,omitempty"`
+}
+
+// RawAggregates are the counts the profile fields are derived from.
+type RawAggregates struct {
+	ChampionCounts map[string]int `json:"champion_counts"`
+	LaneCounts     map[string]int `json:"lane_counts"`
+	QueueCounts    map[string]int `json:"queue_counts"` // by queue name
+	Matches        []MatchSummary `json:"matches"`
+}
+
+func newRawAggregates(championCount map[int]int, laneCount map[string]int, matches []MatchSummary, name func(int) string) *RawAggregates {
+	raw := &RawAggregates{ChampionCounts: map[string]int{}, LaneCounts: map[string]int{}, QueueCounts: map[string]int{}, Matches: NameQueues(matches)}
+	for id, n := range championCount {
+		nm := name(id)
+		if nm == "" {
+			nm = "不明"
+		}
+		raw.ChampionCounts[nm] += n
+	}
+	for lane, n := range laneCount {
+		raw.LaneCounts[lane] = n
+	}
+	for _, m := range raw.Matches {
+		raw.QueueCounts[m.Queue]++
+	}
+	if raw.Matches == nil {
+		raw.Matches = []MatchSummary{}
+	}
+	return raw
+}
+
+// NameQueues returns ms with the queue names the summaries stored before
+// queues were named lack; ms itself is left alone when all have one.
+func NameQueues(ms []MatchSummary) []MatchSummary {
+	if !slices.ContainsFunc(ms, func(m MatchSummary) bool { return m.Queue == "" }) {
+		return ms
+	}
+	ms = slices.Clone(ms)
+	for i := range ms {
+		if ms[i].Queue == "" {
+			ms[i].Queue = riot.QueueName(ms[i].QueueID)
+		}
+	}
+	return ms
+}
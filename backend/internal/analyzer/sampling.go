@@ -0,0 +1,123 @@
+package analyzer
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+)
+
+// LobbySampling is the request-side configuration for lobby-rank estimation.
+type LobbySampling struct {
+	Strategy string `json:"strategy,omitempty"` // "all" (default) or "per_match"
+	PerMatch int    `json:"perMatch,omitempty"` // participants rated per match for "per_match" (default 4)
+}
+
+// ParticipantSampler decides which match participants get a rank lookup.
+// matches holds the participant PUUIDs of each qualifying match; self is the analyzed player.
+type ParticipantSampler interface {
+	Name() string
+	Sample(self string, matches [][]string) []string
+}
+
+// AllParticipants rates every unique participant (including the player), the original behavior.
+type AllParticipants struct{}
+
+func (AllParticipants) Name() string { return "all" }
+func (AllParticipants) Sample(self string, matches [][]string) []string {
+	return uniquePUUIDs(matches)
+}
+
+// PerMatchSample rates N of the other participants in each match.
+// Picks are seeded by the analyzed PUUID so re-running on the same data samples the same players.
+type PerMatchSample struct{ N int }
+
+func (s PerMatchSample) Name() string { return "per_match" }
+func (s PerMatchSample) Sample(self string, matches [][]string) []string {
+	h := fnv.New64a()
+	h.Write([]byte(self))
+	rng := rand.New(rand.NewSource(int64(h.Sum64())))
+	picked := make([][]string, 0, len(matches))
+	for _, m := range matches {
+		others := make([]string, 0, len(m))
+		for _, p := range m {
+			if p != self {
+				others = append(others, p)
+			}
+		}
+		rng.Shuffle(len(others), func(i, j int) { others[i], others[j] = others[j], others[i] })
+		if len(others) > s.N {
+			others = others[:s.N]
+		}
+		picked = append(picked, others)
+	}
+	return uniquePUUIDs(picked)
+}
+
+func uniquePUUIDs(matches [][]string) []string {
+	seen := map[string]struct{}{}
+	out := []string{}
+	for _, m := range matches {
+		for _, p := range m {
+			if _, ok := seen[p]; ok {
+				continue
+			}
+			seen[p] = struct{}{}
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func NewParticipantSampler(cfg LobbySampling) (ParticipantSampler, error) {
+	switch cfg.Strategy {
+	case "", "all":
+		return AllParticipants{}, nil
+	case "per_match":
+		n := cfg.PerMatch
+		if n <= 0 {
+			n = 4
+		}
+		return PerMatchSample{N: n}, nil
+	}
+	return nil, fmt.Errorf("unknown lobby sampling strategy %q (all|per_match)", cfg.Strategy)
+}
+
+// LobbySampleReport describes how the lobby average was estimated.
+// CI95 uses the standard error with a finite population correction, so rating
+// everyone ("all") collapses the interval onto the mean.
+type LobbySampleReport struct {
+	Strategy    string  `json:"strategy"`
+	Population  int     `json:"population"`   // unique participants across analyzed matches
+	Sampled     int     `json:"sampled"`      // rank lookups issued
+	Rated       int     `json:"rated"`        // sampled participants with a solo rank
+	BotsSkipped int     `json:"bots_skipped"` // bot participants left out of the population
+	StdErr      float64 `json:"stderr"`
+	CI95        [2]int  `json:"ci95"`
+}
+
+func summarizeLobbySample(strategy string, population, sampled int, scores []int) (int, *LobbySampleReport) {
+	rep := &LobbySampleReport{Strategy: strategy, Population: population, Sampled: sampled, Rated: len(scores)}
+	if len(scores) == 0 {
+		return 0, rep
+	}
+	sum := 0
+	for _, s := range scores {
+		sum += s
+	}
+	mean := float64(sum) / float64(len(scores))
+	if len(scores) > 1 {
+		v := 0.0
+		for _, s := range scores {
+			v += (float64(s) - mean) * (float64(s) - mean)
+		}
+		v /= float64(len(scores) - 1)
+		se := math.Sqrt(v / float64(len(scores)))
+		if population > 1 && sampled <= population {
+			se *= math.Sqrt(float64(population-sampled) / float64(population-1))
+		}
+		rep.StdErr = math.Round(se*10) / 10
+	}
+	rep.CI95 = [2]int{int(math.Round(mean - 1.96*rep.StdErr)), int(math.Round(mean + 1.96*rep.StdErr))}
+	return sum / len(scores), rep
+}
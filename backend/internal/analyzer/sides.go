@@ -0,0 +1,117 @@
+package analyzer
+
+import (
+	"fmt"
+	"math/rand/v2"
+)
+
+// Side policies for Options.SidePolicy.
+const (
+	SidesFixed     = "fixed"     // team A keeps the configured side (default)
+	SidesRandom    = "random"    // coin flip
+	SidesAlternate = "alternate" // opposite of the side most of team A had last time
+	SidesFair      = "fair"      // minimize every player's blue/red imbalance
+)
+
+func ValidSidePolicy(p string) bool {
+	return p == "" || p == SidesFixed || p == SidesRandom || p == SidesAlternate || p == SidesFair
+}
+
+// SideStats is how often a player has been on each side.
+type SideStats struct {
+	Blue int    `json:"blue"`
+	Red  int    `json:"red"`
+	Last string `json:"last,omitempty"`
+}
+
+func (s SideStats) imbalance() int {
+	if s.Blue > s.Red {
+		return s.Blue - s.Red
+	}
+	return s.Red - s.Blue
+}
+
+func (s SideStats) add(side string) SideStats {
+	if side == SideBlue {
+		s.Blue++
+	} else {
+		s.Red++
+	}
+	s.Last = side
+	return s
+}
+
+// SideReport explains the side assignment in the result.
+type SideReport struct {
+	Policy string `json:"policy"`
+	// Swapped is true when team A ended up on the side configured for team B.
+	Swapped bool `json:"swapped"`
+	// Imbalance is the sum over players of |blue-red| after this game.
+	Imbalance int    `json:"imbalance"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// AssignSides decides which team plays blue side. "fair" guarantees the choice
+// never leaves the lobby's total side imbalance higher than the other option, so
+// players who keep meeting drift back towards an even blue/red count.
+func AssignSides(ts *TeamSplit, policy string, history func(name string) SideStats, r *rand.Rand) {
+	if policy == "" {
+		policy = SidesFixed
+	}
+	imbalance := func(swap bool) int {
+		sideA, sideB := ts.Teams[0].Side, ts.Teams[1].Side
+		if swap {
+			sideA, sideB = sideB, sideA
+		}
+		total := 0
+		for _, p := range ts.TeamA {
+			total += history(p.Name).add(sideA).imbalance()
+		}
+		for _, p := range ts.TeamB {
+			total += history(p.Name).add(sideB).imbalance()
+		}
+		return total
+	}
+	swap := false
+	reason := ""
+	switch policy {
+	case SidesRandom:
+		swap = r.IntN(2) == 1
+		reason = "coin flip"
+	case SidesAlternate:
+		same := 0
+		for _, p := range ts.TeamA {
+			if history(p.Name).Last == ts.Teams[0].Side {
+				same++
+			}
+		}
+		swap = same*2 > len(ts.TeamA)
+		reason = fmt.Sprintf("%d/%d of team A had the %s side last time", same, len(ts.TeamA), ts.Teams[0].Side)
+	case SidesFair:
+		keep, flip := imbalance(false), imbalance(true)
+		swap = flip < keep || (flip == keep && r.IntN(2) == 1)
+		reason = fmt.Sprintf("imbalance %d as configured, %d swapped", keep, flip)
+	}
+	if swap {
+		ts.Teams = swapSides(ts.Teams)
+	}
+	ts.Sides = &SideReport{Policy: policy, Swapped: swap, Imbalance: imbalance(false), Reason: reason}
+}
+
+// swapSides exchanges the sides; a team still named and colored after its
+// default side takes the default name and color of the side it moves to.
+func swapSides(t [2]TeamInfo) [2]TeamInfo {
+	def := map[string]TeamInfo{}
+	for _, d := range DefaultTeams() {
+		def[d.Side] = d
+	}
+	next := [2]string{t[1].Side, t[0].Side}
+	for i, side := range next {
+		if d := def[t[i].Side]; t[i].Name == d.Name && t[i].Color == d.Color {
+			t[i] = def[side]
+		} else {
+			t[i].Side = side
+		}
+	}
+	return t
+}
@@ -0,0 +1,132 @@
+package analyzer
+
+import (
+	"sort"
+	"strings"
+
+	"lol_custom_skill_matching/internal/balance"
+)
+
+// TeamSplit is the balancing outcome for a set of profiles.
+type TeamSplit struct {
+	TeamA     []Profile   `json:"teamA"`
+	TeamB     []Profile   `json:"teamB"`
+	SumA      int         `json:"sumA"`
+	SumB      int         `json:"sumB"`
+	Mode      string      `json:"mode"`
+	Teams     [2]TeamInfo `json:"teams"` // team A, team B
+	BalanceOn string      `json:"balance_on"`
+	Objective string      `json:"objective"`
+	// LaneUnique is the balance_first split with no lane overlap.
+	LaneUnique *balance.RoleSplit `json:"lane_unique,omitempty"`
+	// RolesFirst is the roles_first (mirror-then-balance) split.
+	RolesFirst *balance.RoleSplit `json:"roles_first,omitempty"`
+	// RoleCoverage diagnoses the roster's lanes before balancing: scarce and
+	// crowded roles and who could flex where (nil under five players).
+	RoleCoverage *balance.Coverage `json:"role_coverage,omitempty"`
+	// Captains are the players seeded on team A and B, in that order (none
+	// when the request named none or a captain wasn't analyzed).
+	Captains []Captain `json:"captains,omitempty"`
+	// TagRules reports the tag rules with at least two of the players.
+	TagRules []TagRuleResult `json:"tag_rules,omitempty"`
+	// Sides explains which team got blue side (set by AssignSides).
+	Sides *SideReport `json:"sides,omitempty"`
+	// WinPredictions are the predicted blue/red win chances of each candidate
+	// split (set by PredictWins).
+	WinPredictions []WinPrediction `json:"win_predictions,omitempty"`
+	// Provenance is what produced the split (set by the caller for stored
+	// results).
+	Provenance *Provenance `json:"provenance,omitempty"`
+	// Validation is the consistency check of this split; callers must not emit
+	// the split when it is not OK.
+	Validation SplitValidation `json:"validation"`
+}
+
+// Split balances profiles: alternating by score for any size, plus the
+// role-aware split selected by opts.Mode (from four players; teams past five
+// share balance.Fill).
+func Split(profiles []Profile, opts Options) TeamSplit {
+	balanceOn := BalanceOnScore
+	if opts.BalanceOn == BalanceOnConservative {
+		balanceOn = BalanceOnConservative
+	}
+	sorted := append([]Profile{}, profiles...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].BalancePlayer(balanceOn).Score > sorted[j].BalancePlayer(balanceOn).Score
+	})
+	players := make([]balance.Player, len(sorted))
+	for i, p := range sorted {
+		players[i] = p.BalancePlayer(balanceOn)
+	}
+
+	objective := balance.ObjectiveSum
+	if opts.Objective == ObjectiveSlotwise {
+		objective = balance.ObjectiveSlotwise
+	}
+	captains := seedCaptains(sorted, opts.Captains)
+	groups, tagRules := tagGroups(sorted, opts.TagGroups)
+	bopts := balance.Options{Captains: captains, Objective: objective, LaneGap: opts.MaxLaneGap, Groups: groups, LaneWeight: opts.LaneWeight}
+	s := balance.Alternate(players, bopts)
+	ts := TeamSplit{
+		TeamA: []Profile{}, TeamB: []Profile{}, SumA: s.SumA, SumB: s.SumB,
+		BalanceOn: balanceOn, Objective: string(objective), Teams: WithDefaults(opts.Teams),
+	}
+	ts.RoleCoverage = balance.RoleCoverage(players)
+	if captains != nil {
+		ts.Captains = []Captain{
+			{Player: sorted[captains.A].Name, Role: captains.RoleA},
+			{Player: sorted[captains.B].Name, Role: captains.RoleB},
+		}
+	}
+	for _, i := range s.A {
+		ts.TeamA = append(ts.TeamA, sorted[i])
+	}
+	for _, i := range s.B {
+		ts.TeamB = append(ts.TeamB, sorted[i])
+	}
+
+	if opts.Mode == ModeRolesFirst {
+		// roles_first: assign comfortable roles to everyone first, then balance within fixed roles
+		ts.Mode = ModeRolesFirst
+		ts.RolesFirst = balance.RolesFirst(players, bopts)
+	} else {
+		ts.Mode = ModeBalanceFirst
+		ts.LaneUnique = balance.LaneUnique(players, bopts)
+	}
+	rs := ts.LaneUnique
+	if ts.RolesFirst != nil {
+		rs = ts.RolesFirst
+	}
+	for k, g := range groups {
+		tagRules[k].Kept = g.Keeps(s.A)
+		if rs != nil {
+			kept := g.Keeps(roleSplitTeamA(sorted, rs))
+			tagRules[k].KeptRoles = &kept
+		}
+	}
+	ts.TagRules = tagRules
+	ts.Validation = ValidateSplit(profiles, ts)
+	return ts
+}
+
+// seedCaptains finds the captains among profiles (nil unless both are there).
+func seedCaptains(profiles []Profile, captains []Captain) *balance.Captains {
+	if len(captains) != 2 {
+		return nil
+	}
+	idx := [2]int{-1, -1}
+	for i, p := range profiles {
+		for k, c := range captains {
+			if strings.EqualFold(p.Name, strings.TrimSpace(c.Player)) {
+				idx[k] = i
+			}
+		}
+	}
+	if idx[0] < 0 || idx[1] < 0 {
+		return nil
+	}
+	return &balance.Captains{
+		A: idx[0], B: idx[1],
+		RoleA: strings.ToUpper(captains[0].Role), RoleB: strings.ToUpper(captains[1].Role),
+	}
+}
@@ -0,0 +1,87 @@
+package analyzer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"lol_custom_skill_matching/internal/balance"
+)
+
+// Tag rules: how Split treats the players carrying a tag.
+const (
+	TagRuleSpread   = balance.GroupSpread   // as evenly over the teams as possible
+	TagRuleTogether = balance.GroupTogether // all on the same team
+)
+
+// TagRule asks Split to keep the players carrying Tag (organizer tags, e.g.
+// "new player") to Rule.
+type TagRule struct {
+	Tag  string `json:"tag"`
+	Rule string `json:"rule"` // spread | together
+}
+
+// TagGroup is a TagRule resolved to the players carrying the tag.
+type TagGroup struct {
+	TagRule
+	Players []string `json:"players"` // Riot IDs (name#tag)
+}
+
+// TagRuleResult reports whether a split kept a tag rule. Rules were dropped
+// when no split could keep them all.
+type TagRuleResult struct {
+	TagGroup
+	// Kept is by teamA/teamB; KeptRoles by the mode's 10-player role split,
+	// when there is one.
+	Kept      bool  `json:"kept"`
+	KeptRoles *bool `json:"kept_roles,omitempty"`
+}
+
+func (r TagRule) validate() error {
+	if strings.TrimSpace(r.Tag) == "" {
+		return fmt.Errorf("every tag rule needs a tag")
+	}
+	if r.Rule != TagRuleSpread && r.Rule != TagRuleTogether {
+		return fmt.Errorf("invalid tag rule %q (spread|together)", r.Rule)
+	}
+	return nil
+}
+
+// tagGroups maps opts' tag groups to indices into profiles. Groups with
+// fewer than two of the players have nothing to keep and are left out, as
+// are their results.
+func tagGroups(profiles []Profile, groups []TagGroup) ([]balance.Group, []TagRuleResult) {
+	var out []balance.Group
+	var results []TagRuleResult
+	for _, g := range groups {
+		var members []int
+		var names []string
+		for i, p := range profiles {
+			if slices.ContainsFunc(g.Players, func(n string) bool { return strings.EqualFold(p.Name, strings.TrimSpace(n)) }) {
+				members, names = append(members, i), append(names, p.Name)
+			}
+		}
+		if len(members) < 2 {
+			continue
+		}
+		out = append(out, balance.Group{Rule: g.Rule, Members: members})
+		r := TagRuleResult{TagGroup: g}
+		r.Players = names
+		results = append(results, r)
+	}
+	return out, results
+}
+
+// roleSplitTeamA returns the indices into profiles of rs's team A.
+func roleSplitTeamA(profiles []Profile, rs *balance.RoleSplit) []int {
+	var a []int
+	for _, s := range rs.TeamA {
+		for i, p := range profiles {
+			if p.Name == s.Name {
+				a = append(a, i)
+				break
+			}
+		}
+	}
+	return a
+}
@@ -0,0 +1,61 @@
+package analyzer
+
+import (
+	"fmt"
+	"math/rand/v2"
+)
+
+// TeamInfo is the presentation of one team: display name, color and map side.
+type TeamInfo struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+	Side  string `json:"side"` // blue | red
+}
+
+const (
+	SideBlue = "blue"
+	SideRed  = "red"
+)
+
+// DefaultTeams names team A after the blue side and team B after the red side.
+func DefaultTeams() [2]TeamInfo {
+	return [2]TeamInfo{
+		{Name: "Blue", Color: "#1E88E5", Side: SideBlue},
+		{Name: "Red", Color: "#E53935", Side: SideRed},
+	}
+}
+
+// WithDefaults fills empty fields from DefaultTeams.
+func WithDefaults(teams [2]TeamInfo) [2]TeamInfo {
+	def := DefaultTeams()
+	for i := range teams {
+		if teams[i].Name == "" {
+			teams[i].Name = def[i].Name
+		}
+		if teams[i].Color == "" {
+			teams[i].Color = def[i].Color
+		}
+		if teams[i].Side == "" {
+			teams[i].Side = def[i].Side
+		}
+	}
+	return teams
+}
+
+var (
+	funAdjectives = []string{"無敵の", "眠れる", "怒れる", "伝説の", "迷子の", "光速の", "気まぐれな", "不屈の", "腹ペコの", "孤高の"}
+	funNouns      = []string{"ポロ", "バロン", "ドラゴン", "ミニオン", "スカトル", "ヘラルド", "ワード", "タワー", "ブラスト", "インヒビター"}
+)
+
+// RandomTeamNames picks two distinct fun names, e.g. "眠れるバロン".
+func RandomTeamNames(r *rand.Rand) [2]string {
+	name := func() string {
+		return fmt.Sprintf("%s%s", funAdjectives[r.IntN(len(funAdjectives))], funNouns[r.IntN(len(funNouns))])
+	}
+	a := name()
+	b := name()
+	for b == a {
+		b = name()
+	}
+	return [2]string{a, b}
+}
@@ -0,0 +1,179 @@
+package analyzer
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Phases of a player's analysis, in the order they run.
+const (
+	PhaseAccount   = "account"
+	PhaseMastery   = "mastery" // including the platform probe for accounts elsewhere
+	PhaseMatchList = "matchlist"
+	PhaseDetails   = "details"
+	PhaseRanks     = "ranks" // the player's own rank
+	PhaseLobbyRank = "lobby_rank"
+)
+
+// Phases lists every phase, in the order they run.
+var Phases = []string{PhaseAccount, PhaseMastery, PhaseMatchList, PhaseDetails, PhaseRanks, PhaseLobbyRank}
+
+// PlayerTrace is how long analyzing one player took, by phase. Phases that
+// didn't run (a skipped lobby rank, a player whose account failed) are absent.
+type PlayerTrace struct {
+	Player   string             `json:"player"`
+	PhasesMs map[string]float64 `json:"phases_ms"`
+	TotalMs  float64            `json:"total_ms"`
+}
+
+// Trace collects the PlayerTraces of the analyses run under a context.
+type Trace struct {
+	mu      sync.Mutex
+	players []PlayerTrace
+}
+
+type traceKey struct{}
+
+// WithTrace starts collecting the player traces of analyses made under the
+// returned context.
+func WithTrace(ctx context.Context) (context.Context, *Trace) {
+	t := &Trace{}
+	return context.WithValue(ctx, traceKey{}, t), t
+}
+
+// Players returns the traces collected so far, in the order players finished.
+func (t *Trace) Players() []PlayerTrace {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return slices.Clone(t.players)
+}
+
+func (t *Trace) add(p PlayerTrace) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.players = append(t.players, p)
+}
+
+// PhaseEvent reports a phase of a player's analysis that just ended.
+type PhaseEvent struct {
+	Player string  `json:"player"`
+	Phase  string  `json:"phase"`
+	Ms     float64 `json:"ms"`
+}
+
+type phaseEventsKey struct{}
+
+// WithPhaseEvents calls fn as each phase of the analyses made under the
+// returned context ends. fn runs on the analysis goroutines and must not block.
+func WithPhaseEvents(ctx context.Context, fn func(PhaseEvent)) context.Context {
+	return context.WithValue(ctx, phaseEventsKey{}, fn)
+}
+
+// phaseTimer times the phases of one player's analysis.
+type phaseTimer struct {
+	start   time.Time
+	trace   PlayerTrace
+	onPhase func(PhaseEvent) // nil = nobody listens
+}
+
+func newPhaseTimer(ctx context.Context, player Player) *phaseTimer {
+	t := &phaseTimer{start: time.Now(), trace: PlayerTrace{Player: player.RiotID(), PhasesMs: map[string]float64{}}}
+	t.onPhase, _ = ctx.Value(phaseEventsKey{}).(func(PhaseEvent))
+	return t
+}
+
+// begin starts timing phase; call the returned func when it ends.
+func (t *phaseTimer) begin(phase string) (end func()) {
+	at := time.Now()
+	return func() {
+		d := ms(time.Since(at))
+		t.trace.PhasesMs[phase] += d
+		if t.onPhase != nil {
+			t.onPhase(PhaseEvent{Player: t.trace.Player, Phase: phase, Ms: d})
+		}
+	}
+}
+
+// finishTimer records the player's timings in a's phase stats and ctx's Trace.
+func (a *Analyzer) finishTimer(ctx context.Context, t *phaseTimer) {
+	t.trace.TotalMs = ms(time.Since(t.start))
+	a.phases.record(t.trace.PhasesMs)
+	if tr, ok := ctx.Value(traceKey{}).(*Trace); ok {
+		tr.add(t.trace)
+	}
+}
+
+func ms(d time.Duration) float64 { return math.Round(float64(d)/float64(time.Millisecond)*10) / 10 }
+
+// phaseSampleSize is how many recent timings of each phase the percentiles
+// are taken over.
+const phaseSampleSize = 1024
+
+// PhaseStat summarizes a phase's timings across all analyses: Count and Sum
+// over the process's life, the quantiles over its recent timings.
+type PhaseStat struct {
+	Phase string
+	Count int64
+	Sum   time.Duration
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+}
+
+// phaseStats aggregates phase timings across players and jobs.
+type phaseStats struct {
+	mu     sync.Mutex
+	count  map[string]int64
+	sum    map[string]float64 // ms
+	recent map[string][]float64
+	next   map[string]int // ring position in recent once it is full
+}
+
+func (s *phaseStats) record(phases map[string]float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.count == nil {
+		s.count, s.sum, s.recent, s.next = map[string]int64{}, map[string]float64{}, map[string][]float64{}, map[string]int{}
+	}
+	for phase, v := range phases {
+		s.count[phase]++
+		s.sum[phase] += v
+		if r := s.recent[phase]; len(r) < phaseSampleSize {
+			s.recent[phase] = append(r, v)
+		} else {
+			r[s.next[phase]] = v
+			s.next[phase] = (s.next[phase] + 1) % phaseSampleSize
+		}
+	}
+}
+
+// PhaseStats returns the timing of every phase that has run, in Phases order.
+func (a *Analyzer) PhaseStats() []PhaseStat {
+	s := &a.phases
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []PhaseStat
+	for _, phase := range Phases {
+		if s.count[phase] == 0 {
+			continue
+		}
+		sorted := slices.Clone(s.recent[phase])
+		slices.Sort(sorted)
+		q := func(p float64) time.Duration {
+			i := int(math.Ceil(p*float64(len(sorted)))) - 1
+			return msDuration(sorted[max(i, 0)])
+		}
+		out = append(out, PhaseStat{
+			Phase: phase, Count: s.count[phase], Sum: msDuration(s.sum[phase]),
+			P50: q(0.5), P90: q(0.9), P99: q(0.99),
+		})
+	}
+	return out
+}
+
+func msDuration(v float64) time.Duration {
+	return time.Duration(math.Round(v * float64(time.Millisecond)))
+}
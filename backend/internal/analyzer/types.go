@@ -0,0 +1,312 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"lol_custom_skill_matching/internal/assets"
+	"lol_custom_skill_matching/internal/balance"
+	"lol_custom_skill_matching/internal/riot"
+)
+
+type Player struct {
+	GameName string `json:"gameName"`
+	TagLine  string `json:"tagLine"`
+	// Champions is the player-declared pool; when set it overrides inferred main champions.
+	Champions []string `json:"champions,omitempty"`
+	// Platform (e.g. na1, euw1, kr) is the shard the player plays on and
+	// Region overrides its regional routing (see riot.LookupPlatform); empty
+	// uses the server's platform, probing others when the player has no data.
+	Platform string `json:"platform,omitempty"`
+	Region   string `json:"region,omitempty"`
+}
+
+func (p Player) RiotID() string { return p.GameName + "#" + p.TagLine }
+
+const (
+	ModeBalanceFirst = "balance_first"
+	ModeRolesFirst   = "roles_first"
+
+	BalanceOnScore        = "score"
+	BalanceOnConservative = "conservative"
+
+	ObjectiveSum      = string(balance.ObjectiveSum)
+	ObjectiveSlotwise = string(balance.ObjectiveSlotwise)
+)
+
+// Options tune a single analysis run.
+type Options struct {
+	MatchLimit int
+	// MinGames widens the window of recent matches past MatchLimit, in
+	// batches, until this many of them count (queue, age and patch filters)
+	// or MaxMatchLimit were fetched; 0 keeps it at MatchLimit. The window
+	// ends at the 100 newest matches.
+	MinGames      int
+	MaxMatchLimit int
+	// Mode selects the 10-player split order: "balance_first" (default) or "roles_first".
+	Mode string
+	// BalanceOn "conservative" splits on the lower bound of each skill interval.
+	BalanceOn string
+	// Objective is what the split minimizes: "sum" (default), the difference
+	// of the team totals, or "slotwise", the gaps between the teams' best
+	// players, second best and so on.
+	Objective string
+	// MaxLaneGap caps the skill gap between the two players of a role in the
+	// role splits (0 = no cap); it is dropped, and the split says so, when no
+	// split keeps every lane within it.
+	MaxLaneGap int
+	// LaneWeight is the skill points one step off a player's lanes costs the
+	// lane_unique split (0 = balance.DefaultLaneWeight): a player moves from
+	// their first main lane to a sub lane only for twice that in balance.
+	LaneWeight int
+	// SkipLobbyRank skips the participant-rank phase (~10x the other requests).
+	SkipLobbyRank bool
+	// Sampler picks which participants are rated for the lobby average (nil = all).
+	Sampler ParticipantSampler
+	// IncludeRaw attaches per-player raw aggregates (counts, per-match summaries) for charts.
+	IncludeRaw bool
+	// MinLaneGames is how many counted games make the lanes trusted (0 =
+	// DefaultMinLaneGames). With fewer the profile's LaneConfidence is low
+	// and the role splits treat the player as flexible.
+	MinLaneGames int
+	// HistoryLimit adds up to this many backfilled older matches to the lane,
+	// champion and ranked-winrate sample (0 = recent matches only).
+	HistoryLimit int
+	// Teams names and colors team A and B; empty fields default to Blue/Red side.
+	Teams [2]TeamInfo
+	// SidePolicy picks which team plays blue side: fixed (default), random,
+	// alternate or fair. Applied by the caller with AssignSides, which needs history.
+	SidePolicy string
+	// Queues are the queue ids that count toward the profile (nil = DefaultQueues).
+	Queues []int
+	// Since ignores matches played before it (zero = no limit).
+	Since time.Time
+	// Patch restricts the analysis to matches of one patch: "current" (the
+	// newest patch among the player's matches) or a "major.minor" patch.
+	// "" counts every patch.
+	Patch string
+	// PatchDecay discounts champion usage by this fraction per patch a match
+	// is older than the current one, so picks from before a rework weigh
+	// less (0 = no discount).
+	PatchDecay float64
+	// Captains are none or two players fixed on opposite teams: the first
+	// leads team A, the second team B. Split balances the others around them.
+	Captains []Captain
+	// TagGroups are tag rules resolved to the players carrying the tag. Split
+	// keeps them over MaxLaneGap and drops them when no split keeps them all.
+	TagGroups []TagGroup
+}
+
+// Captain is a player Split seeds on a team, optionally on a fixed role.
+type Captain struct {
+	Player string `json:"player"`         // Riot ID (name#tag)
+	Role   string `json:"role,omitempty"` // TOP, JUNGLE, MIDDLE, BOTTOM or UTILITY ("" = any)
+}
+
+// PatchCurrent is the Options.Patch of the newest patch a player played on.
+const PatchCurrent = "current"
+
+// Lane confidences of a Profile.
+const (
+	LaneConfidenceHigh = "high"
+	LaneConfidenceLow  = "low"
+)
+
+// DefaultMinLaneGames is the Options.MinLaneGames when unset.
+const DefaultMinLaneGames = 5
+
+// minMainLaneGames is how many games it takes for a lane to be a main lane.
+const minMainLaneGames = 2
+
+func (o Options) minLaneGames() int {
+	if o.MinLaneGames > 0 {
+		return o.MinLaneGames
+	}
+	return DefaultMinLaneGames
+}
+
+// onPatch reports whether a match of patch is analyzed; current is the
+// player's newest patch.
+func (o Options) onPatch(patch, current string) bool {
+	switch o.Patch {
+	case "":
+		return true
+	case PatchCurrent:
+		return patch == current
+	}
+	return patch == o.Patch
+}
+
+// patchWeight is what m counts for in champion usage. Summaries stored
+// without a patch are aged by date instead, a patch every two weeks.
+func (o Options) patchWeight(m MatchSummary, current string, currentAt int64) float64 {
+	if o.PatchDecay <= 0 {
+		return 1
+	}
+	d, ok := riot.PatchDistance(current, m.Patch)
+	if !ok {
+		d = max(0, int((currentAt-m.GameCreation)/(14*24*time.Hour).Milliseconds()))
+	}
+	return math.Pow(1-o.PatchDecay, float64(d))
+}
+
+// Counts reports whether a match of queue played at created (ms) is analyzed.
+func (o Options) Counts(queue int, created int64) bool {
+	if !o.Since.IsZero() && created > 0 && created < o.Since.UnixMilli() {
+		return false
+	}
+	if o.Queues == nil {
+		return QualifyingQueue(queue)
+	}
+	return slices.Contains(o.Queues, queue)
+}
+
+func (o Options) Validate() error {
+	if o.Mode != "" && o.Mode != ModeBalanceFirst && o.Mode != ModeRolesFirst {
+		return fmt.Errorf("invalid mode (balance_first|roles_first)")
+	}
+	if o.BalanceOn != "" && o.BalanceOn != BalanceOnScore && o.BalanceOn != BalanceOnConservative {
+		return fmt.Errorf("invalid balanceOn (score|conservative)")
+	}
+	if o.Objective != "" && o.Objective != ObjectiveSum && o.Objective != ObjectiveSlotwise {
+		return fmt.Errorf("invalid objective (sum|slotwise)")
+	}
+	if o.MaxLaneGap < 0 {
+		return fmt.Errorf("invalid maxLaneGap (0 = no cap)")
+	}
+	if o.LaneWeight < 0 {
+		return fmt.Errorf("invalid laneWeight (0 = default)")
+	}
+	if !ValidSidePolicy(o.SidePolicy) {
+		return fmt.Errorf("invalid sidePolicy (fixed|random|alternate|fair)")
+	}
+	for _, q := range o.Queues {
+		if q < 0 {
+			return fmt.Errorf("invalid queue id %d", q)
+		}
+	}
+	if o.Patch != "" && o.Patch != PatchCurrent && !riot.ValidPatch(o.Patch) {
+		return fmt.Errorf("invalid patch %q (current or major.minor, e.g. 15.14)", o.Patch)
+	}
+	if o.PatchDecay < 0 || o.PatchDecay >= 1 {
+		return fmt.Errorf("invalid patchDecay (0 <= decay < 1)")
+	}
+	switch len(o.Captains) {
+	case 0:
+	case 2:
+		for _, c := range o.Captains {
+			if strings.TrimSpace(c.Player) == "" {
+				return fmt.Errorf("every captain needs a player")
+			}
+			if c.Role != "" && !slices.Contains(balance.Roles, strings.ToUpper(c.Role)) {
+				return fmt.Errorf("invalid captain role %q (%s)", c.Role, strings.Join(balance.Roles, "|"))
+			}
+		}
+		if strings.EqualFold(strings.TrimSpace(o.Captains[0].Player), strings.TrimSpace(o.Captains[1].Player)) {
+			return fmt.Errorf("captains must be two different players")
+		}
+	default:
+		return fmt.Errorf("captains must be two players, one per team")
+	}
+	for _, g := range o.TagGroups {
+		if err := g.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Interval is the confidence band around a skill score.
+type Interval struct {
+	Low    int `json:"low"`
+	High   int `json:"high"`
+	Margin int `json:"margin"`
+}
+
+// Profile is the analyzed view of one player.
+type Profile struct {
+	Name               string              `json:"name"`
+	PUUID              string              `json:"-"`
+	Platform           string              `json:"platform,omitempty"` // shard when not the default (e.g. "kr")
+	SkillScore         int                 `json:"skill_score"`
+	CurrentRankScore   int                 `json:"current_rank_score"`
+	Rank               *assets.Rank        `json:"rank,omitempty"` // current solo rank with emblem
+	AvgMatchRankScore  int                 `json:"avg_match_rank_score"`
+	LaneOpponentScore  int                 `json:"lane_opponent_avg_score"` // solo rank of direct lane opponents
+	LaneOpponentsRated int                 `json:"lane_opponents_rated"`    // of those opponents, ranked ones
+	LobbyRankSkipped   bool                `json:"lobby_rank_skipped"`
+	Partial            bool                `json:"partial,omitempty"` // built from what was fetched before Analyzer.PlayerTimeout
+	MainLanes          []string            `json:"main_lanes"`
+	MainSublanes       []string            `json:"main_sublanes"`
+	LaneConfidence     string              `json:"lane_confidence"` // LaneConfidenceLow under Options.MinLaneGames
+	MainChampions      []string            `json:"main_champions"`
+	InferredChampions  []string            `json:"inferred_champions"`
+	ChampionPoolSource string              `json:"champion_pool_source"`
+	MainLaneChampions  map[string][]string `json:"main_lane_champions"`
+	SublaneChampions   map[string][]string `json:"sublane_champions"`
+	MasteryTop3        int                 `json:"mastery_top3"`
+	RankedRecentCount  int                 `json:"ranked_recent_count"`
+	RankedRecentWins   int                 `json:"ranked_recent_wins"`
+	GamesAnalyzed      int                 `json:"games_analyzed"`
+	HistoryGames       int                 `json:"history_games,omitempty"` // of GamesAnalyzed, from backfill
+	MatchWindow        int                 `json:"match_window"`            // recent matches fetched; past MatchLimit when widened for MinGames
+	LatestPatch        string              `json:"latest_patch,omitempty"`  // newest patch among the player's matches
+	SkillInterval      Interval            `json:"skill_interval"`
+	LobbyRankSample    *LobbySampleReport  `json:"lobby_rank_sample,omitempty"`
+	BalanceScore       int                 `json:"balance_score,omitempty"`
+	Raw                *RawAggregates      `json:"raw,omitempty"`
+	ScoreOverride      *ScoreOverride      `json:"score_override,omitempty"`
+	Verified           bool                `json:"verified"` // Riot ID ownership proven
+	Participation      *Participation      `json:"participation,omitempty"`
+	// UncertainRating flags scores the frontend should label as uncertain;
+	// UncertainReasons says why (e.g. few community games).
+	UncertainRating  bool     `json:"uncertain_rating,omitempty"`
+	UncertainReasons []string `json:"uncertain_reasons,omitempty"`
+	// Proficiency scores the player's best champions (top 10), blending
+	// mastery with recent usage and winrate; MainChampions are its top 6.
+	Proficiency []ChampionProficiency `json:"champion_proficiency,omitempty"`
+	// Matches are the per-match summaries behind the profile, kept for export
+	// (GET /players/{riotId}/matches.jsonl) rather than sent with every result.
+	Matches []MatchSummary `json:"-"`
+	// RiotCalls is how many Riot requests building the profile took, reported
+	// in the analysis cost (see NewCostReport).
+	RiotCalls int64 `json:"-"`
+}
+
+// ScoreOverride records an organizer-approved score that replaced the computed one.
+type ScoreOverride struct {
+	Computed int    `json:"computed"`
+	Score    int    `json:"score"`
+	AppealID string `json:"appeal_id"`
+}
+
+// ApplyOverride replaces the skill score, moving the interval (and conservative
+// balance score) with it so the band keeps its width.
+func (p *Profile) ApplyOverride(score int, appealID string) {
+	delta := score - p.SkillScore
+	p.ScoreOverride = &ScoreOverride{Computed: p.SkillScore, Score: score, AppealID: appealID}
+	p.SkillScore = score
+	p.SkillInterval.Low += delta
+	p.SkillInterval.High += delta
+	if p.BalanceScore != 0 {
+		p.BalanceScore += delta
+	}
+}
+
+// BalancePlayer is the view the splitters optimize: the conservative lower bound
+// when requested, otherwise the skill score.
+func (p Profile) BalancePlayer(balanceOn string) balance.Player {
+	score := p.SkillScore
+	if balanceOn == BalanceOnConservative {
+		score = p.SkillInterval.Low
+	}
+	if p.LaneConfidence == LaneConfidenceLow {
+		// too few games to lock the player to a lane: any role, known lanes first
+		lanes := append(slices.Clone(p.MainLanes), p.MainSublanes...)
+		return balance.Player{Name: p.Name, Score: score, SubLanes: lanes, Flexible: true}
+	}
+	return balance.Player{Name: p.Name, Score: score, MainLanes: p.MainLanes, SubLanes: p.MainSublanes}
+}
@@ -0,0 +1,161 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"lol_custom_skill_matching/internal/balance"
+)
+
+// ErrCorruptSplit is returned instead of a split that failed validation.
+var ErrCorruptSplit = errors.New("team split failed validation")
+
+// SplitValidation is the server-side check of a split: every analyzed player is on
+// exactly one team, no account appears twice, and every role split gives each role
+// to at most one player per team, to exactly one past five players, whose
+// others share balance.Fill.
+type SplitValidation struct {
+	OK       bool     `json:"ok"`
+	Players  int      `json:"players"`
+	Assigned int      `json:"assigned"`
+	Errors   []string `json:"errors"`
+}
+
+func (v SplitValidation) Err() error {
+	if v.OK {
+		return nil
+	}
+	return fmt.Errorf("%w: %v", ErrCorruptSplit, v.Errors)
+}
+
+// identity is the PUUID when known, otherwise the Riot ID.
+func (p Profile) identity() string {
+	if p.PUUID != "" {
+		return p.PUUID
+	}
+	return p.Name
+}
+
+// ValidateSplit checks ts against the profiles it was built from.
+func ValidateSplit(profiles []Profile, ts TeamSplit) SplitValidation {
+	v := SplitValidation{Players: len(profiles), Errors: []string{}}
+	fail := func(format string, args ...any) { v.Errors = append(v.Errors, fmt.Sprintf(format, args...)) }
+
+	want := map[string]string{} // identity -> name
+	for _, p := range profiles {
+		if prev, ok := want[p.identity()]; ok {
+			fail("duplicate player: %s and %s are the same account", prev, p.Name)
+			continue
+		}
+		want[p.identity()] = p.Name
+	}
+
+	seen := map[string]string{} // identity -> team
+	for _, team := range []struct {
+		name    string
+		members []Profile
+	}{{"teamA", ts.TeamA}, {"teamB", ts.TeamB}} {
+		for _, p := range team.members {
+			id := p.identity()
+			if _, ok := want[id]; !ok {
+				fail("%s: %s was not in the analyzed players", team.name, p.Name)
+				continue
+			}
+			if t, ok := seen[id]; ok {
+				fail("%s: %s is already assigned to %s", team.name, p.Name, t)
+				continue
+			}
+			seen[id] = team.name
+			v.Assigned++
+		}
+	}
+	for id, name := range want {
+		if _, ok := seen[id]; !ok {
+			fail("%s is not assigned to a team", name)
+		}
+	}
+
+	if len(ts.Captains) == 2 {
+		validateCaptains(ts, fail)
+	}
+
+	names := map[string]struct{}{}
+	for _, p := range profiles {
+		names[p.Name] = struct{}{}
+	}
+	for key, rs := range map[string]*balance.RoleSplit{"lane_unique": ts.LaneUnique, "roles_first": ts.RolesFirst} {
+		if rs != nil {
+			validateRoleSplit(key, rs, names, fail)
+		}
+	}
+	v.OK = len(v.Errors) == 0
+	return v
+}
+
+// validateCaptains checks that the captains lead their teams, on their fixed
+// roles, in every split.
+func validateCaptains(ts TeamSplit, fail func(string, ...any)) {
+	for i, c := range ts.Captains {
+		team, members := "teamA", ts.TeamA
+		if i == 1 {
+			team, members = "teamB", ts.TeamB
+		}
+		if !slices.ContainsFunc(members, func(p Profile) bool { return p.Name == c.Player }) {
+			fail("captain %s is not on %s", c.Player, team)
+		}
+		for key, rs := range map[string]*balance.RoleSplit{"lane_unique": ts.LaneUnique, "roles_first": ts.RolesFirst} {
+			if rs == nil {
+				continue
+			}
+			slots := rs.TeamA
+			if i == 1 {
+				slots = rs.TeamB
+			}
+			j := slices.IndexFunc(slots, func(s balance.Slot) bool { return s.Name == c.Player })
+			switch {
+			case j < 0:
+				fail("%s: captain %s is not on %s", key, c.Player, team)
+			case c.Role != "" && slots[j].Role != c.Role:
+				fail("%s: captain %s plays %s instead of %s", key, c.Player, slots[j].Role, c.Role)
+			}
+		}
+	}
+}
+
+func validateRoleSplit(key string, rs *balance.RoleSplit, names map[string]struct{}, fail func(string, ...any)) {
+	placed := map[string]struct{}{}
+	if d := len(rs.TeamA) - len(rs.TeamB); d < 0 || d > 1 {
+		fail("%s: teams of %d and %d players", key, len(rs.TeamA), len(rs.TeamB))
+	}
+	for _, team := range []struct {
+		name  string
+		slots []balance.Slot
+	}{{"teamA", rs.TeamA}, {"teamB", rs.TeamB}} {
+		roles := map[string]int{}
+		for _, s := range team.slots {
+			roles[s.Role]++
+			if _, ok := names[s.Name]; !ok {
+				fail("%s.%s: %s was not in the analyzed players", key, team.name, s.Name)
+			}
+			if _, ok := placed[s.Name]; ok {
+				fail("%s.%s: %s is placed twice", key, team.name, s.Name)
+			}
+			placed[s.Name] = struct{}{}
+		}
+		full := len(team.slots) >= len(balance.Roles)
+		for _, role := range balance.Roles {
+			if roles[role] > 1 || full && roles[role] == 0 {
+				fail("%s.%s: role %s appears %d times", key, team.name, role, roles[role])
+			}
+			delete(roles, role)
+		}
+		if fill := max(0, len(team.slots)-len(balance.Roles)); roles[balance.Fill] != fill {
+			fail("%s.%s: role %s appears %d times", key, team.name, balance.Fill, roles[balance.Fill])
+		}
+		delete(roles, balance.Fill)
+		for role := range roles {
+			fail("%s.%s: unknown role %q", key, team.name, role)
+		}
+	}
+}
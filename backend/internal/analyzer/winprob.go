@@ -0,0 +1,53 @@
+package analyzer
+
+import "math"
+
+// DefaultWinScale is the team score difference that multiplies the stronger
+// team's odds by e in WinProbability: a 150-point difference is 52/48, one
+// division more for every player (1500 points) about 69/31. The calibrate
+// command fits it to recorded outcomes.
+const DefaultWinScale = 1875.0
+
+// Candidate splits a WinPrediction is for.
+const (
+	PredictionTeams      = "teams" // teamA/teamB
+	PredictionLaneUnique = "lane_unique"
+	PredictionRolesFirst = "roles_first"
+)
+
+// WinPrediction is the predicted outcome of one candidate split.
+type WinPrediction struct {
+	Split      string  `json:"split"`
+	BlueWinPct float64 `json:"blue_win_pct"`
+	RedWinPct  float64 `json:"red_win_pct"`
+	ScoreDiff  int     `json:"score_diff"` // blue minus red
+}
+
+// WinProbability is the chance that a team whose score sum exceeds the other's
+// by diff wins, a logistic function of diff over scale (0 = DefaultWinScale).
+func WinProbability(diff int, scale float64) float64 {
+	if scale <= 0 {
+		scale = DefaultWinScale
+	}
+	return 1 / (1 + math.Exp(-float64(diff)/scale))
+}
+
+// PredictWins fills ts.WinPredictions for each candidate split. Call it after
+// AssignSides: team A plays the side in ts.Teams[0].
+func PredictWins(ts *TeamSplit, scale float64) {
+	predict := func(split string, sumA, sumB int) WinPrediction {
+		diff := sumA - sumB
+		if ts.Teams[0].Side == SideRed {
+			diff = -diff
+		}
+		blue := math.Round(WinProbability(diff, scale)*1000) / 10
+		return WinPrediction{Split: split, BlueWinPct: blue, RedWinPct: math.Round((100-blue)*10) / 10, ScoreDiff: diff}
+	}
+	ts.WinPredictions = []WinPrediction{predict(PredictionTeams, ts.SumA, ts.SumB)}
+	if rs := ts.LaneUnique; rs != nil {
+		ts.WinPredictions = append(ts.WinPredictions, predict(PredictionLaneUnique, rs.SumA, rs.SumB))
+	}
+	if rs := ts.RolesFirst; rs != nil {
+		ts.WinPredictions = append(ts.WinPredictions, predict(PredictionRolesFirst, rs.SumA, rs.SumB))
+	}
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"lol_custom_skill_matching/internal/events"
+	"lol_custom_skill_matching/internal/riot"
+	"lol_custom_skill_matching/internal/signing"
+)
+
+// postAlert sends text to a chat webhook. The body carries it as both
+// "content" (Discord) and "text" (Slack and compatibles), signed by signer
+// when there is one; failures are logged.
+func postAlert(url, text string, signer *signing.Signer) {
+	b, _ := json.Marshal(map[string]string{"content": text, "text": text})
+	client := &http.Client{Timeout: 10 * time.Second}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		log.Printf("alert webhook: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	signer.SignRequest(req, b)
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Printf("alert webhook: %v", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Printf("alert webhook: HTTP %d", resp.StatusCode)
+	}
+}
+
+// sloEvent is the events.RiotDegraded or RiotRecovered event for a Riot
+// endpoint that broke or is back within its SLO.
+func sloEvent(st riot.EndpointSLO) events.Event {
+	ev := events.Event{
+		Type: events.RiotRecovered, Subject: st.Endpoint,
+		Text: fmt.Sprintf("Riot %s API is back within its SLO (p95 %dms, %.0f%% errors)", st.Endpoint, st.P95Ms, st.ErrorRate*100),
+		Data: events.RiotData{Endpoint: st.Endpoint, Requests: st.Requests, ErrorRate: st.ErrorRate, P95Ms: st.P95Ms},
+	}
+	if st.Degraded {
+		ev.Type = events.RiotDegraded
+		ev.Text = fmt.Sprintf("Riot %s API is degraded: p95 %dms, %.0f%% errors over %d requests; analyses will be slow, consider postponing them",
+			st.Endpoint, st.P95Ms, st.ErrorRate*100, st.Requests)
+	}
+	return ev
+}
@@ -0,0 +1,872 @@
+// Package app wires the server components together from a Config.
+package app
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/backfill"
+	"lol_custom_skill_matching/internal/backup"
+	"lol_custom_skill_matching/internal/digest"
+	"lol_custom_skill_matching/internal/discord"
+	"lol_custom_skill_matching/internal/events"
+	"lol_custom_skill_matching/internal/httpapi"
+	"lol_custom_skill_matching/internal/memwatch"
+	"lol_custom_skill_matching/internal/paths"
+	"lol_custom_skill_matching/internal/rankalert"
+	"lol_custom_skill_matching/internal/resultfile"
+	"lol_custom_skill_matching/internal/retention"
+	"lol_custom_skill_matching/internal/riot"
+	"lol_custom_skill_matching/internal/secrets"
+	"lol_custom_skill_matching/internal/signing"
+	"lol_custom_skill_matching/internal/snapshot"
+	"lol_custom_skill_matching/internal/staticdata"
+	"lol_custom_skill_matching/internal/store"
+)
+
+// Config is everything needed to build the server.
+type Config struct {
+	APIKey      string
+	Port        string
+	MatchLimit  int    // default analyzed matches per player
+	ResultDir   string // one copy of each analyze result per file ("" disables)
+	RankWorkers int    // participant rank lookup pool size
+	SkipOnLimit bool   // give up on 429/5xx instead of retrying (SKIP=true)
+	RiotBurst   int    // requests allowed back to back before steady pacing
+	// MatchWorkers is the pool size of each player's match detail fetches.
+	MatchWorkers int
+	// LeagueCacheSize and LeagueCacheTTL bound the analyzer's cache of ranked
+	// entries by puuid (TTL 0 = none).
+	LeagueCacheSize int
+	LeagueCacheTTL  time.Duration
+	// ResultRetention prunes ResultDir by age, file count and total size.
+	ResultRetention resultfile.Retention
+	// ChampionCache keeps the last good champion.json for CDN outages ("" disables).
+	ChampionCache string
+	// LimiterStateFile carries the rate limiter's state across restarts so
+	// a new process doesn't burst into Riot's window ("" disables).
+	LimiterStateFile string
+	// MatchStoreFile persists analyzed/backfilled match summaries ("" = memory only).
+	MatchStoreFile string
+	// BackfillInterval spaces background history requests; BackfillSince is the
+	// oldest match the backfill fetches (zero = start of the current year).
+	BackfillInterval time.Duration
+	BackfillSince    time.Time
+	// OrganizerToken guards organizer endpoints such as appeal review ("" = open).
+	OrganizerToken string
+	// AllowedOrigins are the browser origins (e.g. "https://lol.example.com")
+	// other than the server's own that may call the API and open GET /ws;
+	// "*" allows any (none = same origin only for GET /ws, any for CORS).
+	AllowedOrigins []string
+	// ScoreFormula replaces the built-in skill score formula ("" = built-in); see
+	// analyzer.ScoreFeatures for the names it can use.
+	ScoreFormula string
+	// StoreDriver selects where server state lives: "memory" (default), "sqlite"
+	// or "postgres"; StoreDSN is the SQLite file or Postgres URL.
+	StoreDriver string
+	StoreDSN    string
+	// TenantWeights turns on fair sharing of the Riot quota between the
+	// communities named by the X-Tenant header; unlisted tenants weigh 1
+	// (nil = one shared queue).
+	TenantWeights map[string]float64
+	// Platform is the shard (jp1 when "") and Region its regional routing
+	// (the platform's when "") of players whose request names none.
+	Platform string
+	Region   string
+	// ProbePlatforms are the shards searched for accounts with no data on
+	// Platform (nil = riot.DefaultProbeOrder, empty = no probing).
+	ProbePlatforms []string
+	// BreakerThreshold failed Riot requests in a row switch analyses to stored
+	// profiles until a trial request every BreakerCooldown succeeds (0 = never).
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	// SLOWindow is the rolling window over which each Riot endpoint's latency
+	// and error rate are tracked; match-v5 is degraded, and alerted on, while
+	// its p95 passes SLOP95 or its error rate SLOErrorRate (see riot.SLO).
+	SLOWindow    time.Duration
+	SLOP95       time.Duration
+	SLOErrorRate float64
+	// RiotCache is where Riot answers are cached: "" = the store's database
+	// with a SQL store, else memory; "memory"; or "none". RiotCacheTTLs
+	// overrides riot.DefaultCacheTTLs per endpoint (0 = don't cache it).
+	RiotCache     string
+	RiotCacheTTLs map[string]time.Duration
+	// MatchCache is a SQLite file keeping match details for good, ahead of
+	// RiotCache ("" = none; needs the sqlite build tag).
+	MatchCache string
+	// WinScale shapes predicted win chances (0 = analyzer.DefaultWinScale).
+	WinScale float64
+	// PlayerTimeout bounds the analysis of each player (0 = none); see
+	// analyzer.Analyzer.PlayerTimeout.
+	PlayerTimeout time.Duration
+	// AlertWebhook receives a JSON post (Discord/Slack style) when Riot
+	// starts rejecting the API key or match-v5 breaks or is back within its
+	// SLO ("" = log only); "secret:<name>" reads the URL from the secrets
+	// vault.
+	AlertWebhook string
+	// EventWebhook receives a JSON post (Discord/Slack style) for each event
+	// of EventWebhookTypes on the event bus ("" = none); "secret:<name>"
+	// reads the URL from the secrets vault. EventWebhookTypes is a
+	// comma-separated list of events.Types ("" = all).
+	EventWebhook      string
+	EventWebhookTypes string
+	// AuditLog is a file every event on the bus is appended to as a line of
+	// JSON ("" = none).
+	AuditLog string
+	// ReusePort opens the port with SO_REUSEPORT so a new process can take
+	// over (SIGHUP reload) while this one drains.
+	ReusePort bool
+	// DrainDelay keeps serving with /readyz at 503 before refusing new
+	// connections, so load balancers notice; ShutdownTimeout then bounds how
+	// long requests in flight may take to finish.
+	DrainDelay      time.Duration
+	ShutdownTimeout time.Duration
+	// JobCheckpointFile receives the analyze jobs still running when
+	// ShutdownTimeout is up; the next start finishes them ("" = they are lost).
+	JobCheckpointFile string
+	// DemoMode serves the public demo (canned analyses of a sample roster)
+	// with no Riot key, store or result files; DemoRateLimit caps each
+	// client's demo analyses per minute (0 = unlimited).
+	DemoMode      bool
+	DemoRateLimit int
+	// SnapshotDir keeps the nightly community snapshots ("" = memory only);
+	// SnapshotTime is when they are taken (time since local midnight).
+	SnapshotDir  string
+	SnapshotTime time.Duration
+	// DigestConfig is a JSON file of the communities that get a Discord
+	// digest of each nightly snapshot ("" = none); see digest.Community.
+	DigestConfig string
+	// DiscordBotToken lets the bot read the reactions that answer lobby RSVPs
+	// every DiscordRSVPInterval ("" = none); "secret:<name>" reads it from
+	// the vault.
+	DiscordBotToken     string
+	DiscordRSVPInterval time.Duration
+	// DiscordPublicKey is the bot application's public key (hex), enabling
+	// its slash commands on POST /discord/interactions ("" = none); with
+	// DiscordBotToken they are registered at start.
+	DiscordPublicKey string
+	// RankAlertInterval is how often the ranks of players subscribed to rank
+	// alerts are refreshed (0 = never).
+	RankAlertInterval time.Duration
+	// Retention is how long match summaries and results are stored, pruned
+	// every PruneInterval.
+	Retention     store.Retention
+	PruneInterval time.Duration
+	// SecretsKey is the master key encrypting SecretsFile, the vault of
+	// webhook URLs and tokens referenced as "secret:<name>" ("" = no vault);
+	// SecretsPreviousKey, set after a rotation, is re-encrypted away at start.
+	SecretsKey         string
+	SecretsPreviousKey string
+	SecretsFile        string
+	// SigningKey is the secret the Ed25519 key signing result JSON and
+	// webhook posts is derived from ("" = unsigned); "secret:<name>" reads it
+	// from the vault.
+	SigningKey string
+	// CallerKeys lets requests bring their own Riot key in X-Riot-Key, each
+	// paced by a limiter of its own (only for instances whose users are trusted).
+	CallerKeys bool
+	// Backpressure refuses analyze jobs and backfills with 503 once their
+	// queue holds QueueMaxDepth jobs or a new one would wait over QueueMaxWait.
+	Backpressure httpapi.Backpressure
+	// MemoryLimit is the memory the server keeps within, in bytes: the caches
+	// are flushed when the heap nears it (0 = unwatched; the container's
+	// limit by default).
+	MemoryLimit uint64
+}
+
+// snapshotRetention is how many nightly snapshots SnapshotDir keeps.
+const snapshotRetention = 90
+
+// ConfigFromEnv reads RIOT_API_KEY, PORT, MATCH_LIMIT, RESULT_DIR, RESULT_MAX_AGE,
+// RESULT_MAX_FILES, RESULT_MAX_MB, RANK_WORKERS, MATCH_WORKERS, LEAGUE_CACHE_SIZE, LEAGUE_CACHE_TTL, RIOT_BURST,
+// CHAMPION_CACHE, LIMITER_STATE_FILE, MATCH_STORE_FILE, BACKFILL_INTERVAL, BACKFILL_SINCE, ORGANIZER_TOKEN, ALLOWED_ORIGINS,
+// SCORE_FORMULA, STORE_DRIVER, STORE_DSN, TENANT_WEIGHTS, RIOT_PLATFORM, RIOT_REGION, PROBE_PLATFORMS, RIOT_BREAKER_THRESHOLD,
+// RIOT_BREAKER_COOLDOWN, RIOT_SLO_WINDOW, RIOT_SLO_P95, RIOT_SLO_ERROR_RATE, RIOT_CACHE, RIOT_CACHE_TTLS, MATCH_CACHE, WIN_PROB_SCALE,
+// ALERT_WEBHOOK_URL, EVENT_WEBHOOK_URL, EVENT_WEBHOOK_EVENTS, AUDIT_LOG, REUSE_PORT, DRAIN_DELAY, SHUTDOWN_TIMEOUT, JOB_CHECKPOINT_FILE, DEMO_MODE,
+// DEMO_RATE_LIMIT, SNAPSHOT_DIR, SNAPSHOT_TIME, DIGEST_CONFIG, DISCORD_BOT_TOKEN, DISCORD_RSVP_INTERVAL, DISCORD_PUBLIC_KEY,
+// RANK_ALERT_INTERVAL, MATCH_RETENTION_DAYS, RESULT_RETENTION_SEASONS, PRUNE_INTERVAL, SECRETS_KEY,
+// SECRETS_PREVIOUS_KEY, SECRETS_FILE, RESULT_SIGNING_KEY, RIOT_KEY_HEADER,
+// QUEUE_MAX_DEPTH, QUEUE_MAX_WAIT, MEMORY_LIMIT_MB and SKIP.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		APIKey:      os.Getenv("RIOT_API_KEY"),
+		Port:        os.Getenv("PORT"),
+		MatchLimit:  10,
+		ResultDir:   os.Getenv("RESULT_DIR"),
+		RankWorkers: riot.DefaultWorkers,
+		SkipOnLimit: os.Getenv("SKIP") == "true",
+		RiotBurst:   riot.DefaultLimiterConfig().Burst,
+
+		LeagueCacheSize: analyzer.DefaultLeagueCacheSize,
+		LeagueCacheTTL:  analyzer.DefaultLeagueCacheTTL,
+
+		ChampionCache:    os.Getenv("CHAMPION_CACHE"),
+		LimiterStateFile: os.Getenv("LIMITER_STATE_FILE"),
+
+		MatchStoreFile:   os.Getenv("MATCH_STORE_FILE"),
+		BackfillInterval: 3 * time.Second,
+		OrganizerToken:   os.Getenv("ORGANIZER_TOKEN"),
+		ScoreFormula:     os.Getenv("SCORE_FORMULA"),
+		StoreDriver:      os.Getenv("STORE_DRIVER"),
+		StoreDSN:         os.Getenv("STORE_DSN"),
+		BreakerThreshold: 5,
+		BreakerCooldown:  time.Minute,
+		SLOWindow:        riot.DefaultSLOWindow,
+		SLOP95:           riot.DefaultSLOP95,
+		SLOErrorRate:     riot.DefaultSLOErrorRate,
+		RiotCache:        os.Getenv("RIOT_CACHE"),
+		MatchCache:       os.Getenv("MATCH_CACHE"),
+		AlertWebhook:     os.Getenv("ALERT_WEBHOOK_URL"),
+		EventWebhook:     os.Getenv("EVENT_WEBHOOK_URL"),
+		AuditLog:         os.Getenv("AUDIT_LOG"),
+		ResultRetention:  resultfile.Retention{MaxAge: 30 * 24 * time.Hour, MaxFiles: 1000, MaxBytes: 200 << 20},
+		ReusePort:        os.Getenv("REUSE_PORT") == "true",
+		DrainDelay:       2 * time.Second,
+		ShutdownTimeout:  5 * time.Minute,
+		DemoMode:         os.Getenv("DEMO_MODE") == "true",
+		DemoRateLimit:    10,
+		SnapshotDir:      os.Getenv("SNAPSHOT_DIR"),
+		SnapshotTime:     snapshot.DefaultTime,
+		DigestConfig:     os.Getenv("DIGEST_CONFIG"),
+		DiscordBotToken:  os.Getenv("DISCORD_BOT_TOKEN"),
+		DiscordPublicKey: os.Getenv("DISCORD_PUBLIC_KEY"),
+		Retention:        store.Retention{Matches: 90 * 24 * time.Hour, ResultSeasons: 2},
+		PruneInterval:    retention.DefaultInterval,
+
+		DiscordRSVPInterval: discord.DefaultInterval,
+		RankAlertInterval:   rankalert.DefaultInterval,
+
+		SecretsKey:         os.Getenv("SECRETS_KEY"),
+		SecretsPreviousKey: os.Getenv("SECRETS_PREVIOUS_KEY"),
+		SecretsFile:        os.Getenv("SECRETS_FILE"),
+		JobCheckpointFile:  os.Getenv("JOB_CHECKPOINT_FILE"),
+		SigningKey:         os.Getenv("RESULT_SIGNING_KEY"),
+		CallerKeys:         os.Getenv("RIOT_KEY_HEADER") == "true",
+		Backpressure:       httpapi.Backpressure{MaxWait: time.Hour},
+		MemoryLimit:        memwatch.ContainerLimit(),
+	}
+	if cfg.Port == "" {
+		cfg.Port = "8080"
+	}
+	cfg.EventWebhookTypes = os.Getenv("EVENT_WEBHOOK_EVENTS")
+	if cfg.EventWebhookTypes == "" {
+		cfg.EventWebhookTypes = strings.Join([]string{events.JobFinished, events.ResultRecorded, events.RankChanged, events.RiotDegraded, events.RiotRecovered}, ",")
+	}
+	if cfg.ChampionCache == "" {
+		cfg.ChampionCache = paths.CacheFile("champion_cache.json")
+	}
+	switch cfg.LimiterStateFile {
+	case "":
+		cfg.LimiterStateFile = paths.CacheFile("limiter_state.json")
+	case "none":
+		cfg.LimiterStateFile = ""
+	}
+	switch cfg.MatchCache {
+	case "":
+		if store.HasDriver(store.DriverSQLite) {
+			cfg.MatchCache = paths.CacheFile("match_cache.db")
+		}
+	case "none":
+		cfg.MatchCache = ""
+	}
+	if n, err := strconv.Atoi(os.Getenv("MATCH_LIMIT")); err == nil && n > 0 {
+		cfg.MatchLimit = n
+	}
+	switch cfg.ResultDir {
+	case "":
+		cfg.ResultDir = paths.DataFile("results")
+	case "none":
+		cfg.ResultDir = ""
+	}
+	switch cfg.JobCheckpointFile {
+	case "":
+		cfg.JobCheckpointFile = paths.DataFile("analyze_jobs.json")
+	case "none":
+		cfg.JobCheckpointFile = ""
+	}
+	switch cfg.SnapshotDir {
+	case "":
+		cfg.SnapshotDir = paths.DataFile("snapshots")
+	case "none":
+		cfg.SnapshotDir = ""
+	}
+	if t, err := time.Parse("15:04", os.Getenv("SNAPSHOT_TIME")); err == nil {
+		cfg.SnapshotTime = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	}
+	if os.Getenv("RESULT_FILE") != "" {
+		log.Printf("RESULT_FILE is no longer used: results are written to %s/<result id>.json (RESULT_DIR)", cfg.ResultDir)
+	}
+	if d, err := time.ParseDuration(os.Getenv("RESULT_MAX_AGE")); err == nil && d >= 0 {
+		cfg.ResultRetention.MaxAge = d
+	}
+	if n, err := strconv.Atoi(os.Getenv("RESULT_MAX_FILES")); err == nil && n >= 0 {
+		cfg.ResultRetention.MaxFiles = n
+	}
+	if n, err := strconv.ParseInt(os.Getenv("RESULT_MAX_MB"), 10, 64); err == nil && n >= 0 {
+		cfg.ResultRetention.MaxBytes = n << 20
+	}
+	if n, err := strconv.Atoi(os.Getenv("RANK_WORKERS")); err == nil && n > 0 {
+		cfg.RankWorkers = n
+	}
+	cfg.MatchWorkers = cfg.RankWorkers
+	if n, err := strconv.Atoi(os.Getenv("MATCH_WORKERS")); err == nil && n > 0 {
+		cfg.MatchWorkers = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("LEAGUE_CACHE_SIZE")); err == nil && n > 0 {
+		cfg.LeagueCacheSize = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("LEAGUE_CACHE_TTL")); err == nil && d >= 0 {
+		cfg.LeagueCacheTTL = d
+	}
+	if n, err := strconv.Atoi(os.Getenv("RIOT_BURST")); err == nil && n > 0 {
+		cfg.RiotBurst = n
+	}
+	if cfg.MatchStoreFile == "" {
+		cfg.MatchStoreFile = paths.DataFile("match_history.json")
+	}
+	if cfg.SecretsFile == "" {
+		cfg.SecretsFile = paths.DataFile("secrets.json")
+	}
+	if cfg.StoreDriver == store.DriverSQLite && cfg.StoreDSN == "" {
+		cfg.StoreDSN = paths.DataFile("store.db")
+	}
+	if d, err := time.ParseDuration(os.Getenv("BACKFILL_INTERVAL")); err == nil && d > 0 {
+		cfg.BackfillInterval = d
+	}
+	if t, err := time.Parse("2006-01-02", os.Getenv("BACKFILL_SINCE")); err == nil {
+		cfg.BackfillSince = t
+	}
+	if n, err := strconv.Atoi(os.Getenv("RIOT_BREAKER_THRESHOLD")); err == nil && n >= 0 {
+		cfg.BreakerThreshold = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("RIOT_BREAKER_COOLDOWN")); err == nil && d > 0 {
+		cfg.BreakerCooldown = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("RIOT_SLO_WINDOW")); err == nil && d > 0 {
+		cfg.SLOWindow = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("RIOT_SLO_P95")); err == nil && d > 0 {
+		cfg.SLOP95 = d
+	}
+	if f, err := strconv.ParseFloat(os.Getenv("RIOT_SLO_ERROR_RATE"), 64); err == nil && f >= 0 && f <= 1 {
+		cfg.SLOErrorRate = f
+	}
+	if f, err := strconv.ParseFloat(os.Getenv("WIN_PROB_SCALE"), 64); err == nil && f > 0 {
+		cfg.WinScale = f
+	}
+	if d, err := time.ParseDuration(os.Getenv("PLAYER_TIMEOUT")); err == nil && d >= 0 {
+		cfg.PlayerTimeout = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("DRAIN_DELAY")); err == nil && d >= 0 {
+		cfg.DrainDelay = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("SHUTDOWN_TIMEOUT")); err == nil && d > 0 {
+		cfg.ShutdownTimeout = d
+	}
+	if n, err := strconv.Atoi(os.Getenv("DEMO_RATE_LIMIT")); err == nil && n >= 0 {
+		cfg.DemoRateLimit = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("MATCH_RETENTION_DAYS")); err == nil && n >= 0 {
+		cfg.Retention.Matches = time.Duration(n) * 24 * time.Hour
+	}
+	if n, err := strconv.Atoi(os.Getenv("RESULT_RETENTION_SEASONS")); err == nil && n >= 0 {
+		cfg.Retention.ResultSeasons = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("PRUNE_INTERVAL")); err == nil && d > 0 {
+		cfg.PruneInterval = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("DISCORD_RSVP_INTERVAL")); err == nil && d > 0 {
+		cfg.DiscordRSVPInterval = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("RANK_ALERT_INTERVAL")); err == nil && d >= 0 {
+		cfg.RankAlertInterval = d
+	}
+	if n, err := strconv.Atoi(os.Getenv("QUEUE_MAX_DEPTH")); err == nil && n >= 0 {
+		cfg.Backpressure.MaxDepth = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("QUEUE_MAX_WAIT")); err == nil && d >= 0 {
+		cfg.Backpressure.MaxWait = d
+	}
+	if n, err := strconv.ParseUint(os.Getenv("MEMORY_LIMIT_MB"), 10, 64); err == nil {
+		cfg.MemoryLimit = n << 20
+	}
+	cfg.TenantWeights = parseWeights(os.Getenv("TENANT_WEIGHTS"))
+	cfg.RiotCacheTTLs = parseCacheTTLs(os.Getenv("RIOT_CACHE_TTLS"))
+	cfg.Platform = strings.ToLower(strings.TrimSpace(os.Getenv("RIOT_PLATFORM")))
+	cfg.Region = strings.ToLower(strings.TrimSpace(os.Getenv("RIOT_REGION")))
+	switch v := strings.TrimSpace(os.Getenv("PROBE_PLATFORMS")); v {
+	case "":
+	case "none":
+		cfg.ProbePlatforms = []string{}
+	default:
+		for _, id := range strings.Split(v, ",") {
+			if id = strings.ToLower(strings.TrimSpace(id)); id != "" {
+				cfg.ProbePlatforms = append(cfg.ProbePlatforms, id)
+			}
+		}
+	}
+	for _, o := range strings.Split(os.Getenv("ALLOWED_ORIGINS"), ",") {
+		if o = strings.TrimRight(strings.TrimSpace(o), "/"); o != "" {
+			cfg.AllowedOrigins = append(cfg.AllowedOrigins, o)
+		}
+	}
+	return cfg
+}
+
+// parseWeights reads "name=weight,name=weight"; malformed entries are skipped.
+func parseWeights(s string) map[string]float64 {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	out := map[string]float64{}
+	for _, kv := range strings.Split(s, ",") {
+		name, w, ok := strings.Cut(kv, "=")
+		name = strings.TrimSpace(name)
+		f, err := strconv.ParseFloat(strings.TrimSpace(w), 64)
+		if !ok || name == "" || err != nil || f <= 0 {
+			log.Printf("TENANT_WEIGHTS: ignoring %q", kv)
+			continue
+		}
+		out[name] = f
+	}
+	return out
+}
+
+// parseCacheTTLs reads "endpoint=duration,..." over riot.DefaultCacheTTLs
+// (nil when s is empty); malformed entries are skipped.
+func parseCacheTTLs(s string) map[string]time.Duration {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	out := maps.Clone(riot.DefaultCacheTTLs)
+	for _, kv := range strings.Split(s, ",") {
+		name, v, ok := strings.Cut(kv, "=")
+		name = strings.TrimSpace(name)
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if !ok || name == "" || err != nil || d < 0 {
+			log.Printf("RIOT_CACHE_TTLS: ignoring %q", kv)
+			continue
+		}
+		out[name] = d
+	}
+	return out
+}
+
+// riotCache builds the response cache RiotCache selects for st (nil = none).
+func (cfg Config) riotCache(st store.Store) (riot.ResponseCache, error) {
+	switch cfg.RiotCache {
+	case "":
+		if s, ok := st.(*store.SQL); ok {
+			return s.RiotCache()
+		}
+		return riot.NewMemoryCache(), nil
+	case "memory":
+		return riot.NewMemoryCache(), nil
+	case "none":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("RIOT_CACHE: unknown cache %q (want memory or none)", cfg.RiotCache)
+}
+
+// openStore opens the configured store and merges the match file into it.
+func (cfg Config) openStore() (store.Store, error) {
+	st, err := store.Open(cfg.StoreDriver, cfg.StoreDSN)
+	if err != nil {
+		return nil, fmt.Errorf("opening store: %w", err)
+	}
+	if cfg.MatchStoreFile != "" {
+		if err := st.LoadMatches(cfg.MatchStoreFile); err != nil {
+			return nil, fmt.Errorf("loading %s: %w", cfg.MatchStoreFile, err)
+		}
+	}
+	return st, nil
+}
+
+// cacheFiles are the files besides the store that go into a backup. The
+// secrets stay encrypted in it; restoring them needs the same SECRETS_KEY.
+func (cfg Config) cacheFiles() map[string]string {
+	return map[string]string{"champion_cache.json": cfg.ChampionCache, "secrets.json": cfg.SecretsFile}
+}
+
+// openVault opens the secrets vault (nil without SECRETS_KEY).
+func (cfg Config) openVault() (*secrets.Vault, error) {
+	if cfg.SecretsKey == "" {
+		return nil, nil
+	}
+	key, err := secrets.ParseKey(cfg.SecretsKey)
+	if err != nil {
+		return nil, fmt.Errorf("SECRETS_KEY: %w", err)
+	}
+	var previous [][]byte
+	if cfg.SecretsPreviousKey != "" {
+		prev, err := secrets.ParseKey(cfg.SecretsPreviousKey)
+		if err != nil {
+			return nil, fmt.Errorf("SECRETS_PREVIOUS_KEY: %w", err)
+		}
+		previous = append(previous, prev)
+	}
+	v, err := secrets.Open(cfg.SecretsFile, key, previous...)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", cfg.SecretsFile, err)
+	}
+	return v, nil
+}
+
+// checkSecretRef fails on a "secret:" value when there is no vault to read
+// it from; a missing secret is only logged, it can be set once running.
+func checkSecretRef(what, value string, vault *secrets.Vault) error {
+	if !strings.HasPrefix(value, secrets.RefPrefix) {
+		return nil
+	}
+	if vault == nil {
+		return fmt.Errorf("%s: %q needs SECRETS_KEY", what, value)
+	}
+	if _, err := vault.Resolve(value); err != nil {
+		log.Printf("%s: %v (set it with PUT /admin/secrets/{name})", what, err)
+	}
+	return nil
+}
+
+// alertTypes are the events the alert webhook (ALERT_WEBHOOK_URL) posts:
+// the operator has to act on them.
+var alertTypes = []string{events.KeyRejected, events.RiotDegraded, events.RiotRecovered}
+
+// eventBus builds the event bus with the subscribers cfg turns on: the
+// alert and event webhooks and the audit log.
+func (cfg Config) eventBus(vault *secrets.Vault, signer *signing.Signer) (*events.Bus, error) {
+	bus := events.NewBus()
+	if cfg.AlertWebhook != "" {
+		bus.Subscribe("alert", func(ev events.Event) {
+			url, err := vault.Resolve(cfg.AlertWebhook)
+			if err != nil {
+				log.Printf("alert webhook: %v", err)
+				return
+			}
+			postAlert(url, "LoL custom matching: "+ev.Text, signer)
+		}, alertTypes...)
+	}
+	if cfg.EventWebhook != "" {
+		types, err := events.ParseTypes(cfg.EventWebhookTypes)
+		if err != nil {
+			return nil, fmt.Errorf("EVENT_WEBHOOK_EVENTS: %w", err)
+		}
+		n := events.NewNotifier(cfg.EventWebhook)
+		n.Resolve, n.Signer = vault.Resolve, signer
+		bus.Subscribe("webhook", n.Handle, types...)
+	}
+	if cfg.AuditLog != "" {
+		audit, err := events.OpenAuditLog(cfg.AuditLog)
+		if err != nil {
+			return nil, fmt.Errorf("AUDIT_LOG: %w", err)
+		}
+		bus.Subscribe("audit", audit.Handle)
+	}
+	return bus, nil
+}
+
+// App is the assembled server.
+type App struct {
+	Config   Config
+	Riot     *riot.Client
+	Analyzer *analyzer.Analyzer
+	Store    store.Store
+	Backfill *backfill.Worker
+	// Snapshots takes the nightly community snapshot.
+	Snapshots *snapshot.Scheduler
+	// Pruner applies Config.Retention.
+	Pruner *retention.Pruner
+	// RSVPSync reads lobby RSVPs from Discord reactions.
+	RSVPSync *discord.RSVPSync
+	// RankAlerts refreshes subscribed ranks and alerts their players.
+	RankAlerts *rankalert.Refresher
+	// Events is the event bus the server's side effects subscribe to.
+	Events *events.Bus
+	// Memory flushes the caches when the heap nears Config.MemoryLimit.
+	Memory *memwatch.Watchdog
+	// Static prefetches champions, queues and rank images.
+	Static *staticdata.Loader
+	HTTP   *httpapi.Server
+	// HandedOver, on a SIGHUP reload, is closed once the process this one
+	// replaces has drained and saved its unfinished jobs (or exited): Serve
+	// resumes them only then (nil = nothing to wait for).
+	HandedOver <-chan struct{}
+	// Drained, when set, is called once Serve has saved the unfinished jobs,
+	// to let the successor resume them.
+	Drained func()
+}
+
+// New builds the Riot client, analyzer, store and HTTP layer from cfg.
+func New(cfg Config) (*App, error) {
+	if cfg.DemoMode {
+		// nothing may reach Riot or outlive the process
+		cfg.StoreDriver, cfg.MatchStoreFile, cfg.ResultDir, cfg.RiotCache = store.DriverMemory, "", "", "none"
+		cfg.SnapshotDir, cfg.DigestConfig, cfg.SecretsKey, cfg.AlertWebhook, cfg.SigningKey = "", "", "", "", ""
+		cfg.EventWebhook, cfg.AuditLog = "", ""
+		cfg.DiscordBotToken, cfg.DiscordPublicKey, cfg.RankAlertInterval = "", "", 0
+		cfg.LimiterStateFile, cfg.MatchCache, cfg.JobCheckpointFile = "", "", ""
+		log.Printf("demo mode: serving the sample roster, %d analyses per client and minute", cfg.DemoRateLimit)
+	} else if cfg.APIKey == "" {
+		return nil, errors.New("RIOT_API_KEY is required for the web API server")
+	}
+	vault, err := cfg.openVault()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSecretRef("ALERT_WEBHOOK_URL", cfg.AlertWebhook, vault); err != nil {
+		return nil, err
+	}
+	if err := checkSecretRef("EVENT_WEBHOOK_URL", cfg.EventWebhook, vault); err != nil {
+		return nil, err
+	}
+	var signer *signing.Signer
+	if cfg.SigningKey != "" {
+		// unlike webhooks the key is needed right away, so it must resolve now
+		key, err := vault.Resolve(cfg.SigningKey)
+		if err != nil {
+			return nil, fmt.Errorf("RESULT_SIGNING_KEY: %w", err)
+		}
+		signer = signing.New([]byte(key))
+	}
+	lc := riot.DefaultLimiterConfig()
+	lc.Burst = cfg.RiotBurst
+	rc := riot.NewClient(cfg.APIKey, riot.NewLimiterWithConfig(lc))
+	if cfg.LimiterStateFile != "" {
+		// a stale or unreadable state only costs the warm start
+		if err := riot.LoadLimiterState(rc.Limiter, cfg.LimiterStateFile); err != nil {
+			log.Printf("limiter state %s: %v", cfg.LimiterStateFile, err)
+		}
+	}
+	rc.SkipOnLimit = cfg.SkipOnLimit
+	if cfg.Platform != "" || cfg.Region != "" {
+		platform := cfg.Platform
+		if platform == "" {
+			platform = riot.DefaultPlatform
+		}
+		home, err := riot.LookupPlatform(platform, cfg.Region)
+		if err != nil {
+			return nil, fmt.Errorf("RIOT_PLATFORM/RIOT_REGION: %w", err)
+		}
+		rc.SetHome(home)
+	}
+	if cfg.ProbePlatforms != nil {
+		for _, id := range cfg.ProbePlatforms {
+			if _, ok := riot.Platforms[id]; !ok {
+				return nil, fmt.Errorf("PROBE_PLATFORMS: unknown platform %q", id)
+			}
+		}
+		rc.Probe = cfg.ProbePlatforms
+	}
+	if cfg.BreakerThreshold > 0 {
+		rc.Breaker = riot.NewBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown)
+	}
+	bus, err := cfg.eventBus(vault, signer)
+	if err != nil {
+		return nil, err
+	}
+	rc.OnKeyInvalid = func(ks riot.KeyStatus) {
+		log.Printf("riot: API key rejected (HTTP %d); requests fail until RIOT_API_KEY is replaced", ks.Status)
+		bus.Publish(events.Event{Type: events.KeyRejected, Subject: "riot", Text: ks.Error, Data: events.KeyData{Status: ks.Status}})
+	}
+	rc.SLO = riot.NewSLO()
+	rc.SLO.Window, rc.SLO.P95, rc.SLO.ErrorRate = cfg.SLOWindow, cfg.SLOP95, cfg.SLOErrorRate
+	rc.SLO.OnChange = func(st riot.EndpointSLO) {
+		ev := sloEvent(st)
+		log.Printf("riot: %s", ev.Text)
+		bus.Publish(ev)
+	}
+	if cfg.TenantWeights != nil {
+		rc.Scheduler = riot.NewFairScheduler(rc.Limiter, cfg.TenantWeights)
+	}
+	an := analyzer.New(rc, cfg.RankWorkers)
+	if cfg.MatchWorkers > 0 {
+		an.MatchWorkers = cfg.MatchWorkers
+	}
+	an.SetLeagueCache(cfg.LeagueCacheSize, cfg.LeagueCacheTTL)
+	an.ChampionCacheFile = cfg.ChampionCache
+	an.WinScale = cfg.WinScale
+	an.PlayerTimeout = cfg.PlayerTimeout
+	if cfg.ScoreFormula != "" {
+		f, err := analyzer.CompileScoreFormula(cfg.ScoreFormula)
+		if err != nil {
+			return nil, fmt.Errorf("SCORE_FORMULA: %w", err)
+		}
+		an.ScoreFormula = f
+	}
+	st, err := cfg.openStore()
+	if err != nil {
+		return nil, err
+	}
+	an.History = st
+	if rc.Cache, err = cfg.riotCache(st); err != nil {
+		return nil, err
+	}
+	rc.CacheTTLs = cfg.RiotCacheTTLs
+	if cfg.MatchCache != "" {
+		mc, err := store.OpenMatchCache(cfg.MatchCache)
+		if err != nil {
+			return nil, fmt.Errorf("MATCH_CACHE: %w", err)
+		}
+		rc.Matches = mc
+	}
+	bf := backfill.NewWorker(rc, an, st)
+	if _, inMemory := st.(*store.Memory); inMemory {
+		// a database store writes matches through; the file is only read to import older history
+		bf.StoreFile = cfg.MatchStoreFile
+	}
+	if cfg.BackfillInterval > 0 {
+		bf.Interval = cfg.BackfillInterval
+	}
+	if !cfg.BackfillSince.IsZero() {
+		bf.Since = cfg.BackfillSince
+	}
+	if m := cfg.Retention.Matches; m > 0 && bf.Since.Before(time.Now().Add(-m)) {
+		// older matches would only be pruned again
+		bf.Since = time.Now().Add(-m)
+	}
+	pruner := retention.NewPruner(st, cfg.Retention)
+	pruner.Interval = cfg.PruneInterval
+	pruner.StoreFile = bf.StoreFile
+	var results *resultfile.Dir
+	if cfg.ResultDir != "" {
+		results = resultfile.New(cfg.ResultDir, cfg.ResultRetention)
+	}
+	var snapDir *resultfile.Dir
+	if cfg.SnapshotDir != "" {
+		snapDir = resultfile.New(cfg.SnapshotDir, resultfile.Retention{MaxFiles: snapshotRetention})
+	}
+	snaps := snapshot.NewScheduler(st, snapDir)
+	snaps.Time = cfg.SnapshotTime
+	var poster *digest.Poster
+	if cfg.DigestConfig != "" {
+		cs, err := digest.LoadConfig(cfg.DigestConfig)
+		if err != nil {
+			return nil, fmt.Errorf("DIGEST_CONFIG: %w", err)
+		}
+		for _, c := range cs {
+			if err := checkSecretRef("digest "+c.Name, c.Webhook, vault); err != nil {
+				return nil, fmt.Errorf("DIGEST_CONFIG: %w", err)
+			}
+		}
+		poster = digest.NewPoster(cs, snaps)
+		poster.Resolve, poster.Signer = vault.Resolve, signer
+		snaps.OnTake = poster.Post
+	}
+	if err := checkSecretRef("DISCORD_BOT_TOKEN", cfg.DiscordBotToken, vault); err != nil {
+		return nil, err
+	}
+	var discordKey ed25519.PublicKey
+	if cfg.DiscordPublicKey != "" {
+		if discordKey, err = discord.ParsePublicKey(cfg.DiscordPublicKey); err != nil {
+			return nil, fmt.Errorf("DISCORD_PUBLIC_KEY: %w", err)
+		}
+	}
+	rsvps := discord.NewRSVPSync(st, cfg.DiscordBotToken)
+	rsvps.Resolve, rsvps.Interval = vault.Resolve, cfg.DiscordRSVPInterval
+	alerts := rankalert.NewRefresher(st, an, cfg.DiscordBotToken)
+	alerts.Resolve, alerts.Signer, alerts.Interval = vault.Resolve, signer, cfg.RankAlertInterval
+	alerts.Events = bus
+	bus.Subscribe("rank_alerts", alerts.Handle, events.RankChanged)
+	mem := memwatch.New(cfg.MemoryLimit)
+	mem.Flush = append(mem.Flush, func() { an.ForgetMatches() }, func() { an.ForgetLeagues() })
+	if mc, ok := rc.Cache.(*riot.MemoryCache); ok {
+		mem.Flush = append(mem.Flush, func() { mc.Purge("") })
+	}
+	static := staticdata.New(an.RefreshChampions)
+	var callerKeys *riot.KeyLimiters
+	if cfg.CallerKeys {
+		callerKeys = riot.NewKeyLimiters()
+		log.Printf("accepting caller Riot keys in %s", riot.KeyHeader)
+	}
+	srv := &httpapi.Server{
+		Analyzer: an, Store: st, MatchLimit: cfg.MatchLimit, Results: results,
+		Backfill: bf, Snapshots: snaps, Digest: poster, Pruner: pruner, Events: bus, RSVPSync: rsvps, RankAlerts: alerts, Memory: mem, Static: static, Secrets: vault, Signer: signer, CallerKeys: callerKeys, DiscordKey: discordKey, Backpressure: cfg.Backpressure, OrganizerToken: cfg.OrganizerToken, AllowedOrigins: cfg.AllowedOrigins, BackupFiles: cfg.cacheFiles(),
+		Demo: cfg.DemoMode, DemoRateLimit: cfg.DemoRateLimit,
+	}
+	if results != nil {
+		bus.Subscribe("results", srv.SaveResult, events.ResultRecorded)
+	}
+	return &App{
+		Config:     cfg,
+		Riot:       rc,
+		Analyzer:   an,
+		Store:      st,
+		Backfill:   bf,
+		Snapshots:  snaps,
+		Pruner:     pruner,
+		RSVPSync:   rsvps,
+		RankAlerts: alerts,
+		Events:     bus,
+		Memory:     mem,
+		Static:     static,
+		HTTP:       srv,
+	}, nil
+}
+
+func (a *App) Handler() http.Handler { return a.HTTP.Handler() }
+
+// ListenAndServe serves the API on Config.Port until the process exits.
+func (a *App) ListenAndServe() error {
+	ln, err := a.Listen()
+	if err != nil {
+		return err
+	}
+	return a.Serve(context.Background(), ln)
+}
+
+// Backup writes a backup archive of the configured store and cache files
+// without starting the server (no API key needed). With the in-memory store
+// only the match file's history is there to back up; use GET /admin/backup on
+// the running server instead.
+func Backup(cfg Config, path string) (backup.Manifest, error) {
+	st, err := cfg.openStore()
+	if err != nil {
+		return backup.Manifest{}, err
+	}
+	return backup.WriteFile(path, st, cfg.cacheFiles())
+}
+
+// Restore loads a backup archive into the configured store and cache files.
+// With the in-memory store the restored matches are saved to the match file,
+// the only part that outlives this process.
+func Restore(cfg Config, path string) (backup.Manifest, error) {
+	st, err := cfg.openStore()
+	if err != nil {
+		return backup.Manifest{}, err
+	}
+	m, err := backup.RestoreFile(path, st, cfg.cacheFiles())
+	if err != nil {
+		return m, err
+	}
+	if _, inMemory := st.(*store.Memory); inMemory && cfg.MatchStoreFile != "" {
+		if err := st.SaveMatches(cfg.MatchStoreFile); err != nil {
+			return m, err
+		}
+	}
+	return m, nil
+}
+
+// Calibrate compares the stored results' predicted win chances with their
+// outcomes without starting the server. With the in-memory store it reads
+// the local mode's saved state, the only place results outlive a process.
+func Calibrate(cfg Config, unverified bool) (analyzer.CalibrationReport, error) {
+	st, err := cfg.openStore()
+	if err != nil {
+		return analyzer.CalibrationReport{}, err
+	}
+	if mem, inMemory := st.(*store.Memory); inMemory {
+		if err := loadState(mem, LocalStateFile()); err != nil {
+			return analyzer.CalibrationReport{}, fmt.Errorf("loading %s: %w", LocalStateFile(), err)
+		}
+	}
+	return analyzer.Calibrate(store.CalibrationGames(st.Results(), unverified), cfg.WinScale), nil
+}
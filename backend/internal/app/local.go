@@ -0,0 +1,159 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"lol_custom_skill_matching/internal/paths"
+	"lol_custom_skill_matching/internal/store"
+	"lol_custom_skill_matching/internal/webui"
+)
+
+// localSaveInterval spaces the local mode's state saves.
+const localSaveInterval = time.Minute
+
+// LocalStateFile is where local mode keeps the in-memory store between runs.
+func LocalStateFile() string { return paths.DataFile("state.json") }
+
+// ServeLocal is the desktop mode for organizers who don't run a server: the
+// API and the embedded frontend on localhost only, the browser opened on it,
+// and the in-memory store saved to the user's data directory every minute and
+// when ctx ends. It returns once the server has shut down.
+func (a *App) ServeLocal(ctx context.Context, openBrowser bool) error {
+	mem, inMemory := a.Store.(*store.Memory)
+	stateFile := LocalStateFile()
+	if inMemory {
+		if err := loadState(mem, stateFile); err != nil {
+			return fmt.Errorf("loading %s: %w", stateFile, err)
+		}
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:"+a.Config.Port)
+	if err != nil {
+		// another copy or program has the port; any free one will do
+		if ln, err = net.Listen("tcp", "127.0.0.1:0"); err != nil {
+			return err
+		}
+	}
+	url := "http://" + ln.Addr().String() + "/"
+	srv := &http.Server{Handler: webui.Handler(a.Handler())}
+	srv.RegisterOnShutdown(a.HTTP.CloseStreams)
+	defer a.Events.Close()
+	go a.Backfill.Run(ctx)
+	go a.Snapshots.Run(ctx)
+	go a.Pruner.Run(ctx)
+	go a.RSVPSync.Run(ctx)
+	go a.RankAlerts.Run(ctx)
+	go a.Memory.Run(ctx)
+	go a.Static.Run(ctx)
+	go a.saveLimiter(ctx)
+	defer a.storeLimiter()
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		<-ctx.Done()
+		sctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(sctx)
+		a.drainJobs(sctx)
+	}()
+	a.resumeJobs()
+	if inMemory {
+		go func() {
+			t := time.NewTicker(localSaveInterval)
+			defer t.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-t.C:
+					if err := saveState(mem, stateFile); err != nil {
+						log.Printf("saving %s: %v", stateFile, err)
+					}
+				}
+			}
+		}()
+	}
+
+	log.Printf("local mode: open %s (data in %s)", url, paths.DataDir())
+	if !webui.Embedded() {
+		log.Printf("this binary has no embedded frontend; only the API is served")
+	}
+	if openBrowser {
+		if err := browse(url); err != nil {
+			log.Printf("could not open a browser (%v); open %s yourself", err, url)
+		}
+	}
+	err = srv.Serve(ln)
+	if errors.Is(err, http.ErrServerClosed) {
+		<-drained
+	}
+	if inMemory {
+		if serr := saveState(mem, stateFile); serr != nil {
+			log.Printf("saving %s: %v", stateFile, serr)
+		} else {
+			log.Printf("saved state to %s", stateFile)
+		}
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// loadState restores a snapshot written by saveState; a missing file is a first run.
+func loadState(m *store.Memory, path string) error {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var snap store.Snapshot
+	if err := json.Unmarshal(b, &snap); err != nil {
+		return err
+	}
+	return m.Restore(snap)
+}
+
+// saveState writes the store's snapshot through a temporary file, so a crash
+// mid-write keeps the previous state.
+func saveState(m *store.Memory, path string) error {
+	snap, err := m.Snapshot()
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// browse opens url in the default browser.
+func browse(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	case "darwin":
+		cmd = exec.Command("open", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
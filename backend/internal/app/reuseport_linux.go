@@ -0,0 +1,6 @@
+//go:build linux && !mips && !mipsle && !mips64 && !mips64le
+
+package app
+
+// soReusePort is SO_REUSEPORT, which package syscall lacks on amd64, 386 and arm.
+const soReusePort = 0xf
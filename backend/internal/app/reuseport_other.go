@@ -0,0 +1,13 @@
+//go:build !darwin && (!linux || mips || mipsle || mips64 || mips64le)
+
+package app
+
+import (
+	"fmt"
+	"runtime"
+	"syscall"
+)
+
+func reusePort(network, address string, c syscall.RawConn) error {
+	return fmt.Errorf("REUSE_PORT is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,16 @@
+//go:build darwin || (linux && !mips && !mipsle && !mips64 && !mips64le)
+
+package app
+
+import "syscall"
+
+// reusePort sets SO_REUSEPORT, letting another process bind the same port.
+func reusePort(network, address string, c syscall.RawConn) error {
+	var serr error
+	if err := c.Control(func(fd uintptr) {
+		serr = syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, soReusePort, 1)
+	}); err != nil {
+		return err
+	}
+	return serr
+}
@@ -0,0 +1,159 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"lol_custom_skill_matching/internal/discord"
+	"lol_custom_skill_matching/internal/riot"
+)
+
+// Listen opens Config.Port on all interfaces, with SO_REUSEPORT when
+// Config.ReusePort is set.
+func (a *App) Listen() (net.Listener, error) {
+	lc := net.ListenConfig{}
+	if a.Config.ReusePort {
+		lc.Control = reusePort
+	}
+	return lc.Listen(context.Background(), "tcp", ":"+a.Config.Port)
+}
+
+// Serve runs the backfill worker and serves the API on ln until ctx ends, then
+// drains: /readyz answers 503 for DrainDelay, ln is closed, and requests and
+// analyze jobs in flight (long analyses) get up to ShutdownTimeout to finish
+// before the backfill worker is stopped and the limiter state saved
+// (LimiterStateFile). Jobs still running then are saved to JobCheckpointFile
+// and resumed by the next Serve (once HandedOver, when it waits for a
+// predecessor), and then Drained is called; the event bus's subscribers then
+// handle what is left of its events. It returns once drained.
+func (a *App) Serve(ctx context.Context, ln net.Listener) error {
+	defer a.Events.Close()
+	bctx, stopBackfill := context.WithCancel(context.Background())
+	defer stopBackfill()
+	go a.Backfill.Run(bctx)
+	go a.Snapshots.Run(bctx)
+	go a.Pruner.Run(bctx)
+	go a.RSVPSync.Run(bctx)
+	go a.registerCommands(bctx)
+	go a.RankAlerts.Run(bctx)
+	go a.Memory.Run(bctx)
+	go a.Static.Run(bctx)
+	go a.saveLimiter(bctx)
+	defer a.storeLimiter()
+
+	srv := &http.Server{Handler: a.Handler()}
+	srv.RegisterOnShutdown(a.HTTP.CloseStreams)
+	drained := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		a.HTTP.SetDraining(true)
+		log.Printf("draining: readyz is 503, closing the listener in %s", a.Config.DrainDelay)
+		time.Sleep(a.Config.DrainDelay)
+		sctx, cancel := context.WithTimeout(context.Background(), a.Config.ShutdownTimeout)
+		defer cancel()
+		err := srv.Shutdown(sctx)
+		a.drainJobs(sctx)
+		if a.Drained != nil {
+			a.Drained()
+		}
+		drained <- err
+	}()
+	if a.HandedOver == nil {
+		a.resumeJobs()
+	} else {
+		a.HTTP.AwaitHandoff()
+		go func() {
+			select {
+			case <-a.HandedOver:
+				a.resumeJobs()
+			case <-ctx.Done():
+				// stopped first: the checkpoint waits for the next Serve
+			}
+		}()
+	}
+	log.Printf("Web API listening on %s", ln.Addr())
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	err := <-drained
+	if err != nil {
+		log.Printf("shutdown: requests still running after %s: %v", a.Config.ShutdownTimeout, err)
+	} else {
+		log.Printf("drained")
+	}
+	return err
+}
+
+// drainJobs waits for the analyze jobs in flight until ctx ends and saves
+// the ones it had to stop to JobCheckpointFile.
+func (a *App) drainJobs(ctx context.Context) {
+	n, err := a.HTTP.DrainJobs(ctx, a.Config.JobCheckpointFile)
+	switch {
+	case err != nil:
+		log.Printf("shutdown: saving %d unfinished analyze jobs to %s: %v", n, a.Config.JobCheckpointFile, err)
+	case n > 0 && a.Config.JobCheckpointFile != "":
+		log.Printf("shutdown: %d unfinished analyze jobs saved to %s", n, a.Config.JobCheckpointFile)
+	case n > 0:
+		log.Printf("shutdown: %d analyze jobs stopped unfinished", n)
+	}
+}
+
+// resumeJobs restarts the analyze jobs the last shutdown saved.
+func (a *App) resumeJobs() {
+	n, err := a.HTTP.ResumeJobs(a.Config.JobCheckpointFile)
+	if err != nil {
+		log.Printf("resuming analyze jobs from %s: %v", a.Config.JobCheckpointFile, err)
+	}
+	if n > 0 {
+		log.Printf("resumed %d analyze jobs stopped by the last shutdown", n)
+	}
+}
+
+// limiterSaveInterval is how often the limiter state is written while
+// serving, so even a crash leaves a recent one.
+const limiterSaveInterval = 10 * time.Second
+
+// saveLimiter writes the limiter state every limiterSaveInterval until ctx ends.
+func (a *App) saveLimiter(ctx context.Context) {
+	if a.Config.LimiterStateFile == "" {
+		return
+	}
+	t := time.NewTicker(limiterSaveInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			a.storeLimiter()
+		}
+	}
+}
+
+func (a *App) storeLimiter() {
+	if a.Config.LimiterStateFile == "" {
+		return
+	}
+	if err := riot.SaveLimiterState(a.Riot.Limiter, a.Config.LimiterStateFile); err != nil {
+		log.Printf("limiter state %s: %v", a.Config.LimiterStateFile, err)
+	}
+}
+
+// registerCommands registers the bot's slash commands when both its token and
+// public key are configured; a failure is logged, the endpoint still answers.
+func (a *App) registerCommands(ctx context.Context) {
+	if a.HTTP.DiscordKey == nil || a.RSVPSync.Token == "" {
+		return
+	}
+	token, err := a.RSVPSync.Resolve(a.RSVPSync.Token)
+	if err == nil {
+		err = discord.RegisterCommands(ctx, a.RSVPSync.API, token, a.RSVPSync.HTTP)
+	}
+	if err != nil {
+		log.Printf("discord commands: %v", err)
+	}
+}
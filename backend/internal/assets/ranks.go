@@ -0,0 +1,87 @@
+// Package assets maps game data to the artwork the frontend shows, so every
+// response points at the same images instead of each consumer building URLs.
+package assets
+
+import (
+	"fmt"
+	"strings"
+
+	"lol_custom_skill_matching/internal/riot"
+)
+
+// CommunityDragonImages is the client static-assets folder on Community Dragon.
+const CommunityDragonImages = "https://raw.communitydragon.org/latest/plugins/rcp-fe-lol-static-assets/global/default/images"
+
+// Tiers are the ranked tiers, lowest first.
+var Tiers = []string{"IRON", "BRONZE", "SILVER", "GOLD", "PLATINUM", "EMERALD", "DIAMOND", "MASTER", "GRANDMASTER", "CHALLENGER"}
+
+// Tiers without divisions.
+var apexTiers = map[string]bool{"MASTER": true, "GRANDMASTER": true, "CHALLENGER": true}
+
+// Rank is a solo queue rank with its display label and images.
+type Rank struct {
+	Tier     string `json:"tier"`               // "GOLD"; "" when unranked
+	Division string `json:"division,omitempty"` // "II"; empty for apex tiers
+	LP       int    `json:"lp"`
+	Label    string `json:"label"`  // "Gold II 45 LP", "Unranked"
+	Emblem   string `json:"emblem"` // large tier emblem
+	Crest    string `json:"crest"`  // small crest for lists
+}
+
+// EmblemURL is the large emblem of tier (the unranked crest when tier is "").
+func EmblemURL(tier string) string {
+	if tier == "" {
+		return CrestURL("")
+	}
+	return fmt.Sprintf("%s/ranked-emblem/emblem-%s.png", CommunityDragonImages, strings.ToLower(tier))
+}
+
+// CrestURL is the mini crest of tier ("" = unranked).
+func CrestURL(tier string) string {
+	if tier == "" {
+		tier = "unranked"
+	}
+	return fmt.Sprintf("%s/ranked-mini-crests/%s.png", CommunityDragonImages, strings.ToLower(tier))
+}
+
+// RankImages lists every emblem and crest a Rank can point at, unranked
+// included.
+func RankImages() []string {
+	urls := []string{CrestURL("")}
+	for _, t := range Tiers {
+		urls = append(urls, EmblemURL(t), CrestURL(t))
+	}
+	return urls
+}
+
+// RankOf describes a league entry's tier, division and LP.
+func RankOf(tier, division string, lp int) Rank {
+	tier = strings.ToUpper(tier)
+	if tier == "" {
+		return Unranked()
+	}
+	if apexTiers[tier] {
+		division = ""
+	}
+	label := strings.ToUpper(tier[:1]) + strings.ToLower(tier[1:])
+	if division != "" {
+		label += " " + division
+	}
+	return Rank{
+		Tier: tier, Division: division, LP: lp,
+		Label:  fmt.Sprintf("%s %d LP", label, lp),
+		Emblem: EmblemURL(tier),
+		Crest:  CrestURL(tier),
+	}
+}
+
+// RankFromScore inverts riot.RankScore.
+func RankFromScore(score int) Rank {
+	tier, division, lp := riot.ScoreToRank(score)
+	return RankOf(tier, division, lp)
+}
+
+// Unranked is the rank shown for players without a solo queue entry.
+func Unranked() Rank {
+	return Rank{Label: "Unranked", Emblem: EmblemURL(""), Crest: CrestURL("")}
+}
@@ -0,0 +1,263 @@
+// Package backfill walks players' full season match history in the background so
+// later analyses can use hundreds of games instead of the last few.
+package backfill
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/clock"
+	"lol_custom_skill_matching/internal/riot"
+	"lol_custom_skill_matching/internal/store"
+)
+
+const (
+	StateQueued  = "queued"
+	StateRunning = "running"
+	StateDone    = "done"
+	StateFailed  = "failed"
+)
+
+// pageSize is the match-v5 maximum ids per page.
+const pageSize = 100
+
+// Status is the progress of one player's backfill.
+type Status struct {
+	Player     string    `json:"player"`
+	Tenant     string    `json:"tenant"` // whose Riot quota the job uses
+	State      string    `json:"state"`
+	Listed     int       `json:"listed"`  // match ids seen so far
+	Stored     int       `json:"stored"`  // new summaries persisted
+	Skipped    int       `json:"skipped"` // already stored or non-qualifying queues
+	Error      string    `json:"error,omitempty"`
+	QueuedAt   time.Time `json:"queued_at"`
+	FinishedAt time.Time `json:"finished_at"`
+
+	player  analyzer.Player
+	started time.Time
+}
+
+// Backlog is the work ahead of a new backfill.
+type Backlog struct {
+	Depth int // jobs queued or running
+	// EstimatedWait is until a new job would start, from the average
+	// duration of finished jobs (0 until one has finished).
+	EstimatedWait time.Duration
+	Average       time.Duration
+}
+
+// Worker processes queued players one at a time. It shares the Riot client (and
+// so the limiter) with interactive analyses, pauses while any of them is in
+// flight, and spaces its own requests by Interval so it never takes the whole
+// quota.
+type Worker struct {
+	Riot     *riot.Client
+	Analyzer *analyzer.Analyzer
+	Store    store.Store
+	// Interval is the minimum gap between backfill requests.
+	Interval time.Duration
+	// Since is the oldest match time to fetch (season start).
+	Since time.Time
+	// StoreFile receives the match store after every page ("" = memory only).
+	StoreFile string
+	// Clock paces the requests and times the jobs (nil = the system clock).
+	Clock clock.Clock
+
+	mu    sync.Mutex
+	jobs  map[string]*Status // RiotIDKey -> status
+	order []string
+	wake  chan struct{}
+	avg   time.Duration // moving average of done jobs' durations
+}
+
+func NewWorker(client *riot.Client, an *analyzer.Analyzer, st store.Store) *Worker {
+	now := time.Now()
+	return &Worker{
+		Riot:     client,
+		Analyzer: an,
+		Store:    st,
+		Interval: 3 * time.Second,
+		Since:    time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, time.UTC),
+		jobs:     map[string]*Status{},
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Enqueue schedules a backfill unless one is already queued or running. Its
+// requests are charged to tenant (see riot.FairScheduler).
+func (w *Worker) Enqueue(p analyzer.Player, tenant string) Status {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	key := store.RiotIDKey(p.GameName, p.TagLine)
+	if st, ok := w.jobs[key]; ok && (st.State == StateQueued || st.State == StateRunning) {
+		return *st
+	}
+	st := &Status{Player: p.RiotID(), Tenant: tenant, State: StateQueued, QueuedAt: w.now(), player: p}
+	if _, ok := w.jobs[key]; !ok {
+		w.order = append(w.order, key)
+	}
+	w.jobs[key] = st
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+	return *st
+}
+
+// Jobs lists every backfill in the order first requested.
+func (w *Worker) Jobs() []Status {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	out := make([]Status, 0, len(w.order))
+	for _, key := range w.order {
+		out = append(out, *w.jobs[key])
+	}
+	return out
+}
+
+// Backlog sizes up the queue a new backfill would join.
+func (w *Worker) Backlog() Backlog {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	b := Backlog{Average: w.avg}
+	now := w.now()
+	for _, st := range w.jobs {
+		switch st.State {
+		case StateQueued:
+			b.Depth++
+			b.EstimatedWait += w.avg
+		case StateRunning:
+			b.Depth++
+			b.EstimatedWait += max(0, w.avg-now.Sub(st.started))
+		}
+	}
+	return b
+}
+
+// Run processes the queue until ctx is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	for {
+		st := w.next()
+		if st == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-w.wake:
+			}
+			continue
+		}
+		err := w.backfill(riot.WithTenant(ctx, st.Tenant), st)
+		w.mu.Lock()
+		st.FinishedAt = w.now()
+		if err != nil {
+			st.State, st.Error = StateFailed, err.Error()
+		} else {
+			st.State = StateDone
+			w.observe(st.FinishedAt.Sub(st.started))
+		}
+		w.mu.Unlock()
+		log.Printf("backfill %s: %s (stored=%d skipped=%d)", st.Player, st.State, st.Stored, st.Skipped)
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (w *Worker) next() *Status {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, key := range w.order {
+		if st := w.jobs[key]; st.State == StateQueued {
+			st.State, st.started = StateRunning, w.now()
+			return st
+		}
+	}
+	return nil
+}
+
+// observe folds a done job's duration into the average; w.mu is held.
+func (w *Worker) observe(d time.Duration) {
+	if w.avg == 0 {
+		w.avg = d
+		return
+	}
+	w.avg = (4*w.avg + d) / 5
+}
+
+// pace waits out interactive analyses, then the request interval.
+func (w *Worker) pace(ctx context.Context) error {
+	for w.Analyzer.Active() > 0 {
+		if err := clock.SleepContext(ctx, clock.Or(w.Clock), 2*time.Second); err != nil {
+			return err
+		}
+	}
+	return clock.SleepContext(ctx, clock.Or(w.Clock), w.Interval)
+}
+
+func (w *Worker) now() time.Time { return clock.Or(w.Clock).Now() }
+
+func (w *Worker) update(st *Status, f func(*Status)) {
+	w.mu.Lock()
+	f(st)
+	w.mu.Unlock()
+}
+
+func (w *Worker) backfill(ctx context.Context, st *Status) error {
+	p := st.player
+	if err := w.pace(ctx); err != nil {
+		return err
+	}
+	account, found, err := w.Riot.AccountByRiotID(ctx, p.GameName, p.TagLine)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errNotFound
+	}
+	champs := w.Analyzer.Champions(ctx)
+	for start := 0; ; start += pageSize {
+		if err := w.pace(ctx); err != nil {
+			return err
+		}
+		ids, err := w.Riot.MatchIDsSince(ctx, account.PUUID, w.Since, start, pageSize)
+		if err != nil {
+			return err
+		}
+		w.update(st, func(s *Status) { s.Listed += len(ids) })
+		for _, id := range ids {
+			if w.Store.HasMatch(p.GameName, p.TagLine, id) {
+				w.update(st, func(s *Status) { s.Skipped++ })
+				continue
+			}
+			if err := w.pace(ctx); err != nil {
+				return err
+			}
+			m, err := w.Riot.Match(ctx, id)
+			if err != nil {
+				return err
+			}
+			if m == nil || !analyzer.QualifyingQueue(m.Info.QueueID) {
+				w.update(st, func(s *Status) { s.Skipped++ })
+				continue
+			}
+			if sum, ok := analyzer.SummarizeMatch(id, m, account.PUUID, champs); ok {
+				w.Store.AddMatches(p.GameName, p.TagLine, []analyzer.MatchSummary{sum})
+				w.update(st, func(s *Status) { s.Stored++ })
+			}
+		}
+		if w.StoreFile != "" {
+			if err := w.Store.SaveMatches(w.StoreFile); err != nil {
+				log.Printf("backfill: saving %s: %v", w.StoreFile, err)
+			}
+		}
+		if len(ids) < pageSize {
+			return nil
+		}
+	}
+}
+
+var errNotFound = errors.New("riot id not found")
@@ -0,0 +1,213 @@
+// Package backup snapshots the server state plus its cache files into a
+// tar.gz and restores from one, so a lost container volume doesn't take
+// seasons of community history with it.
+//
+// Archive layout:
+//
+//	manifest.json   what the archive holds (created_at, record counts, files)
+//	store.json      store.Snapshot
+//	files/<name>    cache files, e.g. files/champion_cache.json
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"lol_custom_skill_matching/internal/store"
+)
+
+// FormatVersion is bumped when the archive layout changes incompatibly.
+const FormatVersion = 1
+
+const (
+	manifestName = "manifest.json"
+	storeName    = "store.json"
+	filesDir     = "files/"
+	// maxEntry bounds one archive member when restoring.
+	maxEntry = 1 << 30
+)
+
+// Manifest describes an archive.
+type Manifest struct {
+	Version   int            `json:"version"`
+	CreatedAt time.Time      `json:"created_at"`
+	Records   map[string]int `json:"records"` // bucket -> count
+	Files     []FileInfo     `json:"files"`
+}
+
+// FileInfo is the metadata of a cache file in the archive.
+type FileInfo struct {
+	Name    string    `json:"name"` // key in the files map given to Write/Restore
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// Write archives st and the files that exist among files (name -> path on disk).
+func Write(w io.Writer, st store.Store, files map[string]string) (Manifest, error) {
+	snap, err := st.Snapshot()
+	if err != nil {
+		return Manifest{}, fmt.Errorf("snapshot: %w", err)
+	}
+	m := Manifest{Version: FormatVersion, CreatedAt: time.Now().UTC(), Records: map[string]int{}, Files: []FileInfo{}}
+	for bucket, recs := range snap {
+		m.Records[bucket] = len(recs)
+	}
+	type blob struct {
+		name string
+		data []byte
+		mod  time.Time
+	}
+	var blobs []blob
+	for name, path := range files {
+		if path == "" {
+			continue
+		}
+		b, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return Manifest{}, err
+		}
+		mod := m.CreatedAt
+		if fi, err := os.Stat(path); err == nil {
+			mod = fi.ModTime().UTC()
+		}
+		blobs = append(blobs, blob{filesDir + name, b, mod})
+		m.Files = append(m.Files, FileInfo{Name: name, Size: int64(len(b)), ModTime: mod})
+	}
+	mb, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return Manifest{}, err
+	}
+	sb, err := json.Marshal(snap)
+	if err != nil {
+		return Manifest{}, err
+	}
+	blobs = append([]blob{{manifestName, mb, m.CreatedAt}, {storeName, sb, m.CreatedAt}}, blobs...)
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, b := range blobs {
+		hdr := &tar.Header{Name: b.name, Mode: 0o644, Size: int64(len(b.data)), ModTime: b.mod, Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return Manifest{}, err
+		}
+		if _, err := tw.Write(b.data); err != nil {
+			return Manifest{}, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return Manifest{}, err
+	}
+	return m, gz.Close()
+}
+
+// WriteFile writes an archive to path, replacing it atomically.
+func WriteFile(path string, st store.Store, files map[string]string) (Manifest, error) {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return Manifest{}, err
+	}
+	m, err := Write(f, st, files)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return Manifest{}, err
+	}
+	return m, os.Rename(tmp, path)
+}
+
+// Restore replaces the state of st with the archive and writes back the cache
+// files it knows a path for (name -> path). The archive is read completely
+// before anything is changed.
+func Restore(r io.Reader, st store.Store, files map[string]string) (Manifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("not a gzip archive: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	var m Manifest
+	var snap store.Snapshot
+	blobs := map[string][]byte{}
+	haveManifest := false
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Manifest{}, fmt.Errorf("reading archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		b, err := io.ReadAll(io.LimitReader(tr, maxEntry+1))
+		if err != nil {
+			return Manifest{}, err
+		}
+		if len(b) > maxEntry {
+			return Manifest{}, fmt.Errorf("%s is too large", hdr.Name)
+		}
+		switch {
+		case hdr.Name == manifestName:
+			if err := json.Unmarshal(b, &m); err != nil {
+				return Manifest{}, fmt.Errorf("manifest: %w", err)
+			}
+			haveManifest = true
+		case hdr.Name == storeName:
+			if err := json.Unmarshal(b, &snap); err != nil {
+				return Manifest{}, fmt.Errorf("store: %w", err)
+			}
+		case strings.HasPrefix(hdr.Name, filesDir):
+			blobs[strings.TrimPrefix(hdr.Name, filesDir)] = b
+		}
+	}
+	if !haveManifest || snap == nil {
+		return Manifest{}, errors.New("archive has no manifest or store")
+	}
+	if m.Version > FormatVersion {
+		return Manifest{}, fmt.Errorf("archive version %d is newer than this server (%d)", m.Version, FormatVersion)
+	}
+	if err := st.Restore(snap); err != nil {
+		return Manifest{}, fmt.Errorf("restoring store: %w", err)
+	}
+	for name, b := range blobs {
+		path := files[name]
+		if path == "" {
+			continue
+		}
+		if dir := filepath.Dir(path); dir != "." {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return m, err
+			}
+		}
+		if err := os.WriteFile(path, b, 0o644); err != nil {
+			return m, fmt.Errorf("restoring %s: %w", name, err)
+		}
+	}
+	return m, nil
+}
+
+// RestoreFile restores from an archive on disk.
+func RestoreFile(path string, st store.Store, files map[string]string) (Manifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Manifest{}, err
+	}
+	defer f.Close()
+	return Restore(f, st, files)
+}
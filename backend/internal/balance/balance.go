@@ -0,0 +1,155 @@
+// Package balance splits analyzed players into two teams.
+// It only sees names, scores and lane preferences, so it can run without any Riot data.
+package balance
+
+import (
+	"slices"
+	"sort"
+)
+
+// Roles on Summoner's Rift as reported by match-v5 teamPosition.
+var Roles = []string{"TOP", "JUNGLE", "MIDDLE", "BOTTOM", "UTILITY"}
+
+// Fill is the role the players past the fifth on a team share in lobbies of
+// more than 10 (e.g. rotating in between games). It is not a lane: it never
+// counts as autofill and has no lane gap.
+const Fill = "FILL"
+
+// Player is the balancing view of a profile.
+type Player struct {
+	Name      string
+	Score     int
+	MainLanes []string // most played first
+	SubLanes  []string
+	// Flexible players have too little lane history to be held to a lane:
+	// they have no MainLanes and fill any role, SubLanes first, without
+	// counting as autofilled.
+	Flexible bool
+}
+
+// Split is a team assignment by index into the input slice.
+type Split struct {
+	A, B       []int
+	SumA, SumB int
+}
+
+// Slot is one player placed on a role.
+type Slot struct {
+	Name    string `json:"name"`
+	Role    string `json:"role"`
+	Skill   int    `json:"skill"`
+	Comfort int    `json:"comfort"`
+}
+
+// RoleSplit is a split with one role per player.
+type RoleSplit struct {
+	TeamA    []Slot `json:"teamA"`
+	TeamB    []Slot `json:"teamB"`
+	SumA     int    `json:"sumA"`
+	SumB     int    `json:"sumB"`
+	Comfort  int    `json:"comfort"`
+	Autofill int    `json:"autofill"`
+	// Misfit sums the players' Misfit on their roles.
+	Misfit int `json:"misfit"`
+	// LaneGap is set when the split was made under Options.LaneGap.
+	LaneGap *LaneGap `json:"lane_gap,omitempty"`
+}
+
+// Lineup is the roles of rs in lineup order: Roles, then Fill when a team has
+// more than five players.
+func (rs *RoleSplit) Lineup() []string {
+	if max(len(rs.TeamA), len(rs.TeamB)) > len(Roles) {
+		return append(slices.Clone(Roles), Fill)
+	}
+	return Roles
+}
+
+// Comfort scores how comfortable a player is on a role:
+// 1st main lane=3, 2nd main lane=2, sub lane=1, autofill=0.
+func Comfort(p Player, role string) int {
+	for i, l := range p.MainLanes {
+		if l == role {
+			if i == 0 {
+				return 3
+			}
+			return 2
+		}
+	}
+	for _, l := range p.SubLanes {
+		if l == role {
+			return 1
+		}
+	}
+	return 0
+}
+
+// Alternate sorts by score and deals players to A and B in turn. With
+// captains each captain starts their team and the others go, by score, to the
+// team with the lower sum until it is full. The slotwise objective and groups
+// search every split instead (up to maxSearch players; larger rosters ignore
+// groups).
+func Alternate(players []Player, opts Options) Split {
+	if (opts.Objective == ObjectiveSlotwise || len(opts.Groups) > 0) && len(players) <= maxSearch {
+		return search(players, opts)
+	}
+	order := make([]int, len(players))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return players[order[i]].Score > players[order[j]].Score })
+	if opts.Captains != nil {
+		return seeded(players, order, opts.Captains)
+	}
+	s := Split{A: []int{}, B: []int{}}
+	for i, idx := range order {
+		if i%2 == 0 {
+			s.A = append(s.A, idx)
+			s.SumA += players[idx].Score
+		} else {
+			s.B = append(s.B, idx)
+			s.SumB += players[idx].Score
+		}
+	}
+	return s
+}
+
+// seeded is Alternate around captains; A gets the odd player, as when dealing.
+func seeded(players []Player, order []int, c *Captains) Split {
+	s := Split{A: []int{c.A}, B: []int{c.B}, SumA: players[c.A].Score, SumB: players[c.B].Score}
+	sizeA := (len(players) + 1) / 2
+	for _, idx := range order {
+		if c.team(idx) != 0 {
+			continue
+		}
+		if len(s.A) < sizeA && (s.SumA <= s.SumB || len(s.B) == len(players)-sizeA) {
+			s.A = append(s.A, idx)
+			s.SumA += players[idx].Score
+		} else {
+			s.B = append(s.B, idx)
+			s.SumB += players[idx].Score
+		}
+	}
+	return s
+}
+
+func newRoleSplit(players []Player, a, b []int, rolesA, rolesB []string) *RoleSplit {
+	rs := &RoleSplit{TeamA: []Slot{}, TeamB: []Slot{}}
+	add := func(team *[]Slot, sum *int, idx int, role string) {
+		p := players[idx]
+		c := Comfort(p, role)
+		*team = append(*team, Slot{Name: p.Name, Role: role, Skill: p.Score, Comfort: c})
+		*sum += p.Score
+		rs.Comfort += c
+		rs.Misfit += Misfit(p, role)
+		if c == 0 && !p.Flexible && role != Fill {
+			rs.Autofill++
+		}
+	}
+	for i, idx := range a {
+		add(&rs.TeamA, &rs.SumA, idx, rolesA[i])
+	}
+	for i, idx := range b {
+		add(&rs.TeamB, &rs.SumB, idx, rolesB[i])
+	}
+	return rs
+}
@@ -0,0 +1,218 @@
+package balance
+
+import (
+	"math/bits"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// mirrored is ten players, two mains per role, with the given scores in Roles
+// order (TOP, TOP, JUNGLE, JUNGLE, ...).
+func mirrored(scores ...int) []Player {
+	players := make([]Player, len(scores))
+	for i, s := range scores {
+		role := Roles[i/2]
+		players[i] = Player{Name: role + string(rune('a'+i%2)), Score: s, MainLanes: []string{role}}
+	}
+	return players
+}
+
+// bestCost is the lowest cost of any split with the larger half on A that
+// opts allows, found without the package's search; -1 when none is.
+func bestCost(players []Player, opts Options) int {
+	n, best := len(players), -1
+	for mask := 0; mask < 1<<n; mask++ {
+		if bits.OnesCount(uint(mask)) != (n+1)/2 {
+			continue
+		}
+		var a, b []int
+		for i := 0; i < n; i++ {
+			if mask&(1<<i) != 0 {
+				a = append(a, i)
+			} else {
+				b = append(b, i)
+			}
+		}
+		if !opts.allows(a) {
+			continue
+		}
+		if c := opts.Objective.cost(players, a, b); best < 0 || c < best {
+			best = c
+		}
+	}
+	return best
+}
+
+func TestObjectiveCost(t *testing.T) {
+	players := []Player{{Score: 10}, {Score: 6}, {Score: 5}, {Score: 1}}
+	a, b := []int{0, 3}, []int{1, 2}
+	if got := ObjectiveSum.cost(players, a, b); got != 0 {
+		t.Errorf("sum cost = %d, want 0", got)
+	}
+	// |10-6| + |1-5| on top of the equal totals
+	if got := ObjectiveSlotwise.cost(players, a, b); got != 8 {
+		t.Errorf("slotwise cost = %d, want 8", got)
+	}
+}
+
+func TestAlternateDeals(t *testing.T) {
+	players := []Player{{Score: 1}, {Score: 5}, {Score: 3}, {Score: 4}, {Score: 2}}
+	got := Alternate(players, Options{})
+	want := Split{A: []int{1, 2, 0}, B: []int{3, 4}, SumA: 9, SumB: 6}
+	if !slices.Equal(got.A, want.A) || !slices.Equal(got.B, want.B) || got.SumA != want.SumA || got.SumB != want.SumB {
+		t.Errorf("Alternate = %+v, want %+v", got, want)
+	}
+}
+
+// TestAlternateSearch checks the exhaustive searches against every split.
+func TestAlternateSearch(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for n := 4; n <= 11; n++ {
+		players := make([]Player, n)
+		for i := range players {
+			players[i] = Player{Score: 800 + rng.Intn(1600)}
+		}
+		for _, opts := range []Options{
+			{Objective: ObjectiveSlotwise},
+			{Groups: []Group{{Rule: GroupTogether, Members: []int{0, 1}}, {Rule: GroupSpread, Members: []int{2, 3, 4}}}},
+			{Objective: ObjectiveSlotwise, Captains: &Captains{A: n - 1, B: 0}},
+		} {
+			got := Alternate(players, opts)
+			if len(got.A) != (n+1)/2 || len(got.A)+len(got.B) != n {
+				t.Fatalf("n=%d: teams of %d and %d", n, len(got.A), len(got.B))
+			}
+			if !opts.allows(got.A) {
+				t.Errorf("n=%d %+v: A %v breaks the captains or groups", n, opts, got.A)
+			}
+			if c, want := opts.Objective.cost(players, got.A, got.B), bestCost(players, opts); c != want {
+				t.Errorf("n=%d %+v: cost %d, the best split costs %d", n, opts, c, want)
+			}
+		}
+	}
+}
+
+func TestAlternateDropsImpossibleGroups(t *testing.T) {
+	players := []Player{{Score: 4}, {Score: 3}, {Score: 2}, {Score: 1}}
+	// four players together can't fit a team of two
+	got := Alternate(players, Options{Groups: []Group{{Rule: GroupTogether, Members: []int{0, 1, 2, 3}}}})
+	if len(got.A) != 2 || len(got.B) != 2 || got.SumA != got.SumB {
+		t.Errorf("Alternate = %+v, want the balanced split without the group", got)
+	}
+}
+
+func TestAlternateCaptains(t *testing.T) {
+	players := mirrored(1500, 1400, 1300, 1250, 1600, 1000, 1200, 1100, 900, 950)
+	got := Alternate(players, Options{Captains: &Captains{A: 4, B: 0}})
+	if !slices.Contains(got.A, 4) || !slices.Contains(got.B, 0) {
+		t.Errorf("captains moved: A %v, B %v", got.A, got.B)
+	}
+	if len(got.A) != 5 || len(got.B) != 5 {
+		t.Errorf("teams of %d and %d, want 5 and 5", len(got.A), len(got.B))
+	}
+}
+
+// slotsByRole checks every slot of a team has a distinct role and returns them.
+func slotsByRole(t *testing.T, team []Slot) map[string]Slot {
+	t.Helper()
+	roles := map[string]Slot{}
+	for _, s := range team {
+		if _, dup := roles[s.Role]; dup {
+			t.Errorf("role %s twice in %+v", s.Role, team)
+		}
+		roles[s.Role] = s
+	}
+	return roles
+}
+
+func TestRolesFirstMirrors(t *testing.T) {
+	players := mirrored(1500, 1400, 1300, 1250, 1600, 1000, 1200, 1100, 900, 950)
+	rs := RolesFirst(players, Options{})
+	if rs == nil {
+		t.Fatal("no split")
+	}
+	if rs.Autofill != 0 || rs.Comfort != 30 {
+		t.Errorf("autofill %d, comfort %d; want everyone on their main", rs.Autofill, rs.Comfort)
+	}
+	a, b := slotsByRole(t, rs.TeamA), slotsByRole(t, rs.TeamB)
+	for _, role := range Roles {
+		if a[role].Name == "" || b[role].Name == "" || a[role].Name[:len(role)] != role || b[role].Name[:len(role)] != role {
+			t.Errorf("%s: %q vs %q, want its two mains", role, a[role].Name, b[role].Name)
+		}
+	}
+	// the best orientation of the fixed pairs: 32 choices, mirror included
+	best := -1
+	for mask := 0; mask < 1<<len(Roles); mask++ {
+		d := 0
+		for r := range Roles {
+			x, y := players[2*r].Score, players[2*r+1].Score
+			if mask&(1<<r) != 0 {
+				x, y = y, x
+			}
+			d += x - y
+		}
+		if best < 0 || abs(d) < best {
+			best = abs(d)
+		}
+	}
+	if got := abs(rs.SumA - rs.SumB); got != best {
+		t.Errorf("sum difference %d, want %d", got, best)
+	}
+}
+
+func TestRolesFirstLaneGap(t *testing.T) {
+	players := mirrored(1500, 1400, 1300, 1250, 1600, 1000, 1200, 1100, 900, 950)
+	rs := RolesFirst(players, Options{LaneGap: 150})
+	if rs == nil || rs.LaneGap == nil {
+		t.Fatalf("split %+v, want a lane gap report", rs)
+	}
+	if rs.LaneGap.Relaxed || rs.LaneGap.Max > 150 {
+		t.Errorf("lane gap %+v, want every lane within 150", rs.LaneGap)
+	}
+	// no two players are within 1 point: the cap is dropped and said so
+	rs = RolesFirst(players, Options{LaneGap: 1})
+	if rs == nil || rs.LaneGap == nil || !rs.LaneGap.Relaxed || rs.LaneGap.Max <= 1 {
+		t.Errorf("impossible cap: lane gap %+v, want it relaxed", rs.LaneGap)
+	}
+}
+
+func TestLaneUnique(t *testing.T) {
+	players := mirrored(1500, 1400, 1300, 1250, 1600, 1000, 1200, 1100, 900, 950)
+	rs := LaneUnique(players, Options{Captains: &Captains{A: 2, B: 3, RoleA: "UTILITY"}})
+	if rs == nil {
+		t.Fatal("no split")
+	}
+	a, b := slotsByRole(t, rs.TeamA), slotsByRole(t, rs.TeamB)
+	if len(a) != 5 || len(b) != 5 {
+		t.Errorf("roles A %d, B %d, want all five each", len(a), len(b))
+	}
+	if a["UTILITY"].Name != players[2].Name {
+		t.Errorf("team A's UTILITY is %q, want captain %q", a["UTILITY"].Name, players[2].Name)
+	}
+	if !slices.ContainsFunc(rs.TeamB, func(s Slot) bool { return s.Name == players[3].Name }) {
+		t.Errorf("captain %q not on team B", players[3].Name)
+	}
+	if LaneUnique(players[:3], Options{}) != nil {
+		t.Error("a split of three players")
+	}
+}
+
+func TestRoleCoverage(t *testing.T) {
+	players := mirrored(1500, 1400, 1300, 1250, 1600, 1000, 1200, 1100, 900, 950)
+	// both junglers main mid instead; one of them can still jungle
+	players[2].MainLanes, players[2].SubLanes = []string{"MIDDLE"}, []string{"JUNGLE"}
+	players[3].MainLanes = []string{"MIDDLE"}
+	cov := RoleCoverage(players)
+	if cov == nil {
+		t.Fatal("no coverage")
+	}
+	if !slices.Equal(cov.Scarce, []string{"JUNGLE"}) || !slices.Equal(cov.Crowded, []string{"MIDDLE"}) {
+		t.Errorf("scarce %v, crowded %v; want JUNGLE and MIDDLE", cov.Scarce, cov.Crowded)
+	}
+	if !slices.ContainsFunc(cov.Suggestions, func(f Flex) bool { return f.Player == players[2].Name && f.To == "JUNGLE" }) {
+		t.Errorf("suggestions %+v, want %s to jungle", cov.Suggestions, players[2].Name)
+	}
+	if RoleCoverage(players[:4]) != nil {
+		t.Error("coverage of four players")
+	}
+}
@@ -0,0 +1,51 @@
+package balance
+
+// Captains seeds a split: player A (an index into the players) leads team A
+// and player B team B, on RoleA and RoleB when set ("" = any role). The
+// splitters balance the other players around them.
+type Captains struct {
+	A, B         int
+	RoleA, RoleB string
+}
+
+// team returns the team captain i leads ('A' or 'B'), 0 for other players.
+func (c *Captains) team(i int) byte {
+	switch {
+	case c == nil:
+		return 0
+	case i == c.A:
+		return 'A'
+	case i == c.B:
+		return 'B'
+	}
+	return 0
+}
+
+// role returns the role player i is fixed to ("" = any).
+func (c *Captains) role(i int) string {
+	switch c.team(i) {
+	case 'A':
+		return c.RoleA
+	case 'B':
+		return c.RoleB
+	}
+	return ""
+}
+
+// allows reports whether team A made of the indices in a keeps the captains
+// on their teams.
+func (c *Captains) allows(a []int) bool {
+	if c == nil {
+		return true
+	}
+	hasA := false
+	for _, i := range a {
+		switch c.team(i) {
+		case 'A':
+			hasA = true
+		case 'B':
+			return false
+		}
+	}
+	return hasA
+}
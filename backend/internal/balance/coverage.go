@@ -0,0 +1,111 @@
+package balance
+
+import "fmt"
+
+// RoleCount is how many players of a roster can play a role.
+type RoleCount struct {
+	Role    string `json:"role"`
+	Needed  int    `json:"needed"`  // one per team
+	Primary int    `json:"primary"` // first main lane
+	Mains   int    `json:"mains"`   // any main lane
+	Subs    int    `json:"subs"`    // sub lane only
+}
+
+// Flex suggests a player move from their first main lane (From, "" when they
+// have none) to a role the roster lacks. Comfort is theirs on To (see
+// Comfort); 0 means autofill.
+type Flex struct {
+	Player  string `json:"player"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Comfort int    `json:"comfort"`
+}
+
+// Coverage is the roster's role coverage before balancing: roles fewer
+// players have as their first main lane than the teams need (Scarce), roles
+// more have (Crowded), and who could flex to even it out. It explains
+// autofills in the role splits.
+type Coverage struct {
+	Roles       []RoleCount `json:"roles"`
+	Scarce      []string    `json:"scarce"`
+	Crowded     []string    `json:"crowded"`
+	Suggestions []Flex      `json:"suggestions"`
+	Notes       []string    `json:"notes"`
+}
+
+// RoleCoverage analyzes players' lanes for two teams. Returns nil for
+// rosters too small to fill a team.
+func RoleCoverage(players []Player) *Coverage {
+	if len(players) < len(Roles) {
+		return nil
+	}
+	needed := max(1, len(players)/len(Roles))
+	cov := &Coverage{Roles: []RoleCount{}, Scarce: []string{}, Crowded: []string{}, Suggestions: []Flex{}, Notes: []string{}}
+	primary := map[string]int{}
+	first := make([]string, len(players)) // each player's first main lane, "" if none
+	for i, p := range players {
+		for _, l := range p.MainLanes {
+			if isRole(l) {
+				first[i] = l
+				break
+			}
+		}
+		primary[first[i]]++
+	}
+	for _, role := range Roles {
+		rc := RoleCount{Role: role, Needed: needed, Primary: primary[role]}
+		for _, p := range players {
+			switch c := Comfort(p, role); {
+			case c >= 2:
+				rc.Mains++
+			case c == 1:
+				rc.Subs++
+			}
+		}
+		cov.Roles = append(cov.Roles, rc)
+		switch {
+		case rc.Primary < needed:
+			cov.Scarce = append(cov.Scarce, role)
+			cov.Notes = append(cov.Notes, fmt.Sprintf("%s: %d first-choice players for %d slots (%d more as a second main, %d as a sub lane)",
+				role, rc.Primary, needed, rc.Mains-rc.Primary, rc.Subs))
+		case rc.Primary > needed:
+			cov.Crowded = append(cov.Crowded, role)
+			cov.Notes = append(cov.Notes, fmt.Sprintf("%s: %d first-choice players for %d slots", role, rc.Primary, needed))
+		}
+	}
+	if n := primary[""]; n > 0 {
+		cov.Notes = append(cov.Notes, fmt.Sprintf("%d players have no main lane and can fill anywhere", n))
+	}
+
+	// Fill each scarce role from players who can leave theirs: those with no
+	// main lane, then crowded roles' players, most comfortable first.
+	moved := make([]bool, len(players))
+	for _, role := range cov.Scarce {
+		for primary[role] < needed {
+			best, bestRank := -1, -1
+			for i, p := range players {
+				if moved[i] || first[i] == role || (first[i] != "" && primary[first[i]] <= needed) {
+					continue
+				}
+				// comfort decides; at equal comfort a player without a lane goes first
+				rank := 2 * Comfort(p, role)
+				if first[i] == "" {
+					rank++
+				}
+				if rank > bestRank {
+					best, bestRank = i, rank
+				}
+			}
+			if best < 0 {
+				break
+			}
+			moved[best] = true
+			primary[first[best]]--
+			primary[role]++
+			cov.Suggestions = append(cov.Suggestions, Flex{
+				Player: players[best].Name, From: first[best], To: role, Comfort: Comfort(players[best], role),
+			})
+		}
+	}
+	return cov
+}
@@ -0,0 +1,62 @@
+package balance
+
+// Group rules.
+const (
+	// GroupSpread splits the members as evenly as the teams allow
+	// (e.g. new players).
+	GroupSpread = "spread"
+	// GroupTogether keeps the members on the same team (e.g. a duo).
+	GroupTogether = "together"
+)
+
+// Group is a set of players (indices into the players) the splitters keep to
+// Rule while balancing.
+type Group struct {
+	Rule    string
+	Members []int
+}
+
+// Keeps reports whether team A made of the indices in a keeps g's rule.
+func (g Group) Keeps(a []int) bool {
+	k := 0
+	for _, m := range g.Members {
+		for _, i := range a {
+			if i == m {
+				k++
+				break
+			}
+		}
+	}
+	switch g.Rule {
+	case GroupSpread:
+		return abs(2*k-len(g.Members)) <= 1
+	case GroupTogether:
+		return k == 0 || k == len(g.Members)
+	}
+	return true
+}
+
+// allows reports whether team A made of the indices in a keeps the captains
+// on their teams and every group's rule.
+func (opts Options) allows(a []int) bool {
+	if !opts.Captains.allows(a) {
+		return false
+	}
+	for _, g := range opts.Groups {
+		if !g.Keeps(a) {
+			return false
+		}
+	}
+	return true
+}
+
+// keepGroups runs a role split under opts.Groups and, when no split keeps
+// them all, again without; callers check the result with Group.Keeps.
+func keepGroups(players []Player, opts Options, split func([]Player, Options) *RoleSplit) *RoleSplit {
+	rs := capLanes(players, opts, split)
+	if rs != nil || len(opts.Groups) == 0 {
+		return rs
+	}
+	opts.Groups = nil
+	return capLanes(players, opts, split)
+}
@@ -0,0 +1,61 @@
+package balance
+
+// LaneGap reports the lane-gap cap on a role split: the largest skill gap
+// between the two players of a role, and whether no split kept every lane
+// within the cap so it was dropped.
+type LaneGap struct {
+	Cap     int    `json:"cap"`
+	Max     int    `json:"max"`
+	Lane    string `json:"lane"` // the role with the largest gap
+	Relaxed bool   `json:"relaxed"`
+}
+
+// widestLane returns the role of rs with the largest skill gap and that gap.
+func widestLane(rs *RoleSplit) (string, int) {
+	lane, gap := "", -1
+	for _, a := range rs.TeamA {
+		for _, b := range rs.TeamB {
+			if a.Role == b.Role && a.Role != Fill && abs(a.Skill-b.Skill) > gap {
+				lane, gap = a.Role, abs(a.Skill-b.Skill)
+			}
+		}
+	}
+	return lane, gap
+}
+
+// withinGap reports whether every role keeps its two players within
+// opts.LaneGap (0 = no cap). Team a plays rolesA, b rolesB; Fill is no lane.
+func (opts Options) withinGap(players []Player, a, b []int, rolesA, rolesB []string) bool {
+	if opts.LaneGap <= 0 {
+		return true
+	}
+	for i, x := range a {
+		for j, y := range b {
+			if rolesA[i] == rolesB[j] && rolesA[i] != Fill && abs(players[x].Score-players[y].Score) > opts.LaneGap {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// capLanes runs split under opts.LaneGap and, when no split keeps every lane
+// within it, again without, reporting the outcome on the split.
+func capLanes(players []Player, opts Options, split func([]Player, Options) *RoleSplit) *RoleSplit {
+	rs := split(players, opts)
+	if opts.LaneGap <= 0 {
+		return rs
+	}
+	relaxed := rs == nil
+	if relaxed {
+		uncapped := opts
+		uncapped.LaneGap = 0
+		if rs = split(players, uncapped); rs == nil {
+			return nil
+		}
+	}
+	// teams of under five may share no role, leaving no gap to report
+	lane, gap := widestLane(rs)
+	rs.LaneGap = &LaneGap{Cap: opts.LaneGap, Max: max(gap, 0), Lane: lane, Relaxed: relaxed}
+	return rs
+}
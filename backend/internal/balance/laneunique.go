@@ -0,0 +1,52 @@
+package balance
+
+// LaneUnique gives every player a role, distinct within the team, and
+// balances the teams and the players' lane fit together: among the splits,
+// each team's roles having the least misfit (see Misfit), the one with the
+// lowest cost under opts.Objective plus opts.LaneWeight per misfit step wins,
+// so a player leaves their main lanes only for enough balance. Teams are half
+// the lobby each; past five players they share Fill (see roleSearch).
+// Captains stay on their teams and get their fixed roles whatever their
+// lanes; groups keep their rules. Returns nil under four players.
+func LaneUnique(players []Player, opts Options) *RoleSplit {
+	return keepGroups(players, opts, laneUnique)
+}
+
+func laneUnique(players []Player, opts Options) *RoleSplit {
+	return roleSearch(players, opts, false)
+}
+
+// forEachHalf enumerates every way to pick n/2 indices for A (in index order),
+// passing A and the remaining indices as B.
+func forEachHalf(n int, fn func(a, b []int)) {
+	var comb func(start int, acc []int)
+	comb = func(start int, acc []int) {
+		if len(acc) == n/2 {
+			inA := make([]bool, n)
+			for _, i := range acc {
+				inA[i] = true
+			}
+			b := make([]int, 0, n-len(acc))
+			for i := 0; i < n; i++ {
+				if !inA[i] {
+					b = append(b, i)
+				}
+			}
+			fn(append([]int{}, acc...), b)
+			return
+		}
+		for i := start; i < n; i++ {
+			comb(i+1, append(acc, i))
+		}
+	}
+	comb(0, make([]int, 0, n/2))
+}
+
+func isRole(lane string) bool {
+	for _, r := range Roles {
+		if r == lane {
+			return true
+		}
+	}
+	return false
+}
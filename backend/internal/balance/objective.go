@@ -0,0 +1,106 @@
+package balance
+
+import "sort"
+
+// Objective is what the splitters minimize between the two teams.
+type Objective string
+
+const (
+	// ObjectiveSum compares total skill: |sumA - sumB|.
+	ObjectiveSum Objective = "sum"
+	// ObjectiveSlotwise compares the teams' shapes: each team sorted by skill,
+	// the gaps between their best players, second best and so on, summed,
+	// plus the total difference to prefer the closer totals among equally
+	// shaped splits. It avoids one team having both the best and the worst
+	// player while the totals match.
+	ObjectiveSlotwise Objective = "slotwise"
+)
+
+// Options are the rules a split follows beyond balancing.
+type Options struct {
+	// Captains seeds the teams (nil = none).
+	Captains *Captains
+	// Objective defaults to ObjectiveSum.
+	Objective Objective
+	// LaneGap caps the skill gap between the two players of a role in the
+	// role splits (0 = no cap). When no split keeps every lane within it the
+	// cap is dropped and the split says so (RoleSplit.LaneGap).
+	LaneGap int
+	// Groups are players kept together or spread over the teams. They win
+	// over LaneGap; when no split keeps them all they are dropped.
+	Groups []Group
+	// LaneWeight is the skill points one step of lane misfit is worth in
+	// LaneUnique's objective (0 = DefaultLaneWeight).
+	LaneWeight int
+}
+
+// maxSearch is the largest roster Alternate searches exhaustively for the
+// slotwise objective (C(20,10) splits); larger ones are dealt in turn, which
+// pairs players of adjacent skill anyway.
+const maxSearch = 20
+
+// cost scores teams a and b (indices into players) under o.
+func (o Objective) cost(players []Player, a, b []int) int {
+	d := 0
+	for _, i := range a {
+		d += players[i].Score
+	}
+	for _, i := range b {
+		d -= players[i].Score
+	}
+	d = abs(d)
+	if o != ObjectiveSlotwise {
+		return d
+	}
+	sorted := func(team []int) []int {
+		s := make([]int, len(team))
+		for k, i := range team {
+			s[k] = players[i].Score
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(s)))
+		return s
+	}
+	sa, sb := sorted(a), sorted(b)
+	// with an odd roster the larger team's weakest player has no counterpart
+	for k := 0; k < min(len(sa), len(sb)); k++ {
+		d += abs(sa[k] - sb[k])
+	}
+	return d
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// search tries every split with the larger half on A and returns the one
+// with the lowest cost under opts.
+func search(players []Player, opts Options) Split {
+	n := len(players)
+	best, bestCost := Split{A: []int{}, B: []int{}}, -1
+	forEachHalf(n, func(small, large []int) {
+		a, b := small, large
+		if n%2 == 1 {
+			a, b = large, small
+		}
+		if !opts.allows(a) {
+			return
+		}
+		if c := opts.Objective.cost(players, a, b); bestCost < 0 || c < bestCost {
+			best, bestCost = Split{A: a, B: b}, c
+		}
+	})
+	if bestCost < 0 && len(opts.Groups) > 0 {
+		opts.Groups = nil
+		return search(players, opts)
+	}
+	for _, i := range best.A {
+		best.SumA += players[i].Score
+	}
+	for _, i := range best.B {
+		best.SumB += players[i].Score
+	}
+	return best
+}
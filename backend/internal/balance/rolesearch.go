@@ -0,0 +1,212 @@
+package balance
+
+import (
+	"math/bits"
+	"slices"
+)
+
+// maxRoleSearch is the largest lobby the role search tries exhaustively
+// (C(16,8) splits); larger ones start from Alternate and swap players between
+// the teams while that improves the split.
+const maxRoleSearch = 16
+
+// minRoleSplit is the smallest lobby worth giving roles: two per team.
+const minRoleSplit = 4
+
+// DefaultLaneWeight is the skill points one step of lane misfit (see Misfit)
+// is worth in LaneUnique's objective: moving a player from their first main
+// lane to a sub lane must buy at least 200 points of balance.
+const DefaultLaneWeight = 100
+
+// Misfit scores how far a role is from a player's lanes, the complement of
+// Comfort: 1st main lane=0, 2nd main lane=1, sub lane=2, autofill=3. Flexible
+// players fit their sub lanes (0) and any other role at 1; Fill fits everyone.
+func Misfit(p Player, role string) int {
+	switch {
+	case role == Fill:
+		return 0
+	case p.Flexible:
+		return 1 - min(Comfort(p, role), 1)
+	}
+	return 3 - Comfort(p, role)
+}
+
+// laneWeight is opts.LaneWeight or its default.
+func (opts Options) laneWeight() int {
+	if opts.LaneWeight > 0 {
+		return opts.LaneWeight
+	}
+	return DefaultLaneWeight
+}
+
+// roleCandidate is a split with each team's roles, scored for roleSearch.
+type roleCandidate struct {
+	a, b           []int
+	rolesA, rolesB []string
+	overGap        bool // a lane over opts.LaneGap
+	comfort        int
+	misfit         int
+	cost           int
+	// score is the weighted objective: cost plus laneWeight per misfit step
+	score int
+}
+
+// better reports whether x beats y (nil = none yet): keeping opts.LaneGap
+// first, then the lowest score (balance_first) or the most comfort and then
+// the lowest cost (comfortFirst, roles_first).
+func (x *roleCandidate) better(y *roleCandidate, comfortFirst bool) bool {
+	switch {
+	case y == nil:
+		return true
+	case x.overGap != y.overGap:
+		return !x.overGap
+	case comfortFirst && x.comfort != y.comfort:
+		return x.comfort > y.comfort
+	case comfortFirst:
+		return x.cost < y.cost
+	case x.score != y.score:
+		return x.score < y.score
+	}
+	return x.comfort > y.comfort
+}
+
+// roleSearch finds the role split of teams of n/2, A getting the odd player
+// as when dealing. A team of up to five plays that many distinct roles,
+// picked by its players' lanes; a larger one fills every role and the rest
+// share Fill. Each team's roles have the least misfit (comfortFirst: the most
+// comfort); among splits the lowest weighted objective, the cost under
+// opts.Objective plus opts.laneWeight per misfit step, wins (comfortFirst:
+// the most comfort, then the lowest cost). Captains stay on their teams and
+// get their fixed roles; groups keep their rules. Returns nil under
+// minRoleSplit players or when no split keeps opts.LaneGap.
+func roleSearch(players []Player, opts Options, comfortFirst bool) *RoleSplit {
+	n := len(players)
+	if n < minRoleSplit {
+		return nil
+	}
+	fit := func(p Player, role string) int { return -Misfit(p, role) }
+	if comfortFirst {
+		fit = Comfort
+	}
+	eval := func(a, b []int) *roleCandidate {
+		rc := &roleCandidate{a: a, b: b, cost: opts.Objective.cost(players, a, b)}
+		rc.rolesA = teamRoles(players, a, opts.Captains, fit)
+		rc.rolesB = teamRoles(players, b, opts.Captains, fit)
+		for k, team := range [][]int{a, b} {
+			roles := [][]string{rc.rolesA, rc.rolesB}[k]
+			for i, idx := range team {
+				rc.comfort += Comfort(players[idx], roles[i])
+				rc.misfit += Misfit(players[idx], roles[i])
+			}
+		}
+		rc.score = rc.cost + opts.laneWeight()*rc.misfit
+		rc.overGap = !opts.withinGap(players, a, b, rc.rolesA, rc.rolesB)
+		return rc
+	}
+
+	var best *roleCandidate
+	if n <= maxRoleSearch {
+		forEachHalf(n, func(small, large []int) {
+			a, b := small, large
+			if n%2 == 1 {
+				a, b = large, small
+			}
+			if !opts.allows(a) {
+				return
+			}
+			if rc := eval(a, b); rc.better(best, comfortFirst) {
+				best = rc
+			}
+		})
+	} else {
+		s := Alternate(players, opts)
+		if !opts.allows(s.A) {
+			return nil
+		}
+		slices.Sort(s.A)
+		slices.Sort(s.B)
+		best = eval(s.A, s.B)
+		for {
+			var next *roleCandidate
+			for i := range best.a {
+				for j := range best.b {
+					a, b := slices.Clone(best.a), slices.Clone(best.b)
+					a[i], b[j] = b[j], a[i]
+					slices.Sort(a)
+					slices.Sort(b)
+					if !opts.allows(a) {
+						continue
+					}
+					if rc := eval(a, b); rc.better(best, comfortFirst) && rc.better(next, comfortFirst) {
+						next = rc
+					}
+				}
+			}
+			if next == nil {
+				break
+			}
+			best = next
+		}
+	}
+	if best == nil || best.overGap {
+		return nil
+	}
+	return newRoleSplit(players, best.a, best.b, best.rolesA, best.rolesB)
+}
+
+// teamRoles gives team the roles with the highest total fit: a distinct role
+// each while roles are left, then Fill. Captains get their fixed roles.
+func teamRoles(players []Player, team []int, c *Captains, fit func(Player, string) int) []string {
+	k := len(team)
+	fills := max(0, k-len(Roles))
+	masks := 1 << len(Roles)
+
+	// best[i][m] is the top fit of the first i players holding the roles in m,
+	// reached[i][m] whether any assignment gets there; from[i][m] is the choice
+	// of player i-1 behind it, len(Roles) for Fill
+	best, reached, from := make([][]int, k+1), make([][]bool, k+1), make([][]int, k+1)
+	for i := range best {
+		best[i], reached[i], from[i] = make([]int, masks), make([]bool, masks), make([]int, masks)
+	}
+	reached[0][0] = true
+	for i, idx := range team {
+		p, fixed := players[idx], c.role(idx)
+		for m := range masks {
+			if !reached[i][m] {
+				continue
+			}
+			for r := 0; r <= len(Roles); r++ {
+				role, next := Fill, m
+				switch {
+				case r < len(Roles) && m&(1<<r) != 0:
+					continue
+				case r < len(Roles):
+					role, next = Roles[r], m|1<<r
+				case i-bits.OnesCount(uint(m)) >= fills:
+					continue
+				}
+				if fixed != "" && role != fixed {
+					continue
+				}
+				if f := best[i][m] + fit(p, role); !reached[i+1][next] || f > best[i+1][next] {
+					best[i+1][next], reached[i+1][next], from[i+1][next] = f, true, r
+				}
+			}
+		}
+	}
+
+	end := -1
+	for m := range masks {
+		if reached[k][m] && (end < 0 || best[k][m] > best[k][end]) {
+			end = m
+		}
+	}
+	roles := make([]string, k)
+	for i, m := k, end; i > 0; i-- {
+		roles[i-1] = Fill
+		if r := from[i][m]; r < len(Roles) {
+			roles[i-1], m = Roles[r], m&^(1<<r)
+		}
+	}
+	return roles
+}
@@ -0,0 +1,126 @@
+package balance
+
+// RolesFirst is the "mirror-then-balance" order used by some in-house leagues:
+// every role is filled by exactly two players chosen to maximize total comfort,
+// then each pair is split across teams to minimize the cost under
+// opts.Objective. Among equally comfortable role assignments the most
+// balanced one wins. Captains stay on their teams and fill their fixed roles;
+// groups keep their rules. Other lobby sizes can't mirror every role, so the
+// most comfortable split of half the players per team wins, then the most
+// balanced (see roleSearch).
+func RolesFirst(players []Player, opts Options) *RoleSplit {
+	return keepGroups(players, opts, rolesFirst)
+}
+
+func rolesFirst(players []Player, opts Options) *RoleSplit {
+	if len(players) != 2*len(Roles) {
+		return roleSearch(players, opts, true)
+	}
+	c := opts.Captains
+	comfort := make([][]int, len(players))
+	for i, p := range players {
+		comfort[i] = make([]int, len(Roles))
+		for r, role := range Roles {
+			comfort[i][r] = Comfort(p, role)
+		}
+	}
+
+	// pairs[r] holds the two player indices assigned to role r
+	pairs := make([][]int, len(Roles))
+	bestComfort, bestDiff := -1, 1<<30
+	var bestPairs [][]int
+	var bestMask int
+
+	// fixing role 0's orientation halves the search (A/B mirror), unless
+	// captains tell A from B
+	free := len(Roles) - 1
+	if c != nil {
+		free = len(Roles)
+	}
+	// flipped reports whether mask sends the second of role r's pair to team A
+	flipped := func(mask, r int) bool {
+		if c == nil {
+			return r > 0 && mask&(1<<(r-1)) != 0
+		}
+		return mask&(1<<r) != 0
+	}
+
+	// balancePairs picks, per role, which of the two goes to team A; ok=false
+	// when no choice keeps the groups
+	balancePairs := func() (d, mask int, ok bool) {
+		minDiff, minMask := 1<<30, 0
+		a, b := make([]int, len(Roles)), make([]int, len(Roles))
+	masks:
+		for mask := 0; mask < 1<<free; mask++ {
+			for r, pr := range pairs {
+				a[r], b[r] = pr[0], pr[1]
+				if flipped(mask, r) {
+					a[r], b[r] = b[r], a[r]
+				}
+				if c.team(a[r]) == 'B' || c.team(b[r]) == 'A' {
+					continue masks
+				}
+			}
+			if !opts.allows(a) {
+				continue
+			}
+			if d := opts.Objective.cost(players, a, b); d < minDiff {
+				minDiff, minMask, ok = d, mask, true
+			}
+		}
+		return minDiff, minMask, ok
+	}
+
+	var assign func(i, total int)
+	assign = func(i, total int) {
+		if i == len(players) {
+			if total < bestComfort {
+				return
+			}
+			for _, pr := range pairs {
+				if opts.LaneGap > 0 && abs(players[pr[0]].Score-players[pr[1]].Score) > opts.LaneGap {
+					return
+				}
+			}
+			d, mask, ok := balancePairs()
+			if !ok {
+				return
+			}
+			if total > bestComfort || d < bestDiff {
+				bestComfort, bestDiff, bestMask = total, d, mask
+				bestPairs = make([][]int, len(pairs))
+				for r := range pairs {
+					bestPairs[r] = append([]int{}, pairs[r]...)
+				}
+			}
+			return
+		}
+		for r := range Roles {
+			if len(pairs[r]) == 2 {
+				continue
+			}
+			if role := c.role(i); role != "" && role != Roles[r] {
+				continue
+			}
+			pairs[r] = append(pairs[r], i)
+			assign(i+1, total+comfort[i][r])
+			pairs[r] = pairs[r][:len(pairs[r])-1]
+		}
+	}
+	assign(0, 0)
+	if bestPairs == nil {
+		return nil
+	}
+
+	var a, b []int
+	var rolesA, rolesB []string
+	for r, pr := range bestPairs {
+		x, y := pr[0], pr[1]
+		if flipped(bestMask, r) {
+			x, y = y, x
+		}
+		a, b = append(a, x), append(b, y)
+		rolesA, rolesB = append(rolesA, Roles[r]), append(rolesB, Roles[r])
+	}
+	return newRoleSplit(players, a, b, rolesA, rolesB)
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LRU is a concurrency-safe map of at most size entries, each expiring after
+// a fixed duration; when full, the least recently used entry makes room.
+type LRU[K comparable, V any] struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	size  int
+	order *list.List // of *lruEntry, most recently used first
+	items map[K]*list.Element
+}
+
+type lruEntry[K comparable, V any] struct {
+	key K
+	entry[V]
+}
+
+func NewLRU[K comparable, V any](size int, ttl time.Duration) *LRU[K, V] {
+	return &LRU[K, V]{ttl: ttl, size: max(size, 1), order: list.New(), items: map[K]*list.Element{}}
+}
+
+func (c *LRU[K, V]) Get(k K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[k]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	e := el.Value.(*lruEntry[K, V])
+	if time.Now().After(e.expires) {
+		c.order.Remove(el)
+		delete(c.items, k)
+		var zero V
+		return zero, false
+	}
+	c.order.MoveToFront(el)
+	return e.value, true
+}
+
+func (c *LRU[K, V]) Set(k K, v V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := entry[V]{value: v, expires: time.Now().Add(c.ttl)}
+	if el, ok := c.items[k]; ok {
+		el.Value.(*lruEntry[K, V]).entry = e
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[k] = c.order.PushFront(&lruEntry[K, V]{key: k, entry: e})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry[K, V]).key)
+	}
+}
+
+func (c *LRU[K, V]) Delete(k K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[k]; ok {
+		c.order.Remove(el)
+		delete(c.items, k)
+	}
+}
+
+// Clear removes every entry.
+func (c *LRU[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	clear(c.items)
+}
+
+// Len is the number of entries, expired ones included until they are read or
+// pushed out.
+func (c *LRU[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,54 @@
+// Package cache holds small in-process caches shared by the server components.
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+type entry[V any] struct {
+	value   V
+	expires time.Time
+}
+
+// TTL is a concurrency-safe map whose entries expire after a fixed duration.
+type TTL[K comparable, V any] struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	items map[K]entry[V]
+}
+
+func NewTTL[K comparable, V any](ttl time.Duration) *TTL[K, V] {
+	return &TTL[K, V]{ttl: ttl, items: map[K]entry[V]{}}
+}
+
+func (c *TTL[K, V]) Get(k K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[k]
+	if !ok || time.Now().After(e.expires) {
+		delete(c.items, k)
+		var zero V
+		return zero, false
+	}
+	return e.value, true
+}
+
+func (c *TTL[K, V]) Set(k K, v V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items[k] = entry[V]{value: v, expires: time.Now().Add(c.ttl)}
+}
+
+func (c *TTL[K, V]) Delete(k K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, k)
+}
+
+// Clear removes every entry.
+func (c *TTL[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.items)
+}
@@ -0,0 +1,201 @@
+// Package clock is the time source of the code that paces, backs off and
+// schedules: the Riot limiter, client and breaker, and the background workers.
+// They run on Real unless given another Clock; a Fake lets a caller move time
+// by hand, so a limiter or retry run that would sleep for minutes finishes at
+// once and always the same way.
+package clock
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and waits.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer fires once on C, like time.Timer.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// Ticker fires on C every period, like time.Ticker.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Real is the system clock.
+var Real Clock = system{}
+
+// Or returns c, or Real when c is nil.
+func Or(c Clock) Clock {
+	if c == nil {
+		return Real
+	}
+	return c
+}
+
+// Since is the time elapsed on c since t.
+func Since(c Clock, t time.Time) time.Duration { return c.Now().Sub(t) }
+
+// SleepContext waits d on c, or until ctx is done and returns its error. On a
+// Fake it advances the clock instead, as Sleep does.
+func SleepContext(ctx context.Context, c Clock, d time.Duration) error {
+	if f, ok := c.(*Fake); ok {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		f.Advance(d)
+		return nil
+	}
+	t := c.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C():
+		return nil
+	}
+}
+
+type system struct{}
+
+func (system) Now() time.Time                   { return time.Now() }
+func (system) Sleep(d time.Duration)            { time.Sleep(d) }
+func (system) NewTimer(d time.Duration) Timer   { return systemTimer{time.NewTimer(d)} }
+func (system) NewTicker(d time.Duration) Ticker { return systemTicker{time.NewTicker(d)} }
+
+type systemTimer struct{ t *time.Timer }
+
+func (t systemTimer) C() <-chan time.Time { return t.t.C }
+func (t systemTimer) Stop() bool          { return t.t.Stop() }
+
+type systemTicker struct{ t *time.Ticker }
+
+func (t systemTicker) C() <-chan time.Time { return t.t.C }
+func (t systemTicker) Stop()               { t.t.Stop() }
+
+// Fake is a Clock that only moves when told to: by Advance, or by Sleep and
+// SleepContext, which advance it by the time slept. Timers and tickers fire, in order of
+// their due time, as Advance passes it; like time's, a ticker whose last tick
+// hasn't been received drops the next. It is safe for concurrent use, but a
+// fake clock moved by several sleepers at once moves by all their sleeps.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	seq     uint64
+	pending []*fakeTimer
+}
+
+// NewFake returns a fake clock reading now.
+func NewFake(now time.Time) *Fake { return &Fake{now: now} }
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Sleep advances the clock by d: whoever sleeps on a fake clock drives it.
+func (f *Fake) Sleep(d time.Duration) { f.Advance(d) }
+
+// Advance moves the clock d ahead, firing the timers and tickers due by then.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	to := f.now.Add(max(d, 0))
+	for len(f.pending) > 0 && !f.pending[0].at.After(to) {
+		t := f.pending[0]
+		f.now = t.at
+		select {
+		case t.c <- t.at:
+		default:
+		}
+		if t.period > 0 {
+			t.at = t.at.Add(t.period)
+			f.seq++
+			t.seq = f.seq
+		} else {
+			f.pending = f.pending[1:]
+			t.stopped = true
+		}
+		f.sort()
+	}
+	f.now = to
+}
+
+func (f *Fake) NewTimer(d time.Duration) Timer { return f.add(d, 0) }
+
+// NewTicker panics when d <= 0, as time.NewTicker does.
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	return fakeTicker{f.add(d, d)}
+}
+
+func (f *Fake) add(d, period time.Duration) *fakeTimer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seq++
+	t := &fakeTimer{f: f, at: f.now.Add(d), period: period, seq: f.seq, c: make(chan time.Time, 1)}
+	if period == 0 && d <= 0 {
+		t.c <- f.now
+		t.stopped = true
+		return t
+	}
+	f.pending = append(f.pending, t)
+	f.sort()
+	return t
+}
+
+// sort orders pending by due time, then creation. The caller holds f.mu.
+func (f *Fake) sort() {
+	sort.Slice(f.pending, func(i, j int) bool {
+		a, b := f.pending[i], f.pending[j]
+		if !a.at.Equal(b.at) {
+			return a.at.Before(b.at)
+		}
+		return a.seq < b.seq
+	})
+}
+
+type fakeTimer struct {
+	f       *Fake
+	at      time.Time
+	period  time.Duration // 0 for a timer
+	seq     uint64
+	c       chan time.Time
+	stopped bool
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.c }
+
+// Stop reports whether it stopped the timer before it fired.
+func (t *fakeTimer) Stop() bool {
+	t.f.mu.Lock()
+	defer t.f.mu.Unlock()
+	if t.stopped {
+		return false
+	}
+	t.stopped = true
+	for i, p := range t.f.pending {
+		if p == t {
+			t.f.pending = append(t.f.pending[:i], t.f.pending[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+type fakeTicker struct{ *fakeTimer }
+
+func (t fakeTicker) Stop() { t.fakeTimer.Stop() }
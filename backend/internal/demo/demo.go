@@ -0,0 +1,23 @@
+// Package demo holds the sample roster DEMO_MODE serves: ten made-up players
+// analyzed from synthetic matches, so the hosted frontend can be tried
+// without spending Riot quota.
+package demo
+
+import (
+	_ "embed"
+	"encoding/json"
+
+	"lol_custom_skill_matching/internal/analyzer"
+)
+
+//go:embed roster.json
+var rosterJSON []byte
+
+// Roster returns the sample players' profiles, a fresh copy each call.
+func Roster() []analyzer.Profile {
+	var ps []analyzer.Profile
+	if err := json.Unmarshal(rosterJSON, &ps); err != nil {
+		panic("demo: bad roster.json: " + err.Error())
+	}
+	return ps
+}
@@ -0,0 +1,294 @@
+// Package digest posts a daily or weekly summary of the community snapshot
+// (rank-ups, rank-downs, biggest climbers and upcoming lobbies) to each
+// community's Discord channel, worded by its own message template.
+package digest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"lol_custom_skill_matching/internal/assets"
+	"lol_custom_skill_matching/internal/signing"
+	"lol_custom_skill_matching/internal/snapshot"
+)
+
+const (
+	PeriodDaily  = "daily"
+	PeriodWeekly = "weekly"
+)
+
+// discordLimit is the most characters a Discord message may have.
+const discordLimit = 2000
+
+// DefaultTemplate is used by communities without a template of their own.
+const DefaultTemplate = `**{{if eq .Period "weekly"}}週間{{else}}今日の{{end}}ダイジェスト**（{{.TakenAt.Format "1/2"}}）
+{{- if .RankUps}}
+
+:arrow_up: **ランクアップ**
+{{- range .RankUps}}
+・{{.Player}}: {{rank .From}} → {{rank .To}}
+{{- end}}
+{{- end}}
+{{- if .RankDowns}}
+
+:arrow_down: **ランクダウン**
+{{- range .RankDowns}}
+・{{.Player}}: {{rank .From}} → {{rank .To}}
+{{- end}}
+{{- end}}
+{{- if .Climbers}}
+
+:chart_with_upwards_trend: **上昇幅トップ**
+{{- range $i, $m := .Climbers}}
+{{add $i 1}}. {{$m.Player}}: {{signed $m.RankDelta}}（{{rank $m.To}}）
+{{- end}}
+{{- end}}
+{{- if .Upcoming}}
+
+:calendar: **予定されているカスタム**
+{{- range .Upcoming}}
+・{{.StartsAt.Local.Format "1/2 15:04"}} {{.Name}}（{{.Players}}人）
+{{- end}}
+{{- end}}`
+
+// Community is one digest destination.
+type Community struct {
+	Name string `json:"name"`
+	// Webhook is the Discord webhook URL, or "secret:<name>" to read it from
+	// the secrets vault when posting.
+	Webhook string `json:"webhook"`
+	// Period is "daily" (default) or "weekly"; a weekly digest is posted on
+	// Weekday ("sunday" by default) and covers the seven days before.
+	Period  string `json:"period"`
+	Weekday string `json:"weekday"`
+	// Template is a text/template rendering Data ("" = DefaultTemplate).
+	Template string `json:"template"`
+	// Climbers is how many of the biggest climbers to list (default 3).
+	Climbers int `json:"climbers"`
+
+	tmpl    *template.Template
+	weekday time.Weekday
+}
+
+// Data is what a template renders.
+type Data struct {
+	Community string
+	Period    string
+	TakenAt   time.Time
+	// Since is the snapshot the movements are counted from (nil = none).
+	Since *time.Time
+	// RankUps and RankDowns changed tier or division; Climbers gained the most
+	// rank score, biggest first.
+	RankUps   []snapshot.Movement
+	RankDowns []snapshot.Movement
+	Climbers  []snapshot.Movement
+	// Upcoming are the lobbies scheduled before the next digest.
+	Upcoming []snapshot.Event
+	Members  int
+}
+
+// Empty reports whether there is nothing to post.
+func (d Data) Empty() bool {
+	return len(d.RankUps)+len(d.RankDowns)+len(d.Climbers)+len(d.Upcoming) == 0
+}
+
+var funcs = template.FuncMap{
+	"rank": func(r *assets.Rank) string {
+		if r == nil {
+			return "Unranked"
+		}
+		return r.Label
+	},
+	"signed": func(n int) string { return fmt.Sprintf("%+d", n) },
+	"add":    func(a, b int) int { return a + b },
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+// LoadConfig reads the communities from a JSON file holding an array of
+// Community and checks them.
+func LoadConfig(path string) ([]Community, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cs []Community
+	if err := json.Unmarshal(raw, &cs); err != nil {
+		return nil, err
+	}
+	for i := range cs {
+		if err := cs[i].compile(); err != nil {
+			return nil, err
+		}
+	}
+	return cs, nil
+}
+
+// compile fills in defaults and parses the template.
+func (c *Community) compile() error {
+	if c.Name == "" {
+		return fmt.Errorf("digest community without a name")
+	}
+	if c.Webhook == "" {
+		return fmt.Errorf("digest %s: webhook is required", c.Name)
+	}
+	switch c.Period {
+	case "":
+		c.Period = PeriodDaily
+	case PeriodDaily, PeriodWeekly:
+	default:
+		return fmt.Errorf("digest %s: invalid period %q (daily|weekly)", c.Name, c.Period)
+	}
+	if c.Weekday == "" {
+		c.Weekday = "sunday"
+	}
+	wd, ok := weekdays[strings.ToLower(c.Weekday)]
+	if !ok {
+		return fmt.Errorf("digest %s: invalid weekday %q", c.Name, c.Weekday)
+	}
+	c.weekday = wd
+	if c.Climbers <= 0 {
+		c.Climbers = 3
+	}
+	text := c.Template
+	if text == "" {
+		text = DefaultTemplate
+	}
+	t, err := template.New(c.Name).Funcs(funcs).Parse(text)
+	if err != nil {
+		return fmt.Errorf("digest %s: %w", c.Name, err)
+	}
+	c.tmpl = t
+	return nil
+}
+
+// Poster renders and posts the digests of every community.
+type Poster struct {
+	Communities []Community
+	// Snapshots supplies the snapshot a week back for weekly digests; without
+	// it (or that snapshot) they count from the previous snapshot.
+	Snapshots *snapshot.Scheduler
+	HTTP      *http.Client
+	// Resolve turns a webhook "secret:<name>" into the URL (nil = plain URLs only).
+	Resolve func(string) (string, error)
+	// Signer signs the posts (nil = unsigned).
+	Signer *signing.Signer
+}
+
+func NewPoster(cs []Community, snaps *snapshot.Scheduler) *Poster {
+	return &Poster{Communities: cs, Snapshots: snaps, HTTP: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Community returns the community called name.
+func (p *Poster) Community(name string) (Community, bool) {
+	for _, c := range p.Communities {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Community{}, false
+}
+
+// Post sends each community due on snap's day its digest of snap. Digests
+// with nothing to report aren't posted; failures are logged.
+func (p *Poster) Post(snap snapshot.Snapshot) {
+	for _, c := range p.Communities {
+		if c.Period == PeriodWeekly && snap.TakenAt.Weekday() != c.weekday {
+			continue
+		}
+		d := p.Data(c, snap)
+		if d.Empty() {
+			log.Printf("digest %s: nothing to post", c.Name)
+			continue
+		}
+		text, err := Render(c, d)
+		if err == nil {
+			err = p.send(c.Webhook, text)
+		}
+		if err != nil {
+			log.Printf("digest %s: %v", c.Name, err)
+			continue
+		}
+		log.Printf("digest %s: posted %s digest", c.Name, c.Period)
+	}
+}
+
+// Data picks what c's digest of snap reports.
+func (p *Poster) Data(c Community, snap snapshot.Snapshot) Data {
+	d := Data{Community: c.Name, Period: c.Period, TakenAt: snap.TakenAt, Since: snap.PreviousAt, Members: len(snap.Members)}
+	moves, window := snap.Movements, 24*time.Hour
+	if c.Period == PeriodWeekly {
+		window = 7 * 24 * time.Hour
+		if p.Snapshots != nil {
+			if prev, ok := p.Snapshots.Stored(snap.TakenAt.AddDate(0, 0, -7)); ok {
+				moves, d.Since = snapshot.Movements(prev.Members, snap.Members), &prev.TakenAt
+			}
+		}
+	}
+	for _, m := range moves {
+		switch {
+		case m.TierChange && m.RankDelta > 0:
+			d.RankUps = append(d.RankUps, m)
+		case m.TierChange && m.RankDelta < 0:
+			d.RankDowns = append(d.RankDowns, m)
+		}
+		if m.RankDelta > 0 && len(d.Climbers) < c.Climbers {
+			d.Climbers = append(d.Climbers, m) // moves are biggest climb first
+		}
+	}
+	for _, e := range snap.Upcoming {
+		if e.StartsAt.Before(snap.TakenAt.Add(window)) {
+			d.Upcoming = append(d.Upcoming, e)
+		}
+	}
+	return d
+}
+
+// Render executes c's template, cut to Discord's message limit.
+func Render(c Community, d Data) (string, error) {
+	var b strings.Builder
+	if err := c.tmpl.Execute(&b, d); err != nil {
+		return "", err
+	}
+	text := strings.TrimSpace(b.String())
+	if r := []rune(text); len(r) > discordLimit {
+		text = string(r[:discordLimit-1]) + "…"
+	}
+	return text, nil
+}
+
+// send posts text to a Discord webhook.
+func (p *Poster) send(url, text string) error {
+	if p.Resolve != nil {
+		var err error
+		if url, err = p.Resolve(url); err != nil {
+			return err
+		}
+	}
+	body, _ := json.Marshal(map[string]string{"content": text})
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	p.Signer.SignRequest(req, body)
+	resp, err := p.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook: HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Interaction types and responses (see Discord's interactions docs).
+const (
+	interactionPing    = 1
+	interactionCommand = 2
+
+	responsePong    = 1
+	responseMessage = 4
+	flagEphemeral   = 64
+)
+
+// permAdministrator and permManageGuild are the Administrator and Manage
+// Server permissions: the bot's commands are for the organizers.
+const (
+	permAdministrator = 1 << 3
+	permManageGuild   = 1 << 5
+)
+
+// Commands are the bot's slash commands, registered by RegisterCommands.
+var Commands = []Command{{
+	Name:        "forget",
+	Description: "Delete a player's stored data and keep them out of analyses",
+	Options: []CommandOption{{
+		Type: 3, Name: "riot_id", Description: "Riot ID, e.g. Alice#JP1", Required: true,
+	}},
+	DefaultMemberPermissions: strconv.Itoa(permManageGuild),
+}}
+
+// Command is a slash command's definition.
+type Command struct {
+	Name                     string          `json:"name"`
+	Description              string          `json:"description"`
+	Options                  []CommandOption `json:"options,omitempty"`
+	DefaultMemberPermissions string          `json:"default_member_permissions,omitempty"`
+}
+
+type CommandOption struct {
+	Type        int    `json:"type"` // 3 = string
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// Interaction is what Discord posts to the interactions endpoint: a ping,
+// or a slash command with its options.
+type Interaction struct {
+	Type int `json:"type"`
+	Data struct {
+		Name    string `json:"name"`
+		Options []struct {
+			Name  string          `json:"name"`
+			Value json.RawMessage `json:"value"`
+		} `json:"options"`
+	} `json:"data"`
+	Member *struct {
+		Permissions string `json:"permissions"`
+		User        struct {
+			ID       string `json:"id"`
+			Username string `json:"username"`
+		} `json:"user"`
+	} `json:"member"`
+}
+
+// Option is the string value of the command's option name ("" if absent).
+func (in Interaction) Option(name string) string {
+	for _, o := range in.Data.Options {
+		var v string
+		if o.Name == name && json.Unmarshal(o.Value, &v) == nil {
+			return v
+		}
+	}
+	return ""
+}
+
+// Organizer reports whether the member who ran the command may manage the
+// server. Commands sent outside a server (DMs) have no member and are refused.
+func (in Interaction) Organizer() bool {
+	if in.Member == nil {
+		return false
+	}
+	perms, err := strconv.ParseUint(in.Member.Permissions, 10, 64)
+	return err == nil && perms&(permAdministrator|permManageGuild) != 0
+}
+
+// ParsePublicKey reads the application's public key as shown in the
+// developer portal (hex).
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != ed25519.PublicKeySize {
+		return nil, errors.New("expected the application's 64-digit hex public key")
+	}
+	return ed25519.PublicKey(b), nil
+}
+
+// ReadInteraction reads an interaction posted by Discord, checking its
+// signature against key; Discord disables endpoints that accept forgeries.
+func ReadInteraction(r *http.Request, key ed25519.PublicKey) (Interaction, error) {
+	var in Interaction
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		return in, err
+	}
+	sig, err := hex.DecodeString(r.Header.Get("X-Signature-Ed25519"))
+	msg := append([]byte(r.Header.Get("X-Signature-Timestamp")), body...)
+	if err != nil || !ed25519.Verify(key, msg, sig) {
+		return in, errors.New("invalid request signature")
+	}
+	return in, json.Unmarshal(body, &in)
+}
+
+// Pong is the answer to a ping interaction.
+func Pong() any { return map[string]int{"type": responsePong} }
+
+// IsPing reports whether in is Discord checking the endpoint.
+func (in Interaction) IsPing() bool { return in.Type == interactionPing }
+
+// IsCommand reports whether in is a slash command.
+func (in Interaction) IsCommand() bool { return in.Type == interactionCommand }
+
+// Reply is a message answering a command, shown only to whoever ran it.
+func Reply(content string) any {
+	return map[string]any{"type": responseMessage, "data": map[string]any{"content": content, "flags": flagEphemeral}}
+}
+
+// RegisterCommands sets the bot application's slash commands to Commands,
+// replacing the ones registered before.
+func RegisterCommands(ctx context.Context, api, token string, client *http.Client) error {
+	var app struct {
+		ID string `json:"id"`
+	}
+	if err := call(ctx, client, http.MethodGet, api+"/oauth2/applications/@me", token, nil, &app); err != nil {
+		return fmt.Errorf("application: %w", err)
+	}
+	u := fmt.Sprintf("%s/applications/%s/commands", api, url.PathEscape(app.ID))
+	if err := call(ctx, client, http.MethodPut, u, token, Commands, nil); err != nil {
+		return fmt.Errorf("commands: %w", err)
+	}
+	return nil
+}
+
+// call sends a bot request with body as JSON, decoding the answer into out.
+func call(ctx context.Context, client *http.Client, method, u, token string, body, out any) error {
+	var rd io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		rd = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, rd)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bot "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out)
+}
@@ -0,0 +1,242 @@
+// Package discord syncs lobby RSVPs from reactions to a Discord message
+// (announcing the game night) through a bot's REST API: ✅ yes, ❓ maybe,
+// ❌ no. It also reads the bot's slash commands posted to the interactions
+// endpoint.
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"lol_custom_skill_matching/internal/clock"
+	"lol_custom_skill_matching/internal/store"
+)
+
+// DefaultAPI is Discord's REST API.
+const DefaultAPI = "https://discord.com/api/v10"
+
+// DefaultInterval is how often the reactions are read.
+const DefaultInterval = time.Minute
+
+// Reactions are the emojis read as each answer. A player who reacted with
+// several counts with the most cautious (no, then maybe).
+var Reactions = []struct{ Emoji, Status string }{
+	{"❌", store.RSVPNo},
+	{"❓", store.RSVPMaybe},
+	{"✅", store.RSVPYes},
+}
+
+// pastStart is how long after StartsAt a lobby's reactions are still read.
+const pastStart = 12 * time.Hour
+
+// reactionsPage is the most users Discord returns per request.
+const reactionsPage = 100
+
+// Stats are the syncer's totals since start, for GET /metrics.
+type Stats struct {
+	Runs    int64     `json:"runs"`
+	Updated int64     `json:"updated"` // RSVPs set from reactions
+	Errors  int64     `json:"errors"`
+	LastRun time.Time `json:"last_run"`
+}
+
+// RSVPSync reads the reactions of the messages linked to lobbies
+// (store.Lobby.Discord) every Interval and sets the RSVPs they answer.
+type RSVPSync struct {
+	Store store.Store
+	// Token is the bot token, or "secret:<name>" when Resolve reads it from
+	// the vault ("" = disabled). The bot needs to see the channels.
+	Token    string
+	Resolve  func(string) (string, error)
+	Interval time.Duration
+	API      string
+	HTTP     *http.Client
+	// Clock schedules the syncs (nil = the system clock).
+	Clock clock.Clock
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+func NewRSVPSync(st store.Store, token string) *RSVPSync {
+	return &RSVPSync{Store: st, Token: token, Interval: DefaultInterval, API: DefaultAPI, HTTP: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Enabled reports whether there is a bot token.
+func (s *RSVPSync) Enabled() bool { return s.Token != "" }
+
+// Stats returns the totals so far.
+func (s *RSVPSync) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// Run syncs right away and then every Interval until ctx is done. It returns
+// at once without a token.
+func (s *RSVPSync) Run(ctx context.Context) {
+	if !s.Enabled() {
+		return
+	}
+	clk := clock.Or(s.Clock)
+	t := clk.NewTicker(s.Interval)
+	defer t.Stop()
+	for {
+		s.Sync(ctx, clk.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C():
+		}
+	}
+}
+
+// Sync reads the reactions of every linked lobby that hasn't long started and
+// reports how many RSVPs changed. A lobby whose reactions can't be read is
+// logged and left as it was.
+func (s *RSVPSync) Sync(ctx context.Context, now time.Time) int {
+	token, err := s.token()
+	if err != nil {
+		log.Printf("discord rsvp: %v", err)
+		s.count(0, 1, now)
+		return 0
+	}
+	updated, errs := 0, 0
+	for _, l := range s.Store.Lobbies() {
+		if l.Discord == nil || (l.StartsAt != nil && now.After(l.StartsAt.Add(pastStart))) {
+			continue
+		}
+		answers, err := s.answers(ctx, token, *l.Discord)
+		if err != nil {
+			log.Printf("discord rsvp: lobby %s: %v", l.ID, err)
+			errs++
+			continue
+		}
+		if c := l; apply(&c, answers, now) == 0 && maps.Equal(l.Discord.Seen, answers) {
+			continue // nothing to write
+		}
+		n := 0
+		_, err = s.Store.UpdateLobby(l.ID, func(l *store.Lobby) error {
+			n = apply(l, answers, now)
+			return nil
+		})
+		if err != nil {
+			continue // deleted meanwhile
+		}
+		updated += n
+	}
+	s.count(updated, errs, now)
+	return updated
+}
+
+func (s *RSVPSync) count(updated, errs int, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.Runs++
+	s.stats.Updated += int64(updated)
+	s.stats.Errors += int64(errs)
+	s.stats.LastRun = now
+}
+
+func (s *RSVPSync) token() (string, error) {
+	if s.Resolve == nil {
+		return s.Token, nil
+	}
+	return s.Resolve(s.Token)
+}
+
+// answers maps the linked players who reacted to their answer.
+func (s *RSVPSync) answers(ctx context.Context, token string, link store.DiscordLink) (map[string]string, error) {
+	out := map[string]string{}
+	for _, r := range Reactions {
+		users, err := s.reactors(ctx, token, link.ChannelID, link.MessageID, r.Emoji)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range users {
+			if player, ok := link.Users[u]; ok {
+				if _, seen := out[player]; !seen { // Reactions is most cautious first
+					out[player] = r.Status
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+// apply sets the RSVPs that answers changed since the last sync and reports
+// how many; an answer given through the API meanwhile stands until the
+// player's reaction changes.
+func apply(l *store.Lobby, answers map[string]string, now time.Time) int {
+	link := *l.Discord
+	n := 0
+	for player, status := range answers {
+		if link.Seen[player] == status {
+			continue
+		}
+		if old, ok := l.RSVPOf(player); ok && old.Status == status {
+			continue
+		}
+		r := store.RSVP{Player: player, Status: status, Source: store.RSVPSourceDiscord, UpdatedAt: now}
+		if old, ok := l.RSVPOf(player); ok {
+			r.ArriveAt = old.ArriveAt
+		}
+		l.SetRSVP(r)
+		n++
+	}
+	if !maps.Equal(link.Seen, answers) {
+		link.Seen = answers
+		l.Discord = &link
+	}
+	return n
+}
+
+// reactors lists the ids of the users who reacted to a message with emoji.
+func (s *RSVPSync) reactors(ctx context.Context, token, channelID, messageID, emoji string) ([]string, error) {
+	var ids []string
+	after := ""
+	for {
+		u := fmt.Sprintf("%s/channels/%s/messages/%s/reactions/%s?limit=%d", s.API,
+			url.PathEscape(channelID), url.PathEscape(messageID), url.PathEscape(emoji), reactionsPage)
+		if after != "" {
+			u += "&after=" + url.QueryEscape(after)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bot "+token)
+		resp, err := s.HTTP.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		var users []struct {
+			ID string `json:"id"`
+		}
+		err = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&users)
+		resp.Body.Close()
+		switch {
+		case resp.StatusCode == http.StatusNotFound:
+			return ids, nil // nobody reacted with emoji (or the message is gone)
+		case resp.StatusCode >= 300:
+			return nil, fmt.Errorf("reactions %s: HTTP %d", emoji, resp.StatusCode)
+		case err != nil:
+			return nil, fmt.Errorf("reactions %s: %w", emoji, err)
+		}
+		for _, u := range users {
+			ids = append(ids, u.ID)
+		}
+		if len(users) < reactionsPage {
+			return ids, nil
+		}
+		after = users[len(users)-1].ID
+	}
+}
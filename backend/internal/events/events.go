@@ -0,0 +1,253 @@
+// Package events is the server's internal event bus. The analysis code
+// publishes what happened (an analyze job started or finished, a profile or
+// result was recorded, a subscribed rank moved, Riot's match API slowed down
+// or rejected the key) and the side effects subscribe to it: the result
+// files, the rank alerts, the alert and event webhooks, the GET /events
+// stream and the audit log. Each subscriber runs on a goroutine of its own,
+// so a slow one holds up neither the publisher nor the others.
+package events
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event types.
+const (
+	JobStarted     = "job.started"       // an analyze job started or was retried (JobData)
+	JobFinished    = "job.finished"      // an analyze job attempt ended, done or failed (JobData)
+	ProfileUpdated = "profile.updated"   // a fresh profile's rating was recorded (ProfileData)
+	ResultRecorded = "result.recorded"   // an analyze result was stored (ResultData)
+	RankChanged    = "rank.changed"      // a rank-alert subscriber's rank moved (RankData)
+	RiotDegraded   = "riot.degraded"     // a watched Riot endpoint broke its latency SLO (RiotData)
+	RiotRecovered  = "riot.recovered"    // it is back within the SLO (RiotData)
+	KeyRejected    = "riot.key_rejected" // Riot started rejecting the API key (KeyData)
+)
+
+// Types are the event types, in the order above.
+var Types = []string{JobStarted, JobFinished, ProfileUpdated, ResultRecorded, RankChanged, RiotDegraded, RiotRecovered, KeyRejected}
+
+// Event is something that happened. Subject is what it is about: the job id,
+// the player's Riot ID or the result id. Text says it in one line for chat.
+type Event struct {
+	Type    string    `json:"type"`
+	Time    time.Time `json:"time"`
+	Subject string    `json:"subject"`
+	Text    string    `json:"text"`
+	Data    any       `json:"data,omitempty"`
+}
+
+// JobData is the Data of JobStarted and JobFinished.
+type JobData struct {
+	Players int    `json:"players"`
+	Preset  string `json:"preset,omitempty"`
+	Attempt int    `json:"attempt"`
+	// the rest are set on JobFinished
+	State    string `json:"state,omitempty"` // done or failed
+	ResultID string `json:"result_id,omitempty"`
+	Failures int    `json:"failures,omitempty"` // players without a profile
+	Error    string `json:"error,omitempty"`
+}
+
+// ProfileData is the Data of ProfileUpdated.
+type ProfileData struct {
+	SkillScore    int    `json:"skill_score"`
+	Rank          string `json:"rank"` // e.g. "Gold II 45 LP"
+	GamesAnalyzed int    `json:"games_analyzed"`
+}
+
+// ResultData is the Data of ResultRecorded. A blind lobby's result is
+// Hidden: no sums, and only the players its lobby view shows.
+type ResultData struct {
+	LobbyID string   `json:"lobby_id,omitempty"`
+	Hidden  bool     `json:"hidden,omitempty"`
+	TeamA   []string `json:"teamA"`
+	TeamB   []string `json:"teamB"`
+	SumA    *int     `json:"sumA,omitempty"`
+	SumB    *int     `json:"sumB,omitempty"`
+}
+
+// RankData is the Data of RankChanged: the labels of the old and new rank,
+// and the new one in full (no tier when unranked).
+type RankData struct {
+	From     string `json:"from"` // rank labels, e.g. "Gold II 45 LP"
+	To       string `json:"to"`
+	Tier     string `json:"tier,omitempty"`
+	Division string `json:"division,omitempty"`
+	LP       int    `json:"lp"`
+	Wins     int    `json:"wins"`
+	Losses   int    `json:"losses"`
+}
+
+// RiotData is the Data of RiotDegraded and RiotRecovered: the endpoint's
+// figures over the SLO window.
+type RiotData struct {
+	Endpoint  string  `json:"endpoint"` // e.g. "match"
+	Requests  int     `json:"requests"`
+	ErrorRate float64 `json:"error_rate"`
+	P95Ms     int64   `json:"p95_ms"`
+}
+
+// KeyData is the Data of KeyRejected.
+type KeyData struct {
+	Status int `json:"status"` // 401 or 403
+}
+
+// Handler acts on an event.
+type Handler func(Event)
+
+// DefaultBuffer is how many events a subscriber can fall behind before
+// newer ones are dropped for it.
+const DefaultBuffer = 256
+
+// Stats are the bus's totals since start, for GET /metrics.
+type Stats struct {
+	Published int64            `json:"published"`
+	Dropped   map[string]int64 `json:"dropped"` // by subscriber
+}
+
+// Bus hands published events to its subscribers. A nil *Bus drops them, so
+// publishers need no check.
+type Bus struct {
+	// Buffer is each subscriber's queue (0 = DefaultBuffer); set it before
+	// subscribing.
+	Buffer int
+
+	mu        sync.RWMutex
+	subs      map[*subscriber]struct{}
+	closed    bool
+	published atomic.Int64
+	dropped   sync.Map // subscriber name -> *atomic.Int64
+}
+
+type subscriber struct {
+	name  string
+	types []string // nil = all
+	ch    chan Event
+	done  chan struct{}
+}
+
+func NewBus() *Bus { return &Bus{subs: map[*subscriber]struct{}{}} }
+
+// Subscribe runs fn on the events of the given types (none = all), in the
+// order they were published, until cancel. name identifies the subscriber
+// in the logs and Stats. cancel returns once fn has handled the events
+// already queued; it is safe to call more than once.
+func (b *Bus) Subscribe(name string, fn Handler, types ...string) (cancel func()) {
+	buf := b.Buffer
+	if buf <= 0 {
+		buf = DefaultBuffer
+	}
+	s := &subscriber{name: name, types: types, ch: make(chan Event, buf), done: make(chan struct{})}
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return func() {}
+	}
+	b.subs[s] = struct{}{}
+	b.mu.Unlock()
+	go func() {
+		defer close(s.done)
+		for ev := range s.ch {
+			handle(s.name, fn, ev)
+		}
+	}()
+	return func() {
+		b.mu.Lock()
+		if _, ok := b.subs[s]; ok {
+			delete(b.subs, s)
+			close(s.ch)
+		}
+		b.mu.Unlock()
+		<-s.done
+	}
+}
+
+// handle runs fn, logging instead of crashing on a panic: a broken
+// subscriber must not take the server down.
+func handle(name string, fn Handler, ev Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("events: %s panicked on %s: %v", name, ev.Type, r)
+		}
+	}()
+	fn(ev)
+}
+
+// Publish hands ev, stamped with the time when it has none, to the
+// subscribers without waiting on any: a subscriber whose queue is full
+// misses it.
+func (b *Bus) Publish(ev Event) {
+	if b == nil {
+		return
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	b.published.Add(1)
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for s := range b.subs {
+		if s.types != nil && !slices.Contains(s.types, ev.Type) {
+			continue
+		}
+		select {
+		case s.ch <- ev:
+		default:
+			n, _ := b.dropped.LoadOrStore(s.name, new(atomic.Int64))
+			if n.(*atomic.Int64).Add(1) == 1 {
+				log.Printf("events: %s is falling behind; dropping events for it", s.name)
+			}
+		}
+	}
+}
+
+// Close stops every subscriber once it has handled its queued events; later
+// events are dropped. It is for shutdown, after the last publisher.
+func (b *Bus) Close() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	subs := make([]*subscriber, 0, len(b.subs))
+	for s := range b.subs {
+		subs = append(subs, s)
+		close(s.ch)
+	}
+	b.subs, b.closed = map[*subscriber]struct{}{}, true
+	b.mu.Unlock()
+	for _, s := range subs {
+		<-s.done
+	}
+}
+
+// Stats returns the totals so far.
+func (b *Bus) Stats() Stats {
+	st := Stats{Published: b.published.Load(), Dropped: map[string]int64{}}
+	b.dropped.Range(func(k, v any) bool {
+		st.Dropped[k.(string)] = v.(*atomic.Int64).Load()
+		return true
+	})
+	return st
+}
+
+// ParseTypes reads a comma-separated list of event types, "" being all of
+// them (nil).
+func ParseTypes(s string) ([]string, error) {
+	var types []string
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t == "" {
+			continue
+		}
+		if !slices.Contains(Types, t) {
+			return nil, fmt.Errorf("unknown event type %q (%s)", t, strings.Join(Types, "|"))
+		}
+		types = append(types, t)
+	}
+	return types, nil
+}
@@ -0,0 +1,95 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"lol_custom_skill_matching/internal/signing"
+)
+
+// Notifier posts each event's Text to a chat webhook. The body carries it as
+// both "content" (Discord) and "text" (Slack and compatibles), signed by
+// Signer when there is one; failures are logged.
+type Notifier struct {
+	// URL is the webhook, or "secret:<name>" when Resolve reads it from the
+	// vault (resolved on every post, so a rotated URL applies at once).
+	URL     string
+	Resolve func(string) (string, error)
+	Signer  *signing.Signer
+	HTTP    *http.Client
+}
+
+func NewNotifier(url string) *Notifier {
+	return &Notifier{URL: url, HTTP: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Handle posts ev; it is the Notifier's Handler.
+func (n *Notifier) Handle(ev Event) {
+	if err := n.post(ev.Text); err != nil {
+		log.Printf("event webhook: %s: %v", ev.Type, err)
+	}
+}
+
+func (n *Notifier) post(text string) error {
+	url := n.URL
+	if n.Resolve != nil {
+		var err error
+		if url, err = n.Resolve(url); err != nil {
+			return err
+		}
+	}
+	b, _ := json.Marshal(map[string]string{"content": text, "text": text})
+	ctx, cancel := context.WithTimeout(context.Background(), n.HTTP.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	n.Signer.SignRequest(req, b)
+	resp, err := n.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// AuditLog appends every event it handles to a file as a line of JSON.
+type AuditLog struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// OpenAuditLog opens (or creates) the log at path for appending.
+func OpenAuditLog(path string) (*AuditLog, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditLog{f: f}, nil
+}
+
+// Handle writes ev; it is the AuditLog's Handler.
+func (a *AuditLog) Handle(ev Event) {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		log.Printf("audit log: %s: %v", ev.Type, err)
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.f.Write(append(b, '\n')); err != nil {
+		log.Printf("audit log: %v", err)
+	}
+}
@@ -0,0 +1,482 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/riot"
+	"lol_custom_skill_matching/internal/store"
+)
+
+type analyzeRequest struct {
+	Players []analyzer.Player `json:"players"`
+	// Platform and Region route the players that set neither (see
+	// analyzer.Player.Platform); empty uses the server's RIOT_PLATFORM.
+	Platform string `json:"platform,omitempty"`
+	Region   string `json:"region,omitempty"`
+	// Preset picks "quick", "standard" or "deep" (see analyzer.Presets); the
+	// fields below override single knobs of it.
+	Preset     string `json:"preset,omitempty"`
+	MatchLimit int    `json:"matchLimit,omitempty"`
+	// MinGames and MaxMatchLimit widen the match window of a player with
+	// fewer qualifying games (see analyzer.Options.MinGames).
+	MinGames      int `json:"minGames,omitempty"`
+	MaxMatchLimit int `json:"maxMatchLimit,omitempty"`
+	// Mode selects the 10-player split order: "balance_first" (default) or "roles_first".
+	Mode string `json:"mode,omitempty"`
+	// BalanceOn "conservative" splits on the lower bound of each skill interval.
+	BalanceOn string `json:"balanceOn,omitempty"`
+	// Objective "slotwise" balances the teams' skill distribution, not just the totals.
+	Objective string `json:"objective,omitempty"`
+	// MaxLaneGap caps the skill gap of every lane matchup in the role splits.
+	MaxLaneGap int `json:"maxLaneGap,omitempty"`
+	// LaneWeight is what one step off a player's lanes costs lane_unique, in skill points.
+	LaneWeight int `json:"laneWeight,omitempty"`
+	// MinLaneGames is how many counted games it takes to hold a player to
+	// their lanes (default analyzer.DefaultMinLaneGames).
+	MinLaneGames int `json:"minLaneGames,omitempty"`
+	// IncludeLobbyRank=false skips the participant-rank phase (~10x the other requests).
+	IncludeLobbyRank *bool `json:"includeLobbyRank,omitempty"`
+	// LobbyRankSampling trades lobby-rank accuracy for quota.
+	LobbyRankSampling analyzer.LobbySampling `json:"lobbyRankSampling,omitempty"`
+	// IncludeRaw attaches per-player raw aggregates (counts, per-match summaries) for charts.
+	IncludeRaw bool `json:"includeRaw,omitempty"`
+	// HistoryLimit adds up to N backfilled older matches to lane/champion/winrate stats.
+	HistoryLimit int `json:"historyLimit,omitempty"`
+	// Queues are the queues that count, by id or key (riot.ParseQueue);
+	// MaxAgeDays ignores older matches.
+	Queues     riot.QueueIDs `json:"queues,omitempty"`
+	MaxAgeDays int           `json:"maxAgeDays,omitempty"`
+	// Patch "current" (or "15.14") analyzes only matches of that patch;
+	// PatchDecay discounts champion usage per patch of age (0-1).
+	Patch      string  `json:"patch,omitempty"`
+	PatchDecay float64 `json:"patchDecay,omitempty"`
+	// Teams names/colors team A and B (default Blue/Red); RandomTeamNames fills
+	// unnamed teams with generated fun names.
+	Teams           []analyzer.TeamInfo `json:"teams,omitempty"`
+	RandomTeamNames bool                `json:"randomTeamNames,omitempty"`
+	// SidePolicy picks blue side: "fixed" (default), "random", "alternate" or "fair"
+	// (uses each player's stored side history).
+	SidePolicy string `json:"sidePolicy,omitempty"`
+	// RequireVerified rejects the request when any player hasn't proven Riot ID ownership.
+	RequireVerified bool `json:"requireVerified,omitempty"`
+	// Captains are two of the players kept on opposite teams (the first on
+	// team A), each optionally on a fixed role.
+	Captains []analyzer.Captain `json:"captains,omitempty"`
+	// TagRules keep the players carrying an organizer tag (see
+	// /players/{riotId}/notes) spread over the teams or together.
+	TagRules []analyzer.TagRule `json:"tagRules,omitempty"`
+}
+
+// simple meta for progress/diagnostics
+type analyzeMeta struct {
+	DurationMS int64  `json:"duration_ms"`
+	Players    int    `json:"players"`
+	MatchLimit int    `json:"match_limit"`
+	ResultID   string `json:"result_id"` // GET /results/{id}
+	// ResultFile is where the copy of the result on disk is written, just
+	// after the response ("" when disabled).
+	ResultFile string `json:"result_file,omitempty"`
+	// Preset is the preset applied, with any per-request overrides.
+	Preset analyzer.Preset `json:"preset"`
+	// Cost is what the analysis spent and the accuracy it bought.
+	Cost analyzer.CostReport `json:"cost"`
+	// Degraded is set when Riot was down and stored profiles were used.
+	Degraded *degradedMeta `json:"degraded,omitempty"`
+	// Partial names the players whose analysis timed out (PLAYER_TIMEOUT)
+	// and whose profiles only use what was fetched by then.
+	Partial []string `json:"partial,omitempty"`
+	// Demo marks the sample roster's canned profiles (DEMO_MODE).
+	Demo bool `json:"demo,omitempty"`
+}
+
+// presetDefault names the settings used when a request picks no preset:
+// standard, with the server's MATCH_LIMIT.
+const presetDefault = "default"
+
+// resolvePreset returns the preset req asked for with its overrides applied.
+func (s *Server) resolvePreset(req analyzeRequest) (analyzer.Preset, error) {
+	name := req.Preset
+	if name == "" {
+		name = analyzer.PresetStandard
+	}
+	p, err := analyzer.LookupPreset(name)
+	if err != nil {
+		return p, err
+	}
+	if req.Preset == "" {
+		p.Name = presetDefault
+		p.MatchLimit = s.MatchLimit
+	}
+	if req.MatchLimit > 0 {
+		p.MatchLimit = req.MatchLimit
+	}
+	if req.MinGames > 0 {
+		p.MinGames = req.MinGames
+	}
+	if req.MaxMatchLimit > 0 {
+		p.MaxMatchLimit = req.MaxMatchLimit
+	}
+	if req.IncludeLobbyRank != nil {
+		p.SkipLobbyRank = !*req.IncludeLobbyRank
+	}
+	if req.LobbyRankSampling.Strategy != "" {
+		p.Sampling = req.LobbyRankSampling
+	}
+	if req.HistoryLimit > 0 {
+		p.HistoryLimit = req.HistoryLimit
+	}
+	if req.Queues != nil {
+		p.Queues = req.Queues
+	}
+	if req.MaxAgeDays > 0 {
+		p.MaxAgeDays = req.MaxAgeDays
+	}
+	if req.Patch != "" {
+		p.Patch = req.Patch
+	}
+	if req.PatchDecay != 0 {
+		p.PatchDecay = req.PatchDecay
+	}
+	return p, nil
+}
+
+// analyzeResponse is the body of POST /analyze as splitFields streams it,
+// for the OpenAPI document: the split, then meta.
+type analyzeResponse struct {
+	analyzer.TeamSplit
+	Meta *analyzeMeta `json:"meta,omitempty"`
+}
+
+// splitFields lays out the analyze response for streaming (see analyzeResponse).
+func splitFields(ts analyzer.TeamSplit, meta *analyzeMeta) []field {
+	fields := []field{
+		{Key: "teamA", List: listOf(ts.TeamA)},
+		{Key: "teamB", List: listOf(ts.TeamB)},
+		{Key: "sumA", Value: ts.SumA},
+		{Key: "sumB", Value: ts.SumB},
+		{Key: "mode", Value: ts.Mode},
+		{Key: "teams", Value: ts.Teams},
+		{Key: "sides", Value: ts.Sides},
+		{Key: "win_predictions", Value: ts.WinPredictions},
+		{Key: "balance_on", Value: ts.BalanceOn},
+		{Key: "objective", Value: ts.Objective},
+	}
+	if len(ts.Captains) > 0 {
+		fields = append(fields, field{Key: "captains", Value: ts.Captains})
+	}
+	if len(ts.TagRules) > 0 {
+		fields = append(fields, field{Key: "tag_rules", Value: ts.TagRules})
+	}
+	if ts.LaneUnique != nil {
+		fields = append(fields, field{Key: "lane_unique", Value: ts.LaneUnique})
+	}
+	if ts.RolesFirst != nil {
+		fields = append(fields, field{Key: "roles_first", Value: ts.RolesFirst})
+	}
+	if ts.RoleCoverage != nil {
+		fields = append(fields, field{Key: "role_coverage", Value: ts.RoleCoverage})
+	}
+	if ts.Provenance != nil {
+		fields = append(fields, field{Key: "provenance", Value: ts.Provenance})
+	}
+	fields = append(fields, field{Key: "validation", Value: ts.Validation})
+	if meta != nil {
+		fields = append(fields, field{Key: "meta", Value: meta})
+	}
+	return fields
+}
+
+// checkCaptains rejects captains that aren't among the players' names.
+func checkCaptains(captains []analyzer.Captain, names []string) error {
+	for _, c := range captains {
+		if !slices.ContainsFunc(names, func(n string) bool { return strings.EqualFold(n, strings.TrimSpace(c.Player)) }) {
+			return fmt.Errorf("captain %q is not one of the players", c.Player)
+		}
+	}
+	return nil
+}
+
+func riotIDs(players []analyzer.Player) []string {
+	out := make([]string, len(players))
+	for i, p := range players {
+		out[i] = p.RiotID()
+	}
+	return out
+}
+
+func profileNames(profiles []analyzer.Profile) []string {
+	out := make([]string, len(profiles))
+	for i, p := range profiles {
+		out[i] = p.Name
+	}
+	return out
+}
+
+// resolveTeams turns the request's team list into team A/B info.
+func resolveTeams(teams []analyzer.TeamInfo, random bool) ([2]analyzer.TeamInfo, error) {
+	var out [2]analyzer.TeamInfo
+	if len(teams) > 2 {
+		return out, fmt.Errorf("teams takes at most 2 entries")
+	}
+	copy(out[:], teams)
+	for i := range out {
+		if s := out[i].Side; s != "" && s != analyzer.SideBlue && s != analyzer.SideRed {
+			return out, fmt.Errorf("invalid team side (blue|red)")
+		}
+	}
+	if random {
+		names := analyzer.RandomTeamNames(rand.New(rand.NewPCG(uint64(time.Now().UnixNano()), 0)))
+		for i := range out {
+			if out[i].Name == "" {
+				out[i].Name = names[i]
+			}
+		}
+	}
+	return analyzer.WithDefaults(out), nil
+}
+
+// apiError is a handler failure: a plain-text message or a JSON body.
+type apiError struct {
+	Status int
+	Body   any
+}
+
+func (e *apiError) write(w http.ResponseWriter) {
+	if msg, ok := e.Body.(string); ok {
+		http.Error(w, msg, e.Status)
+		return
+	}
+	writeJSON(w, e.Status, e.Body)
+}
+
+func (s *Server) handleAnalyze(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	proj, err := parseFields(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req analyzeRequest
+	if !decodeAnalyzeRequest(w, r, &req) {
+		return
+	}
+	split, meta, aerr := s.runAnalysis(r.Context(), req, "")
+	if aerr != nil {
+		aerr.write(w)
+		return
+	}
+	rid := RequestID(r.Context())
+	fields := proj.fields(splitFields(split, meta))
+	if wantsNDJSON(r) {
+		w.Header().Set("Content-Type", ndjsonContentType)
+		if err := streamNDJSON(w, fields); err != nil {
+			log.Printf("[req %s] stream error: %v", rid, err)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := streamJSON(w, fields); err != nil {
+		log.Printf("[req %s] stream error: %v", rid, err)
+	}
+}
+
+// runAnalysis validates req, analyzes the players and returns a validated split,
+// stored as a result (of lobbyID, if any).
+func (s *Server) runAnalysis(ctx context.Context, req analyzeRequest, lobbyID string) (analyzer.TeamSplit, *analyzeMeta, *apiError) {
+	rid := RequestID(ctx)
+	preset, opts, aerr := s.prepareAnalysis(req)
+	if aerr != nil {
+		return analyzer.TeamSplit{}, nil, aerr
+	}
+	log.Printf("[req %s] analyze start players=%d preset=%s matchLimit=%d", rid, len(req.Players), preset.Name, opts.MatchLimit)
+	astart := time.Now()
+	actx, usage := riot.WithUsage(ctx)
+	players := s.withDeclaredPools(req.Players)
+	// With the breaker open this fails fast on the first request, unless that
+	// request is the breaker's trial and Riot is back.
+	profiles, err := s.Analyzer.Analyze(actx, players, opts)
+	var degraded *degradedMeta
+	if err != nil {
+		log.Printf("[req %s] analyze error: %v", rid, err)
+		if errors.Is(err, riot.ErrKeyInvalid) {
+			status := http.StatusServiceUnavailable
+			if riot.UsesCallerKey(ctx) {
+				// the caller's own key: theirs to fix, the server is fine
+				status = http.StatusUnauthorized
+			}
+			return analyzer.TeamSplit{}, nil, &apiError{status, map[string]any{"error": err.Error(), "key_invalid": true}}
+		}
+		if !s.Analyzer.Riot.Breaker.Open() {
+			return analyzer.TeamSplit{}, nil, analysisError(err)
+		}
+		// Riot is down: game night goes on with what we have
+		log.Printf("[req %s] riot breaker open; using stored profiles", rid)
+		profiles, degraded = s.staleProfiles(ctx, players, opts)
+	}
+	return s.completeAnalysis(ctx, req, lobbyID, preset, opts, profiles, degraded, usage, astart)
+}
+
+// analysisError answers an analysis that failed while Riot's breaker is
+// closed: 503 when Riot is down, throttling or slow (worth retrying), 502
+// when it answered something unusable, 400 for the request's own faults.
+func analysisError(err error) *apiError {
+	f := analyzer.Classify(err)
+	status := http.StatusBadRequest
+	switch f.Category {
+	case analyzer.ErrorTransientRiot:
+		status = http.StatusServiceUnavailable
+	case analyzer.ErrorPermanentRiot:
+		status = http.StatusBadGateway
+	}
+	return &apiError{status, map[string]any{"error": f.Message, "category": f.Category, "retryable": f.Retryable}}
+}
+
+// decodeAnalyzeRequest reads the body of /analyze or /analyze/jobs into req,
+// answering 400 when it can't; a queue it doesn't know is named.
+func decodeAnalyzeRequest(w http.ResponseWriter, r *http.Request, req *analyzeRequest) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		msg := "invalid json"
+		if errors.Is(err, riot.ErrUnknownQueue) {
+			msg = err.Error()
+		}
+		http.Error(w, msg, http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// prepareAnalysis resolves req's preset and options and runs the checks that
+// need no Riot requests.
+func (s *Server) prepareAnalysis(req analyzeRequest) (analyzer.Preset, analyzer.Options, *apiError) {
+	preset, err := s.resolvePreset(req)
+	if err != nil {
+		return preset, analyzer.Options{}, &apiError{http.StatusBadRequest, err.Error()}
+	}
+	opts := analyzer.Options{
+		Mode:         req.Mode,
+		BalanceOn:    req.BalanceOn,
+		Objective:    req.Objective,
+		MaxLaneGap:   req.MaxLaneGap,
+		LaneWeight:   req.LaneWeight,
+		IncludeRaw:   req.IncludeRaw,
+		MinLaneGames: req.MinLaneGames,
+		SidePolicy:   req.SidePolicy,
+		Captains:     req.Captains,
+		TagGroups:    s.tagGroups(req.TagRules),
+	}
+	if err := preset.Apply(&opts); err != nil {
+		return preset, opts, &apiError{http.StatusBadRequest, err.Error()}
+	}
+	if err := opts.Validate(); err != nil {
+		return preset, opts, &apiError{http.StatusBadRequest, err.Error()}
+	}
+	if opts.Teams, err = resolveTeams(req.Teams, req.RandomTeamNames); err != nil {
+		return preset, opts, &apiError{http.StatusBadRequest, err.Error()}
+	}
+	if err := checkCaptains(opts.Captains, riotIDs(req.Players)); err != nil {
+		return preset, opts, &apiError{http.StatusBadRequest, err.Error()}
+	}
+	for i := range req.Players {
+		p := &req.Players[i]
+		p.Champions = store.CleanChampionList(p.Champions)
+		if p.Platform == "" && p.Region == "" {
+			p.Platform, p.Region = req.Platform, req.Region
+		}
+		if p.Platform != "" || p.Region != "" {
+			if _, err := riot.LookupPlatform(p.Platform, p.Region); err != nil {
+				return preset, opts, &apiError{http.StatusBadRequest, p.RiotID() + ": " + err.Error()}
+			}
+		}
+	}
+	if out := s.optedOut(req.Players); len(out) > 0 {
+		return preset, opts, errOptedOut(out)
+	}
+
+	if req.RequireVerified {
+		// cheap check before spending quota; ownership by the same PUUID is re-checked after analysis
+		var unverified []string
+		for _, p := range req.Players {
+			if _, ok := s.Store.Verified(p.GameName, p.TagLine); !ok {
+				unverified = append(unverified, p.RiotID())
+			}
+		}
+		if len(unverified) > 0 {
+			return preset, opts, &apiError{http.StatusForbidden, map[string]any{"error": "players must verify their riot id", "unverified": unverified}}
+		}
+	}
+	return preset, opts, nil
+}
+
+// completeAnalysis splits the analyzed profiles (stored ones when degraded),
+// records them and stores the split as a result of lobbyID, if any. astart
+// and usage are when the analysis started and what it spent.
+func (s *Server) completeAnalysis(ctx context.Context, req analyzeRequest, lobbyID string, preset analyzer.Preset, opts analyzer.Options,
+	profiles []analyzer.Profile, degraded *degradedMeta, usage *riot.Usage, astart time.Time) (analyzer.TeamSplit, *analyzeMeta, *apiError) {
+	rid := RequestID(ctx)
+	if degraded != nil {
+		if len(profiles) < 2 {
+			return analyzer.TeamSplit{}, nil, &apiError{http.StatusServiceUnavailable, map[string]any{"error": "riot api unavailable and too few stored profiles", "missing": degraded.Missing}}
+		}
+	} else {
+		for _, p := range profiles {
+			if id, ok := parseRiotID(p.Name); ok {
+				s.Store.AddMatches(id.GameName, id.TagLine, p.Matches)
+			}
+		}
+	}
+	s.applyOverrides(profiles)
+	var partial []string
+	if degraded == nil {
+		// stale profiles must not look freshly computed, nor partial ones
+		// like complete ones
+		complete := make([]analyzer.Profile, 0, len(profiles))
+		for _, p := range profiles {
+			if p.Partial {
+				partial = append(partial, p.Name)
+				continue
+			}
+			complete = append(complete, p)
+		}
+		s.Store.RecordRatings(complete)
+		s.publishProfiles(complete)
+	}
+	analyzer.MarkParticipation(profiles, s.Store.Participation)
+	if unverified := s.markVerified(profiles); req.RequireVerified && len(unverified) > 0 {
+		return analyzer.TeamSplit{}, nil, &apiError{http.StatusForbidden, map[string]any{"error": "players must verify their riot id", "unverified": unverified}}
+	}
+	split := analyzer.Split(profiles, opts)
+	if !split.Validation.OK {
+		log.Printf("[req %s] refusing corrupt split: %v", rid, split.Validation.Errors)
+		return analyzer.TeamSplit{}, nil, &apiError{http.StatusUnprocessableEntity, map[string]any{"error": analyzer.ErrCorruptSplit.Error(), "validation": split.Validation}}
+	}
+	analyzer.AssignSides(&split, opts.SidePolicy, s.Store.SideStats, rand.New(rand.NewPCG(uint64(time.Now().UnixNano()), 1)))
+	analyzer.PredictWins(&split, s.Analyzer.WinScale)
+	s.Store.RecordSides(split)
+	split.Provenance = s.Analyzer.Provenance(req.Players, opts)
+	result := s.Store.AddResult(lobbyID, split)
+	s.publishResult(result.ID, lobbyID, split)
+	var resultFile string
+	if s.Results != nil {
+		resultFile = s.Results.File(result.ID) // SaveResult writes it
+	}
+	dur := time.Since(astart)
+	cost := analyzer.NewCostReport(profiles, usage, preset)
+	if degraded != nil {
+		cost.AccuracyTier = analyzer.AccuracyStale
+	}
+	log.Printf("[req %s] analyze done in %s riotCalls=%d cacheHitRate=%.2f accuracy=%s", rid, dur, cost.RiotCalls, cost.CacheHitRate, cost.AccuracyTier)
+	return split, &analyzeMeta{DurationMS: dur.Milliseconds(), Players: len(req.Players), MatchLimit: opts.MatchLimit, ResultID: result.ID, ResultFile: resultFile, Preset: preset, Cost: cost, Degraded: degraded, Partial: partial}, nil
+}
@@ -0,0 +1,147 @@
+package httpapi
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/store"
+)
+
+// organizer checks the bearer token for organizer-only endpoints. With no
+// OrganizerToken configured every caller is treated as an organizer.
+func (s *Server) organizer(w http.ResponseWriter, r *http.Request) bool {
+	if s.OrganizerToken == "" {
+		return true
+	}
+	got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(got), []byte(s.OrganizerToken)) == 1 {
+		return true
+	}
+	http.Error(w, "organizer token required", http.StatusUnauthorized)
+	return false
+}
+
+// admin is organizer for the endpoints that hand out or overwrite secrets or
+// the whole store: unlike the others they stay closed until an
+// OrganizerToken is configured.
+func (s *Server) admin(w http.ResponseWriter, r *http.Request) bool {
+	if s.OrganizerToken == "" {
+		http.Error(w, "set ORGANIZER_TOKEN to use this endpoint", http.StatusForbidden)
+		return false
+	}
+	return s.organizer(w, r)
+}
+
+// applyOverrides swaps in organizer-approved scores before the split.
+func (s *Server) applyOverrides(profiles []analyzer.Profile) {
+	for i := range profiles {
+		id, ok := parseRiotID(profiles[i].Name)
+		if !ok {
+			continue
+		}
+		if o, ok := s.Store.ScoreOverride(id.GameName, id.TagLine); ok {
+			profiles[i].ApplyOverride(o.Score, o.AppealID)
+		}
+	}
+}
+
+// handleAppeals serves POST /appeals (any player) and GET /appeals?state= (organizers).
+func (s *Server) handleAppeals(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var body struct {
+			Player         string `json:"player"` // name#tag
+			Note           string `json:"note"`
+			ComputedScore  int    `json:"computedScore"`
+			RequestedScore int    `json:"requestedScore"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+		p, ok := parseRiotID(body.Player)
+		if !ok {
+			http.Error(w, "invalid riot id (expected name#tag or name-tag)", http.StatusBadRequest)
+			return
+		}
+		if strings.TrimSpace(body.Note) == "" {
+			http.Error(w, "note is required", http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, http.StatusCreated, s.Store.AddAppeal(p.GameName, p.TagLine, strings.TrimSpace(body.Note), body.ComputedScore, body.RequestedScore))
+	case http.MethodGet:
+		if !s.organizer(w, r) {
+			return
+		}
+		state := r.URL.Query().Get("state")
+		switch state {
+		case "":
+			state = store.AppealPending
+		case "all":
+			state = ""
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"appeals": s.Store.Appeals(state)})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAppealDecision serves POST /appeals/{id}/decision (organizers):
+// {"approve": true, "score": 2400, "note": "..."} sets the player's override.
+func (s *Server) handleAppealDecision(w http.ResponseWriter, r *http.Request) {
+	if !s.organizer(w, r) {
+		return
+	}
+	var body struct {
+		Approve bool   `json:"approve"`
+		Score   int    `json:"score"`
+		Note    string `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if body.Approve && body.Score <= 0 {
+		http.Error(w, "score is required to approve", http.StatusBadRequest)
+		return
+	}
+	a, err := s.Store.DecideAppeal(r.PathValue("id"), body.Approve, body.Score, body.Note)
+	switch {
+	case errors.Is(err, store.ErrAppealNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, store.ErrAppealDecided):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		writeJSON(w, http.StatusOK, a)
+	}
+}
+
+// handlePlayerAppeals serves GET /players/{riotId}/appeals (history and current
+// override) and DELETE to drop the override (organizers).
+func (s *Server) handlePlayerAppeals(w http.ResponseWriter, r *http.Request) {
+	p, ok := parseRiotID(r.PathValue("riotId"))
+	if !ok {
+		http.Error(w, "invalid riot id (expected name#tag or name-tag)", http.StatusBadRequest)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodDelete:
+		if !s.organizer(w, r) {
+			return
+		}
+		s.Store.ClearScoreOverride(p.GameName, p.TagLine)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	resp := map[string]any{"name": p.RiotID(), "appeals": s.Store.PlayerAppeals(p.GameName, p.TagLine)}
+	if o, ok := s.Store.ScoreOverride(p.GameName, p.TagLine); ok {
+		resp["override"] = o
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
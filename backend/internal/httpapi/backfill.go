@@ -0,0 +1,38 @@
+package httpapi
+
+import (
+	"net/http"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/riot"
+)
+
+// handleBackfill serves POST /players/{riotId}/backfill: queue a background walk of
+// the player's season history. Progress is listed by GET /backfill.
+func (s *Server) handleBackfill(w http.ResponseWriter, r *http.Request) {
+	if s.Backfill == nil {
+		http.Error(w, "backfill is disabled", http.StatusNotFound)
+		return
+	}
+	p, ok := parseRiotID(r.PathValue("riotId"))
+	if !ok {
+		http.Error(w, "invalid riot id (expected name#tag or name-tag)", http.StatusBadRequest)
+		return
+	}
+	if out := s.optedOut([]analyzer.Player{p}); len(out) > 0 {
+		errOptedOut(out).write(w)
+		return
+	}
+	if b := s.Backfill.Backlog(); s.saturated(w, queueBackfill, b.Depth, b.EstimatedWait, b.Average) {
+		return
+	}
+	writeJSON(w, http.StatusAccepted, s.Backfill.Enqueue(p, riot.TenantFrom(r.Context())))
+}
+
+func (s *Server) handleBackfillList(w http.ResponseWriter, r *http.Request) {
+	if s.Backfill == nil {
+		http.Error(w, "backfill is disabled", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"jobs": s.Backfill.Jobs()})
+}
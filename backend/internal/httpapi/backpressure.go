@@ -0,0 +1,138 @@
+package httpapi
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Backpressure refuses queued work (analyze jobs, backfills) once its queue
+// is full, so clients get a 503 with Retry-After they can show instead of a
+// job that finishes in an hour. Each queue is checked on its own.
+type Backpressure struct {
+	// MaxDepth is the most jobs a queue holds, queued or running (0 = no limit).
+	MaxDepth int
+	// MaxWait is the longest estimated wait a new job is accepted with (0 = no limit).
+	MaxWait time.Duration
+}
+
+// defaultRetryAfter is suggested when a full queue has no finished job to
+// estimate from yet.
+const defaultRetryAfter = time.Minute
+
+// saturated writes a 503 when a queue of depth jobs, estimated to keep a new
+// one waiting for wait, takes no more. avg is a job's usual duration (0 =
+// unknown). It reports whether the request was refused.
+func (s *Server) saturated(w http.ResponseWriter, queue string, depth int, wait, avg time.Duration) bool {
+	bp := s.Backpressure
+	var retry time.Duration
+	switch {
+	case bp.MaxDepth > 0 && depth >= bp.MaxDepth:
+		// until enough jobs finish to make room
+		retry = time.Duration(depth-bp.MaxDepth+1) * avg
+	case bp.MaxWait > 0 && wait > bp.MaxWait:
+		retry = wait - bp.MaxWait
+	default:
+		return false
+	}
+	if retry <= 0 {
+		retry = defaultRetryAfter
+	}
+	secs := int(retry.Seconds()) + 1
+	s.rejected(queue).Add(1)
+	w.Header().Set("Retry-After", strconv.Itoa(secs))
+	writeJSON(w, http.StatusServiceUnavailable, map[string]any{
+		"error":                  "the " + queue + " queue is full; try again later",
+		"queue":                  queue,
+		"queue_length":           depth,
+		"estimated_wait_seconds": int(wait.Seconds()),
+		"retry_after_seconds":    secs,
+	})
+	return true
+}
+
+const (
+	queueAnalyze  = "analyze"
+	queueBackfill = "backfill"
+)
+
+// rejected counts the jobs refused by a queue, for /metrics.
+func (s *Server) rejected(queue string) *atomic.Int64 {
+	if queue == queueBackfill {
+		return &s.rejectedBackfills
+	}
+	return &s.rejectedJobs
+}
+
+// jobLoad tracks the analyze jobs in flight. They run side by side but share
+// the Riot quota, so each one ahead delays a new job by about its own length.
+type jobLoad struct {
+	mu      sync.Mutex
+	running map[*analyzeJob]time.Time // job -> attempt start
+	avg     time.Duration             // moving average of attempt durations
+}
+
+func (l *jobLoad) start(j *analyzeJob) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.running == nil {
+		l.running = map[*analyzeJob]time.Time{}
+	}
+	l.running[j] = time.Now()
+}
+
+func (l *jobLoad) finish(j *analyzeJob) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	d := time.Since(l.running[j])
+	delete(l.running, j)
+	if l.avg == 0 {
+		l.avg = d
+	} else {
+		l.avg = (4*l.avg + d) / 5
+	}
+}
+
+// jobs lists the jobs running.
+func (l *jobLoad) jobs() []*analyzeJob {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Collect(maps.Keys(l.running))
+}
+
+// wait blocks until no job runs or ctx is done.
+func (l *jobLoad) wait(ctx context.Context) error {
+	t := time.NewTicker(100 * time.Millisecond)
+	defer t.Stop()
+	for {
+		l.mu.Lock()
+		n := len(l.running)
+		l.mu.Unlock()
+		if n == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// backlog returns the jobs running, the estimated wait they add to a new
+// one and the average attempt duration.
+func (l *jobLoad) backlog() (int, time.Duration, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var wait time.Duration
+	now := time.Now()
+	for _, at := range l.running {
+		wait += max(0, l.avg-now.Sub(at))
+	}
+	return len(l.running), wait, l.avg
+}
@@ -0,0 +1,42 @@
+package httpapi
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"lol_custom_skill_matching/internal/backup"
+)
+
+// handleBackup serves GET /admin/backup (organizers, with ORGANIZER_TOKEN set:
+// it holds captain and verification tokens): a tar.gz of the store and
+// cache files.
+func (s *Server) handleBackup(w http.ResponseWriter, r *http.Request) {
+	if !s.admin(w, r) {
+		return
+	}
+	name := fmt.Sprintf("lolmatch-backup-%s.tar.gz", time.Now().UTC().Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	if _, err := backup.Write(w, s.Store, s.BackupFiles); err != nil {
+		// headers are gone; the truncated archive fails to open on the client
+		log.Printf("[req %s] backup: %v", RequestID(r.Context()), err)
+	}
+}
+
+// handleRestore serves POST /admin/restore (organizers, with ORGANIZER_TOKEN
+// set) with a backup archive as
+// the body. It replaces the whole store.
+func (s *Server) handleRestore(w http.ResponseWriter, r *http.Request) {
+	if !s.admin(w, r) {
+		return
+	}
+	m, err := backup.Restore(r.Body, s.Store, s.BackupFiles)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Printf("[req %s] restored backup from %s", RequestID(r.Context()), m.CreatedAt.Format(time.RFC3339))
+	writeJSON(w, http.StatusOK, m)
+}
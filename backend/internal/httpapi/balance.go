@@ -0,0 +1,182 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/balance"
+	"lol_custom_skill_matching/internal/store"
+)
+
+// ratedPlayer is a player with a score supplied by the caller instead of Riot data.
+type ratedPlayer struct {
+	Name  string `json:"name"`
+	Score int    `json:"score"`
+	// Low is the conservative lower bound used by balanceOn=conservative (default Score).
+	Low       *int     `json:"low,omitempty"`
+	MainLanes []string `json:"mainLanes,omitempty"` // most preferred first
+	SubLanes  []string `json:"subLanes,omitempty"`
+}
+
+type balanceRequest struct {
+	Players         []ratedPlayer       `json:"players"`
+	Mode            string              `json:"mode,omitempty"`
+	BalanceOn       string              `json:"balanceOn,omitempty"`
+	Objective       string              `json:"objective,omitempty"`
+	MaxLaneGap      int                 `json:"maxLaneGap,omitempty"`
+	LaneWeight      int                 `json:"laneWeight,omitempty"`
+	Teams           []analyzer.TeamInfo `json:"teams,omitempty"`
+	RandomTeamNames bool                `json:"randomTeamNames,omitempty"`
+	SidePolicy      string              `json:"sidePolicy,omitempty"`
+	Captains        []analyzer.Captain  `json:"captains,omitempty"`
+	TagRules        []analyzer.TagRule  `json:"tagRules,omitempty"`
+	// Lobby leaves out its players who answered no to the RSVP, and those
+	// who answered maybe when Maybe is exclude (default warn).
+	Lobby string `json:"lobby,omitempty"`
+	Maybe string `json:"maybe,omitempty"`
+}
+
+// lanes upper-cases role names and rejects anything that isn't a Summoner's Rift role.
+func lanes(name string, in []string) ([]string, error) {
+	out := []string{}
+	for _, l := range in {
+		l = strings.ToUpper(strings.TrimSpace(l))
+		if !slices.Contains(balance.Roles, l) {
+			return nil, fmt.Errorf("%s: invalid lane %q (%s)", name, l, strings.Join(balance.Roles, "|"))
+		}
+		out = append(out, l)
+	}
+	return out, nil
+}
+
+// profiles turns the rated players into the profiles the splitters work on.
+func (req balanceRequest) profiles() ([]analyzer.Profile, error) {
+	if len(req.Players) < 2 {
+		return nil, fmt.Errorf("need at least 2 players")
+	}
+	seen := map[string]bool{}
+	out := make([]analyzer.Profile, 0, len(req.Players))
+	for _, rp := range req.Players {
+		name := strings.TrimSpace(rp.Name)
+		if name == "" {
+			return nil, fmt.Errorf("every player needs a name")
+		}
+		if seen[strings.ToLower(name)] {
+			return nil, fmt.Errorf("duplicate player %q", name)
+		}
+		seen[strings.ToLower(name)] = true
+		main, err := lanes(name, rp.MainLanes)
+		if err != nil {
+			return nil, err
+		}
+		sub, err := lanes(name, rp.SubLanes)
+		if err != nil {
+			return nil, err
+		}
+		low := rp.Score
+		if rp.Low != nil {
+			low = *rp.Low
+		}
+		if low > rp.Score {
+			return nil, fmt.Errorf("%s: low is above score", name)
+		}
+		p := analyzer.Profile{
+			Name: name, SkillScore: rp.Score, MainLanes: main, MainSublanes: sub,
+			SkillInterval: analyzer.Interval{Low: low, High: rp.Score + rp.Score - low, Margin: rp.Score - low},
+		}
+		if req.BalanceOn == analyzer.BalanceOnConservative {
+			p.BalanceScore = low
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// handleBalance serves POST /balance: the balancing engine alone, on scores and
+// lane preferences supplied by the caller. It makes no Riot calls and stores
+// nothing (side history is read for sidePolicy but not updated).
+func (s *Server) handleBalance(w http.ResponseWriter, r *http.Request) {
+	proj, err := parseFields(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req balanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	var rsvp *rsvpReport
+	if req.Lobby != "" {
+		if req.Maybe == "" {
+			req.Maybe = maybeWarn
+		}
+		if req.Maybe != maybeWarn && req.Maybe != maybeExclude {
+			http.Error(w, "invalid maybe (warn|exclude)", http.StatusBadRequest)
+			return
+		}
+		l, ok := s.Store.Lobby(req.Lobby)
+		if !ok {
+			http.Error(w, store.ErrLobbyNotFound.Error(), http.StatusNotFound)
+			return
+		}
+		names := make([]string, len(req.Players))
+		for i, p := range req.Players {
+			names[i] = strings.TrimSpace(p.Name)
+		}
+		keep, rep := rsvpFilter(l, names, req.Maybe)
+		req.Players = slices.DeleteFunc(req.Players, func(p ratedPlayer) bool { return !slices.Contains(keep, strings.TrimSpace(p.Name)) })
+		rsvp = &rep
+	}
+	opts := analyzer.Options{
+		Mode: req.Mode, BalanceOn: req.BalanceOn, Objective: req.Objective, MaxLaneGap: req.MaxLaneGap,
+		LaneWeight: req.LaneWeight, SidePolicy: req.SidePolicy, Captains: req.Captains, TagGroups: s.tagGroups(req.TagRules),
+	}
+	if err := opts.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if opts.Teams, err = resolveTeams(req.Teams, req.RandomTeamNames); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	profiles, err := req.profiles()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := checkCaptains(opts.Captains, profileNames(profiles)); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	split := analyzer.Split(profiles, opts)
+	if !split.Validation.OK {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"error": analyzer.ErrCorruptSplit.Error(), "validation": split.Validation})
+		return
+	}
+	analyzer.AssignSides(&split, opts.SidePolicy, s.Store.SideStats, rand.New(rand.NewPCG(uint64(time.Now().UnixNano()), 1)))
+	analyzer.PredictWins(&split, s.Analyzer.WinScale)
+	all := splitFields(split, nil)
+	if rsvp != nil {
+		all = append(all, field{Key: "rsvp", Value: rsvp})
+	}
+	fields := proj.fields(all)
+	if wantsNDJSON(r) {
+		w.Header().Set("Content-Type", ndjsonContentType)
+		if err := streamNDJSON(w, fields); err != nil {
+			log.Printf("[req %s] stream error: %v", RequestID(r.Context()), err)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := streamJSON(w, fields); err != nil {
+		log.Printf("[req %s] stream error: %v", RequestID(r.Context()), err)
+	}
+}
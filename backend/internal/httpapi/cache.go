@@ -0,0 +1,51 @@
+package httpapi
+
+import (
+	"log"
+	"net/http"
+	"strings"
+)
+
+// handleCacheStats serves GET /admin/cache/stats (organizers): the Riot
+// response cache's entries and hit rates per endpoint.
+func (s *Server) handleCacheStats(w http.ResponseWriter, r *http.Request) {
+	if !s.organizer(w, r) {
+		return
+	}
+	st, err := s.Analyzer.Riot.CacheStats()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, st)
+}
+
+// handleCachePurge serves DELETE /admin/cache?scope= (organizers), where scope
+// is player:{puuid}, match:{id} or all: the cached Riot answers in scope are
+// dropped and fetched again on the next analysis.
+func (s *Server) handleCachePurge(w http.ResponseWriter, r *http.Request) {
+	if !s.organizer(w, r) {
+		return
+	}
+	scope := r.URL.Query().Get("scope")
+	kind, subject, _ := strings.Cut(scope, ":")
+	switch {
+	case scope == "all":
+		s.Analyzer.ForgetMatches()
+		s.Analyzer.ForgetLeagues()
+	case kind == "match" && subject != "":
+		s.Analyzer.ForgetMatches(subject)
+	case kind == "player" && subject != "":
+		s.Analyzer.ForgetLeagues(subject)
+	default:
+		http.Error(w, "scope must be player:{puuid}, match:{id} or all", http.StatusBadRequest)
+		return
+	}
+	n, err := s.Analyzer.Riot.PurgeCache(subject)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	log.Printf("[req %s] purged %d cached riot responses (%s)", RequestID(r.Context()), n, scope)
+	writeJSON(w, http.StatusOK, map[string]any{"scope": scope, "purged": n})
+}
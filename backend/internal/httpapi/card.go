@@ -0,0 +1,145 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"lol_custom_skill_matching/internal/assets"
+	"lol_custom_skill_matching/internal/cache"
+	"lol_custom_skill_matching/internal/riot"
+	"lol_custom_skill_matching/internal/store"
+)
+
+// cardTTL is how long a card is served from memory; a roster view redraws
+// often, ranks and icons change rarely.
+const cardTTL = 10 * time.Minute
+
+// playerCard is the compact roster-view summary of a player.
+type playerCard struct {
+	Name      string         `json:"name"`
+	Platform  string         `json:"platform,omitempty"`
+	IconURL   string         `json:"icon_url"`
+	Level     int            `json:"level"`
+	Rank      assets.Rank    `json:"rank"`
+	Wins      int            `json:"wins"`
+	Losses    int            `json:"losses"`
+	Winrate   int            `json:"winrate"` // ranked solo, percent
+	Champions []cardChampion `json:"top_champions"`
+	// Score is the last computed skill score, when the player has been analyzed.
+	Score    *int      `json:"score,omitempty"`
+	Verified bool      `json:"verified"`
+	CachedAt time.Time `json:"cached_at"`
+}
+
+type cardChampion struct {
+	Name    string `json:"name"`
+	IconURL string `json:"icon_url"`
+	Points  int    `json:"mastery_points"`
+}
+
+// handlePlayerCard serves GET /players/{riotId}/card. Cards are built from four
+// Riot calls (account, summoner, league, mastery) and kept for cardTTL; the
+// score and verification come from the store on every request. The
+// organizer's note and tags stay out of it: cards are public.
+func (s *Server) handlePlayerCard(w http.ResponseWriter, r *http.Request) {
+	p, ok := parseRiotID(r.PathValue("riotId"))
+	if !ok {
+		http.Error(w, "invalid riot id (expected name#tag or name-tag)", http.StatusBadRequest)
+		return
+	}
+	proj, err := parseFields(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	key := store.RiotIDKey(p.GameName, p.TagLine)
+	card, cached := s.cardCache().Get(key)
+	if !cached {
+		card, err = s.buildCard(r.Context(), p.GameName, p.TagLine)
+		if errors.Is(err, errPlayerNotFound) {
+			http.Error(w, "player not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		s.cards.Set(key, card)
+	}
+	card.Score = nil
+	for _, rt := range s.Store.Ratings() {
+		if strings.EqualFold(rt.Player, card.Name) {
+			score := rt.Score
+			if rt.Override != nil {
+				score = *rt.Override
+			}
+			card.Score = &score
+			break
+		}
+	}
+	_, card.Verified = s.Store.Verified(p.GameName, p.TagLine)
+	writeJSON(w, http.StatusOK, proj.project(card))
+}
+
+// cardCache returns the card cache, made on first use.
+func (s *Server) cardCache() *cache.TTL[string, playerCard] {
+	s.cardsOnce.Do(func() { s.cards = cache.NewTTL[string, playerCard](cardTTL) })
+	return s.cards
+}
+
+var errPlayerNotFound = errors.New("player not found")
+
+func (s *Server) buildCard(ctx context.Context, gameName, tagLine string) (playerCard, error) {
+	rc := s.Analyzer.Riot
+	account, found, err := rc.AccountByRiotID(ctx, gameName, tagLine)
+	if err != nil {
+		return playerCard{}, errors.New("account lookup failed")
+	}
+	if !found {
+		return playerCard{}, errPlayerNotFound
+	}
+	sm, found, err := rc.SummonerByPUUID(ctx, account.PUUID)
+	if err == nil && !found {
+		// no summoner on the default platform: the account plays on another shard
+		if _, moved, perr := rc.FindPlatform(ctx, account.PUUID); perr == nil && moved {
+			sm, found, err = rc.SummonerByPUUID(ctx, account.PUUID)
+		}
+	}
+	if err != nil || !found {
+		return playerCard{}, errors.New("summoner lookup failed")
+	}
+	card := playerCard{
+		Name:      account.GameName + "#" + account.TagLine,
+		Platform:  rc.PlatformOf(account.PUUID),
+		IconURL:   riot.ProfileIconURL(sm.ProfileIconID),
+		Level:     sm.SummonerLevel,
+		Rank:      assets.Unranked(),
+		Champions: []cardChampion{},
+		CachedAt:  time.Now().UTC(),
+	}
+	if entries, err := rc.LeagueEntries(ctx, account.PUUID); err == nil {
+		if e, ok := riot.SoloEntry(entries); ok {
+			card.Rank = assets.RankOf(e.Tier, e.Rank, e.LeaguePoints)
+			card.Wins, card.Losses = e.Wins, e.Losses
+			if n := e.Wins + e.Losses; n > 0 {
+				card.Winrate = e.Wins * 100 / n
+			}
+		}
+	}
+	masteries, _ := rc.Masteries(ctx, account.PUUID)
+	sort.Slice(masteries, func(i, j int) bool { return masteries[i].ChampionPoints > masteries[j].ChampionPoints })
+	champs := s.Analyzer.Champions(ctx)
+	for _, m := range masteries {
+		if len(card.Champions) >= 3 {
+			break
+		}
+		if name := champs.Name(m.ChampionID); name != "" {
+			card.Champions = append(card.Champions, cardChampion{Name: name, IconURL: champs.IconURL(m.ChampionID), Points: m.ChampionPoints})
+		}
+	}
+	return card, nil
+}
@@ -0,0 +1,107 @@
+package httpapi
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/riot"
+)
+
+// maxExtraLocales bounds ?locale=, each of which is a champion.json download.
+const maxExtraLocales = 4
+
+// championRegistry is the Data Dragon registry the analysis names champions
+// with, so clients show the same names and icons.
+type championRegistry struct {
+	Version string `json:"version"`
+	// Locales are the keys of each champion's names; the first is the one
+	// analyses use.
+	Locales   []string       `json:"locales"`
+	Champions []championMeta `json:"champions"`
+}
+
+type championMeta struct {
+	ID    int               `json:"id"`
+	Key   string            `json:"key"`   // Data Dragon id, e.g. "MonkeyKing"
+	Names map[string]string `json:"names"` // by locale
+	Roles []string          `json:"roles"` // Data Dragon tags, e.g. "Fighter"
+	// Positions are the champion's primary positions (teamPosition values).
+	Positions []string `json:"positions"`
+	IconURL   string   `json:"icon_url"`
+}
+
+// handleChampions serves GET /champions[?locale=en_US,ko_KR]: every champion
+// with its names in the server's locale and any asked for.
+func (s *Server) handleChampions(w http.ResponseWriter, r *http.Request) {
+	locales := []string{riot.DataDragonLocale}
+	if q := r.URL.Query().Get("locale"); q != "" {
+		for _, l := range strings.Split(q, ",") {
+			if !riot.ValidLocale(l) {
+				http.Error(w, "invalid locale (e.g. en_US)", http.StatusBadRequest)
+				return
+			}
+			if !slices.Contains(locales, l) {
+				locales = append(locales, l)
+			}
+		}
+		if len(locales) > 1+maxExtraLocales {
+			http.Error(w, "too many locales", http.StatusBadRequest)
+			return
+		}
+	}
+	champs := s.Analyzer.Champions(r.Context())
+	if len(champs.ByID) == 0 {
+		http.Error(w, "champion data unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	reg := championRegistry{Version: riot.DataDragonVersion, Locales: locales, Champions: make([]championMeta, 0, len(champs.ByID))}
+	for id, name := range champs.ByID {
+		roles, positions := champs.Roles[id], champs.Positions[id]
+		if roles == nil {
+			roles = []string{}
+		}
+		if positions == nil {
+			positions = []string{}
+		}
+		reg.Champions = append(reg.Champions, championMeta{
+			ID: id, Key: champs.Keys[id], Names: map[string]string{riot.DataDragonLocale: name},
+			Roles: roles, Positions: positions, IconURL: champs.IconURL(id),
+		})
+	}
+	sort.Slice(reg.Champions, func(i, j int) bool { return reg.Champions[i].ID < reg.Champions[j].ID })
+	for _, l := range locales[1:] {
+		other, err := s.Analyzer.ChampionsIn(r.Context(), l)
+		if err != nil {
+			http.Error(w, "data dragon: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+		for i, c := range reg.Champions {
+			if name := other.Name(c.ID); name != "" {
+				reg.Champions[i].Names[l] = name
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, reg)
+}
+
+// queueMeta is a queue of the registry.
+type queueMeta struct {
+	riot.Queue
+	// Default is whether analyses count the queue without a "queues" option.
+	Default bool `json:"default"`
+}
+
+// handleQueues serves GET /queues: the queue registry (the built-in queues,
+// then queues.json once the static data loader has it), for clients naming
+// queues and choosing /analyze's "queues".
+func (s *Server) handleQueues(w http.ResponseWriter, r *http.Request) {
+	qs := riot.AllQueues()
+	out := make([]queueMeta, len(qs))
+	for i, q := range qs {
+		out[i] = queueMeta{Queue: q, Default: slices.Contains(analyzer.DefaultQueues, q.ID)}
+	}
+	writeJSON(w, http.StatusOK, out)
+}
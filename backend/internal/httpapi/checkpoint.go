@@ -0,0 +1,202 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/riot"
+	"lol_custom_skill_matching/internal/store"
+)
+
+// jobStopTimeout bounds how long stopped analyze jobs get to wind down
+// before their progress is saved.
+const jobStopTimeout = 10 * time.Second
+
+// checkpointedJob is an analyze job a shutdown stopped, for the next
+// process to finish.
+type checkpointedJob struct {
+	ID        string         `json:"id"`
+	RequestID string         `json:"request_id,omitempty"`
+	Tenant    string         `json:"tenant,omitempty"`
+	Request   analyzeRequest `json:"request"`
+	// Profiles are the players analyzed before the stop.
+	Profiles  []profileRecord `json:"profiles,omitempty"`
+	Attempts  int             `json:"attempts"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// profileRecord keeps the profile fields its JSON leaves out.
+type profileRecord struct {
+	analyzer.Profile
+	PUUID     string                  `json:"puuid"`
+	Matches   []analyzer.MatchSummary `json:"matches,omitempty"`
+	RiotCalls int64                   `json:"riot_calls"`
+}
+
+// jobsStopped is done once DrainJobs stops the jobs still running.
+func (s *Server) jobsStopped() context.Context {
+	s.stopOnce.Do(func() { s.stop, s.stopJobs = context.WithCancel(context.Background()) })
+	return s.stop
+}
+
+// DrainJobs waits for the analyze jobs in flight to finish until ctx is
+// done, then stops the rest. With a path, their progress (the request and
+// the profiles so far) is saved there and the next process finishes them
+// under the same ids (see ResumeJobs). Jobs on a caller's Riot key are only
+// stopped: the key is never written down. It returns how many jobs it
+// stopped.
+func (s *Server) DrainJobs(ctx context.Context, path string) (int, error) {
+	if s.load.wait(ctx) == nil {
+		return 0, nil
+	}
+	jobs := s.load.jobs()
+	s.jobsStopped()
+	s.stopJobs()
+	wctx, cancel := context.WithTimeout(context.Background(), jobStopTimeout)
+	defer cancel()
+	if err := s.load.wait(wctx); err != nil {
+		log.Printf("analyze jobs: %d still running %s after being stopped", len(s.load.jobs()), jobStopTimeout)
+	}
+	if path == "" {
+		return len(jobs), nil
+	}
+	var saved []checkpointedJob
+	for _, j := range jobs {
+		if c, ok := j.checkpoint(); ok {
+			saved = append(saved, c)
+		}
+	}
+	if len(saved) == 0 {
+		return len(jobs), nil
+	}
+	b, err := json.Marshal(saved)
+	if err != nil {
+		return len(jobs), err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return len(jobs), err
+	}
+	return len(jobs), os.Rename(tmp, path)
+}
+
+// checkpoint returns what it takes to finish j in another process; ok is
+// false when there is nothing to finish or it can't be written down.
+func (j *analyzeJob) checkpoint() (checkpointedJob, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.status.State == jobDone || riot.UsesCallerKey(j.ctx) {
+		return checkpointedJob{}, false
+	}
+	c := checkpointedJob{
+		ID: j.status.ID, RequestID: RequestID(j.ctx), Tenant: riot.TenantFrom(j.ctx), Request: j.req,
+		Attempts: j.status.Attempts, CreatedAt: j.status.CreatedAt,
+	}
+	for _, p := range j.req.Players {
+		if prof, ok := j.profiles[store.RiotIDKey(p.GameName, p.TagLine)]; ok {
+			c.Profiles = append(c.Profiles, profileRecord{Profile: prof, PUUID: prof.PUUID, Matches: prof.Matches, RiotCalls: prof.RiotCalls})
+		}
+	}
+	return c, true
+}
+
+// AwaitHandoff marks this server as the successor of one still draining
+// (SIGHUP reload): until ResumeJobs runs, a job id it doesn't know may be one
+// of the predecessor's, so GET /analyze/jobs/{id} answers 503 instead of 404
+// and GET /ws waits before subscribing.
+func (s *Server) AwaitHandoff() {
+	s.handoffMu.Lock()
+	defer s.handoffMu.Unlock()
+	if s.handoff == nil {
+		s.handoff = make(chan struct{})
+	}
+}
+
+// handoffPending is closed once the jobs handed over are resumed; nil when
+// none are awaited.
+func (s *Server) handoffPending() <-chan struct{} {
+	s.handoffMu.Lock()
+	defer s.handoffMu.Unlock()
+	return s.handoff
+}
+
+// endHandoff releases the lookups AwaitHandoff held back.
+func (s *Server) endHandoff() {
+	s.handoffMu.Lock()
+	defer s.handoffMu.Unlock()
+	if s.handoff != nil {
+		close(s.handoff)
+		s.handoff = nil
+	}
+}
+
+// ResumeJobs restarts the analyze jobs DrainJobs saved to path and removes
+// it. Each keeps its id, so clients polling it carry on, and only analyzes
+// the players it has no profile for yet. It returns how many it restarted.
+// On a reload it must run after the predecessor's DrainJobs (see
+// AwaitHandoff).
+func (s *Server) ResumeJobs(path string) (int, error) {
+	defer s.endHandoff()
+	if path == "" {
+		return 0, nil
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var saved []checkpointedJob
+	if err := json.Unmarshal(b, &saved); err != nil {
+		return 0, fmt.Errorf("%s: %w", path, err)
+	}
+	n := 0
+	for _, c := range saved {
+		preset, opts, aerr := s.prepareAnalysis(c.Request)
+		if aerr != nil {
+			log.Printf("[req %s] analyze job %s not resumed: %v", c.RequestID, c.ID, aerr.Body)
+			continue
+		}
+		ctx := riot.WithTenant(context.WithValue(context.Background(), ctxReqID, c.RequestID), c.Tenant)
+		ctx, usage := riot.WithUsage(ctx)
+		j := &analyzeJob{
+			status: jobStatus{
+				ID: c.ID, State: jobRunning, Players: len(c.Request.Players), Attempts: c.Attempts,
+				CreatedAt: c.CreatedAt, UpdatedAt: time.Now(),
+			},
+			ctx:      ctx,
+			usage:    usage,
+			req:      c.Request,
+			preset:   preset,
+			opts:     opts,
+			profiles: map[string]analyzer.Profile{},
+		}
+		for _, r := range c.Profiles {
+			prof := r.Profile
+			prof.PUUID, prof.Matches, prof.RiotCalls = r.PUUID, r.Matches, r.RiotCalls
+			if id, ok := parseRiotID(prof.Name); ok {
+				j.profiles[store.RiotIDKey(id.GameName, id.TagLine)] = prof
+			}
+		}
+		var missing []analyzer.Player
+		for _, p := range c.Request.Players {
+			if _, ok := j.profiles[store.RiotIDKey(p.GameName, p.TagLine)]; !ok {
+				missing = append(missing, p)
+			}
+		}
+		j.status.Analyzed = len(j.profiles)
+		s.analyzeJobs().Set(c.ID, j)
+		log.Printf("[req %s] analyze job %s resumed players=%d", c.RequestID, c.ID, len(missing))
+		s.load.start(j)
+		go s.runJob(j, missing)
+		n++
+	}
+	return n, os.Remove(path)
+}
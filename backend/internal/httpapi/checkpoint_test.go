@@ -0,0 +1,134 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/riot/riottest"
+	"lol_custom_skill_matching/internal/store"
+)
+
+// TestReloadHandoff plays a SIGHUP reload: the successor is up while the old
+// process still runs a job, and must take the job over only once the old one
+// drained and saved it.
+func TestReloadHandoff(t *testing.T) {
+	fake := riottest.NewServer()
+	defer fake.Close()
+	defer fake.ServeDataDragon()()
+	players := fake.AddCommunity()[:2]
+
+	// the old process's Riot API hangs, so its job is still running
+	reached := make(chan struct{}, 1)
+	stall := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case reached <- struct{}{}:
+		default:
+		}
+		<-r.Context().Done()
+	}))
+	defer stall.Close()
+	oldRC := fake.Client()
+	oldRC.RegionalHost, oldRC.PlatformHost = stall.URL, stall.URL
+	old := &Server{Analyzer: analyzer.New(oldRC, 4), Store: store.NewMemory(), MatchLimit: 10}
+	succ := &Server{Analyzer: analyzer.New(fake.Client(), 4), Store: store.NewMemory(), MatchLimit: 10}
+	succ.AwaitHandoff()
+	api := httptest.NewServer(succ.Handler())
+	defer api.Close()
+
+	body := `{"players":[{"gameName":"` + players[0].GameName + `","tagLine":"` + players[0].TagLine +
+		`"},{"gameName":"` + players[1].GameName + `","tagLine":"` + players[1].TagLine + `"}]}`
+	rec := httptest.NewRecorder()
+	old.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/analyze/jobs", strings.NewReader(body)))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("POST /analyze/jobs = %d: %s", rec.Code, rec.Body)
+	}
+	var st jobStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &st); err != nil {
+		t.Fatal(err)
+	}
+	<-reached
+
+	// before the handoff the successor neither knows nor forgets the job
+	resp, err := http.Get(api.URL + "/analyze/jobs/" + st.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Retry-After") == "" {
+		t.Fatalf("GET job before the handoff = %d (Retry-After %q), want 503 with Retry-After", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(api.URL, "http")+"/ws?job="+st.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	first := make(chan jobEvent, 1)
+	go func() {
+		var ev jobEvent
+		conn.SetReadDeadline(time.Now().Add(30 * time.Second))
+		if conn.ReadJSON(&ev) == nil {
+			first <- ev
+		}
+		close(first)
+	}()
+
+	// the checkpoint only exists once the old process drained
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("checkpoint before the drain: %v", err)
+	}
+	select {
+	case ev := <-first:
+		t.Fatalf("ws message before the handoff: %+v", ev)
+	default:
+	}
+	expired, cancel := context.WithCancel(context.Background())
+	cancel()
+	if n, err := old.DrainJobs(expired, path); n != 1 || err != nil {
+		t.Fatalf("DrainJobs = %d, %v; want 1 job stopped", n, err)
+	}
+	if n, err := succ.ResumeJobs(path); n != 1 || err != nil {
+		t.Fatalf("ResumeJobs = %d, %v; want 1 job resumed", n, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("checkpoint left after ResumeJobs: %v", err)
+	}
+
+	ev, ok := <-first
+	if !ok || ev.Job != st.ID || ev.Status == nil {
+		t.Fatalf("first ws message after the handoff = %+v, want the job's status", ev)
+	}
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		resp, err := http.Get(api.URL + "/analyze/jobs/" + st.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got jobStatus
+		err = json.NewDecoder(resp.Body).Decode(&got)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || err != nil {
+			t.Fatalf("GET job after the handoff = %d, %v", resp.StatusCode, err)
+		}
+		if got.State == jobDone {
+			if got.ResultID == "" || got.ID != st.ID {
+				t.Errorf("resumed job = %+v, want the same id with a result", got)
+			}
+			break
+		}
+		if got.State == jobFailed || time.Now().After(deadline) {
+			t.Fatalf("resumed job = %+v, want it done", got)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
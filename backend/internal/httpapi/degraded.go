@@ -0,0 +1,64 @@
+package httpapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+)
+
+// degradedMeta explains a result built from stored profiles because Riot was
+// unreachable (the client's breaker was open).
+type degradedMeta struct {
+	Reason  string         `json:"reason"`
+	Stale   []staleProfile `json:"stale"`
+	Missing []string       `json:"missing"` // players with no stored profile, left out
+}
+
+type staleProfile struct {
+	Player   string    `json:"player"`
+	AsOf     time.Time `json:"as_of"` // when the profile was computed
+	AgeHours int       `json:"age_hours"`
+}
+
+// staleProfiles stands in for Analyze while Riot is down: every player gets
+// their freshest stored profile (see store.LatestProfile), labelled uncertain
+// with its age and its skill interval widened by it. Declared champion pools still apply, resolved with the cached
+// Data Dragon registry.
+func (s *Server) staleProfiles(ctx context.Context, players []analyzer.Player, opts analyzer.Options) ([]analyzer.Profile, *degradedMeta) {
+	meta := &degradedMeta{Reason: "riot api unavailable; using stored profiles", Stale: []staleProfile{}, Missing: []string{}}
+	profiles := make([]analyzer.Profile, 0, len(players))
+	for _, pl := range players {
+		p, asOf, ok := s.Store.LatestProfile(pl.RiotID())
+		if !ok {
+			meta.Missing = append(meta.Missing, pl.RiotID())
+			continue
+		}
+		if len(pl.Champions) > 0 {
+			p.MainChampions = s.Analyzer.Champions(ctx).Resolve(pl.Champions)
+			p.ChampionPoolSource = "declared"
+		}
+		p.Age(time.Since(asOf))
+		if opts.BalanceOn == analyzer.BalanceOnConservative {
+			p.BalanceScore = p.SkillInterval.Low
+		}
+		p.UncertainRating = true
+		p.UncertainReasons = append(p.UncertainReasons,
+			fmt.Sprintf("stale profile from %s (Riot API unavailable)", asOf.Local().Format("2006-01-02 15:04")))
+		profiles = append(profiles, p)
+		meta.Stale = append(meta.Stale, staleProfile{Player: p.Name, AsOf: asOf, AgeHours: int(time.Since(asOf).Hours())})
+	}
+	return profiles, meta
+}
+
+// handleBreakerStats serves GET /stats/breaker.
+func (s *Server) handleBreakerStats(w http.ResponseWriter, r *http.Request) {
+	b := s.Analyzer.Riot.Breaker
+	if b == nil {
+		writeJSON(w, http.StatusOK, map[string]any{"state": "disabled"})
+		return
+	}
+	writeJSON(w, http.StatusOK, b.Stats())
+}
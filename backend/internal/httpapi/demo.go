@@ -0,0 +1,173 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/demo"
+)
+
+// demoPreset names the settings of a demo analysis in meta.preset.
+const demoPreset = "demo"
+
+// demoHandler routes the public demo: canned analyses of the sample roster,
+// the dry-run balancer and read-only metadata. Nothing reaches Riot or the
+// store, and analyses are rate limited per client.
+func (s *Server) demoHandler() http.Handler {
+	lim := &demoLimiter{perMinute: s.DemoRateLimit, counts: map[string]int{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("GET /readyz", s.handleReady)
+	mux.HandleFunc("GET /champions", s.handleChampions)
+	mux.HandleFunc("GET /queues", s.handleQueues)
+	mux.HandleFunc("GET /openapi.json", s.handleOpenAPI)
+	mux.HandleFunc("GET /docs", s.handleDocs)
+	mux.HandleFunc("GET /demo", s.handleDemoRoster)
+	mux.Handle("POST /analyze", lim.wrap(http.HandlerFunc(s.handleDemoAnalyze)))
+	mux.Handle("POST /balance", lim.wrap(http.HandlerFunc(s.handleBalance)))
+	return logRequests(s.withCORS(mux))
+}
+
+// handleDemoRoster serves GET /demo: the sample players a demo analysis knows.
+func (s *Server) handleDemoRoster(w http.ResponseWriter, r *http.Request) {
+	players := []analyzer.Player{}
+	for _, p := range demo.Roster() {
+		if id, ok := parseRiotID(p.Name); ok {
+			players = append(players, id)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"demo": true, "players": players})
+}
+
+// handleDemoAnalyze serves POST /analyze in demo mode: the sample roster's
+// stored profiles (all of them when no players are given) split like a real
+// analysis. Sides are assigned but not recorded.
+func (s *Server) handleDemoAnalyze(w http.ResponseWriter, r *http.Request) {
+	var req analyzeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	opts := analyzer.Options{
+		Mode: req.Mode, BalanceOn: req.BalanceOn, Objective: req.Objective, MaxLaneGap: req.MaxLaneGap,
+		LaneWeight: req.LaneWeight, SidePolicy: req.SidePolicy, Captains: req.Captains, TagGroups: s.tagGroups(req.TagRules),
+	}
+	if err := opts.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var err error
+	if opts.Teams, err = resolveTeams(req.Teams, req.RandomTeamNames); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	roster := demo.Roster()
+	profiles := roster
+	if len(req.Players) > 0 {
+		byName, seen := map[string]analyzer.Profile{}, map[string]bool{}
+		for _, p := range roster {
+			byName[strings.ToLower(p.Name)] = p
+		}
+		profiles = nil
+		for _, p := range req.Players {
+			key := strings.ToLower(p.RiotID())
+			prof, ok := byName[key]
+			if !ok {
+				http.Error(w, "demo mode only knows the sample roster (GET /demo); "+p.RiotID()+" isn't in it", http.StatusBadRequest)
+				return
+			}
+			if seen[key] {
+				http.Error(w, "duplicate player "+p.RiotID(), http.StatusBadRequest)
+				return
+			}
+			seen[key] = true
+			profiles = append(profiles, prof)
+		}
+		if len(profiles) < 2 {
+			http.Error(w, "need at least 2 players", http.StatusBadRequest)
+			return
+		}
+	}
+	if err := checkCaptains(opts.Captains, profileNames(profiles)); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if opts.BalanceOn == analyzer.BalanceOnConservative {
+		for i := range profiles {
+			profiles[i].BalanceScore = profiles[i].SkillInterval.Low
+		}
+	}
+	split := analyzer.Split(profiles, opts)
+	if !split.Validation.OK {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"error": analyzer.ErrCorruptSplit.Error(), "validation": split.Validation})
+		return
+	}
+	analyzer.AssignSides(&split, opts.SidePolicy, s.Store.SideStats, rand.New(rand.NewPCG(uint64(time.Now().UnixNano()), 1)))
+	analyzer.PredictWins(&split, s.Analyzer.WinScale)
+	meta := &analyzeMeta{Players: len(profiles), Preset: analyzer.Preset{Name: demoPreset}, Demo: true}
+	fields := splitFields(split, meta)
+	if wantsNDJSON(r) {
+		w.Header().Set("Content-Type", ndjsonContentType)
+		if err := streamNDJSON(w, fields); err != nil {
+			log.Printf("[req %s] stream error: %v", RequestID(r.Context()), err)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := streamJSON(w, fields); err != nil {
+		log.Printf("[req %s] stream error: %v", RequestID(r.Context()), err)
+	}
+}
+
+// demoLimiter allows each client perMinute requests per calendar minute
+// (0 = unlimited). A fixed window is crude but enough to keep a public demo
+// from being scripted against.
+type demoLimiter struct {
+	perMinute int
+	mu        sync.Mutex
+	window    time.Time
+	counts    map[string]int // client -> requests this window
+}
+
+func (l *demoLimiter) allow(client string, now time.Time) (bool, time.Duration) {
+	if l.perMinute <= 0 {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if w := now.Truncate(time.Minute); !w.Equal(l.window) {
+		l.window = w
+		clear(l.counts)
+	}
+	if l.counts[client] >= l.perMinute {
+		return false, l.window.Add(time.Minute).Sub(now)
+	}
+	l.counts[client]++
+	return true, 0
+}
+
+func (l *demoLimiter) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := clientIP(r)
+		if host, _, err := net.SplitHostPort(client); err == nil {
+			client = host // each connection has its own port
+		}
+		if ok, wait := l.allow(client, time.Now()); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+			http.Error(w, "demo rate limit reached; try again in a minute", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
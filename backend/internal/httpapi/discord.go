@@ -0,0 +1,43 @@
+package httpapi
+
+import (
+	"fmt"
+	"net/http"
+
+	"lol_custom_skill_matching/internal/discord"
+)
+
+// handleDiscordInteraction serves POST /discord/interactions, the bot's
+// interactions endpoint: Discord posts the slash commands (discord.Commands)
+// there, signed with the application's key (DiscordKey).
+func (s *Server) handleDiscordInteraction(w http.ResponseWriter, r *http.Request) {
+	if s.DiscordKey == nil {
+		http.Error(w, "discord commands are disabled (DISCORD_PUBLIC_KEY)", http.StatusNotFound)
+		return
+	}
+	in, err := discord.ReadInteraction(r, s.DiscordKey)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case in.IsPing():
+		writeJSON(w, http.StatusOK, discord.Pong())
+	case !in.IsCommand():
+		http.Error(w, "unsupported interaction", http.StatusBadRequest)
+	case !in.Organizer():
+		writeJSON(w, http.StatusOK, discord.Reply("Only members who can manage the server may use this command."))
+	case in.Data.Name == "forget":
+		p, ok := parseRiotID(in.Option("riot_id"))
+		if !ok {
+			writeJSON(w, http.StatusOK, discord.Reply("Expected a Riot ID like Alice#JP1."))
+			return
+		}
+		d := s.deletePlayer(r.Context(), p)
+		writeJSON(w, http.StatusOK, discord.Reply(fmt.Sprintf(
+			"Deleted %s's stored data (%d matches, %d results anonymized as %s); they are kept out of analyses from now on.",
+			d.Player, d.Matches, len(d.Results), d.Alias)))
+	default:
+		writeJSON(w, http.StatusOK, discord.Reply("Unknown command."))
+	}
+}
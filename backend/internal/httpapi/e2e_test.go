@@ -0,0 +1,250 @@
+package httpapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/clock"
+	"lol_custom_skill_matching/internal/httpapi"
+	"lol_custom_skill_matching/internal/riot"
+	"lol_custom_skill_matching/internal/riot/riottest"
+	"lol_custom_skill_matching/internal/store"
+)
+
+// TestMain keeps the server's request logs out of the output unless -v.
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if !testing.Verbose() {
+		log.SetOutput(io.Discard)
+	}
+	os.Exit(m.Run())
+}
+
+// breakerCooldown is how long the breaker stays open; the test moves the
+// breaker's clock past it rather than waiting.
+const breakerCooldown = time.Minute
+
+type harness struct {
+	fake    *riottest.Server
+	riot    *riot.Client
+	breaker *clock.Fake
+	api     *httptest.Server
+	players []riottest.Player
+}
+
+type analyzeResponse struct {
+	TeamA      []analyzer.Profile       `json:"teamA"`
+	TeamB      []analyzer.Profile       `json:"teamB"`
+	Validation analyzer.SplitValidation `json:"validation"`
+	Meta       struct {
+		ResultID string              `json:"result_id"`
+		Cost     analyzer.CostReport `json:"cost"`
+		Degraded *struct {
+			Stale   []json.RawMessage `json:"stale"`
+			Missing []string          `json:"missing"`
+		} `json:"degraded"`
+	} `json:"meta"`
+}
+
+func (r analyzeResponse) profiles() []analyzer.Profile {
+	return append(append([]analyzer.Profile{}, r.TeamA...), r.TeamB...)
+}
+
+// analyze posts players to /analyze and fails the test on a status other
+// than want.
+func (h *harness) analyze(t *testing.T, players []riottest.Player, want int) analyzeResponse {
+	t.Helper()
+	type reqPlayer struct {
+		GameName string `json:"gameName"`
+		TagLine  string `json:"tagLine"`
+	}
+	body := struct {
+		Players []reqPlayer `json:"players"`
+	}{}
+	for _, p := range players {
+		body.Players = append(body.Players, reqPlayer{p.GameName, p.TagLine})
+	}
+	b, _ := json.Marshal(body)
+	resp, err := http.Post(h.api.URL+"/analyze", "application/json", bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != want {
+		t.Fatalf("POST /analyze: status %d, want %d: %s", resp.StatusCode, want, bytes.TrimSpace(raw))
+	}
+	var out analyzeResponse
+	if want == http.StatusOK {
+		if err := json.Unmarshal(raw, &out); err != nil {
+			t.Fatalf("decoding response: %v", err)
+		}
+	}
+	return out
+}
+
+// TestEndToEnd runs the web API against a fake Riot API (riottest): canned
+// accounts, matches, ranks and masteries, with injected 429 and 5xx answers.
+// Every step goes through POST /analyze, so the analyzer, match and rank
+// caches, limiter, retries and circuit breaker are all exercised. The steps
+// build on each other: the outage steps degrade to the profiles stored by
+// the first one.
+func TestEndToEnd(t *testing.T) {
+	fake := riottest.NewServer()
+	defer fake.Close()
+	defer fake.ServeDataDragon()()
+	rc := fake.Client()
+	rc.MaxRetry = 2
+	rc.Breaker = riot.NewBreaker(3, breakerCooldown)
+	bc := clock.NewFake(time.Now())
+	rc.Breaker.Clock = bc
+	srv := &httpapi.Server{Analyzer: analyzer.New(rc, 4), Store: store.NewMemory(), MatchLimit: 10}
+	api := httptest.NewServer(srv.Handler())
+	defer api.Close()
+	h := &harness{fake: fake, riot: rc, breaker: bc, api: api, players: fake.AddCommunity()}
+
+	steps := []struct {
+		name string
+		run  func(*testing.T, *harness)
+	}{
+		{"analyze_community", analyzeCommunity},
+		{"match_cache", matchCache},
+		{"unknown_riot_id", unknownRiotID},
+		{"rate_limited", rateLimited},
+		{"server_errors_retried", serverErrorsRetried},
+		{"outage_degrades", outageDegrades},
+		{"outage_recovers", outageRecovers},
+	}
+	for _, s := range steps {
+		if !t.Run(s.name, func(t *testing.T) { s.run(t, h) }) {
+			t.Fatalf("%s failed; the later steps depend on it", s.name)
+		}
+	}
+}
+
+func analyzeCommunity(t *testing.T, h *harness) {
+	res := h.analyze(t, h.players, http.StatusOK)
+	if !res.Validation.OK || len(res.TeamA) != 5 || len(res.TeamB) != 5 {
+		t.Fatalf("split %d vs %d, validation %+v", len(res.TeamA), len(res.TeamB), res.Validation)
+	}
+	byName := map[string]riottest.Player{}
+	for _, p := range h.players {
+		byName[p.RiotID()] = p
+	}
+	for _, p := range res.profiles() {
+		want, ok := byName[p.Name]
+		if !ok {
+			t.Fatalf("unexpected player %s", p.Name)
+		}
+		if p.Rank == nil || p.Rank.Tier != want.Tier {
+			t.Errorf("%s: rank %+v, want %s", p.Name, p.Rank, want.Tier)
+		}
+		if p.GamesAnalyzed != 10 || p.RankedRecentCount != 5 {
+			t.Errorf("%s: %d games (%d ranked), want 10 (5)", p.Name, p.GamesAnalyzed, p.RankedRecentCount)
+		}
+		if len(p.MainLanes) == 0 || p.MainLanes[0] != riottest.Lanes[strings.Index("0123456789", p.Name[6:7])%5] {
+			t.Errorf("%s: main lanes %v", p.Name, p.MainLanes)
+		}
+		if len(p.MainChampions) == 0 {
+			t.Errorf("%s: no champion names (Data Dragon not served?)", p.Name)
+		}
+		if p.LobbyRankSample == nil || p.LobbyRankSample.Rated == 0 {
+			t.Errorf("%s: lobby rank not sampled", p.Name)
+		}
+	}
+	// every match and every distinct puuid's rank is looked up once
+	if misses := int64(100 + h.fake.Hits(riottest.RouteLeague)); res.Meta.Cost.RiotCalls == 0 || res.Meta.Cost.CacheMisses != misses {
+		t.Errorf("cost %+v, want riot calls and %d cache misses", res.Meta.Cost, misses)
+	}
+}
+
+func matchCache(t *testing.T, h *harness) {
+	before, leagues := h.fake.Hits(riottest.RouteMatch), h.fake.Hits(riottest.RouteLeague)
+	res := h.analyze(t, h.players, http.StatusOK)
+	if n := h.fake.Hits(riottest.RouteMatch) - before; n != 0 {
+		t.Errorf("%d match requests on a re-analysis, want 0", n)
+	}
+	if n := h.fake.Hits(riottest.RouteLeague) - leagues; n != 0 {
+		t.Errorf("%d rank requests on a re-analysis, want 0", n)
+	}
+	if c := res.Meta.Cost; c.CacheHits < 100 || c.CacheMisses != 0 || c.CacheHitRate != 1 {
+		t.Errorf("cache hits %d misses %d rate %g, want at least 100, 0 and 1", c.CacheHits, c.CacheMisses, c.CacheHitRate)
+	}
+}
+
+func unknownRiotID(t *testing.T, h *harness) {
+	players := append([]riottest.Player{{GameName: "Nobody", TagLine: "JP1"}}, h.players[:3]...)
+	res := h.analyze(t, players, http.StatusOK)
+	if n := len(res.profiles()); n != 3 {
+		t.Errorf("%d profiles, want the 3 existing players", n)
+	}
+}
+
+func rateLimited(t *testing.T, h *harness) {
+	before := h.riot.Limiter.Stats().Throttled
+	h.fake.Inject(riottest.Fault{Status: http.StatusTooManyRequests, RetryAfter: "1", Times: 3})
+	h.analyze(t, h.players[:4], http.StatusOK)
+	if n := h.riot.Limiter.Stats().Throttled - before; n != 3 {
+		t.Errorf("limiter saw %d throttles, want 3", n)
+	}
+}
+
+func serverErrorsRetried(t *testing.T, h *harness) {
+	// one try fails, the retry (MaxRetry 2) gets through
+	h.fake.Inject(riottest.Fault{Route: riottest.RouteLeague, Status: http.StatusInternalServerError, Times: 1})
+	res := h.analyze(t, h.players[:4], http.StatusOK)
+	if res.Meta.Degraded != nil {
+		t.Errorf("degraded after a retried 500")
+	}
+	for _, p := range res.profiles() {
+		if p.Rank == nil || p.Rank.Tier == "" {
+			t.Errorf("%s lost its rank to a retried 500", p.Name)
+		}
+	}
+}
+
+func outageDegrades(t *testing.T, h *harness) {
+	h.fake.Inject(riottest.Fault{Status: http.StatusServiceUnavailable, Times: -1})
+	// each analysis stops at its first failed request (the account lookup)
+	// until the breaker's threshold (3) is reached, answering 503 as Riot's
+	// failure rather than the request's; the next one degrades
+	for i := 0; i < 2; i++ {
+		h.analyze(t, h.players, http.StatusServiceUnavailable)
+	}
+	res := h.analyze(t, h.players, http.StatusOK)
+	if res.Meta.Degraded == nil || len(res.Meta.Degraded.Stale) != len(h.players) {
+		t.Fatalf("degraded meta %+v, want %d stale profiles", res.Meta.Degraded, len(h.players))
+	}
+	if res.Meta.Cost.AccuracyTier != analyzer.AccuracyStale {
+		t.Errorf("accuracy tier %q", res.Meta.Cost.AccuracyTier)
+	}
+	for _, p := range res.profiles() {
+		if !p.UncertainRating {
+			t.Errorf("%s not labelled uncertain", p.Name)
+		}
+	}
+	if !h.riot.Breaker.Open() {
+		t.Errorf("breaker closed during the outage")
+	}
+}
+
+func outageRecovers(t *testing.T, h *harness) {
+	h.fake.ClearFaults()
+	h.breaker.Advance(breakerCooldown)
+	// the analysis's first request is the breaker's trial; it succeeds and
+	// the rest of the analysis runs normally
+	res := h.analyze(t, h.players[:4], http.StatusOK)
+	if res.Meta.Degraded != nil || h.riot.Breaker.Open() {
+		t.Errorf("still degraded after the outage ended")
+	}
+}
@@ -0,0 +1,174 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/assets"
+	"lol_custom_skill_matching/internal/events"
+)
+
+// publishJob publishes typ (events.JobStarted or JobFinished) for j; the
+// caller holds j.mu.
+func (s *Server) publishJob(typ string, j *analyzeJob) {
+	st := j.status
+	d := events.JobData{Players: st.Players, Preset: j.preset.Name, Attempt: st.Attempts}
+	var text string
+	switch typ {
+	case events.JobStarted:
+		d.Attempt++ // runJob counts it once done
+		text = fmt.Sprintf("analyze job %s started: %d players (attempt %d)", st.ID, st.Players, d.Attempt)
+	default:
+		d.State, d.ResultID, d.Failures = st.State, st.ResultID, len(st.Failures)
+		if st.Error != nil {
+			d.Error = st.Error.Message
+		}
+		switch {
+		case st.State == jobDone:
+			text = fmt.Sprintf("analyze job %s done: result %s", st.ID, st.ResultID)
+		case d.Error != "":
+			text = fmt.Sprintf("analyze job %s failed: %s", st.ID, d.Error)
+		default:
+			text = fmt.Sprintf("analyze job %s failed: %d of %d players without a profile", st.ID, d.Failures, st.Players)
+		}
+	}
+	s.Events.Publish(events.Event{Type: typ, Subject: st.ID, Text: text, Data: d})
+}
+
+// publishProfiles publishes events.ProfileUpdated for each profile whose
+// rating was recorded.
+func (s *Server) publishProfiles(profiles []analyzer.Profile) {
+	for _, p := range profiles {
+		rank := assets.Unranked().Label
+		if p.Rank != nil {
+			rank = p.Rank.Label
+		}
+		s.Events.Publish(events.Event{
+			Type: events.ProfileUpdated, Subject: p.Name,
+			Text: fmt.Sprintf("%s: skill %d (%s, %d games)", p.Name, p.SkillScore, rank, p.GamesAnalyzed),
+			Data: events.ProfileData{SkillScore: p.SkillScore, Rank: rank, GamesAnalyzed: p.GamesAnalyzed},
+		})
+	}
+}
+
+// publishResult publishes events.ResultRecorded for the stored result id. A
+// blind lobby's result is published hidden: its new split will be sealed, with
+// no role revealed yet.
+func (s *Server) publishResult(id, lobbyID string, ts analyzer.TeamSplit) {
+	d := events.ResultData{LobbyID: lobbyID, TeamA: []string{}, TeamB: []string{}}
+	if l, ok := s.Store.Lobby(lobbyID); ok && lobbyID != "" && l.Blind {
+		hidden, _ := hideSplit(&ts, l.Reveal, 0)
+		for _, sl := range hidden.TeamA {
+			d.TeamA = append(d.TeamA, sl.Name)
+		}
+		for _, sl := range hidden.TeamB {
+			d.TeamB = append(d.TeamB, sl.Name)
+		}
+		d.Hidden = true
+		s.Events.Publish(events.Event{
+			Type: events.ResultRecorded, Subject: id, Data: d,
+			Text: fmt.Sprintf("result %s: lobby %s hides the teams until both captains accept", id, l.Name),
+		})
+		return
+	}
+	d.SumA, d.SumB = &ts.SumA, &ts.SumB
+	for _, p := range ts.TeamA {
+		d.TeamA = append(d.TeamA, p.Name)
+	}
+	for _, p := range ts.TeamB {
+		d.TeamB = append(d.TeamB, p.Name)
+	}
+	s.Events.Publish(events.Event{
+		Type: events.ResultRecorded, Subject: id, Data: d,
+		Text: fmt.Sprintf("result %s: %s (%d) vs %s (%d)", id, strings.Join(d.TeamA, ", "), ts.SumA, strings.Join(d.TeamB, ", "), ts.SumB),
+	})
+}
+
+// SaveResult is the events.ResultRecorded subscriber writing each stored
+// result to Results, for traceability. A failed write is logged; the result
+// stays in the store.
+func (s *Server) SaveResult(ev events.Event) {
+	if s.Results == nil || ev.Type != events.ResultRecorded {
+		return
+	}
+	res, ok := s.Store.Result(ev.Subject)
+	if !ok {
+		return
+	}
+	path, err := s.Results.Write(res.ID, resultWriter(splitFields(res.Split, nil)))
+	if err != nil {
+		log.Printf("failed to write result file (%s): %v", s.Results.File(res.ID), err)
+		return
+	}
+	log.Printf("wrote result to %s", path)
+}
+
+// streamsClosed is done once CloseStreams ends the event streams.
+func (s *Server) streamsClosed() context.Context {
+	s.streamsOnce.Do(func() { s.streams, s.closeStreams = context.WithCancel(context.Background()) })
+	return s.streams
+}
+
+// CloseStreams ends the GET /events streams, which would otherwise keep a
+// shutdown waiting for as long as their clients stay.
+func (s *Server) CloseStreams() {
+	s.streamsClosed()
+	s.closeStreams()
+}
+
+// handleEvents serves GET /events (organizers), the event bus as Server-Sent
+// Events: one "event: <type>" message per events.Event, its JSON as data.
+// ?types=job.finished,result.recorded picks the types (default all).
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if !s.organizer(w, r) {
+		return
+	}
+	if s.Events == nil {
+		http.Error(w, "events are disabled", http.StatusNotFound)
+		return
+	}
+	types, err := events.ParseTypes(r.URL.Query().Get("types"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // nginx would hold the stream back
+	w.WriteHeader(http.StatusOK)
+	flush := flushOf(w)
+	flush()
+
+	// the subscriber and the keepalives take turns on w
+	var mu sync.Mutex
+	cancel := s.Events.Subscribe("sse", func(ev events.Event) {
+		b, _ := json.Marshal(ev)
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, b)
+		flush()
+	}, types...)
+	defer cancel()
+	t := time.NewTicker(wsPingInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.streamsClosed().Done():
+			return
+		case <-t.C:
+			mu.Lock()
+			fmt.Fprint(w, ": ping\n\n")
+			flush()
+			mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// projection is a ?fields= selection of JSON members, so list views can skip
+// the champion and lane maps: comma-separated paths whose dots descend into
+// objects, e.g. fields=teamA.name,teamA.rank,teamA.main_lanes,sumA. Lists
+// are looked through (teamA.name keeps every player's name) and a path that
+// ends on an object keeps all of it. A nil projection keeps everything.
+type projection map[string]projection
+
+// parseFields reads r's fields parameter (nil when absent).
+func parseFields(r *http.Request) (projection, error) {
+	v := r.URL.Query().Get("fields")
+	if v == "" {
+		return nil, nil
+	}
+	p := projection{}
+	for _, path := range strings.Split(v, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		node := p
+		keys := strings.Split(path, ".")
+		for i, k := range keys {
+			if k == "" {
+				return nil, fmt.Errorf("invalid fields path %q", path)
+			}
+			sub, seen := node[k]
+			switch {
+			case i == len(keys)-1:
+				node[k] = nil // the whole member, even if parts were asked for
+			case seen && sub == nil:
+				// already kept whole
+			default:
+				if sub == nil {
+					sub = projection{}
+					node[k] = sub
+				}
+			}
+			if sub == nil {
+				break
+			}
+			node = sub
+		}
+	}
+	if len(p) == 0 {
+		return nil, fmt.Errorf("fields names no member")
+	}
+	return p, nil
+}
+
+// project returns v with only the selected members.
+func (p projection) project(v any) any {
+	if p == nil {
+		return v
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var generic any
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return v
+	}
+	return p.apply(generic)
+}
+
+func (p projection) apply(v any) any {
+	if p == nil {
+		return v
+	}
+	switch t := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(p))
+		for k, sub := range p {
+			if x, ok := t[k]; ok {
+				out[k] = sub.apply(x)
+			}
+		}
+		return out
+	case []any:
+		out := make([]any, len(t))
+		for i, x := range t {
+			out[i] = p.apply(x)
+		}
+		return out
+	}
+	return v
+}
+
+// fields keeps the selected streamed fields, projecting each value and list
+// element on its own so large lists still stream.
+func (p projection) fields(fs []field) []field {
+	if p == nil {
+		return fs
+	}
+	var out []field
+	for _, f := range fs {
+		sub, ok := p[f.Key]
+		if !ok {
+			continue
+		}
+		if f.List != nil {
+			list := make([]any, len(f.List))
+			for i, item := range f.List {
+				list[i] = sub.project(item)
+			}
+			f.List = list
+		} else {
+			f.Value = sub.project(f.Value)
+		}
+		out = append(out, f)
+	}
+	return out
+}
@@ -0,0 +1,258 @@
+package httpapi
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/cache"
+	"lol_custom_skill_matching/internal/events"
+	"lol_custom_skill_matching/internal/riot"
+	"lol_custom_skill_matching/internal/store"
+)
+
+const (
+	jobRunning = "running"
+	jobDone    = "done"
+	jobFailed  = "failed"
+)
+
+// jobTTL is how long analyze jobs can be polled and retried.
+const jobTTL = 24 * time.Hour
+
+// jobStatus is the progress of an analyze job.
+type jobStatus struct {
+	ID       string `json:"id"`
+	State    string `json:"state"`
+	Players  int    `json:"players"`
+	Analyzed int    `json:"analyzed"` // players with a profile
+	Attempts int    `json:"attempts"`
+	// Failures are the players without a profile; Error is a failure of the
+	// job as a whole (e.g. a split that didn't validate).
+	Failures []analyzer.PlayerFailure `json:"failures,omitempty"`
+	Error    *analyzer.Failure        `json:"error,omitempty"`
+	// Retryable is set on a failed job that POST /analyze/jobs/{id}/retry can
+	// make progress on.
+	Retryable bool         `json:"retryable"`
+	ResultID  string       `json:"result_id,omitempty"` // GET /results/{id}
+	Meta      *analyzeMeta `json:"meta,omitempty"`
+	// Trace is how long each player's analysis phases took, over all attempts.
+	Trace     []analyzer.PlayerTrace `json:"trace,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+	UpdatedAt time.Time              `json:"updated_at"`
+}
+
+// analyzeJob is an analysis run in the background (POST /analyze/jobs). A
+// player that fails keeps the job from finishing; the others' profiles are
+// kept, so a retry only analyzes the failed players again.
+type analyzeJob struct {
+	mu       sync.Mutex
+	status   jobStatus
+	ctx      context.Context // request values, all attempts' Riot usage
+	usage    *riot.Usage
+	req      analyzeRequest
+	preset   analyzer.Preset
+	opts     analyzer.Options
+	profiles map[string]analyzer.Profile // RiotIDKey -> profile
+
+	smu  sync.Mutex
+	subs map[chan jobEvent]struct{} // GET /ws subscribers
+}
+
+func (j *analyzeJob) snapshot() jobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.statusLocked()
+}
+
+// statusLocked is snapshot for callers holding j.mu.
+func (j *analyzeJob) statusLocked() jobStatus {
+	st := j.status
+	st.Failures = slices.Clone(st.Failures)
+	st.Trace = slices.Clone(st.Trace)
+	return st
+}
+
+// analyzeJobs returns the jobs that can still be polled, by id.
+func (s *Server) analyzeJobs() *cache.TTL[string, *analyzeJob] {
+	s.jobsOnce.Do(func() { s.jobs = cache.NewTTL[string, *analyzeJob](jobTTL) })
+	return s.jobs
+}
+
+// handleCreateJob serves POST /analyze/jobs: the body of POST /analyze,
+// analyzed in the background. Progress and the result id are polled with
+// GET /analyze/jobs/{id}.
+func (s *Server) handleCreateJob(w http.ResponseWriter, r *http.Request) {
+	var req analyzeRequest
+	if !decodeAnalyzeRequest(w, r, &req) {
+		return
+	}
+	if len(req.Players) < 2 {
+		http.Error(w, "need at least 2 players", http.StatusBadRequest)
+		return
+	}
+	preset, opts, aerr := s.prepareAnalysis(req)
+	if aerr != nil {
+		aerr.write(w)
+		return
+	}
+	if n, wait, avg := s.load.backlog(); s.saturated(w, queueAnalyze, n, wait, avg) {
+		return
+	}
+	// the job outlives the request but keeps its id and tenant
+	ctx, usage := riot.WithUsage(context.WithoutCancel(r.Context()))
+	now := time.Now()
+	j := &analyzeJob{
+		status:   jobStatus{ID: reqID(), State: jobRunning, Players: len(req.Players), CreatedAt: now, UpdatedAt: now},
+		ctx:      ctx,
+		usage:    usage,
+		req:      req,
+		preset:   preset,
+		opts:     opts,
+		profiles: map[string]analyzer.Profile{},
+	}
+	s.analyzeJobs().Set(j.status.ID, j)
+	log.Printf("[req %s] analyze job %s start players=%d preset=%s", RequestID(r.Context()), j.status.ID, len(req.Players), preset.Name)
+	s.load.start(j)
+	go s.runJob(j, req.Players)
+	writeJSON(w, http.StatusAccepted, j.snapshot())
+}
+
+func (s *Server) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	j, ok := s.analyzeJobs().Get(r.PathValue("id"))
+	if !ok && s.handoffPending() != nil {
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "job not found yet: the previous process is still handing its jobs over", http.StatusServiceUnavailable)
+		return
+	}
+	if !ok {
+		http.Error(w, "job not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, j.snapshot())
+}
+
+// handleRetryJob serves POST /analyze/jobs/{id}/retry: analyze the failed job's
+// retryable failures again, reusing the profiles (and cached Riot data) the
+// earlier attempts got.
+func (s *Server) handleRetryJob(w http.ResponseWriter, r *http.Request) {
+	j, ok := s.analyzeJobs().Get(r.PathValue("id"))
+	if !ok {
+		http.Error(w, "job not found", http.StatusNotFound)
+		return
+	}
+	if n, wait, avg := s.load.backlog(); s.saturated(w, queueAnalyze, n, wait, avg) {
+		return
+	}
+	j.mu.Lock()
+	st := &j.status
+	if st.State != jobFailed || !st.Retryable {
+		state := st.State
+		j.mu.Unlock()
+		http.Error(w, "only a failed job with retryable failures can be retried (state "+state+")", http.StatusConflict)
+		return
+	}
+	var players []analyzer.Player
+	var kept []analyzer.PlayerFailure
+	for _, f := range st.Failures {
+		if f.Retryable {
+			players = append(players, f.Player)
+		} else {
+			kept = append(kept, f)
+		}
+	}
+	st.State, st.Failures, st.Error, st.Retryable, st.UpdatedAt = jobRunning, kept, nil, false, time.Now()
+	j.publishStatus(jobEventStatus)
+	j.mu.Unlock()
+	log.Printf("[req %s] analyze job %s retry players=%d", RequestID(r.Context()), st.ID, len(players))
+	s.load.start(j)
+	go s.runJob(j, players)
+	writeJSON(w, http.StatusAccepted, j.snapshot())
+}
+
+// runJob analyzes players for j and, once every player has a profile,
+// completes the analysis like POST /analyze.
+func (s *Server) runJob(j *analyzeJob, players []analyzer.Player) {
+	defer s.load.finish(j)
+	rid := RequestID(j.ctx)
+	j.mu.Lock()
+	s.publishJob(events.JobStarted, j)
+	j.mu.Unlock()
+	// a shutdown stops the analysis; DrainJobs saves what it got
+	actx, cancel := context.WithCancel(j.ctx)
+	defer cancel()
+	stop := context.AfterFunc(s.jobsStopped(), cancel)
+	defer stop()
+	tctx, trace := analyzer.WithTrace(actx)
+	tctx = analyzer.WithPhaseEvents(tctx, func(e analyzer.PhaseEvent) {
+		j.publish(jobEvent{Type: jobEventPhase, Job: j.status.ID, Phase: &e})
+	})
+	profiles, failures, err := s.Analyzer.AnalyzeEach(tctx, s.withDeclaredPools(players), j.opts)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	st := &j.status
+	defer func() {
+		if st.State == jobDone {
+			j.publishStatus(jobEventTeams)
+		} else {
+			j.publishStatus(jobEventStatus)
+		}
+		s.publishJob(events.JobFinished, j)
+	}()
+	defer func() { st.UpdatedAt = time.Now() }()
+	st.Attempts++
+	st.Trace = append(st.Trace, trace.Players()...)
+	if err != nil {
+		st.State, st.Error = jobFailed, &analyzer.Failure{Category: analyzer.ErrorInvalidInput, Message: err.Error()}
+		return
+	}
+	for _, p := range profiles {
+		if id, ok := parseRiotID(p.Name); ok {
+			j.profiles[store.RiotIDKey(id.GameName, id.TagLine)] = p
+		}
+	}
+	st.Analyzed = len(j.profiles)
+	st.Failures = append(st.Failures, failures...)
+	if len(st.Failures) > 0 {
+		st.State = jobFailed
+		for _, f := range st.Failures {
+			st.Retryable = st.Retryable || f.Retryable
+		}
+		log.Printf("[req %s] analyze job %s failed players=%d retryable=%t", rid, st.ID, len(st.Failures), st.Retryable)
+		return
+	}
+	all := make([]analyzer.Profile, 0, len(j.profiles))
+	for _, p := range j.req.Players {
+		if prof, ok := j.profiles[store.RiotIDKey(p.GameName, p.TagLine)]; ok {
+			all = append(all, prof)
+		}
+	}
+	_, meta, aerr := s.completeAnalysis(j.ctx, j.req, "", j.preset, j.opts, all, nil, j.usage, st.CreatedAt)
+	if aerr != nil {
+		st.State, st.Error = jobFailed, jobFailure(aerr)
+		return
+	}
+	st.State, st.ResultID, st.Meta = jobDone, meta.ResultID, meta
+	log.Printf("[req %s] analyze job %s done result=%s", rid, st.ID, meta.ResultID)
+}
+
+// jobFailure classifies an error completing a job's analysis: the request's
+// fault for 4xx statuses (e.g. unverified players), ours otherwise.
+func jobFailure(aerr *apiError) *analyzer.Failure {
+	f := &analyzer.Failure{Category: analyzer.ErrorInternal}
+	if aerr.Status < 500 && aerr.Status != http.StatusUnprocessableEntity {
+		f.Category = analyzer.ErrorInvalidInput
+	}
+	switch b := aerr.Body.(type) {
+	case string:
+		f.Message = b
+	case map[string]any:
+		f.Message, _ = b["error"].(string)
+	}
+	return f
+}
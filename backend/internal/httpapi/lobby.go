@@ -0,0 +1,323 @@
+package httpapi
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/balance"
+	"lol_custom_skill_matching/internal/store"
+)
+
+var teamKeys = [2]string{"teamA", "teamB"}
+
+// lobbyView is what clients see of a lobby. In a blind lobby the result carries
+// no skill numbers until both captains have accepted.
+type lobbyView struct {
+	ID            string               `json:"id"`
+	Name          string               `json:"name"`
+	Players       []string             `json:"players"`
+	Blind         bool                 `json:"blind"`
+	Reveal        string               `json:"reveal,omitempty"`
+	Status        string               `json:"status"` // waiting | proposed | accepted
+	Teams         [2]analyzer.TeamInfo `json:"teams"`
+	StartsAt      *time.Time           `json:"starts_at,omitempty"`
+	RSVPs         []store.RSVP         `json:"rsvps"`
+	Discord       *lobbyDiscord        `json:"discord,omitempty"`
+	Accepted      map[string]bool      `json:"accepted"`
+	RevealedRoles []string             `json:"revealed_roles,omitempty"`
+	Result        any                  `json:"result,omitempty"`
+}
+
+// hiddenSlot is a revealed seat without any score.
+type hiddenSlot struct {
+	Name string `json:"name"`
+	Role string `json:"role,omitempty"`
+}
+
+type hiddenResult struct {
+	Hidden bool                 `json:"hidden"`
+	Teams  [2]analyzer.TeamInfo `json:"teams"`
+	TeamA  []hiddenSlot         `json:"teamA"`
+	TeamB  []hiddenSlot         `json:"teamB"`
+}
+
+// roleSplitOf is the split that assigns roles, if the lobby got one.
+func roleSplitOf(ts *analyzer.TeamSplit) *balance.RoleSplit {
+	if ts.RolesFirst != nil {
+		return ts.RolesFirst
+	}
+	return ts.LaneUnique
+}
+
+// fullyRevealed reports whether the whole composition is visible, which is
+// required before captains can accept.
+func fullyRevealed(l store.Lobby) bool {
+	if l.Split == nil {
+		return false
+	}
+	return l.Reveal != store.RevealRoles || roleSplitOf(l.Split) == nil || l.RevealedRoles >= len(balance.Roles)
+}
+
+func newLobbyView(l store.Lobby) lobbyView {
+	v := lobbyView{
+		ID: l.ID, Name: l.Name, Players: []string{}, Blind: l.Blind, Status: "waiting", Teams: l.Teams, StartsAt: l.StartsAt,
+		RSVPs:    rsvpView(l),
+		Accepted: map[string]bool{teamKeys[0]: l.Accepted[0], teamKeys[1]: l.Accepted[1]},
+	}
+	if d := l.Discord; d != nil {
+		v.Discord = &lobbyDiscord{ChannelID: d.ChannelID, MessageID: d.MessageID, Users: len(d.Users)}
+	}
+	if l.Blind {
+		v.Reveal = l.Reveal
+	}
+	for _, p := range l.Players {
+		v.Players = append(v.Players, p.RiotID())
+	}
+	if l.Split == nil {
+		return v
+	}
+	v.Status = "proposed"
+	if !sealed(l) {
+		if l.Blind {
+			v.Status = "accepted"
+		}
+		v.Result = l.Split
+		return v
+	}
+	v.Result, v.RevealedRoles = hideSplit(l.Split, l.Reveal, l.RevealedRoles)
+	return v
+}
+
+// sealed reports whether l hides its teams' numbers: a blind lobby does until
+// both captains have accepted.
+func sealed(l store.Lobby) bool {
+	return l.Blind && !(l.Accepted[0] && l.Accepted[1])
+}
+
+// sealedLobby is the lobby of res while it is sealed. Every view of a stored
+// result (GET /results, overlays, events) goes through it, so a blind
+// lobby's split can't be read there before the lobby reveals it.
+func (s *Server) sealedLobby(res store.Result) (store.Lobby, bool) {
+	if res.LobbyID == "" {
+		return store.Lobby{}, false
+	}
+	l, ok := s.Store.Lobby(res.LobbyID)
+	return l, ok && sealed(l)
+}
+
+// hideSplit is what a sealed lobby shows of ts: who plays together, or with
+// reveal "roles" the revealedRoles first roles' players, and no score. The
+// roles shown are returned too when ts assigns roles.
+func hideSplit(ts *analyzer.TeamSplit, reveal string, revealedRoles int) (hiddenResult, []string) {
+	res := hiddenResult{Hidden: true, Teams: ts.Teams, TeamA: []hiddenSlot{}, TeamB: []hiddenSlot{}}
+	rs := roleSplitOf(ts)
+	if rs == nil {
+		for _, p := range ts.TeamA {
+			res.TeamA = append(res.TeamA, hiddenSlot{Name: p.Name})
+		}
+		for _, p := range ts.TeamB {
+			res.TeamB = append(res.TeamB, hiddenSlot{Name: p.Name})
+		}
+		return res, nil
+	}
+	shown := len(balance.Roles)
+	if reveal == store.RevealRoles {
+		shown = min(revealedRoles, len(balance.Roles))
+	}
+	roles := balance.Roles[:shown]
+	if shown == len(balance.Roles) {
+		roles = rs.Lineup() // Fill players show with the last role
+	}
+	for _, role := range roles {
+		for _, s := range rs.TeamA {
+			if s.Role == role {
+				res.TeamA = append(res.TeamA, hiddenSlot{Name: s.Name, Role: s.Role})
+			}
+		}
+		for _, s := range rs.TeamB {
+			if s.Role == role {
+				res.TeamB = append(res.TeamB, hiddenSlot{Name: s.Name, Role: s.Role})
+			}
+		}
+	}
+	return res, append([]string{}, roles...)
+}
+
+func (s *Server) lobbyError(w http.ResponseWriter, err error) {
+	if errors.Is(err, store.ErrLobbyNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusConflict)
+}
+
+// handleCreateLobby serves POST /lobbies:
+// {"name": "...", "players": [...], "blind": true, "reveal": "accept"|"roles",
+// "teams": [{"name": "...", "color": "#..."}, ...], "randomTeamNames": true,
+// "startsAt": "2026-05-01T21:00:00+09:00"}.
+func (s *Server) handleCreateLobby(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name    string            `json:"name"`
+		Players []analyzer.Player `json:"players"`
+		Blind   bool              `json:"blind"`
+		Reveal  string            `json:"reveal"`
+
+		Teams           []analyzer.TeamInfo `json:"teams"`
+		RandomTeamNames bool                `json:"randomTeamNames"`
+		StartsAt        *time.Time          `json:"startsAt"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if body.Reveal == "" {
+		body.Reveal = store.RevealAccept
+	}
+	if body.Reveal != store.RevealAccept && body.Reveal != store.RevealRoles {
+		http.Error(w, "invalid reveal (accept|roles)", http.StatusBadRequest)
+		return
+	}
+	teams, err := resolveTeams(body.Teams, body.RandomTeamNames)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	l := s.Store.CreateLobby(body.Name, body.Players, teams, body.Blind, body.Reveal)
+	if body.StartsAt != nil {
+		l, _ = s.Store.UpdateLobby(l.ID, func(l *store.Lobby) error {
+			l.StartsAt = body.StartsAt
+			return nil
+		})
+	}
+	resp := map[string]any{"lobby": newLobbyView(l)}
+	if l.Blind {
+		// shown once: the organizer hands one to each captain
+		resp["captain_tokens"] = map[string]string{teamKeys[0]: l.CaptainTokens[0], teamKeys[1]: l.CaptainTokens[1]}
+	}
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+func (s *Server) handleGetLobby(w http.ResponseWriter, r *http.Request) {
+	l, ok := s.Store.Lobby(r.PathValue("id"))
+	if !ok {
+		http.Error(w, store.ErrLobbyNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, newLobbyView(l))
+}
+
+// handleAnalyzeLobby serves POST /lobbies/{id}/analyze with the /analyze options
+// (players come from the lobby). A new split resets reveal and accept state, so
+// only organizers may ask for one.
+func (s *Server) handleAnalyzeLobby(w http.ResponseWriter, r *http.Request) {
+	if !s.organizer(w, r) {
+		return
+	}
+	l, ok := s.Store.Lobby(r.PathValue("id"))
+	if !ok {
+		http.Error(w, store.ErrLobbyNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	var req analyzeRequest
+	if r.ContentLength != 0 {
+		if !decodeAnalyzeRequest(w, r, &req) {
+			return
+		}
+	}
+	req.Players = append([]analyzer.Player(nil), l.Players...)
+	if len(req.Teams) == 0 {
+		req.Teams = l.Teams[:]
+	}
+	split, _, aerr := s.runAnalysis(r.Context(), req, l.ID)
+	if aerr != nil {
+		aerr.write(w)
+		return
+	}
+	l, err := s.Store.UpdateLobby(l.ID, func(l *store.Lobby) error {
+		l.Split = &split
+		l.Teams = split.Teams
+		l.RevealedRoles = 0
+		l.Accepted = [2]bool{}
+		return nil
+	})
+	if err != nil {
+		s.lobbyError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newLobbyView(l))
+}
+
+// handleRevealLobby serves POST /lobbies/{id}/reveal (organizers): reveal the next
+// role of a blind "roles" lobby.
+func (s *Server) handleRevealLobby(w http.ResponseWriter, r *http.Request) {
+	if !s.organizer(w, r) {
+		return
+	}
+	l, err := s.Store.UpdateLobby(r.PathValue("id"), func(l *store.Lobby) error {
+		if l.Split == nil {
+			return errors.New("lobby has no split yet")
+		}
+		if !l.Blind || l.Reveal != store.RevealRoles {
+			return errors.New("lobby does not reveal by role")
+		}
+		if l.RevealedRoles < len(balance.Roles) {
+			l.RevealedRoles++
+		}
+		return nil
+	})
+	if err != nil {
+		s.lobbyError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newLobbyView(l))
+}
+
+// handleAcceptLobby serves POST /lobbies/{id}/accept {"team": "teamA", "token": "..."}.
+// Scores are revealed once both captains have accepted the full composition.
+func (s *Server) handleAcceptLobby(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Team  string `json:"team"`
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	team := -1
+	for i, k := range teamKeys {
+		if body.Team == k {
+			team = i
+		}
+	}
+	if team < 0 {
+		http.Error(w, "invalid team (teamA|teamB)", http.StatusBadRequest)
+		return
+	}
+	errForbidden := errors.New("invalid captain token")
+	l, err := s.Store.UpdateLobby(r.PathValue("id"), func(l *store.Lobby) error {
+		if !l.Blind {
+			return errors.New("lobby is not blind")
+		}
+		if subtle.ConstantTimeCompare([]byte(body.Token), []byte(l.CaptainTokens[team])) != 1 {
+			return errForbidden
+		}
+		if !fullyRevealed(*l) {
+			return errors.New("split is not fully revealed yet")
+		}
+		l.Accepted[team] = true
+		return nil
+	})
+	if errors.Is(err, errForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		s.lobbyError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newLobbyView(l))
+}
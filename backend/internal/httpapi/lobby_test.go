@@ -0,0 +1,44 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/store"
+)
+
+func TestAnalyzeLobbyNeedsOrganizer(t *testing.T) {
+	st := store.NewMemory()
+	l := st.CreateLobby("friday", []analyzer.Player{{GameName: "Alice", TagLine: "JP1"}, {GameName: "Bob", TagLine: "JP1"}},
+		[2]analyzer.TeamInfo{}, true, store.RevealRoles)
+	st.UpdateLobby(l.ID, func(l *store.Lobby) error {
+		l.Split, l.RevealedRoles, l.Accepted = &analyzer.TeamSplit{}, 5, [2]bool{true, false}
+		return nil
+	})
+	h := (&Server{Store: st, OrganizerToken: "s3cret"}).Handler()
+	post := func(token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/lobbies/"+l.ID+"/analyze", strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, token := range []string{"", "captain"} {
+		if rec := post(token, "{}"); rec.Code != http.StatusUnauthorized {
+			t.Errorf("token %q = %d %s, want 401", token, rec.Code, rec.Body)
+		}
+	}
+	if got, _ := st.Lobby(l.ID); got.RevealedRoles != 5 || got.Accepted != [2]bool{true, false} {
+		t.Errorf("lobby after refused analyses: revealed %d, accepted %v; want them kept", got.RevealedRoles, got.Accepted)
+	}
+	// the organizer gets past the check (to the body's validation)
+	if rec := post("s3cret", "{"); rec.Code != http.StatusBadRequest {
+		t.Errorf("organizer = %d %s, want 400 for the broken body", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,35 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"lol_custom_skill_matching/internal/analyzer"
+)
+
+// handleMatchesExport serves GET /players/{riotId}/matches.jsonl: one stored match
+// summary per line, newest first, for analysis outside the app. Only matches that
+// an earlier /analyze saw are available; nothing is fetched from Riot here.
+func (s *Server) handleMatchesExport(w http.ResponseWriter, r *http.Request) {
+	p, ok := parseRiotID(r.PathValue("riotId"))
+	if !ok {
+		http.Error(w, "invalid riot id (expected name#tag or name-tag)", http.StatusBadRequest)
+		return
+	}
+	matches := s.Store.Matches(p.GameName, p.TagLine)
+	if len(matches) == 0 {
+		http.Error(w, "no analyzed matches for "+p.RiotID(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", ndjsonContentType)
+	flush := flushOf(w)
+	enc := json.NewEncoder(w)
+	for _, m := range analyzer.NameQueues(matches) {
+		if err := enc.Encode(m); err != nil {
+			log.Printf("[req %s] export stream error: %v", RequestID(r.Context()), err)
+			return
+		}
+		flush()
+	}
+}
@@ -0,0 +1,203 @@
+package httpapi
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"lol_custom_skill_matching/internal/riot"
+)
+
+// handleMetrics serves GET /metrics in the Prometheus text format: the shared
+// limiter, Riot's latency and errors per endpoint, the job queues, analysis phase timings, the event bus, the retention pruner and, when tenants are configured, each
+// tenant's quota consumption.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	rc := s.Analyzer.Riot
+	ls := rc.Limiter.Stats()
+	metric(w, "riot_limiter_requests_total", "counter", "Riot API requests sent.")
+	fmt.Fprintf(w, "riot_limiter_requests_total %d\n", ls.Requests)
+	metric(w, "riot_limiter_throttled_total", "counter", "429 responses received.")
+	fmt.Fprintf(w, "riot_limiter_throttled_total %d\n", ls.Throttled)
+	metric(w, "riot_limiter_wait_seconds_total", "counter", "Time spent waiting for the limiter.")
+	fmt.Fprintf(w, "riot_limiter_wait_seconds_total %g\n", float64(ls.WaitedMs)/1000)
+	metric(w, "riot_limiter_rate_factor", "gauge", "Current fraction of the configured request rate.")
+	fmt.Fprintf(w, "riot_limiter_rate_factor %g\n", ls.RateFactor)
+	metric(w, "riot_limiter_limit", "gauge", "Application rate limit in force per window (from X-App-Rate-Limit once Riot answered).")
+	for _, l := range ls.Limits {
+		fmt.Fprintf(w, "riot_limiter_limit{window_seconds=\"%d\"} %d\n", l.Seconds, l.Limit)
+	}
+	if rc.Breaker != nil {
+		bs := rc.Breaker.Stats()
+		open := 0
+		if bs.State != riot.BreakerClosed {
+			open = 1
+		}
+		metric(w, "riot_breaker_open", "gauge", "1 while Riot is treated as down and analyses use stored profiles.")
+		fmt.Fprintf(w, "riot_breaker_open %d\n", open)
+		metric(w, "riot_breaker_failures", "gauge", "Consecutive failed Riot requests.")
+		fmt.Fprintf(w, "riot_breaker_failures %d\n", bs.Failures)
+	}
+	if slo := rc.SLO.Stats(); len(slo) > 0 {
+		metric(w, "riot_endpoint_latency_seconds", "summary", "Riot response time per endpoint; quantiles over the SLO window.")
+		for _, e := range slo {
+			fmt.Fprintf(w, "riot_endpoint_latency_seconds{endpoint=%q,quantile=\"0.5\"} %g\n", e.Endpoint, float64(e.P50Ms)/1000)
+			fmt.Fprintf(w, "riot_endpoint_latency_seconds{endpoint=%q,quantile=\"0.95\"} %g\n", e.Endpoint, float64(e.P95Ms)/1000)
+			fmt.Fprintf(w, "riot_endpoint_latency_seconds_count{endpoint=%q} %d\n", e.Endpoint, e.Requests)
+		}
+		metric(w, "riot_endpoint_error_ratio", "gauge", "Share of Riot requests per endpoint failing with 5xx or network errors over the SLO window.")
+		for _, e := range slo {
+			fmt.Fprintf(w, "riot_endpoint_error_ratio{endpoint=%q} %g\n", e.Endpoint, e.ErrorRate)
+		}
+		metric(w, "riot_endpoint_degraded", "gauge", "1 while a watched endpoint (match-v5) breaks its latency or error SLO.")
+		for _, e := range slo {
+			if e.Watched {
+				fmt.Fprintf(w, "riot_endpoint_degraded{endpoint=%q} %d\n", e.Endpoint, boolGauge(e.Degraded))
+			}
+		}
+	}
+	if s.CallerKeys != nil {
+		metric(w, "riot_caller_keys", "gauge", "Caller-supplied Riot keys (X-Riot-Key) with a limiter.")
+		fmt.Fprintf(w, "riot_caller_keys %d\n", s.CallerKeys.Len())
+	}
+	jobs, wait, _ := s.load.backlog()
+	metric(w, "analyze_jobs_running", "gauge", "Analyze jobs in flight.")
+	fmt.Fprintf(w, "analyze_jobs_running %d\n", jobs)
+	metric(w, "analyze_jobs_estimated_wait_seconds", "gauge", "Estimated delay the running analyze jobs add to a new one.")
+	fmt.Fprintf(w, "analyze_jobs_estimated_wait_seconds %g\n", wait.Seconds())
+	if s.Backfill != nil {
+		b := s.Backfill.Backlog()
+		metric(w, "backfill_queue_depth", "gauge", "Backfills queued or running.")
+		fmt.Fprintf(w, "backfill_queue_depth %d\n", b.Depth)
+		metric(w, "backfill_estimated_wait_seconds", "gauge", "Estimated wait before a new backfill starts.")
+		fmt.Fprintf(w, "backfill_estimated_wait_seconds %g\n", b.EstimatedWait.Seconds())
+	}
+	metric(w, "queue_rejected_total", "counter", "Jobs refused with 503 because their queue was full.")
+	fmt.Fprintf(w, "queue_rejected_total{queue=%q} %d\n", queueAnalyze, s.rejectedJobs.Load())
+	fmt.Fprintf(w, "queue_rejected_total{queue=%q} %d\n", queueBackfill, s.rejectedBackfills.Load())
+	if phases := s.Analyzer.PhaseStats(); len(phases) > 0 {
+		metric(w, "analyze_phase_seconds", "summary", "Time a player's analysis spends in each phase; quantiles over recent players.")
+		for _, p := range phases {
+			for _, q := range []struct {
+				q string
+				d time.Duration
+			}{{"0.5", p.P50}, {"0.9", p.P90}, {"0.99", p.P99}} {
+				fmt.Fprintf(w, "analyze_phase_seconds{phase=%q,quantile=%q} %g\n", p.Phase, q.q, q.d.Seconds())
+			}
+			fmt.Fprintf(w, "analyze_phase_seconds_sum{phase=%q} %g\n", p.Phase, p.Sum.Seconds())
+			fmt.Fprintf(w, "analyze_phase_seconds_count{phase=%q} %d\n", p.Phase, p.Count)
+		}
+	}
+	if s.Events != nil {
+		es := s.Events.Stats()
+		metric(w, "events_published_total", "counter", "Events published on the event bus.")
+		fmt.Fprintf(w, "events_published_total %d\n", es.Published)
+		metric(w, "events_dropped_total", "counter", "Events a subscriber missed by falling behind.")
+		for _, name := range slices.Sorted(maps.Keys(es.Dropped)) {
+			fmt.Fprintf(w, "events_dropped_total{subscriber=%q} %d\n", name, es.Dropped[name])
+		}
+	}
+	if s.Pruner != nil && s.Pruner.Enabled() {
+		ps := s.Pruner.Stats()
+		metric(w, "store_prune_runs_total", "counter", "Retention pruner runs.")
+		fmt.Fprintf(w, "store_prune_runs_total %d\n", ps.Runs)
+		metric(w, "store_pruned_rows_total", "counter", "Stored records removed by the retention policy.")
+		fmt.Fprintf(w, "store_pruned_rows_total{kind=\"matches\"} %d\n", ps.Pruned.Matches)
+		fmt.Fprintf(w, "store_pruned_rows_total{kind=\"results\"} %d\n", ps.Pruned.Results)
+		fmt.Fprintf(w, "store_pruned_rows_total{kind=\"lobbies\"} %d\n", ps.Pruned.Lobbies)
+		if !ps.LastRun.IsZero() {
+			metric(w, "store_prune_last_run_timestamp_seconds", "gauge", "When the retention pruner last ran.")
+			fmt.Fprintf(w, "store_prune_last_run_timestamp_seconds %d\n", ps.LastRun.Unix())
+		}
+	}
+	if s.RSVPSync != nil && s.RSVPSync.Enabled() {
+		rs := s.RSVPSync.Stats()
+		metric(w, "discord_rsvp_syncs_total", "counter", "Reads of the Discord reactions answering lobby RSVPs.")
+		fmt.Fprintf(w, "discord_rsvp_syncs_total %d\n", rs.Runs)
+		metric(w, "discord_rsvp_updates_total", "counter", "Lobby RSVPs set from Discord reactions.")
+		fmt.Fprintf(w, "discord_rsvp_updates_total %d\n", rs.Updated)
+		metric(w, "discord_rsvp_errors_total", "counter", "Lobbies whose Discord reactions could not be read.")
+		fmt.Fprintf(w, "discord_rsvp_errors_total %d\n", rs.Errors)
+	}
+	if s.RankAlerts != nil && s.RankAlerts.Enabled() {
+		ra := s.RankAlerts.Stats()
+		metric(w, "rank_alert_watches", "gauge", "Players subscribed to rank alerts.")
+		fmt.Fprintf(w, "rank_alert_watches %d\n", len(s.Store.RankWatches()))
+		metric(w, "rank_alert_checks_total", "counter", "Subscribed ranks refreshed from Riot.")
+		fmt.Fprintf(w, "rank_alert_checks_total %d\n", ra.Checked)
+		metric(w, "rank_alert_changes_total", "counter", "Rank changes worth an alert.")
+		fmt.Fprintf(w, "rank_alert_changes_total %d\n", ra.Changes)
+		metric(w, "rank_alert_sent_total", "counter", "Rank alerts delivered (webhook posts and Discord DMs).")
+		fmt.Fprintf(w, "rank_alert_sent_total %d\n", ra.Sent)
+		metric(w, "rank_alert_errors_total", "counter", "Rank lookups and alerts that failed.")
+		fmt.Fprintf(w, "rank_alert_errors_total %d\n", ra.Errors)
+	}
+	if s.Memory != nil && s.Memory.Enabled() {
+		ms := s.Memory.Stats()
+		metric(w, "memory_limit_bytes", "gauge", "Memory the server keeps within.")
+		fmt.Fprintf(w, "memory_limit_bytes %d\n", s.Memory.Limit)
+		metric(w, "memory_heap_bytes", "gauge", "Heap in use at the last memory check.")
+		fmt.Fprintf(w, "memory_heap_bytes %d\n", ms.HeapBytes)
+		metric(w, "memory_cache_flushes_total", "counter", "Cache flushes to stay within the memory limit.")
+		fmt.Fprintf(w, "memory_cache_flushes_total %d\n", ms.Flushes)
+	}
+	if s.Static != nil {
+		ss := s.Static.Stats()
+		ready := 0
+		if ss.Ready {
+			ready = 1
+		}
+		metric(w, "static_data_ready", "gauge", "1 once champions, queues and rank images have been prefetched.")
+		fmt.Fprintf(w, "static_data_ready %d\n", ready)
+		metric(w, "static_data_load_seconds", "gauge", "Duration of the last static data load.")
+		fmt.Fprintf(w, "static_data_load_seconds %g\n", float64(ss.LoadMillis)/1000)
+		metric(w, "static_data_errors", "gauge", "Parts the last static data load failed to fetch.")
+		fmt.Fprintf(w, "static_data_errors %d\n", len(ss.Errors))
+	}
+	if rc.Scheduler == nil {
+		return
+	}
+	ts := rc.Scheduler.Stats()
+	metric(w, "riot_tenant_requests_total", "counter", "Riot API requests released to each tenant.")
+	for _, t := range ts {
+		fmt.Fprintf(w, "riot_tenant_requests_total{tenant=%q} %d\n", labelValue(t.Tenant), t.Requests)
+	}
+	metric(w, "riot_tenant_wait_seconds_total", "counter", "Time each tenant's requests queued for quota.")
+	for _, t := range ts {
+		fmt.Fprintf(w, "riot_tenant_wait_seconds_total{tenant=%q} %g\n", labelValue(t.Tenant), float64(t.WaitedMs)/1000)
+	}
+	metric(w, "riot_tenant_queue_depth", "gauge", "Requests each tenant has waiting now.")
+	for _, t := range ts {
+		fmt.Fprintf(w, "riot_tenant_queue_depth{tenant=%q} %d\n", labelValue(t.Tenant), t.Queued)
+	}
+	metric(w, "riot_tenant_weight", "gauge", "Configured share of each tenant.")
+	for _, t := range ts {
+		fmt.Fprintf(w, "riot_tenant_weight{tenant=%q} %g\n", labelValue(t.Tenant), t.Weight)
+	}
+}
+
+func boolGauge(v bool) int {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+func metric(w io.Writer, name, typ, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// labelValue keeps a tenant name within what %q and the exposition format
+// agree on: printable ASCII, quotes and backslashes escaped.
+func labelValue(v string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e {
+			return '_'
+		}
+		return r
+	}, v)
+}
@@ -0,0 +1,148 @@
+package httpapi
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"lol_custom_skill_matching/internal/riot"
+)
+
+// withCORS answers preflights and lets browsers read the answers: from any
+// origin, or with AllowedOrigins only from those.
+func (s *Server) withCORS(h http.Handler) http.Handler {
+	anyOrigin := len(s.AllowedOrigins) == 0 || slices.Contains(s.AllowedOrigins, "*")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if anyOrigin {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Add("Vary", "Origin")
+			if o := r.Header.Get("Origin"); o != "" && s.originAllowed(r) {
+				w.Header().Set("Access-Control-Allow-Origin", o)
+			}
+		}
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Tenant, "+riot.KeyHeader)
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// originAllowed reports whether r may come from its Origin: none (not a
+// browser), the server's own host, or one of AllowedOrigins.
+func (s *Server) originAllowed(r *http.Request) bool {
+	o := r.Header.Get("Origin")
+	if o == "" {
+		return true
+	}
+	if u, err := url.Parse(o); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return slices.ContainsFunc(s.AllowedOrigins, func(a string) bool { return a == "*" || strings.EqualFold(a, o) })
+}
+
+// ---- Simple request logging middleware ----
+type ctxKey string
+
+const ctxReqID ctxKey = "reqID"
+
+// RequestID returns the id assigned by the logging middleware ("" outside a request).
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(ctxReqID).(string)
+	return id
+}
+
+type loggingResponseWriter struct {
+	http.ResponseWriter
+	status int
+	nbytes int
+}
+
+func (lw *loggingResponseWriter) WriteHeader(code int) {
+	lw.status = code
+	lw.ResponseWriter.WriteHeader(code)
+}
+func (lw *loggingResponseWriter) Write(b []byte) (int, error) {
+	if lw.status == 0 {
+		lw.status = http.StatusOK
+	}
+	n, err := lw.ResponseWriter.Write(b)
+	lw.nbytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the connection.
+func (lw *loggingResponseWriter) Unwrap() http.ResponseWriter { return lw.ResponseWriter }
+
+// Hijack hands the connection over to a WebSocket upgrade through the logging
+// wrapper.
+func (lw *loggingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	lw.status = http.StatusSwitchingProtocols
+	return http.NewResponseController(lw.ResponseWriter).Hijack()
+}
+
+// Flush lets streamed responses reach the client through the logging wrapper.
+func (lw *loggingResponseWriter) Flush() {
+	if f, ok := lw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func reqID() string { return fmt.Sprintf("%x", time.Now().UnixNano()) }
+
+func clientIP(r *http.Request) string {
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		return strings.Split(xff, ",")[0]
+	}
+	if xr := r.Header.Get("X-Real-IP"); xr != "" {
+		return xr
+	}
+	return r.RemoteAddr
+}
+
+// callerKey runs requests carrying riot.KeyHeader on the caller's key, paced
+// by its own limiter, when the server accepts such keys.
+func (s *Server) callerKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimSpace(r.Header.Get(riot.KeyHeader))
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if s.CallerKeys == nil {
+			http.Error(w, riot.KeyHeader+" is not accepted by this server (RIOT_KEY_HEADER)", http.StatusForbidden)
+			return
+		}
+		if !strings.HasPrefix(key, "RGAPI-") {
+			http.Error(w, riot.KeyHeader+" must be a Riot API key (RGAPI-...)", http.StatusBadRequest)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(riot.WithKey(r.Context(), key, s.CallerKeys.For(key))))
+	})
+}
+
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := reqID()
+		start := time.Now()
+		lw := &loggingResponseWriter{ResponseWriter: w}
+		ctx := context.WithValue(r.Context(), ctxReqID, id)
+		if t := strings.TrimSpace(r.Header.Get("X-Tenant")); t != "" {
+			ctx = riot.WithTenant(ctx, t)
+		}
+		log.Printf("[req %s] %s %s from %s", id, r.Method, r.URL.Path, clientIP(r))
+		next.ServeHTTP(lw, r.WithContext(ctx))
+		dur := time.Since(start)
+		log.Printf("[req %s] done status=%d bytes=%d dur=%s", id, lw.status, lw.nbytes, dur)
+	})
+}
@@ -0,0 +1,84 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/store"
+)
+
+// handleNote serves GET/PUT/DELETE /players/{riotId}/notes (organizers),
+// the organizer's note and tags for a player. The tags steer tagRules
+// balancing, so reading and writing them takes the organizer token.
+func (s *Server) handleNote(w http.ResponseWriter, r *http.Request) {
+	if !s.organizer(w, r) {
+		return
+	}
+	p, ok := parseRiotID(r.PathValue("riotId"))
+	if !ok {
+		http.Error(w, "invalid riot id (expected name#tag or name-tag)", http.StatusBadRequest)
+		return
+	}
+	var n store.PlayerNote
+	switch r.Method {
+	case http.MethodGet:
+		n, _ = s.Store.Note(p.GameName, p.TagLine)
+	case http.MethodPut, http.MethodPost:
+		var body struct {
+			Note string   `json:"note"`
+			Tags []string `json:"tags"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+		if err := store.CheckNote(strings.TrimSpace(body.Note), store.CleanTags(body.Tags)); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if out := s.optedOut([]analyzer.Player{p}); len(out) > 0 {
+			errOptedOut(out).write(w)
+			return
+		}
+		n, _ = s.Store.SetNote(p.GameName, p.TagLine, body.Note, body.Tags)
+	case http.MethodDelete:
+		s.Store.SetNote(p.GameName, p.TagLine, "", nil)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if n.Player == "" {
+		n.Player = p.RiotID()
+	}
+	if n.Tags == nil {
+		n.Tags = []string{}
+	}
+	writeJSON(w, http.StatusOK, n)
+}
+
+// handleNotes serves GET /players/notes (organizers): every player with a
+// note or tags, ?tag= keeps those carrying the tag.
+func (s *Server) handleNotes(w http.ResponseWriter, r *http.Request) {
+	if !s.organizer(w, r) {
+		return
+	}
+	writeJSON(w, http.StatusOK, s.Store.Notes(strings.TrimSpace(r.URL.Query().Get("tag"))))
+}
+
+// tagGroups resolves tag rules to the stored players carrying each tag.
+func (s *Server) tagGroups(rules []analyzer.TagRule) []analyzer.TagGroup {
+	var out []analyzer.TagGroup
+	for _, r := range rules {
+		r.Tag = strings.TrimSpace(r.Tag)
+		g := analyzer.TagGroup{TagRule: r, Players: []string{}}
+		if r.Tag != "" {
+			for _, n := range s.Store.Notes(r.Tag) {
+				g.Players = append(g.Players, n.Player)
+			}
+		}
+		out = append(out, g)
+	}
+	return out
+}
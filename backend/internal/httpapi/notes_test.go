@@ -0,0 +1,52 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"lol_custom_skill_matching/internal/store"
+)
+
+func TestNotesNeedOrganizer(t *testing.T) {
+	st := store.NewMemory()
+	st.SetNote("Alice", "JP1", "new player", []string{"duo with Bob"})
+	h := (&Server{Store: st, OrganizerToken: "s3cret"}).Handler()
+
+	tests := []struct {
+		method, path, body string
+	}{
+		{http.MethodGet, "/players/notes", ""},
+		{http.MethodGet, "/players/notes?tag=duo+with+bob", ""},
+		{http.MethodGet, "/players/Alice-JP1/notes", ""},
+		{http.MethodPut, "/players/Alice-JP1/notes", `{"note": "support main", "tags": ["spread me"]}`},
+		{http.MethodPost, "/players/Alice-JP1/notes", `{"note": "support main", "tags": ["spread me"]}`},
+		{http.MethodDelete, "/players/Alice-JP1/notes", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			do := func(token string) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+				if token != "" {
+					req.Header.Set("Authorization", "Bearer "+token)
+				}
+				rec := httptest.NewRecorder()
+				h.ServeHTTP(rec, req)
+				return rec
+			}
+			for _, token := range []string{"", "wrong"} {
+				if rec := do(token); rec.Code != http.StatusUnauthorized {
+					t.Errorf("token %q = %d %s, want 401", token, rec.Code, rec.Body)
+				}
+			}
+			if rec := do("s3cret"); rec.Code != http.StatusOK {
+				t.Errorf("organizer token = %d %s, want 200", rec.Code, rec.Body)
+			}
+		})
+	}
+	// the refused writes changed nothing; the organizer's DELETE ran last
+	if n, ok := st.Note("Alice", "JP1"); ok && (n.Note != "" || len(n.Tags) > 0) {
+		t.Errorf("note after DELETE = %+v", n)
+	}
+}
@@ -0,0 +1,141 @@
+package httpapi
+
+import (
+	"net/http"
+	"sync"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/openapi"
+	"lol_custom_skill_matching/internal/riot"
+)
+
+// errorBody is the JSON body of the failures that say more than a message,
+// e.g. {"error": "players must verify their riot id", "unverified": [...]}.
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+// apiDoc is the OpenAPI document of the analyze API, built once from the
+// types the handlers decode and encode.
+var apiDoc = sync.OnceValue(func() *openapi.Document {
+	g := openapi.NewGenerator()
+	openapi.Define[riot.QueueIDs](g, &openapi.Schema{
+		Type: "array", Nullable: true,
+		Items:       &openapi.Schema{OneOf: []*openapi.Schema{openapi.Integer(), openapi.String()}},
+		Description: "queue ids or keys (GET /queues), e.g. [420, \"draft\"]",
+	})
+
+	jsonOf := func(s *openapi.Schema) map[string]*openapi.MediaType {
+		return map[string]*openapi.MediaType{"application/json": {Schema: s}}
+	}
+	text := map[string]*openapi.MediaType{"text/plain": {Schema: openapi.String()}}
+	failure := func(desc string) *openapi.Response {
+		return &openapi.Response{Description: desc, Content: map[string]*openapi.MediaType{
+			"text/plain":       {Schema: openapi.String()},
+			"application/json": {Schema: openapi.SchemaFor[errorBody](g)},
+		}}
+	}
+	request := &openapi.RequestBody{
+		Description: "Only players is needed; the other fields override the preset (see meta.preset of the response).",
+		Required:    true, Content: jsonOf(openapi.SchemaFor[analyzeRequest](g)),
+	}
+	jobID := openapi.Parameter{Name: "id", In: "path", Required: true, Schema: openapi.String()}
+	fields := openapi.Parameter{
+		Name: "fields", In: "query", Schema: openapi.String(),
+		Description: "comma-separated paths of the members to keep, e.g. teamA.name,teamA.rank,sumA",
+	}
+	busy := failure("too many jobs queued; Retry-After says when to try again")
+	job := &openapi.Response{Description: "the job's progress", Content: jsonOf(openapi.SchemaFor[jobStatus](g))}
+	notFound := &openapi.Response{Description: "no such job", Content: text}
+
+	analyze := openapi.SchemaFor[analyzeResponse](g)
+	doc := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title: "lol_custom_skill_matching", Version: analyzer.AlgorithmVersion,
+			Description: "Skill profiles and balanced teams for League of Legends custom games.",
+		},
+		Paths: map[string]*openapi.PathItem{
+			"/analyze": {Post: &openapi.Operation{
+				Summary:     "Analyze players and split them into two teams",
+				Description: "The response is streamed; ?format=ndjson (or Accept: application/x-ndjson) sends one member per line instead.",
+				Tags:        []string{"analyze"},
+				Parameters: []openapi.Parameter{fields, {
+					Name: "format", In: "query", Schema: &openapi.Schema{Type: "string", Enum: []any{"ndjson"}},
+				}},
+				RequestBody: request,
+				Responses: map[string]*openapi.Response{
+					"200": {Description: "the split, then meta", Content: map[string]*openapi.MediaType{
+						"application/json": {Schema: analyze},
+						ndjsonContentType:  {Schema: &openapi.Schema{Type: "object", Description: "one {key: value} object per member of the JSON body"}},
+					}},
+					"400": failure("invalid request"),
+					"401": failure("the X-Riot-Key header's key was rejected"),
+					"403": failure("players must verify their Riot ID"),
+					"422": failure("the split failed validation"),
+					"503": failure("Riot is unavailable and too few profiles are stored, or the server's key was rejected"),
+				},
+			}},
+			"/analyze/jobs": {Post: &openapi.Operation{
+				Summary:     "Start an analysis in the background",
+				Description: "The body of POST /analyze; poll GET /analyze/jobs/{id} (or subscribe on GET /ws) until state is done, then read GET /results/{result_id}.",
+				Tags:        []string{"analyze"}, RequestBody: request,
+				Responses: map[string]*openapi.Response{"202": job, "400": failure("invalid request"), "503": busy},
+			}},
+			"/analyze/jobs/{id}": {Get: &openapi.Operation{
+				Summary: "Poll an analyze job", Tags: []string{"analyze"}, Parameters: []openapi.Parameter{jobID},
+				Responses: map[string]*openapi.Response{"200": job, "404": notFound},
+			}},
+			"/analyze/jobs/{id}/retry": {Post: &openapi.Operation{
+				Summary: "Retry the retryable failures of a failed job", Tags: []string{"analyze"}, Parameters: []openapi.Parameter{jobID},
+				Responses: map[string]*openapi.Response{
+					"202": job, "404": notFound, "409": {Description: "the job is not failed or has nothing to retry", Content: text}, "503": busy,
+				},
+			}},
+			"/results/{id}": {Get: &openapi.Operation{
+				Summary: "Get a stored result", Tags: []string{"results"},
+				Parameters: []openapi.Parameter{{Name: "id", In: "path", Required: true, Schema: openapi.String()}, fields},
+				Responses: map[string]*openapi.Response{
+					"200": {Description: "the result", Content: jsonOf(openapi.SchemaFor[resultResponse](g))},
+					"404": {Description: "no such result", Content: text},
+				},
+			}},
+			"/queues": {Get: &openapi.Operation{
+				Summary: "List the known queues", Tags: []string{"static data"},
+				Responses: map[string]*openapi.Response{
+					"200": {Description: "the queue registry", Content: jsonOf(&openapi.Schema{Type: "array", Items: openapi.SchemaFor[queueMeta](g)})},
+				},
+			}},
+		},
+	}
+	doc.Components.Schemas = g.Schemas()
+	return doc
+})
+
+// handleOpenAPI serves GET /openapi.json.
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, apiDoc())
+}
+
+// handleDocs serves GET /docs, Swagger UI over /openapi.json. The UI itself
+// loads from its CDN, so the binary doesn't carry it.
+func (s *Server) handleDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(docsPage))
+}
+
+// swaggerUI is the Swagger UI release docsPage loads.
+const swaggerUI = "https://unpkg.com/swagger-ui-dist@5"
+
+const docsPage = `<!doctype html>
+<html lang="en">
+<meta charset="utf-8">
+<title>lol_custom_skill_matching API</title>
+<link rel="stylesheet" href="` + swaggerUI + `/swagger-ui.css">
+<div id="ui"></div>
+<script src="` + swaggerUI + `/swagger-ui-bundle.js"></script>
+<script>
+  SwaggerUIBundle({ url: "openapi.json", dom_id: "#ui", deepLinking: true });
+</script>
+</html>
+`
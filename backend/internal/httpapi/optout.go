@@ -0,0 +1,129 @@
+package httpapi
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/store"
+)
+
+// handleDeletePlayer serves DELETE /players/{riotId} (organizers, with
+// ORGANIZER_TOKEN set): delete everything stored about the player and keep
+// them out from now on. Results and lobbies they played in stay, with the
+// player anonymized, so the other players' history still adds up.
+func (s *Server) handleDeletePlayer(w http.ResponseWriter, r *http.Request) {
+	if !s.admin(w, r) {
+		return
+	}
+	p, ok := parseRiotID(r.PathValue("riotId"))
+	if !ok {
+		http.Error(w, "invalid riot id (expected name#tag or name-tag)", http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.deletePlayer(r.Context(), p))
+}
+
+// deletePlayer deletes p for DELETE /players/{riotId} and the bot's /forget.
+func (s *Server) deletePlayer(ctx context.Context, p analyzer.Player) store.Deletion {
+	rid := RequestID(ctx)
+	d := s.Store.DeletePlayer(p.GameName, p.TagLine)
+	puuid := d.PUUID
+	if puuid == "" {
+		// the store never learned it; the account lookup is cached with the
+		// rest (or asks Riot once)
+		account, found, err := s.Analyzer.Riot.AccountByRiotID(ctx, p.GameName, p.TagLine)
+		if err != nil {
+			log.Printf("[req %s] resolving %s to purge cached riot responses: %v", rid, d.Player, err)
+		}
+		if found {
+			puuid = account.PUUID
+		}
+	}
+	if puuid != "" {
+		s.Analyzer.ForgetLeagues(puuid)
+		if _, err := s.Analyzer.Riot.PurgeCache(puuid); err != nil {
+			log.Printf("[req %s] purging cached riot responses of %s: %v", rid, d.Player, err)
+		}
+	}
+	if s.Results != nil {
+		for _, id := range d.Results {
+			if err := s.rewriteResultFile(id); err != nil {
+				log.Printf("[req %s] anonymizing result file %s: %v", rid, id, err)
+			}
+		}
+	}
+	if s.Snapshots != nil {
+		if err := s.Snapshots.Forget(d.Player, d.Alias); err != nil {
+			log.Printf("[req %s] removing %s from snapshots: %v", rid, d.Player, err)
+		}
+	}
+	log.Printf("[req %s] deleted player %s (matches=%d results=%d lobbies=%d)", rid, d.Player, d.Matches, len(d.Results), len(d.Lobbies))
+	return d
+}
+
+// rewriteResultFile writes result id's file again from the store, keeping its
+// time so retention still counts from when the result was made. Pruned files
+// stay gone.
+func (s *Server) rewriteResultFile(id string) error {
+	info, ok := s.Results.Stat(id)
+	if !ok {
+		return nil
+	}
+	res, ok := s.Store.Result(id)
+	if !ok {
+		return nil
+	}
+	path, err := s.Results.Write(id, resultWriter(splitFields(res.Split, nil)))
+	if path == "" {
+		return err
+	}
+	if cerr := os.Chtimes(path, info.ModTime, info.ModTime); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// handleOptOuts serves GET /players/opt-outs (organizers).
+func (s *Server) handleOptOuts(w http.ResponseWriter, r *http.Request) {
+	if !s.organizer(w, r) {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"opt_outs": s.Store.OptOuts()})
+}
+
+// handleOptIn serves DELETE /players/{riotId}/opt-out (organizers): a deleted
+// player who wants back in is analyzed and stored again.
+func (s *Server) handleOptIn(w http.ResponseWriter, r *http.Request) {
+	if !s.organizer(w, r) {
+		return
+	}
+	p, ok := parseRiotID(r.PathValue("riotId"))
+	if !ok {
+		http.Error(w, "invalid riot id (expected name#tag or name-tag)", http.StatusBadRequest)
+		return
+	}
+	if !s.Store.OptIn(p.GameName, p.TagLine) {
+		http.Error(w, "player has not opted out", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// optedOut returns the Riot IDs of the players that opted out.
+func (s *Server) optedOut(players []analyzer.Player) []string {
+	var out []string
+	for _, p := range players {
+		if s.Store.OptedOut(p.GameName, p.TagLine) {
+			out = append(out, p.RiotID())
+		}
+	}
+	return out
+}
+
+// errOptedOut is the response to a request naming opted-out players.
+func errOptedOut(players []string) *apiError {
+	return &apiError{http.StatusForbidden, map[string]any{"error": "players opted out of data collection", "opted_out": players}}
+}
@@ -0,0 +1,99 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/store"
+)
+
+// handleResultOutcome serves POST /results/{id}/outcome (organizers): record
+// who won, either {"winner": "A"|"B"} or {"matchId": "JP1_..."} to read it
+// from the custom game on Riot's side after checking the teams match.
+func (s *Server) handleResultOutcome(w http.ResponseWriter, r *http.Request) {
+	if !s.organizer(w, r) {
+		return
+	}
+	var body struct {
+		Winner  string `json:"winner"`
+		MatchID string `json:"matchId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	res, ok := s.Store.Result(r.PathValue("id"))
+	if !ok {
+		http.Error(w, store.ErrResultNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	var o store.Outcome
+	switch {
+	case body.MatchID != "":
+		m, err := s.Analyzer.Riot.Match(r.Context(), body.MatchID)
+		if err != nil {
+			http.Error(w, "match lookup failed", http.StatusBadGateway)
+			return
+		}
+		if m == nil {
+			http.Error(w, "match not found", http.StatusNotFound)
+			return
+		}
+		aWon, err := analyzer.MatchOutcome(res.Split, m)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		awards := analyzer.Awards(res.Split, m)
+		o = store.Outcome{Winner: store.WinnerB, MatchID: body.MatchID, Verified: true, Awards: &awards}
+		if aWon {
+			o.Winner = store.WinnerA
+		}
+	case strings.EqualFold(body.Winner, store.WinnerA), strings.EqualFold(body.Winner, store.WinnerB):
+		o = store.Outcome{Winner: strings.ToUpper(body.Winner)}
+	default:
+		http.Error(w, `expected "winner" ("A" or "B") or "matchId"`, http.StatusBadRequest)
+		return
+	}
+	res, err := s.Store.SetOutcome(res.ID, o)
+	if errors.Is(err, store.ErrResultNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// handleCalibration serves GET /calibration?unverified=true: how the predicted
+// win chances of stored results compare with their outcomes, and the scale
+// and formula weights that would have matched better.
+func (s *Server) handleCalibration(w http.ResponseWriter, r *http.Request) {
+	games := store.CalibrationGames(s.Store.Results(), r.URL.Query().Get("unverified") == "true")
+	writeJSON(w, http.StatusOK, analyzer.Calibrate(games, s.Analyzer.WinScale))
+}
+
+// handleHeadToHead serves GET /players/{a}/vs/{b}: how often two players were
+// on the same or opposite teams in stored results, and how those games went.
+func (s *Server) handleHeadToHead(w http.ResponseWriter, r *http.Request) {
+	a, okA := parseRiotID(r.PathValue("a"))
+	b, okB := parseRiotID(r.PathValue("b"))
+	if !okA || !okB {
+		http.Error(w, "invalid riot id (expected name#tag or name-tag)", http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, store.HeadToHeadOf(s.Store.Results(), a.RiotID(), b.RiotID()))
+}
+
+// handleLeaderboard serves GET /leaderboard?season=2026 (default: this year):
+// standings over the season's results with an outcome, with MVP/ace titles
+// from verified matches.
+func (s *Server) handleLeaderboard(w http.ResponseWriter, r *http.Request) {
+	season := r.URL.Query().Get("season")
+	if season == "" {
+		season = store.SeasonOf(time.Now())
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"season": season, "standings": store.Leaderboard(s.Store.Results(), season, s.Store.Participations())})
+}
@@ -0,0 +1,168 @@
+package httpapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/assets"
+	"lol_custom_skill_matching/internal/balance"
+	"lol_custom_skill_matching/internal/riot"
+	"lol_custom_skill_matching/internal/store"
+)
+
+// overlay is a stored result laid out for stream overlays and casting tools:
+// only what is shown on screen, every image an absolute URL.
+type overlay struct {
+	ResultID   string         `json:"result_id"`
+	CreatedAt  time.Time      `json:"created_at"`
+	Lobby      string         `json:"lobby,omitempty"` // lobby name
+	Mode       string         `json:"mode"`
+	Teams      [2]overlayTeam `json:"teams"`            // team A, team B
+	Winner     string         `json:"winner,omitempty"` // A or B
+	DataDragon string         `json:"data_dragon_version"`
+}
+
+type overlayTeam struct {
+	Name    string          `json:"name"`
+	Color   string          `json:"color"`
+	Side    string          `json:"side"`
+	Score   int             `json:"score"`
+	WinPct  *float64        `json:"win_pct,omitempty"`
+	Players []overlayPlayer `json:"players"`
+}
+
+type overlayPlayer struct {
+	Name     string `json:"name"` // name#tag
+	GameName string `json:"game_name"`
+	TagLine  string `json:"tag_line"`
+	// Role is set when the result assigned roles; players are then in role order.
+	Role      string            `json:"role,omitempty"`
+	Score     int               `json:"score"`
+	Rank      assets.Rank       `json:"rank"`
+	IconURL   string            `json:"icon_url,omitempty"`
+	Champions []overlayChampion `json:"champions"`
+}
+
+type overlayChampion struct {
+	Name    string `json:"name"`
+	IconURL string `json:"icon_url,omitempty"`
+}
+
+// handleOverlay serves GET /results/{id}/overlay.json. It makes no Riot
+// calls: profile icons come from player cards built recently (see
+// handlePlayerCard) and are left out otherwise. A sealed lobby's result is
+// withheld (409) until both captains accept.
+func (s *Server) handleOverlay(w http.ResponseWriter, r *http.Request) {
+	res, ok := s.Store.Result(r.PathValue("id"))
+	if !ok {
+		http.Error(w, "result not found", http.StatusNotFound)
+		return
+	}
+	if _, ok := s.sealedLobby(res); ok {
+		w.Header().Set("Cache-Control", "no-cache")
+		http.Error(w, "the lobby's teams are hidden until both captains accept", http.StatusConflict)
+		return
+	}
+	ts := res.Split
+	out := overlay{ResultID: res.ID, CreatedAt: res.CreatedAt, Mode: ts.Mode, DataDragon: riot.DataDragonVersion}
+	if l, ok := s.Store.Lobby(res.LobbyID); ok && res.LobbyID != "" {
+		out.Lobby = l.Name
+	}
+	if res.Outcome != nil {
+		out.Winner = res.Outcome.Winner
+	}
+	profiles := map[string]analyzer.Profile{}
+	for _, p := range append(append([]analyzer.Profile{}, ts.TeamA...), ts.TeamB...) {
+		profiles[strings.ToLower(p.Name)] = p
+	}
+	champs := s.Analyzer.Champions(r.Context())
+	player := func(name, role string) overlayPlayer {
+		p := profiles[strings.ToLower(name)]
+		op := overlayPlayer{Name: name, Role: role, Score: p.SkillScore, Rank: assets.Unranked(), Champions: []overlayChampion{}}
+		if id, ok := parseRiotID(name); ok {
+			op.GameName, op.TagLine = id.GameName, id.TagLine
+			op.IconURL = s.cachedIconURL(id)
+		}
+		if p.Rank != nil {
+			op.Rank = *p.Rank
+		}
+		for _, c := range p.MainChampions {
+			oc := overlayChampion{Name: c}
+			if id, ok := champs.ID(c); ok {
+				oc.IconURL = champs.IconURL(id)
+			}
+			op.Champions = append(op.Champions, oc)
+		}
+		return op
+	}
+
+	prediction := analyzer.PredictionTeams
+	sums := [2]int{ts.SumA, ts.SumB}
+	var teams [2][]overlayPlayer
+	if rs := roleSplitOf(&ts); rs != nil {
+		prediction = analyzer.PredictionLaneUnique
+		if rs == ts.RolesFirst {
+			prediction = analyzer.PredictionRolesFirst
+		}
+		sums = [2]int{rs.SumA, rs.SumB}
+		for k, slots := range [][]balance.Slot{rs.TeamA, rs.TeamB} {
+			for _, role := range rs.Lineup() {
+				for _, sl := range slots {
+					if sl.Role == role {
+						teams[k] = append(teams[k], player(sl.Name, role))
+					}
+				}
+			}
+		}
+	} else {
+		for k, team := range [][]analyzer.Profile{ts.TeamA, ts.TeamB} {
+			for _, p := range team {
+				teams[k] = append(teams[k], player(p.Name, ""))
+			}
+		}
+	}
+	for k := range out.Teams {
+		t := ts.Teams[k]
+		out.Teams[k] = overlayTeam{Name: t.Name, Color: t.Color, Side: t.Side, Score: sums[k], Players: teams[k]}
+		if out.Teams[k].Players == nil {
+			out.Teams[k].Players = []overlayPlayer{}
+		}
+	}
+	for _, wp := range ts.WinPredictions {
+		if wp.Split != prediction {
+			continue
+		}
+		for k := range out.Teams {
+			pct := wp.RedWinPct
+			if out.Teams[k].Side == analyzer.SideBlue {
+				pct = wp.BlueWinPct
+			}
+			out.Teams[k].WinPct = &pct
+		}
+	}
+	// overlay pages poll this; an unchanged result costs a 304
+	b, _ := json.Marshal(out)
+	sum := sha256.Sum256(b)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(append(b, '\n'))
+}
+
+// cachedIconURL is the profile icon of the player's card when one is cached.
+func (s *Server) cachedIconURL(p analyzer.Player) string {
+	if card, ok := s.cardCache().Get(store.RiotIDKey(p.GameName, p.TagLine)); ok {
+		return card.IconURL
+	}
+	return ""
+}
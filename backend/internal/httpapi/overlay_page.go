@@ -0,0 +1,117 @@
+package httpapi
+
+import (
+	"net/http"
+)
+
+// handleOverlayPage serves GET /results/{id}/overlay, a page for an OBS (or
+// any streaming tool's) browser source: the two teams of overlay.json with
+// icons on a transparent background, polled every ?refresh= seconds (default
+// 5) so an edited result, e.g. its winner, shows without reloading. For a
+// sealed lobby the page stays empty while overlay.json is withheld (409) and
+// shows the teams once both captains accept.
+func (s *Server) handleOverlayPage(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.Store.Result(r.PathValue("id")); !ok {
+		http.Error(w, "result not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	_, _ = w.Write([]byte(overlayPage))
+}
+
+// overlayPage renders overlay.json next to it. Names are set as text, never
+// as markup.
+const overlayPage = `<!doctype html>
+<html lang="ja">
+<meta charset="utf-8">
+<title>overlay</title>
+<style>
+  html, body { margin: 0; background: transparent; color: #fff; font: 600 18px/1.3 system-ui, sans-serif; text-shadow: 0 1px 3px #000; }
+  #lobby { text-align: center; font-size: 20px; padding: 6px; }
+  #teams { display: flex; gap: 16px; padding: 8px; }
+  .team { flex: 1; background: rgba(0, 0, 0, .55); border-top: 4px solid var(--color, #888); border-radius: 6px; padding: 8px; }
+  .team h2 { margin: 0 0 6px; font-size: 22px; display: flex; justify-content: space-between; }
+  .team.won h2::after { content: "WIN"; color: #ffd54f; }
+  .player { display: flex; align-items: center; gap: 8px; padding: 3px 0; }
+  .player > img { width: 40px; height: 40px; border-radius: 50%; background: #222; }
+  .role { width: 64px; font-size: 13px; opacity: .8; }
+  .name { flex: 1; overflow: hidden; white-space: nowrap; text-overflow: ellipsis; }
+  .rank { font-size: 13px; opacity: .85; }
+  .champs img { width: 28px; height: 28px; border-radius: 4px; margin-left: 2px; }
+  .meta { font-size: 14px; opacity: .85; }
+</style>
+<div id="lobby"></div>
+<div id="teams"></div>
+<script>
+const refresh = Math.max(2, Number(new URLSearchParams(location.search).get("refresh")) || 5);
+let last = "";
+
+function el(tag, cls, text) {
+  const e = document.createElement(tag);
+  if (cls) e.className = cls;
+  if (text !== undefined) e.textContent = text;
+  return e;
+}
+
+function img(src, alt) {
+  const i = el("img");
+  if (src) i.src = src;
+  i.alt = alt || "";
+  return i;
+}
+
+function render(o) {
+  document.getElementById("lobby").textContent = o.lobby || "";
+  const teams = document.getElementById("teams");
+  teams.replaceChildren();
+  o.teams.forEach((t, k) => {
+    const box = el("div", "team");
+    if (t.color) box.style.setProperty("--color", t.color);
+    if (o.winner === "AB"[k]) box.classList.add("won");
+    const h = el("h2");
+    h.append(el("span", "", t.name));
+    box.append(h);
+    const meta = [t.side, "score " + t.score];
+    if (t.win_pct != null) meta.push(t.win_pct.toFixed(1) + "%");
+    box.append(el("div", "meta", meta.filter(Boolean).join(" / ")));
+    for (const p of t.players) {
+      const row = el("div", "player");
+      row.append(img(p.icon_url, p.name));
+      if (p.role) row.append(el("span", "role", p.role));
+      row.append(el("span", "name", p.game_name || p.name));
+      row.append(el("span", "rank", p.rank && p.rank.label || ""));
+      const champs = el("span", "champs");
+      for (const c of p.champions.slice(0, 3)) {
+        if (c.icon_url) champs.append(img(c.icon_url, c.name));
+      }
+      row.append(champs);
+      box.append(row);
+    }
+    teams.append(box);
+  });
+}
+
+async function poll() {
+  try {
+    const resp = await fetch("overlay.json", { cache: "no-cache" });
+    if (resp.ok) {
+      const text = await resp.text();
+      if (text !== last) {
+        last = text;
+        render(JSON.parse(text));
+      }
+    } else if (resp.status === 409 && last !== "") {
+      // the lobby was analyzed again and hides its teams until accepted
+      last = "";
+      document.getElementById("teams").replaceChildren();
+    }
+  } catch (e) {
+    // keep showing the last teams while the server is unreachable
+  }
+  setTimeout(poll, refresh * 1000);
+}
+poll();
+</script>
+</html>
+`
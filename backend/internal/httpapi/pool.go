@@ -0,0 +1,70 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"lol_custom_skill_matching/internal/analyzer"
+)
+
+// parseRiotID accepts "name#tag" (URL-encoded as %23 in paths) or "name-tag".
+func parseRiotID(s string) (analyzer.Player, bool) {
+	s = strings.TrimSpace(s)
+	i := strings.LastIndex(s, "#")
+	if i < 0 {
+		i = strings.LastIndex(s, "-")
+	}
+	if i <= 0 || i == len(s)-1 {
+		return analyzer.Player{}, false
+	}
+	return analyzer.Player{GameName: s[:i], TagLine: s[i+1:]}, true
+}
+
+// withDeclaredPools fills Player.Champions from the store when the request didn't declare any.
+func (s *Server) withDeclaredPools(players []analyzer.Player) []analyzer.Player {
+	out := make([]analyzer.Player, len(players))
+	for i, p := range players {
+		if len(p.Champions) == 0 {
+			p.Champions = s.Store.Pool(p.GameName, p.TagLine)
+		}
+		out[i] = p
+	}
+	return out
+}
+
+// handlePool serves GET/PUT/DELETE /players/{riotId}/pool
+func (s *Server) handlePool(w http.ResponseWriter, r *http.Request) {
+	p, ok := parseRiotID(r.PathValue("riotId"))
+	if !ok {
+		http.Error(w, "invalid riot id (expected name#tag or name-tag)", http.StatusBadRequest)
+		return
+	}
+	var champs []string
+	switch r.Method {
+	case http.MethodGet:
+		champs = s.Store.Pool(p.GameName, p.TagLine)
+	case http.MethodPut, http.MethodPost:
+		var body struct {
+			Champions []string `json:"champions"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+		if out := s.optedOut([]analyzer.Player{p}); len(out) > 0 {
+			errOptedOut(out).write(w)
+			return
+		}
+		champs = s.Store.SetPool(p.GameName, p.TagLine, body.Champions)
+	case http.MethodDelete:
+		s.Store.SetPool(p.GameName, p.TagLine, nil)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if champs == nil {
+		champs = []string{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"name": p.RiotID(), "champions": champs})
+}
@@ -0,0 +1,109 @@
+package httpapi
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/store"
+)
+
+// memberTokenHeader carries the member token /verify/{token} hands out.
+const memberTokenHeader = "X-Member-Token"
+
+// member checks that the caller is the verified owner of p: the member token
+// of their verification. Verifications from before member tokens have none;
+// verifying again issues one.
+func (s *Server) member(w http.ResponseWriter, r *http.Request, p analyzer.Player) (store.Verification, bool) {
+	v, ok := s.Store.Verified(p.GameName, p.TagLine)
+	if !ok {
+		http.Error(w, "verify the riot id first (POST /players/{riotId}/verification)", http.StatusForbidden)
+		return store.Verification{}, false
+	}
+	got := r.Header.Get(memberTokenHeader)
+	if got == "" || v.Token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(v.Token)) != 1 {
+		http.Error(w, "member token required ("+memberTokenHeader+")", http.StatusUnauthorized)
+		return store.Verification{}, false
+	}
+	return v, true
+}
+
+// handleRankAlerts serves GET/PUT/DELETE /players/{riotId}/rank-alerts, a
+// verified player's own subscription to alerts of their rank changes
+// ({"webhook": "https://...", "discordUserId": "...", "lpChanges": false}),
+// sent by the scheduled rank refresh. The member token from /verify/{token}
+// goes in X-Member-Token.
+func (s *Server) handleRankAlerts(w http.ResponseWriter, r *http.Request) {
+	p, ok := parseRiotID(r.PathValue("riotId"))
+	if !ok {
+		http.Error(w, "invalid riot id (expected name#tag or name-tag)", http.StatusBadRequest)
+		return
+	}
+	v, ok := s.member(w, r, p)
+	if !ok {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		rw, ok := s.Store.RankWatch(p.GameName, p.TagLine)
+		if !ok || rw.PUUID != v.PUUID {
+			http.Error(w, "no rank alerts", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, rw)
+	case http.MethodPut:
+		var body struct {
+			Webhook       string `json:"webhook"`
+			DiscordUserID string `json:"discordUserId"`
+			LPChanges     bool   `json:"lpChanges"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+		body.Webhook, body.DiscordUserID = strings.TrimSpace(body.Webhook), strings.TrimSpace(body.DiscordUserID)
+		if body.Webhook == "" && body.DiscordUserID == "" {
+			http.Error(w, "webhook or discordUserId is required", http.StatusBadRequest)
+			return
+		}
+		if body.Webhook != "" && !webhookURL(body.Webhook) {
+			http.Error(w, "webhook must be an https URL", http.StatusBadRequest)
+			return
+		}
+		if body.DiscordUserID != "" && !snowflake(body.DiscordUserID) {
+			http.Error(w, "discordUserId must be a Discord id", http.StatusBadRequest)
+			return
+		}
+		if body.DiscordUserID != "" && (s.RankAlerts == nil || s.RankAlerts.BotToken == "") {
+			http.Error(w, "discord DMs need DISCORD_BOT_TOKEN on the server", http.StatusBadRequest)
+			return
+		}
+		if out := s.optedOut([]analyzer.Player{p}); len(out) > 0 {
+			errOptedOut(out).write(w)
+			return
+		}
+		rw, ok := s.Store.SetRankWatch(store.RankWatch{
+			Player: p.RiotID(), Webhook: body.Webhook, DiscordUserID: body.DiscordUserID,
+			LPChanges: body.LPChanges, PUUID: v.PUUID,
+		})
+		if !ok {
+			errOptedOut([]string{p.RiotID()}).write(w)
+			return
+		}
+		writeJSON(w, http.StatusOK, rw)
+	case http.MethodDelete:
+		s.Store.DeleteRankWatch(p.GameName, p.TagLine)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// webhookURL reports whether v is an https URL with a host.
+func webhookURL(v string) bool {
+	u, err := url.Parse(v)
+	return err == nil && u.Scheme == "https" && u.Host != ""
+}
@@ -0,0 +1,194 @@
+package httpapi
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"lol_custom_skill_matching/internal/store"
+)
+
+var ratingColumns = []string{"player", "score", "low", "high", "main_lanes", "sub_lanes", "champions", "override", "updated_at"}
+
+// listSep joins lanes and champions inside one CSV cell.
+const listSep = "|"
+
+func wantsCSV(r *http.Request) bool {
+	return r.URL.Query().Get("format") == "csv" || strings.Contains(r.Header.Get("Accept"), "text/csv")
+}
+
+// handleRatings serves GET /ratings (JSON, or CSV with ?format=csv) and POST
+// /ratings to import the same JSON array or CSV back (organizers).
+func (s *Server) handleRatings(w http.ResponseWriter, r *http.Request) {
+	if !s.organizer(w, r) {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		rs := s.Store.Ratings()
+		if !wantsCSV(r) {
+			writeJSON(w, http.StatusOK, rs)
+			return
+		}
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="ratings.csv"`)
+		if err := writeRatingsCSV(w, rs); err != nil {
+			log.Printf("[req %s] ratings export: %v", RequestID(r.Context()), err)
+		}
+	case http.MethodPost:
+		var rs []store.Rating
+		var err error
+		if ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); ct == "text/csv" {
+			rs, err = readRatingsCSV(r.Body)
+		} else if err = json.NewDecoder(r.Body).Decode(&rs); err != nil {
+			err = errors.New("invalid json (expected an array of ratings)")
+		}
+		if err == nil {
+			err = s.checkRatings(rs)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.Store.ImportRatings(rs)
+		writeJSON(w, http.StatusOK, map[string]int{"imported": len(rs)})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// checkRatings validates an import as a whole so a bad row imports nothing.
+func (s *Server) checkRatings(rs []store.Rating) error {
+	for i := range rs {
+		r := &rs[i]
+		id, ok := parseRiotID(r.Player)
+		if !ok {
+			return fmt.Errorf("rating %d: invalid player %q (expected name#tag)", i+1, r.Player)
+		}
+		r.Player = id.RiotID()
+		var err error
+		if r.MainLanes, err = lanes(r.Player, r.MainLanes); err != nil {
+			return fmt.Errorf("rating %d: %w", i+1, err)
+		}
+		if r.SubLanes, err = lanes(r.Player, r.SubLanes); err != nil {
+			return fmt.Errorf("rating %d: %w", i+1, err)
+		}
+		if r.Low == 0 && r.High == 0 {
+			r.Low, r.High = r.Score, r.Score
+		}
+		if r.Low > r.Score || r.High < r.Score {
+			return fmt.Errorf("rating %d: score must lie between low and high", i+1)
+		}
+	}
+	return nil
+}
+
+func writeRatingsCSV(w io.Writer, rs []store.Rating) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(ratingColumns); err != nil {
+		return err
+	}
+	for _, r := range rs {
+		override := ""
+		if r.Override != nil {
+			override = strconv.Itoa(*r.Override)
+		}
+		updated := ""
+		if !r.UpdatedAt.IsZero() {
+			updated = r.UpdatedAt.UTC().Format(time.RFC3339)
+		}
+		if err := cw.Write([]string{
+			r.Player, strconv.Itoa(r.Score), strconv.Itoa(r.Low), strconv.Itoa(r.High),
+			strings.Join(r.MainLanes, listSep), strings.Join(r.SubLanes, listSep), strings.Join(r.Champions, listSep),
+			override, updated,
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// readRatingsCSV reads the export format. Columns are matched by header name, so
+// a spreadsheet may reorder or drop the optional ones; player and score are required.
+func readRatingsCSV(body io.Reader) ([]store.Rating, error) {
+	cr := csv.NewReader(body)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("csv header: %w", err)
+	}
+	col := map[string]int{}
+	for i, h := range header {
+		col[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))] = i
+	}
+	for _, c := range []string{"player", "score"} {
+		if _, ok := col[c]; !ok {
+			return nil, fmt.Errorf("csv: missing %q column", c)
+		}
+	}
+	var rs []store.Rating
+	for line := 2; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return rs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("csv: %w", err)
+		}
+		cell := func(name string) string {
+			if i, ok := col[name]; ok && i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+		num := func(name string) (int, error) {
+			v := cell(name)
+			if v == "" {
+				return 0, nil
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return 0, fmt.Errorf("csv line %d: invalid %s %q", line, name, v)
+			}
+			return n, nil
+		}
+		list := func(name string) []string {
+			if v := cell(name); v != "" {
+				return strings.Split(v, listSep)
+			}
+			return nil
+		}
+		r := store.Rating{Player: cell("player"), MainLanes: list("main_lanes"), SubLanes: list("sub_lanes"), Champions: list("champions")}
+		if r.Score, err = num("score"); err != nil {
+			return nil, err
+		}
+		if r.Low, err = num("low"); err != nil {
+			return nil, err
+		}
+		if r.High, err = num("high"); err != nil {
+			return nil, err
+		}
+		if cell("override") != "" {
+			o, err := num("override")
+			if err != nil {
+				return nil, err
+			}
+			r.Override = &o
+		}
+		if v := cell("updated_at"); v != "" {
+			if r.UpdatedAt, err = time.Parse(time.RFC3339, v); err != nil {
+				return nil, fmt.Errorf("csv line %d: invalid updated_at %q", line, v)
+			}
+		}
+		rs = append(rs, r)
+	}
+}
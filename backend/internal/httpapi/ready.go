@@ -0,0 +1,94 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"lol_custom_skill_matching/internal/riot"
+)
+
+// pinger is implemented by stores backed by a database.
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// SetDraining marks the server as shutting down: /readyz answers 503 so
+// healthchecks and load balancers stop routing to it while the requests in
+// flight finish.
+func (s *Server) SetDraining(v bool) { s.draining.Store(v) }
+
+type readiness struct {
+	Status string            `json:"status"` // "ready", "starting", "draining" or "unavailable"
+	Checks map[string]string `json:"checks"`
+}
+
+// handleReady serves GET /readyz: 200 while the server should get traffic,
+// 503 while it prefetches static data at startup, drains or its database
+// doesn't answer. An open Riot breaker
+// doesn't make it unready (analyses fall back to stored profiles); it is
+// reported as "degraded".
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	res := readiness{Status: "ready", Checks: map[string]string{"store": "ok", "riot": "ok"}}
+	status := http.StatusOK
+	if p, ok := s.Store.(pinger); ok {
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+		defer cancel()
+		if err := p.Ping(ctx); err != nil {
+			res.Checks["store"] = err.Error()
+			res.Status, status = "unavailable", http.StatusServiceUnavailable
+		}
+	}
+	if b := s.Analyzer.Riot.Breaker; b != nil && b.Stats().State != riot.BreakerClosed {
+		res.Checks["riot"] = "degraded"
+	}
+	if !s.Analyzer.Riot.KeyStatus().Valid {
+		// every instance shares the key, so routing elsewhere wouldn't help
+		res.Checks["riot"] = "key_invalid"
+	}
+	if s.Static != nil && !s.Static.Ready() {
+		res.Checks["static"] = "loading"
+		res.Status, status = "starting", http.StatusServiceUnavailable
+	}
+	if s.draining.Load() {
+		res.Status, status = "draining", http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, res)
+}
+
+type serverStatus struct {
+	Status   string         `json:"status"` // "ok", "degraded" or "key_invalid"
+	RiotKey  riot.KeyStatus `json:"riot_key"`
+	Breaker  string         `json:"breaker"`
+	Draining bool           `json:"draining"`
+	// RiotEndpoints are Riot's latency and errors per endpoint over the SLO
+	// window; a degraded one makes Status "degraded".
+	RiotEndpoints []riot.EndpointSLO `json:"riot_endpoints"`
+}
+
+// handleStatus serves GET /status: what an operator needs to act on, above
+// all a Riot API key that has expired, then a Riot outage or slowdown that
+// would drag analyses out.
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	rc := s.Analyzer.Riot
+	st := serverStatus{Status: "ok", RiotKey: rc.KeyStatus(), Breaker: "disabled", Draining: s.draining.Load()}
+	if rc.Breaker != nil {
+		st.Breaker = rc.Breaker.Stats().State
+		if st.Breaker != riot.BreakerClosed {
+			st.Status = "degraded"
+		}
+	}
+	st.RiotEndpoints = rc.SLO.Stats()
+	if st.RiotEndpoints == nil {
+		st.RiotEndpoints = []riot.EndpointSLO{}
+	}
+	for _, e := range st.RiotEndpoints {
+		if e.Degraded {
+			st.Status = "degraded"
+		}
+	}
+	if !st.RiotKey.Valid {
+		st.Status = "key_invalid"
+	}
+	writeJSON(w, http.StatusOK, st)
+}
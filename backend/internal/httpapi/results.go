@@ -0,0 +1,159 @@
+package httpapi
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"time"
+
+	"lol_custom_skill_matching/internal/resultfile"
+	"lol_custom_skill_matching/internal/signing"
+	"lol_custom_skill_matching/internal/store"
+)
+
+// handleResults serves GET /results: stored splits, newest first.
+// ?input_hash= keeps the splits of the same roster and options (see
+// analyzer.Provenance), to compare them over time. Sealed lobbies' results
+// are listed as sealedResult.
+func (s *Server) handleResults(w http.ResponseWriter, r *http.Request) {
+	proj, err := parseFields(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	results := s.Store.Results()
+	if h := r.URL.Query().Get("input_hash"); h != "" {
+		results = slices.DeleteFunc(results, func(res store.Result) bool {
+			return res.Split.Provenance == nil || res.Split.Provenance.InputHash != h
+		})
+	}
+	out := make([]any, len(results))
+	for i, res := range results {
+		out[i] = s.resultView(res)
+	}
+	writeJSON(w, http.StatusOK, proj.project(out))
+}
+
+// sealedResult is a stored result of a sealed lobby (see sealedLobby): its
+// split as the lobby shows it, without scores, until both captains accept.
+type sealedResult struct {
+	ID        string         `json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	LobbyID   string         `json:"lobby_id"`
+	Split     hiddenResult   `json:"split"`
+	Outcome   *store.Outcome `json:"outcome,omitempty"`
+}
+
+// resultView is res as clients may see it: the result itself, or a
+// sealedResult while its lobby is sealed.
+func (s *Server) resultView(res store.Result) any {
+	l, ok := s.sealedLobby(res)
+	if !ok {
+		return res
+	}
+	split, _ := hideSplit(&res.Split, l.Reveal, l.RevealedRoles)
+	return sealedResult{ID: res.ID, CreatedAt: res.CreatedAt, LobbyID: res.LobbyID, Split: split, Outcome: res.Outcome}
+}
+
+// resultResponse is a stored result as GET /results/{id} serves it.
+type resultResponse struct {
+	store.Result
+	File      *resultfile.Info   `json:"file,omitempty"`
+	Signature *signing.Signature `json:"signature,omitempty"`
+}
+
+// handleResult serves GET /results/{id}, with the result's file while the
+// retention policy keeps it and, with a Signer, its signature (of the whole
+// result, whatever ?fields= selects). A sealed lobby's result is served as
+// a sealedResult, without file or signature.
+func (s *Server) handleResult(w http.ResponseWriter, r *http.Request) {
+	proj, err := parseFields(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, ok := s.Store.Result(r.PathValue("id"))
+	if !ok {
+		http.Error(w, "result not found", http.StatusNotFound)
+		return
+	}
+	if _, ok := s.sealedLobby(res); ok {
+		writeJSON(w, http.StatusOK, proj.project(s.resultView(res)))
+		return
+	}
+	out := resultResponse{Result: res}
+	if s.Results != nil {
+		if fi, ok := s.Results.Stat(res.ID); ok {
+			out.File = &fi
+		}
+	}
+	if s.Signer != nil {
+		payload, err := json.Marshal(res)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sig := s.Signer.Sign(payload)
+		out.Signature = &sig
+	}
+	writeJSON(w, http.StatusOK, proj.project(out))
+}
+
+// signedPayload is how a result's signature is computed, for GET /results/signing.
+const signedPayload = "Ed25519 over the compact JSON of the result as GET /results/{id} returns it, " +
+	"without the file and signature fields (fields in the order served, no whitespace)"
+
+// handleSigningMethod serves GET /results/signing: how results and webhook
+// posts are signed and the public key that checks them, so downstream tools
+// can verify without asking the server.
+func (s *Server) handleSigningMethod(w http.ResponseWriter, r *http.Request) {
+	if s.Signer == nil {
+		http.Error(w, "result signing is disabled (RESULT_SIGNING_KEY)", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"algorithm":      signing.Algorithm,
+		"key_id":         s.Signer.KeyID(),
+		"public_key":     hex.EncodeToString(s.Signer.PublicKey()),
+		"public_key_pem": s.Signer.PublicKeyPEM(),
+		"result":         signedPayload,
+		"verify":         "POST /results/verify with the result JSON, signature included, or check it with public_key",
+		"webhooks": map[string]string{
+			"signature": signing.HeaderSignature + ": ed25519=<hex Ed25519 signature of \"<timestamp>.<body>\">",
+			"timestamp": signing.HeaderTimestamp + ": <unix seconds>",
+			"key_id":    signing.HeaderKeyID,
+		},
+	})
+}
+
+// handleVerifyResult serves POST /results/verify: a result as served by
+// GET /results/{id}; valid is true when its signature matches its content.
+func (s *Server) handleVerifyResult(w http.ResponseWriter, r *http.Request) {
+	if s.Signer == nil {
+		http.Error(w, "result signing is disabled (RESULT_SIGNING_KEY)", http.StatusNotFound)
+		return
+	}
+	var body struct {
+		store.Result
+		Signature *signing.Signature `json:"signature"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if body.Signature == nil {
+		http.Error(w, "signature is required", http.StatusBadRequest)
+		return
+	}
+	payload, err := json.Marshal(body.Result)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	out := map[string]any{"valid": s.Signer.Verify(payload, *body.Signature), "result_id": body.ID}
+	if body.Signature.KeyID != s.Signer.KeyID() {
+		out["error"] = "signed with another key (" + body.Signature.KeyID + ")"
+	}
+	writeJSON(w, http.StatusOK, out)
+}
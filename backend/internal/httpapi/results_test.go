@@ -0,0 +1,120 @@
+package httpapi
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/events"
+	"lol_custom_skill_matching/internal/riot/riottest"
+	"lol_custom_skill_matching/internal/signing"
+	"lol_custom_skill_matching/internal/store"
+)
+
+func TestBlindLobbyResultsSealed(t *testing.T) {
+	fake := riottest.NewServer()
+	defer fake.Close()
+	defer fake.ServeDataDragon()()
+	st := store.NewMemory()
+	bus := events.NewBus()
+	var published []events.Event
+	bus.Subscribe("test", func(ev events.Event) { published = append(published, ev) })
+	srv := &Server{Store: st, Events: bus, Analyzer: analyzer.New(fake.Client(), 1)}
+	h := srv.Handler()
+
+	l := st.CreateLobby("scrim", nil, [2]analyzer.TeamInfo{}, true, "")
+	ts := analyzer.TeamSplit{
+		TeamA: []analyzer.Profile{{Name: "Alice#JP1", SkillScore: 512}},
+		TeamB: []analyzer.Profile{{Name: "Bob#JP1", SkillScore: 498}},
+		SumA:  512, SumB: 498,
+	}
+	res := st.AddResult(l.ID, ts)
+	srv.publishResult(res.ID, l.ID, ts)
+	bus.Close()
+
+	get := func(path string) (int, string) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code, rec.Body.String()
+	}
+	for _, path := range []string{"/results", "/results/" + res.ID} {
+		code, body := get(path)
+		if code != http.StatusOK || !strings.Contains(body, `"hidden":true`) || strings.Contains(body, "512") {
+			t.Errorf("GET %s before accepting = %d %s, want the split hidden", path, code, body)
+		}
+	}
+	if code, _ := get("/results/" + res.ID + "/overlay.json"); code != http.StatusConflict {
+		t.Errorf("GET overlay.json before accepting = %d, want 409", code)
+	}
+	if len(published) != 1 {
+		t.Fatalf("published %d events, want 1", len(published))
+	}
+	b, _ := json.Marshal(published[0])
+	if d := published[0].Data.(events.ResultData); !d.Hidden || d.SumA != nil || strings.Contains(string(b), "512") {
+		t.Errorf("result.recorded = %s, want it hidden", b)
+	}
+
+	if _, err := st.UpdateLobby(l.ID, func(l *store.Lobby) error {
+		l.Accepted = [2]bool{true, true}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if code, body := get("/results/" + res.ID); code != http.StatusOK || !strings.Contains(body, `"sumA":512`) {
+		t.Errorf("GET result after accepting = %d %s, want the split", code, body)
+	}
+	if code, _ := get("/results/" + res.ID + "/overlay.json"); code != http.StatusOK {
+		t.Errorf("GET overlay.json after accepting = %d, want 200", code)
+	}
+}
+
+func TestResultSignatureVerifiesWithPublishedKey(t *testing.T) {
+	st := store.NewMemory()
+	srv := &Server{Store: st, Signer: signing.New([]byte("secret"))}
+	h := srv.Handler()
+	res := st.AddResult("", analyzer.TeamSplit{
+		TeamA: []analyzer.Profile{{Name: "Alice#JP1", SkillScore: 512}},
+		TeamB: []analyzer.Profile{{Name: "Bob#JP1", SkillScore: 498}},
+		SumA:  512, SumB: 498,
+	})
+	get := func(path string, v any) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d %s", path, rec.Code, rec.Body)
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var method struct {
+		Algorithm string `json:"algorithm"`
+		KeyID     string `json:"key_id"`
+		PublicKey string `json:"public_key"`
+	}
+	get("/results/signing", &method)
+	pub, err := hex.DecodeString(method.PublicKey)
+	if err != nil || method.Algorithm != signing.Algorithm || len(pub) != ed25519.PublicKeySize {
+		t.Fatalf("GET /results/signing = %+v", method)
+	}
+	var served resultResponse
+	get("/results/"+res.ID, &served)
+	payload, _ := json.Marshal(served.Result)
+	if served.Signature == nil || served.Signature.KeyID != method.KeyID {
+		t.Fatalf("signature = %+v, want one by %s", served.Signature, method.KeyID)
+	}
+	if !signing.Verify(pub, payload, *served.Signature) {
+		t.Error("the published public key rejects the result's signature")
+	}
+	served.Split.SumA = 600
+	payload, _ = json.Marshal(served.Result)
+	if signing.Verify(pub, payload, *served.Signature) {
+		t.Error("an edited result verifies")
+	}
+}
@@ -0,0 +1,185 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/store"
+)
+
+// What /balance does with the players of a lobby who answered maybe; those
+// who answered no are always left out.
+const (
+	maybeWarn    = "warn"    // balance them, listed in rsvp.maybe
+	maybeExclude = "exclude" // leave them out like no
+)
+
+var errNotInLobby = errors.New("player is not in the lobby")
+
+// rsvpReport is the rsvp field of a /balance response made for a lobby.
+type rsvpReport struct {
+	Lobby    string   `json:"lobby"`
+	Maybe    []string `json:"maybe"`    // balanced although they may not come (maybe=warn)
+	Excluded []string `json:"excluded"` // left out: no, or maybe with maybe=exclude
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// lobbyDiscord is what clients see of a lobby's Discord link.
+type lobbyDiscord struct {
+	ChannelID string `json:"channel_id"`
+	MessageID string `json:"message_id"`
+	Users     int    `json:"users"` // linked Discord users
+}
+
+// handleRSVP serves PUT /lobbies/{id}/rsvp/{riotId} {"status": "yes"|"maybe"|"no",
+// "arriveAt": "2026-05-01T21:30:00+09:00"} and DELETE to clear the answer.
+func (s *Server) handleRSVP(w http.ResponseWriter, r *http.Request) {
+	p, ok := parseRiotID(r.PathValue("riotId"))
+	if !ok {
+		http.Error(w, "invalid riot id (expected name#tag or name-tag)", http.StatusBadRequest)
+		return
+	}
+	var body struct {
+		Status   string     `json:"status"`
+		ArriveAt *time.Time `json:"arriveAt"`
+	}
+	if r.Method != http.MethodDelete {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+		body.Status = strings.ToLower(strings.TrimSpace(body.Status))
+		if !store.ValidRSVP(body.Status) {
+			http.Error(w, "invalid status (yes|maybe|no)", http.StatusBadRequest)
+			return
+		}
+	}
+	l, err := s.Store.UpdateLobby(r.PathValue("id"), func(l *store.Lobby) error {
+		m, ok := l.Member(p.RiotID())
+		if !ok {
+			return errNotInLobby
+		}
+		l.SetRSVP(store.RSVP{Player: m.RiotID(), Status: body.Status, ArriveAt: body.ArriveAt, Source: store.RSVPSourceAPI, UpdatedAt: time.Now()})
+		return nil
+	})
+	if errors.Is(err, errNotInLobby) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.lobbyError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newLobbyView(l))
+}
+
+// handleLobbyDiscord serves PUT /lobbies/{id}/discord (organizers)
+// {"channelId": "...", "messageId": "...", "users": {"<discord user id>": "name#tag"}}:
+// reactions to the message set the linked players' RSVPs (see
+// discord.RSVPSync). DELETE unlinks it.
+func (s *Server) handleLobbyDiscord(w http.ResponseWriter, r *http.Request) {
+	if !s.organizer(w, r) {
+		return
+	}
+	var link *store.DiscordLink
+	if r.Method != http.MethodDelete {
+		var body struct {
+			ChannelID string            `json:"channelId"`
+			MessageID string            `json:"messageId"`
+			Users     map[string]string `json:"users"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+		if !snowflake(body.ChannelID) || !snowflake(body.MessageID) {
+			http.Error(w, "channelId and messageId must be Discord ids", http.StatusBadRequest)
+			return
+		}
+		link = &store.DiscordLink{ChannelID: body.ChannelID, MessageID: body.MessageID, Users: map[string]string{}}
+		for user, player := range body.Users {
+			if !snowflake(user) {
+				http.Error(w, "invalid discord user id "+user, http.StatusBadRequest)
+				return
+			}
+			link.Users[user] = player
+		}
+	}
+	var unknown string
+	l, err := s.Store.UpdateLobby(r.PathValue("id"), func(l *store.Lobby) error {
+		if link != nil {
+			for user, player := range link.Users {
+				m, ok := l.Member(player)
+				if !ok {
+					unknown = player
+					return errNotInLobby
+				}
+				link.Users[user] = m.RiotID()
+			}
+		}
+		l.Discord = link
+		return nil
+	})
+	if errors.Is(err, errNotInLobby) {
+		http.Error(w, unknown+": "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		s.lobbyError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newLobbyView(l))
+}
+
+// snowflake reports whether id looks like a Discord id.
+func snowflake(id string) bool {
+	return len(id) >= 5 && len(id) <= 20 && strings.Trim(id, "0123456789") == ""
+}
+
+// rsvpFilter drops from names the lobby's players who won't come (no, and
+// maybe when policy is exclude) and reports what it did.
+func rsvpFilter(l store.Lobby, names []string, policy string) ([]string, rsvpReport) {
+	rep := rsvpReport{Lobby: l.ID, Maybe: []string{}, Excluded: []string{}}
+	var keep []string
+	for _, name := range names {
+		m, member := l.Member(name)
+		if !member {
+			rep.Warnings = append(rep.Warnings, name+" is not in the lobby")
+			keep = append(keep, name)
+			continue
+		}
+		r, answered := l.RSVPOf(m.RiotID())
+		switch {
+		case answered && (r.Status == store.RSVPNo || r.Status == store.RSVPMaybe && policy == maybeExclude):
+			rep.Excluded = append(rep.Excluded, name)
+			continue
+		case answered && r.Status == store.RSVPMaybe:
+			rep.Maybe = append(rep.Maybe, name)
+		case !answered:
+			rep.Warnings = append(rep.Warnings, name+" has not answered")
+		}
+		if answered && r.ArriveAt != nil && l.StartsAt != nil && r.ArriveAt.After(*l.StartsAt) {
+			rep.Warnings = append(rep.Warnings, name+" arrives at "+r.ArriveAt.Format(time.RFC3339))
+		}
+		keep = append(keep, name)
+	}
+	return keep, rep
+}
+
+// rsvpView lists the lobby's answers in the order of its players.
+func rsvpView(l store.Lobby) []store.RSVP {
+	out := slices.Clone(l.RSVPs)
+	order := func(player string) int {
+		return slices.IndexFunc(l.Players, func(p analyzer.Player) bool { return strings.EqualFold(p.RiotID(), player) })
+	}
+	slices.SortStableFunc(out, func(a, b store.RSVP) int { return order(a.Player) - order(b.Player) })
+	if out == nil {
+		out = []store.RSVP{}
+	}
+	return out
+}
@@ -0,0 +1,31 @@
+package httpapi
+
+import (
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultSearchLimit = 10
+	maxSearchLimit     = 50
+)
+
+// handleSearchPlayers serves GET /players/search?q=&limit=: known Riot IDs for
+// roster autocomplete, best matches first.
+func (s *Server) handleSearchPlayers(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		http.Error(w, "q is required", http.StatusBadRequest)
+		return
+	}
+	limit := defaultSearchLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxSearchLimit)
+	}
+	writeJSON(w, http.StatusOK, s.Store.SearchPlayers(q, limit))
+}
@@ -0,0 +1,115 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"lol_custom_skill_matching/internal/secrets"
+)
+
+// vault checks for organizer endpoints that need the secrets vault. They
+// redirect signed webhook posts and re-key the vault, so they need an
+// ORGANIZER_TOKEN (see admin).
+func (s *Server) vault(w http.ResponseWriter, r *http.Request) bool {
+	if !s.admin(w, r) {
+		return false
+	}
+	if s.Secrets == nil {
+		http.Error(w, "secrets are disabled (SECRETS_KEY)", http.StatusNotFound)
+		return false
+	}
+	return true
+}
+
+// handleSecrets serves GET /admin/secrets (organizers): the stored secrets'
+// names and when they were set, never their values.
+func (s *Server) handleSecrets(w http.ResponseWriter, r *http.Request) {
+	if !s.vault(w, r) {
+		return
+	}
+	list, err := s.Secrets.List()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"key_id": s.Secrets.KeyID(), "secrets": list})
+}
+
+// handleSetSecret serves PUT /admin/secrets/{name} (organizers):
+// {"value": "https://discord.com/api/webhooks/..."} stores or replaces it.
+// Config values "secret:{name}" pick up the new value on their next use.
+func (s *Server) handleSetSecret(w http.ResponseWriter, r *http.Request) {
+	if !s.vault(w, r) {
+		return
+	}
+	var body struct {
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if body.Value == "" {
+		http.Error(w, "value is required", http.StatusBadRequest)
+		return
+	}
+	info, err := s.Secrets.Set(r.PathValue("name"), body.Value)
+	if errors.Is(err, secrets.ErrInvalidName) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	log.Printf("[req %s] secret %s set", RequestID(r.Context()), info.Name)
+	writeJSON(w, http.StatusOK, info)
+}
+
+func (s *Server) handleDeleteSecret(w http.ResponseWriter, r *http.Request) {
+	if !s.vault(w, r) {
+		return
+	}
+	err := s.Secrets.Delete(r.PathValue("name"))
+	if errors.Is(err, secrets.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	log.Printf("[req %s] secret %s deleted", RequestID(r.Context()), r.PathValue("name"))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRotateSecrets serves POST /admin/secrets/rotate (organizers):
+// {"key": "<new master key>"} re-encrypts every secret with it. Put the new
+// key in SECRETS_KEY before the next restart (the old one in
+// SECRETS_PREVIOUS_KEY is accepted meanwhile).
+func (s *Server) handleRotateSecrets(w http.ResponseWriter, r *http.Request) {
+	if !s.vault(w, r) {
+		return
+	}
+	var body struct {
+		Key string `json:"key"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	key, err := secrets.ParseKey(body.Key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	n, err := s.Secrets.Rotate(key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	log.Printf("[req %s] secrets re-encrypted with key %s (%d)", RequestID(r.Context()), s.Secrets.KeyID(), n)
+	writeJSON(w, http.StatusOK, map[string]any{"key_id": s.Secrets.KeyID(), "rotated": n})
+}
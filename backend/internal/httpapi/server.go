@@ -0,0 +1,202 @@
+// Package httpapi exposes the analyzer over HTTP.
+package httpapi
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/backfill"
+	"lol_custom_skill_matching/internal/cache"
+	"lol_custom_skill_matching/internal/digest"
+	"lol_custom_skill_matching/internal/discord"
+	"lol_custom_skill_matching/internal/events"
+	"lol_custom_skill_matching/internal/memwatch"
+	"lol_custom_skill_matching/internal/rankalert"
+	"lol_custom_skill_matching/internal/resultfile"
+	"lol_custom_skill_matching/internal/retention"
+	"lol_custom_skill_matching/internal/riot"
+	"lol_custom_skill_matching/internal/secrets"
+	"lol_custom_skill_matching/internal/signing"
+	"lol_custom_skill_matching/internal/snapshot"
+	"lol_custom_skill_matching/internal/staticdata"
+	"lol_custom_skill_matching/internal/store"
+)
+
+// Server holds the HTTP handlers and their dependencies.
+type Server struct {
+	Analyzer *analyzer.Analyzer
+	Store    store.Store
+	// MatchLimit is the default when a request doesn't set one.
+	MatchLimit int
+	// Results receives a copy of every analyze result, one file each, for
+	// traceability (nil disables).
+	Results *resultfile.Dir
+	// Backfill queues deep history walks (nil disables the endpoints).
+	Backfill *backfill.Worker
+	// Snapshots serves the nightly community snapshot (nil disables it).
+	Snapshots *snapshot.Scheduler
+	// Digest posts the snapshot to community Discord channels (nil = none).
+	Digest *digest.Poster
+	// Pruner applies the data retention policy; its totals go to /metrics (nil = none).
+	Pruner *retention.Pruner
+	// RSVPSync reads lobby RSVPs from Discord reactions; its totals go to /metrics (nil = none).
+	RSVPSync *discord.RSVPSync
+	// RankAlerts refreshes the ranks of players subscribed to rank alerts;
+	// its totals go to /metrics (nil = none).
+	RankAlerts *rankalert.Refresher
+	// Memory keeps the server within its memory limit; its totals go to
+	// /metrics (nil = none).
+	Memory *memwatch.Watchdog
+	// Static prefetches champions, queues and rank images; /readyz is 503
+	// until its first load is done (nil = no prefetch, ready at once).
+	Static *staticdata.Loader
+	// Secrets is the encrypted vault of webhook URLs and tokens (nil disables
+	// the admin endpoints).
+	Secrets *secrets.Vault
+	// Events receives what the handlers did (jobs, profiles, results) for its
+	// subscribers; GET /events streams it (nil = no events).
+	Events *events.Bus
+	// Signer signs results (GET /results/{id}) for POST /results/verify (nil = unsigned).
+	Signer *signing.Signer
+	// AllowedOrigins are the browser origins besides the server's own that
+	// may open GET /ws and get CORS headers ("*" = any). Without any, GET /ws
+	// is same-origin only and CORS allows every origin.
+	AllowedOrigins []string
+	// CallerKeys paces requests that bring their own Riot key in the
+	// X-Riot-Key header (nil = the header is refused).
+	CallerKeys *riot.KeyLimiters
+	// Backpressure refuses analyze jobs and backfills when their queue is full.
+	Backpressure Backpressure
+	// OrganizerToken guards organizer endpoints (appeal review); "" leaves them
+	// open, except the admin ones (secrets, backup) which it closes.
+	OrganizerToken string
+	// DiscordKey is the bot application's public key, checking the slash
+	// commands posted to POST /discord/interactions (nil = disabled).
+	DiscordKey ed25519.PublicKey
+	// BackupFiles are the cache files (archive name -> path) included in backups.
+	BackupFiles map[string]string
+	// Demo serves the public demo instead of the API: canned analyses of a
+	// sample roster, DemoRateLimit per client and minute (0 = unlimited).
+	Demo          bool
+	DemoRateLimit int
+
+	cardsOnce sync.Once
+	cards     *cache.TTL[string, playerCard] // RiotIDKey -> card
+	jobsOnce  sync.Once
+	jobs      *cache.TTL[string, *analyzeJob]
+	draining  atomic.Bool
+	load      jobLoad
+	stopOnce  sync.Once
+	stop      context.Context // see jobsStopped
+	stopJobs  context.CancelFunc
+	handoffMu sync.Mutex
+	handoff   chan struct{} // see AwaitHandoff
+
+	streamsOnce  sync.Once
+	streams      context.Context // see streamsClosed
+	closeStreams context.CancelFunc
+
+	rejectedJobs, rejectedBackfills atomic.Int64
+}
+
+// Handler returns the routed handler wrapped in logging and CORS middleware.
+func (s *Server) Handler() http.Handler {
+	if s.Demo {
+		return s.demoHandler()
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("GET /readyz", s.handleReady)
+	mux.HandleFunc("GET /status", s.handleStatus)
+	mux.HandleFunc("/analyze", s.handleAnalyze)
+	mux.HandleFunc("POST /analyze/jobs", s.handleCreateJob)
+	mux.HandleFunc("GET /analyze/jobs/{id}", s.handleGetJob)
+	mux.HandleFunc("POST /analyze/jobs/{id}/retry", s.handleRetryJob)
+	mux.HandleFunc("GET /ws", s.handleWS)
+	mux.HandleFunc("GET /events", s.handleEvents)
+	mux.HandleFunc("POST /balance", s.handleBalance)
+	mux.HandleFunc("POST /simulate", s.handleSimulate)
+	mux.HandleFunc("GET /results", s.handleResults)
+	mux.HandleFunc("GET /results/{id}", s.handleResult)
+	mux.HandleFunc("GET /results/signing", s.handleSigningMethod)
+	mux.HandleFunc("POST /results/verify", s.handleVerifyResult)
+	mux.HandleFunc("POST /results/{id}/outcome", s.handleResultOutcome)
+	mux.HandleFunc("GET /results/{id}/overlay.json", s.handleOverlay)
+	mux.HandleFunc("GET /results/{id}/overlay", s.handleOverlayPage)
+	mux.HandleFunc("GET /leaderboard", s.handleLeaderboard)
+	mux.HandleFunc("GET /calibration", s.handleCalibration)
+	mux.HandleFunc("GET /snapshot/latest", s.handleSnapshot)
+	mux.HandleFunc("POST /snapshot", s.handleTakeSnapshot)
+	mux.HandleFunc("GET /digest/preview", s.handleDigestPreview)
+	mux.HandleFunc("/players/{riotId}/pool", s.handlePool)
+	mux.HandleFunc("GET /players/{riotId}/matches.jsonl", s.handleMatchesExport)
+	mux.HandleFunc("POST /players/{riotId}/backfill", s.handleBackfill)
+	mux.HandleFunc("GET /backfill", s.handleBackfillList)
+	mux.HandleFunc("/appeals", s.handleAppeals)
+	mux.HandleFunc("POST /appeals/{id}/decision", s.handleAppealDecision)
+	mux.HandleFunc("/players/{riotId}/appeals", s.handlePlayerAppeals)
+	mux.HandleFunc("POST /players/{riotId}/verification", s.handleStartVerification)
+	mux.HandleFunc("GET /players/{riotId}/verification", s.handleVerificationStatus)
+	mux.HandleFunc("/verify/{token}", s.handleCheckVerification)
+	mux.HandleFunc("GET /players/{riotId}/sides", s.handleSides)
+	mux.HandleFunc("GET /players/search", s.handleSearchPlayers)
+	mux.HandleFunc("/players/{riotId}/notes", s.handleNote)
+	mux.HandleFunc("/players/{riotId}/rank-alerts", s.handleRankAlerts)
+	mux.HandleFunc("GET /players/notes", s.handleNotes)
+	mux.HandleFunc("GET /players/opt-outs", s.handleOptOuts)
+	mux.HandleFunc("DELETE /players/{riotId}", s.handleDeletePlayer)
+	mux.HandleFunc("POST /discord/interactions", s.handleDiscordInteraction)
+	mux.HandleFunc("DELETE /players/{riotId}/opt-out", s.handleOptIn)
+	mux.HandleFunc("GET /players/{riotId}/card", s.handlePlayerCard)
+	mux.HandleFunc("GET /players/{a}/vs/{b}", s.handleHeadToHead)
+	mux.HandleFunc("/ratings", s.handleRatings)
+	mux.HandleFunc("POST /lobbies", s.handleCreateLobby)
+	mux.HandleFunc("GET /lobbies/{id}", s.handleGetLobby)
+	mux.HandleFunc("POST /lobbies/{id}/analyze", s.handleAnalyzeLobby)
+	mux.HandleFunc("POST /lobbies/{id}/reveal", s.handleRevealLobby)
+	mux.HandleFunc("POST /lobbies/{id}/accept", s.handleAcceptLobby)
+	mux.HandleFunc("PUT /lobbies/{id}/rsvp/{riotId}", s.handleRSVP)
+	mux.HandleFunc("DELETE /lobbies/{id}/rsvp/{riotId}", s.handleRSVP)
+	mux.HandleFunc("PUT /lobbies/{id}/discord", s.handleLobbyDiscord)
+	mux.HandleFunc("DELETE /lobbies/{id}/discord", s.handleLobbyDiscord)
+	mux.HandleFunc("GET /lobbies/{id}/suggestions", s.handleLobbySuggestions)
+	mux.HandleFunc("GET /admin/backup", s.handleBackup)
+	mux.HandleFunc("POST /admin/restore", s.handleRestore)
+	mux.HandleFunc("GET /admin/cache/stats", s.handleCacheStats)
+	mux.HandleFunc("DELETE /admin/cache", s.handleCachePurge)
+	mux.HandleFunc("GET /admin/secrets", s.handleSecrets)
+	mux.HandleFunc("PUT /admin/secrets/{name...}", s.handleSetSecret)
+	mux.HandleFunc("DELETE /admin/secrets/{name...}", s.handleDeleteSecret)
+	mux.HandleFunc("POST /admin/secrets/rotate", s.handleRotateSecrets)
+	mux.HandleFunc("GET /champions", s.handleChampions)
+	mux.HandleFunc("GET /queues", s.handleQueues)
+	mux.HandleFunc("GET /openapi.json", s.handleOpenAPI)
+	mux.HandleFunc("GET /docs", s.handleDocs)
+	mux.HandleFunc("GET /scoring", func(w http.ResponseWriter, r *http.Request) {
+		formula := ""
+		if s.Analyzer.ScoreFormula != nil {
+			formula = s.Analyzer.ScoreFormula.String()
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"formula": formula, "features": analyzer.ScoreFeatures})
+	})
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+	mux.HandleFunc("GET /stats/breaker", s.handleBreakerStats)
+	mux.HandleFunc("GET /stats/limiter", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.Analyzer.Riot.Limiter.Stats())
+	})
+	return logRequests(s.withCORS(s.callerKey(mux)))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
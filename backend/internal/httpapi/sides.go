@@ -0,0 +1,14 @@
+package httpapi
+
+import "net/http"
+
+// handleSides serves GET /players/{riotId}/sides: how often the player has been
+// put on blue and red side by past analyses.
+func (s *Server) handleSides(w http.ResponseWriter, r *http.Request) {
+	p, ok := parseRiotID(r.PathValue("riotId"))
+	if !ok {
+		http.Error(w, "invalid riot id (expected name#tag or name-tag)", http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"name": p.RiotID(), "sides": s.Store.SideStats(p.RiotID())})
+}
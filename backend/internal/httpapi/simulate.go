@@ -0,0 +1,202 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+)
+
+type simulateRequest struct {
+	Players []analyzer.Player `json:"players"`
+	// Replace swaps players of the roster for others, e.g. a late joiner.
+	Replace    []replacement      `json:"replace"`
+	Mode       string             `json:"mode,omitempty"`
+	BalanceOn  string             `json:"balanceOn,omitempty"`
+	Objective  string             `json:"objective,omitempty"`
+	MaxLaneGap int                `json:"maxLaneGap,omitempty"`
+	LaneWeight int                `json:"laneWeight,omitempty"`
+	Captains   []analyzer.Captain `json:"captains,omitempty"`
+	TagRules   []analyzer.TagRule `json:"tagRules,omitempty"`
+}
+
+// replacement takes Out (name#tag) off the roster and puts In on it.
+type replacement struct {
+	Out string `json:"out"`
+	In  string `json:"in"`
+}
+
+// fairness compares one candidate split of the roster before and after.
+// Diffs are absolute team score differences; a negative Delta is fairer.
+type fairness struct {
+	Split      string  `json:"split"`
+	DiffBefore int     `json:"diff_before"`
+	DiffAfter  int     `json:"diff_after"`
+	Delta      int     `json:"delta"`
+	FavBefore  float64 `json:"favorite_win_pct_before"` // the stronger team's win chance
+	FavAfter   float64 `json:"favorite_win_pct_after"`
+}
+
+// roster applies the replacements to players.
+func (req simulateRequest) roster() ([]analyzer.Player, error) {
+	if len(req.Replace) == 0 {
+		return nil, fmt.Errorf("replace needs at least one {\"out\", \"in\"}")
+	}
+	out := append([]analyzer.Player{}, req.Players...)
+	index := func(riotID string) int {
+		for i, p := range out {
+			if strings.EqualFold(p.RiotID(), riotID) {
+				return i
+			}
+		}
+		return -1
+	}
+	for _, rp := range req.Replace {
+		in, ok := parseRiotID(strings.TrimSpace(rp.In))
+		if !ok {
+			return nil, fmt.Errorf("invalid riot id %q (name#tag)", rp.In)
+		}
+		i := index(strings.TrimSpace(rp.Out))
+		if i < 0 {
+			return nil, fmt.Errorf("%q is not on the roster", rp.Out)
+		}
+		if index(in.RiotID()) >= 0 {
+			return nil, fmt.Errorf("%s is already on the roster", in.RiotID())
+		}
+		out[i] = in
+	}
+	return out, nil
+}
+
+// cachedProfiles returns the stored profiles of players, their skill
+// intervals widened by their age and overrides applied, and the players that
+// have none.
+func (s *Server) cachedProfiles(players []analyzer.Player, opts analyzer.Options) ([]analyzer.Profile, []staleProfile, []string) {
+	profiles, asOf, missing := []analyzer.Profile{}, []staleProfile{}, []string{}
+	for _, pl := range players {
+		p, at, ok := s.Store.LatestProfile(pl.RiotID())
+		if !ok {
+			missing = append(missing, pl.RiotID())
+			continue
+		}
+		p.Age(time.Since(at))
+		if opts.BalanceOn == analyzer.BalanceOnConservative {
+			p.BalanceScore = p.SkillInterval.Low
+		}
+		profiles = append(profiles, p)
+		asOf = append(asOf, staleProfile{Player: p.Name, AsOf: at, AgeHours: int(time.Since(at).Hours())})
+	}
+	s.applyOverrides(profiles)
+	return profiles, asOf, missing
+}
+
+// handleSimulate serves POST /simulate: the roster's split before and after
+// replacing players, from stored profiles only. It makes no Riot calls and
+// stores nothing, so organizers can try out a late joiner.
+func (s *Server) handleSimulate(w http.ResponseWriter, r *http.Request) {
+	var req simulateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	opts := analyzer.Options{
+		Mode: req.Mode, BalanceOn: req.BalanceOn, Objective: req.Objective, MaxLaneGap: req.MaxLaneGap,
+		LaneWeight: req.LaneWeight, Captains: req.Captains, TagGroups: s.tagGroups(req.TagRules),
+	}
+	if err := opts.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.Players) < 2 {
+		http.Error(w, "need at least 2 players", http.StatusBadRequest)
+		return
+	}
+	after, err := req.roster()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, roster := range [][]analyzer.Player{req.Players, after} {
+		if err := checkCaptains(opts.Captains, riotIDs(roster)); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	profiles, asOf, missing := s.cachedProfiles(append(append([]analyzer.Player{}, req.Players...), req.incoming()...), opts)
+	if len(missing) > 0 {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "no stored profile; analyze these players first", "missing": missing})
+		return
+	}
+	byName := map[string]analyzer.Profile{}
+	for _, p := range profiles {
+		byName[strings.ToLower(p.Name)] = p
+	}
+	split := func(roster []analyzer.Player) (analyzer.TeamSplit, bool) {
+		ps := make([]analyzer.Profile, len(roster))
+		for i, pl := range roster {
+			ps[i] = byName[strings.ToLower(pl.RiotID())]
+		}
+		ts := analyzer.Split(ps, opts)
+		if !ts.Validation.OK {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"error": analyzer.ErrCorruptSplit.Error(), "validation": ts.Validation})
+			return ts, false
+		}
+		analyzer.PredictWins(&ts, s.Analyzer.WinScale)
+		return ts, true
+	}
+	before, ok := split(req.Players)
+	if !ok {
+		return
+	}
+	next, ok := split(after)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"before":   before,
+		"after":    next,
+		"fairness": compareFairness(before, next),
+		"profiles": asOf,
+	})
+}
+
+// incoming are the players the replacements put on the roster.
+func (req simulateRequest) incoming() []analyzer.Player {
+	var out []analyzer.Player
+	for _, rp := range req.Replace {
+		if p, ok := parseRiotID(strings.TrimSpace(rp.In)); ok {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// compareFairness pairs the win predictions of the candidate splits both
+// rosters have.
+func compareFairness(before, after analyzer.TeamSplit) []fairness {
+	out := []fairness{}
+	for _, b := range before.WinPredictions {
+		for _, a := range after.WinPredictions {
+			if a.Split != b.Split {
+				continue
+			}
+			f := fairness{
+				Split: b.Split, DiffBefore: abs(b.ScoreDiff), DiffAfter: abs(a.ScoreDiff),
+				FavBefore: max(b.BlueWinPct, b.RedWinPct), FavAfter: max(a.BlueWinPct, a.RedWinPct),
+			}
+			f.Delta = f.DiffAfter - f.DiffBefore
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
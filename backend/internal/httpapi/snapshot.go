@@ -0,0 +1,73 @@
+package httpapi
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"lol_custom_skill_matching/internal/digest"
+)
+
+// handleSnapshot serves GET /snapshot/latest: the precomputed nightly
+// snapshot (member profiles, leaderboard, rank movements) as stored.
+func (s *Server) handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	if s.Snapshots == nil {
+		http.Error(w, "snapshots are disabled", http.StatusNotFound)
+		return
+	}
+	raw, at, ok := s.Snapshots.Latest()
+	if !ok {
+		http.Error(w, "no snapshot yet", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Last-Modified", at.UTC().Format(http.TimeFormat))
+	_, _ = w.Write(raw)
+}
+
+// handleTakeSnapshot serves POST /snapshot (organizer): take a snapshot now
+// instead of waiting for the night.
+func (s *Server) handleTakeSnapshot(w http.ResponseWriter, r *http.Request) {
+	if !s.organizer(w, r) {
+		return
+	}
+	if s.Snapshots == nil {
+		http.Error(w, "snapshots are disabled", http.StatusNotFound)
+		return
+	}
+	snap, err := s.Snapshots.Take(time.Now())
+	if err != nil {
+		// still served from memory; only the file failed
+		log.Printf("[req %s] snapshot: %v", RequestID(r.Context()), err)
+	}
+	writeJSON(w, http.StatusCreated, snap)
+}
+
+// handleDigestPreview serves GET /digest/preview?community=name (organizer):
+// the community's digest of the latest snapshot, rendered but not posted.
+func (s *Server) handleDigestPreview(w http.ResponseWriter, r *http.Request) {
+	if !s.organizer(w, r) {
+		return
+	}
+	if s.Digest == nil || s.Snapshots == nil {
+		http.Error(w, "digests are not configured (DIGEST_CONFIG)", http.StatusNotFound)
+		return
+	}
+	c, ok := s.Digest.Community(r.URL.Query().Get("community"))
+	if !ok {
+		http.Error(w, "unknown community", http.StatusNotFound)
+		return
+	}
+	snap, ok := s.Snapshots.Current()
+	if !ok {
+		http.Error(w, "no snapshot yet", http.StatusNotFound)
+		return
+	}
+	d := s.Digest.Data(c, snap)
+	text, err := digest.Render(c, d)
+	if err != nil {
+		http.Error(w, "template: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"community": c.Name, "period": c.Period, "empty": d.Empty(), "text": text})
+}
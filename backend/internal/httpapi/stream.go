@@ -0,0 +1,130 @@
+package httpapi
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Large results (includeRaw, big lobbies) are written incrementally instead of being
+// marshaled into one buffer: each top-level field, and each player inside team lists,
+// is encoded separately and flushed so clients receive data as it's produced.
+
+const ndjsonContentType = "application/x-ndjson"
+
+// wantsNDJSON reports whether the client asked for newline-delimited JSON
+// (?format=ndjson or Accept: application/x-ndjson).
+func wantsNDJSON(r *http.Request) bool {
+	return r.URL.Query().Get("format") == "ndjson" || strings.Contains(r.Header.Get("Accept"), ndjsonContentType)
+}
+
+func flushOf(w io.Writer) func() {
+	if f, ok := w.(http.Flusher); ok {
+		return f.Flush
+	}
+	return func() {}
+}
+
+// field is one top-level key of a streamed object; List marks values written element by element.
+type field struct {
+	Key   string
+	Value any
+	List  []any
+}
+
+func listOf[T any](items []T) []any {
+	out := make([]any, len(items))
+	for i := range items {
+		out[i] = items[i]
+	}
+	return out
+}
+
+// streamJSON writes fields as a single JSON object, flushing after every list element.
+func streamJSON(w io.Writer, fields []field) error {
+	flush := flushOf(w)
+	enc := json.NewEncoder(w)
+	for i, f := range fields {
+		sep := ","
+		if i == 0 {
+			sep = "{"
+		}
+		kb, _ := json.Marshal(f.Key)
+		if _, err := io.WriteString(w, sep+string(kb)+":"); err != nil {
+			return err
+		}
+		if f.List != nil {
+			if _, err := io.WriteString(w, "["); err != nil {
+				return err
+			}
+			for j, item := range f.List {
+				if j > 0 {
+					if _, err := io.WriteString(w, ","); err != nil {
+						return err
+					}
+				}
+				if err := enc.Encode(item); err != nil {
+					return err
+				}
+				flush()
+			}
+			if _, err := io.WriteString(w, "]"); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := enc.Encode(f.Value); err != nil {
+			return err
+		}
+		flush()
+	}
+	closing := "}\n"
+	if len(fields) == 0 {
+		closing = "{}\n"
+	}
+	_, err := io.WriteString(w, closing)
+	flush()
+	return err
+}
+
+// streamNDJSON writes one {"key","value"} line per field;
+// lists are expanded to one line per element with its index.
+func streamNDJSON(w io.Writer, fields []field) error {
+	flush := flushOf(w)
+	enc := json.NewEncoder(w)
+	type line struct {
+		Key   string `json:"key"`
+		Index *int   `json:"index,omitempty"`
+		Value any    `json:"value"`
+	}
+	for _, f := range fields {
+		if f.List != nil {
+			for i, item := range f.List {
+				idx := i
+				if err := enc.Encode(line{Key: f.Key, Index: &idx, Value: item}); err != nil {
+					return err
+				}
+				flush()
+			}
+			continue
+		}
+		if err := enc.Encode(line{Key: f.Key, Value: f.Value}); err != nil {
+			return err
+		}
+		flush()
+	}
+	return nil
+}
+
+// resultWriter streams the result to a result file through a buffered writer.
+func resultWriter(fields []field) func(io.Writer) error {
+	return func(w io.Writer) error {
+		bw := bufio.NewWriter(w)
+		if err := streamJSON(bw, fields); err != nil {
+			return err
+		}
+		return bw.Flush()
+	}
+}
@@ -0,0 +1,184 @@
+package httpapi
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/balance"
+	"lol_custom_skill_matching/internal/store"
+)
+
+// lobbySize is a full lobby: one player per role on each team.
+var lobbySize = 2 * len(balance.Roles)
+
+// Defaults and bounds of GET /lobbies/{id}/suggestions?limit=.
+const (
+	defaultSuggestions = 5
+	maxSuggestions     = 50
+)
+
+// substitute is a stored player suggested for a lobby's open slots.
+type substitute struct {
+	Player string `json:"player"`
+	Score  int    `json:"score"`
+	// ScoreGap is Score minus the lobby's average (0 when no confirmed
+	// player has a profile).
+	ScoreGap int `json:"score_gap"`
+	// Role is the open role they play best ("" when no role is open or they
+	// play none of them); Comfort is theirs on it (see balance.Comfort).
+	Role      string    `json:"role"`
+	Comfort   int       `json:"comfort"`
+	MainLanes []string  `json:"main_lanes"`
+	SubLanes  []string  `json:"sub_lanes"`
+	AsOf      time.Time `json:"as_of"` // when the profile was computed
+}
+
+// suggestionsView is the response of GET /lobbies/{id}/suggestions.
+type suggestionsView struct {
+	Lobby string `json:"lobby"`
+	// Confirmed are the lobby's players expected to come: all but those who
+	// answered no (and maybe, with maybe=exclude).
+	Confirmed []string `json:"confirmed"`
+	OpenSlots int      `json:"open_slots"`
+	// OpenRoles are the roles the confirmed players' first main lanes leave
+	// open, once per missing player.
+	OpenRoles   []string     `json:"open_roles"`
+	TargetScore int          `json:"target_score"` // the confirmed players' average
+	Missing     []string     `json:"missing"`      // confirmed players without a stored profile
+	Suggestions []substitute `json:"suggestions"`
+}
+
+// handleLobbySuggestions serves GET /lobbies/{id}/suggestions (organizers):
+// when fewer than 10 of the lobby's players are confirmed, the stored players
+// best filling the gap, from stored profiles only (no Riot calls). Those who
+// play an open role rank first, most comfortable first, then those closest to
+// the lobby's average score. ?limit= (default 5), ?maybe=warn|exclude as for
+// /balance and ?balanceOn=conservative to compare lower bounds.
+func (s *Server) handleLobbySuggestions(w http.ResponseWriter, r *http.Request) {
+	if !s.organizer(w, r) {
+		return
+	}
+	q := r.URL.Query()
+	limit := defaultSuggestions
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxSuggestions {
+			http.Error(w, "limit must be 1-"+strconv.Itoa(maxSuggestions), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	policy := q.Get("maybe")
+	if policy == "" {
+		policy = maybeWarn
+	}
+	if policy != maybeWarn && policy != maybeExclude {
+		http.Error(w, "invalid maybe (warn|exclude)", http.StatusBadRequest)
+		return
+	}
+	opts := analyzer.Options{BalanceOn: q.Get("balanceOn")}
+	if err := opts.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	l, ok := s.Store.Lobby(r.PathValue("id"))
+	if !ok {
+		http.Error(w, store.ErrLobbyNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	confirmed, _ := rsvpFilter(l, riotIDs(l.Players), policy)
+	v := suggestionsView{
+		Lobby: l.ID, Confirmed: confirmed, OpenSlots: max(0, lobbySize-len(confirmed)),
+		OpenRoles: []string{}, Missing: []string{}, Suggestions: []substitute{},
+	}
+	if v.Confirmed == nil {
+		v.Confirmed = []string{}
+	}
+	var players []analyzer.Player
+	for _, name := range confirmed {
+		if p, ok := parseRiotID(name); ok {
+			players = append(players, p)
+		}
+	}
+	profiles, _, missing := s.cachedProfiles(players, opts)
+	v.Missing = missing
+	if v.OpenSlots == 0 {
+		writeJSON(w, http.StatusOK, v)
+		return
+	}
+
+	open := map[string]int{}
+	for _, role := range balance.Roles {
+		open[role] = lobbySize / len(balance.Roles)
+	}
+	sum := 0
+	for _, p := range profiles {
+		bp := p.BalancePlayer(opts.BalanceOn)
+		sum += bp.Score
+		if len(bp.MainLanes) > 0 && open[bp.MainLanes[0]] > 0 {
+			open[bp.MainLanes[0]]--
+		}
+	}
+	for _, role := range balance.Roles {
+		for range open[role] {
+			v.OpenRoles = append(v.OpenRoles, role)
+		}
+	}
+	if len(profiles) > 0 {
+		v.TargetScore = sum / len(profiles)
+	}
+
+	// every stored player not in the lobby (declined or not); those without a
+	// stored profile (only a pool or an override) drop out in cachedProfiles
+	var pool []analyzer.Player
+	for _, rt := range s.Store.Ratings() {
+		p, ok := parseRiotID(rt.Player)
+		if !ok {
+			continue
+		}
+		if _, member := l.Member(p.RiotID()); member || s.Store.OptedOut(p.GameName, p.TagLine) {
+			continue
+		}
+		pool = append(pool, p)
+	}
+	candidates, asOf, _ := s.cachedProfiles(pool, opts)
+	for i, p := range candidates {
+		bp := p.BalancePlayer(opts.BalanceOn)
+		sub := substitute{
+			Player: p.Name, Score: bp.Score, MainLanes: bp.MainLanes, SubLanes: bp.SubLanes, AsOf: asOf[i].AsOf,
+		}
+		if len(profiles) > 0 {
+			sub.ScoreGap = bp.Score - v.TargetScore
+		}
+		for _, role := range balance.Roles {
+			if c := balance.Comfort(bp, role); open[role] > 0 && c > sub.Comfort {
+				sub.Role, sub.Comfort = role, c
+			}
+		}
+		if sub.MainLanes == nil {
+			sub.MainLanes = []string{}
+		}
+		if sub.SubLanes == nil {
+			sub.SubLanes = []string{}
+		}
+		v.Suggestions = append(v.Suggestions, sub)
+	}
+	slices.SortFunc(v.Suggestions, func(a, b substitute) int {
+		if a.Comfort != b.Comfort {
+			return b.Comfort - a.Comfort
+		}
+		if d := abs(a.ScoreGap) - abs(b.ScoreGap); d != 0 {
+			return d
+		}
+		return strings.Compare(strings.ToLower(a.Player), strings.ToLower(b.Player))
+	})
+	if len(v.Suggestions) > limit {
+		v.Suggestions = v.Suggestions[:limit]
+	}
+	writeJSON(w, http.StatusOK, v)
+}
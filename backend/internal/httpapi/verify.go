@@ -0,0 +1,154 @@
+package httpapi
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/store"
+)
+
+// challengeTTL is how long a player has to change their icon or enter the code.
+const challengeTTL = 15 * time.Minute
+
+// starterIcons are the profile icons (0-28) every account owns.
+const starterIcons = 29
+
+func randInt(n int) int {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0
+	}
+	return int(v.Int64())
+}
+
+// handleStartVerification serves POST /players/{riotId}/verification: it issues a
+// challenge ({"method": "icon"} by default, or "code") and a magic link
+// /verify/{token} the player opens once the icon or code is in place.
+func (s *Server) handleStartVerification(w http.ResponseWriter, r *http.Request) {
+	p, ok := parseRiotID(r.PathValue("riotId"))
+	if !ok {
+		http.Error(w, "invalid riot id (expected name#tag or name-tag)", http.StatusBadRequest)
+		return
+	}
+	var body struct {
+		Method string `json:"method"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+	}
+	if body.Method == "" {
+		body.Method = store.VerifyIcon
+	}
+	if body.Method != store.VerifyIcon && body.Method != store.VerifyCode {
+		http.Error(w, "invalid method (icon|code)", http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	account, found, err := s.Analyzer.Riot.AccountByRiotID(ctx, p.GameName, p.TagLine)
+	if err != nil {
+		http.Error(w, "account lookup failed", http.StatusBadGateway)
+		return
+	}
+	if !found {
+		http.Error(w, "riot id not found", http.StatusNotFound)
+		return
+	}
+	var iconID int
+	var code string
+	if body.Method == store.VerifyIcon {
+		sm, found, err := s.Analyzer.Riot.SummonerByPUUID(ctx, account.PUUID)
+		if err != nil || !found {
+			http.Error(w, "summoner lookup failed", http.StatusBadGateway)
+			return
+		}
+		// a starter icon other than the current one, so the change proves access
+		iconID = randInt(starterIcons - 1)
+		if iconID >= sm.ProfileIconID {
+			iconID++
+		}
+	} else {
+		code = fmt.Sprintf("LCSM-%06d", randInt(1000000))
+	}
+	c := s.Store.AddChallenge(p.GameName, p.TagLine, account.PUUID, body.Method, iconID, code, challengeTTL)
+	writeJSON(w, http.StatusCreated, map[string]any{"challenge": c, "link": "/verify/" + c.Token})
+}
+
+// handleCheckVerification serves GET/POST /verify/{token}: the magic link. It
+// checks the live icon or code and marks the Riot ID verified on success.
+func (s *Server) handleCheckVerification(w http.ResponseWriter, r *http.Request) {
+	token := r.PathValue("token")
+	c, ok := s.Store.ChallengeByToken(token)
+	if !ok {
+		http.Error(w, "unknown or expired verification link", http.StatusNotFound)
+		return
+	}
+	ctx := r.Context()
+	sm, found, err := s.Analyzer.Riot.SummonerByPUUID(ctx, c.PUUID)
+	if err != nil || !found {
+		http.Error(w, "summoner lookup failed", http.StatusBadGateway)
+		return
+	}
+	var passed bool
+	switch c.Method {
+	case store.VerifyIcon:
+		passed = sm.ProfileIconID == c.IconID
+	case store.VerifyCode:
+		got, found, err := s.Analyzer.Riot.ThirdPartyCode(ctx, sm)
+		if err != nil {
+			http.Error(w, "verification code lookup failed", http.StatusBadGateway)
+			return
+		}
+		passed = found && got == c.Code
+	}
+	if !passed {
+		writeJSON(w, http.StatusConflict, map[string]any{"verified": false, "challenge": c})
+		return
+	}
+	v, ok := s.Store.CompleteChallenge(token)
+	if !ok {
+		http.Error(w, "unknown or expired verification link", http.StatusNotFound)
+		return
+	}
+	// the member token is shown only here; verifying again replaces it
+	writeJSON(w, http.StatusOK, map[string]any{"verified": true, "verification": v, "member_token": v.Token})
+}
+
+// handleVerificationStatus serves GET /players/{riotId}/verification.
+func (s *Server) handleVerificationStatus(w http.ResponseWriter, r *http.Request) {
+	p, ok := parseRiotID(r.PathValue("riotId"))
+	if !ok {
+		http.Error(w, "invalid riot id (expected name#tag or name-tag)", http.StatusBadRequest)
+		return
+	}
+	resp := map[string]any{"name": p.RiotID(), "verified": false}
+	if v, ok := s.Store.Verified(p.GameName, p.TagLine); ok {
+		resp["verified"] = true
+		resp["verification"] = v
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// markVerified flags profiles whose Riot ID was proven by the same account.
+// A verification for a PUUID that no longer owns the Riot ID doesn't count.
+func (s *Server) markVerified(profiles []analyzer.Profile) (unverified []string) {
+	for i := range profiles {
+		id, ok := parseRiotID(profiles[i].Name)
+		if !ok {
+			continue
+		}
+		if v, ok := s.Store.Verified(id.GameName, id.TagLine); ok && v.PUUID == profiles[i].PUUID {
+			profiles[i].Verified = true
+		} else {
+			unverified = append(unverified, profiles[i].Name)
+		}
+	}
+	return unverified
+}
@@ -0,0 +1,221 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"lol_custom_skill_matching/internal/analyzer"
+)
+
+// Types of the messages GET /ws pushes.
+const (
+	jobEventStatus = "status" // the job's status: on subscribing, on retry and when it fails
+	jobEventPhase  = "phase"  // a phase of a player's analysis ended
+	jobEventTeams  = "teams"  // the teams are computed; the status has result_id (see resultView)
+	jobEventError  = "error"  // e.g. subscribing to an unknown job
+)
+
+// jobEvent is a message pushed to a job's subscribers.
+type jobEvent struct {
+	Type   string               `json:"type"`
+	Job    string               `json:"job"`
+	Phase  *analyzer.PhaseEvent `json:"phase,omitempty"`
+	Status *jobStatus           `json:"status,omitempty"`
+	Error  string               `json:"error,omitempty"`
+}
+
+// jobEventBuffer is how many events a slow subscriber can fall behind before
+// phase events are dropped for it; status events are never dropped.
+const jobEventBuffer = 256
+
+// wsPingInterval keeps idle connections open through proxies.
+const wsPingInterval = 30 * time.Second
+
+// wsWriteWait bounds every write, so a stalled client can't hold a
+// subscriber's goroutine.
+const wsWriteWait = 10 * time.Second
+
+// wsMaxMessage is the largest message a client may send (subscriptions are
+// tiny).
+const wsMaxMessage = 64 << 10
+
+// subscribe returns the job's events from now on; cancel stops them and
+// closes the channel.
+func (j *analyzeJob) subscribe() (<-chan jobEvent, func()) {
+	ch := make(chan jobEvent, jobEventBuffer)
+	j.smu.Lock()
+	if j.subs == nil {
+		j.subs = map[chan jobEvent]struct{}{}
+	}
+	j.subs[ch] = struct{}{}
+	j.smu.Unlock()
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			j.smu.Lock()
+			defer j.smu.Unlock()
+			delete(j.subs, ch)
+			close(ch)
+		})
+	}
+}
+
+// publish sends ev to every subscriber without waiting on any: a phase event
+// is dropped for a subscriber whose buffer is full, a status event takes the
+// place of its oldest event.
+func (j *analyzeJob) publish(ev jobEvent) {
+	j.smu.Lock()
+	defer j.smu.Unlock()
+	for ch := range j.subs {
+		select {
+		case ch <- ev:
+			continue
+		default:
+		}
+		if ev.Type == jobEventPhase {
+			continue
+		}
+		// publishers take turns under smu, so the freed slot stays free
+		select {
+		case <-ch:
+		default:
+		}
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// publishStatus publishes the job's status as typ; the caller holds j.mu.
+func (j *analyzeJob) publishStatus(typ string) {
+	st := j.statusLocked()
+	j.publish(jobEvent{Type: typ, Job: st.ID, Status: &st})
+}
+
+// handleWS serves GET /ws, a WebSocket pushing the progress of analyze jobs
+// (POST /analyze/jobs) instead of polling GET /analyze/jobs/{id}. Subscribe
+// with ?job=<id> or by sending {"subscribe": "<id>"} (several jobs are fine);
+// {"unsubscribe": "<id>"} stops a job's messages. Every message is a
+// jobEvent. Browsers may connect from the server's own origin or one of
+// AllowedOrigins (see originAllowed); others get a 403.
+func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
+	up := websocket.Upgrader{CheckOrigin: s.originAllowed}
+	conn, err := up.Upgrade(w, r, nil)
+	if err != nil {
+		// the Upgrader answered with the error
+		return
+	}
+	conn.SetReadLimit(wsMaxMessage)
+	rid := RequestID(r.Context())
+	done := make(chan struct{})
+	var mu, wmu sync.Mutex
+	cancels := map[string]func(){}
+	defer func() {
+		close(done)
+		mu.Lock()
+		for _, cancel := range cancels {
+			cancel()
+		}
+		mu.Unlock()
+		closing := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+		conn.WriteControl(websocket.CloseMessage, closing, time.Now().Add(wsWriteWait))
+		conn.Close()
+	}()
+	send := func(ev jobEvent) {
+		b, _ := json.Marshal(ev)
+		// a connection takes one writer at a time
+		wmu.Lock()
+		defer wmu.Unlock()
+		conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		if err := conn.WriteMessage(websocket.TextMessage, b); err != nil && !errors.Is(err, websocket.ErrCloseSent) {
+			log.Printf("[req %s] ws write: %v", rid, err)
+		}
+	}
+	var subscribe func(id string)
+	subscribe = func(id string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if _, ok := cancels[id]; ok {
+			return
+		}
+		j, ok := s.analyzeJobs().Get(id)
+		if handoff := s.handoffPending(); !ok && handoff != nil {
+			// the job may be the predecessor's: look again once it is handed over
+			go func() {
+				select {
+				case <-handoff:
+					subscribe(id)
+				case <-done:
+				}
+			}()
+			return
+		}
+		if !ok {
+			send(jobEvent{Type: jobEventError, Job: id, Error: "job not found"})
+			return
+		}
+		events, cancel := j.subscribe()
+		cancels[id] = cancel
+		st := j.snapshot()
+		send(jobEvent{Type: jobEventStatus, Job: id, Status: &st})
+		go func() {
+			for ev := range events {
+				send(ev)
+			}
+		}()
+	}
+	unsubscribe := func(id string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if cancel, ok := cancels[id]; ok {
+			cancel()
+			delete(cancels, id)
+		}
+	}
+
+	go func() {
+		t := time.NewTicker(wsPingInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+				if conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)) != nil {
+					return
+				}
+			}
+		}
+	}()
+	if id := strings.TrimSpace(r.URL.Query().Get("job")); id != "" {
+		subscribe(id)
+	}
+	for {
+		_, b, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var msg struct {
+			Subscribe   string `json:"subscribe"`
+			Unsubscribe string `json:"unsubscribe"`
+		}
+		if err := json.Unmarshal(b, &msg); err != nil {
+			send(jobEvent{Type: jobEventError, Error: "invalid json"})
+			continue
+		}
+		if id := strings.TrimSpace(msg.Subscribe); id != "" {
+			subscribe(id)
+		}
+		if id := strings.TrimSpace(msg.Unsubscribe); id != "" {
+			unsubscribe(id)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+
+	"lol_custom_skill_matching/internal/store"
+)
+
+func TestWSOrigin(t *testing.T) {
+	srv := &Server{Store: store.NewMemory(), AllowedOrigins: []string{"https://lol.example.com"}}
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws?job=missing"
+
+	tests := []struct {
+		name   string
+		origin string
+		want   int
+	}{
+		{"no origin", "", http.StatusSwitchingProtocols},
+		{"same origin", ts.URL, http.StatusSwitchingProtocols},
+		{"allowed origin", "https://lol.example.com", http.StatusSwitchingProtocols},
+		{"other origin", "https://evil.example.net", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			if tt.origin != "" {
+				h.Set("Origin", tt.origin)
+			}
+			conn, resp, err := websocket.DefaultDialer.Dial(wsURL, h)
+			if resp == nil {
+				t.Fatalf("dial: %v", err)
+			}
+			if resp.StatusCode != tt.want {
+				t.Fatalf("handshake = %d (%v), want %d", resp.StatusCode, err, tt.want)
+			}
+			if conn == nil {
+				return
+			}
+			defer conn.Close()
+			var ev jobEvent
+			if err := conn.ReadJSON(&ev); err != nil {
+				t.Fatal(err)
+			}
+			if ev.Type != jobEventError || ev.Job != "missing" {
+				t.Errorf("first message = %+v, want a job not found error", ev)
+			}
+		})
+	}
+}
+
+func TestCORSAllowedOrigins(t *testing.T) {
+	get := func(srv *Server, origin string) http.Header {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodOptions, "/results", nil)
+		req.Header.Set("Origin", origin)
+		srv.Handler().ServeHTTP(rec, req)
+		return rec.Header()
+	}
+	open := &Server{Store: store.NewMemory()}
+	if got := get(open, "https://anywhere.example").Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("without AllowedOrigins: Access-Control-Allow-Origin = %q, want *", got)
+	}
+	closed := &Server{Store: store.NewMemory(), AllowedOrigins: []string{"https://lol.example.com"}}
+	if got := get(closed, "https://lol.example.com").Get("Access-Control-Allow-Origin"); got != "https://lol.example.com" {
+		t.Errorf("allowed origin: Access-Control-Allow-Origin = %q", got)
+	}
+	if got := get(closed, "https://evil.example.net").Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("other origin: Access-Control-Allow-Origin = %q, want none", got)
+	}
+}
@@ -0,0 +1,157 @@
+// Package memwatch keeps the server within a memory limit, such as a
+// container's 256–512MB: it sets the Go runtime's soft limit a little below
+// it, and when the heap still climbs past High of it, it flushes the
+// in-process caches (match details, ranks, Riot answers), which refill from
+// Riot or the store as they are needed again.
+package memwatch
+
+import (
+	"context"
+	"log"
+	"os"
+	"runtime/debug"
+	"runtime/metrics"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"lol_custom_skill_matching/internal/clock"
+)
+
+// Defaults of New.
+const (
+	DefaultInterval = 10 * time.Second
+	DefaultHigh     = 0.8
+)
+
+// softLimit is the fraction of Limit given to the runtime as its soft memory
+// limit, leaving room for the memory the Go heap doesn't count.
+const softLimit = 0.9
+
+// Stats are the watchdog's totals since start, for GET /metrics.
+type Stats struct {
+	Limit     uint64    `json:"limit_bytes"`
+	HeapBytes uint64    `json:"heap_bytes"` // at the last check
+	Checks    int64     `json:"checks"`
+	Flushes   int64     `json:"flushes"`
+	LastFlush time.Time `json:"last_flush"`
+}
+
+// Watchdog checks the heap every Interval and runs Flush when it is over
+// High of Limit.
+type Watchdog struct {
+	// Limit is the memory the process may use, in bytes (0 = disabled).
+	Limit uint64
+	// High is the fraction of Limit the heap may reach before the caches are
+	// flushed.
+	High     float64
+	Interval time.Duration
+	// Flush empties the caches; each runs on the watchdog's goroutine.
+	Flush []func()
+	// Clock schedules the checks (nil = the system clock).
+	Clock clock.Clock
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+func New(limit uint64) *Watchdog {
+	return &Watchdog{Limit: limit, High: DefaultHigh, Interval: DefaultInterval}
+}
+
+// Enabled reports whether there is a limit to keep.
+func (w *Watchdog) Enabled() bool { return w.Limit > 0 }
+
+// Stats returns the totals so far.
+func (w *Watchdog) Stats() Stats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.stats
+}
+
+// Run sets the runtime's soft memory limit, unless GOMEMLIMIT does, and
+// checks the heap every Interval until ctx is done. It returns at once
+// without a limit.
+func (w *Watchdog) Run(ctx context.Context) {
+	if !w.Enabled() {
+		return
+	}
+	if os.Getenv("GOMEMLIMIT") == "" {
+		debug.SetMemoryLimit(int64(float64(w.Limit) * softLimit))
+	}
+	clk := clock.Or(w.Clock)
+	t := clk.NewTicker(w.Interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C():
+			w.Check(clk.Now())
+		}
+	}
+}
+
+// Check flushes the caches when the heap is over High of Limit and reports
+// whether it did.
+func (w *Watchdog) Check(now time.Time) bool {
+	heap := HeapBytes()
+	over := w.Enabled() && float64(heap) > float64(w.Limit)*w.High
+	if over {
+		for _, f := range w.Flush {
+			f()
+		}
+		debug.FreeOSMemory()
+		after := HeapBytes()
+		log.Printf("memory: heap at %dMB of %dMB; flushed the caches, now %dMB", heap>>20, w.Limit>>20, after>>20)
+		heap = after
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stats.Limit, w.stats.HeapBytes = w.Limit, heap
+	w.stats.Checks++
+	if over {
+		w.stats.Flushes++
+		w.stats.LastFlush = now
+	}
+	return over
+}
+
+// HeapBytes is the memory taken by heap objects, live or not yet swept.
+func HeapBytes() uint64 {
+	s := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	metrics.Read(s)
+	if s[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return s[0].Value.Uint64()
+}
+
+// cgroupFiles hold the container's memory limit: cgroup v2, then v1.
+var cgroupFiles = []string{"/sys/fs/cgroup/memory.max", "/sys/fs/cgroup/memory/memory.limit_in_bytes"}
+
+// noLimit is above any real limit; cgroup v1 reports "unlimited" as a number
+// near the top of int64.
+const noLimit = 1 << 50
+
+// ContainerLimit is the memory limit of the cgroup the process runs in (0 =
+// none, or not in a container).
+func ContainerLimit() uint64 {
+	for _, f := range cgroupFiles {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		v := strings.TrimSpace(string(b))
+		if v == "max" {
+			return 0
+		}
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil || n >= noLimit {
+			return 0
+		}
+		return n
+	}
+	return 0
+}
@@ -0,0 +1,244 @@
+// Package openapi builds OpenAPI 3 documents whose schemas are generated
+// from the Go types handlers decode and encode, following encoding/json's
+// rules (field names and omitempty from the json tags, embedded structs
+// flattened), so the document can't drift from the payloads it describes.
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Version is the OpenAPI version of the documents.
+const Version = "3.0.3"
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// PathItem holds a path's operations by method.
+type PathItem struct {
+	Get    *Operation `json:"get,omitempty"`
+	Put    *Operation `json:"put,omitempty"`
+	Post   *Operation `json:"post,omitempty"`
+	Delete *Operation `json:"delete,omitempty"`
+}
+
+type Operation struct {
+	Summary     string               `json:"summary"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"` // by status code
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // path, query or header
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Description string                `json:"description,omitempty"`
+	Required    bool                  `json:"required"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is the subset of the OpenAPI schema object the generator uses.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+}
+
+// String, Integer and Ref are shorthands for hand-written schemas.
+func String() *Schema         { return &Schema{Type: "string"} }
+func Integer() *Schema        { return &Schema{Type: "integer"} }
+func Ref(name string) *Schema { return &Schema{Ref: "#/components/schemas/" + name} }
+
+// Generator turns Go types into schemas, collecting the named struct types
+// under the document's components.
+type Generator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+	custom  map[reflect.Type]*Schema
+}
+
+func NewGenerator() *Generator {
+	return &Generator{schemas: map[string]*Schema{}, names: map[reflect.Type]string{}, custom: map[reflect.Type]*Schema{}}
+}
+
+// Define sets the schema of T, for types with their own JSON encoding that
+// reflection can't see through.
+func Define[T any](g *Generator, s *Schema) {
+	g.custom[reflect.TypeFor[T]()] = s
+}
+
+// Schemas are the components generated so far, by name.
+func (g *Generator) Schemas() map[string]*Schema { return g.schemas }
+
+// SchemaFor is the schema of T: a reference for a named struct.
+func SchemaFor[T any](g *Generator) *Schema { return g.Schema(reflect.TypeFor[T]()) }
+
+var (
+	timeType      = reflect.TypeFor[time.Time]()
+	durationType  = reflect.TypeFor[time.Duration]()
+	rawType       = reflect.TypeFor[json.RawMessage]()
+	marshalerType = reflect.TypeFor[json.Marshaler]()
+)
+
+// Schema is the schema of t's JSON.
+func (g *Generator) Schema(t reflect.Type) *Schema {
+	if s, ok := g.custom[t]; ok {
+		return s
+	}
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case durationType:
+		return &Schema{Type: "integer", Format: "int64", Description: "nanoseconds"}
+	case rawType:
+		return &Schema{}
+	}
+	if t.Implements(marshalerType) {
+		return &Schema{} // its own encoding; Define it to say more
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := g.Schema(t.Elem())
+		if s.Ref != "" {
+			return s // a $ref takes no siblings in OpenAPI 3.0; null is implied by absence
+		}
+		c := *s
+		c.Nullable = true
+		return &c
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Uint:
+		return &Schema{Type: "integer"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.Schema(t.Elem()), Nullable: true}
+	case reflect.Array:
+		n := t.Len()
+		return &Schema{Type: "array", Items: g.Schema(t.Elem()), MinItems: &n, MaxItems: &n}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.Schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		if name, ok := g.names[t]; ok {
+			return Ref(name)
+		}
+		name := g.name(t)
+		g.names[t] = name
+		g.schemas[name] = &Schema{} // placeholder for recursive types
+		*g.schemas[name] = *g.object(t)
+		return Ref(name)
+	}
+	return &Schema{} // interfaces: anything
+}
+
+// object is the schema of a struct's fields, embedded ones flattened as
+// encoding/json does. Fields without omitempty are always sent, so they are
+// required.
+func (g *Generator) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	g.fields(t, s)
+	return s
+}
+
+func (g *Generator) fields(t reflect.Type, s *Schema) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		ft := f.Type
+		if f.Anonymous && name == "" {
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.fields(ft, s)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = g.Schema(f.Type)
+		if !strings.Contains(","+opts+",", ",omitempty,") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+var unsafeName = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// name is the component name of t: its package and name, capitalized (an
+// unexported handler payload such as httpapi.analyzeRequest is still part of
+// the API).
+func (g *Generator) name(t reflect.Type) string {
+	pkg := t.PkgPath()
+	pkg = pkg[strings.LastIndex(pkg, "/")+1:]
+	r := []rune(t.Name())
+	r[0] = unicode.ToUpper(r[0])
+	return unsafeName.ReplaceAllString(pkg+"."+string(r), "_")
+}
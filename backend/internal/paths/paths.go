@@ -0,0 +1,107 @@
+// Package paths decides where the server and the CLI keep their files, so they
+// behave the same whatever the working directory is (a binary double-clicked
+// on Windows starts in its own folder, on macOS in the home directory):
+//
+//   - caches (champion.json) go under the user cache directory
+//     ($XDG_CACHE_HOME, ~/Library/Caches, %LocalAppData%),
+//   - data (match history, results, the SQLite store) under the user data
+//     directory ($XDG_DATA_HOME or ~/.local/share, ~/Library/Application
+//     Support, %AppData%),
+//   - configuration (.env) under the user config directory.
+//
+// CACHE_DIR and DATA_DIR override the first two. Without an override, a file
+// that already exists in the working directory keeps being used there, so
+// installs from before these directories existed don't lose their history.
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// App names the per-user subdirectories.
+const App = "lol_custom_skill_matching"
+
+// CacheDir is where re-downloadable files go.
+func CacheDir() string {
+	if d := os.Getenv("CACHE_DIR"); d != "" {
+		return d
+	}
+	return userDir(os.UserCacheDir)
+}
+
+// DataDir is where files worth keeping go.
+func DataDir() string {
+	if d := os.Getenv("DATA_DIR"); d != "" {
+		return d
+	}
+	return userDir(dataHome)
+}
+
+// ConfigDir is where a per-user .env is looked up.
+func ConfigDir() string { return userDir(os.UserConfigDir) }
+
+// CacheFile is the path of the cache file name; see the package doc.
+func CacheFile(name string) string { return resolve("CACHE_DIR", CacheDir(), name) }
+
+// DataFile is the path of the data file (or directory) name; see the package doc.
+func DataFile(name string) string { return resolve("DATA_DIR", DataDir(), name) }
+
+// Find returns the first of name in the working directory, next to the
+// executable and in DataDir that exists, or "" with the places searched.
+func Find(name string) (string, []string) {
+	tried := []string{name}
+	if exe, err := os.Executable(); err == nil {
+		tried = append(tried, filepath.Join(filepath.Dir(exe), name))
+	}
+	tried = append(tried, filepath.Join(DataDir(), name))
+	for _, p := range tried {
+		if fi, err := os.Stat(p); err == nil && !fi.IsDir() {
+			return p, tried
+		}
+	}
+	return "", tried
+}
+
+// resolve places name in dir, creating dir, unless env is unset and name
+// already exists in the working directory.
+func resolve(env, dir, name string) string {
+	if os.Getenv(env) == "" {
+		if _, err := os.Stat(name); err == nil {
+			return name
+		}
+	}
+	if dir == "." {
+		return name
+	}
+	_ = os.MkdirAll(dir, 0o755) // a failure shows up when the file is written
+	return filepath.Join(dir, name)
+}
+
+// userDir is App under base, or the working directory when the system has no
+// such directory (e.g. no $HOME in a container).
+func userDir(base func() (string, error)) string {
+	d, err := base()
+	if err != nil || d == "" {
+		return "."
+	}
+	return filepath.Join(d, App)
+}
+
+// dataHome follows the XDG base directory spec on Unix; Windows and macOS
+// keep data next to the configuration.
+func dataHome() (string, error) {
+	switch runtime.GOOS {
+	case "windows", "darwin", "ios", "plan9":
+		return os.UserConfigDir()
+	}
+	if d := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(d) {
+		return d, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share"), nil
+}
@@ -0,0 +1,333 @@
+// Package rankalert refreshes the solo queue rank of the players subscribed to
+// rank alerts (store.RankWatch) on a schedule and publishes an
+// events.RankChanged when it moved; Handle, subscribed to those, tells the
+// players through their Discord webhook, as a DM from the Discord bot, or
+// both. The lookups go through the analyzer's rank cache, which they keep
+// warm for the players' next analysis.
+package rankalert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/assets"
+	"lol_custom_skill_matching/internal/clock"
+	"lol_custom_skill_matching/internal/discord"
+	"lol_custom_skill_matching/internal/events"
+	"lol_custom_skill_matching/internal/riot"
+	"lol_custom_skill_matching/internal/signing"
+	"lol_custom_skill_matching/internal/store"
+)
+
+// DefaultInterval is how often the subscribed ranks are refreshed.
+const DefaultInterval = 30 * time.Minute
+
+// Stats are the refresher's totals since start, for GET /metrics.
+type Stats struct {
+	Runs    int64     `json:"runs"`
+	Checked int64     `json:"checked"` // ranks looked up
+	Changes int64     `json:"changes"` // rank moves worth an alert
+	Sent    int64     `json:"sent"`    // alerts delivered (webhook posts and DMs)
+	Errors  int64     `json:"errors"`
+	LastRun time.Time `json:"last_run"`
+}
+
+// Refresher looks up the rank of every subscribed player every Interval and
+// publishes a RankChanged event when it changed since the last check, which
+// Handle delivers.
+type Refresher struct {
+	Store    store.Store
+	Analyzer *analyzer.Analyzer
+	// Interval between refreshes (0 = disabled).
+	Interval time.Duration
+	// BotToken sends the DMs, or "secret:<name>" when Resolve reads it from
+	// the vault ("" = webhooks only). Members' webhooks are plain URLs.
+	BotToken string
+	Resolve  func(string) (string, error)
+	// Signer signs the webhook posts (nil = unsigned).
+	Signer *signing.Signer
+	API    string
+	HTTP   *http.Client
+	// Clock schedules the refreshes (nil = the system clock).
+	Clock clock.Clock
+	// Events receives a RankChanged event for every move; without it
+	// (nil) no alert is sent.
+	Events *events.Bus
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+func NewRefresher(st store.Store, an *analyzer.Analyzer, botToken string) *Refresher {
+	return &Refresher{
+		Store: st, Analyzer: an, Interval: DefaultInterval, BotToken: botToken,
+		API: discord.DefaultAPI, HTTP: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Enabled reports whether the refresh runs.
+func (r *Refresher) Enabled() bool { return r.Interval > 0 }
+
+// Stats returns the totals so far.
+func (r *Refresher) Stats() Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stats
+}
+
+// Run refreshes right away and then every Interval until ctx is done. It
+// returns at once when disabled.
+func (r *Refresher) Run(ctx context.Context) {
+	if !r.Enabled() {
+		return
+	}
+	clk := clock.Or(r.Clock)
+	t := clk.NewTicker(r.Interval)
+	defer t.Stop()
+	for {
+		r.Refresh(ctx, clk.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C():
+		}
+	}
+}
+
+// Refresh checks every subscription and reports how many ranks moved. The
+// first check of a subscription only records the rank. A rank that can't be
+// looked up is logged and checked again next time; a move is recorded by
+// Handle once delivered, so an undelivered one is published again.
+func (r *Refresher) Refresh(ctx context.Context, now time.Time) int {
+	checked, changes, errs := 0, 0, 0
+	for _, w := range r.Store.RankWatches() {
+		if ctx.Err() != nil {
+			break
+		}
+		gameName, tagLine, ok := splitRiotID(w.Player)
+		if !ok {
+			continue
+		}
+		// the Riot ID changed hands: the new owner hasn't subscribed
+		if v, ok := r.Store.Verified(gameName, tagLine); !ok || v.PUUID != w.PUUID {
+			continue
+		}
+		entries, err := r.Analyzer.RefreshLeagues(ctx, w.PUUID)
+		if err != nil {
+			log.Printf("rank alerts: %s: %v", w.Player, err)
+			errs++
+			continue
+		}
+		checked++
+		seen := Seen(entries)
+		if w.Last == nil || !Moved(*w.Last, seen, w.LPChanges) {
+			r.Store.RecordRank(gameName, tagLine, w.PUUID, seen, now, false)
+			continue
+		}
+		changes++
+		r.Events.Publish(events.Event{
+			Type: events.RankChanged, Time: now, Subject: w.Player, Text: Message(w.Player, *w.Last, seen),
+			Data: events.RankData{
+				From: assets.RankOf(w.Last.Tier, w.Last.Rank, w.Last.LP).Label,
+				To:   assets.RankOf(seen.Tier, seen.Rank, seen.LP).Label,
+				Tier: seen.Tier, Division: seen.Rank, LP: seen.LP, Wins: seen.Wins, Losses: seen.Losses,
+			},
+		})
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stats.Runs++
+	r.stats.Checked += int64(checked)
+	r.stats.Changes += int64(changes)
+	r.stats.Errors += int64(errs)
+	r.stats.LastRun = now
+	return changes
+}
+
+// Handle is the events.RankChanged subscriber: it sends the event's text
+// wherever the player's subscription asks and, once anyone got it, records
+// the new rank as notified. A subscription gone or replaced by another
+// account meanwhile gets nothing.
+func (r *Refresher) Handle(ev events.Event) {
+	d, ok := ev.Data.(events.RankData)
+	if ev.Type != events.RankChanged || !ok {
+		return
+	}
+	gameName, tagLine, ok := splitRiotID(ev.Subject)
+	if !ok {
+		return
+	}
+	watches := r.Store.RankWatches()
+	i := slices.IndexFunc(watches, func(w store.RankWatch) bool { return w.Player == ev.Subject })
+	if i < 0 {
+		return
+	}
+	w := watches[i]
+	if v, ok := r.Store.Verified(gameName, tagLine); !ok || v.PUUID != w.PUUID {
+		return
+	}
+	n, err := r.notify(context.Background(), w, ev.Text)
+	if err != nil {
+		log.Printf("rank alerts: %s: %v", w.Player, err)
+	}
+	if n > 0 {
+		seen := store.RankSeen{Tier: d.Tier, Rank: d.Division, LP: d.LP, Wins: d.Wins, Losses: d.Losses}
+		r.Store.RecordRank(gameName, tagLine, w.PUUID, seen, ev.Time, true)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stats.Sent += int64(n)
+	if err != nil {
+		r.stats.Errors++
+	}
+}
+
+// Seen is the solo queue rank in entries (the zero RankSeen when unranked).
+func Seen(entries []riot.LeagueEntry) store.RankSeen {
+	e, ok := riot.SoloEntry(entries)
+	if !ok {
+		return store.RankSeen{}
+	}
+	return store.RankSeen{Tier: e.Tier, Rank: e.Rank, LP: e.LeaguePoints, Wins: e.Wins, Losses: e.Losses}
+}
+
+// Moved reports whether the rank went from old to seen: a new tier or
+// division, getting ranked or dropping out, and with lp any LP change too.
+func Moved(old, seen store.RankSeen, lp bool) bool {
+	if old.Tier != seen.Tier || old.Rank != seen.Rank {
+		return true
+	}
+	return lp && old.LP != seen.LP
+}
+
+// Message is the alert text for player's move from old to seen.
+func Message(player string, old, seen store.RankSeen) string {
+	from := assets.RankOf(old.Tier, old.Rank, old.LP).Label
+	to := assets.RankOf(seen.Tier, seen.Rank, seen.LP).Label
+	var what string
+	switch {
+	case old.Tier == "":
+		what = "ranked"
+	case seen.Tier == "":
+		what = "unranked"
+	case old.Tier != seen.Tier || old.Rank != seen.Rank:
+		what = "demoted"
+		if riot.RankScore(seen.Tier, seen.Rank, 0) > riot.RankScore(old.Tier, old.Rank, 0) {
+			what = "promoted"
+		}
+	default:
+		what = fmt.Sprintf("%+d LP", seen.LP-old.LP)
+	}
+	msg := fmt.Sprintf("%s: %s → %s (%s)", player, from, to, what)
+	if seen.Tier != "" {
+		msg += fmt.Sprintf(", %dW %dL", seen.Wins, seen.Losses)
+	}
+	return msg
+}
+
+// notify sends text wherever w asks and reports how many got it.
+func (r *Refresher) notify(ctx context.Context, w store.RankWatch, text string) (int, error) {
+	sent := 0
+	var firstErr error
+	if w.Webhook != "" {
+		if err := r.postWebhook(ctx, w.Webhook, text); err != nil {
+			firstErr = fmt.Errorf("webhook: %w", err)
+		} else {
+			sent++
+		}
+	}
+	if w.DiscordUserID != "" {
+		if err := r.sendDM(ctx, w.DiscordUserID, text); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("discord dm: %w", err)
+			}
+		} else {
+			sent++
+		}
+	}
+	return sent, firstErr
+}
+
+// postWebhook posts text as a Discord webhook message.
+func (r *Refresher) postWebhook(ctx context.Context, webhook, text string) error {
+	body, _ := json.Marshal(map[string]string{"content": text})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	r.Signer.SignRequest(req, body)
+	_, err = r.do(req)
+	return err
+}
+
+// sendDM opens the bot's DM channel with the user and posts text there.
+func (r *Refresher) sendDM(ctx context.Context, userID, text string) error {
+	if r.BotToken == "" {
+		return fmt.Errorf("no DISCORD_BOT_TOKEN")
+	}
+	token := r.BotToken
+	if r.Resolve != nil {
+		var err error
+		if token, err = r.Resolve(token); err != nil {
+			return err
+		}
+	}
+	var channel struct {
+		ID string `json:"id"`
+	}
+	if err := r.botPost(ctx, token, "/users/@me/channels", map[string]string{"recipient_id": userID}, &channel); err != nil {
+		return err
+	}
+	return r.botPost(ctx, token, "/channels/"+url.PathEscape(channel.ID)+"/messages", map[string]string{"content": text}, nil)
+}
+
+// botPost posts v as JSON to the bot API's path and decodes the answer into
+// out (nil = ignored).
+func (r *Refresher) botPost(ctx context.Context, token, path string, v, out any) error {
+	body, _ := json.Marshal(v)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.API+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bot "+token)
+	b, err := r.do(req)
+	if err != nil || out == nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+func (r *Refresher) do(req *http.Request) ([]byte, error) {
+	resp, err := r.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return b, err
+}
+
+// splitRiotID splits a stored "name#tag".
+func splitRiotID(riotID string) (gameName, tagLine string, ok bool) {
+	i := strings.LastIndex(riotID, "#")
+	if i <= 0 || i == len(riotID)-1 {
+		return "", "", false
+	}
+	return riotID[:i], riotID[i+1:], true
+}
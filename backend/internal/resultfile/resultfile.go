@@ -0,0 +1,156 @@
+// Package resultfile keeps a copy of every analyze result on disk, one file
+// per result (<dir>/<result id>.json), so concurrent analyses never overwrite
+// each other. A retention policy prunes the oldest files by age, count and
+// total size after each write.
+package resultfile
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const ext = ".json"
+
+// Retention bounds what a Dir keeps; zero fields are unlimited.
+type Retention struct {
+	MaxAge   time.Duration
+	MaxFiles int
+	MaxBytes int64
+}
+
+// Dir is a directory of result files. It is safe for concurrent use.
+type Dir struct {
+	Path      string
+	Retention Retention
+
+	mu sync.Mutex // serializes pruning
+}
+
+// New returns a Dir for path; the directory is created on the first write.
+func New(path string, r Retention) *Dir { return &Dir{Path: path, Retention: r} }
+
+// File is where the result id is (or would be) written.
+func (d *Dir) File(id string) string { return filepath.Join(d.Path, id+ext) }
+
+// Info describes a stored result file.
+type Info struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// Stat returns the file of result id; ok is false once it was pruned (or never written).
+func (d *Dir) Stat(id string) (Info, bool) {
+	if !validID(id) {
+		return Info{}, false
+	}
+	fi, err := os.Stat(d.File(id))
+	if err != nil || !fi.Mode().IsRegular() {
+		return Info{}, false
+	}
+	return Info{Path: d.File(id), Size: fi.Size(), ModTime: fi.ModTime()}, true
+}
+
+// Write stores result id through write and then applies the retention policy.
+// The file appears atomically: readers never see a partial result.
+func (d *Dir) Write(id string, write func(io.Writer) error) (string, error) {
+	if !validID(id) {
+		return "", fmt.Errorf("invalid result id %q", id)
+	}
+	if err := os.MkdirAll(d.Path, 0o755); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(d.Path, ".tmp-"+id+"-*")
+	if err != nil {
+		return "", err
+	}
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	path := d.File(id)
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if _, err := d.Prune(time.Now()); err != nil {
+		return path, fmt.Errorf("pruning %s: %w", d.Path, err)
+	}
+	return path, nil
+}
+
+// List returns the stored files, oldest first.
+func (d *Dir) List() ([]Info, error) {
+	entries, err := os.ReadDir(d.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var files []Info
+	for _, e := range entries {
+		if !e.Type().IsRegular() || !strings.HasSuffix(e.Name(), ext) || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			continue // removed meanwhile
+		}
+		files = append(files, Info{Path: filepath.Join(d.Path, e.Name()), Size: fi.Size(), ModTime: fi.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].ModTime.Before(files[j].ModTime) })
+	return files, nil
+}
+
+// Prune removes result files older than MaxAge, then the oldest ones until at
+// most MaxFiles totalling at most MaxBytes remain. It returns how many it removed.
+func (d *Dir) Prune(now time.Time) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	files, err := d.List()
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, f := range files {
+		total += f.Size
+	}
+
+	r := d.Retention
+	removed := 0
+	var errs []error
+	for _, f := range files {
+		expired := r.MaxAge > 0 && now.Sub(f.ModTime) > r.MaxAge
+		tooMany := r.MaxFiles > 0 && len(files)-removed > r.MaxFiles
+		tooBig := r.MaxBytes > 0 && total > r.MaxBytes
+		if !expired && !tooMany && !tooBig {
+			break // the rest are newer
+		}
+		if err := os.Remove(f.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+			continue
+		}
+		removed++
+		total -= f.Size
+	}
+	return removed, errors.Join(errs...)
+}
+
+// validID keeps ids from escaping the directory.
+func validID(id string) bool {
+	return id != "" && !strings.ContainsAny(id, `/\.`) && id == filepath.Base(id)
+}
@@ -0,0 +1,95 @@
+// Package retention prunes stored data past its retention (old match
+// summaries, results of past seasons) in the background, so the store and
+// its database don't grow without bound.
+package retention
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"lol_custom_skill_matching/internal/clock"
+	"lol_custom_skill_matching/internal/store"
+)
+
+// DefaultInterval is how often the pruner runs.
+const DefaultInterval = 6 * time.Hour
+
+// Stats are the pruner's totals since start, for GET /metrics.
+type Stats struct {
+	Runs    int64        `json:"runs"`
+	Pruned  store.Pruned `json:"pruned"`
+	LastRun time.Time    `json:"last_run"`
+}
+
+// Pruner applies Policy to Store every Interval.
+type Pruner struct {
+	Store    store.Store
+	Policy   store.Retention
+	Interval time.Duration
+	// StoreFile receives the match store after a run that pruned matches
+	// ("" = memory only or a database store).
+	StoreFile string
+	// Clock schedules the runs (nil = the system clock).
+	Clock clock.Clock
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+func NewPruner(st store.Store, policy store.Retention) *Pruner {
+	return &Pruner{Store: st, Policy: policy, Interval: DefaultInterval}
+}
+
+// Enabled reports whether the policy prunes anything.
+func (p *Pruner) Enabled() bool {
+	return p.Policy.Matches > 0 || p.Policy.ResultSeasons > 0
+}
+
+// Stats returns the totals so far.
+func (p *Pruner) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stats
+}
+
+// Prune applies the policy once.
+func (p *Pruner) Prune(now time.Time) store.Pruned {
+	n := p.Store.Prune(p.Policy, now)
+	if n.Matches > 0 && p.StoreFile != "" {
+		if err := p.Store.SaveMatches(p.StoreFile); err != nil {
+			log.Printf("retention: saving %s: %v", p.StoreFile, err)
+		}
+	}
+	p.mu.Lock()
+	p.stats.Runs++
+	p.stats.Pruned.Matches += n.Matches
+	p.stats.Pruned.Results += n.Results
+	p.stats.Pruned.Lobbies += n.Lobbies
+	p.stats.LastRun = now
+	p.mu.Unlock()
+	if n.Matches+n.Results+n.Lobbies > 0 {
+		log.Printf("retention: pruned %d matches, %d results, %d lobbies", n.Matches, n.Results, n.Lobbies)
+	}
+	return n
+}
+
+// Run prunes right away and then every Interval until ctx is done. It returns
+// at once when the policy keeps everything.
+func (p *Pruner) Run(ctx context.Context) {
+	if !p.Enabled() {
+		return
+	}
+	clk := clock.Or(p.Clock)
+	t := clk.NewTicker(p.Interval)
+	defer t.Stop()
+	for {
+		p.Prune(clk.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C():
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package riot
+
+import (
+	"context"
+	"time"
+)
+
+// API groups a Client's endpoints by Riot API, for callers that only need a
+// few of them (the CLI, tools). They all share the Client's key, limiter and
+// retry policy.
+type API struct {
+	Account AccountClient
+	Match   MatchClient
+	League  LeagueClient
+	Mastery MasteryClient
+}
+
+// API returns c's endpoints grouped by Riot API.
+func (c *Client) API() API {
+	return API{Account: AccountClient{c}, Match: MatchClient{c}, League: LeagueClient{c}, Mastery: MasteryClient{c}}
+}
+
+// AccountClient calls account-v1.
+type AccountClient struct{ c *Client }
+
+// ByRiotID resolves a Riot ID; found=false when the account doesn't exist.
+func (a AccountClient) ByRiotID(ctx context.Context, gameName, tagLine string) (Account, bool, error) {
+	return a.c.AccountByRiotID(ctx, gameName, tagLine)
+}
+
+// MatchClient calls match-v5.
+type MatchClient struct{ c *Client }
+
+// IDs lists recent match ids, newest first.
+func (m MatchClient) IDs(ctx context.Context, puuid string, start, count int) ([]string, error) {
+	return m.c.MatchIDs(ctx, puuid, start, count)
+}
+
+// IDsSince lists match ids played at or after since, newest first.
+func (m MatchClient) IDsSince(ctx context.Context, puuid string, since time.Time, start, count int) ([]string, error) {
+	return m.c.MatchIDsSince(ctx, puuid, since, start, count)
+}
+
+// Get fetches match details; nil when the match doesn't exist.
+func (m MatchClient) Get(ctx context.Context, matchID string) (*Match, error) {
+	return m.c.Match(ctx, matchID)
+}
+
+// LeagueClient calls league-v4.
+type LeagueClient struct{ c *Client }
+
+// Entries returns ranked entries for a puuid (empty when unranked).
+func (l LeagueClient) Entries(ctx context.Context, puuid string) ([]LeagueEntry, error) {
+	return l.c.LeagueEntries(ctx, puuid)
+}
+
+// SoloScore returns the puuid's RANKED_SOLO_5x5 score (see RankScore);
+// ok=false when unranked.
+func (l LeagueClient) SoloScore(ctx context.Context, puuid string) (score int, ok bool, err error) {
+	entries, err := l.c.LeagueEntries(ctx, puuid)
+	if err != nil {
+		return 0, false, err
+	}
+	score, ok = SoloScore(entries)
+	return score, ok, nil
+}
+
+// MasteryClient calls champion-mastery-v4.
+type MasteryClient struct{ c *Client }
+
+// ByPUUID returns all champion masteries for a puuid.
+func (m MasteryClient) ByPUUID(ctx context.Context, puuid string) ([]Mastery, error) {
+	return m.c.Masteries(ctx, puuid)
+}
@@ -0,0 +1,108 @@
+package riot
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"lol_custom_skill_matching/internal/clock"
+)
+
+// ErrUnavailable is returned without calling Riot while the breaker is open.
+var ErrUnavailable = errors.New("riot: api unavailable (circuit open)")
+
+// Breaker states.
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open" // a trial request is deciding
+)
+
+// Breaker stops calling Riot after Threshold requests in a row failed with
+// 5xx or network errors (retries spent), as during maintenance. While open,
+// requests fail fast with ErrUnavailable; every Cooldown one trial request goes
+// through and its success closes the breaker again. 404 and 429 answers mean
+// Riot is up and count as successes.
+type Breaker struct {
+	Threshold int
+	Cooldown  time.Duration
+	// Clock times the cooldown (nil = the system clock).
+	Clock clock.Clock
+
+	mu       sync.Mutex
+	failures int // consecutive
+	openedAt time.Time
+	trial    bool
+}
+
+// BreakerStats is the breaker's current state for /stats and /metrics.
+type BreakerStats struct {
+	State    string    `json:"state"`
+	Failures int       `json:"failures"` // consecutive failed requests
+	OpenedAt time.Time `json:"opened_at,omitzero"`
+	RetryAt  time.Time `json:"retry_at,omitzero"` // next trial request
+}
+
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{Threshold: threshold, Cooldown: cooldown}
+}
+
+func (b *Breaker) open() bool { return b.failures >= b.Threshold }
+
+// allow reports whether a request may go out, taking the trial slot when the
+// cooldown has passed.
+func (b *Breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.open() {
+		return true
+	}
+	if b.trial || clock.Since(clock.Or(b.Clock), b.openedAt) < b.Cooldown {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+// record settles a request allowed by allow. Cancelled and skipped requests
+// and a rejected key say nothing about Riot being down and only free the
+// trial slot.
+func (b *Breaker) record(ctx context.Context, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+	switch {
+	case err == nil:
+		b.failures = 0
+	case ctx.Err() != nil || errors.Is(err, ErrSkipped) || errors.Is(err, ErrKeyInvalid):
+	default:
+		b.failures++
+		if b.open() {
+			b.openedAt = clock.Or(b.Clock).Now()
+		}
+	}
+}
+
+// Open reports whether Riot is being treated as down (false for a nil Breaker).
+func (b *Breaker) Open() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.open()
+}
+
+func (b *Breaker) Stats() BreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	st := BreakerStats{State: BreakerClosed, Failures: b.failures}
+	if b.open() {
+		st.State, st.OpenedAt, st.RetryAt = BreakerOpen, b.openedAt, b.openedAt.Add(b.Cooldown)
+		if b.trial {
+			st.State = BreakerHalfOpen
+		}
+	}
+	return st
+}
@@ -0,0 +1,111 @@
+package riot
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"lol_custom_skill_matching/internal/clock"
+)
+
+func TestBreaker(t *testing.T) {
+	down := errors.New("503")
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// a step advances the clock, then asks allow and, when allowed and err
+	// is set, records the request's outcome
+	type step struct {
+		advance   time.Duration
+		wantAllow bool
+		err       error // nil = success; ignored when not allowed
+		ctx       context.Context
+		wantState string
+	}
+	ok := func(state string) step { return step{wantAllow: true, wantState: state} }
+	fail := func(err error, state string) step { return step{wantAllow: true, err: err, wantState: state} }
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"stays closed below the threshold", []step{
+			fail(down, BreakerClosed), fail(down, BreakerClosed), ok(BreakerClosed),
+			fail(down, BreakerClosed), fail(down, BreakerClosed), ok(BreakerClosed),
+		}},
+		{"opens at the threshold and fails fast", []step{
+			fail(down, BreakerClosed), fail(down, BreakerClosed), fail(down, BreakerOpen),
+			{advance: 30 * time.Second, wantAllow: false, wantState: BreakerOpen},
+			{advance: 29 * time.Second, wantAllow: false, wantState: BreakerOpen},
+		}},
+		{"a successful trial closes it", []step{
+			fail(down, BreakerClosed), fail(down, BreakerClosed), fail(down, BreakerOpen),
+			{advance: time.Minute, wantAllow: true, wantState: BreakerClosed},
+			ok(BreakerClosed),
+		}},
+		{"a failed trial waits another cooldown", []step{
+			fail(down, BreakerClosed), fail(down, BreakerClosed), fail(down, BreakerOpen),
+			{advance: time.Minute, wantAllow: true, err: down, wantState: BreakerOpen},
+			{advance: 59 * time.Second, wantAllow: false, wantState: BreakerOpen},
+			{advance: time.Second, wantAllow: true, wantState: BreakerClosed},
+		}},
+		{"skips, rejected keys and cancellations don't count", []step{
+			fail(down, BreakerClosed), fail(down, BreakerClosed),
+			fail(ErrSkipped, BreakerClosed), fail(ErrKeyInvalid, BreakerClosed),
+			{wantAllow: true, err: down, ctx: cancelled, wantState: BreakerClosed},
+			fail(down, BreakerOpen),
+		}},
+		{"a skipped trial frees the slot", []step{
+			fail(down, BreakerClosed), fail(down, BreakerClosed), fail(down, BreakerOpen),
+			{advance: time.Minute, wantAllow: true, err: ErrSkipped, wantState: BreakerOpen},
+			{wantAllow: true, wantState: BreakerClosed},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk := clock.NewFake(time.Unix(1_700_000_000, 0))
+			b := NewBreaker(3, time.Minute)
+			b.Clock = clk
+			for i, s := range tt.steps {
+				clk.Advance(s.advance)
+				allowed := b.allow()
+				if allowed != s.wantAllow {
+					t.Fatalf("step %d: allow = %t, want %t", i, allowed, s.wantAllow)
+				}
+				if allowed {
+					ctx := s.ctx
+					if ctx == nil {
+						ctx = context.Background()
+					}
+					b.record(ctx, s.err)
+				}
+				if st := b.Stats(); st.State != s.wantState {
+					t.Fatalf("step %d: state %s, want %s", i, st.State, s.wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1_700_000_000, 0))
+	b := NewBreaker(1, time.Minute)
+	b.Clock = clk
+	b.allow()
+	b.record(context.Background(), errors.New("503"))
+	opened := clk.Now()
+	st := b.Stats()
+	if st.State != BreakerOpen || !st.OpenedAt.Equal(opened) || !st.RetryAt.Equal(opened.Add(time.Minute)) {
+		t.Fatalf("stats %+v, want open since %s until %s", st, opened, opened.Add(time.Minute))
+	}
+	clk.Advance(time.Minute)
+	if !b.allow() {
+		t.Fatal("no trial after the cooldown")
+	}
+	if b.allow() {
+		t.Error("a second request went out during the trial")
+	}
+	if st := b.Stats(); st.State != BreakerHalfOpen {
+		t.Errorf("state %s during the trial, want %s", st.State, BreakerHalfOpen)
+	}
+}
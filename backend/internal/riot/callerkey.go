@@ -0,0 +1,92 @@
+package riot
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// KeyHeader carries a caller's own Riot API key, for servers that accept it
+// (shared instances where the operator's quota is scarce).
+const KeyHeader = "X-Riot-Key"
+
+type callerKeyCtx struct{}
+
+type callerKey struct {
+	key     string
+	limiter *Limiter
+}
+
+// WithKey makes the Riot calls made under ctx use key, paced by limiter,
+// instead of the client's key, limiter and tenant scheduler. Responses still
+// go through the client's cache and breaker.
+func WithKey(ctx context.Context, key string, limiter *Limiter) context.Context {
+	return context.WithValue(ctx, callerKeyCtx{}, callerKey{key: key, limiter: limiter})
+}
+
+// UsesCallerKey reports whether ctx carries a key set by WithKey.
+func UsesCallerKey(ctx context.Context) bool {
+	_, ok := ctx.Value(callerKeyCtx{}).(callerKey)
+	return ok
+}
+
+func callerKeyFrom(ctx context.Context) (callerKey, bool) {
+	ck, ok := ctx.Value(callerKeyCtx{}).(callerKey)
+	return ck, ok
+}
+
+// callerKeyError is returned when Riot rejects a caller's key. Unlike the
+// server key's rejection it changes nothing for other requests.
+func callerKeyError(status int) error {
+	return fmt.Errorf("%w (HTTP %d): the key in %s is invalid or expired", ErrKeyInvalid, status, KeyHeader)
+}
+
+// KeyLimiters gives every caller key its own limiter, so one caller's quota
+// isn't spent by another. Limiters unused for IdleTTL are dropped.
+type KeyLimiters struct {
+	// New makes the limiter of a key seen for the first time.
+	New     func() *Limiter
+	IdleTTL time.Duration
+
+	mu sync.Mutex
+	m  map[[sha256.Size]byte]*keyLimiter
+}
+
+type keyLimiter struct {
+	limiter  *Limiter
+	lastUsed time.Time
+}
+
+// NewKeyLimiters paces each key at the development key limits (see NewLimiter).
+func NewKeyLimiters() *KeyLimiters {
+	return &KeyLimiters{New: NewLimiter, IdleTTL: time.Hour, m: map[[sha256.Size]byte]*keyLimiter{}}
+}
+
+// For returns key's limiter. Keys are held by hash only.
+func (k *KeyLimiters) For(key string) *Limiter {
+	id := sha256.Sum256([]byte(key))
+	now := time.Now()
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for h, kl := range k.m {
+		if now.Sub(kl.lastUsed) > k.IdleTTL {
+			delete(k.m, h)
+		}
+	}
+	kl, ok := k.m[id]
+	if !ok {
+		kl = &keyLimiter{limiter: k.New()}
+		k.m[id] = kl
+	}
+	kl.lastUsed = now
+	return kl.limiter
+}
+
+// Len is how many caller keys have a limiter.
+func (k *KeyLimiters) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.m)
+}
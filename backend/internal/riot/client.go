@@ -0,0 +1,316 @@
+package riot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"lol_custom_skill_matching/internal/clock"
+)
+
+const (
+	DefaultPlatform     = "jp1"
+	DefaultRegionalHost = "https://asia.api.riotgames.com"
+	DefaultPlatformHost = "https://jp1.api.riotgames.com"
+)
+
+// ErrSkipped is returned when SkipOnLimit is set and a request hit 429/5xx/network errors.
+var ErrSkipped = errors.New("riot: request skipped")
+
+// ErrRetriesExhausted is returned when 5xx/network errors outlasted MaxRetry tries.
+var ErrRetriesExhausted = errors.New("riot: request failed after retries")
+
+// Client calls the Riot API with the shared limiter and the retry policy:
+// 429 slows the limiter down (see Limiter.Throttled) and retries without limit, 5xx/network errors back off
+// exponentially up to MaxRetry tries, 404 is a normal "no data" answer.
+type Client struct {
+	APIKey  string
+	HTTP    *http.Client
+	Limiter *Limiter
+	// Scheduler, when set, shares Limiter between tenants (see TenantFrom).
+	Scheduler *FairScheduler
+	// Breaker, when set, fails requests fast while Riot is down.
+	Breaker *Breaker
+	// SLO, when set, tracks each endpoint's latency and error rate.
+	SLO          *SLO
+	MaxRetry     int
+	SkipOnLimit  bool
+	RegionalHost string
+	PlatformHost string
+	// Probe lists the platforms FindPlatform tries for accounts missing from
+	// PlatformHost (nil = no probing).
+	Probe []string
+	// Cache, when set, answers repeated requests for each endpoint's TTL in
+	// CacheTTLs (nil = DefaultCacheTTLs; endpoints without a TTL aren't cached).
+	Cache     ResponseCache
+	CacheTTLs map[string]time.Duration
+	// Matches, when set, keeps match details for good, ahead of Cache.
+	Matches MatchCache
+	// OnKeyInvalid, when set, is called when Riot starts rejecting the API
+	// key (see KeyStatus). It runs on the request's goroutine.
+	OnKeyInvalid func(KeyStatus)
+	// Hooks, when set, follow each request's tries (e.g. a CLI progress line).
+	Hooks Hooks
+	// Clock times the retry waits (nil = Limiter's clock).
+	Clock clock.Clock
+
+	shardMu sync.Mutex
+	shards  map[string]Platform // puuid -> platform found by FindPlatform
+
+	cacheMu sync.Mutex
+	lookups map[string]*[2]int64 // endpoint -> response cache hits, misses
+
+	keyMu       sync.Mutex
+	keyRejected time.Time // zero while the key is accepted
+	keyStatus   int
+}
+
+// Hooks are told about a Client's requests on the request's goroutine; any
+// of them may be nil.
+type Hooks struct {
+	// Attempt is called before each try with how long pacing held it.
+	Attempt func(waited time.Duration)
+	// Done is called when a request is answered (200 or 404).
+	Done func()
+	// Throttled is called on a 429 with the wait before the retry.
+	Throttled func(wait time.Duration)
+}
+
+func (h Hooks) attempt(waited time.Duration) {
+	if h.Attempt != nil {
+		h.Attempt(waited)
+	}
+}
+
+func (h Hooks) done() {
+	if h.Done != nil {
+		h.Done()
+	}
+}
+
+func (h Hooks) throttled(wait time.Duration) {
+	if h.Throttled != nil {
+		h.Throttled(wait)
+	}
+}
+
+func NewClient(apiKey string, limiter *Limiter) *Client {
+	if limiter == nil {
+		limiter = NewLimiter()
+	}
+	return &Client{
+		APIKey:       apiKey,
+		HTTP:         &http.Client{},
+		Limiter:      limiter,
+		MaxRetry:     3,
+		RegionalHost: DefaultRegionalHost,
+		PlatformHost: DefaultPlatformHost,
+		Probe:        DefaultProbeOrder,
+	}
+}
+
+// clock is c.Clock, or else its limiter's.
+func (c *Client) clock() clock.Clock {
+	if c.Clock == nil && c.Limiter != nil {
+		return c.Limiter.clock
+	}
+	return clock.Or(c.Clock)
+}
+
+// Do performs a GET with rate limiting and retries. The returned response is 200 or 404.
+// With a Breaker, it returns ErrUnavailable while the breaker is open.
+func (c *Client) Do(ctx context.Context, url string) (*http.Response, error) {
+	return c.get(ctx, "", url)
+}
+
+// get is Do for a request to endpoint ("" = none), which c.SLO tracks.
+func (c *Client) get(ctx context.Context, endpoint, url string) (*http.Response, error) {
+	if c.Breaker == nil {
+		return c.do(ctx, endpoint, url)
+	}
+	if !c.Breaker.allow() {
+		return nil, ErrUnavailable
+	}
+	resp, err := c.do(ctx, endpoint, url)
+	c.Breaker.record(ctx, err)
+	return resp, err
+}
+
+func (c *Client) do(ctx context.Context, endpoint, url string) (*http.Response, error) {
+	backoff := 1 * time.Second
+	tries := 0
+	var lastStatus int
+	key, limiter := c.APIKey, c.Limiter
+	ck, byo := callerKeyFrom(ctx)
+	if byo {
+		key, limiter = ck.key, ck.limiter
+	}
+	clk := c.clock()
+	for {
+		paced := clk.Now()
+		if byo {
+			if _, err := limiter.WaitContext(ctx); err != nil {
+				return nil, err
+			}
+		} else if c.Scheduler != nil {
+			if err := c.Scheduler.Acquire(ctx, TenantFrom(ctx)); err != nil {
+				return nil, err
+			}
+		} else if _, err := c.Limiter.WaitContext(ctx); err != nil {
+			return nil, err
+		}
+		tries++
+		c.Hooks.attempt(clock.Since(clk, paced))
+		countCall(ctx)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("X-Riot-Token", key)
+		sent := clk.Now()
+		resp, err := c.HTTP.Do(req)
+		if resp != nil {
+			limiter.Observe(resp.Header)
+		}
+		switch {
+		case resp != nil && resp.StatusCode >= 500, err != nil && ctx.Err() == nil:
+			c.SLO.record(endpoint, clock.Since(clk, sent), true)
+		case resp != nil && (resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusNotFound):
+			c.SLO.record(endpoint, clock.Since(clk, sent), false)
+		}
+		if err == nil && resp.StatusCode == http.StatusOK {
+			if !byo {
+				c.acceptKey()
+			}
+			c.Hooks.done()
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if resp != nil {
+			lastStatus = resp.StatusCode
+			if resp.StatusCode == http.StatusNotFound {
+				if !byo {
+					c.acceptKey()
+				}
+				c.Hooks.done()
+				return resp, nil
+			}
+			if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+				resp.Body.Close()
+				if byo {
+					return nil, callerKeyError(resp.StatusCode)
+				}
+				return nil, c.rejectKey(resp.StatusCode)
+			}
+			if resp.StatusCode == http.StatusTooManyRequests {
+				retryAfter := ParseRetryAfter(resp.Header.Get("Retry-After"), clk.Now())
+				resp.Body.Close()
+				wait := limiter.Throttled(retryAfter)
+				log.Printf("riot: 429 on %s, retrying in %s", req.URL.Path, wait.Round(time.Millisecond))
+				c.Hooks.throttled(wait)
+				if c.SkipOnLimit {
+					return nil, ErrSkipped
+				}
+				if err := clock.SleepContext(ctx, clk, wait); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			resp.Body.Close()
+		}
+		if c.SkipOnLimit {
+			return nil, ErrSkipped
+		}
+		if c.MaxRetry > 0 && tries >= c.MaxRetry {
+			break
+		}
+		if err := clock.SleepContext(ctx, clk, backoff); err != nil {
+			return nil, err
+		}
+		if backoff < 30*time.Second {
+			backoff *= 2
+		}
+	}
+	return nil, fmt.Errorf("%w, status=%d", ErrRetriesExhausted, lastStatus)
+}
+
+// ParseRetryAfter reads a Retry-After value in either delta-seconds or HTTP-date
+// form; 0 means absent or unparsable.
+func ParseRetryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if n, err := strconv.Atoi(v); err == nil {
+		if n < 0 {
+			return 0
+		}
+		return time.Duration(n) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// getJSON decodes a 200 response into v; found=false on 404. With a Cache,
+// endpoint's answers are served from it and stored in it for their TTL,
+// tagged with subject (a puuid or match id).
+func (c *Client) getJSON(ctx context.Context, endpoint, subject, url string, v any) (bool, error) {
+	ttl := c.cacheTTL(endpoint, http.StatusOK)
+	if c.Cache == nil || ttl <= 0 {
+		resp, err := c.get(ctx, endpoint, url)
+		if err != nil {
+			return false, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	if cr, ok := c.Cache.Get(url); ok {
+		CountCache(ctx, true)
+		c.countLookup(endpoint, true)
+		if cr.Status == http.StatusNotFound {
+			return false, nil
+		}
+		return true, json.Unmarshal(cr.Body, v)
+	}
+	CountCache(ctx, false)
+	c.countLookup(endpoint, false)
+	resp, err := c.get(ctx, endpoint, url)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	cr := CachedResponse{Endpoint: endpoint, Subject: subject, Status: resp.StatusCode}
+	if resp.StatusCode != http.StatusNotFound {
+		if cr.Body, err = io.ReadAll(resp.Body); err != nil {
+			return false, err
+		}
+		if err := json.Unmarshal(cr.Body, v); err != nil {
+			return false, err
+		}
+		if s, ok := v.(cacheSubject); ok && cr.Subject == "" {
+			cr.Subject = s.cacheSubject()
+		}
+	}
+	cr.Expires = time.Now().Add(c.cacheTTL(endpoint, cr.Status))
+	c.Cache.Put(url, cr)
+	return cr.Status != http.StatusNotFound, nil
+}
@@ -0,0 +1,211 @@
+package riot
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"lol_custom_skill_matching/internal/clock"
+)
+
+// scripted answers its requests with statuses in turn, then 200. A 429
+// carries Retry-After: retryAfter.
+type scripted struct {
+	statuses   []int
+	retryAfter string
+	hits       atomic.Int32
+}
+
+func (s *scripted) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := int(s.hits.Add(1)) - 1
+	if n >= len(s.statuses) {
+		_, _ = io.WriteString(w, "{}")
+		return
+	}
+	if s.statuses[n] == http.StatusTooManyRequests && s.retryAfter != "" {
+		w.Header().Set("Retry-After", s.retryAfter)
+	}
+	w.WriteHeader(s.statuses[n])
+}
+
+// testClient is a client of srv paced by a limiter on a fake clock, so
+// retries and throttling waits take no real time.
+func testClient(srv *httptest.Server, cfg LimiterConfig) (*Client, *clock.Fake) {
+	clk := clock.NewFake(time.Unix(1_700_000_000, 0))
+	cfg.Clock = clk
+	c := NewClient("test-key", NewLimiterWithConfig(cfg))
+	c.HTTP = srv.Client()
+	return c, clk
+}
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard) // 429 retries are logged
+	os.Exit(m.Run())
+}
+
+func TestClientRetries(t *testing.T) {
+	tests := []struct {
+		name        string
+		statuses    []int
+		retryAfter  string
+		maxRetry    int
+		skipOnLimit bool
+		wantTries   int
+		wantStatus  int   // of the response, when wantErr is nil
+		wantErr     error // errors.Is
+		wantWaited  time.Duration
+	}{
+		{name: "ok", wantTries: 1, wantStatus: 200},
+		{name: "not found is an answer", statuses: []int{404}, wantTries: 1, wantStatus: 404},
+		{name: "5xx backs off", statuses: []int{500, 503}, maxRetry: 3, wantTries: 3, wantStatus: 200, wantWaited: 3 * time.Second},
+		{name: "502 then ok", statuses: []int{502}, maxRetry: 2, wantTries: 2, wantStatus: 200, wantWaited: time.Second},
+		{name: "retries exhausted", statuses: []int{500, 500, 500}, maxRetry: 3, wantTries: 3, wantErr: ErrRetriesExhausted, wantWaited: 3 * time.Second},
+		{name: "backoff doubles", statuses: []int{500, 500, 500, 500}, maxRetry: 5, wantTries: 5, wantStatus: 200, wantWaited: 15 * time.Second},
+		{name: "429 outlasts MaxRetry", statuses: []int{429, 429, 429}, retryAfter: "2", maxRetry: 1, wantTries: 4, wantStatus: 200, wantWaited: 6 * time.Second},
+		{name: "429 skipped", statuses: []int{429}, skipOnLimit: true, maxRetry: 3, wantTries: 1, wantErr: ErrSkipped},
+		{name: "5xx skipped", statuses: []int{500}, skipOnLimit: true, maxRetry: 3, wantTries: 1, wantErr: ErrSkipped},
+		{name: "rejected key", statuses: []int{403}, maxRetry: 3, wantTries: 1, wantErr: ErrKeyInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &scripted{statuses: tt.statuses, retryAfter: tt.retryAfter}
+			srv := httptest.NewServer(h)
+			defer srv.Close()
+			c, clk := testClient(srv, DefaultLimiterConfig())
+			c.MaxRetry, c.SkipOnLimit = tt.maxRetry, tt.skipOnLimit
+			start := clk.Now()
+
+			resp, err := c.Do(context.Background(), srv.URL)
+			if resp != nil {
+				resp.Body.Close()
+			}
+			if got := int(h.hits.Load()); got != tt.wantTries {
+				t.Errorf("tries = %d, want %d", got, tt.wantTries)
+			}
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("err = %v, want %v", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Fatalf("err = %v", err)
+			case resp.StatusCode != tt.wantStatus:
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if waited := clock.Since(clk, start); waited < tt.wantWaited {
+				t.Errorf("waited %s, want at least %s", waited, tt.wantWaited)
+			}
+		})
+	}
+}
+
+func TestClientCancelledDuringBackoff(t *testing.T) {
+	srv := httptest.NewServer(&scripted{statuses: []int{500, 500}})
+	defer srv.Close()
+	c, _ := testClient(srv, DefaultLimiterConfig())
+	ctx, cancel := context.WithCancel(context.Background())
+	c.Hooks.Attempt = func(time.Duration) { cancel() }
+	if _, err := c.Do(ctx, srv.URL); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestClientLimiter(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        LimiterConfig
+		statuses   []int
+		retryAfter string
+		requests   int
+		wantWaited time.Duration // at least, on the limiter's clock
+		wantMax    time.Duration // at most
+		wantFactor float64
+		throttled  int64
+	}{
+		{
+			name:     "burst goes out at once",
+			cfg:      LimiterConfig{ShortLimit: 20, ShortWindow: time.Second, LongLimit: 100, LongWindow: 2 * time.Minute, Burst: 5},
+			requests: 5, wantMax: 0, wantFactor: 1,
+		},
+		{
+			// past the burst, the long window's (100-5)/120s refill paces requests
+			name:     "paced past the burst",
+			cfg:      LimiterConfig{ShortLimit: 20, ShortWindow: time.Second, LongLimit: 100, LongWindow: 2 * time.Minute, Burst: 5},
+			requests: 7, wantWaited: 2 * 120 * time.Second / 95, wantMax: 3 * time.Second, wantFactor: 1,
+		},
+		{
+			name:       "429 halves the rate and honours Retry-After",
+			cfg:        DefaultLimiterConfig(),
+			statuses:   []int{429},
+			retryAfter: "10",
+			requests:   1, wantWaited: 10 * time.Second, wantMax: 15 * time.Second, wantFactor: 0.5, throttled: 1,
+		},
+		{
+			// each 429 drains the buckets; the long one refills at a quarter of
+			// 90/120s after the second
+			name:       "429s compound",
+			cfg:        DefaultLimiterConfig(),
+			statuses:   []int{429, 429},
+			retryAfter: "1",
+			requests:   1, wantWaited: 2*time.Second + 120*time.Second/90*4, wantMax: 12 * time.Second, wantFactor: 0.25, throttled: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &scripted{statuses: tt.statuses, retryAfter: tt.retryAfter}
+			srv := httptest.NewServer(h)
+			defer srv.Close()
+			c, clk := testClient(srv, tt.cfg)
+			start := clk.Now()
+			for i := 0; i < tt.requests; i++ {
+				resp, err := c.Do(context.Background(), srv.URL)
+				if err != nil {
+					t.Fatalf("request %d: %v", i, err)
+				}
+				resp.Body.Close()
+			}
+			waited := clock.Since(clk, start)
+			if waited < tt.wantWaited || waited > tt.wantMax {
+				t.Errorf("waited %s, want %s to %s", waited, tt.wantWaited, tt.wantMax)
+			}
+			st := c.Limiter.Stats()
+			if st.Requests != int64(tt.requests+len(tt.statuses)) {
+				t.Errorf("limiter released %d requests, want %d", st.Requests, tt.requests+len(tt.statuses))
+			}
+			if st.Throttled != tt.throttled {
+				t.Errorf("throttled = %d, want %d", st.Throttled, tt.throttled)
+			}
+			if st.RateFactor != tt.wantFactor {
+				t.Errorf("rate factor = %g, want %g", st.RateFactor, tt.wantFactor)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{" 3 ", 3 * time.Second},
+		{"-1", 0},
+		{"soon", 0},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := ParseRetryAfter(tt.in, now); got != tt.want {
+			t.Errorf("ParseRetryAfter(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
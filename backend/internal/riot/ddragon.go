@@ -0,0 +1,238 @@
+package riot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"lol_custom_skill_matching/internal/clock"
+)
+
+const (
+	DataDragonVersion = "15.14.1"
+	DataDragonLocale  = "ja_JP"
+)
+
+// DataDragonHost serves champion data and icons; tests point it at a fake CDN.
+var DataDragonHost = "https://ddragon.leagueoflegends.com"
+
+// Champions is the Data Dragon champion registry.
+type Champions struct {
+	ByID  map[int]string    // numeric champion id -> localized name
+	ByKey map[string]string // lower(Data Dragon id, e.g. "monkeyking") -> localized name
+	Names map[string]struct{}
+	Keys  map[int]string   // numeric champion id -> Data Dragon id (e.g. "MonkeyKing")
+	Roles map[int][]string // numeric champion id -> Data Dragon tags (e.g. "Fighter", "Tank")
+	// Positions maps numeric champion ids to their primary positions
+	// (teamPosition values, e.g. "MIDDLE"), most played first.
+	Positions map[int][]string
+}
+
+// EmptyChampions is used when Data Dragon is unavailable; every lookup misses.
+func EmptyChampions() *Champions {
+	return &Champions{ByID: map[int]string{}, ByKey: map[string]string{}, Names: map[string]struct{}{}, Keys: map[int]string{}, Roles: map[int][]string{}, Positions: map[int][]string{}}
+}
+
+// Name returns the localized name or "" when unknown.
+func (c *Champions) Name(id int) string { return c.ByID[id] }
+
+// IconURL returns the champion's square icon on Data Dragon ("" when unknown).
+func (c *Champions) IconURL(id int) string {
+	key, ok := c.Keys[id]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%s/cdn/%s/img/champion/%s.png", DataDragonHost, DataDragonVersion, key)
+}
+
+// ID returns the numeric id of a champion by localized name or Data Dragon id
+// (case-insensitive).
+func (c *Champions) ID(name string) (int, bool) {
+	for id, n := range c.ByID {
+		if strings.EqualFold(n, name) || strings.EqualFold(c.Keys[id], name) {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// ProfileIconURL returns a summoner icon on Data Dragon.
+func ProfileIconURL(iconID int) string {
+	return fmt.Sprintf("%s/cdn/%s/img/profileicon/%d.png", DataDragonHost, DataDragonVersion, iconID)
+}
+
+// Resolve maps declared entries (Data Dragon id like "MonkeyKing" or localized name)
+// to the localized display name; unknown entries are kept verbatim.
+func (c *Champions) Resolve(declared []string) []string {
+	out := []string{}
+	seen := map[string]struct{}{}
+	for _, d := range declared {
+		name := d
+		if n, ok := c.ByKey[strings.ToLower(d)]; ok {
+			name = n
+		} else if _, ok := c.Names[d]; !ok {
+			for n := range c.Names {
+				if strings.EqualFold(n, d) {
+					name = n
+					break
+				}
+			}
+		}
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		out = append(out, name)
+	}
+	return out
+}
+
+// ValidLocale reports whether locale looks like a Data Dragon locale ("en_US").
+func ValidLocale(locale string) bool {
+	if len(locale) != 5 || locale[2] != '_' {
+		return false
+	}
+	for i := 0; i < len(locale); i++ {
+		c := locale[i]
+		switch {
+		case i == 2:
+		case i < 2 && 'a' <= c && c <= 'z':
+		case i > 2 && 'A' <= c && c <= 'Z':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ChampionsURL is the Data Dragon champion.json location.
+func ChampionsURL(version, locale string) string {
+	return fmt.Sprintf("%s/cdn/%s/data/%s/champion.json", DataDragonHost, version, locale)
+}
+
+// ddragonTries bounds retries against the CDN; it has no quota, so a blip is
+// either over within a few seconds or not worth waiting for.
+const ddragonTries = 3
+
+// FetchChampions loads champion.json for the given version/locale, retrying
+// network errors, 429 and 5xx with backoff (or Retry-After when sent).
+func FetchChampions(ctx context.Context, client *http.Client, version, locale string) (*Champions, error) {
+	raw, err := fetchDataDragon(ctx, client, ChampionsURL(version, locale))
+	if err != nil {
+		return nil, err
+	}
+	return ParseChampions(raw)
+}
+
+// LoadChampions fetches champion.json and keeps a copy in cacheFile; when the CDN
+// is unreachable the last cached copy is used instead so names don't all turn
+// into "unknown" for the run. An empty cacheFile disables the disk copy.
+func LoadChampions(ctx context.Context, client *http.Client, version, locale, cacheFile string) (*Champions, error) {
+	raw, err := fetchDataDragon(ctx, client, ChampionsURL(version, locale))
+	if err == nil {
+		c, perr := ParseChampions(raw)
+		if perr == nil {
+			if cacheFile != "" {
+				if werr := os.WriteFile(cacheFile, raw, 0o644); werr != nil {
+					log.Printf("data dragon: cache write failed: %v", werr)
+				}
+			}
+			return c, nil
+		}
+		err = perr
+	}
+	if cacheFile == "" {
+		return nil, err
+	}
+	cached, rerr := os.ReadFile(cacheFile)
+	if rerr != nil {
+		return nil, err
+	}
+	c, perr := ParseChampions(cached)
+	if perr != nil {
+		return nil, err
+	}
+	log.Printf("data dragon: %v; using cached %s", err, cacheFile)
+	return c, nil
+}
+
+func fetchDataDragon(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	backoff := 1 * time.Second
+	var lastErr error
+	for try := 1; ; try++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		wait := backoff
+		resp, err := client.Do(req)
+		if err == nil {
+			if resp.StatusCode == http.StatusOK {
+				body, rerr := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				if rerr == nil {
+					return body, nil
+				}
+				lastErr = rerr
+			} else {
+				resp.Body.Close()
+				lastErr = fmt.Errorf("data dragon: status %d", resp.StatusCode)
+				if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+					return nil, lastErr
+				}
+				if ra := ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ra > 0 {
+					wait = ra
+				}
+			}
+		} else {
+			lastErr = err
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if try >= ddragonTries {
+			return nil, lastErr
+		}
+		if err := clock.SleepContext(ctx, clock.Real, wait); err != nil {
+			return nil, err
+		}
+		backoff *= 2
+	}
+}
+
+// ParseChampions decodes a champion.json document.
+func ParseChampions(raw []byte) (*Champions, error) {
+	var champData struct {
+		Data map[string]struct {
+			ID   string   `json:"id"`
+			Key  string   `json:"key"`
+			Name string   `json:"name"`
+			Tags []string `json:"tags"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &champData); err != nil {
+		return nil, err
+	}
+	if len(champData.Data) == 0 {
+		return nil, errors.New("data dragon: no champions in champion.json")
+	}
+	c := EmptyChampions()
+	for _, v := range champData.Data {
+		id, _ := strconv.Atoi(v.Key)
+		c.ByID[id] = v.Name
+		c.ByKey[strings.ToLower(v.ID)] = v.Name
+		c.Names[v.Name] = struct{}{}
+		c.Keys[id] = v.ID
+		c.Roles[id] = v.Tags
+		c.Positions[id] = primaryPositionsOf(v.ID, v.Tags)
+	}
+	return c, nil
+}
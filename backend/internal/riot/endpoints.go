@@ -0,0 +1,167 @@
+package riot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+type Account struct {
+	PUUID    string `json:"puuid"`
+	GameName string `json:"gameName"`
+	TagLine  string `json:"tagLine"`
+}
+
+// BotPUUID is what match-v5 reports for AI participants in custom and co-op games.
+const BotPUUID = "BOT"
+
+// IsBotPUUID reports whether a participant PUUID belongs to a bot. Bots have no
+// account, so looking up their rank only burns quota on a guaranteed miss.
+func IsBotPUUID(puuid string) bool { return puuid == "" || puuid == BotPUUID }
+
+type Participant struct {
+	PUUID                string `json:"puuid"`
+	RiotIDGameName       string `json:"riotIdGameName"`
+	RiotIDTagline        string `json:"riotIdTagline"`
+	TeamID               int    `json:"teamId"` // 100 blue, 200 red
+	ChampionID           int    `json:"championId"`
+	TeamPosition         string `json:"teamPosition"`
+	Win                  bool   `json:"win"`
+	Kills                int    `json:"kills"`
+	Deaths               int    `json:"deaths"`
+	Assists              int    `json:"assists"`
+	TotalMinionsKilled   int    `json:"totalMinionsKilled"`
+	NeutralMinionsKilled int    `json:"neutralMinionsKilled"`
+	// TotalDamageDealtToChampions and VisionScore feed custom match awards.
+	TotalDamageDealtToChampions int `json:"totalDamageDealtToChampions"`
+	VisionScore                 int `json:"visionScore"`
+}
+
+type Match struct {
+	Metadata struct {
+		MatchID string `json:"matchId"`
+	} `json:"metadata"`
+	Info struct {
+		QueueID      int           `json:"queueId"`
+		GameCreation int64         `json:"gameCreation"`
+		GameDuration int           `json:"gameDuration"`
+		GameVersion  string        `json:"gameVersion"` // e.g. "15.14.700.1234"
+		Participants []Participant `json:"participants"`
+	} `json:"info"`
+}
+
+type LeagueEntry struct {
+	QueueType    string `json:"queueType"`
+	Tier         string `json:"tier"`
+	Rank         string `json:"rank"`
+	LeaguePoints int    `json:"leaguePoints"`
+	Wins         int    `json:"wins"`
+	Losses       int    `json:"losses"`
+}
+
+type Mastery struct {
+	ChampionID     int `json:"championId"`
+	ChampionLevel  int `json:"championLevel"`
+	ChampionPoints int `json:"championPoints"`
+}
+
+type Summoner struct {
+	ID            string `json:"id"` // encrypted summoner id
+	PUUID         string `json:"puuid"`
+	ProfileIconID int    `json:"profileIconId"`
+	SummonerLevel int    `json:"summonerLevel"`
+}
+
+// AccountByRiotID resolves a Riot ID; found=false when the account doesn't exist.
+func (c *Client) AccountByRiotID(ctx context.Context, gameName, tagLine string) (Account, bool, error) {
+	var a Account
+	u := fmt.Sprintf("%s/riot/account/v1/accounts/by-riot-id/%s/%s", c.RegionalHost, url.PathEscape(gameName), url.PathEscape(tagLine))
+	found, err := c.getJSON(ctx, EndpointAccount, "", u, &a)
+	return a, found, err
+}
+
+// MatchIDs lists recent match ids, newest first.
+func (c *Client) MatchIDs(ctx context.Context, puuid string, start, count int) ([]string, error) {
+	var ids []string
+	u := fmt.Sprintf("%s/lol/match/v5/matches/by-puuid/%s/ids?start=%d&count=%d", c.regionalHost(puuid), puuid, start, count)
+	if _, err := c.getJSON(ctx, EndpointMatchIDs, puuid, u, &ids); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// MatchIDsSince pages through match ids played at or after since, newest first.
+func (c *Client) MatchIDsSince(ctx context.Context, puuid string, since time.Time, start, count int) ([]string, error) {
+	var ids []string
+	u := fmt.Sprintf("%s/lol/match/v5/matches/by-puuid/%s/ids?startTime=%d&start=%d&count=%d", c.regionalHost(puuid), puuid, since.Unix(), start, count)
+	if _, err := c.getJSON(ctx, EndpointMatchIDs, puuid, u, &ids); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// Match fetches match details; nil when the match doesn't exist.
+func (c *Client) Match(ctx context.Context, matchID string) (*Match, error) {
+	var m Match
+	if c.Matches != nil {
+		if b, ok := c.Matches.GetMatch(matchID); ok && json.Unmarshal(b, &m) == nil {
+			CountCache(ctx, true)
+			c.countLookup(EndpointMatch, true)
+			return &m, nil
+		}
+		m = Match{}
+		if c.Cache == nil {
+			c.countLookup(EndpointMatch, false) // else getJSON counts it
+		}
+	}
+	found, err := c.getJSON(ctx, EndpointMatch, matchID, fmt.Sprintf("%s/lol/match/v5/matches/%s", c.matchHost(matchID), matchID), &m)
+	if err != nil || !found {
+		return nil, err
+	}
+	if c.Matches != nil {
+		// only the fields the analysis reads are kept
+		if b, err := json.Marshal(&m); err == nil {
+			c.Matches.PutMatch(matchID, b)
+		}
+	}
+	return &m, nil
+}
+
+// LeagueEntries returns ranked entries for a puuid (empty when unranked).
+func (c *Client) LeagueEntries(ctx context.Context, puuid string) ([]LeagueEntry, error) {
+	var e []LeagueEntry
+	if _, err := c.getJSON(ctx, EndpointLeague, puuid, fmt.Sprintf("%s/lol/league/v4/entries/by-puuid/%s", c.platformHost(puuid), puuid), &e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Masteries returns all champion masteries for a puuid.
+func (c *Client) Masteries(ctx context.Context, puuid string) ([]Mastery, error) {
+	var m []Mastery
+	if _, err := c.getJSON(ctx, EndpointMastery, puuid, fmt.Sprintf("%s/lol/champion-mastery/v4/champion-masteries/by-puuid/%s", c.platformHost(puuid), puuid), &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// SummonerByPUUID fetches the platform summoner (profile icon, level).
+func (c *Client) SummonerByPUUID(ctx context.Context, puuid string) (Summoner, bool, error) {
+	return c.summonerOn(ctx, c.platformHost(puuid), puuid)
+}
+
+func (c *Client) summonerOn(ctx context.Context, host, puuid string) (Summoner, bool, error) {
+	var sm Summoner
+	found, err := c.getJSON(ctx, EndpointSummoner, puuid, fmt.Sprintf("%s/lol/summoner/v4/summoners/by-puuid/%s", host, puuid), &sm)
+	return sm, found, err
+}
+
+// ThirdPartyCode reads the verification code a player entered in the client
+// settings of sm's platform; found=false when none is set.
+func (c *Client) ThirdPartyCode(ctx context.Context, sm Summoner) (string, bool, error) {
+	var code string
+	found, err := c.getJSON(ctx, EndpointCode, sm.PUUID, fmt.Sprintf("%s/lol/platform/v4/third-party-code/by-summoner/%s", c.platformHost(sm.PUUID), url.PathEscape(sm.ID)), &code)
+	return code, found, err
+}
@@ -0,0 +1,184 @@
+package riot
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"lol_custom_skill_matching/internal/clock"
+)
+
+// DefaultTenant is the tenant of requests that don't name one.
+const DefaultTenant = "default"
+
+type tenantKey struct{}
+
+// WithTenant tags ctx so the Riot calls made under it are charged to tenant.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFrom returns the tenant set by WithTenant (DefaultTenant when none).
+func TenantFrom(ctx context.Context) string {
+	if t, _ := ctx.Value(tenantKey{}).(string); t != "" {
+		return t
+	}
+	return DefaultTenant
+}
+
+// TenantStats is one tenant's share of the Riot quota.
+type TenantStats struct {
+	Tenant   string  `json:"tenant"`
+	Weight   float64 `json:"weight"`
+	Requests int64   `json:"requests"`  // requests released to this tenant
+	WaitedMs int64   `json:"waited_ms"` // total time its requests queued
+	Queued   int     `json:"queued"`    // requests waiting right now
+}
+
+type waiter struct {
+	tenant   string
+	finish   float64 // virtual finish tag
+	seq      uint64  // FIFO among equal tags
+	enqueued time.Time
+	ready    chan struct{}
+	served   bool
+}
+
+type tenantState struct {
+	weight     float64
+	lastFinish float64
+	stats      TenantStats
+}
+
+// FairScheduler shares one Limiter between tenants with weighted fair queuing:
+// every request gets a virtual finish tag start+1/weight, and whenever the
+// limiter has a token the waiting request with the smallest tag goes next. A
+// tenant with weight 2 gets twice the requests of a weight-1 tenant while both
+// are busy, and an idle tenant's share goes to whoever is waiting, so one
+// community's 20-player backfill can't starve another's game-night analysis.
+type FairScheduler struct {
+	Limiter *Limiter
+
+	mu      sync.Mutex
+	weights map[string]float64 // configured; others get 1
+	tenants map[string]*tenantState
+	waiting []*waiter
+	vtime   float64 // start tag of the last released request
+	seq     uint64
+	running bool // dispatcher goroutine active
+}
+
+// NewFairScheduler builds a scheduler over limiter; weights <= 0 count as 1.
+func NewFairScheduler(limiter *Limiter, weights map[string]float64) *FairScheduler {
+	s := &FairScheduler{Limiter: limiter, weights: map[string]float64{}, tenants: map[string]*tenantState{}}
+	for t, w := range weights {
+		if w > 0 {
+			s.weights[t] = w
+		}
+	}
+	return s
+}
+
+func (s *FairScheduler) tenant(name string) *tenantState {
+	ts, ok := s.tenants[name]
+	if !ok {
+		w := s.weights[name]
+		if w <= 0 {
+			w = 1
+		}
+		ts = &tenantState{weight: w, stats: TenantStats{Tenant: name, Weight: w}}
+		s.tenants[name] = ts
+	}
+	return ts
+}
+
+// Acquire blocks until tenant may send one request.
+func (s *FairScheduler) Acquire(ctx context.Context, tenant string) error {
+	s.mu.Lock()
+	ts := s.tenant(tenant)
+	start := max(s.vtime, ts.lastFinish)
+	ts.lastFinish = start + 1/ts.weight
+	s.seq++
+	w := &waiter{tenant: tenant, finish: ts.lastFinish, seq: s.seq, enqueued: s.Limiter.clock.Now(), ready: make(chan struct{})}
+	s.waiting = append(s.waiting, w)
+	ts.stats.Queued++
+	if !s.running {
+		s.running = true
+		go s.dispatch()
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if w.served {
+			return nil // released just now; use it rather than waste the token
+		}
+		s.remove(w)
+		ts.stats.Queued--
+		return ctx.Err()
+	}
+}
+
+func (s *FairScheduler) remove(w *waiter) {
+	for i, x := range s.waiting {
+		if x == w {
+			s.waiting = append(s.waiting[:i], s.waiting[i+1:]...)
+			return
+		}
+	}
+}
+
+// dispatch takes a limiter token whenever someone is waiting and hands it to
+// the smallest finish tag. It exits when the queue drains.
+func (s *FairScheduler) dispatch() {
+	for {
+		s.mu.Lock()
+		if len(s.waiting) == 0 {
+			s.running = false
+			s.mu.Unlock()
+			return
+		}
+		s.mu.Unlock()
+		s.Limiter.Wait()
+		s.mu.Lock()
+		if len(s.waiting) == 0 {
+			// everyone gave up while we waited; the token is spent either way
+			s.running = false
+			s.mu.Unlock()
+			return
+		}
+		next := 0
+		for i, w := range s.waiting {
+			if n := s.waiting[next]; w.finish < n.finish || (w.finish == n.finish && w.seq < n.seq) {
+				next = i
+			}
+		}
+		w := s.waiting[next]
+		s.waiting = append(s.waiting[:next], s.waiting[next+1:]...)
+		ts := s.tenants[w.tenant]
+		s.vtime = max(s.vtime, w.finish-1/ts.weight)
+		ts.stats.Queued--
+		ts.stats.Requests++
+		ts.stats.WaitedMs += clock.Since(s.Limiter.clock, w.enqueued).Milliseconds()
+		w.served = true
+		close(w.ready)
+		s.mu.Unlock()
+	}
+}
+
+// Stats lists every tenant seen so far, by name.
+func (s *FairScheduler) Stats() []TenantStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]TenantStats, 0, len(s.tenants))
+	for _, ts := range s.tenants {
+		out = append(out, ts.stats)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Tenant < out[j].Tenant })
+	return out
+}
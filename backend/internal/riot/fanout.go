@@ -0,0 +1,45 @@
+package riot
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultWorkers is the pool size of FanOut callers that don't set one.
+const DefaultWorkers = 4
+
+// FanOut calls fn for every item on up to workers goroutines and returns the
+// results in the order of items. The requests fn makes still pass the client's
+// limiter one by one, so the pool only overlaps their latency: a lobby's
+// match details and participant ranks arrive as fast as the rate limit allows
+// instead of one round trip at a time. Once ctx is done no more items are
+// started and theirs are left zero.
+func FanOut[T, R any](ctx context.Context, workers int, items []T, fn func(context.Context, T) R) []R {
+	out := make([]R, len(items))
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	workers = min(workers, len(items))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				out[i] = fn(ctx, items[i])
+			}
+		}()
+	}
+	for i := range items {
+		select {
+		case jobs <- i:
+			continue
+		case <-ctx.Done():
+		}
+		break
+	}
+	close(jobs)
+	wg.Wait()
+	return out
+}
@@ -0,0 +1,58 @@
+package riot
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrKeyInvalid is returned when Riot rejects the API key (401/403). Retrying
+// can't help; the key has to be replaced.
+var ErrKeyInvalid = errors.New("riot: api key rejected")
+
+// keyHint tells operators what to do about a rejected key.
+const keyHint = "it is invalid or expired (development keys last 24h): get a new one at https://developer.riotgames.com, set RIOT_API_KEY and restart or reload the server"
+
+// KeyStatus tells whether Riot accepts the API key.
+type KeyStatus struct {
+	Valid  bool      `json:"valid"`
+	Status int       `json:"status,omitempty"` // 401 or 403 while invalid
+	Since  time.Time `json:"since,omitzero"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// KeyStatus reports whether the last answer from Riot accepted the key.
+func (c *Client) KeyStatus() KeyStatus {
+	c.keyMu.Lock()
+	defer c.keyMu.Unlock()
+	if c.keyRejected.IsZero() {
+		return KeyStatus{Valid: true}
+	}
+	return KeyStatus{Status: c.keyStatus, Since: c.keyRejected, Error: keyError(c.keyStatus).Error()}
+}
+
+// rejectKey marks the key invalid after a 401/403 and returns the error for
+// the request. OnKeyInvalid hears of the first rejection only.
+func (c *Client) rejectKey(status int) error {
+	c.keyMu.Lock()
+	first := c.keyRejected.IsZero()
+	if first {
+		c.keyRejected, c.keyStatus = time.Now(), status
+	}
+	c.keyMu.Unlock()
+	if first && c.OnKeyInvalid != nil {
+		c.OnKeyInvalid(c.KeyStatus())
+	}
+	return keyError(status)
+}
+
+func keyError(status int) error {
+	return fmt.Errorf("%w (HTTP %d): %s", ErrKeyInvalid, status, keyHint)
+}
+
+// acceptKey clears the invalid state once Riot answers a request again.
+func (c *Client) acceptKey() {
+	c.keyMu.Lock()
+	defer c.keyMu.Unlock()
+	c.keyRejected, c.keyStatus = time.Time{}, 0
+}
@@ -0,0 +1,256 @@
+package riot
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"lol_custom_skill_matching/internal/clock"
+)
+
+// Adaptive slowdown (AIMD): every 429 halves the pacing rate down to minRateFactor
+// and holds it for throttleCooldown, after which the rate recovers additively.
+const (
+	minRateFactor    = 0.1
+	throttleCooldown = 30 * time.Second
+	recoverPerSecond = 0.02 // full speed ~45s after a single halving
+)
+
+// LimiterConfig describes the application rate limits and how much of them may be
+// spent in a single burst.
+type LimiterConfig struct {
+	ShortLimit  int // requests per ShortWindow (20)
+	ShortWindow time.Duration
+	LongLimit   int // requests per LongWindow (100)
+	LongWindow  time.Duration
+	// Burst is how many requests may go out back to back before the limiter falls
+	// back to the steady rate. Smaller bursts trade start-up latency for smoother
+	// pacing near window edges.
+	Burst int
+	// Clock paces the limiter (nil = the system clock).
+	Clock clock.Clock
+}
+
+// DefaultLimiterConfig matches a development key: 20 req/s and 100 req/120s.
+func DefaultLimiterConfig() LimiterConfig {
+	return LimiterConfig{
+		ShortLimit:  20,
+		ShortWindow: time.Second,
+		LongLimit:   100,
+		LongWindow:  120 * time.Second,
+		Burst:       10,
+	}
+}
+
+// bucket is a token bucket sized so that capacity plus refill over the window
+// never exceeds the window limit.
+type bucket struct {
+	capacity float64
+	tokens   float64
+	rate     float64 // tokens per second
+	last     time.Time
+}
+
+func newWindowBucket(limit int, window time.Duration, burst int, now time.Time) *bucket {
+	if burst >= limit {
+		burst = limit - 1
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &bucket{
+		capacity: float64(burst),
+		tokens:   float64(burst),
+		rate:     float64(limit-burst) / window.Seconds(),
+		last:     now,
+	}
+}
+
+func (b *bucket) refill(now time.Time, factor float64) {
+	if now.Before(b.last) {
+		return
+	}
+	b.tokens += now.Sub(b.last).Seconds() * b.rate * factor
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+}
+
+// deficit is how long until one token is available.
+func (b *bucket) deficit(now time.Time, factor float64) time.Duration {
+	if now.Before(b.last) {
+		return b.last.Sub(now)
+	}
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / (b.rate * factor) * float64(time.Second))
+}
+
+// Limiter keeps requests under the application limits with one token bucket per
+// window. Each bucket refills at (limit-burst)/window, so no window ever sees more
+// than its limit while requests are spread evenly instead of stalling after a
+// burst. The limits start from the config and follow the ones Riot reports (see
+// Observe). It is safe for concurrent use.
+type Limiter struct {
+	mu      sync.Mutex
+	buckets []*bucket
+	limits  []RateWindow // one per bucket
+	burst   int
+	clock   clock.Clock
+
+	factor        float64 // current fraction of the configured rate
+	cooldownUntil time.Time
+	recoveredAt   time.Time // last time factor was recovered
+	stats         LimiterStats
+}
+
+// LimiterStats is a snapshot of limiter activity since start.
+type LimiterStats struct {
+	Requests     int64     `json:"requests"`
+	Throttled    int64     `json:"throttled"` // 429 responses reported
+	WaitedMs     int64     `json:"waited_ms"` // total time callers slept in Wait
+	RateFactor   float64   `json:"rate_factor"`
+	LastThrottle time.Time `json:"last_throttle"`
+	// Limits are the application limits in force; LimitChanges counts the
+	// times Riot reported different ones.
+	Limits       []RateWindow `json:"limits"`
+	LimitChanges int64        `json:"limit_changes"`
+}
+
+func NewLimiter() *Limiter { return NewLimiterWithConfig(DefaultLimiterConfig()) }
+
+// NewLimiterWithConfig builds a limiter; zero fields fall back to the defaults.
+func NewLimiterWithConfig(cfg LimiterConfig) *Limiter {
+	def := DefaultLimiterConfig()
+	if cfg.ShortLimit <= 0 || cfg.ShortWindow <= 0 {
+		cfg.ShortLimit, cfg.ShortWindow = def.ShortLimit, def.ShortWindow
+	}
+	if cfg.LongLimit <= 0 || cfg.LongWindow <= 0 {
+		cfg.LongLimit, cfg.LongWindow = def.LongLimit, def.LongWindow
+	}
+	if cfg.Burst <= 0 {
+		cfg.Burst = def.Burst
+	}
+	cl := clock.Or(cfg.Clock)
+	now := cl.Now()
+	return &Limiter{
+		buckets: []*bucket{
+			newWindowBucket(cfg.ShortLimit, cfg.ShortWindow, cfg.Burst, now),
+			newWindowBucket(cfg.LongLimit, cfg.LongWindow, cfg.Burst, now),
+		},
+		limits: []RateWindow{
+			{Limit: cfg.ShortLimit, Seconds: max(int(cfg.ShortWindow/time.Second), 1)},
+			{Limit: cfg.LongLimit, Seconds: max(int(cfg.LongWindow/time.Second), 1)},
+		},
+		burst:       cfg.Burst,
+		clock:       cl,
+		factor:      1,
+		recoveredAt: now,
+	}
+}
+
+// recover raises the rate factor additively once the cooldown has passed.
+func (r *Limiter) recover(now time.Time) {
+	if r.factor >= 1 || now.Before(r.cooldownUntil) {
+		r.recoveredAt = now
+		return
+	}
+	from := r.recoveredAt
+	if from.Before(r.cooldownUntil) {
+		from = r.cooldownUntil
+	}
+	r.factor += now.Sub(from).Seconds() * recoverPerSecond
+	if r.factor > 1 {
+		r.factor = 1
+	}
+	r.recoveredAt = now
+}
+
+// Throttled records a 429 and slows the limiter down: the rate is halved for a
+// cooldown period, the buckets are drained and, when the server sent Retry-After,
+// no request is released before it elapses. It returns how long the caller should
+// wait before retrying.
+func (r *Limiter) Throttled(retryAfter time.Duration) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.clock.Now()
+	for _, b := range r.buckets {
+		b.refill(now, r.factor)
+	}
+	r.recover(now)
+	r.factor /= 2
+	if r.factor < minRateFactor {
+		r.factor = minRateFactor
+	}
+	r.cooldownUntil = now.Add(throttleCooldown)
+	r.stats.Throttled++
+	r.stats.LastThrottle = now
+	resume := now.Add(retryAfter)
+	var wait time.Duration
+	for _, b := range r.buckets {
+		b.tokens = 0
+		if b.last.Before(resume) {
+			b.last = resume
+		}
+		if d := b.deficit(now, r.factor); d > wait {
+			wait = d
+		}
+	}
+	return wait
+}
+
+// Stats returns a snapshot of the limiter counters.
+func (r *Limiter) Stats() LimiterStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recover(r.clock.Now())
+	st := r.stats
+	st.RateFactor = r.factor
+	st.Limits = slices.Clone(r.limits)
+	return st
+}
+
+// Wait blocks until a request is permitted and returns the time spent sleeping.
+func (r *Limiter) Wait() time.Duration {
+	slept, _ := r.WaitContext(context.Background())
+	return slept
+}
+
+// WaitContext is Wait that gives up when ctx is done, returning its error
+// without taking a request: a caller past its deadline doesn't sit out a
+// Retry-After pause first.
+func (r *Limiter) WaitContext(ctx context.Context) (time.Duration, error) {
+	var slept time.Duration
+	for {
+		r.mu.Lock()
+		now := r.clock.Now()
+		r.recover(now)
+		var sleepFor time.Duration
+		for _, b := range r.buckets {
+			b.refill(now, r.factor)
+			if d := b.deficit(now, r.factor); d > sleepFor {
+				sleepFor = d
+			}
+		}
+		if sleepFor == 0 {
+			for _, b := range r.buckets {
+				b.tokens--
+			}
+			r.stats.Requests++
+			r.stats.WaitedMs += slept.Milliseconds()
+			r.mu.Unlock()
+			return slept, nil
+		}
+		if sleepFor < 10*time.Millisecond {
+			sleepFor = 10 * time.Millisecond
+		}
+		r.mu.Unlock()
+		if err := clock.SleepContext(ctx, r.clock, sleepFor); err != nil {
+			return slept, err
+		}
+		slept += sleepFor
+	}
+}
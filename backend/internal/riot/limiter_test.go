@@ -0,0 +1,145 @@
+package riot
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"lol_custom_skill_matching/internal/clock"
+)
+
+func fakeLimiter(cfg LimiterConfig) (*Limiter, *clock.Fake) {
+	clk := clock.NewFake(time.Unix(1_700_000_000, 0))
+	cfg.Clock = clk
+	return NewLimiterWithConfig(cfg), clk
+}
+
+// TestLimiterWindows releases requests as fast as the limiter lets them and
+// checks that no window ever sees more than its limit.
+func TestLimiterWindows(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      LimiterConfig
+		requests int
+	}{
+		{"development key", DefaultLimiterConfig(), 250},
+		{"no burst", LimiterConfig{ShortLimit: 5, ShortWindow: time.Second, LongLimit: 50, LongWindow: 30 * time.Second, Burst: 1}, 120},
+		{"burst above the limit", LimiterConfig{ShortLimit: 3, ShortWindow: time.Second, LongLimit: 10, LongWindow: 10 * time.Second, Burst: 50}, 40},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, clk := fakeLimiter(tt.cfg)
+			var at []time.Time
+			for i := 0; i < tt.requests; i++ {
+				l.Wait()
+				at = append(at, clk.Now())
+			}
+			cfg := tt.cfg
+			for _, w := range []struct {
+				limit  int
+				window time.Duration
+			}{{cfg.ShortLimit, cfg.ShortWindow}, {cfg.LongLimit, cfg.LongWindow}} {
+				for i := range at {
+					n := 0
+					for j := i; j < len(at) && at[j].Sub(at[i]) < w.window; j++ {
+						n++
+					}
+					if n > w.limit {
+						t.Fatalf("%d requests within %s from request %d, limit %d", n, w.window, i, w.limit)
+					}
+				}
+			}
+			if st := l.Stats(); st.Requests != int64(tt.requests) {
+				t.Errorf("stats count %d requests, want %d", st.Requests, tt.requests)
+			}
+		})
+	}
+}
+
+func TestLimiterBurst(t *testing.T) {
+	l, clk := fakeLimiter(LimiterConfig{ShortLimit: 20, ShortWindow: time.Second, LongLimit: 100, LongWindow: 2 * time.Minute, Burst: 10})
+	start := clk.Now()
+	for i := 0; i < 10; i++ {
+		if slept := l.Wait(); slept != 0 {
+			t.Fatalf("request %d of the burst slept %s", i, slept)
+		}
+	}
+	if slept := l.Wait(); slept == 0 {
+		t.Fatalf("request past the burst went out at once")
+	}
+	// the long window's bucket refills at 90/120s
+	if got, want := clock.Since(clk, start), 120*time.Second/90; got < want || got > want+20*time.Millisecond {
+		t.Errorf("11th request after %s, want about %s", got, want)
+	}
+}
+
+func TestLimiterThrottled(t *testing.T) {
+	tests := []struct {
+		name       string
+		throttles  int
+		retryAfter time.Duration
+		wantFactor float64
+	}{
+		{"one 429", 1, 0, 0.5},
+		{"two 429s", 2, 0, 0.25},
+		{"floor", 10, 0, minRateFactor},
+		{"Retry-After", 1, 7 * time.Second, 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, clk := fakeLimiter(DefaultLimiterConfig())
+			start := clk.Now()
+			var wait time.Duration
+			for i := 0; i < tt.throttles; i++ {
+				wait = l.Throttled(tt.retryAfter)
+			}
+			if wait < tt.retryAfter {
+				t.Errorf("wait %s, shorter than Retry-After %s", wait, tt.retryAfter)
+			}
+			st := l.Stats()
+			if st.RateFactor != tt.wantFactor || st.Throttled != int64(tt.throttles) {
+				t.Errorf("factor %g throttled %d, want %g %d", st.RateFactor, st.Throttled, tt.wantFactor, tt.throttles)
+			}
+			// nothing is released before the wait Throttled returned
+			l.Wait()
+			if got := clock.Since(clk, start); got < wait {
+				t.Errorf("next request after %s, want at least %s", got, wait)
+			}
+		})
+	}
+}
+
+func TestLimiterRecovers(t *testing.T) {
+	tests := []struct {
+		after time.Duration // since the 429
+		want  float64
+	}{
+		{0, 0.5},
+		{throttleCooldown, 0.5},
+		{throttleCooldown + 10*time.Second, 0.5 + 10*recoverPerSecond},
+		{throttleCooldown + 25*time.Second, 1},
+		{time.Hour, 1},
+	}
+	for _, tt := range tests {
+		l, clk := fakeLimiter(DefaultLimiterConfig())
+		l.Throttled(0)
+		clk.Advance(tt.after)
+		if got := l.Stats().RateFactor; got < tt.want-1e-9 || got > tt.want+1e-9 {
+			t.Errorf("%s after a 429: factor %g, want %g", tt.after, got, tt.want)
+		}
+	}
+}
+
+func TestLimiterWaitContext(t *testing.T) {
+	l, _ := fakeLimiter(DefaultLimiterConfig())
+	l.Throttled(time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := l.WaitContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if st := l.Stats(); st.Requests != 0 {
+		t.Errorf("a cancelled wait took a request")
+	}
+}
@@ -0,0 +1,99 @@
+package riot
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"slices"
+	"time"
+)
+
+// LimiterState is what a Limiter needs to keep pacing where a previous
+// process left off, so a restart right after heavy use doesn't start with
+// full buckets and burst into a 429 (Riot's 120s window still counts the
+// old process's requests).
+type LimiterState struct {
+	SavedAt time.Time `json:"saved_at"`
+	// Tokens are each bucket's tokens at SavedAt, short window first;
+	// Resume is when each bucket refills again (after SavedAt while a
+	// Retry-After holds it).
+	Tokens     []float64   `json:"tokens"`
+	Resume     []time.Time `json:"resume"`
+	RateFactor float64     `json:"rate_factor"`
+	Cooldown   time.Time   `json:"cooldown_until"`
+	// Limits are the buckets' windows (see Limiter.Observe), so a production
+	// key's limits hold from the start.
+	Limits []RateWindow `json:"limits,omitempty"`
+}
+
+// State snapshots the limiter for Restore.
+func (r *Limiter) State() LimiterState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.clock.Now()
+	r.recover(now)
+	st := LimiterState{SavedAt: now, RateFactor: r.factor, Cooldown: r.cooldownUntil, Limits: slices.Clone(r.limits)}
+	for _, b := range r.buckets {
+		b.refill(now, r.factor)
+		st.Tokens = append(st.Tokens, b.tokens)
+		st.Resume = append(st.Resume, b.last)
+	}
+	return st
+}
+
+// Restore resumes from st: its limits replace the configured ones and tokens
+// refill from SavedAt as if the process had never stopped, capped at the
+// current burst. A state of other buckets (e.g. older format) is ignored.
+func (r *Limiter) Restore(st LimiterState) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(st.Limits) == len(st.Tokens) && !slices.Equal(st.Limits, r.limits) &&
+		!slices.ContainsFunc(st.Limits, func(w RateWindow) bool { return w.Limit <= 0 || w.Seconds <= 0 }) {
+		r.setLimits(st.Limits, r.clock.Now())
+	}
+	if len(st.Tokens) != len(r.buckets) || len(st.Resume) != len(r.buckets) || st.SavedAt.IsZero() {
+		return
+	}
+	for i, b := range r.buckets {
+		b.tokens = min(st.Tokens[i], b.capacity)
+		b.last = st.SavedAt
+		if st.Resume[i].After(b.last) {
+			b.last = st.Resume[i]
+		}
+	}
+	if st.RateFactor > 0 {
+		r.factor = min(max(st.RateFactor, minRateFactor), 1)
+	}
+	r.cooldownUntil = st.Cooldown
+	r.recoveredAt = st.SavedAt
+}
+
+// SaveLimiterState writes l's state to path through a temporary file.
+func SaveLimiterState(l *Limiter, path string) error {
+	b, err := json.Marshal(l.State())
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadLimiterState restores l from path; a missing file is a first start.
+func LoadLimiterState(l *Limiter, path string) error {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var st LimiterState
+	if err := json.Unmarshal(b, &st); err != nil {
+		return err
+	}
+	l.Restore(st)
+	return nil
+}
@@ -0,0 +1,15 @@
+package riot
+
+// MatchCache keeps match details by match id. A finished match never
+// changes, so entries don't expire: every analysis after the first reads
+// known matches from here instead of Riot. Implementations must be safe for
+// concurrent use.
+type MatchCache interface {
+	GetMatch(matchID string) ([]byte, bool)
+	PutMatch(matchID string, body []byte)
+	// PurgeMatches removes match matchID ("" = all) and reports how many it
+	// removed.
+	PurgeMatches(matchID string) (int, error)
+	// CountMatches sizes the cache.
+	CountMatches() (CacheCount, error)
+}
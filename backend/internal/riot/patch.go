@@ -0,0 +1,50 @@
+package riot
+
+import (
+	"strconv"
+	"strings"
+)
+
+// PatchesPerYear is how many patches a season has, give or take one.
+const PatchesPerYear = 24
+
+// PatchOf returns the "major.minor" patch of a game version such as
+// "15.14.700.1234" ("" when the version isn't one).
+func PatchOf(version string) string {
+	major, minor, ok := parsePatch(version)
+	if !ok {
+		return ""
+	}
+	return strconv.Itoa(major) + "." + strconv.Itoa(minor)
+}
+
+// ValidPatch reports whether p looks like a "major.minor" patch.
+func ValidPatch(p string) bool {
+	_, _, ok := parsePatch(p)
+	return ok && strings.Count(p, ".") == 1
+}
+
+// PatchDistance is how many patches older than current p is (0 when it is
+// the same or newer, ok=false when either isn't a patch). Seasons are counted
+// as PatchesPerYear patches, so 14.24 is one patch before 15.1.
+func PatchDistance(current, p string) (int, bool) {
+	cmaj, cmin, ok1 := parsePatch(current)
+	pmaj, pmin, ok2 := parsePatch(p)
+	if !ok1 || !ok2 {
+		return 0, false
+	}
+	return max(0, (cmaj-pmaj)*PatchesPerYear+cmin-pmin), true
+}
+
+func parsePatch(v string) (major, minor int, ok bool) {
+	parts := strings.SplitN(v, ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, false
+	}
+	major, err1 := strconv.Atoi(parts[0])
+	minor, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil || major < 0 || minor < 0 {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
@@ -0,0 +1,185 @@
+package riot
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Platform is a LoL shard (jp1, kr, na1, ...) and the regional cluster that
+// serves its match-v5 data.
+type Platform struct {
+	ID       string
+	Regional string // asia, americas, europe, sea
+}
+
+// Host is the platform API base URL.
+func (p Platform) Host() string { return "https://" + p.ID + ".api.riotgames.com" }
+
+// RegionalHost is the base URL for the platform's account/match data.
+func (p Platform) RegionalHost() string { return "https://" + p.Regional + ".api.riotgames.com" }
+
+// Platforms lists every LoL shard by id.
+var Platforms = map[string]Platform{
+	"jp1": {"jp1", "asia"}, "kr": {"kr", "asia"},
+	"na1": {"na1", "americas"}, "br1": {"br1", "americas"}, "la1": {"la1", "americas"}, "la2": {"la2", "americas"},
+	"euw1": {"euw1", "europe"}, "eun1": {"eun1", "europe"}, "tr1": {"tr1", "europe"}, "ru": {"ru", "europe"}, "me1": {"me1", "europe"},
+	"oc1": {"oc1", "sea"}, "sg2": {"sg2", "sea"}, "tw2": {"tw2", "sea"}, "vn2": {"vn2", "sea"}, "th2": {"th2", "sea"}, "ph2": {"ph2", "sea"},
+}
+
+// Regions are the regional routing values (clusters) of account and match
+// data.
+var Regions = []string{"americas", "asia", "europe", "sea"}
+
+// LookupPlatform returns the platform id (case-insensitive) routed to region,
+// or to its own regional cluster when region is "".
+func LookupPlatform(id, region string) (Platform, error) {
+	id, region = strings.ToLower(strings.TrimSpace(id)), strings.ToLower(strings.TrimSpace(region))
+	if id == "" {
+		return Platform{}, fmt.Errorf("region %q needs a platform (e.g. na1, euw1, kr)", region)
+	}
+	p, ok := Platforms[id]
+	if !ok {
+		return Platform{}, fmt.Errorf("unknown platform %q", id)
+	}
+	if region != "" {
+		if !slices.Contains(Regions, region) {
+			return Platform{}, fmt.Errorf("unknown region %q (%s)", region, strings.Join(Regions, "|"))
+		}
+		p.Regional = region
+	}
+	return p, nil
+}
+
+// SetHome makes p the default platform, whose hosts serve every puuid not
+// pinned or probed elsewhere.
+func (c *Client) SetHome(p Platform) {
+	c.PlatformHost, c.RegionalHost = p.Host(), p.RegionalHost()
+}
+
+// Pin routes puuid's requests to p, e.g. a player the request said plays on
+// another shard; FindPlatform then leaves puuid alone.
+func (c *Client) Pin(puuid string, p Platform) {
+	if p.Host() == c.PlatformHost && p.RegionalHost() == c.RegionalHost {
+		p = Platform{}
+	}
+	c.remember(puuid, p)
+}
+
+// ShareShard routes others like puuid when puuid lives off the default
+// platform, e.g. the participants of its matches, which share its shard.
+// Puuids with a shard of their own keep it.
+func (c *Client) ShareShard(puuid string, others []string) {
+	c.shardMu.Lock()
+	defer c.shardMu.Unlock()
+	p := c.shards[puuid]
+	if p.ID == "" {
+		return
+	}
+	for _, o := range others {
+		if _, known := c.shards[o]; !known {
+			c.shards[o] = p
+		}
+	}
+}
+
+// DefaultProbeOrder is where accounts missing from the default platform are
+// looked for, most likely first for a Japanese community.
+var DefaultProbeOrder = []string{"kr", "na1", "euw1", "oc1", "tw2", "sg2", "vn2", "eun1"}
+
+// FindPlatform locates the shard puuid plays on when it has no summoner on the
+// default platform, trying Probe in order, and remembers the answer so later
+// calls for puuid (ranks, masteries, matches) go to that shard. moved reports
+// that puuid lives somewhere other than the default platform. Accounts found
+// nowhere stay on the default platform; either way puuid is probed only once.
+func (c *Client) FindPlatform(ctx context.Context, puuid string) (id string, moved bool, err error) {
+	c.shardMu.Lock()
+	p, known := c.shards[puuid]
+	c.shardMu.Unlock()
+	if known {
+		return p.ID, p.ID != "" && p.Host() != c.PlatformHost, nil
+	}
+	if len(c.Probe) == 0 {
+		return "", false, nil
+	}
+	if _, found, err := c.summonerOn(ctx, c.PlatformHost, puuid); err != nil || found {
+		if found {
+			c.remember(puuid, Platform{})
+		}
+		return "", false, err
+	}
+	for _, pid := range c.Probe {
+		p, ok := Platforms[strings.ToLower(pid)]
+		if !ok || p.Host() == c.PlatformHost {
+			continue
+		}
+		_, found, err := c.summonerOn(ctx, p.Host(), puuid)
+		if err != nil {
+			return "", false, err
+		}
+		if found {
+			c.remember(puuid, p)
+			return p.ID, true, nil
+		}
+	}
+	c.remember(puuid, Platform{})
+	return "", false, nil
+}
+
+// remember stores the shard of puuid; the zero Platform means the default one.
+func (c *Client) remember(puuid string, p Platform) {
+	c.shardMu.Lock()
+	defer c.shardMu.Unlock()
+	if c.shards == nil {
+		c.shards = map[string]Platform{}
+	}
+	c.shards[puuid] = p
+}
+
+// PlatformOf returns the shard remembered for puuid ("" = default platform).
+func (c *Client) PlatformOf(puuid string) string {
+	c.shardMu.Lock()
+	defer c.shardMu.Unlock()
+	return c.shards[puuid].ID
+}
+
+func (c *Client) platformHost(puuid string) string {
+	c.shardMu.Lock()
+	defer c.shardMu.Unlock()
+	if p := c.shards[puuid]; p.ID != "" {
+		return p.Host()
+	}
+	return c.PlatformHost
+}
+
+func (c *Client) regionalHost(puuid string) string {
+	c.shardMu.Lock()
+	defer c.shardMu.Unlock()
+	if p := c.shards[puuid]; p.ID != "" {
+		return p.RegionalHost()
+	}
+	return c.RegionalHost
+}
+
+// matchHost routes a match id by its platform prefix (NA1_123 -> americas)
+// when that differs from the default platform's cluster.
+func (c *Client) matchHost(matchID string) string {
+	prefix, _, ok := strings.Cut(matchID, "_")
+	if !ok {
+		return c.RegionalHost
+	}
+	p, ok := Platforms[strings.ToLower(prefix)]
+	if !ok {
+		return c.RegionalHost
+	}
+	for _, home := range Platforms {
+		if home.Host() == c.PlatformHost {
+			if home.Regional != p.Regional {
+				return p.RegionalHost()
+			}
+			break
+		}
+	}
+	return c.RegionalHost
+}
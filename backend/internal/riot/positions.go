@@ -0,0 +1,69 @@
+package riot
+
+import (
+	_ "embed"
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// Data Dragon champion tags (Champions.Roles).
+const (
+	TagAssassin = "Assassin"
+	TagFighter  = "Fighter"
+	TagMage     = "Mage"
+	TagMarksman = "Marksman"
+	TagSupport  = "Support"
+	TagTank     = "Tank"
+)
+
+// positionsJSON maps Data Dragon ids to the positions a champion is mainly
+// played in (match-v5 teamPosition values, most played first). Data Dragon
+// has no positions, so this is kept by hand; add new champions here.
+//
+//go:embed positions.json
+var positionsJSON []byte
+
+var primaryPositions = func() map[string][]string {
+	var byKey map[string][]string
+	if err := json.Unmarshal(positionsJSON, &byKey); err != nil {
+		panic("riot: bad positions.json: " + err.Error())
+	}
+	out := make(map[string][]string, len(byKey))
+	for k, v := range byKey {
+		out[strings.ToLower(k)] = v
+	}
+	return out
+}()
+
+// tagPositions guesses the positions of a champion missing from
+// positions.json (e.g. released after it was last updated) from its first tag.
+var tagPositions = map[string][]string{
+	TagMarksman: {"BOTTOM"},
+	TagSupport:  {"UTILITY"},
+	TagMage:     {"MIDDLE"},
+	TagAssassin: {"MIDDLE"},
+	TagFighter:  {"TOP"},
+	TagTank:     {"TOP"},
+}
+
+// primaryPositionsOf returns the positions of the champion with Data Dragon id
+// key and tags.
+func primaryPositionsOf(key string, tags []string) []string {
+	if p, ok := primaryPositions[strings.ToLower(key)]; ok {
+		return p
+	}
+	if len(tags) > 0 {
+		return tagPositions[tags[0]]
+	}
+	return nil
+}
+
+// HasRole reports whether Data Dragon tags champion id with tag (e.g. TagTank).
+func (c *Champions) HasRole(id int, tag string) bool { return slices.Contains(c.Roles[id], tag) }
+
+// PlaysPosition reports whether position (a teamPosition, e.g. "UTILITY") is
+// one of champion id's primary positions.
+func (c *Champions) PlaysPosition(id int, position string) bool {
+	return slices.Contains(c.Positions[id], position)
+}
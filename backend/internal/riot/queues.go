@@ -0,0 +1,187 @@
+package riot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Queue ids of match-v5 (Info.QueueID) the server treats specially.
+const (
+	QueueCustom     = 0
+	QueueDraft      = 400
+	QueueRankedSolo = 420
+	QueueBlind      = 430
+	QueueRankedFlex = 440
+	QueueARAM       = 450
+	QueueQuickplay  = 490
+	QueueArena      = 1700
+)
+
+// Queue describes a queue id of match-v5.
+type Queue struct {
+	ID          int    `json:"queueId"`
+	Map         string `json:"map"`
+	Description string `json:"description"` // "5v5 Ranked Solo games"; empty for custom games
+	// Name is short enough for a table column ("Ranked Solo/Duo").
+	Name string `json:"name"`
+	// Key names the queue in filters ("ranked_solo"); only the queues of
+	// the built-in table have one.
+	Key    string `json:"key,omitempty"`
+	Ranked bool   `json:"ranked"`
+}
+
+// builtinQueues are the queues known without queues.json, which adds the
+// others and Riot's current descriptions (see SetQueues).
+var builtinQueues = []Queue{
+	{ID: QueueCustom, Map: "Custom games", Name: "Custom", Key: "custom"},
+	{ID: QueueDraft, Map: "Summoner's Rift", Description: "5v5 Draft Pick games", Name: "Normal Draft", Key: "draft"},
+	{ID: QueueRankedSolo, Map: "Summoner's Rift", Description: "5v5 Ranked Solo games", Name: "Ranked Solo/Duo", Key: "ranked_solo", Ranked: true},
+	{ID: QueueBlind, Map: "Summoner's Rift", Description: "5v5 Blind Pick games", Name: "Normal Blind", Key: "blind"},
+	{ID: QueueRankedFlex, Map: "Summoner's Rift", Description: "5v5 Ranked Flex games", Name: "Ranked Flex", Key: "ranked_flex", Ranked: true},
+	{ID: QueueARAM, Map: "Howling Abyss", Description: "5v5 ARAM games", Name: "ARAM", Key: "aram"},
+	{ID: QueueQuickplay, Map: "Summoner's Rift", Description: "Quickplay", Name: "Quickplay", Key: "quickplay"},
+	{ID: QueueArena, Map: "Rings of Wrath", Description: "Arena", Name: "Arena", Key: "arena"},
+}
+
+// queues is the registry: builtinQueues, overlaid with queues.json once the
+// static data loader has fetched it.
+var queues = struct {
+	sync.RWMutex
+	byID map[int]Queue
+}{byID: queueMap(nil)}
+
+// queueMap merges fetched into the built-in table. A fetched queue keeps the
+// built-in name, key and ranked flag of its id; the others are named after
+// their description.
+func queueMap(fetched []Queue) map[int]Queue {
+	m := map[int]Queue{}
+	for _, q := range builtinQueues {
+		m[q.ID] = q
+	}
+	for _, q := range fetched {
+		if b, ok := m[q.ID]; ok {
+			if q.Description != "" {
+				b.Description = q.Description
+			}
+			if q.Map != "" {
+				b.Map = q.Map
+			}
+			m[q.ID] = b
+			continue
+		}
+		q.Name = strings.TrimSuffix(q.Description, " games")
+		if q.Name == "" {
+			q.Name = "Queue " + strconv.Itoa(q.ID)
+		}
+		q.Key, q.Ranked = "", strings.Contains(q.Description, "Ranked")
+		m[q.ID] = q
+	}
+	return m
+}
+
+// SetQueues adds the queues.json table to the registry, replacing the last one.
+func SetQueues(fetched []Queue) {
+	m := queueMap(fetched)
+	queues.Lock()
+	defer queues.Unlock()
+	queues.byID = m
+}
+
+// LookupQueue returns the queue of id; ok is false for an id the registry
+// doesn't know.
+func LookupQueue(id int) (Queue, bool) {
+	queues.RLock()
+	defer queues.RUnlock()
+	q, ok := queues.byID[id]
+	return q, ok
+}
+
+// QueueName is the name of queue id ("Queue 1234" when unknown).
+func QueueName(id int) string {
+	if q, ok := LookupQueue(id); ok {
+		return q.Name
+	}
+	return "Queue " + strconv.Itoa(id)
+}
+
+// RankedQueue reports whether games of queue id change a rank.
+func RankedQueue(id int) bool {
+	q, _ := LookupQueue(id)
+	return q.Ranked
+}
+
+// AllQueues lists the registry by id.
+func AllQueues() []Queue {
+	queues.RLock()
+	defer queues.RUnlock()
+	out := make([]Queue, 0, len(queues.byID))
+	for _, q := range queues.byID {
+		out = append(out, q)
+	}
+	slices.SortFunc(out, func(a, b Queue) int { return a.ID - b.ID })
+	return out
+}
+
+// ErrUnknownQueue is returned for a queue filter that is neither an id nor a key.
+var ErrUnknownQueue = errors.New("unknown queue")
+
+// ParseQueue reads a queue filter: an id ("420") or a key ("ranked_solo").
+func ParseQueue(s string) (int, error) {
+	if id, err := strconv.Atoi(s); err == nil && id >= 0 {
+		return id, nil
+	}
+	for _, q := range builtinQueues {
+		if strings.EqualFold(q.Key, s) {
+			return q.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("%w %q (an id or one of %s)", ErrUnknownQueue, s, strings.Join(queueKeys(), ", "))
+}
+
+func queueKeys() []string {
+	keys := make([]string, len(builtinQueues))
+	for i, q := range builtinQueues {
+		keys[i] = q.Key
+	}
+	return keys
+}
+
+// QueueIDs is a list of queue ids that also reads keys from JSON, so a
+// filter can say ["ranked_solo", 400].
+type QueueIDs []int
+
+func (ids *QueueIDs) UnmarshalJSON(b []byte) error {
+	var raw []any
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if raw == nil {
+		*ids = nil
+		return nil
+	}
+	out := make(QueueIDs, 0, len(raw))
+	for _, v := range raw {
+		switch v := v.(type) {
+		case float64:
+			if v != float64(int(v)) || v < 0 {
+				return fmt.Errorf("%w id %v", ErrUnknownQueue, v)
+			}
+			out = append(out, int(v))
+		case string:
+			id, err := ParseQueue(v)
+			if err != nil {
+				return err
+			}
+			out = append(out, id)
+		default:
+			return fmt.Errorf("%w %v (an id or a key)", ErrUnknownQueue, v)
+		}
+	}
+	*ids = out
+	return nil
+}
@@ -0,0 +1,44 @@
+package riot
+
+// Tier/Rank maps
+var tierToInt = map[string]int{
+	"IRON": 1, "BRONZE": 2, "SILVER": 3, "GOLD": 4, "PLATINUM": 5,
+	"EMERALD": 6, "DIAMOND": 7, "MASTER": 8, "GRANDMASTER": 9, "CHALLENGER": 10,
+}
+var intToTier = map[int]string{1: "IRON", 2: "BRONZE", 3: "SILVER", 4: "GOLD", 5: "PLATINUM", 6: "EMERALD", 7: "DIAMOND", 8: "MASTER", 9: "GRANDMASTER", 10: "CHALLENGER"}
+var rankToInt = map[string]int{"IV": 1, "III": 2, "II": 3, "I": 4}
+var intToRank = map[int]string{1: "IV", 2: "III", 3: "II", 4: "I"}
+
+// RankScore maps tier/division/LP onto one scale: 100 points per division, 400 per tier.
+func RankScore(tier, rank string, lp int) int {
+	t := tierToInt[tier]
+	r := rankToInt[rank]
+	return ((t-1)*4+(r-1))*100 + lp
+}
+
+// ScoreToRank is the inverse of RankScore.
+func ScoreToRank(score int) (string, string, int) {
+	tierIdx := score/400 + 1
+	rankIdx := (score%400)/100 + 1
+	lp := score % 100
+	return intToTier[tierIdx], intToRank[rankIdx], lp
+}
+
+// SoloEntry returns the RANKED_SOLO_5x5 entry; ok=false when unranked.
+func SoloEntry(entries []LeagueEntry) (LeagueEntry, bool) {
+	for _, e := range entries {
+		if e.QueueType == "RANKED_SOLO_5x5" {
+			return e, true
+		}
+	}
+	return LeagueEntry{}, false
+}
+
+// SoloScore returns the RANKED_SOLO_5x5 score from league entries; ok=false when unranked.
+func SoloScore(entries []LeagueEntry) (int, bool) {
+	e, ok := SoloEntry(entries)
+	if !ok {
+		return 0, false
+	}
+	return RankScore(e.Tier, e.Rank, e.LeaguePoints), true
+}
@@ -0,0 +1,100 @@
+package riot
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRankScore(t *testing.T) {
+	tests := []struct {
+		tier, rank string
+		lp         int
+		want       int
+	}{
+		{"IRON", "IV", 0, 0},
+		{"IRON", "IV", 75, 75},
+		{"IRON", "I", 0, 300},
+		{"BRONZE", "IV", 0, 400},
+		{"GOLD", "II", 50, 1450},
+		{"EMERALD", "I", 99, 2399},
+		{"DIAMOND", "IV", 0, 2400},
+		// apex tiers have no divisions; Riot reports them as I
+		{"MASTER", "I", 0, 3100},
+		{"CHALLENGER", "I", 1200, 5100},
+	}
+	for _, tt := range tests {
+		if got := RankScore(tt.tier, tt.rank, tt.lp); got != tt.want {
+			t.Errorf("RankScore(%s, %s, %d) = %d, want %d", tt.tier, tt.rank, tt.lp, got, tt.want)
+		}
+	}
+}
+
+func TestScoreToRank(t *testing.T) {
+	for tier := range tierToInt {
+		for rank := range rankToInt {
+			for _, lp := range []int{0, 1, 50, 99} {
+				gotTier, gotRank, gotLP := ScoreToRank(RankScore(tier, rank, lp))
+				if gotTier != tier || gotRank != rank || gotLP != lp {
+					t.Errorf("ScoreToRank(RankScore(%s, %s, %d)) = %s %s %d", tier, rank, lp, gotTier, gotRank, gotLP)
+				}
+			}
+		}
+	}
+}
+
+func TestSoloScore(t *testing.T) {
+	flex := LeagueEntry{QueueType: "RANKED_FLEX_SR", Tier: "DIAMOND", Rank: "I", LeaguePoints: 10}
+	solo := LeagueEntry{QueueType: "RANKED_SOLO_5x5", Tier: "SILVER", Rank: "III", LeaguePoints: 20}
+	tests := []struct {
+		name    string
+		entries []LeagueEntry
+		want    int
+		ok      bool
+	}{
+		{"unranked", nil, 0, false},
+		{"flex only", []LeagueEntry{flex}, 0, false},
+		{"solo", []LeagueEntry{solo}, 920, true},
+		{"solo after flex", []LeagueEntry{flex, solo}, 920, true},
+	}
+	for _, tt := range tests {
+		got, ok := SoloScore(tt.entries)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: SoloScore = %d, %t, want %d, %t", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestLeagueClientSoloScore(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		want   int
+		ok     bool
+	}{
+		{"ranked", `[{"queueType":"RANKED_SOLO_5x5","tier":"PLATINUM","rank":"IV","leaguePoints":30}]`, 200, 1630, true},
+		{"unranked", `[]`, 200, 0, false},
+		{"unknown puuid", ``, 404, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/lol/league/v4/entries/by-puuid/p1" {
+					t.Errorf("path %s", r.URL.Path)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = io.WriteString(w, tt.body)
+			}))
+			defer srv.Close()
+			c, _ := testClient(srv, DefaultLimiterConfig())
+			c.PlatformHost = srv.URL
+			got, ok, err := c.API().League.SoloScore(context.Background(), "p1")
+			if err != nil || got != tt.want || ok != tt.ok {
+				t.Errorf("SoloScore = %d, %t, %v, want %d, %t", got, ok, err, tt.want, tt.ok)
+			}
+		})
+	}
+}
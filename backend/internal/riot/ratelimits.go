@@ -0,0 +1,88 @@
+package riot
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateWindow is one application rate limit: Limit requests per Seconds. In
+// a count header Limit is the requests already counted in the window.
+type RateWindow struct {
+	Limit   int `json:"limit"`
+	Seconds int `json:"window_seconds"`
+}
+
+func (w RateWindow) window() time.Duration { return time.Duration(w.Seconds) * time.Second }
+
+// ParseRateLimits reads Riot's "limit:seconds,limit:seconds" headers
+// (X-App-Rate-Limit, X-App-Rate-Limit-Count), shortest window first; nil when
+// v is empty or malformed.
+func ParseRateLimits(v string) []RateWindow {
+	var out []RateWindow
+	for _, part := range strings.Split(v, ",") {
+		n, s, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			return nil
+		}
+		limit, err1 := strconv.Atoi(n)
+		secs, err2 := strconv.Atoi(s)
+		if err1 != nil || err2 != nil || limit < 0 || secs <= 0 {
+			return nil
+		}
+		out = append(out, RateWindow{Limit: limit, Seconds: secs})
+	}
+	slices.SortFunc(out, func(a, b RateWindow) int { return a.Seconds - b.Seconds })
+	return out
+}
+
+// Observe adopts the application limits Riot sends with every response
+// (X-App-Rate-Limit), so a production key runs at its own limits instead of a
+// development key's, and its count of requests in each window
+// (X-App-Rate-Limit-Count), which includes other processes sharing the key:
+// a bucket never holds more tokens than its window has left.
+func (r *Limiter) Observe(h http.Header) {
+	limits := ParseRateLimits(h.Get("X-App-Rate-Limit"))
+	if len(limits) == 0 || slices.ContainsFunc(limits, func(w RateWindow) bool { return w.Limit == 0 }) {
+		return
+	}
+	counts := ParseRateLimits(h.Get("X-App-Rate-Limit-Count"))
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.clock.Now()
+	for _, b := range r.buckets {
+		b.refill(now, r.factor)
+	}
+	if !slices.Equal(limits, r.limits) {
+		r.setLimits(limits, now)
+		r.stats.LimitChanges++
+	}
+	for _, c := range counts {
+		i := slices.IndexFunc(r.limits, func(w RateWindow) bool { return w.Seconds == c.Seconds })
+		if i < 0 {
+			continue
+		}
+		if left := float64(max(r.limits[i].Limit-c.Limit, 0)); r.buckets[i].tokens > left {
+			r.buckets[i].tokens = left
+		}
+	}
+}
+
+// setLimits replaces the buckets with one per window of limits. A window the
+// limiter already had keeps its tokens (up to the new burst) and any
+// Retry-After hold. The caller holds r.mu.
+func (r *Limiter) setLimits(limits []RateWindow, now time.Time) {
+	buckets := make([]*bucket, len(limits))
+	for i, w := range limits {
+		b := newWindowBucket(w.Limit, w.window(), r.burst, now)
+		if j := slices.IndexFunc(r.limits, func(o RateWindow) bool { return o.Seconds == w.Seconds }); j >= 0 {
+			old := r.buckets[j]
+			b.tokens = min(old.tokens, b.capacity)
+			b.last = old.last
+		}
+		buckets[i] = b
+	}
+	r.buckets, r.limits = buckets, slices.Clone(limits)
+}
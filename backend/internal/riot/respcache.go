@@ -0,0 +1,257 @@
+package riot
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Endpoints, for ResponseCache TTLs and entries.
+const (
+	EndpointAccount  = "account"
+	EndpointSummoner = "summoner"
+	EndpointMatchIDs = "match_ids"
+	EndpointMatch    = "match"
+	EndpointLeague   = "league"
+	EndpointMastery  = "mastery"
+	EndpointCode     = "third_party_code"
+)
+
+// DefaultCacheTTLs is how long each endpoint's answers stay fresh. Match
+// details never change; the third-party code must be read live to verify a
+// player, so it isn't cached.
+var DefaultCacheTTLs = map[string]time.Duration{
+	EndpointAccount:  30 * 24 * time.Hour,
+	EndpointSummoner: 24 * time.Hour,
+	EndpointMatchIDs: 10 * time.Minute,
+	EndpointMatch:    30 * 24 * time.Hour,
+	EndpointLeague:   6 * time.Hour,
+	EndpointMastery:  24 * time.Hour,
+}
+
+// notFoundTTL caps how long a 404 is remembered: a missing account may be
+// created or renamed into any minute.
+const notFoundTTL = 10 * time.Minute
+
+// CachedResponse is a Riot answer kept by a ResponseCache.
+type CachedResponse struct {
+	Endpoint string
+	// Subject is the puuid (or match id) the answer is about, so a player's
+	// entries can be found together.
+	Subject string
+	Status  int // 200 or 404
+	Body    []byte
+	Expires time.Time
+}
+
+// ResponseCache keeps Riot answers by request URL (which holds no secret: the
+// API key travels in a header). Implementations must be safe for concurrent
+// use and must not return expired entries.
+type ResponseCache interface {
+	Get(key string) (CachedResponse, bool)
+	Put(key string, r CachedResponse)
+	// Purge removes the entries about subject ("" = all) and reports how
+	// many it removed.
+	Purge(subject string) (int, error)
+	// Count reports the live entries of each endpoint.
+	Count() (map[string]CacheCount, error)
+}
+
+// CacheCount sizes one endpoint's entries in a ResponseCache.
+type CacheCount struct {
+	Entries int   `json:"entries"`
+	Bytes   int64 `json:"bytes"` // response bodies
+}
+
+// MemoryCache is a ResponseCache in process memory.
+type MemoryCache struct {
+	mu    sync.Mutex
+	items map[string]CachedResponse
+	puts  int
+}
+
+func NewMemoryCache() *MemoryCache { return &MemoryCache{items: map[string]CachedResponse{}} }
+
+func (c *MemoryCache) Get(key string) (CachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.items[key]
+	if !ok || time.Now().After(r.Expires) {
+		delete(c.items, key)
+		return CachedResponse{}, false
+	}
+	return r, true
+}
+
+func (c *MemoryCache) Put(key string, r CachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items[key] = r
+	// entries that are never read again would otherwise pile up
+	if c.puts++; c.puts%1000 == 0 {
+		now := time.Now()
+		for k, e := range c.items {
+			if now.After(e.Expires) {
+				delete(c.items, k)
+			}
+		}
+	}
+}
+
+func (c *MemoryCache) Purge(subject string) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for k, e := range c.items {
+		if subject == "" || e.Subject == subject {
+			delete(c.items, k)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (c *MemoryCache) Count() (map[string]CacheCount, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	out := map[string]CacheCount{}
+	for _, e := range c.items {
+		if now.After(e.Expires) {
+			continue
+		}
+		n := out[e.Endpoint]
+		n.Entries++
+		n.Bytes += int64(len(e.Body))
+		out[e.Endpoint] = n
+	}
+	return out, nil
+}
+
+// CacheStats describes the response cache: its contents and the lookups made
+// since the process started.
+type CacheStats struct {
+	Enabled   bool                          `json:"enabled"`
+	Hits      int64                         `json:"hits"`
+	Misses    int64                         `json:"misses"`
+	HitRate   float64                       `json:"hit_rate"`
+	Endpoints map[string]EndpointCacheStats `json:"endpoints,omitempty"`
+	// MatchCache sizes the match-detail cache (Client.Matches), when set.
+	MatchCache *CacheCount `json:"match_cache,omitempty"`
+}
+
+// EndpointCacheStats is CacheStats for one endpoint.
+type EndpointCacheStats struct {
+	TTLSeconds float64 `json:"ttl_seconds"` // 0 = not cached
+	CacheCount
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hit_rate"`
+}
+
+// countLookup records a response cache lookup for CacheStats.
+func (c *Client) countLookup(endpoint string, hit bool) {
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+	if c.lookups == nil {
+		c.lookups = map[string]*[2]int64{}
+	}
+	n := c.lookups[endpoint]
+	if n == nil {
+		n = new([2]int64)
+		c.lookups[endpoint] = n
+	}
+	if hit {
+		n[0]++
+	} else {
+		n[1]++
+	}
+}
+
+// CacheStats reports the response cache's entries and hit rates per endpoint.
+func (c *Client) CacheStats() (CacheStats, error) {
+	if c.Cache == nil && c.Matches == nil {
+		return CacheStats{}, nil
+	}
+	counts := map[string]CacheCount{}
+	if c.Cache != nil {
+		var err error
+		if counts, err = c.Cache.Count(); err != nil {
+			return CacheStats{}, err
+		}
+	}
+	st := CacheStats{Enabled: true, Endpoints: map[string]EndpointCacheStats{}}
+	for _, ep := range []string{EndpointAccount, EndpointSummoner, EndpointMatchIDs, EndpointMatch, EndpointLeague, EndpointMastery, EndpointCode} {
+		e := EndpointCacheStats{CacheCount: counts[ep]}
+		if c.Cache != nil {
+			e.TTLSeconds = c.cacheTTL(ep, http.StatusOK).Seconds()
+		}
+		st.Endpoints[ep] = e
+	}
+	if c.Matches != nil {
+		n, err := c.Matches.CountMatches()
+		if err != nil {
+			return CacheStats{}, err
+		}
+		st.MatchCache = &n
+	}
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+	for ep, n := range c.lookups {
+		e := st.Endpoints[ep]
+		e.Hits, e.Misses, e.HitRate = n[0], n[1], hitRate(n[0], n[1])
+		st.Endpoints[ep] = e
+		st.Hits += n[0]
+		st.Misses += n[1]
+	}
+	st.HitRate = hitRate(st.Hits, st.Misses)
+	return st, nil
+}
+
+func hitRate(hits, misses int64) float64 {
+	if hits+misses == 0 {
+		return 0
+	}
+	return float64(hits) / float64(hits+misses)
+}
+
+// PurgeCache drops the cached responses about subject, a puuid or match id
+// ("" = all), so they are fetched again.
+func (c *Client) PurgeCache(subject string) (int, error) {
+	n := 0
+	if c.Cache != nil {
+		var err error
+		if n, err = c.Cache.Purge(subject); err != nil {
+			return n, err
+		}
+	}
+	if c.Matches != nil {
+		// keyed by match id, so a puuid removes nothing
+		m, err := c.Matches.PurgeMatches(subject)
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// cacheTTL is how long an answer of endpoint with status may be kept (0 = not cached).
+func (c *Client) cacheTTL(endpoint string, status int) time.Duration {
+	ttl := c.CacheTTLs[endpoint]
+	if c.CacheTTLs == nil {
+		ttl = DefaultCacheTTLs[endpoint]
+	}
+	if status == http.StatusNotFound {
+		ttl = min(ttl, notFoundTTL)
+	}
+	return ttl
+}
+
+// cacheSubject is implemented by responses that name their subject only in
+// the body (an account lookup is by Riot ID).
+type cacheSubject interface {
+	cacheSubject() string
+}
+
+func (a *Account) cacheSubject() string { return a.PUUID }
@@ -0,0 +1,83 @@
+package riottest
+
+import (
+	"fmt"
+	"time"
+
+	"lol_custom_skill_matching/internal/riot"
+)
+
+// Champion is an entry of the fake champion.json.
+type Champion struct {
+	Key  int    // numeric id used by match-v5 and mastery
+	ID   string // Data Dragon id
+	Name string
+}
+
+// Champions is the registry served as champion.json.
+var Champions = []Champion{
+	{266, "Aatrox", "エイトロックス"}, {103, "Ahri", "アーリ"}, {84, "Akali", "アカリ"},
+	{12, "Alistar", "アリスター"}, {32, "Amumu", "アムム"}, {22, "Ashe", "アッシュ"},
+	{268, "Azir", "アジール"}, {432, "Bard", "バード"}, {53, "Blitzcrank", "ブリッツクランク"},
+	{63, "Brand", "ブランド"}, {51, "Caitlyn", "ケイトリン"}, {64, "LeeSin", "リー・シン"},
+}
+
+// Lanes are match-v5 team positions.
+var Lanes = []string{"TOP", "JUNGLE", "MIDDLE", "BOTTOM", "UTILITY"}
+
+var communityTiers = []string{"IRON", "BRONZE", "SILVER", "GOLD", "PLATINUM", "EMERALD", "DIAMOND", "GOLD", "SILVER", "PLATINUM"}
+
+// AddCommunity registers the canned ten-player community, Player0#JP1 to
+// Player9#JP1, and returns it. Player i is ranked communityTiers[i], mains
+// Lanes[i%5] and has ten recent matches (even ones ranked solo, odd ones
+// normal draft) against strangers of its own tier, one win in two.
+func (s *Server) AddCommunity() []Player {
+	players := make([]Player, 0, len(communityTiers))
+	base := time.Now().Add(-24 * time.Hour)
+	for i, tier := range communityTiers {
+		p := Player{
+			GameName: fmt.Sprintf("Player%d", i), TagLine: "JP1", PUUID: fmt.Sprintf("puuid-player-%d", i),
+			Tier: tier, Division: "II", LP: 50, Wins: 60, Losses: 50,
+			IconID: 4000 + i, Level: 100 + i, ThirdPartyCode: fmt.Sprintf("code-%d", i),
+			Masteries: []riot.Mastery{
+				{ChampionID: Champions[i%len(Champions)].Key, ChampionLevel: 7, ChampionPoints: 200000 - i*1000},
+				{ChampionID: Champions[(i+1)%len(Champions)].Key, ChampionLevel: 5, ChampionPoints: 80000},
+				{ChampionID: Champions[(i+2)%len(Champions)].Key, ChampionLevel: 4, ChampionPoints: 30000},
+			},
+		}
+		s.AddPlayer(p)
+		players = append(players, p)
+		for k := 0; k < 10; k++ {
+			m := riot.Match{}
+			m.Metadata.MatchID = fmt.Sprintf("JP1_%d", 1000+i*100+k)
+			m.Info.QueueID = 420
+			if k%2 == 1 {
+				m.Info.QueueID = 400
+			}
+			m.Info.GameCreation = base.Add(-time.Duration(i*100+k) * time.Hour).UnixMilli()
+			m.Info.GameDuration = 1800
+			for j := 0; j < 10; j++ {
+				part := riot.Participant{
+					TeamID: 100, TeamPosition: Lanes[j%5], Win: k%2 == 0,
+					ChampionID: Champions[(i+j+k)%len(Champions)].Key,
+					Kills:      5 + j%3, Deaths: 4, Assists: 6, TotalMinionsKilled: 150,
+					TotalDamageDealtToChampions: 15000 + 500*j, VisionScore: 20 + j,
+				}
+				if j >= 5 {
+					part.TeamID, part.Win = 200, k%2 == 1
+				}
+				if j == 0 {
+					part.PUUID, part.RiotIDGameName, part.RiotIDTagline = p.PUUID, p.GameName, p.TagLine
+					part.TeamPosition = Lanes[i%5]
+					part.ChampionID = Champions[(i+k%2)%len(Champions)].Key
+				} else {
+					part.PUUID = fmt.Sprintf("puuid-stranger-%d-%d-%d", i, k, j)
+					s.AddPlayer(Player{PUUID: part.PUUID, Tier: tier, Division: "I", LP: 10 * j, Wins: 30, Losses: 30})
+				}
+				m.Info.Participants = append(m.Info.Participants, part)
+			}
+			s.AddMatch(m)
+		}
+	}
+	return players
+}
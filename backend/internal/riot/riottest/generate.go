@@ -0,0 +1,173 @@
+package riottest
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"lol_custom_skill_matching/internal/riot"
+)
+
+// GenOptions sizes a synthetic dataset.
+type GenOptions struct {
+	Players int
+	// Matches is how many recent matches each player gets (default 20).
+	Matches int
+	// Seed makes the dataset reproducible.
+	Seed uint64
+	// TagLine of the generated Riot IDs (default "GEN").
+	TagLine string
+}
+
+// tierWeights roughly follows the ranked solo distribution; "" is unranked.
+var tierWeights = []struct {
+	tier   string
+	weight int
+}{
+	{"", 15}, {"IRON", 5}, {"BRONZE", 13}, {"SILVER", 18}, {"GOLD", 18}, {"PLATINUM", 13},
+	{"EMERALD", 10}, {"DIAMOND", 5}, {"MASTER", 2}, {"GRANDMASTER", 1},
+}
+
+var (
+	divisions  = []string{"IV", "III", "II", "I"}
+	nameFirsts = []string{"Sakura", "Kuma", "Yoru", "Hoshi", "Kaze", "Tora", "Mochi", "Neko", "Sora", "Ryu", "Yuki", "Kitsune"}
+	nameLasts  = []string{"Main", "Gap", "Enjoyer", "Diff", "Smurf", "OTP", "Carry", "Feeder", "Kun", "Chan", "Sensei", "Jr"}
+	queues     = []int{420, 420, 420, 400, 430, 450} // 450 (ARAM) is ignored by the analyzer
+)
+
+// Generate registers opts.Players synthetic players with ranks, lane and
+// champion preferences and recent matches against strangers of nearby tiers,
+// and returns them. The same options always give the same dataset.
+func (s *Server) Generate(opts GenOptions) []Player {
+	if opts.Matches <= 0 {
+		opts.Matches = 20
+	}
+	if opts.TagLine == "" {
+		opts.TagLine = "GEN"
+	}
+	rng := rand.New(rand.NewPCG(opts.Seed, 0x6c6f6c))
+	now := time.Now()
+	players := make([]Player, 0, opts.Players)
+	for i := 0; i < opts.Players; i++ {
+		tier := pickTier(rng)
+		p := Player{
+			GameName: fmt.Sprintf("%s%s%d", nameFirsts[rng.IntN(len(nameFirsts))], nameLasts[rng.IntN(len(nameLasts))], i),
+			TagLine:  opts.TagLine,
+			PUUID:    fmt.Sprintf("puuid-gen-%d-%d", opts.Seed, i),
+			IconID:   rng.IntN(5000), Level: 30 + rng.IntN(500),
+		}
+		if tier != "" {
+			p.Tier, p.LP = tier, rng.IntN(100)
+			if !isApex(tier) {
+				p.Division = divisions[rng.IntN(len(divisions))]
+			}
+			games := 20 + rng.IntN(300)
+			p.Wins = games/2 + rng.IntN(games/10+1) - games/20
+			p.Losses = games - p.Wins
+		}
+		// a main lane, a second lane and a pool of 3-5 champions, most played first
+		main, second := rng.IntN(len(Lanes)), rng.IntN(len(Lanes))
+		pool := rng.Perm(len(Champions))[:3+rng.IntN(3)]
+		for rank, c := range pool {
+			p.Masteries = append(p.Masteries, riot.Mastery{
+				ChampionID: Champions[c].Key, ChampionLevel: max(7-rank, 1), ChampionPoints: (200000 + rng.IntN(300000)) >> rank,
+			})
+		}
+		s.AddPlayer(p)
+		players = append(players, p)
+
+		winrate := 0.5
+		if n := p.Wins + p.Losses; n > 0 {
+			winrate = float64(p.Wins) / float64(n)
+		}
+		for k := 0; k < opts.Matches; k++ {
+			lane := Lanes[main]
+			if rng.IntN(4) == 0 {
+				lane = Lanes[second]
+			}
+			champ := pool[0] // the best champion half the time
+			if rng.IntN(2) == 0 {
+				champ = pool[rng.IntN(len(pool))]
+			}
+			s.AddMatch(s.genMatch(rng, p, fmt.Sprintf("GEN%d_%d", opts.Seed, i*opts.Matches+k),
+				now.Add(-time.Duration(k*20+rng.IntN(20))*time.Hour), lane, Champions[champ].Key, rng.Float64() < winrate))
+		}
+	}
+	return players
+}
+
+func pickTier(rng *rand.Rand) string {
+	total := 0
+	for _, t := range tierWeights {
+		total += t.weight
+	}
+	n := rng.IntN(total)
+	for _, t := range tierWeights {
+		if n < t.weight {
+			return t.tier
+		}
+		n -= t.weight
+	}
+	return ""
+}
+
+func isApex(tier string) bool {
+	return tier == "MASTER" || tier == "GRANDMASTER" || tier == "CHALLENGER"
+}
+
+// genMatch builds one match of p with nine strangers ranked around p's tier.
+func (s *Server) genMatch(rng *rand.Rand, p Player, id string, at time.Time, lane string, champ int, win bool) riot.Match {
+	m := riot.Match{}
+	m.Metadata.MatchID = id
+	m.Info.QueueID = queues[rng.IntN(len(queues))]
+	m.Info.GameCreation = at.UnixMilli()
+	m.Info.GameDuration = 1200 + rng.IntN(1200)
+	m.Info.GameVersion = gameVersionAt(at)
+	tierIdx := 0
+	for i, t := range tierWeights {
+		if t.tier == p.Tier {
+			tierIdx = i
+		}
+	}
+	for j := 0; j < 10; j++ {
+		part := riot.Participant{
+			TeamID: 100, TeamPosition: Lanes[j%5], Win: win,
+			ChampionID: Champions[rng.IntN(len(Champions))].Key,
+			Kills:      rng.IntN(12), Deaths: rng.IntN(10), Assists: rng.IntN(15),
+			TotalMinionsKilled: 20 + rng.IntN(220), NeutralMinionsKilled: rng.IntN(40),
+			TotalDamageDealtToChampions: 5000 + rng.IntN(30000), VisionScore: 5 + rng.IntN(60),
+		}
+		if j >= 5 {
+			part.TeamID, part.Win = 200, !win
+		}
+		if j == 0 {
+			part.PUUID, part.RiotIDGameName, part.RiotIDTagline = p.PUUID, p.GameName, p.TagLine
+			part.TeamPosition, part.ChampionID = lane, champ
+		} else {
+			part.PUUID = fmt.Sprintf("%s-stranger-%d", id, j)
+			st := Player{PUUID: part.PUUID}
+			// strangers sit within a tier of p; unranked players meet low ranks
+			if t := tierWeights[max(1, min(len(tierWeights)-1, tierIdx+rng.IntN(3)-1))].tier; rng.IntN(8) > 0 {
+				st.Tier, st.LP, st.Wins, st.Losses = t, rng.IntN(100), 30+rng.IntN(50), 30+rng.IntN(50)
+				if !isApex(t) {
+					st.Division = divisions[rng.IntN(len(divisions))]
+				}
+			}
+			s.AddPlayer(st)
+		}
+		m.Info.Participants = append(m.Info.Participants, part)
+	}
+	return m
+}
+
+// patch15_14 is when 15.14 went live; gameVersionAt counts a patch every two
+// weeks from there, riot.PatchesPerYear to a season.
+var patch15_14 = time.Date(2025, 7, 16, 0, 0, 0, 0, time.UTC)
+
+// gameVersionAt names the game version live at t.
+func gameVersionAt(t time.Time) string {
+	n := int(math.Floor(t.Sub(patch15_14).Hours() / (14 * 24)))
+	idx := 15*riot.PatchesPerYear + 13 + n // 15.14 is the 14th patch of season 15
+	return fmt.Sprintf("%d.%d.1.1", idx/riot.PatchesPerYear, idx%riot.PatchesPerYear+1)
+}
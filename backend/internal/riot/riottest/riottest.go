@@ -0,0 +1,334 @@
+// Package riottest is a fake Riot API for integration and load testing. It
+// serves accounts, summoners, matches, leagues, masteries, Data Dragon's
+// champion.json and versions.json and the queue table from canned data, and can be told to answer with 429s or
+// 5xx errors to exercise the client's rate limit and retry handling.
+package riottest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"lol_custom_skill_matching/internal/riot"
+)
+
+// Routes, for Fault.Route and Hits.
+const (
+	RouteAccount   = "account"
+	RouteSummoner  = "summoner"
+	RouteMatchIDs  = "match_ids"
+	RouteMatch     = "match"
+	RouteLeague    = "league"
+	RouteMastery   = "mastery"
+	RouteCode      = "third_party_code"
+	RouteChampions = "champions" // Data Dragon; never faulted by a catch-all Fault
+	RouteStatic    = "static"    // versions.json and queues.json; neither is a catch-all Fault's
+)
+
+// Player is an account with everything the analyzer looks up for it.
+type Player struct {
+	GameName string
+	TagLine  string
+	PUUID    string
+	// Tier ("" = unranked), Division, LP, Wins and Losses make the solo queue entry.
+	Tier           string
+	Division       string
+	LP             int
+	Wins           int
+	Losses         int
+	Masteries      []riot.Mastery
+	IconID         int
+	Level          int
+	ThirdPartyCode string
+}
+
+// RiotID returns name#tag.
+func (p Player) RiotID() string { return p.GameName + "#" + p.TagLine }
+
+// Fault makes the server answer Times requests of Route ("" = any Riot route)
+// with Status, sending RetryAfter (seconds) when set. Times < 0 keeps failing
+// until ClearFaults.
+type Fault struct {
+	Route      string
+	Status     int
+	RetryAfter string
+	Times      int
+}
+
+// Server is the fake API. Its URL serves both the regional and the platform
+// routes and Data Dragon.
+type Server struct {
+	*httptest.Server
+	// Latency delays every answer, like the real API's round trip. Set it
+	// before sending requests.
+	Latency time.Duration
+
+	mu        sync.Mutex
+	accounts  map[string]riot.Account // lower(name#tag) -> account
+	summoners map[string]riot.Summoner
+	leagues   map[string][]riot.LeagueEntry
+	masteries map[string][]riot.Mastery
+	matchIDs  map[string][]string // puuid -> match ids, newest first
+	matches   map[string]riot.Match
+	codes     map[string]string // summoner id -> third-party code
+	faults    []*Fault
+	hits      map[string]int
+}
+
+// NewServer starts an empty fake API; Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		accounts: map[string]riot.Account{}, summoners: map[string]riot.Summoner{},
+		leagues: map[string][]riot.LeagueEntry{}, masteries: map[string][]riot.Mastery{},
+		matchIDs: map[string][]string{}, matches: map[string]riot.Match{}, codes: map[string]string{},
+		hits: map[string]int{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /riot/account/v1/accounts/by-riot-id/{name}/{tag}", s.route(RouteAccount, s.account))
+	mux.HandleFunc("GET /lol/summoner/v4/summoners/by-puuid/{puuid}", s.route(RouteSummoner, s.summoner))
+	mux.HandleFunc("GET /lol/match/v5/matches/by-puuid/{puuid}/ids", s.route(RouteMatchIDs, s.ids))
+	mux.HandleFunc("GET /lol/match/v5/matches/{id}", s.route(RouteMatch, s.match))
+	mux.HandleFunc("GET /lol/league/v4/entries/by-puuid/{puuid}", s.route(RouteLeague, s.league))
+	mux.HandleFunc("GET /lol/champion-mastery/v4/champion-masteries/by-puuid/{puuid}", s.route(RouteMastery, s.mastery))
+	mux.HandleFunc("GET /lol/platform/v4/third-party-code/by-summoner/{id}", s.route(RouteCode, s.code))
+	mux.HandleFunc("GET /cdn/{version}/data/{locale}/champion.json", s.route(RouteChampions, s.champions))
+	mux.HandleFunc("GET /api/versions.json", s.route(RouteStatic, s.versions))
+	mux.HandleFunc("GET /docs/lol/queues.json", s.route(RouteStatic, s.queues))
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Client returns a Riot client pointed at the server, with a limiter loose
+// enough that tests are paced by the server rather than a development key.
+func (s *Server) Client() *riot.Client {
+	rc := riot.NewClient("riottest", riot.NewLimiterWithConfig(riot.LimiterConfig{
+		ShortLimit: 2000, ShortWindow: time.Second, LongLimit: 100000, LongWindow: time.Minute, Burst: 1000,
+	}))
+	rc.RegionalHost, rc.PlatformHost = s.URL, s.URL
+	rc.Probe = nil
+	return rc
+}
+
+// ServeDataDragon points riot.DataDragonHost and riot.StaticDataHost at the
+// server until restore is called.
+func (s *Server) ServeDataDragon() (restore func()) {
+	prev, prevStatic := riot.DataDragonHost, riot.StaticDataHost
+	riot.DataDragonHost, riot.StaticDataHost = s.URL, s.URL
+	return func() { riot.DataDragonHost, riot.StaticDataHost = prev, prevStatic }
+}
+
+// AddPlayer registers p. A player without a GameName only gets its rank and
+// masteries, like the strangers met in matches.
+func (s *Server) AddPlayer(p Player) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p.GameName != "" {
+		s.accounts[strings.ToLower(p.RiotID())] = riot.Account{PUUID: p.PUUID, GameName: p.GameName, TagLine: p.TagLine}
+	}
+	sm := riot.Summoner{ID: "sm-" + p.PUUID, PUUID: p.PUUID, ProfileIconID: p.IconID, SummonerLevel: max(p.Level, 30)}
+	s.summoners[p.PUUID] = sm
+	s.leagues[p.PUUID] = []riot.LeagueEntry{}
+	if p.Tier != "" {
+		s.leagues[p.PUUID] = []riot.LeagueEntry{{
+			QueueType: "RANKED_SOLO_5x5", Tier: p.Tier, Rank: p.Division, LeaguePoints: p.LP, Wins: p.Wins, Losses: p.Losses,
+		}}
+	}
+	s.masteries[p.PUUID] = append([]riot.Mastery{}, p.Masteries...)
+	s.codes[sm.ID] = p.ThirdPartyCode
+}
+
+// AddMatch stores m and lists it for each participant, newest first.
+func (s *Server) AddMatch(m riot.Match) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := m.Metadata.MatchID
+	s.matches[id] = m
+	for _, p := range m.Info.Participants {
+		if riot.IsBotPUUID(p.PUUID) {
+			continue
+		}
+		ids := append(s.matchIDs[p.PUUID], id)
+		sort.SliceStable(ids, func(i, j int) bool {
+			return s.matches[ids[i]].Info.GameCreation > s.matches[ids[j]].Info.GameCreation
+		})
+		s.matchIDs[p.PUUID] = ids
+	}
+}
+
+// Inject queues a fault; faults apply in the order they were injected.
+func (s *Server) Inject(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults drops every pending fault.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// Hits returns how many requests route received ("" = all), faults included.
+func (s *Server) Hits(route string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if route != "" {
+		return s.hits[route]
+	}
+	n := 0
+	for _, c := range s.hits {
+		n += c
+	}
+	return n
+}
+
+// ResetHits zeroes the request counters.
+func (s *Server) ResetHits() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hits = map[string]int{}
+}
+
+// route counts the request and answers it with a pending fault, if any,
+// before handing it to h.
+func (s *Server) route(name string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.hits[name]++
+		var fault *Fault
+		for i, f := range s.faults {
+			if f.Route == name || (f.Route == "" && name != RouteChampions && name != RouteStatic) {
+				fault = f
+				if f.Times > 0 {
+					if f.Times--; f.Times == 0 {
+						s.faults = append(s.faults[:i], s.faults[i+1:]...)
+					}
+				}
+				break
+			}
+		}
+		s.mu.Unlock()
+		if s.Latency > 0 {
+			time.Sleep(s.Latency)
+		}
+		if fault != nil {
+			if fault.RetryAfter != "" {
+				w.Header().Set("Retry-After", fault.RetryAfter)
+			}
+			http.Error(w, http.StatusText(fault.Status), fault.Status)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// reply writes v as JSON, or 404 when found is false.
+func reply(w http.ResponseWriter, v any, found bool) {
+	if !found {
+		http.Error(w, `{"status":{"status_code":404,"message":"Data not found"}}`, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func (s *Server) account(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.accounts[strings.ToLower(r.PathValue("name")+"#"+r.PathValue("tag"))]
+	reply(w, a, ok)
+}
+
+func (s *Server) summoner(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sm, ok := s.summoners[r.PathValue("puuid")]
+	reply(w, sm, ok)
+}
+
+func (s *Server) ids(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	start, _ := strconv.Atoi(q.Get("start"))
+	count, err := strconv.Atoi(q.Get("count"))
+	if err != nil {
+		count = 20
+	}
+	since, _ := strconv.ParseInt(q.Get("startTime"), 10, 64)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := []string{}
+	for _, id := range s.matchIDs[r.PathValue("puuid")] {
+		if since > 0 && s.matches[id].Info.GameCreation < since*1000 {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	ids = ids[min(start, len(ids)):]
+	reply(w, ids[:min(count, len(ids))], true)
+}
+
+func (s *Server) match(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.matches[r.PathValue("id")]
+	reply(w, m, ok)
+}
+
+func (s *Server) league(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.leagues[r.PathValue("puuid")]
+	if !ok {
+		e = []riot.LeagueEntry{}
+	}
+	reply(w, e, true)
+}
+
+func (s *Server) mastery(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.masteries[r.PathValue("puuid")]
+	if !ok {
+		m = []riot.Mastery{}
+	}
+	reply(w, m, true)
+}
+
+func (s *Server) code(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	code, ok := s.codes[r.PathValue("id")]
+	reply(w, code, ok && code != "")
+}
+
+func (s *Server) champions(w http.ResponseWriter, r *http.Request) {
+	data := map[string]any{}
+	for _, c := range Champions {
+		data[c.ID] = map[string]string{"id": c.ID, "key": strconv.Itoa(c.Key), "name": c.Name}
+	}
+	reply(w, map[string]any{"type": "champion", "version": r.PathValue("version"), "data": data}, true)
+}
+
+func (s *Server) versions(w http.ResponseWriter, r *http.Request) {
+	reply(w, []string{riot.DataDragonVersion}, true)
+}
+
+// Queues is the queue table the server serves: those the generated matches use.
+var Queues = []riot.Queue{
+	{ID: 0, Map: "Custom games"},
+	{ID: 400, Map: "Summoner's Rift", Description: "5v5 Draft Pick games"},
+	{ID: 420, Map: "Summoner's Rift", Description: "5v5 Ranked Solo games"},
+	{ID: 430, Map: "Summoner's Rift", Description: "5v5 Blind Pick games"},
+	{ID: 440, Map: "Summoner's Rift", Description: "5v5 Ranked Flex games"},
+	{ID: 450, Map: "Howling Abyss", Description: "5v5 ARAM games"},
+}
+
+func (s *Server) queues(w http.ResponseWriter, r *http.Request) {
+	reply(w, Queues, true)
+}
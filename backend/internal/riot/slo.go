@@ -0,0 +1,166 @@
+package riot
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"lol_custom_skill_matching/internal/clock"
+)
+
+// SLO defaults: the rolling window and the thresholds a watched endpoint is
+// held to.
+const (
+	DefaultSLOWindow    = 5 * time.Minute
+	DefaultSLOP95       = 3 * time.Second
+	DefaultSLOErrorRate = 0.1
+)
+
+const (
+	// sloMinRequests is how many requests in the window it takes to judge an
+	// endpoint: a couple of slow answers after a quiet hour aren't an outage,
+	// nor a couple of fast ones a recovery. Short of it the last verdict holds.
+	sloMinRequests = 20
+	// sloMaxSamples caps the requests kept per endpoint.
+	sloMaxSamples = 2000
+)
+
+// SLO tracks the latency and error rate of each Riot endpoint over a rolling
+// Window, per HTTP request (retries included, limiter waits excluded). A 5xx
+// or network error is an error; 429 and a rejected key say nothing about
+// Riot's health and aren't counted. A watched endpoint whose p95 passes P95
+// or whose error rate passes ErrorRate is degraded, and OnChange hears when
+// it becomes so and when it recovers.
+type SLO struct {
+	Window    time.Duration
+	P95       time.Duration
+	ErrorRate float64
+	// Watch are the endpoints held to the thresholds (nil = match-v5's,
+	// EndpointMatchIDs and EndpointMatch); the others are only tracked.
+	Watch []string
+	// OnChange, when set, is called on the request's goroutine when a watched
+	// endpoint becomes degraded or recovers.
+	OnChange func(EndpointSLO)
+	// Clock dates the requests (nil = the system clock).
+	Clock clock.Clock
+
+	mu       sync.Mutex
+	samples  map[string][]sloSample
+	degraded map[string]bool
+}
+
+type sloSample struct {
+	at     time.Time
+	took   time.Duration
+	failed bool
+}
+
+// EndpointSLO is an endpoint's figures over the window, for /status and
+// /metrics.
+type EndpointSLO struct {
+	Endpoint  string  `json:"endpoint"`
+	Requests  int     `json:"requests"`
+	Errors    int     `json:"errors"`
+	ErrorRate float64 `json:"error_rate"`
+	P50Ms     int64   `json:"p50_ms"`
+	P95Ms     int64   `json:"p95_ms"`
+	Watched   bool    `json:"watched"`
+	Degraded  bool    `json:"degraded"`
+}
+
+func NewSLO() *SLO {
+	return &SLO{Window: DefaultSLOWindow, P95: DefaultSLOP95, ErrorRate: DefaultSLOErrorRate}
+}
+
+func (s *SLO) watched(endpoint string) bool {
+	if s.Watch == nil {
+		return endpoint == EndpointMatchIDs || endpoint == EndpointMatch
+	}
+	return slices.Contains(s.Watch, endpoint)
+}
+
+// record adds a request to endpoint ("" = untracked) that took took.
+func (s *SLO) record(endpoint string, took time.Duration, failed bool) {
+	if s == nil || endpoint == "" {
+		return
+	}
+	now := clock.Or(s.Clock).Now()
+	s.mu.Lock()
+	if s.samples == nil {
+		s.samples, s.degraded = map[string][]sloSample{}, map[string]bool{}
+	}
+	kept := s.prune(endpoint, now)
+	if len(kept) == sloMaxSamples {
+		kept = slices.Delete(kept, 0, 1)
+	}
+	s.samples[endpoint] = append(kept, sloSample{at: now, took: took, failed: failed})
+	var changed *EndpointSLO
+	if s.watched(endpoint) {
+		st := s.stats(endpoint)
+		if st.Degraded != s.degraded[endpoint] {
+			s.degraded[endpoint] = st.Degraded
+			changed = &st
+		}
+	}
+	s.mu.Unlock()
+	if changed != nil && s.OnChange != nil {
+		s.OnChange(*changed)
+	}
+}
+
+// prune drops endpoint's requests older than the window.
+func (s *SLO) prune(endpoint string, now time.Time) []sloSample {
+	kept := s.samples[endpoint]
+	i, _ := slices.BinarySearchFunc(kept, now.Add(-s.Window), func(x sloSample, t time.Time) int { return x.at.Compare(t) })
+	kept = slices.Delete(kept, 0, i)
+	s.samples[endpoint] = kept
+	return kept
+}
+
+// stats sums up endpoint's window; the caller holds s.mu.
+func (s *SLO) stats(endpoint string) EndpointSLO {
+	st := EndpointSLO{Endpoint: endpoint, Watched: s.watched(endpoint)}
+	took := make([]time.Duration, 0, len(s.samples[endpoint]))
+	for _, x := range s.samples[endpoint] {
+		took = append(took, x.took)
+		if x.failed {
+			st.Errors++
+		}
+	}
+	st.Requests = len(took)
+	st.Degraded = s.degraded[endpoint]
+	if st.Requests == 0 {
+		return st
+	}
+	slices.Sort(took)
+	q := func(p float64) int64 {
+		i := int(math.Ceil(p*float64(len(took)))) - 1
+		return took[max(i, 0)].Milliseconds()
+	}
+	st.ErrorRate = float64(st.Errors) / float64(st.Requests)
+	st.P50Ms, st.P95Ms = q(0.5), q(0.95)
+	if st.Watched && st.Requests >= sloMinRequests {
+		st.Degraded = st.P95Ms > s.P95.Milliseconds() || st.ErrorRate > s.ErrorRate
+	}
+	return st
+}
+
+// Stats returns each endpoint Riot was asked about within the window, by
+// name (nil for a nil SLO).
+func (s *SLO) Stats() []EndpointSLO {
+	if s == nil {
+		return nil
+	}
+	now := clock.Or(s.Clock).Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []EndpointSLO
+	for _, endpoint := range slices.Sorted(maps.Keys(s.samples)) {
+		if len(s.prune(endpoint, now)) > 0 {
+			out = append(out, s.stats(endpoint))
+		}
+	}
+	return out
+}
@@ -0,0 +1,52 @@
+package riot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// StaticDataHost serves the developer portal's game constants (queues.json);
+// tests point it at a fake CDN, as DataDragonHost.
+var StaticDataHost = "https://static.developer.riotgames.com"
+
+// VersionsURL lists the Data Dragon versions, newest first.
+func VersionsURL() string { return DataDragonHost + "/api/versions.json" }
+
+// QueuesURL is the queue id table of the developer portal.
+func QueuesURL() string { return StaticDataHost + "/docs/lol/queues.json" }
+
+// FetchVersions loads the Data Dragon versions, newest first, retrying as
+// FetchChampions does.
+func FetchVersions(ctx context.Context, client *http.Client) ([]string, error) {
+	raw, err := fetchDataDragon(ctx, client, VersionsURL())
+	if err != nil {
+		return nil, err
+	}
+	var vs []string
+	if err := json.Unmarshal(raw, &vs); err != nil {
+		return nil, fmt.Errorf("data dragon: versions.json: %w", err)
+	}
+	if len(vs) == 0 {
+		return nil, errors.New("data dragon: no versions in versions.json")
+	}
+	return vs, nil
+}
+
+// FetchQueues loads the queue table, retrying as FetchChampions does.
+func FetchQueues(ctx context.Context, client *http.Client) ([]Queue, error) {
+	raw, err := fetchDataDragon(ctx, client, QueuesURL())
+	if err != nil {
+		return nil, err
+	}
+	var qs []Queue
+	if err := json.Unmarshal(raw, &qs); err != nil {
+		return nil, fmt.Errorf("queues.json: %w", err)
+	}
+	if len(qs) == 0 {
+		return nil, errors.New("queues.json: no queues")
+	}
+	return qs, nil
+}
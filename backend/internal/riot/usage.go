@@ -0,0 +1,53 @@
+package riot
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Usage counts the Riot requests sent under a context (retries included) and
+// the lookups callers answered from their own caches instead.
+type Usage struct {
+	parent      *Usage
+	calls       atomic.Int64
+	cacheHits   atomic.Int64
+	cacheMisses atomic.Int64
+}
+
+type usageKey struct{}
+
+// WithUsage starts counting the requests made under the returned context.
+// Counting nests: a Usage started inside another also counts toward it.
+func WithUsage(ctx context.Context) (context.Context, *Usage) {
+	u := &Usage{parent: usageFrom(ctx)}
+	return context.WithValue(ctx, usageKey{}, u), u
+}
+
+func usageFrom(ctx context.Context) *Usage {
+	u, _ := ctx.Value(usageKey{}).(*Usage)
+	return u
+}
+
+// CountCache records a cache lookup made on behalf of ctx's Usage, if any.
+func CountCache(ctx context.Context, hit bool) {
+	for u := usageFrom(ctx); u != nil; u = u.parent {
+		if hit {
+			u.cacheHits.Add(1)
+		} else {
+			u.cacheMisses.Add(1)
+		}
+	}
+}
+
+func countCall(ctx context.Context) {
+	for u := usageFrom(ctx); u != nil; u = u.parent {
+		u.calls.Add(1)
+	}
+}
+
+// Calls is the number of HTTP requests sent to Riot.
+func (u *Usage) Calls() int64 { return u.calls.Load() }
+
+// CacheHits and CacheMisses count the lookups reported with CountCache.
+func (u *Usage) CacheHits() int64   { return u.cacheHits.Load() }
+func (u *Usage) CacheMisses() int64 { return u.cacheMisses.Load() }
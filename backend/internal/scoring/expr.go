@@ -0,0 +1,140 @@
+// Package scoring evaluates deployment-supplied skill score formulas. A formula
+// is a Starlark expression over the features the analyzer exposes, e.g.
+//
+//	current_rank*2 + avg_lobby_rank + mastery_top3/1000
+//
+// Starlark brings the operators (+ - * / // %, comparisons, and/or/not,
+// a if cond else b) and built-ins such as min, max and abs; formulas can also
+// call sqrt, log, round and clamp(x, lo, hi). Features are floats, so / never
+// truncates. A formula can't load modules or do I/O, and its evaluation is
+// capped at maxSteps.
+package scoring
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"go.starlark.net/resolve"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+// Features are the named inputs of a formula.
+type Features map[string]float64
+
+// maxSteps bounds one evaluation (a comprehension over range(1e9) would
+// otherwise hold the analysis).
+const maxSteps = 100_000
+
+// maxScore bounds the result, far above any real score, so it fits an int.
+const maxScore = 1e12
+
+// fileOptions are the Starlark dialect of formulas: the defaults, so no while
+// loops or recursion.
+var fileOptions = &syntax.FileOptions{}
+
+// builtins are the functions formulas get on top of Starlark's universe.
+var builtins = starlark.StringDict{
+	"sqrt": floatFunc("sqrt", 1, func(a []float64) (float64, error) {
+		if a[0] < 0 {
+			return 0, fmt.Errorf("sqrt of negative %g", a[0])
+		}
+		return math.Sqrt(a[0]), nil
+	}),
+	"log": floatFunc("log", 1, func(a []float64) (float64, error) {
+		if a[0] <= 0 {
+			return 0, fmt.Errorf("log of non-positive %g", a[0])
+		}
+		return math.Log(a[0]), nil
+	}),
+	"round": floatFunc("round", 1, func(a []float64) (float64, error) { return math.Round(a[0]), nil }),
+	"clamp": floatFunc("clamp", 3, func(a []float64) (float64, error) { return math.Max(a[1], math.Min(a[2], a[0])), nil }),
+}
+
+// floatFunc is a built-in taking n numbers.
+func floatFunc(name string, n int, fn func([]float64) (float64, error)) *starlark.Builtin {
+	return starlark.NewBuiltin(name, func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if len(kwargs) > 0 || len(args) != n {
+			return nil, fmt.Errorf("%s: want %d arguments, got %d", name, n, len(args)+len(kwargs))
+		}
+		a := make([]float64, n)
+		for i, v := range args {
+			f, ok := starlark.AsFloat(v)
+			if !ok {
+				return nil, fmt.Errorf("%s: argument %d is a %s, not a number", name, i+1, v.Type())
+			}
+			a[i] = f
+		}
+		r, err := fn(a)
+		if err != nil {
+			return nil, err
+		}
+		return starlark.Float(r), nil
+	})
+}
+
+// Expr is a compiled formula; it is immutable and safe for concurrent use.
+type Expr struct {
+	src string
+}
+
+func (e *Expr) String() string { return e.src }
+
+// Eval computes the formula. Errors (division by zero, a wrong type, too many
+// steps) and results that aren't finite numbers fail it; a number is rounded
+// half away from zero to the integer score.
+func (e *Expr) Eval(f Features) (int, error) {
+	// resolving annotates the syntax tree, so every evaluation parses its own
+	expr, err := fileOptions.ParseExpr("SCORE_FORMULA", e.src, 0)
+	if err != nil {
+		return 0, err
+	}
+	env := make(starlark.StringDict, len(builtins)+len(f))
+	for k, v := range builtins {
+		env[k] = v
+	}
+	for k, v := range f {
+		env[k] = starlark.Float(v)
+	}
+	thread := &starlark.Thread{Name: "score", Print: func(*starlark.Thread, string) {}}
+	thread.SetMaxExecutionSteps(maxSteps)
+	v, err := starlark.EvalExprOptions(fileOptions, thread, expr, env)
+	if err != nil {
+		var ee *starlark.EvalError
+		if errors.As(err, &ee) {
+			return 0, errors.New(ee.Msg)
+		}
+		return 0, err
+	}
+	var x float64
+	switch v := v.(type) {
+	case starlark.Int, starlark.Float:
+		x, _ = starlark.AsFloat(v)
+	default:
+		return 0, fmt.Errorf("score is a %s, not a number", v.Type())
+	}
+	if math.IsNaN(x) || math.IsInf(x, 0) || math.Abs(x) > maxScore {
+		return 0, fmt.Errorf("score %g is not a finite number in range", x)
+	}
+	return int(math.Round(x)), nil
+}
+
+// Compile parses src and checks that every name is in known (nil = any name)
+// or a built-in.
+func Compile(src string, known []string) (*Expr, error) {
+	expr, err := fileOptions.ParseExpr("SCORE_FORMULA", src, 0)
+	if err != nil {
+		return nil, err
+	}
+	isPredeclared := func(name string) bool {
+		return known == nil || slices.Contains(known, name) || builtins.Has(name)
+	}
+	if _, err := resolve.ExprOptions(fileOptions, expr, isPredeclared, starlark.Universe.Has); err != nil {
+		have := slices.Sorted(slices.Values(known))
+		return nil, fmt.Errorf("%v (features: %s)", err, strings.Join(have, ", "))
+	}
+	return &Expr{src: src}, nil
+}
@@ -0,0 +1,124 @@
+package scoring
+
+import (
+	"strings"
+	"testing"
+)
+
+var known = []string{"current_rank", "avg_lobby_rank", "mastery_top3", "lobby_rank_skipped", "winrate_rank", "games"}
+
+var features = Features{
+	"current_rank": 1500, "avg_lobby_rank": 1400, "mastery_top3": 2_345_678,
+	"lobby_rank_skipped": 0, "winrate_rank": 1600, "games": 0,
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"empty", "", "got end of file"},
+		{"unbalanced", "(current_rank + 1", "got end of file"},
+		{"dangling operator", "current_rank *", "got end of file"},
+		{"statement", "x = 1", "got '='"},
+		{"unknown feature", "current_rank + lobby_size", "undefined: lobby_size"},
+		{"unknown function", "median(current_rank)", "undefined: median"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile(tt.src, known)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Compile(%q) = %v, want an error with %q", tt.src, err, tt.want)
+			}
+		})
+	}
+	if _, err := Compile("current_rank + lobby_size", nil); err != nil {
+		t.Errorf("Compile with any name = %v", err)
+	}
+}
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		name, src string
+		want      int
+	}{
+		{"precedence", "1 + 2 * 3", 7},
+		{"parentheses", "(1 + 2) * 3", 9},
+		{"unary minus binds tighter than *", "-2 * 3 + 10", 4},
+		{"float division", "mastery_top3/1000", 2346},
+		{"floor division", "7 // 2", 3},
+		{"modulo", "current_rank % 400", 300},
+		{"built-in formula", "current_rank*2 + avg_lobby_rank + mastery_top3/1000", 6746},
+		{"conditional", "winrate_rank if lobby_rank_skipped else avg_lobby_rank", 1400},
+		{"comparison in condition", "100 if current_rank >= 1500 and avg_lobby_rank < 1500 else 0", 100},
+		{"min max abs", "min(current_rank, avg_lobby_rank) + max(1, 2) + abs(-3)", 1405},
+		{"clamp", "clamp(current_rank, 0, 1000)", 1000},
+		{"sqrt log", "sqrt(16) + log(1)", 4},
+		{"round", "round(2.5)", 3},
+		{"rounds half away from zero", "2.5", 3},
+		{"rounds negative half away from zero", "-2.5", -3},
+		{"rounds down", "1400.49", 1400},
+		{"integer result", "current_rank // 1", 1500},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := Compile(tt.src, known)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := e.Eval(features)
+			if err != nil || got != tt.want {
+				t.Errorf("Eval(%q) = %d, %v, want %d", tt.src, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalShortCircuit(t *testing.T) {
+	tests := []string{
+		// the right side would divide by zero
+		"games > 0 and 100/games > 1 or 5",
+		"(games == 0 and 7) or 100/games",
+		"current_rank if games == 0 else current_rank/games",
+	}
+	for _, src := range tests {
+		e, err := Compile(src, known)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := e.Eval(features); err != nil {
+			t.Errorf("Eval(%q) = %v, want the division skipped", src, err)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"division by zero", "current_rank / games", "division by zero"},
+		{"floor division by zero", "current_rank // games", "division by zero"},
+		{"modulo by zero", "current_rank % games", "by zero"},
+		{"log of zero", "log(games)", "log of non-positive"},
+		{"boolean result", "current_rank > 0", "bool, not a number"},
+		{"string result", `"high"`, "string, not a number"},
+		{"infinite result", "1e308 * 10", "not a finite number"},
+		{"wrong argument", `sqrt("x")`, "not a number"},
+		{"arity", "clamp(1, 2)", "want 3 arguments"},
+		{"step limit", "len([x for x in range(1000000)])", "too many steps"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := Compile(tt.src, known)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := e.Eval(features); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Eval(%q) = %v, want an error with %q", tt.src, err, tt.want)
+			}
+		})
+	}
+	e, _ := Compile("current_rank + lobby_size", nil)
+	if _, err := e.Eval(features); err == nil || !strings.Contains(err.Error(), "lobby_size") {
+		t.Errorf("Eval with an unknown feature = %v", err)
+	}
+}
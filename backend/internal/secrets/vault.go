@@ -0,0 +1,320 @@
+// Package secrets keeps per-community secrets (webhook URLs, tokens,
+// credentials) encrypted at rest with a master key from the environment, so
+// config files and backups only hold references ("secret:<name>").
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RefPrefix marks a config value that names a secret instead of holding it.
+const RefPrefix = "secret:"
+
+var (
+	ErrNotFound    = errors.New("secret not found")
+	ErrInvalidName = errors.New("invalid secret name (letters, digits, . _ - and / between parts)")
+)
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9._-]+(/[A-Za-z0-9._-]+)*$`)
+
+// Info describes a stored secret without its value.
+type Info struct {
+	Name      string    `json:"name"`
+	KeyID     string    `json:"key_id"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// entry is one encrypted secret as stored. The name is authenticated with
+// the value, so entries can't be swapped between names.
+type entry struct {
+	KeyID     string    `json:"key_id"`
+	Nonce     []byte    `json:"nonce"`
+	Data      []byte    `json:"data"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type file struct {
+	Version int              `json:"version"`
+	Secrets map[string]entry `json:"secrets"`
+}
+
+type key struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// Vault is a file of secrets encrypted with AES-256-GCM. The file is read on
+// every access, so a restored backup takes effect without a restart.
+type Vault struct {
+	Path string
+
+	mu       sync.Mutex
+	current  key
+	previous []key // still accepted for reading after a key rotation
+}
+
+// ParseKey decodes a master key: 32 bytes, base64 or hex encoded.
+func ParseKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	for _, dec := range []func(string) ([]byte, error){base64.StdEncoding.DecodeString, base64.RawURLEncoding.DecodeString, hex.DecodeString} {
+		if b, err := dec(s); err == nil && len(b) == 32 {
+			return b, nil
+		}
+	}
+	return nil, errors.New("master key must be 32 bytes, base64 or hex encoded (e.g. openssl rand -base64 32)")
+}
+
+func newKey(raw []byte) (key, error) {
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return key{}, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return key{}, err
+	}
+	sum := sha256.Sum256(raw)
+	return key{id: hex.EncodeToString(sum[:4]), aead: aead}, nil
+}
+
+// Open returns the vault at path encrypting with master. Secrets encrypted
+// with one of the previous keys are re-encrypted with master, which is how a
+// key rotated in the environment takes over.
+func Open(path string, master []byte, previous ...[]byte) (*Vault, error) {
+	cur, err := newKey(master)
+	if err != nil {
+		return nil, err
+	}
+	v := &Vault{Path: path, current: cur}
+	for _, raw := range previous {
+		k, err := newKey(raw)
+		if err != nil {
+			return nil, err
+		}
+		v.previous = append(v.previous, k)
+	}
+	f, err := v.read()
+	if err != nil {
+		return nil, err
+	}
+	stale := false
+	for name, e := range f.Secrets {
+		value, err := v.decrypt(name, e)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if e.KeyID != cur.id {
+			updated := e.UpdatedAt
+			if e, err = v.encrypt(name, value); err != nil {
+				return nil, err
+			}
+			e.UpdatedAt = updated
+			f.Secrets[name], stale = e, true
+		}
+	}
+	if stale {
+		if err := v.write(f); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// KeyID identifies the current master key (the first bytes of its SHA-256).
+func (v *Vault) KeyID() string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.current.id
+}
+
+func (v *Vault) read() (file, error) {
+	f := file{Version: 1, Secrets: map[string]entry{}}
+	raw, err := os.ReadFile(v.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return f, err
+	}
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return f, fmt.Errorf("reading %s: %w", v.Path, err)
+	}
+	if f.Secrets == nil {
+		f.Secrets = map[string]entry{}
+	}
+	return f, nil
+}
+
+// write replaces the file atomically.
+func (v *Vault) write(f file) error {
+	raw, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(v.Path), 0o700); err != nil {
+		return err
+	}
+	tmp := v.Path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, v.Path)
+}
+
+func (v *Vault) encrypt(name, value string) (entry, error) {
+	nonce := make([]byte, v.current.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return entry{}, err
+	}
+	return entry{
+		KeyID: v.current.id, Nonce: nonce, UpdatedAt: time.Now(),
+		Data: v.current.aead.Seal(nil, nonce, []byte(value), []byte(name)),
+	}, nil
+}
+
+func (v *Vault) decrypt(name string, e entry) (string, error) {
+	for _, k := range append([]key{v.current}, v.previous...) {
+		if k.id != e.KeyID {
+			continue
+		}
+		plain, err := k.aead.Open(nil, e.Nonce, e.Data, []byte(name))
+		if err != nil {
+			return "", fmt.Errorf("decrypting with key %s: %w", k.id, err)
+		}
+		return string(plain), nil
+	}
+	return "", fmt.Errorf("encrypted with key %s, which is not configured", e.KeyID)
+}
+
+// Get returns the value of secret name.
+func (v *Vault) Get(name string) (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	f, err := v.read()
+	if err != nil {
+		return "", err
+	}
+	e, ok := f.Secrets[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return v.decrypt(name, e)
+}
+
+// Set stores (or replaces) secret name.
+func (v *Vault) Set(name, value string) (Info, error) {
+	if !validName.MatchString(name) {
+		return Info{}, ErrInvalidName
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	f, err := v.read()
+	if err != nil {
+		return Info{}, err
+	}
+	e, err := v.encrypt(name, value)
+	if err != nil {
+		return Info{}, err
+	}
+	f.Secrets[name] = e
+	if err := v.write(f); err != nil {
+		return Info{}, err
+	}
+	return Info{Name: name, KeyID: e.KeyID, UpdatedAt: e.UpdatedAt}, nil
+}
+
+// Delete removes secret name.
+func (v *Vault) Delete(name string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	f, err := v.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := f.Secrets[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	delete(f.Secrets, name)
+	return v.write(f)
+}
+
+// List describes the stored secrets by name.
+func (v *Vault) List() ([]Info, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	f, err := v.read()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Info, 0, len(f.Secrets))
+	for name, e := range f.Secrets {
+		out = append(out, Info{Name: name, KeyID: e.KeyID, UpdatedAt: e.UpdatedAt})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// Rotate re-encrypts every secret with master, which becomes the current key;
+// the old one stays accepted for reading until restart. Returns how many
+// secrets were re-encrypted.
+func (v *Vault) Rotate(master []byte) (int, error) {
+	next, err := newKey(master)
+	if err != nil {
+		return 0, err
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	f, err := v.read()
+	if err != nil {
+		return 0, err
+	}
+	old, prev := v.current, v.previous
+	v.current, v.previous = next, append(slices.Clone(prev), old)
+	for name, e := range f.Secrets {
+		value, err := v.decrypt(name, e)
+		if err == nil {
+			e, err = v.encrypt(name, value)
+		}
+		if err != nil {
+			v.current, v.previous = old, prev
+			return 0, fmt.Errorf("%s: %w", name, err)
+		}
+		e.UpdatedAt = f.Secrets[name].UpdatedAt
+		f.Secrets[name] = e
+	}
+	if err := v.write(f); err != nil {
+		v.current, v.previous = old, prev
+		return 0, err
+	}
+	return len(f.Secrets), nil
+}
+
+// Resolve returns value, or the secret it names when it is "secret:<name>".
+// A nil vault resolves plain values only.
+func (v *Vault) Resolve(value string) (string, error) {
+	name, ok := strings.CutPrefix(value, RefPrefix)
+	if !ok {
+		return value, nil
+	}
+	if v == nil {
+		return "", fmt.Errorf("%q needs the secrets vault (SECRETS_KEY)", value)
+	}
+	return v.Get(name)
+}
@@ -0,0 +1,73 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSignVerify(t *testing.T) {
+	s := New([]byte("result signing secret"))
+	payload := []byte(`{"id":"r1","sumA":512,"sumB":498}`)
+	sig := s.Sign(payload)
+	if sig.Algorithm != Algorithm || sig.KeyID != s.KeyID() {
+		t.Fatalf("signature = %+v, want %s by %s", sig, Algorithm, s.KeyID())
+	}
+	if !Verify(s.PublicKey(), payload, sig) {
+		t.Error("the public key rejects the signature")
+	}
+	if Verify(s.PublicKey(), []byte(`{"id":"r1","sumA":600,"sumB":498}`), sig) {
+		t.Error("an edited payload verifies")
+	}
+	if other := New([]byte("another secret")); other.Verify(payload, sig) {
+		t.Error("another key verifies the signature")
+	}
+	if again := New([]byte("result signing secret")); !again.PublicKey().Equal(s.PublicKey()) || again.KeyID() != s.KeyID() {
+		t.Error("the same secret gives another key")
+	}
+}
+
+func TestPublicKeyPEM(t *testing.T) {
+	s := New([]byte("secret"))
+	block, _ := pem.Decode([]byte(s.PublicKeyPEM()))
+	if block == nil || block.Type != "PUBLIC KEY" {
+		t.Fatalf("PublicKeyPEM = %q", s.PublicKeyPEM())
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k, ok := pub.(ed25519.PublicKey); !ok || !k.Equal(s.PublicKey()) {
+		t.Errorf("PEM key = %v, want %x", pub, s.PublicKey())
+	}
+}
+
+func TestSignRequest(t *testing.T) {
+	s := New([]byte("secret"))
+	body := []byte(`{"content":"hello"}`)
+	req, _ := http.NewRequest(http.MethodPost, "http://example.invalid", nil)
+	s.SignRequest(req, body)
+	v, ok := strings.CutPrefix(req.Header.Get(HeaderSignature), "ed25519=")
+	sig, err := hex.DecodeString(v)
+	if !ok || err != nil {
+		t.Fatalf("%s = %q", HeaderSignature, req.Header.Get(HeaderSignature))
+	}
+	msg := req.Header.Get(HeaderTimestamp) + "." + string(body)
+	if !ed25519.Verify(s.PublicKey(), []byte(msg), sig) {
+		t.Error("the webhook signature doesn't verify")
+	}
+	if req.Header.Get(HeaderKeyID) != s.KeyID() {
+		t.Errorf("%s = %q, want %q", HeaderKeyID, req.Header.Get(HeaderKeyID), s.KeyID())
+	}
+
+	var nilSigner *Signer
+	req, _ = http.NewRequest(http.MethodPost, "http://example.invalid", nil)
+	nilSigner.SignRequest(req, body)
+	if len(req.Header) != 0 {
+		t.Errorf("a nil Signer set %v", req.Header)
+	}
+}
@@ -0,0 +1,222 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"lol_custom_skill_matching/internal/clock"
+	"lol_custom_skill_matching/internal/resultfile"
+	"lol_custom_skill_matching/internal/store"
+)
+
+// DefaultTime is when the nightly snapshot is taken: 04:00 local time, after
+// game nights and before anyone reads the digest.
+const DefaultTime = 4 * time.Hour
+
+// Scheduler takes a snapshot every day at Time and keeps the latest one ready
+// to serve.
+type Scheduler struct {
+	Store store.Store
+	// Dir keeps one file per snapshot (<date>.json) so the latest survives a
+	// restart and movements span it (nil = memory only).
+	Dir *resultfile.Dir
+	// Time is the time of day (since local midnight) snapshots are taken.
+	Time time.Duration
+	// OnTake, when set, receives every nightly snapshot (not those taken on
+	// demand). It runs on the scheduler's goroutine.
+	OnTake func(Snapshot)
+	// Clock schedules the snapshots (nil = the system clock).
+	Clock clock.Clock
+
+	mu     sync.Mutex
+	latest *Snapshot
+	raw    []byte // latest, encoded
+}
+
+func NewScheduler(st store.Store, dir *resultfile.Dir) *Scheduler {
+	return &Scheduler{Store: st, Dir: dir, Time: DefaultTime}
+}
+
+// Latest returns the latest snapshot, JSON encoded; ok is false before the
+// first one.
+func (s *Scheduler) Latest() (raw []byte, takenAt time.Time, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.latest == nil {
+		return nil, time.Time{}, false
+	}
+	return s.raw, s.latest.TakenAt, true
+}
+
+// Current returns the latest snapshot; ok is false before the first one.
+func (s *Scheduler) Current() (Snapshot, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.latest == nil {
+		return Snapshot{}, false
+	}
+	return *s.latest, true
+}
+
+// Take snapshots the store now, with movements since the latest snapshot,
+// and stores it.
+func (s *Scheduler) Take(now time.Time) (Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap := Take(s.Store, s.latest, now)
+	raw, err := json.Marshal(snap)
+	if err != nil {
+		return snap, err
+	}
+	s.latest, s.raw = &snap, raw
+	if s.Dir != nil {
+		id := now.Format(dayFormat)
+		if _, err := s.Dir.Write(id, func(w io.Writer) error { _, err := w.Write(raw); return err }); err != nil {
+			return snap, err
+		}
+	}
+	return snap, nil
+}
+
+// dayFormat names the file of each day's snapshot.
+const dayFormat = "2006-01-02"
+
+// Stored returns the snapshot stored for day's date, if any (never without
+// a Dir).
+func (s *Scheduler) Stored(day time.Time) (Snapshot, bool) {
+	var snap Snapshot
+	if s.Dir == nil {
+		return snap, false
+	}
+	raw, err := os.ReadFile(s.Dir.File(day.Format(dayFormat)))
+	if err != nil || json.Unmarshal(raw, &snap) != nil {
+		return snap, false
+	}
+	return snap, true
+}
+
+// Forget removes a deleted player (name#tag) from the latest and every stored
+// snapshot (see Snapshot.forget). Stored files keep their times so the order
+// of snapshots stays the same.
+func (s *Scheduler) Forget(riotID, alias string) error {
+	key := memberKey(riotID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.latest != nil {
+		snap := *s.latest
+		snap.Members = append([]Member{}, snap.Members...)
+		snap.Movements = append([]Movement{}, snap.Movements...)
+		snap.Leaderboard = append([]store.Standing{}, snap.Leaderboard...)
+		if snap.forget(key, alias) {
+			raw, err := json.Marshal(snap)
+			if err != nil {
+				return err
+			}
+			s.latest, s.raw = &snap, raw
+		}
+	}
+	if s.Dir == nil {
+		return nil
+	}
+	files, err := s.Dir.List()
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		raw, err := os.ReadFile(f.Path)
+		if err != nil {
+			return err
+		}
+		var snap Snapshot
+		if err := json.Unmarshal(raw, &snap); err != nil {
+			return fmt.Errorf("%s: %w", f.Path, err)
+		}
+		if !snap.forget(key, alias) {
+			continue
+		}
+		if raw, err = json.Marshal(snap); err != nil {
+			return err
+		}
+		id := strings.TrimSuffix(filepath.Base(f.Path), filepath.Ext(f.Path))
+		path, err := s.Dir.Write(id, func(w io.Writer) error { _, err := w.Write(raw); return err })
+		if err != nil {
+			return err
+		}
+		if err := os.Chtimes(path, f.ModTime, f.ModTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// load reads the newest stored snapshot, if any.
+func (s *Scheduler) load() error {
+	if s.Dir == nil {
+		return nil
+	}
+	files, err := s.Dir.List()
+	if err != nil || len(files) == 0 {
+		return err
+	}
+	raw, err := os.ReadFile(files[len(files)-1].Path)
+	if err != nil {
+		return err
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(raw, &snap); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.latest, s.raw = &snap, raw
+	s.mu.Unlock()
+	return nil
+}
+
+// Run takes a snapshot every day at Time until ctx is done. One missed while
+// the server was down is taken right away.
+func (s *Scheduler) Run(ctx context.Context) {
+	if err := s.load(); err != nil {
+		log.Printf("snapshot: loading the latest: %v", err)
+	}
+	clk := clock.Or(s.Clock)
+	for {
+		now := clk.Now()
+		due := lastDue(now, s.Time)
+		if _, at, ok := s.Latest(); !ok || at.Before(due) {
+			snap, err := s.Take(now)
+			if err != nil {
+				log.Printf("snapshot: %v", err)
+			} else {
+				log.Printf("snapshot: %d members, %d movements", len(snap.Members), len(snap.Movements))
+			}
+			if s.OnTake != nil {
+				s.OnTake(snap)
+			}
+		}
+		t := clk.NewTimer(due.AddDate(0, 0, 1).Sub(now))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C():
+		}
+	}
+}
+
+// lastDue is the latest time of day at (since local midnight) not after now.
+func lastDue(now time.Time, at time.Duration) time.Time {
+	y, m, d := now.Date()
+	due := time.Date(y, m, d, 0, 0, 0, 0, now.Location()).Add(at)
+	if due.After(now) {
+		due = due.AddDate(0, 0, -1)
+	}
+	return due
+}
@@ -0,0 +1,160 @@
+// Package snapshot precomputes a nightly view of the community (member
+// profiles, the season leaderboard, rank movements since the previous
+// snapshot and upcoming lobbies) so dashboards and digest posters read one JSON document instead
+// of querying the store and Riot.
+package snapshot
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/assets"
+	"lol_custom_skill_matching/internal/store"
+)
+
+// Snapshot is the community at TakenAt.
+type Snapshot struct {
+	TakenAt time.Time `json:"taken_at"`
+	Season  string    `json:"season"`
+	// PreviousAt is the snapshot Movements compare with (nil for the first).
+	PreviousAt  *time.Time       `json:"previous_at,omitempty"`
+	Members     []Member         `json:"members"`
+	Leaderboard []store.Standing `json:"leaderboard"`
+	Movements   []Movement       `json:"movements"`
+	// Upcoming are the lobbies scheduled after TakenAt, soonest first.
+	Upcoming []Event `json:"upcoming"`
+}
+
+// Event is a scheduled lobby.
+type Event struct {
+	LobbyID  string    `json:"lobby_id"`
+	Name     string    `json:"name"`
+	StartsAt time.Time `json:"starts_at"`
+	Players  int       `json:"players"`
+}
+
+// Member is a player with a rating and their latest analyzed profile, if any.
+type Member struct {
+	store.Rating
+	// Profile is the latest profile from a result, without its match list.
+	Profile   *analyzer.Profile `json:"profile,omitempty"`
+	ProfileAt *time.Time        `json:"profile_at,omitempty"`
+}
+
+// Movement is a member whose solo rank or skill score changed since the
+// previous snapshot.
+type Movement struct {
+	Player string       `json:"player"`
+	From   *assets.Rank `json:"from"`
+	To     *assets.Rank `json:"to"`
+	// RankDelta is the change in rank score (100 per division, 1 per LP).
+	RankDelta  int `json:"rank_delta"`
+	ScoreDelta int `json:"score_delta"`
+	// TierChange is set when the tier or division changed, not just LP.
+	TierChange bool `json:"tier_change"`
+}
+
+// Take builds the snapshot of st at now, with movements since prev (nil = none).
+func Take(st store.Store, prev *Snapshot, now time.Time) Snapshot {
+	snap := Snapshot{
+		TakenAt: now, Season: store.SeasonOf(now),
+		Members: []Member{}, Movements: []Movement{}, Upcoming: []Event{},
+		Leaderboard: store.Leaderboard(st.Results(), store.SeasonOf(now), st.Participations()),
+	}
+	for _, r := range st.Ratings() {
+		m := Member{Rating: r}
+		if p, at, ok := st.LatestProfile(r.Player); ok {
+			p.Matches, p.Raw = nil, nil
+			m.Profile, m.ProfileAt = &p, &at
+		}
+		snap.Members = append(snap.Members, m)
+	}
+	for _, l := range st.Lobbies() {
+		if l.StartsAt != nil && l.StartsAt.After(now) {
+			snap.Upcoming = append(snap.Upcoming, Event{LobbyID: l.ID, Name: l.Name, StartsAt: *l.StartsAt, Players: len(l.Players)})
+		}
+	}
+	sort.Slice(snap.Upcoming, func(i, j int) bool { return snap.Upcoming[i].StartsAt.Before(snap.Upcoming[j].StartsAt) })
+	if prev != nil {
+		snap.PreviousAt = &prev.TakenAt
+		snap.Movements = Movements(prev.Members, snap.Members)
+	}
+	return snap
+}
+
+// Movements compares members between two snapshots: every member with a
+// profile in both whose rank or score changed, biggest climb first.
+func Movements(before, after []Member) []Movement {
+	old := map[string]Member{}
+	for _, m := range before {
+		old[memberKey(m.Player)] = m
+	}
+	out := []Movement{}
+	for _, m := range after {
+		o, ok := old[memberKey(m.Player)]
+		if !ok || o.Profile == nil || m.Profile == nil {
+			continue
+		}
+		mv := Movement{
+			Player: m.Player, From: o.Profile.Rank, To: m.Profile.Rank,
+			RankDelta:  m.Profile.CurrentRankScore - o.Profile.CurrentRankScore,
+			ScoreDelta: m.Score - o.Score,
+		}
+		if mv.From != nil && mv.To != nil {
+			mv.TierChange = mv.From.Tier != mv.To.Tier || mv.From.Division != mv.To.Division
+		}
+		if mv.RankDelta != 0 || mv.ScoreDelta != 0 || mv.TierChange {
+			out = append(out, mv)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.RankDelta != b.RankDelta {
+			return a.RankDelta > b.RankDelta
+		}
+		return a.Player < b.Player
+	})
+	return out
+}
+
+// forget drops the member with key and their movements, and renames their
+// leaderboard standing to alias. Reports whether anything changed.
+func (snap *Snapshot) forget(key, alias string) bool {
+	changed := false
+	members := snap.Members[:0]
+	for _, m := range snap.Members {
+		if memberKey(m.Player) == key {
+			changed = true
+			continue
+		}
+		members = append(members, m)
+	}
+	snap.Members = members
+	moves := snap.Movements[:0]
+	for _, m := range snap.Movements {
+		if memberKey(m.Player) == key {
+			changed = true
+			continue
+		}
+		moves = append(moves, m)
+	}
+	snap.Movements = moves
+	for i := range snap.Leaderboard {
+		if memberKey(snap.Leaderboard[i].Player) == key {
+			snap.Leaderboard[i].Player = alias
+			changed = true
+		}
+	}
+	return changed
+}
+
+// memberKey normalizes a member's "name#tag" like store.RiotIDKey.
+func memberKey(riotID string) string {
+	i := strings.LastIndex(riotID, "#")
+	if i < 0 {
+		return store.RiotIDKey(riotID, "")
+	}
+	return store.RiotIDKey(riotID[:i], riotID[i+1:])
+}
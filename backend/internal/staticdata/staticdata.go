@@ -0,0 +1,199 @@
+// Package staticdata prefetches the game data that only changes with a patch
+// (the champion registry, the Data Dragon versions, the queue table and the
+// rank emblems) all at once when the server starts, and refreshes it in the
+// background, so analyses never wait on a CDN. The server reports itself
+// unready until the first load is done.
+package staticdata
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"lol_custom_skill_matching/internal/assets"
+	"lol_custom_skill_matching/internal/clock"
+	"lol_custom_skill_matching/internal/riot"
+)
+
+// DefaultInterval is how often the data is reloaded: well within the day the
+// analyzer caches champions for, so its copy never expires under an analysis.
+const DefaultInterval = 12 * time.Hour
+
+// loadTimeout bounds a load; a CDN that hasn't answered by then is retried by
+// the next one.
+const loadTimeout = 30 * time.Second
+
+// assetWorkers is the number of rank images checked at once.
+const assetWorkers = 4
+
+// Stats describe the loads so far, for GET /metrics.
+type Stats struct {
+	Ready    bool      `json:"ready"`
+	Loads    int64     `json:"loads"`
+	LastLoad time.Time `json:"last_load"`
+	// LoadMillis is how long the last load took, its parts overlapping.
+	LoadMillis int64  `json:"load_ms"`
+	Champions  int    `json:"champions"`
+	Version    string `json:"version"`        // the Data Dragon version served
+	Latest     string `json:"latest_version"` // the newest one out
+	Queues     int    `json:"queues"`
+	RankAssets int    `json:"rank_assets"` // emblems and crests that resolve
+	// Errors are the parts the last load failed, by name.
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// Loader loads the static data every Interval.
+type Loader struct {
+	// Champions loads the registry analyses name champions with
+	// (Analyzer.RefreshChampions, which caches it).
+	Champions func(context.Context) (*riot.Champions, error)
+	// Client fetches the rest (nil = http.DefaultClient).
+	Client   *http.Client
+	Interval time.Duration
+	// Clock schedules the loads (nil = the system clock).
+	Clock clock.Clock
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+func New(champions func(context.Context) (*riot.Champions, error)) *Loader {
+	return &Loader{Champions: champions, Interval: DefaultInterval}
+}
+
+// Ready reports whether the first load is done, whatever it got: champions
+// fall back to the last good copy (see Analyzer.Champions) and the next load
+// retries the rest.
+func (l *Loader) Ready() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats.Ready
+}
+
+// Stats returns the loads so far.
+func (l *Loader) Stats() Stats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats
+}
+
+// Run loads right away and then every Interval until ctx is done.
+func (l *Loader) Run(ctx context.Context) {
+	clk := clock.Or(l.Clock)
+	t := clk.NewTicker(l.Interval)
+	defer t.Stop()
+	for {
+		l.Load(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C():
+		}
+	}
+}
+
+// Load fetches every part at once and returns when all are done. A part that
+// fails keeps what the last load got.
+func (l *Loader) Load(ctx context.Context) {
+	clk := clock.Or(l.Clock)
+	start := clk.Now()
+	ctx, cancel := context.WithTimeout(ctx, loadTimeout)
+	defer cancel()
+	client := l.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	var (
+		wg       sync.WaitGroup
+		errMu    sync.Mutex
+		errs     = map[string]string{}
+		champs   *riot.Champions
+		versions []string
+		queues   []riot.Queue
+		images   int
+	)
+	part := func(name string, f func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := f(); err != nil {
+				log.Printf("static data: %s: %v", name, err)
+				errMu.Lock()
+				errs[name] = err.Error()
+				errMu.Unlock()
+			}
+		}()
+	}
+	part("champions", func() (err error) { champs, err = l.Champions(ctx); return err })
+	part("versions", func() (err error) { versions, err = riot.FetchVersions(ctx, client); return err })
+	part("queues", func() (err error) { queues, err = riot.FetchQueues(ctx, client); return err })
+	part("rank_assets", func() (err error) { images, err = checkImages(ctx, client, assets.RankImages()); return err })
+	wg.Wait()
+
+	now := clk.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s := &l.stats
+	s.Ready = true
+	s.Loads++
+	s.LastLoad, s.LoadMillis = now, now.Sub(start).Milliseconds()
+	s.Version, s.Errors = riot.DataDragonVersion, nil
+	if len(errs) > 0 {
+		s.Errors = errs
+	}
+	if champs != nil {
+		s.Champions = len(champs.ByID)
+	}
+	if len(versions) > 0 {
+		if versions[0] != s.Latest && versions[0] != riot.DataDragonVersion {
+			log.Printf("static data: Data Dragon %s is out; serving %s", versions[0], riot.DataDragonVersion)
+		}
+		s.Latest = versions[0]
+	}
+	if queues != nil {
+		riot.SetQueues(queues)
+		s.Queues = len(queues)
+	}
+	if images > 0 {
+		s.RankAssets = images
+	}
+	log.Printf("static data: loaded in %dms (%d champions, %d queues, %d rank images)", s.LoadMillis, s.Champions, s.Queues, s.RankAssets)
+}
+
+// checkImages asks for each of urls and returns how many exist. The images
+// themselves go to the clients straight from the CDN; this only notices a
+// renamed one before it shows up broken.
+func checkImages(ctx context.Context, client *http.Client, urls []string) (int, error) {
+	ok := riot.FanOut(ctx, assetWorkers, urls, func(ctx context.Context, u string) bool {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, u, nil)
+		if err != nil {
+			return false
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	})
+	var missing []string
+	for i, u := range urls {
+		if !ok[i] {
+			missing = append(missing, strings.TrimPrefix(u, assets.CommunityDragonImages+"/"))
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if len(missing) == len(urls) {
+		return 0, fmt.Errorf("none of the %d images answered", len(urls))
+	}
+	if len(missing) > 0 {
+		return len(urls) - len(missing), fmt.Errorf("%d of %d images missing: %s", len(missing), len(urls), strings.Join(missing, ", "))
+	}
+	return len(urls), nil
+}
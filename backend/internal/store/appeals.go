@@ -0,0 +1,131 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+const (
+	AppealPending  = "pending"
+	AppealApproved = "approved"
+	AppealRejected = "rejected"
+)
+
+var (
+	ErrAppealNotFound = errors.New("appeal not found")
+	ErrAppealDecided  = errors.New("appeal already decided")
+)
+
+// Appeal is a player's dispute of their computed skill score.
+type Appeal struct {
+	ID             string    `json:"id"`
+	Player         string    `json:"player"` // name#tag as submitted
+	Note           string    `json:"note"`
+	ComputedScore  int       `json:"computed_score,omitempty"`  // score the player saw
+	RequestedScore int       `json:"requested_score,omitempty"` // score the player asks for
+	State          string    `json:"state"`
+	CreatedAt      time.Time `json:"created_at"`
+	// Set when an organizer decides.
+	DecidedAt     *time.Time `json:"decided_at,omitempty"`
+	OverrideScore int        `json:"override_score,omitempty"`
+	DecisionNote  string     `json:"decision_note,omitempty"`
+
+	key string
+}
+
+// Override replaces a player's computed skill score in every analysis.
+type Override struct {
+	Score    int       `json:"score"`
+	AppealID string    `json:"appeal_id"`
+	Note     string    `json:"note,omitempty"`
+	SetAt    time.Time `json:"set_at"`
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// AddAppeal files a pending appeal.
+func (s *Memory) AddAppeal(gameName, tagLine, note string, computed, requested int) Appeal {
+	a := &Appeal{
+		ID: newID(), Player: gameName + "#" + tagLine, Note: note,
+		ComputedScore: computed, RequestedScore: requested,
+		State: AppealPending, CreatedAt: time.Now(), key: RiotIDKey(gameName, tagLine),
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.appeals[a.ID] = a
+	s.appealOrder = append(s.appealOrder, a.ID)
+	return *a
+}
+
+// Appeals lists appeals oldest first; state "" returns all.
+func (s *Memory) Appeals(state string) []Appeal {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := []Appeal{}
+	for _, id := range s.appealOrder {
+		if a := s.appeals[id]; state == "" || a.State == state {
+			out = append(out, *a)
+		}
+	}
+	return out
+}
+
+// PlayerAppeals is the decision history of one player, oldest first.
+func (s *Memory) PlayerAppeals(gameName, tagLine string) []Appeal {
+	key := RiotIDKey(gameName, tagLine)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := []Appeal{}
+	for _, id := range s.appealOrder {
+		if a := s.appeals[id]; a.key == key {
+			out = append(out, *a)
+		}
+	}
+	return out
+}
+
+// DecideAppeal approves (setting the player's score override) or rejects a
+// pending appeal.
+func (s *Memory) DecideAppeal(id string, approve bool, score int, note string) (Appeal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.appeals[id]
+	if !ok {
+		return Appeal{}, ErrAppealNotFound
+	}
+	if a.State != AppealPending {
+		return *a, ErrAppealDecided
+	}
+	now := time.Now()
+	a.DecidedAt = &now
+	a.DecisionNote = note
+	if approve {
+		a.State = AppealApproved
+		a.OverrideScore = score
+		s.overrides[a.key] = Override{Score: score, AppealID: a.ID, Note: note, SetAt: now}
+	} else {
+		a.State = AppealRejected
+	}
+	return *a, nil
+}
+
+// ScoreOverride returns the organizer-approved score for a player, if any.
+func (s *Memory) ScoreOverride(gameName, tagLine string) (Override, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	o, ok := s.overrides[RiotIDKey(gameName, tagLine)]
+	return o, ok
+}
+
+// ClearScoreOverride drops a player's override so the computed score applies again.
+func (s *Memory) ClearScoreOverride(gameName, tagLine string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.overrides, RiotIDKey(gameName, tagLine))
+}
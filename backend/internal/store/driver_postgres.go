@@ -0,0 +1,8 @@
+//go:build postgres
+
+package store
+
+// Postgres driver. Build with:
+//
+//	go build -tags postgres ./...
+import _ "github.com/jackc/pgx/v5/stdlib"
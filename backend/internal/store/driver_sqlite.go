@@ -0,0 +1,8 @@
+//go:build sqlite
+
+package store
+
+// Pure-Go SQLite driver (no cgo). Build with:
+//
+//	go build -tags sqlite ./...
+import _ "modernc.org/sqlite"
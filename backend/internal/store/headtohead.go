@@ -0,0 +1,96 @@
+package store
+
+import (
+	"strings"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+)
+
+// HeadToHead is how two players' stored results went.
+type HeadToHead struct {
+	A        string       `json:"a"`
+	B        string       `json:"b"`
+	Together PairRecord   `json:"together"` // same team
+	Against  PairRecord   `json:"against"`  // opposite teams
+	Games    []PairResult `json:"games"`    // newest first
+}
+
+// PairRecord counts results; only those with an outcome have a winner.
+type PairRecord struct {
+	Games   int `json:"games"`
+	Decided int `json:"decided"`
+	// Together: the pair's wins and losses. Against: wins of A and of B.
+	Wins   int `json:"wins"`
+	Losses int `json:"losses"`
+	// Verified is how many of the decided games were checked against Riot.
+	Verified int `json:"verified"`
+}
+
+// PairResult is one result both players were in.
+type PairResult struct {
+	ResultID  string    `json:"result_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Together  bool      `json:"together"`
+	// Winner is "a", "b" (against), "both", "neither" (together) or "" when
+	// no outcome was recorded.
+	Winner   string `json:"winner,omitempty"`
+	Verified bool   `json:"verified,omitempty"`
+}
+
+// HeadToHeadOf tallies results (newest first, as Results returns them) for
+// players a and b, given as name#tag.
+func HeadToHeadOf(results []Result, a, b string) HeadToHead {
+	h := HeadToHead{A: a, B: b, Games: []PairResult{}}
+	ka, kb := riotIDKeyOf(a), riotIDKeyOf(b)
+	for _, r := range results {
+		ta, tb := teamOf(r.Split, ka), teamOf(r.Split, kb)
+		if ta == "" || tb == "" {
+			continue
+		}
+		g := PairResult{ResultID: r.ID, CreatedAt: r.CreatedAt, Together: ta == tb}
+		rec := &h.Against
+		if g.Together {
+			rec = &h.Together
+		}
+		rec.Games++
+		if o := r.Outcome; o != nil {
+			rec.Decided++
+			g.Verified = o.Verified
+			if o.Verified {
+				rec.Verified++
+			}
+			switch {
+			case g.Together && o.Winner == ta:
+				g.Winner = "both"
+				rec.Wins++
+			case g.Together:
+				g.Winner = "neither"
+				rec.Losses++
+			case o.Winner == ta:
+				g.Winner = "a"
+				rec.Wins++
+			default:
+				g.Winner = "b"
+				rec.Losses++
+			}
+		}
+		h.Games = append(h.Games, g)
+	}
+	return h
+}
+
+// teamOf returns WinnerA/WinnerB for the team key plays on, "" when absent.
+func teamOf(ts analyzer.TeamSplit, key string) string {
+	for _, p := range ts.TeamA {
+		if strings.Contains(p.Name, "#") && riotIDKeyOf(p.Name) == key {
+			return WinnerA
+		}
+	}
+	for _, p := range ts.TeamB {
+		if strings.Contains(p.Name, "#") && riotIDKeyOf(p.Name) == key {
+			return WinnerB
+		}
+	}
+	return ""
+}
@@ -0,0 +1,105 @@
+package store
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+)
+
+// SeasonOf is the season a result belongs to: its calendar year, as ranked
+// seasons start in January.
+func SeasonOf(t time.Time) string { return strconv.Itoa(t.Year()) }
+
+// Standing is one player's record over a season's decided results.
+type Standing struct {
+	Player  string `json:"player"`
+	Games   int    `json:"games"`
+	Wins    int    `json:"wins"`
+	Winrate int    `json:"winrate"` // percent
+	MVPs    int    `json:"mvps"`
+	Aces    int    `json:"aces"`
+	// AvgRating is the mean match rating over verified games (0 when none).
+	AvgRating float64 `json:"avg_rating"`
+	Verified  int     `json:"verified"` // games with awards
+	// Participation is all-time attendance (streaks span seasons).
+	Participation analyzer.Participation `json:"participation"`
+}
+
+// Leaderboard ranks the players of season's results that have an outcome:
+// wins, then winrate, then MVP and ace titles. part supplies attendance by
+// RiotIDKey (see Memory.Participations).
+func Leaderboard(results []Result, season string, part map[string]analyzer.Participation) []Standing {
+	by := map[string]*Standing{}
+	ratingSum := map[string]float64{}
+	get := func(name string) *Standing {
+		key := riotIDKeyOf(name)
+		st, ok := by[key]
+		if !ok {
+			st = &Standing{Player: name}
+			by[key] = st
+		}
+		return st
+	}
+	for _, r := range results {
+		if r.Outcome == nil || SeasonOf(r.CreatedAt) != season {
+			continue
+		}
+		for team, ps := range map[string][]string{WinnerA: names(r.Split.TeamA), WinnerB: names(r.Split.TeamB)} {
+			for _, n := range ps {
+				st := get(n)
+				st.Games++
+				if r.Outcome.Winner == team {
+					st.Wins++
+				}
+			}
+		}
+		if a := r.Outcome.Awards; a != nil {
+			for _, p := range a.Players {
+				st := get(p.Name)
+				st.Verified++
+				ratingSum[riotIDKeyOf(p.Name)] += p.Rating
+			}
+			if a.MVP != "" {
+				get(a.MVP).MVPs++
+			}
+			if a.Ace != "" {
+				get(a.Ace).Aces++
+			}
+		}
+	}
+	out := make([]Standing, 0, len(by))
+	for key, st := range by {
+		if st.Games > 0 {
+			st.Winrate = st.Wins * 100 / st.Games
+		}
+		if st.Verified > 0 {
+			st.AvgRating = float64(int(ratingSum[key]/float64(st.Verified)*10+0.5)) / 10
+		}
+		st.Participation = part[key]
+		out = append(out, *st)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Wins != b.Wins {
+			return a.Wins > b.Wins
+		}
+		if a.Winrate != b.Winrate {
+			return a.Winrate > b.Winrate
+		}
+		if a.MVPs+a.Aces != b.MVPs+b.Aces {
+			return a.MVPs+a.Aces > b.MVPs+b.Aces
+		}
+		return a.Player < b.Player
+	})
+	return out
+}
+
+func names(team []analyzer.Profile) []string {
+	out := make([]string, len(team))
+	for i, p := range team {
+		out[i] = p.Name
+	}
+	return out
+}
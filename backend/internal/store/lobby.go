@@ -0,0 +1,92 @@
+package store
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+)
+
+const (
+	RevealAccept = "accept" // composition visible, scores after both captains accept
+	RevealRoles  = "roles"  // composition revealed one role at a time, then accept
+)
+
+var ErrLobbyNotFound = errors.New("lobby not found")
+
+// Lobby is an organizer's custom-game session: its players and the split
+// proposed for them, plus the double-blind reveal/accept state.
+type Lobby struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Players   []analyzer.Player `json:"players"`
+	CreatedAt time.Time         `json:"created_at"`
+
+	// Blind hides skill numbers until both captains accept.
+	Blind  bool   `json:"blind"`
+	Reveal string `json:"reveal"`
+	// Teams is the configured name/color/side of team A and B.
+	Teams [2]analyzer.TeamInfo `json:"teams"`
+	// StartsAt is when the game night is planned (nil = unscheduled).
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+	// RSVPs are the players' answers; Discord, when set, syncs them from
+	// reactions to a message.
+	RSVPs   []RSVP       `json:"rsvps,omitempty"`
+	Discord *DiscordLink `json:"discord,omitempty"`
+
+	Split         *analyzer.TeamSplit `json:"-"`
+	RevealedRoles int                 `json:"-"`
+	Accepted      [2]bool             `json:"-"` // teamA, teamB
+	CaptainTokens [2]string           `json:"-"`
+}
+
+// CreateLobby stores a new lobby; blind lobbies get one captain token per team.
+func (s *Memory) CreateLobby(name string, players []analyzer.Player, teams [2]analyzer.TeamInfo, blind bool, reveal string) Lobby {
+	l := &Lobby{ID: newID(), Name: name, Players: players, CreatedAt: time.Now(), Teams: teams, Blind: blind, Reveal: reveal}
+	if blind {
+		l.CaptainTokens = [2]string{newID(), newID()}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lobbies[l.ID] = l
+	return *l
+}
+
+func (s *Memory) Lobby(id string) (Lobby, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	l, ok := s.lobbies[id]
+	if !ok {
+		return Lobby{}, false
+	}
+	return *l, true
+}
+
+// Lobbies returns every lobby, oldest first.
+func (s *Memory) Lobbies() []Lobby {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Lobby, 0, len(s.lobbies))
+	for _, l := range s.lobbies {
+		out = append(out, *l)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+// UpdateLobby applies f under the store lock; an error from f leaves the lobby unchanged.
+func (s *Memory) UpdateLobby(id string, f func(*Lobby) error) (Lobby, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.lobbies[id]
+	if !ok {
+		return Lobby{}, ErrLobbyNotFound
+	}
+	next := *l
+	if err := f(&next); err != nil {
+		return *l, err
+	}
+	*l = next
+	return next, nil
+}
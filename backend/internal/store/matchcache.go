@@ -0,0 +1,82 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"lol_custom_skill_matching/internal/riot"
+)
+
+// MatchCache is a riot.MatchCache in a SQLite file of its own, so match
+// details fetched once are never fetched again, whatever the store and across
+// restarts. It needs the sqlite build tag (go build -tags sqlite; the driver
+// is pure Go and in go.mod), and OpenMatchCache says so without it.
+type MatchCache struct {
+	db *sql.DB
+}
+
+// OpenMatchCache opens (or creates) the cache file at path.
+func OpenMatchCache(path string) (*MatchCache, error) {
+	if !HasDriver(DriverSQLite) {
+		return nil, fmt.Errorf("this binary was built without %s support (rebuild with -tags %s)", DriverSQLite, DriverSQLite)
+	}
+	db, err := sql.Open(sqlDriverNames[DriverSQLite], path)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1) // one writer; avoids "database is locked"
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS match_cache (
+		match_id TEXT PRIMARY KEY,
+		body TEXT NOT NULL,
+		fetched_at BIGINT NOT NULL
+	)`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating match_cache in %s: %w", path, err)
+	}
+	return &MatchCache{db: db}, nil
+}
+
+func (c *MatchCache) Close() error { return c.db.Close() }
+
+func (c *MatchCache) GetMatch(matchID string) ([]byte, bool) {
+	var body string
+	if err := c.db.QueryRow(`SELECT body FROM match_cache WHERE match_id = ?`, matchID).Scan(&body); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("match cache: reading %s: %v", matchID, err)
+		}
+		return nil, false
+	}
+	return []byte(body), true
+}
+
+// PutMatch stores body; a failed write is logged, the match is simply not
+// cached.
+func (c *MatchCache) PutMatch(matchID string, body []byte) {
+	if _, err := c.db.Exec(`INSERT INTO match_cache (match_id, body, fetched_at) VALUES (?, ?, ?)
+		ON CONFLICT (match_id) DO UPDATE SET body = excluded.body, fetched_at = excluded.fetched_at`,
+		matchID, string(body), time.Now().Unix()); err != nil {
+		log.Printf("match cache: writing %s: %v", matchID, err)
+	}
+}
+
+func (c *MatchCache) PurgeMatches(matchID string) (int, error) {
+	q, args := `DELETE FROM match_cache`, []any{}
+	if matchID != "" {
+		q, args = q+` WHERE match_id = ?`, append(args, matchID)
+	}
+	res, err := c.db.Exec(q, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+func (c *MatchCache) CountMatches() (riot.CacheCount, error) {
+	var n riot.CacheCount
+	err := c.db.QueryRow(`SELECT COUNT(*), COALESCE(SUM(LENGTH(body)), 0) FROM match_cache`).Scan(&n.Entries, &n.Bytes)
+	return n, err
+}
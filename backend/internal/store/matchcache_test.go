@@ -0,0 +1,55 @@
+package store
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMatchCache runs with -tags sqlite; the default build only checks that
+// OpenMatchCache says how to get it.
+func TestMatchCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "match_cache.db")
+	mc, err := OpenMatchCache(path)
+	if !HasDriver(DriverSQLite) {
+		if err == nil || !strings.Contains(err.Error(), "-tags sqlite") {
+			t.Fatalf("OpenMatchCache without the driver: %v, want a hint at -tags sqlite", err)
+		}
+		t.Skip("built without -tags sqlite")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := mc.GetMatch("JP1_1"); ok {
+		t.Fatal("empty cache has JP1_1")
+	}
+	mc.PutMatch("JP1_1", []byte(`{"v":1}`))
+	mc.PutMatch("JP1_2", []byte(`{"v":2}`))
+	mc.PutMatch("JP1_1", []byte(`{"v":3}`))
+	if body, ok := mc.GetMatch("JP1_1"); !ok || string(body) != `{"v":3}` {
+		t.Errorf("JP1_1 = %s, %t, want the last write", body, ok)
+	}
+	if n, err := mc.CountMatches(); err != nil || n.Entries != 2 || n.Bytes != 14 {
+		t.Errorf("count = %+v, %v, want 2 entries of 14 bytes", n, err)
+	}
+	if err := mc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the file keeps the matches across restarts
+	mc, err = OpenMatchCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mc.Close()
+	if _, ok := mc.GetMatch("JP1_2"); !ok {
+		t.Error("JP1_2 lost on reopening")
+	}
+	if n, err := mc.PurgeMatches("JP1_2"); err != nil || n != 1 {
+		t.Errorf("purging JP1_2 = %d, %v, want 1", n, err)
+	}
+	if n, err := mc.PurgeMatches(""); err != nil || n != 1 {
+		t.Errorf("purging all = %d, %v, want 1", n, err)
+	}
+}
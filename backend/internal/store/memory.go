@@ -0,0 +1,203 @@
+// Package store keeps server-side state (player-declared champion pools, analyzed
+// match summaries, score appeals and overrides, ownership verification, lobbies,
+// side history, ratings, results, opt-outs, organizer notes) in memory by default, or in a SQL database
+// behind the same Store interface.
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"lol_custom_skill_matching/internal/analyzer"
+)
+
+// RiotIDKey normalizes "name#tag" so lookups are case-insensitive.
+func RiotIDKey(gameName, tagLine string) string {
+	return strings.ToLower(strings.TrimSpace(gameName)) + "#" + strings.ToUpper(strings.TrimSpace(tagLine))
+}
+
+// riotIDKeyOf normalizes a combined "name#tag".
+func riotIDKeyOf(riotID string) string {
+	i := strings.LastIndex(riotID, "#")
+	if i < 0 {
+		return RiotIDKey(riotID, "")
+	}
+	return RiotIDKey(riotID[:i], riotID[i+1:])
+}
+
+// Memory is the in-memory store. The zero value is not usable; use NewMemory.
+type Memory struct {
+	mu      sync.RWMutex
+	pools   map[string][]string                // RiotIDKey -> champions
+	matches map[string][]analyzer.MatchSummary // RiotIDKey -> summaries, newest first
+
+	appeals     map[string]*Appeal // id -> appeal
+	appealOrder []string
+	overrides   map[string]Override // RiotIDKey -> approved score
+
+	challenges map[string]*Challenge   // token -> pending ownership proof
+	verified   map[string]Verification // RiotIDKey -> proven owner
+
+	lobbies map[string]*Lobby
+	sides   map[string]analyzer.SideStats // RiotIDKey -> blue/red history
+	ratings map[string]Rating             // RiotIDKey -> last computed rating
+	results map[string]Result             // id -> stored split
+
+	optouts map[string]OptOut     // RiotIDKey -> deleted player kept out
+	notes   map[string]PlayerNote // RiotIDKey -> organizer note and tags
+
+	rankWatches map[string]RankWatch // RiotIDKey -> rank-alert subscription
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		pools:     map[string][]string{},
+		matches:   map[string][]analyzer.MatchSummary{},
+		appeals:   map[string]*Appeal{},
+		overrides: map[string]Override{},
+
+		challenges: map[string]*Challenge{},
+		verified:   map[string]Verification{},
+
+		lobbies: map[string]*Lobby{},
+		sides:   map[string]analyzer.SideStats{},
+		ratings: map[string]Rating{},
+		results: map[string]Result{},
+
+		optouts: map[string]OptOut{},
+		notes:   map[string]PlayerNote{},
+
+		rankWatches: map[string]RankWatch{},
+	}
+}
+
+// Pool returns the player-declared champion pool (nil when none).
+func (s *Memory) Pool(gameName, tagLine string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]string(nil), s.pools[RiotIDKey(gameName, tagLine)]...)
+}
+
+// SetPool replaces the declared pool; an empty list clears it. Returns the stored list.
+// Opted-out players' pools are not stored.
+func (s *Memory) SetPool(gameName, tagLine string, champs []string) []string {
+	champs = CleanChampionList(champs)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := RiotIDKey(gameName, tagLine)
+	if len(champs) == 0 || s.optedOut(key) {
+		delete(s.pools, key)
+		return nil
+	}
+	s.pools[key] = champs
+	return champs
+}
+
+// AddMatches merges summaries into the player's history; a match already stored
+// is replaced by the newer copy. Opted-out players' matches are dropped.
+func (s *Memory) AddMatches(gameName, tagLine string, ms []analyzer.MatchSummary) {
+	if len(ms) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := RiotIDKey(gameName, tagLine)
+	if s.optedOut(key) {
+		return
+	}
+	byID := map[string]analyzer.MatchSummary{}
+	for _, m := range s.matches[key] {
+		byID[m.MatchID] = m
+	}
+	for _, m := range ms {
+		byID[m.MatchID] = m
+	}
+	merged := make([]analyzer.MatchSummary, 0, len(byID))
+	for _, m := range byID {
+		merged = append(merged, m)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].GameCreation > merged[j].GameCreation })
+	s.matches[key] = merged
+}
+
+// Matches returns the stored summaries for a player, newest first.
+func (s *Memory) Matches(gameName, tagLine string) []analyzer.MatchSummary {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]analyzer.MatchSummary(nil), s.matches[RiotIDKey(gameName, tagLine)]...)
+}
+
+// HasMatch reports whether a summary for matchID is already stored for the player.
+func (s *Memory) HasMatch(gameName, tagLine, matchID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, m := range s.matches[RiotIDKey(gameName, tagLine)] {
+		if m.MatchID == matchID {
+			return true
+		}
+	}
+	return false
+}
+
+// SaveMatches writes every stored summary to path as JSON (RiotIDKey -> summaries).
+// The file is replaced atomically so a crash mid-write keeps the previous copy.
+func (s *Memory) SaveMatches(path string) error {
+	s.mu.RLock()
+	b, err := json.Marshal(s.matches)
+	s.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadMatches merges summaries saved by SaveMatches; a missing file is not an error.
+func (s *Memory) LoadMatches(path string) error {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var saved map[string][]analyzer.MatchSummary
+	if err := json.Unmarshal(b, &saved); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, ms := range saved {
+		if len(s.matches[key]) == 0 {
+			s.matches[key] = ms
+		}
+	}
+	return nil
+}
+
+// CleanChampionList trims and de-duplicates (case-insensitive) while keeping order.
+func CleanChampionList(in []string) []string {
+	out := []string{}
+	seen := map[string]struct{}{}
+	for _, c := range in {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		k := strings.ToLower(c)
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		out = append(out, c)
+	}
+	return out
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Limits on a player's note and tags.
+const (
+	MaxNoteLen = 1000
+	MaxTags    = 20
+	MaxTagLen  = 40
+)
+
+// PlayerNote is what organizers keep about a player: a freeform note and tags
+// ("new player", "camille OTP", "duo with X") used to filter the roster and in
+// tag rules when balancing.
+type PlayerNote struct {
+	Player    string    `json:"player"` // name#tag
+	Note      string    `json:"note"`
+	Tags      []string  `json:"tags"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// HasTag reports whether the note carries tag (case-insensitive).
+func (n PlayerNote) HasTag(tag string) bool {
+	return slices.ContainsFunc(n.Tags, func(t string) bool { return strings.EqualFold(t, tag) })
+}
+
+// CleanTags trims tags and drops empty ones and repeats (case-insensitive),
+// keeping the first spelling.
+func CleanTags(tags []string) []string {
+	out := []string{}
+	for _, t := range tags {
+		t = strings.Join(strings.Fields(t), " ")
+		if t == "" || slices.ContainsFunc(out, func(o string) bool { return strings.EqualFold(o, t) }) {
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// CheckNote rejects a note or tags (already cleaned) over the limits.
+func CheckNote(note string, tags []string) error {
+	if utf8.RuneCountInString(note) > MaxNoteLen {
+		return fmt.Errorf("note is longer than %d characters", MaxNoteLen)
+	}
+	if len(tags) > MaxTags {
+		return fmt.Errorf("more than %d tags", MaxTags)
+	}
+	for _, t := range tags {
+		if utf8.RuneCountInString(t) > MaxTagLen {
+			return fmt.Errorf("tag %q is longer than %d characters", t, MaxTagLen)
+		}
+	}
+	return nil
+}
+
+// Note returns the player's note and tags.
+func (s *Memory) Note(gameName, tagLine string) (PlayerNote, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n, ok := s.notes[RiotIDKey(gameName, tagLine)]
+	n.Tags = slices.Clone(n.Tags)
+	return n, ok
+}
+
+// SetNote replaces the player's note and tags; an empty note with no tags
+// clears them. Opted-out players' notes are not stored.
+func (s *Memory) SetNote(gameName, tagLine, note string, tags []string) (PlayerNote, bool) {
+	n := PlayerNote{Player: gameName + "#" + tagLine, Note: strings.TrimSpace(note), Tags: CleanTags(tags), UpdatedAt: time.Now()}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := RiotIDKey(gameName, tagLine)
+	if (n.Note == "" && len(n.Tags) == 0) || s.optedOut(key) {
+		delete(s.notes, key)
+		return PlayerNote{}, false
+	}
+	s.notes[key] = n
+	n.Tags = slices.Clone(n.Tags)
+	return n, true
+}
+
+// Notes lists the players with a note or tags, by name; a non-empty tag keeps
+// only the players carrying it.
+func (s *Memory) Notes(tag string) []PlayerNote {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := []PlayerNote{}
+	for _, n := range s.notes {
+		if tag != "" && !n.HasTag(tag) {
+			continue
+		}
+		n.Tags = slices.Clone(n.Tags)
+		out = append(out, n)
+	}
+	sort.Slice(out, func(i, j int) bool { return strings.ToLower(out[i].Player) < strings.ToLower(out[j].Player) })
+	return out
+}
@@ -0,0 +1,226 @@
+package store
+
+import (
+	"sort"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/balance"
+)
+
+// OptOut is a player who asked for their data to be deleted. Nothing about
+// them is stored again until they are taken off the list.
+type OptOut struct {
+	Player string    `json:"player"` // name#tag
+	At     time.Time `json:"at"`
+}
+
+// Deletion is what DeletePlayer removed. Results and lobbies the player took
+// part in are kept for the other players, with the player replaced by Alias.
+type Deletion struct {
+	Player   string   `json:"player"`
+	Alias    string   `json:"alias"`
+	Matches  int      `json:"matches"`
+	Rating   bool     `json:"rating"`
+	Pool     bool     `json:"pool"`
+	Override bool     `json:"override"`
+	Verified bool     `json:"verified"`
+	Note     bool     `json:"note"`
+	Appeals  []string `json:"appeals"`
+	Results  []string `json:"results"`
+	Lobbies  []string `json:"lobbies"`
+	// RankAlerts is whether they had subscribed to rank-change alerts.
+	RankAlerts bool `json:"rank_alerts"`
+	// PUUID is the account's as far as the store knows it (a verification,
+	// rank-alert subscription or pending challenge), so cached Riot answers
+	// can be purged too; "" when it was never recorded.
+	PUUID string `json:"-"`
+}
+
+// DeletePlayer removes everything stored about the player (match history,
+// rating, pool, override, side history, verification, appeals, note, rank
+// alerts), anonymizes them in results and lobbies, and opts them out.
+func (s *Memory) DeletePlayer(gameName, tagLine string) Deletion {
+	key := RiotIDKey(gameName, tagLine)
+	tag := newID()[:6]
+	d := Deletion{Player: gameName + "#" + tagLine, Alias: "deleted#" + tag, Appeals: []string{}, Results: []string{}, Lobbies: []string{}}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d.Matches = len(s.matches[key])
+	_, d.Rating = s.ratings[key]
+	_, d.Pool = s.pools[key]
+	_, d.Override = s.overrides[key]
+	_, d.Note = s.notes[key]
+	_, d.RankAlerts = s.rankWatches[key]
+	var v Verification
+	if v, d.Verified = s.verified[key]; d.Verified {
+		d.PUUID = v.PUUID
+	}
+	if w, ok := s.rankWatches[key]; ok && d.PUUID == "" {
+		d.PUUID = w.PUUID
+	}
+	delete(s.matches, key)
+	delete(s.ratings, key)
+	delete(s.pools, key)
+	delete(s.overrides, key)
+	delete(s.verified, key)
+	delete(s.sides, key)
+	delete(s.notes, key)
+	delete(s.rankWatches, key)
+	for tok, c := range s.challenges {
+		if c.key == key {
+			if d.PUUID == "" {
+				d.PUUID = c.PUUID
+			}
+			delete(s.challenges, tok)
+		}
+	}
+	kept := s.appealOrder[:0]
+	for _, id := range s.appealOrder {
+		if s.appeals[id].key == key {
+			delete(s.appeals, id)
+			d.Appeals = append(d.Appeals, id)
+			continue
+		}
+		kept = append(kept, id)
+	}
+	s.appealOrder = kept
+	for id, r := range s.results {
+		if redactSplit(&r.Split, key, d.Alias) {
+			if a := r.Outcome; a != nil && a.Awards != nil {
+				awards := redactAwards(*a.Awards, key, d.Alias)
+				o := *a
+				o.Awards = &awards
+				r.Outcome = &o
+			}
+			s.results[id] = r
+			d.Results = append(d.Results, id)
+		}
+	}
+	for id, l := range s.lobbies {
+		found := false
+		players := append([]analyzer.Player{}, l.Players...)
+		for i, p := range players {
+			if RiotIDKey(p.GameName, p.TagLine) == key {
+				players[i] = analyzer.Player{GameName: "deleted", TagLine: tag}
+				found = true
+			}
+		}
+		var split *analyzer.TeamSplit
+		if l.Split != nil {
+			ts := *l.Split
+			if redactSplit(&ts, key, d.Alias) {
+				split, found = &ts, true
+			}
+		}
+		c := *l
+		if c.forgetPlayer(key) {
+			found = true
+		}
+		if found {
+			c.Players = players
+			if split != nil {
+				c.Split = split
+			}
+			s.lobbies[id] = &c
+			d.Lobbies = append(d.Lobbies, id)
+		}
+	}
+	sort.Strings(d.Results)
+	sort.Strings(d.Lobbies)
+	s.optouts[key] = OptOut{Player: d.Player, At: time.Now()}
+	return d
+}
+
+// redactSplit replaces the profile and slots of the player with key by a bare
+// profile named alias, keeping only what the split's sums were made of.
+// Reports whether the player was in ts; the team slices are copied first.
+func redactSplit(ts *analyzer.TeamSplit, key, alias string) bool {
+	found := false
+	for _, team := range []*[]analyzer.Profile{&ts.TeamA, &ts.TeamB} {
+		for i, p := range *team {
+			if riotIDKeyOf(p.Name) != key {
+				continue
+			}
+			if !found {
+				ts.TeamA, ts.TeamB = append([]analyzer.Profile{}, ts.TeamA...), append([]analyzer.Profile{}, ts.TeamB...)
+				found = true
+			}
+			(*team)[i] = analyzer.Profile{
+				Name: alias, SkillScore: p.SkillScore, SkillInterval: p.SkillInterval, BalanceScore: p.BalanceScore,
+				MainLanes: []string{}, MainSublanes: []string{}, MainChampions: []string{}, InferredChampions: []string{},
+				MainLaneChampions: map[string][]string{}, SublaneChampions: map[string][]string{},
+			}
+		}
+	}
+	for _, rs := range []**balance.RoleSplit{&ts.LaneUnique, &ts.RolesFirst} {
+		if *rs == nil {
+			continue
+		}
+		c := **rs
+		c.TeamA, c.TeamB = append([]balance.Slot{}, c.TeamA...), append([]balance.Slot{}, c.TeamB...)
+		for _, slots := range [][]balance.Slot{c.TeamA, c.TeamB} {
+			for i := range slots {
+				if riotIDKeyOf(slots[i].Name) == key {
+					slots[i].Name = alias
+					found = true
+				}
+			}
+		}
+		*rs = &c
+	}
+	return found
+}
+
+// redactAwards renames the player with key to alias in a match's awards.
+func redactAwards(a analyzer.MatchAwards, key, alias string) analyzer.MatchAwards {
+	if riotIDKeyOf(a.MVP) == key {
+		a.MVP = alias
+	}
+	if riotIDKeyOf(a.Ace) == key {
+		a.Ace = alias
+	}
+	a.Players = append([]analyzer.Performance{}, a.Players...)
+	for i := range a.Players {
+		if riotIDKeyOf(a.Players[i].Name) == key {
+			a.Players[i].Name = alias
+		}
+	}
+	return a
+}
+
+// OptedOut reports whether the player is on the opt-out list.
+func (s *Memory) OptedOut(gameName, tagLine string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.optouts[RiotIDKey(gameName, tagLine)]
+	return ok
+}
+
+// OptOuts lists the opted-out players, most recent first.
+func (s *Memory) OptOuts() []OptOut {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]OptOut, 0, len(s.optouts))
+	for _, o := range s.optouts {
+		out = append(out, o)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].At.After(out[j].At) })
+	return out
+}
+
+// OptIn takes the player off the opt-out list; false when they weren't on it.
+func (s *Memory) OptIn(gameName, tagLine string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := RiotIDKey(gameName, tagLine)
+	_, ok := s.optouts[key]
+	delete(s.optouts, key)
+	return ok
+}
+
+// optedOut is OptedOut by key for callers holding s.mu.
+func (s *Memory) optedOut(key string) bool {
+	_, ok := s.optouts[key]
+	return ok
+}
@@ -0,0 +1,85 @@
+package store
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+)
+
+// sessionDay is the game night a lobby or result belongs to (local date).
+func sessionDay(t time.Time) string { return t.Local().Format("2006-01-02") }
+
+// Participation returns the attendance of riotID (name#tag) over the
+// community's game nights.
+func (s *Memory) Participation(riotID string) analyzer.Participation {
+	return s.Participations()[riotIDKeyOf(riotID)]
+}
+
+// Participations returns every member's attendance by RiotIDKey. A game night
+// is a local day with at least one lobby or stored result; a member attended
+// it when entered in a lobby or result that day.
+func (s *Memory) Participations() map[string]analyzer.Participation {
+	s.mu.RLock()
+	days := map[string]struct{}{}
+	attended := map[string]map[string]struct{}{} // key -> days
+	out := map[string]analyzer.Participation{}
+	see := func(riotID string, at time.Time) {
+		if !strings.Contains(riotID, "#") {
+			return
+		}
+		key, day := riotIDKeyOf(riotID), sessionDay(at)
+		if attended[key] == nil {
+			attended[key] = map[string]struct{}{}
+		}
+		attended[key][day] = struct{}{}
+		p := out[key]
+		if at.After(p.LastPlayed) {
+			p.LastPlayed = at
+		}
+		out[key] = p
+	}
+	for _, l := range s.lobbies {
+		days[sessionDay(l.CreatedAt)] = struct{}{}
+		for _, p := range l.Players {
+			see(p.RiotID(), l.CreatedAt)
+		}
+	}
+	for _, r := range s.results {
+		days[sessionDay(r.CreatedAt)] = struct{}{}
+		for _, team := range [][]analyzer.Profile{r.Split.TeamA, r.Split.TeamB} {
+			for _, p := range team {
+				see(p.Name, r.CreatedAt)
+				if strings.Contains(p.Name, "#") {
+					key := riotIDKeyOf(p.Name)
+					pt := out[key]
+					pt.Games++
+					out[key] = pt
+				}
+			}
+		}
+	}
+	s.mu.RUnlock()
+
+	order := make([]string, 0, len(days))
+	for d := range days {
+		order = append(order, d)
+	}
+	sort.Strings(order)
+	for key, p := range out {
+		run := 0
+		for _, d := range order {
+			if _, ok := attended[key][d]; ok {
+				run++
+				p.BestStreak = max(p.BestStreak, run)
+			} else {
+				run = 0
+			}
+		}
+		p.CurrentStreak = run
+		p.Sessions = len(attended[key])
+		out[key] = p
+	}
+	return out
+}
@@ -0,0 +1,105 @@
+package store
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// RankSeen is a player's solo queue rank as the rank-alert refresh last saw
+// it; Tier is "" while unranked.
+type RankSeen struct {
+	Tier   string `json:"tier"`
+	Rank   string `json:"rank"`
+	LP     int    `json:"lp"`
+	Wins   int    `json:"wins"`
+	Losses int    `json:"losses"`
+}
+
+// RankWatch is a verified player's subscription to notifications of their own
+// rank changes, sent to a Discord webhook, as a Discord DM by the bot, or both.
+type RankWatch struct {
+	Player string `json:"player"` // name#tag
+	// Webhook is a Discord-compatible webhook URL ("" = none).
+	Webhook string `json:"webhook,omitempty"`
+	// DiscordUserID gets a DM from the bot ("" = none).
+	DiscordUserID string `json:"discord_user_id,omitempty"`
+	// LPChanges also notifies LP moves within a division, not only
+	// promotions, demotions and (un)ranking.
+	LPChanges bool `json:"lp_changes"`
+	// Last is the rank of the last check (nil until the first).
+	Last       *RankSeen  `json:"last,omitempty"`
+	CheckedAt  *time.Time `json:"checked_at,omitempty"`
+	NotifiedAt *time.Time `json:"notified_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+
+	// PUUID is the verified account's; the watch lapses when the Riot ID is
+	// verified by another account.
+	PUUID string `json:"-"`
+}
+
+// RankWatch returns the player's rank-alert subscription.
+func (s *Memory) RankWatch(gameName, tagLine string) (RankWatch, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	w, ok := s.rankWatches[RiotIDKey(gameName, tagLine)]
+	return w, ok
+}
+
+// RankWatches lists every rank-alert subscription, by player.
+func (s *Memory) RankWatches() []RankWatch {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]RankWatch, 0, len(s.rankWatches))
+	for _, w := range s.rankWatches {
+		out = append(out, w)
+	}
+	sort.Slice(out, func(i, j int) bool { return strings.ToLower(out[i].Player) < strings.ToLower(out[j].Player) })
+	return out
+}
+
+// SetRankWatch stores w as its player's subscription. The rank last seen is
+// kept while the account stays the same, so changing where alerts go doesn't
+// alert again. Opted-out players can't subscribe.
+func (s *Memory) SetRankWatch(w RankWatch) (RankWatch, bool) {
+	key := riotIDKeyOf(w.Player)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.optedOut(key) {
+		return RankWatch{}, false
+	}
+	w.Last, w.CheckedAt, w.NotifiedAt, w.CreatedAt = nil, nil, nil, time.Now()
+	if old, ok := s.rankWatches[key]; ok && old.PUUID == w.PUUID {
+		w.Last, w.CheckedAt, w.NotifiedAt, w.CreatedAt = old.Last, old.CheckedAt, old.NotifiedAt, old.CreatedAt
+	}
+	s.rankWatches[key] = w
+	return w, true
+}
+
+// DeleteRankWatch ends the player's subscription.
+func (s *Memory) DeleteRankWatch(gameName, tagLine string) bool {
+	key := RiotIDKey(gameName, tagLine)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.rankWatches[key]
+	delete(s.rankWatches, key)
+	return ok
+}
+
+// RecordRank stores the rank a check of puuid's subscription saw at at, and
+// that it was notified when notified. A subscription replaced by another
+// account meanwhile is left alone.
+func (s *Memory) RecordRank(gameName, tagLine, puuid string, seen RankSeen, at time.Time, notified bool) {
+	key := RiotIDKey(gameName, tagLine)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, ok := s.rankWatches[key]
+	if !ok || w.PUUID != puuid {
+		return
+	}
+	w.Last, w.CheckedAt = &seen, &at
+	if notified {
+		w.NotifiedAt = &at
+	}
+	s.rankWatches[key] = w
+}
@@ -0,0 +1,159 @@
+package store
+
+import (
+	"sort"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+)
+
+// Rating is the portable view of a player: last computed score and lanes, plus
+// the declared champion pool and organizer override kept elsewhere in the store.
+type Rating struct {
+	Player    string    `json:"player"` // name#tag
+	Score     int       `json:"score"`  // computed skill score (before any override)
+	Low       int       `json:"low"`
+	High      int       `json:"high"`
+	MainLanes []string  `json:"main_lanes"`
+	SubLanes  []string  `json:"sub_lanes"`
+	Champions []string  `json:"champions,omitempty"` // declared pool
+	Override  *int      `json:"override,omitempty"`  // organizer-set score
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RecordRatings remembers the computed score and lanes of analyzed profiles,
+// except those of opted-out players.
+func (s *Memory) RecordRatings(profiles []analyzer.Profile) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range profiles {
+		if s.optedOut(riotIDKeyOf(p.Name)) {
+			continue
+		}
+		score, low, high := p.SkillScore, p.SkillInterval.Low, p.SkillInterval.High
+		if o := p.ScoreOverride; o != nil {
+			delta := o.Computed - o.Score
+			score, low, high = o.Computed, low+delta, high+delta
+		}
+		s.ratings[riotIDKeyOf(p.Name)] = Rating{
+			Player: p.Name, Score: score, Low: low, High: high,
+			MainLanes: append([]string{}, p.MainLanes...), SubLanes: append([]string{}, p.MainSublanes...),
+			UpdatedAt: now,
+		}
+	}
+}
+
+// Ratings lists every player with a rating, declared pool or override, by name.
+func (s *Memory) Ratings() []Rating {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	byKey := map[string]Rating{}
+	for key, r := range s.ratings {
+		byKey[key] = r
+	}
+	for key, champs := range s.pools {
+		r, ok := byKey[key]
+		if !ok {
+			r = Rating{Player: key, MainLanes: []string{}, SubLanes: []string{}}
+		}
+		r.Champions = append([]string(nil), champs...)
+		byKey[key] = r
+	}
+	for key, o := range s.overrides {
+		r, ok := byKey[key]
+		if !ok {
+			r = Rating{Player: key, MainLanes: []string{}, SubLanes: []string{}}
+		}
+		score := o.Score
+		r.Override = &score
+		byKey[key] = r
+	}
+	out := make([]Rating, 0, len(byKey))
+	for _, r := range byKey {
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool { return riotIDKeyOf(out[i].Player) < riotIDKeyOf(out[j].Player) })
+	return out
+}
+
+// ImportRatings stores ratings as if they had been computed here. A non-empty
+// champion list replaces the declared pool and a set override replaces the
+// organizer override; missing ones leave the current state alone. Opted-out
+// players are skipped.
+func (s *Memory) ImportRatings(rs []Rating) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range rs {
+		key := riotIDKeyOf(r.Player)
+		if s.optedOut(key) {
+			continue
+		}
+		if r.UpdatedAt.IsZero() {
+			r.UpdatedAt = now
+		}
+		if champs := CleanChampionList(r.Champions); len(champs) > 0 {
+			s.pools[key] = champs
+		}
+		if r.Override != nil {
+			s.overrides[key] = Override{Score: *r.Override, Note: "imported", SetAt: now}
+		}
+		r.Champions, r.Override = nil, nil
+		s.ratings[key] = r
+	}
+}
+
+// LatestProfile returns the freshest stored profile of riotID (name#tag) and
+// when it was computed: the player's profile in the newest result, with score
+// and lanes from a later rating if one was recorded since, or a profile made
+// from the rating alone. Scores are the computed ones, without overrides, and
+// request-specific fields (verification, participation, labels) are cleared.
+func (s *Memory) LatestProfile(riotID string) (analyzer.Profile, time.Time, bool) {
+	key := riotIDKeyOf(riotID)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var p analyzer.Profile
+	var asOf time.Time
+	found := false
+	for _, res := range s.results {
+		if found && !res.CreatedAt.After(asOf) {
+			continue
+		}
+		for _, team := range [][]analyzer.Profile{res.Split.TeamA, res.Split.TeamB} {
+			for _, pr := range team {
+				if riotIDKeyOf(pr.Name) == key {
+					p, asOf, found = pr, res.CreatedAt, true
+				}
+			}
+		}
+	}
+	if found {
+		if o := p.ScoreOverride; o != nil {
+			delta := o.Computed - o.Score
+			p.SkillScore = o.Computed
+			p.SkillInterval.Low += delta
+			p.SkillInterval.High += delta
+		}
+	}
+	if r, ok := s.ratings[key]; ok && (!found || r.UpdatedAt.After(asOf)) {
+		if !found {
+			p = analyzer.Profile{
+				Name: r.Player, MainChampions: []string{}, InferredChampions: []string{}, ChampionPoolSource: "inferred",
+				MainLaneChampions: map[string][]string{}, SublaneChampions: map[string][]string{},
+			}
+		}
+		p.SkillScore = r.Score
+		p.SkillInterval = analyzer.Interval{Low: r.Low, High: r.High, Margin: (r.High - r.Low) / 2}
+		p.MainLanes = append([]string{}, r.MainLanes...)
+		p.MainSublanes = append([]string{}, r.SubLanes...)
+		asOf, found = r.UpdatedAt, true
+	}
+	if !found {
+		return analyzer.Profile{}, time.Time{}, false
+	}
+	p.ScoreOverride, p.BalanceScore, p.Verified = nil, 0, false
+	p.Participation, p.UncertainRating, p.UncertainReasons = nil, false, nil
+	p.Raw, p.Matches, p.RiotCalls = nil, nil, 0
+	return p, asOf, true
+}
@@ -0,0 +1,96 @@
+package store
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+)
+
+var ErrResultNotFound = errors.New("result not found")
+
+// Result is one stored team split.
+type Result struct {
+	ID        string             `json:"id"`
+	CreatedAt time.Time          `json:"created_at"`
+	LobbyID   string             `json:"lobby_id,omitempty"`
+	Split     analyzer.TeamSplit `json:"split"`
+	// Outcome is set once the game was played.
+	Outcome *Outcome `json:"outcome,omitempty"`
+}
+
+// Winners of an Outcome.
+const (
+	WinnerA = "A"
+	WinnerB = "B"
+)
+
+// Outcome records which team won a result's game. Verified outcomes were read
+// from the custom match on Riot's side, matched player by player to the split.
+type Outcome struct {
+	Winner     string    `json:"winner"` // WinnerA or WinnerB
+	MatchID    string    `json:"match_id,omitempty"`
+	Verified   bool      `json:"verified"`
+	RecordedAt time.Time `json:"recorded_at"`
+	// Awards are the per-player ratings and titles of a verified match.
+	Awards *analyzer.MatchAwards `json:"awards,omitempty"`
+}
+
+// AddResult keeps a split so it can be looked up later by id.
+func (s *Memory) AddResult(lobbyID string, ts analyzer.TeamSplit) Result {
+	r := Result{ID: newID(), CreatedAt: time.Now(), LobbyID: lobbyID, Split: ts}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results[r.ID] = r
+	return r
+}
+
+func (s *Memory) Result(id string) (Result, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r, ok := s.results[id]
+	return r, ok
+}
+
+// Results lists stored results, newest first.
+func (s *Memory) Results() []Result {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Result, 0, len(s.results))
+	for _, r := range s.results {
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+// SetOutcome records (or replaces) the outcome of result id.
+func (s *Memory) SetOutcome(id string, o Outcome) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.results[id]
+	if !ok {
+		return Result{}, ErrResultNotFound
+	}
+	if o.RecordedAt.IsZero() {
+		o.RecordedAt = time.Now()
+	}
+	r.Outcome = &o
+	s.results[id] = r
+	return r, nil
+}
+
+// CalibrationGames are the decided results as analyzer.Calibrate input. Only
+// verified outcomes are used unless unverified is set: a hand-entered winner
+// may belong to a different lineup than the split.
+func CalibrationGames(results []Result, unverified bool) []analyzer.CalibrationGame {
+	games := []analyzer.CalibrationGame{}
+	for _, r := range results {
+		if r.Outcome == nil || !(r.Outcome.Verified || unverified) {
+			continue
+		}
+		games = append(games, analyzer.CalibrationGameOf(r.Split, r.Outcome.Winner == WinnerA))
+	}
+	return games
+}
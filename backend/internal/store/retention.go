@@ -0,0 +1,77 @@
+package store
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+)
+
+// Retention is how long stored data is kept (zero values keep it forever).
+type Retention struct {
+	// Matches drops match summaries of games played longer ago than this.
+	Matches time.Duration
+	// ResultSeasons keeps the results (and lobbies) of the current season and
+	// the ones before it, this many in all.
+	ResultSeasons int
+}
+
+// Pruned counts what Prune removed.
+type Pruned struct {
+	Matches int `json:"matches"`
+	Results int `json:"results"`
+	Lobbies int `json:"lobbies"`
+
+	// record ids changed, for the SQL write-through
+	matchKeys, resultIDs, lobbyIDs []string
+}
+
+// resultCutoff is the start of the oldest season r keeps (zero = keep all).
+func (r Retention) resultCutoff(now time.Time) time.Time {
+	if r.ResultSeasons <= 0 {
+		return time.Time{}
+	}
+	year, _ := strconv.Atoi(SeasonOf(now))
+	return time.Date(year-r.ResultSeasons+1, time.January, 1, 0, 0, 0, 0, now.Location())
+}
+
+// Prune removes what r no longer keeps as of now.
+func (s *Memory) Prune(r Retention, now time.Time) Pruned {
+	var p Pruned
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.Matches > 0 {
+		cutoff := now.Add(-r.Matches).UnixMilli()
+		for key, ms := range s.matches {
+			// newest first: keep the prefix played after cutoff
+			n := sort.Search(len(ms), func(i int) bool { return ms[i].GameCreation < cutoff })
+			if n == len(ms) {
+				continue
+			}
+			p.Matches += len(ms) - n
+			p.matchKeys = append(p.matchKeys, key)
+			if n == 0 {
+				delete(s.matches, key)
+			} else {
+				s.matches[key] = append([]analyzer.MatchSummary(nil), ms[:n]...)
+			}
+		}
+	}
+	if cutoff := r.resultCutoff(now); !cutoff.IsZero() {
+		for id, res := range s.results {
+			if res.CreatedAt.Before(cutoff) {
+				delete(s.results, id)
+				p.resultIDs = append(p.resultIDs, id)
+			}
+		}
+		for id, l := range s.lobbies {
+			if l.CreatedAt.Before(cutoff) && (l.StartsAt == nil || l.StartsAt.Before(cutoff)) {
+				delete(s.lobbies, id)
+				p.lobbyIDs = append(p.lobbyIDs, id)
+			}
+		}
+		p.Results, p.Lobbies = len(p.resultIDs), len(p.lobbyIDs)
+	}
+	return p
+}
@@ -0,0 +1,99 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+)
+
+func TestPrune(t *testing.T) {
+	now := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	day := func(n int) int64 { return now.AddDate(0, 0, -n).UnixMilli() }
+	m := NewMemory()
+	m.AddMatches("Alice", "JP1", []analyzer.MatchSummary{{MatchID: "JP1_1", GameCreation: day(1)}, {MatchID: "JP1_2", GameCreation: day(40)}})
+	m.AddMatches("Bob", "JP1", []analyzer.MatchSummary{{MatchID: "JP1_3", GameCreation: day(50)}})
+	current := m.AddResult("", analyzer.TeamSplit{})
+	lastSeason := m.AddResult("", analyzer.TeamSplit{})
+	older := m.AddResult("", analyzer.TeamSplit{})
+	oldLobby := m.CreateLobby("old", nil, [2]analyzer.TeamInfo{}, false, "")
+	planned := m.CreateLobby("planned", nil, [2]analyzer.TeamInfo{}, false, "")
+	for id, at := range map[string]time.Time{lastSeason.ID: now.AddDate(-1, 0, 0), older.ID: now.AddDate(-2, 0, 0)} {
+		r := m.results[id]
+		r.CreatedAt = at
+		m.results[id] = r
+	}
+	m.lobbies[oldLobby.ID].CreatedAt = now.AddDate(-2, 0, 0)
+	m.lobbies[planned.ID].CreatedAt = now.AddDate(-2, 0, 0)
+	starts := now.AddDate(0, 1, 0)
+	m.lobbies[planned.ID].StartsAt = &starts
+
+	if p := m.Prune(Retention{}, now); p.Matches+p.Results+p.Lobbies != 0 {
+		t.Fatalf("zero retention pruned %+v", p)
+	}
+	p := m.Prune(Retention{Matches: 30 * 24 * time.Hour, ResultSeasons: 2}, now)
+	if p.Matches != 2 || p.Results != 1 || p.Lobbies != 1 {
+		t.Errorf("pruned %+v, want 2 matches, 1 result and 1 lobby", p)
+	}
+	if ms := m.Matches("Alice", "JP1"); len(ms) != 1 || ms[0].MatchID != "JP1_1" {
+		t.Errorf("Alice's matches = %+v, want the recent one", ms)
+	}
+	if ms := m.Matches("Bob", "JP1"); len(ms) != 0 {
+		t.Errorf("Bob's matches = %+v, want none", ms)
+	}
+	for id, want := range map[string]bool{current.ID: true, lastSeason.ID: true, older.ID: false} {
+		if _, ok := m.Result(id); ok != want {
+			t.Errorf("result %s kept = %t, want %t", id, ok, want)
+		}
+	}
+	if _, ok := m.Lobby(oldLobby.ID); ok {
+		t.Error("old lobby kept")
+	}
+	if _, ok := m.Lobby(planned.ID); !ok {
+		t.Error("lobby planned for next month pruned")
+	}
+}
+
+func TestDeletePlayer(t *testing.T) {
+	m := NewMemory()
+	m.AddMatches("Alice", "JP1", []analyzer.MatchSummary{{MatchID: "JP1_1"}})
+	m.SetPool("Alice", "JP1", []string{"Ahri"})
+	m.SetNote("Alice", "JP1", "mid main", nil)
+	ts := analyzer.TeamSplit{TeamA: []analyzer.Profile{{Name: "Alice#JP1"}}, TeamB: []analyzer.Profile{{Name: "Bob#JP1"}}}
+	r := m.AddResult("", ts)
+	l := m.CreateLobby("friday", []analyzer.Player{{GameName: "alice", TagLine: "jp1"}, {GameName: "Bob", TagLine: "JP1"}}, [2]analyzer.TeamInfo{}, false, "")
+
+	d := m.DeletePlayer("Alice", "JP1")
+	if d.Matches != 1 || !d.Pool || !d.Note || len(d.Results) != 1 || len(d.Lobbies) != 1 {
+		t.Errorf("deletion %+v, want the match, pool, note, result and lobby", d)
+	}
+	if len(m.Matches("Alice", "JP1")) != 0 || m.Pool("Alice", "JP1") != nil {
+		t.Error("Alice's data kept")
+	}
+	if _, ok := m.Note("Alice", "JP1"); ok {
+		t.Error("Alice's note kept")
+	}
+	got, _ := m.Result(r.ID)
+	if got.Split.TeamA[0].Name != d.Alias || got.Split.TeamB[0].Name != "Bob#JP1" {
+		t.Errorf("result teams %q vs %q, want Alice replaced by %q", got.Split.TeamA[0].Name, got.Split.TeamB[0].Name, d.Alias)
+	}
+	if gl, _ := m.Lobby(l.ID); gl.Players[0].GameName != "deleted" || gl.Players[1].GameName != "Bob" {
+		t.Errorf("lobby players %+v, want Alice anonymized", gl.Players)
+	}
+
+	// opted out: nothing about her is stored again until she opts back in
+	if !m.OptedOut("alice", "jp1") {
+		t.Fatal("Alice not opted out")
+	}
+	m.AddMatches("Alice", "JP1", []analyzer.MatchSummary{{MatchID: "JP1_2"}})
+	if m.SetPool("Alice", "JP1", []string{"Ahri"}) != nil || len(m.Matches("Alice", "JP1")) != 0 {
+		t.Error("data stored for an opted-out player")
+	}
+	if !m.OptIn("Alice", "JP1") {
+		t.Fatal("OptIn = false")
+	}
+	m.AddMatches("Alice", "JP1", []analyzer.MatchSummary{{MatchID: "JP1_2"}})
+	if len(m.Matches("Alice", "JP1")) != 1 {
+		t.Error("matches not stored after opting back in")
+	}
+}
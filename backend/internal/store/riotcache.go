@@ -0,0 +1,107 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"lol_custom_skill_matching/internal/riot"
+)
+
+// riotCacheSweepEvery is how many puts pass between deletions of expired rows.
+const riotCacheSweepEvery = 1000
+
+// RiotCache is a riot.ResponseCache in the store's database, so cached Riot
+// answers survive restarts and are shared by every server on the database.
+type RiotCache struct {
+	db     *sql.DB
+	rebind func(string) string
+
+	mu   sync.Mutex
+	puts int
+}
+
+// RiotCache creates the riot_cache table if needed and returns a cache on it.
+func (s *SQL) RiotCache() (*RiotCache, error) {
+	if _, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS riot_cache (
+		key TEXT PRIMARY KEY,
+		endpoint TEXT NOT NULL,
+		subject TEXT NOT NULL,
+		status INTEGER NOT NULL,
+		body TEXT NOT NULL,
+		expires_at BIGINT NOT NULL
+	)`); err != nil {
+		return nil, fmt.Errorf("creating riot_cache: %w", err)
+	}
+	return &RiotCache{db: s.db, rebind: s.rebind}, nil
+}
+
+func (c *RiotCache) Get(key string) (riot.CachedResponse, bool) {
+	r := riot.CachedResponse{}
+	var body string
+	var expires int64
+	err := c.db.QueryRow(c.rebind(`SELECT endpoint, subject, status, body, expires_at FROM riot_cache WHERE key = ? AND expires_at > ?`),
+		key, time.Now().Unix()).Scan(&r.Endpoint, &r.Subject, &r.Status, &body, &expires)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("riot cache: reading %s: %v", key, err)
+		}
+		return riot.CachedResponse{}, false
+	}
+	r.Body = []byte(body)
+	r.Expires = time.Unix(expires, 0)
+	return r, true
+}
+
+// Put stores r; a failed write is logged, the answer is simply not cached.
+func (c *RiotCache) Put(key string, r riot.CachedResponse) {
+	if _, err := c.db.Exec(c.rebind(`INSERT INTO riot_cache (key, endpoint, subject, status, body, expires_at) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET endpoint = excluded.endpoint, subject = excluded.subject, status = excluded.status, body = excluded.body, expires_at = excluded.expires_at`),
+		key, r.Endpoint, r.Subject, r.Status, string(r.Body), r.Expires.Unix()); err != nil {
+		log.Printf("riot cache: writing %s: %v", key, err)
+		return
+	}
+	c.mu.Lock()
+	c.puts++
+	sweep := c.puts%riotCacheSweepEvery == 0
+	c.mu.Unlock()
+	if sweep {
+		if _, err := c.db.Exec(c.rebind(`DELETE FROM riot_cache WHERE expires_at <= ?`), time.Now().Unix()); err != nil {
+			log.Printf("riot cache: deleting expired rows: %v", err)
+		}
+	}
+}
+
+func (c *RiotCache) Purge(subject string) (int, error) {
+	q, args := `DELETE FROM riot_cache`, []any{}
+	if subject != "" {
+		q, args = q+` WHERE subject = ?`, append(args, subject)
+	}
+	res, err := c.db.Exec(c.rebind(q), args...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+func (c *RiotCache) Count() (map[string]riot.CacheCount, error) {
+	rows, err := c.db.Query(c.rebind(`SELECT endpoint, COUNT(*), COALESCE(SUM(LENGTH(body)), 0) FROM riot_cache WHERE expires_at > ? GROUP BY endpoint`), time.Now().Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]riot.CacheCount{}
+	for rows.Next() {
+		var ep string
+		var n riot.CacheCount
+		if err := rows.Scan(&ep, &n.Entries, &n.Bytes); err != nil {
+			return nil, err
+		}
+		out[ep] = n
+	}
+	return out, rows.Err()
+}
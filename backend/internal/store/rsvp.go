@@ -0,0 +1,100 @@
+package store
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+)
+
+// RSVP answers.
+const (
+	RSVPYes   = "yes"
+	RSVPMaybe = "maybe"
+	RSVPNo    = "no"
+)
+
+// Where an RSVP was last set.
+const (
+	RSVPSourceAPI     = "api"
+	RSVPSourceDiscord = "discord"
+)
+
+// ValidRSVP reports whether status is yes, maybe or no.
+func ValidRSVP(status string) bool {
+	return status == RSVPYes || status == RSVPMaybe || status == RSVPNo
+}
+
+// RSVP is whether a lobby's player will come to the game night.
+type RSVP struct {
+	Player string `json:"player"` // name#tag, as in Lobby.Players
+	Status string `json:"status"` // yes | maybe | no
+	// ArriveAt is when the player expects to arrive (nil = on time/unknown).
+	ArriveAt  *time.Time `json:"arrive_at,omitempty"`
+	Source    string     `json:"source"` // api | discord
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// DiscordLink ties a lobby to a Discord message whose reactions set its
+// players' RSVPs.
+type DiscordLink struct {
+	ChannelID string `json:"channel_id"`
+	MessageID string `json:"message_id"`
+	// Users maps Discord user ids to the lobby's players (name#tag).
+	Users map[string]string `json:"users"`
+	// Seen is each player's answer at the last sync, so an answer changed
+	// through the API stands until the player reacts differently.
+	Seen map[string]string `json:"seen,omitempty"`
+}
+
+// Member returns the lobby's player with Riot ID riotID (case-insensitive).
+func (l Lobby) Member(riotID string) (analyzer.Player, bool) {
+	for _, p := range l.Players {
+		if strings.EqualFold(p.RiotID(), strings.TrimSpace(riotID)) {
+			return p, true
+		}
+	}
+	return analyzer.Player{}, false
+}
+
+// RSVPOf returns the player's RSVP, if any.
+func (l Lobby) RSVPOf(player string) (RSVP, bool) {
+	i := slices.IndexFunc(l.RSVPs, func(r RSVP) bool { return strings.EqualFold(r.Player, player) })
+	if i < 0 {
+		return RSVP{}, false
+	}
+	return l.RSVPs[i], true
+}
+
+// SetRSVP replaces r.Player's RSVP (a zero Status removes it). The list is
+// copied, so lobbies handed out earlier keep theirs.
+func (l *Lobby) SetRSVP(r RSVP) {
+	rsvps := slices.DeleteFunc(slices.Clone(l.RSVPs), func(o RSVP) bool { return strings.EqualFold(o.Player, r.Player) })
+	if r.Status != "" {
+		rsvps = append(rsvps, r)
+	}
+	l.RSVPs = rsvps
+}
+
+// forgetPlayer removes the player with key from the RSVPs and the Discord
+// link, for DeletePlayer; reports whether there was anything to remove.
+func (l *Lobby) forgetPlayer(key string) bool {
+	isKey := func(riotID string) bool { return riotIDKeyOf(riotID) == key }
+	found := false
+	if slices.ContainsFunc(l.RSVPs, func(r RSVP) bool { return isKey(r.Player) }) {
+		l.RSVPs = slices.DeleteFunc(slices.Clone(l.RSVPs), func(r RSVP) bool { return isKey(r.Player) })
+		found = true
+	}
+	if d := l.Discord; d != nil {
+		c := *d
+		c.Users, c.Seen = maps.Clone(d.Users), maps.Clone(d.Seen)
+		maps.DeleteFunc(c.Users, func(_, player string) bool { return isKey(player) })
+		maps.DeleteFunc(c.Seen, func(player, _ string) bool { return isKey(player) })
+		if len(c.Users) != len(d.Users) || len(c.Seen) != len(d.Seen) {
+			l.Discord, found = &c, true
+		}
+	}
+	return found
+}
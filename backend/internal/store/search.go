@@ -0,0 +1,181 @@
+package store
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"lol_custom_skill_matching/internal/analyzer"
+)
+
+// KnownPlayer is a Riot ID the server has seen in ratings, lobbies, results,
+// verifications or appeals.
+type KnownPlayer struct {
+	Player   string    `json:"player"` // name#tag as last seen
+	LastSeen time.Time `json:"last_seen"`
+	Games    int       `json:"games"` // stored results it played in
+	Rated    bool      `json:"rated"` // has a stored rating
+	Verified bool      `json:"verified"`
+}
+
+// Match kinds, best first.
+const (
+	matchPrefix    = iota // name starts with the query
+	matchWord             // a later word of the name starts with it
+	matchSubstring        // the query appears somewhere
+	matchFuzzy            // one typo away from a prefix, or its letters in order
+	noMatch
+)
+
+// SearchPlayers returns up to limit known players matching q, best matches
+// first: prefix, then word prefix, substring and fuzzy matches, ties broken by
+// how often and how recently the player was seen. Matching ignores case; a
+// query containing "#" is matched against the whole Riot ID.
+func (s *Memory) SearchPlayers(q string, limit int) []KnownPlayer {
+	q = strings.ToLower(strings.TrimSpace(q))
+	if q == "" || limit <= 0 {
+		return []KnownPlayer{}
+	}
+	type hit struct {
+		KnownPlayer
+		kind int
+	}
+	var hits []hit
+	for key, kp := range s.knownPlayers() {
+		target := key
+		if !strings.Contains(q, "#") {
+			target, _, _ = strings.Cut(key, "#")
+		}
+		if kind := matchKind(strings.ToLower(target), q); kind != noMatch {
+			hits = append(hits, hit{kp, kind})
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		a, b := hits[i], hits[j]
+		if a.kind != b.kind {
+			return a.kind < b.kind
+		}
+		if a.Games != b.Games {
+			return a.Games > b.Games
+		}
+		if !a.LastSeen.Equal(b.LastSeen) {
+			return a.LastSeen.After(b.LastSeen)
+		}
+		return a.Player < b.Player
+	})
+	out := make([]KnownPlayer, 0, min(limit, len(hits)))
+	for i := 0; i < len(hits) && i < limit; i++ {
+		out = append(out, hits[i].KnownPlayer)
+	}
+	return out
+}
+
+// knownPlayers gathers every Riot ID in the store by RiotIDKey, keeping the
+// most recently seen spelling.
+func (s *Memory) knownPlayers() map[string]KnownPlayer {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	known := map[string]KnownPlayer{}
+	see := func(riotID string, at time.Time, f func(*KnownPlayer)) {
+		if !strings.Contains(riotID, "#") {
+			return
+		}
+		key := riotIDKeyOf(riotID)
+		kp, ok := known[key]
+		if !ok || at.After(kp.LastSeen) {
+			kp.Player = riotID
+			kp.LastSeen = at
+		}
+		if f != nil {
+			f(&kp)
+		}
+		known[key] = kp
+	}
+	for _, r := range s.ratings {
+		see(r.Player, r.UpdatedAt, func(kp *KnownPlayer) { kp.Rated = true })
+	}
+	for _, l := range s.lobbies {
+		for _, p := range l.Players {
+			see(p.RiotID(), l.CreatedAt, nil)
+		}
+	}
+	for _, res := range s.results {
+		for _, team := range [][]analyzer.Profile{res.Split.TeamA, res.Split.TeamB} {
+			for _, p := range team {
+				see(p.Name, res.CreatedAt, func(kp *KnownPlayer) { kp.Games++ })
+			}
+		}
+	}
+	for _, v := range s.verified {
+		see(v.Player, v.VerifiedAt, func(kp *KnownPlayer) { kp.Verified = true })
+	}
+	for _, a := range s.appeals {
+		see(a.Player, a.CreatedAt, nil)
+	}
+	return known
+}
+
+func matchKind(name, q string) int {
+	switch {
+	case strings.HasPrefix(name, q):
+		return matchPrefix
+	case wordPrefix(name, q):
+		return matchWord
+	case strings.Contains(name, q):
+		return matchSubstring
+	case utf8.RuneCountInString(q) >= 3 && (nearPrefix(name, q) || subsequence(name, q)):
+		return matchFuzzy
+	}
+	return noMatch
+}
+
+// wordPrefix reports whether a word of name (split on spaces, "_", "-", ".",
+// "#") starts with q.
+func wordPrefix(name, q string) bool {
+	for _, w := range strings.FieldsFunc(name, func(r rune) bool { return strings.ContainsRune(" _-.#", r) }) {
+		if strings.HasPrefix(w, q) {
+			return true
+		}
+	}
+	return false
+}
+
+// nearPrefix reports whether some prefix of name is within one edit of q.
+func nearPrefix(name, q string) bool {
+	n, m := []rune(name), []rune(q)
+	// prev[j] = distance between q[:i] and n[:j]; the answer is min over j of the last row
+	prev := make([]int, len(n)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(m); i++ {
+		cur := make([]int, len(n)+1)
+		cur[0] = i
+		for j := 1; j <= len(n); j++ {
+			cost := 1
+			if m[i-1] == n[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	for _, d := range prev {
+		if d <= 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// subsequence reports whether the runes of q appear in name in order.
+func subsequence(name, q string) bool {
+	rest := []rune(q)
+	for _, r := range name {
+		if len(rest) > 0 && r == rest[0] {
+			rest = rest[1:]
+		}
+	}
+	return len(rest) == 0
+}
@@ -0,0 +1,36 @@
+package store
+
+import (
+	"lol_custom_skill_matching/internal/analyzer"
+)
+
+// SideStats returns how often the player (by "name#tag") has played each side.
+func (s *Memory) SideStats(riotID string) analyzer.SideStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sides[riotIDKeyOf(riotID)]
+}
+
+// RecordSides adds the sides of a stored result to every player's history
+// (but an opted-out player's).
+func (s *Memory) RecordSides(ts analyzer.TeamSplit) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, team := range [][]analyzer.Profile{ts.TeamA, ts.TeamB} {
+		side := ts.Teams[i].Side
+		for _, p := range team {
+			key := riotIDKeyOf(p.Name)
+			if s.optedOut(key) {
+				continue
+			}
+			st := s.sides[key]
+			if side == analyzer.SideBlue {
+				st.Blue++
+			} else {
+				st.Red++
+			}
+			st.Last = side
+			s.sides[key] = st
+		}
+	}
+}
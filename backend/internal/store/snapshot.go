@@ -0,0 +1,111 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Snapshot is the whole store as bucket -> id -> record JSON, the same form the
+// SQL store keeps on disk. It moves state between stores and into backups.
+type Snapshot map[string]map[string]json.RawMessage
+
+var buckets = []string{
+	bucketPools, bucketMatches, bucketAppeals, bucketOverrides, bucketChallenges,
+	bucketVerified, bucketLobbies, bucketSides, bucketRatings, bucketResults,
+	bucketOptOuts, bucketNotes, bucketRankWatch,
+}
+
+// ids lists the record ids of a bucket.
+func (m *Memory) ids(bucket string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var ids []string
+	switch bucket {
+	case bucketPools:
+		ids = keysOf(m.pools)
+	case bucketMatches:
+		ids = keysOf(m.matches)
+	case bucketAppeals:
+		ids = append(ids, m.appealOrder...)
+	case bucketOverrides:
+		ids = keysOf(m.overrides)
+	case bucketChallenges:
+		ids = keysOf(m.challenges)
+	case bucketVerified:
+		ids = keysOf(m.verified)
+	case bucketLobbies:
+		ids = keysOf(m.lobbies)
+	case bucketSides:
+		ids = keysOf(m.sides)
+	case bucketRatings:
+		ids = keysOf(m.ratings)
+	case bucketResults:
+		ids = keysOf(m.results)
+	case bucketOptOuts:
+		ids = keysOf(m.optouts)
+	case bucketNotes:
+		ids = keysOf(m.notes)
+	case bucketRankWatch:
+		ids = keysOf(m.rankWatches)
+	}
+	return ids
+}
+
+func keysOf[V any](m map[string]V) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
+
+// Snapshot copies every record.
+func (m *Memory) Snapshot() (Snapshot, error) {
+	snap := Snapshot{}
+	for _, bucket := range buckets {
+		recs := map[string]json.RawMessage{}
+		for _, id := range m.ids(bucket) {
+			v, ok := m.record(bucket, id)
+			if !ok {
+				continue // removed since ids was taken
+			}
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s/%s: %w", bucket, id, err)
+			}
+			recs[id] = b
+		}
+		snap[bucket] = recs
+	}
+	return snap, nil
+}
+
+// Restore replaces the whole state with snap. Nothing changes when a record
+// fails to decode.
+func (m *Memory) Restore(snap Snapshot) error {
+	fresh := NewMemory()
+	for bucket, recs := range snap {
+		for id, data := range recs {
+			if err := fresh.loadRecord(bucket, id, data); err != nil {
+				return fmt.Errorf("%s/%s: %w", bucket, id, err)
+			}
+		}
+	}
+	fresh.sortAppeals()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pools, m.matches = fresh.pools, fresh.matches
+	m.appeals, m.appealOrder, m.overrides = fresh.appeals, fresh.appealOrder, fresh.overrides
+	m.challenges, m.verified = fresh.challenges, fresh.verified
+	m.lobbies, m.sides, m.ratings, m.results = fresh.lobbies, fresh.sides, fresh.ratings, fresh.results
+	m.optouts, m.notes, m.rankWatches = fresh.optouts, fresh.notes, fresh.rankWatches
+	return nil
+}
+
+// sortAppeals restores creation order after loading appeals from storage.
+func (m *Memory) sortAppeals() {
+	sort.Slice(m.appealOrder, func(i, j int) bool {
+		return m.appeals[m.appealOrder[i]].CreatedAt.Before(m.appeals[m.appealOrder[j]].CreatedAt)
+	})
+}
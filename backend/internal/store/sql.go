@@ -0,0 +1,745 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+)
+
+// sqlDriverNames maps Open's driver names to database/sql driver names. The
+// drivers are linked in with build tags (see driver_*.go) so the default build
+// stays free of cgo and extra modules.
+var sqlDriverNames = map[string]string{
+	DriverSQLite:   "sqlite",
+	DriverPostgres: "pgx",
+}
+
+// Buckets of the store_records table, one per kind of state.
+const (
+	bucketPools      = "pools"
+	bucketMatches    = "matches"
+	bucketAppeals    = "appeals"
+	bucketOverrides  = "overrides"
+	bucketChallenges = "challenges"
+	bucketVerified   = "verified"
+	bucketLobbies    = "lobbies"
+	bucketSides      = "sides"
+	bucketRatings    = "ratings"
+	bucketResults    = "results"
+	bucketOptOuts    = "optouts"
+	bucketNotes      = "notes"
+	bucketRankWatch  = "rankwatch"
+)
+
+// Records keep the fields the API hides (json:"-") so they survive a restart.
+type (
+	lobbyRecord struct {
+		Lobby
+		Split         *analyzer.TeamSplit `json:"split,omitempty"`
+		RevealedRoles int                 `json:"revealed_roles"`
+		Accepted      [2]bool             `json:"accepted"`
+		CaptainTokens [2]string           `json:"captain_tokens"`
+	}
+	challengeRecord struct {
+		Challenge
+		PUUID string `json:"puuid"`
+	}
+	verificationRecord struct {
+		Verification
+		PUUID string `json:"puuid"`
+		Token string `json:"token,omitempty"`
+	}
+	rankWatchRecord struct {
+		RankWatch
+		PUUID string `json:"puuid"`
+	}
+)
+
+// SQL is a Store persisted to a database. All state is loaded into a Memory
+// at start and every change is written through as one JSON row per record, so
+// the schema is a single table that works on both SQLite and Postgres. Most
+// reads are served from memory; matches, lobbies and results are read from
+// the database first (see refresh), so two processes on the same database,
+// like the old and the new one of a SIGHUP reload, see each other's.
+type SQL struct {
+	*Memory
+	db      *sql.DB
+	dialect string
+
+	wmu  sync.Mutex                 // serializes change + write-through
+	rows map[string]map[string]bool // bucket -> ids present in the database
+}
+
+// HasDriver reports whether this binary links in driver ("sqlite" or
+// "postgres").
+func HasDriver(driver string) bool {
+	name := sqlDriverNames[driver]
+	return name != "" && slices.Contains(sql.Drivers(), name)
+}
+
+// OpenSQL connects to driver ("sqlite" or "postgres"), creates the table if
+// needed and loads everything.
+func OpenSQL(driver, dsn string) (*SQL, error) {
+	name := sqlDriverNames[driver]
+	if name == "" {
+		return nil, fmt.Errorf("unknown sql driver %q", driver)
+	}
+	if !HasDriver(driver) {
+		return nil, fmt.Errorf("this binary was built without %s support (rebuild with -tags %s)", driver, driver)
+	}
+	db, err := sql.Open(name, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if driver == DriverSQLite {
+		db.SetMaxOpenConns(1) // one writer; avoids "database is locked"
+	}
+	s := &SQL{Memory: NewMemory(), db: db, dialect: driver, rows: map[string]map[string]bool{}}
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS store_records (
+		bucket TEXT NOT NULL,
+		id TEXT NOT NULL,
+		data TEXT NOT NULL,
+		PRIMARY KEY (bucket, id)
+	)`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating store_records: %w", err)
+	}
+	if err := s.load(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Ping checks that the database answers.
+func (s *SQL) Ping(ctx context.Context) error { return s.db.PingContext(ctx) }
+
+func (s *SQL) Close() error { return s.db.Close() }
+
+// rebind turns ? placeholders into $n for Postgres.
+func (s *SQL) rebind(q string) string {
+	if s.dialect != DriverPostgres {
+		return q
+	}
+	var b strings.Builder
+	n := 0
+	for _, c := range q {
+		if c == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+func (s *SQL) load() error {
+	rows, err := s.db.Query(`SELECT bucket, id, data FROM store_records`)
+	if err != nil {
+		return fmt.Errorf("loading store: %w", err)
+	}
+	defer rows.Close()
+	m := s.Memory
+	for rows.Next() {
+		var bucket, id, data string
+		if err := rows.Scan(&bucket, &id, &data); err != nil {
+			return err
+		}
+		if err := m.loadRecord(bucket, id, []byte(data)); err != nil {
+			return fmt.Errorf("loading %s/%s: %w", bucket, id, err)
+		}
+		if s.rows[bucket] == nil {
+			s.rows[bucket] = map[string]bool{}
+		}
+		s.rows[bucket][id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	m.sortAppeals()
+	return nil
+}
+
+// loadRecord puts one database row back into memory.
+func (m *Memory) loadRecord(bucket, id string, data []byte) error {
+	switch bucket {
+	case bucketPools:
+		var v []string
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		m.pools[id] = v
+	case bucketMatches:
+		var v []analyzer.MatchSummary
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		m.matches[id] = v
+	case bucketAppeals:
+		var v Appeal
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		v.key = riotIDKeyOf(v.Player)
+		m.appeals[id] = &v
+		m.appealOrder = append(m.appealOrder, id)
+	case bucketOverrides:
+		var v Override
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		m.overrides[id] = v
+	case bucketChallenges:
+		var v challengeRecord
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		c := v.Challenge
+		c.PUUID, c.key = v.PUUID, riotIDKeyOf(c.Player)
+		m.challenges[id] = &c
+	case bucketVerified:
+		var v verificationRecord
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		v.Verification.PUUID, v.Verification.Token = v.PUUID, v.Token
+		m.verified[id] = v.Verification
+	case bucketLobbies:
+		var v lobbyRecord
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		l := v.Lobby
+		l.Split, l.RevealedRoles, l.Accepted, l.CaptainTokens = v.Split, v.RevealedRoles, v.Accepted, v.CaptainTokens
+		m.lobbies[id] = &l
+	case bucketSides:
+		var v analyzer.SideStats
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		m.sides[id] = v
+	case bucketRatings:
+		var v Rating
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		m.ratings[id] = v
+	case bucketResults:
+		var v Result
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		m.results[id] = v
+	case bucketOptOuts:
+		var v OptOut
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		m.optouts[id] = v
+	case bucketNotes:
+		var v PlayerNote
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		m.notes[id] = v
+	case bucketRankWatch:
+		var v rankWatchRecord
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		v.RankWatch.PUUID = v.PUUID
+		m.rankWatches[id] = v.RankWatch
+	default:
+		log.Printf("store: ignoring unknown bucket %q", bucket)
+	}
+	return nil
+}
+
+// reloadRecords replaces records of bucket with rows read from the database:
+// the one with id, or the whole bucket when id is "". Records without a row
+// are dropped. Only the buckets SQL re-reads are supported.
+func (m *Memory) reloadRecords(bucket, id string, rows map[string][]byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch bucket {
+	case bucketMatches:
+		dropRecords(m.matches, id)
+	case bucketLobbies:
+		dropRecords(m.lobbies, id)
+	case bucketResults:
+		dropRecords(m.results, id)
+	default:
+		return fmt.Errorf("bucket %s is not re-read", bucket)
+	}
+	for rid, data := range rows {
+		if err := m.loadRecord(bucket, rid, data); err != nil {
+			return fmt.Errorf("%s/%s: %w", bucket, rid, err)
+		}
+	}
+	return nil
+}
+
+// dropRecords deletes id from recs, or every record when id is "".
+func dropRecords[V any](recs map[string]V, id string) {
+	if id == "" {
+		clear(recs)
+		return
+	}
+	delete(recs, id)
+}
+
+// record returns the current in-memory value of a record, in its stored form.
+func (m *Memory) record(bucket, id string) (any, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var v any
+	var ok bool
+	switch bucket {
+	case bucketPools:
+		v, ok = m.pools[id]
+	case bucketMatches:
+		v, ok = m.matches[id]
+	case bucketAppeals:
+		var a *Appeal
+		if a, ok = m.appeals[id]; ok {
+			v = *a
+		}
+	case bucketOverrides:
+		v, ok = m.overrides[id]
+	case bucketChallenges:
+		var c *Challenge
+		if c, ok = m.challenges[id]; ok {
+			v = challengeRecord{Challenge: *c, PUUID: c.PUUID}
+		}
+	case bucketVerified:
+		var ver Verification
+		if ver, ok = m.verified[id]; ok {
+			v = verificationRecord{Verification: ver, PUUID: ver.PUUID, Token: ver.Token}
+		}
+	case bucketLobbies:
+		var l *Lobby
+		if l, ok = m.lobbies[id]; ok {
+			v = lobbyRecord{Lobby: *l, Split: l.Split, RevealedRoles: l.RevealedRoles, Accepted: l.Accepted, CaptainTokens: l.CaptainTokens}
+		}
+	case bucketSides:
+		v, ok = m.sides[id]
+	case bucketRatings:
+		v, ok = m.ratings[id]
+	case bucketResults:
+		v, ok = m.results[id]
+	case bucketOptOuts:
+		v, ok = m.optouts[id]
+	case bucketNotes:
+		v, ok = m.notes[id]
+	case bucketRankWatch:
+		var w RankWatch
+		if w, ok = m.rankWatches[id]; ok {
+			v = rankWatchRecord{RankWatch: w, PUUID: w.PUUID}
+		}
+	}
+	return v, ok
+}
+
+// sync writes the given records through: upserted when present in memory,
+// deleted when not. Failures are logged; memory stays authoritative until restart.
+func (s *SQL) sync(bucket string, ids ...string) {
+	for _, id := range ids {
+		if err := s.syncOne(bucket, id); err != nil {
+			log.Printf("store: writing %s/%s: %v", bucket, id, err)
+		}
+	}
+}
+
+func (s *SQL) syncOne(bucket, id string) error {
+	v, ok := s.Memory.record(bucket, id)
+	if !ok {
+		if !s.rows[bucket][id] {
+			return nil
+		}
+		if _, err := s.db.Exec(s.rebind(`DELETE FROM store_records WHERE bucket = ? AND id = ?`), bucket, id); err != nil {
+			return err
+		}
+		delete(s.rows[bucket], id)
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(s.rebind(`INSERT INTO store_records (bucket, id, data) VALUES (?, ?, ?)
+		ON CONFLICT (bucket, id) DO UPDATE SET data = excluded.data`), bucket, id, string(b)); err != nil {
+		return err
+	}
+	if s.rows[bucket] == nil {
+		s.rows[bucket] = map[string]bool{}
+	}
+	s.rows[bucket][id] = true
+	return nil
+}
+
+// refresh re-reads the record id of bucket (the whole bucket when id is "")
+// from the database into memory, so a change another process made to the
+// same database is seen. On failure memory is served as it is. Callers hold
+// s.wmu.
+func (s *SQL) refresh(bucket, id string) {
+	q, args := `SELECT id, data FROM store_records WHERE bucket = ?`, []any{bucket}
+	if id != "" {
+		q, args = q+` AND id = ?`, append(args, id)
+	}
+	rows, err := s.db.Query(s.rebind(q), args...)
+	if err != nil {
+		log.Printf("store: reading %s: %v", bucket, err)
+		return
+	}
+	defer rows.Close()
+	found := map[string][]byte{}
+	for rows.Next() {
+		var rid, data string
+		if err := rows.Scan(&rid, &data); err != nil {
+			log.Printf("store: reading %s: %v", bucket, err)
+			return
+		}
+		found[rid] = []byte(data)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("store: reading %s: %v", bucket, err)
+		return
+	}
+	if err := s.Memory.reloadRecords(bucket, id, found); err != nil {
+		log.Printf("store: reading %v", err)
+		return
+	}
+	if s.rows[bucket] == nil || id == "" {
+		s.rows[bucket] = map[string]bool{}
+	}
+	if id != "" {
+		delete(s.rows[bucket], id)
+	}
+	for rid := range found {
+		s.rows[bucket][rid] = true
+	}
+}
+
+// persisted lists the ids of a bucket that are in the database, so records
+// dropped from memory as a side effect can be deleted too.
+func (s *SQL) persisted(bucket string) []string {
+	ids := make([]string, 0, len(s.rows[bucket]))
+	for id := range s.rows[bucket] {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// ---- write-through wrappers for every method that changes state, and the
+// reads that go to the database ----
+
+func (s *SQL) SetPool(gameName, tagLine string, champs []string) []string {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	out := s.Memory.SetPool(gameName, tagLine, champs)
+	s.sync(bucketPools, RiotIDKey(gameName, tagLine))
+	return out
+}
+
+func (s *SQL) AddMatches(gameName, tagLine string, ms []analyzer.MatchSummary) {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	s.refresh(bucketMatches, RiotIDKey(gameName, tagLine))
+	s.Memory.AddMatches(gameName, tagLine, ms)
+	s.sync(bucketMatches, RiotIDKey(gameName, tagLine))
+}
+
+func (s *SQL) Matches(gameName, tagLine string) []analyzer.MatchSummary {
+	s.wmu.Lock()
+	s.refresh(bucketMatches, RiotIDKey(gameName, tagLine))
+	s.wmu.Unlock()
+	return s.Memory.Matches(gameName, tagLine)
+}
+
+func (s *SQL) HasMatch(gameName, tagLine, matchID string) bool {
+	s.wmu.Lock()
+	s.refresh(bucketMatches, RiotIDKey(gameName, tagLine))
+	s.wmu.Unlock()
+	return s.Memory.HasMatch(gameName, tagLine, matchID)
+}
+
+// LoadMatches merges a match file (e.g. from before the database was set up)
+// and writes the imported players through.
+func (s *SQL) LoadMatches(path string) error {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	before := map[string]bool{}
+	s.Memory.mu.RLock()
+	for key := range s.Memory.matches {
+		before[key] = true
+	}
+	s.Memory.mu.RUnlock()
+	if err := s.Memory.LoadMatches(path); err != nil {
+		return err
+	}
+	var added []string
+	s.Memory.mu.RLock()
+	for key := range s.Memory.matches {
+		if !before[key] {
+			added = append(added, key)
+		}
+	}
+	s.Memory.mu.RUnlock()
+	s.sync(bucketMatches, added...)
+	return nil
+}
+
+func (s *SQL) AddAppeal(gameName, tagLine, note string, computed, requested int) Appeal {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	a := s.Memory.AddAppeal(gameName, tagLine, note, computed, requested)
+	s.sync(bucketAppeals, a.ID)
+	return a
+}
+
+func (s *SQL) DecideAppeal(id string, approve bool, score int, note string) (Appeal, error) {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	a, err := s.Memory.DecideAppeal(id, approve, score, note)
+	if err == nil {
+		s.sync(bucketAppeals, a.ID)
+		s.sync(bucketOverrides, riotIDKeyOf(a.Player))
+	}
+	return a, err
+}
+
+func (s *SQL) ClearScoreOverride(gameName, tagLine string) {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	s.Memory.ClearScoreOverride(gameName, tagLine)
+	s.sync(bucketOverrides, RiotIDKey(gameName, tagLine))
+}
+
+func (s *SQL) AddChallenge(gameName, tagLine, puuid, method string, iconID int, code string, ttl time.Duration) Challenge {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	c := s.Memory.AddChallenge(gameName, tagLine, puuid, method, iconID, code, ttl)
+	// adding a challenge drops the player's earlier one and any expired ones
+	s.sync(bucketChallenges, append(s.persisted(bucketChallenges), c.Token)...)
+	return c
+}
+
+func (s *SQL) CompleteChallenge(token string) (Verification, bool) {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	v, ok := s.Memory.CompleteChallenge(token)
+	if ok {
+		s.sync(bucketChallenges, token)
+		s.sync(bucketVerified, riotIDKeyOf(v.Player))
+	}
+	return v, ok
+}
+
+func (s *SQL) CreateLobby(name string, players []analyzer.Player, teams [2]analyzer.TeamInfo, blind bool, reveal string) Lobby {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	l := s.Memory.CreateLobby(name, players, teams, blind, reveal)
+	s.sync(bucketLobbies, l.ID)
+	return l
+}
+
+func (s *SQL) Lobby(id string) (Lobby, bool) {
+	s.wmu.Lock()
+	s.refresh(bucketLobbies, id)
+	s.wmu.Unlock()
+	return s.Memory.Lobby(id)
+}
+
+func (s *SQL) Lobbies() []Lobby {
+	s.wmu.Lock()
+	s.refresh(bucketLobbies, "")
+	s.wmu.Unlock()
+	return s.Memory.Lobbies()
+}
+
+func (s *SQL) UpdateLobby(id string, f func(*Lobby) error) (Lobby, error) {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	s.refresh(bucketLobbies, id)
+	l, err := s.Memory.UpdateLobby(id, f)
+	if err == nil {
+		s.sync(bucketLobbies, id)
+	}
+	return l, err
+}
+
+func (s *SQL) RecordSides(ts analyzer.TeamSplit) {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	s.Memory.RecordSides(ts)
+	var keys []string
+	for _, p := range append(append([]analyzer.Profile{}, ts.TeamA...), ts.TeamB...) {
+		keys = append(keys, riotIDKeyOf(p.Name))
+	}
+	s.sync(bucketSides, keys...)
+}
+
+func (s *SQL) RecordRatings(profiles []analyzer.Profile) {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	s.Memory.RecordRatings(profiles)
+	var keys []string
+	for _, p := range profiles {
+		keys = append(keys, riotIDKeyOf(p.Name))
+	}
+	s.sync(bucketRatings, keys...)
+}
+
+func (s *SQL) ImportRatings(rs []Rating) {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	s.Memory.ImportRatings(rs)
+	var keys []string
+	for _, r := range rs {
+		keys = append(keys, riotIDKeyOf(r.Player))
+	}
+	s.sync(bucketRatings, keys...)
+	s.sync(bucketPools, keys...)
+	s.sync(bucketOverrides, keys...)
+}
+
+// Restore replaces the state and rewrites the table in one transaction.
+func (s *SQL) Restore(snap Snapshot) error {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM store_records`); err != nil {
+		return err
+	}
+	rows := map[string]map[string]bool{}
+	for bucket, recs := range snap {
+		rows[bucket] = map[string]bool{}
+		for id, data := range recs {
+			if _, err := tx.Exec(s.rebind(`INSERT INTO store_records (bucket, id, data) VALUES (?, ?, ?)`), bucket, id, string(data)); err != nil {
+				return err
+			}
+			rows[bucket][id] = true
+		}
+	}
+	if err := s.Memory.Restore(snap); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.rows = rows
+	return nil
+}
+
+func (s *SQL) SetNote(gameName, tagLine, note string, tags []string) (PlayerNote, bool) {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	n, ok := s.Memory.SetNote(gameName, tagLine, note, tags)
+	s.sync(bucketNotes, RiotIDKey(gameName, tagLine))
+	return n, ok
+}
+
+func (s *SQL) SetRankWatch(w RankWatch) (RankWatch, bool) {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	w, ok := s.Memory.SetRankWatch(w)
+	s.sync(bucketRankWatch, riotIDKeyOf(w.Player))
+	return w, ok
+}
+
+func (s *SQL) DeleteRankWatch(gameName, tagLine string) bool {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	ok := s.Memory.DeleteRankWatch(gameName, tagLine)
+	s.sync(bucketRankWatch, RiotIDKey(gameName, tagLine))
+	return ok
+}
+
+func (s *SQL) RecordRank(gameName, tagLine, puuid string, seen RankSeen, at time.Time, notified bool) {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	s.Memory.RecordRank(gameName, tagLine, puuid, seen, at, notified)
+	s.sync(bucketRankWatch, RiotIDKey(gameName, tagLine))
+}
+
+func (s *SQL) AddResult(lobbyID string, ts analyzer.TeamSplit) Result {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	r := s.Memory.AddResult(lobbyID, ts)
+	s.sync(bucketResults, r.ID)
+	return r
+}
+
+func (s *SQL) Result(id string) (Result, bool) {
+	s.wmu.Lock()
+	s.refresh(bucketResults, id)
+	s.wmu.Unlock()
+	return s.Memory.Result(id)
+}
+
+func (s *SQL) Results() []Result {
+	s.wmu.Lock()
+	s.refresh(bucketResults, "")
+	s.wmu.Unlock()
+	return s.Memory.Results()
+}
+
+func (s *SQL) SetOutcome(id string, o Outcome) (Result, error) {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	s.refresh(bucketResults, id)
+	r, err := s.Memory.SetOutcome(id, o)
+	if err == nil {
+		s.sync(bucketResults, id)
+	}
+	return r, err
+}
+
+func (s *SQL) DeletePlayer(gameName, tagLine string) Deletion {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	d := s.Memory.DeletePlayer(gameName, tagLine)
+	key := RiotIDKey(gameName, tagLine)
+	for _, bucket := range []string{bucketMatches, bucketRatings, bucketPools, bucketOverrides, bucketVerified, bucketSides, bucketNotes, bucketRankWatch, bucketOptOuts} {
+		s.sync(bucket, key)
+	}
+	s.sync(bucketChallenges, s.persisted(bucketChallenges)...)
+	s.sync(bucketAppeals, d.Appeals...)
+	s.sync(bucketResults, d.Results...)
+	s.sync(bucketLobbies, d.Lobbies...)
+	return d
+}
+
+func (s *SQL) OptIn(gameName, tagLine string) bool {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	ok := s.Memory.OptIn(gameName, tagLine)
+	s.sync(bucketOptOuts, RiotIDKey(gameName, tagLine))
+	return ok
+}
+
+// Prune deletes the pruned rows. SQLite reuses their pages, so the file stops
+// growing rather than shrinking.
+func (s *SQL) Prune(r Retention, now time.Time) Pruned {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	p := s.Memory.Prune(r, now)
+	s.sync(bucketMatches, p.matchKeys...)
+	s.sync(bucketResults, p.resultIDs...)
+	s.sync(bucketLobbies, p.lobbyIDs...)
+	return p
+}
@@ -0,0 +1,66 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+)
+
+// TestSQLSharedDatabase opens two stores on one database, like the old and the
+// new process of a SIGHUP reload: each sees the matches, lobbies and results
+// the other wrote. It runs with -tags sqlite.
+func TestSQLSharedDatabase(t *testing.T) {
+	if !HasDriver(DriverSQLite) {
+		t.Skip("built without -tags sqlite")
+	}
+	path := filepath.Join(t.TempDir(), "store.db")
+	old, err := OpenSQL(DriverSQLite, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer old.Close()
+	succ, err := OpenSQL(DriverSQLite, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer succ.Close()
+
+	old.AddMatches("Player0", "JP1", []analyzer.MatchSummary{{MatchID: "JP1_1", GameCreation: 1}})
+	if !succ.HasMatch("player0", "jp1", "JP1_1") {
+		t.Error("match added by the other store not found")
+	}
+	succ.AddMatches("Player0", "JP1", []analyzer.MatchSummary{{MatchID: "JP1_2", GameCreation: 2}})
+	if ms := old.Matches("Player0", "JP1"); len(ms) != 2 || ms[0].MatchID != "JP1_2" {
+		t.Errorf("matches = %+v, want both stores' merged, newest first", ms)
+	}
+
+	l := old.CreateLobby("friday", nil, [2]analyzer.TeamInfo{}, false, "")
+	if _, err := succ.UpdateLobby(l.ID, func(l *Lobby) error { l.Name = "saturday"; return nil }); err != nil {
+		t.Fatalf("updating the other store's lobby: %v", err)
+	}
+	if got, ok := old.Lobby(l.ID); !ok || got.Name != "saturday" {
+		t.Errorf("lobby = %+v, %t, want the other store's update", got, ok)
+	}
+	if ls := succ.Lobbies(); len(ls) != 1 {
+		t.Errorf("lobbies = %d, want 1", len(ls))
+	}
+
+	r := succ.AddResult(l.ID, analyzer.TeamSplit{})
+	if rs := old.Results(); len(rs) != 1 || rs[0].ID != r.ID {
+		t.Fatalf("results = %+v, want the other store's", rs)
+	}
+	if _, err := old.SetOutcome(r.ID, Outcome{Winner: WinnerA, RecordedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := succ.Result(r.ID); !ok || got.Outcome == nil || got.Outcome.Winner != WinnerA {
+		t.Errorf("result = %+v, want the other store's outcome", got)
+	}
+
+	// a deletion by one store reaches the other's memory
+	old.DeletePlayer("Player0", "JP1")
+	if ms := succ.Matches("Player0", "JP1"); len(ms) != 0 {
+		t.Errorf("matches after deletion = %+v, want none", ms)
+	}
+}
@@ -0,0 +1,118 @@
+package store
+
+import (
+	"fmt"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+)
+
+// Store is the server state the HTTP layer and background workers use. Memory
+// is the zero-setup default; SQL keeps the same state in SQLite or Postgres.
+type Store interface {
+	// champion pools
+	Pool(gameName, tagLine string) []string
+	SetPool(gameName, tagLine string, champs []string) []string
+
+	// match summaries
+	AddMatches(gameName, tagLine string, ms []analyzer.MatchSummary)
+	Matches(gameName, tagLine string) []analyzer.MatchSummary
+	HasMatch(gameName, tagLine, matchID string) bool
+	SaveMatches(path string) error
+	LoadMatches(path string) error
+
+	// appeals and overrides
+	AddAppeal(gameName, tagLine, note string, computed, requested int) Appeal
+	Appeals(state string) []Appeal
+	PlayerAppeals(gameName, tagLine string) []Appeal
+	DecideAppeal(id string, approve bool, score int, note string) (Appeal, error)
+	ScoreOverride(gameName, tagLine string) (Override, bool)
+	ClearScoreOverride(gameName, tagLine string)
+
+	// ownership verification
+	AddChallenge(gameName, tagLine, puuid, method string, iconID int, code string, ttl time.Duration) Challenge
+	ChallengeByToken(token string) (Challenge, bool)
+	CompleteChallenge(token string) (Verification, bool)
+	Verified(gameName, tagLine string) (Verification, bool)
+
+	// lobbies
+	CreateLobby(name string, players []analyzer.Player, teams [2]analyzer.TeamInfo, blind bool, reveal string) Lobby
+	Lobby(id string) (Lobby, bool)
+	Lobbies() []Lobby
+	UpdateLobby(id string, f func(*Lobby) error) (Lobby, error)
+
+	// side history
+	SideStats(riotID string) analyzer.SideStats
+	RecordSides(ts analyzer.TeamSplit)
+
+	// ratings (profiles)
+	RecordRatings(profiles []analyzer.Profile)
+	Ratings() []Rating
+	ImportRatings(rs []Rating)
+	LatestProfile(riotID string) (analyzer.Profile, time.Time, bool)
+
+	// results
+	AddResult(lobbyID string, ts analyzer.TeamSplit) Result
+	Result(id string) (Result, bool)
+	Results() []Result
+	SetOutcome(id string, o Outcome) (Result, error)
+
+	// participation
+	Participation(riotID string) analyzer.Participation
+	Participations() map[string]analyzer.Participation
+
+	// organizer notes and tags
+	Note(gameName, tagLine string) (PlayerNote, bool)
+	SetNote(gameName, tagLine, note string, tags []string) (PlayerNote, bool)
+	Notes(tag string) []PlayerNote
+
+	// rank alerts
+	RankWatch(gameName, tagLine string) (RankWatch, bool)
+	RankWatches() []RankWatch
+	SetRankWatch(w RankWatch) (RankWatch, bool)
+	DeleteRankWatch(gameName, tagLine string) bool
+	RecordRank(gameName, tagLine, puuid string, seen RankSeen, at time.Time, notified bool)
+
+	// search
+	SearchPlayers(q string, limit int) []KnownPlayer
+
+	// data deletion
+	DeletePlayer(gameName, tagLine string) Deletion
+	OptedOut(gameName, tagLine string) bool
+	OptOuts() []OptOut
+	OptIn(gameName, tagLine string) bool
+
+	// retention
+	Prune(r Retention, now time.Time) Pruned
+
+	// backup
+	Snapshot() (Snapshot, error)
+	Restore(snap Snapshot) error
+}
+
+var (
+	_ Store = (*Memory)(nil)
+	_ Store = (*SQL)(nil)
+)
+
+// Drivers accepted by Open.
+const (
+	DriverMemory   = "memory"
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
+// Open returns the store for driver: "memory" (or "") needs no dsn; "sqlite"
+// takes a file path and "postgres" a connection URL.
+func Open(driver, dsn string) (Store, error) {
+	switch driver {
+	case "", DriverMemory:
+		return NewMemory(), nil
+	case DriverSQLite, DriverPostgres:
+		if dsn == "" {
+			return nil, fmt.Errorf("store %s needs a dsn", driver)
+		}
+		return OpenSQL(driver, dsn)
+	}
+	return nil, fmt.Errorf("unknown store driver %q (memory|sqlite|postgres)", driver)
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"time"
+)
+
+const (
+	VerifyIcon = "icon" // set a given profile icon
+	VerifyCode = "code" // enter a code in the client's third-party verification field
+)
+
+// Challenge is a pending ownership proof for a Riot ID, addressed by its token
+// (the magic link) so no login session is needed.
+type Challenge struct {
+	Token     string    `json:"token"`
+	Player    string    `json:"player"`
+	Method    string    `json:"method"`
+	IconID    int       `json:"icon_id,omitempty"`
+	Code      string    `json:"code,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+
+	PUUID string `json:"-"`
+	key   string
+}
+
+// Verification records that the owner of PUUID proved control of the Riot ID.
+// Token, handed out once when the challenge is completed, is the member
+// token the player uses to manage their own settings (rank alerts).
+type Verification struct {
+	Player     string    `json:"player"`
+	Method     string    `json:"method"`
+	VerifiedAt time.Time `json:"verified_at"`
+
+	PUUID string `json:"-"`
+	Token string `json:"-"`
+}
+
+// AddChallenge stores a new challenge for the player, replacing any earlier one.
+func (s *Memory) AddChallenge(gameName, tagLine, puuid, method string, iconID int, code string, ttl time.Duration) Challenge {
+	c := &Challenge{
+		Token: newID() + newID(), Player: gameName + "#" + tagLine, Method: method,
+		IconID: iconID, Code: code, ExpiresAt: time.Now().Add(ttl),
+		PUUID: puuid, key: RiotIDKey(gameName, tagLine),
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for tok, old := range s.challenges {
+		if old.key == c.key || time.Now().After(old.ExpiresAt) {
+			delete(s.challenges, tok)
+		}
+	}
+	s.challenges[c.Token] = c
+	return *c
+}
+
+// ChallengeByToken returns an unexpired challenge.
+func (s *Memory) ChallengeByToken(token string) (Challenge, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.challenges[token]
+	if !ok || time.Now().After(c.ExpiresAt) {
+		return Challenge{}, false
+	}
+	return *c, true
+}
+
+// CompleteChallenge consumes the challenge and marks the player verified.
+func (s *Memory) CompleteChallenge(token string) (Verification, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.challenges[token]
+	if !ok || time.Now().After(c.ExpiresAt) {
+		return Verification{}, false
+	}
+	delete(s.challenges, token)
+	v := Verification{Player: c.Player, Method: c.Method, VerifiedAt: time.Now(), PUUID: c.PUUID, Token: newID() + newID()}
+	s.verified[c.key] = v
+	return v, true
+}
+
+// Verified returns the player's verification, if any.
+func (s *Memory) Verified(gameName, tagLine string) (Verification, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.verified[RiotIDKey(gameName, tagLine)]
+	return v, ok
+}
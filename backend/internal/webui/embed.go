@@ -0,0 +1,19 @@
+//go:build embedui
+
+package webui
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed all:dist
+var embedded embed.FS
+
+func init() {
+	sub, err := fs.Sub(embedded, "dist")
+	if err != nil {
+		panic(err)
+	}
+	dist = sub
+}
@@ -0,0 +1,53 @@
+// Package webui serves the frontend (front/) from the binary, for the
+// self-contained local mode. The build is only embedded with -tags embedui
+// after copying front/dist here:
+//
+//	cd front && VITE_SAME_ORIGIN=true pnpm build && cp -r dist ../backend/internal/webui/dist
+//	cd ../backend && go build -tags embedui -o lolmatch ./cmd/server
+package webui
+
+import (
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+// dist is the frontend build; nil when it wasn't embedded.
+var dist fs.FS
+
+// Embedded reports whether the binary carries the frontend.
+func Embedded() bool { return dist != nil }
+
+// Handler serves the frontend's files for GET requests that name one ("/"
+// being index.html) and hands every other request, the API, to next.
+func Handler(next http.Handler) http.Handler {
+	files := http.FileServerFS(dist)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if r.URL.Path == "/" && dist == nil {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write([]byte(notEmbedded))
+			return
+		}
+		if name := strings.TrimPrefix(r.URL.Path, "/"); dist != nil && (name == "" || isFile(name)) {
+			files.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isFile(name string) bool {
+	fi, err := fs.Stat(dist, name)
+	return err == nil && !fi.IsDir()
+}
+
+const notEmbedded = `<!doctype html>
+<meta charset="utf-8">
+<title>lol_custom_skill_matching</title>
+<p>このバイナリには画面（フロントエンド）が含まれていません。API は動作しています。</p>
+<p>画面を含めるには front をビルドして <code>-tags embedui</code> でビルドしてください（README の「ローカルモード」を参照）。</p>
+`
@@ -0,0 +1,173 @@
+// Package lolmatch is the embeddable entry point to the analyzer: it resolves
+// Riot IDs, builds skill profiles and splits them into two teams, the same way
+// the web API does, without going through HTTP.
+//
+//	a, err := lolmatch.New(lolmatch.Config{APIKey: os.Getenv("RIOT_API_KEY")})
+//	profiles, split, err := a.Analyze(ctx, players, lolmatch.Options{MatchLimit: 10})
+package lolmatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"lol_custom_skill_matching/internal/analyzer"
+	"lol_custom_skill_matching/internal/balance"
+	"lol_custom_skill_matching/internal/riot"
+)
+
+type (
+	Player             = analyzer.Player
+	Options            = analyzer.Options
+	Profile            = analyzer.Profile
+	Interval           = analyzer.Interval
+	TeamSplit          = analyzer.TeamSplit
+	RoleSplit          = balance.RoleSplit
+	Slot               = balance.Slot
+	LaneGap            = balance.LaneGap
+	Coverage           = balance.Coverage
+	RawAggregates      = analyzer.RawAggregates
+	MatchSummary       = analyzer.MatchSummary
+	LobbySampling      = analyzer.LobbySampling
+	LobbySampleReport  = analyzer.LobbySampleReport
+	ParticipantSampler = analyzer.ParticipantSampler
+	AllParticipants    = analyzer.AllParticipants
+	PerMatchSample     = analyzer.PerMatchSample
+	SplitValidation    = analyzer.SplitValidation
+	TeamInfo           = analyzer.TeamInfo
+	SideStats          = analyzer.SideStats
+	SideReport         = analyzer.SideReport
+	Preset             = analyzer.Preset
+	Captain            = analyzer.Captain
+	Provenance         = analyzer.Provenance
+)
+
+// ErrCorruptSplit is wrapped by Analyze when the split fails validation.
+var ErrCorruptSplit = analyzer.ErrCorruptSplit
+
+const (
+	ModeBalanceFirst      = analyzer.ModeBalanceFirst
+	ModeRolesFirst        = analyzer.ModeRolesFirst
+	BalanceOnScore        = analyzer.BalanceOnScore
+	BalanceOnConservative = analyzer.BalanceOnConservative
+	ObjectiveSum          = analyzer.ObjectiveSum
+	ObjectiveSlotwise     = analyzer.ObjectiveSlotwise
+	SidesFixed            = analyzer.SidesFixed
+	SidesRandom           = analyzer.SidesRandom
+	SidesAlternate        = analyzer.SidesAlternate
+	SidesFair             = analyzer.SidesFair
+	PresetQuick           = analyzer.PresetQuick
+	PresetStandard        = analyzer.PresetStandard
+	PresetDeep            = analyzer.PresetDeep
+)
+
+// LookupPreset returns a built-in preset; apply it with Preset.Apply(&opts).
+func LookupPreset(name string) (Preset, error) { return analyzer.LookupPreset(name) }
+
+// NewParticipantSampler builds the sampler for a lobby-rank sampling config.
+func NewParticipantSampler(cfg LobbySampling) (ParticipantSampler, error) {
+	return analyzer.NewParticipantSampler(cfg)
+}
+
+// Config configures an Analyzer. Only APIKey is required.
+type Config struct {
+	APIKey string
+	// RankWorkers is the participant rank lookup pool size (default 4);
+	// MatchWorkers that of each player's match detail fetches (default
+	// RankWorkers).
+	RankWorkers  int
+	MatchWorkers int
+	// SkipOnLimit gives up on 429/5xx instead of retrying.
+	SkipOnLimit bool
+	// Burst is how many requests may go out back to back before the rate
+	// limiter settles into steady pacing (default 10).
+	Burst int
+	// HTTPClient overrides the client used for Riot API calls.
+	HTTPClient *http.Client
+	// ScoreFormula replaces the built-in skill score formula, e.g.
+	// "current_rank*3 + mastery_top3/2000"; ScoreFeatures lists the names it can use.
+	ScoreFormula string
+	// Platform (default jp1) and Region (default the platform's) route the
+	// players that don't set Player.Platform.
+	Platform string
+	Region   string
+	// PlayerTimeout bounds the analysis of each player (0 = none): one that
+	// runs out gets a Profile.Partial built from what was fetched by then.
+	PlayerTimeout time.Duration
+}
+
+// ScoreFeatures are the inputs available to Config.ScoreFormula.
+var ScoreFeatures = analyzer.ScoreFeatures
+
+// Analyzer is safe for concurrent use; all calls share one rate limiter, so
+// create a single Analyzer per API key.
+type Analyzer struct {
+	an *analyzer.Analyzer
+}
+
+func New(cfg Config) (*Analyzer, error) {
+	if cfg.APIKey == "" {
+		return nil, errors.New("lolmatch: APIKey is required")
+	}
+	lc := riot.DefaultLimiterConfig()
+	lc.Burst = cfg.Burst
+	rc := riot.NewClient(cfg.APIKey, riot.NewLimiterWithConfig(lc))
+	rc.SkipOnLimit = cfg.SkipOnLimit
+	if cfg.HTTPClient != nil {
+		rc.HTTP = cfg.HTTPClient
+	}
+	if cfg.Platform != "" || cfg.Region != "" {
+		if cfg.Platform == "" {
+			cfg.Platform = riot.DefaultPlatform
+		}
+		home, err := riot.LookupPlatform(cfg.Platform, cfg.Region)
+		if err != nil {
+			return nil, fmt.Errorf("lolmatch: %w", err)
+		}
+		rc.SetHome(home)
+	}
+	an := analyzer.New(rc, cfg.RankWorkers)
+	if cfg.MatchWorkers > 0 {
+		an.MatchWorkers = cfg.MatchWorkers
+	}
+	an.PlayerTimeout = cfg.PlayerTimeout
+	if cfg.ScoreFormula != "" {
+		f, err := analyzer.CompileScoreFormula(cfg.ScoreFormula)
+		if err != nil {
+			return nil, fmt.Errorf("lolmatch: ScoreFormula: %w", err)
+		}
+		an.ScoreFormula = f
+	}
+	return &Analyzer{an: an}, nil
+}
+
+// Analyze profiles every player and balances the ones that resolved.
+// Players whose Riot ID does not exist, or whose account lookup outlasted
+// Config.PlayerTimeout, are left out of the result. A split that
+// fails validation (see TeamSplit.Validation) is returned with an error wrapping
+// ErrCorruptSplit and must not be used.
+//
+// Sides follow opts.SidePolicy with no side history; use AnalyzeWithSides to
+// supply one.
+func (a *Analyzer) Analyze(ctx context.Context, players []Player, opts Options) ([]Profile, TeamSplit, error) {
+	return a.AnalyzeWithSides(ctx, players, opts, func(string) SideStats { return SideStats{} })
+}
+
+// AnalyzeWithSides is Analyze with each player's side history (keyed by
+// "name#tag") so the "alternate" and "fair" side policies can take it into account.
+func (a *Analyzer) AnalyzeWithSides(ctx context.Context, players []Player, opts Options, history func(riotID string) SideStats) ([]Profile, TeamSplit, error) {
+	profiles, err := a.an.Analyze(ctx, players, opts)
+	if err != nil {
+		return nil, TeamSplit{}, err
+	}
+	split := analyzer.Split(profiles, opts)
+	if err := split.Validation.Err(); err != nil {
+		return profiles, split, err
+	}
+	analyzer.AssignSides(&split, opts.SidePolicy, history, rand.New(rand.NewPCG(uint64(time.Now().UnixNano()), 1)))
+	split.Provenance = a.an.Provenance(players, opts)
+	return profiles, split, nil
+}
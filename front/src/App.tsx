@@ -3,14 +3,20 @@ import './App.css'
 
 type Player = { gameName: string; tagLine: string }
 type TeamPlayer = { name: string; skill_score: number; main_lanes?: string[] }
+type WinPrediction = { split: string; blue_win_pct: number; red_win_pct: number; score_diff: number }
 type AnalyzeResponse = {
   teamA: TeamPlayer[]
   teamB: TeamPlayer[]
   sumA: number
   sumB: number
+  win_predictions?: WinPrediction[]
 }
 
-const DEFAULT_API_BASE = import.meta.env.VITE_API_BASE || 'http://localhost:8080'
+const SPLIT_LABELS: Record<string, string> = { teams: 'チーム分け', lane_unique: 'レーン重複なし', roles_first: 'レーン優先' }
+
+// VITE_SAME_ORIGIN: the build embedded in the server's local mode calls the server that served it
+const DEFAULT_API_BASE =
+  import.meta.env.VITE_API_BASE || (import.meta.env.VITE_SAME_ORIGIN === 'true' ? window.location.origin : 'http://localhost:8080')
 const CLOUD_API_BASE: string | undefined = import.meta.env.VITE_API_CLOUD_BASE
 const MAX_PLAYERS = 10
 
@@ -103,6 +109,21 @@ function App() {
     })
   }
 
+  // DEMO_MODE servers list their sample roster at /demo
+  const loadDemoRoster = async () => {
+    setError(null)
+    setInfo(null)
+    try {
+      const res = await fetch(`${apiBase}/demo`)
+      if (!res.ok) throw new Error('このサーバーはデモモードではありません')
+      const data: { players: Player[] } = await res.json()
+      setPlayers(data.players.slice(0, MAX_PLAYERS))
+      setInfo('サンプルのプレイヤーを登録しました')
+    } catch (e: any) {
+      setError(e?.message || 'request failed')
+    }
+  }
+
   const addNewPlayer = () => {
     const gn = newPlayer.gameName.trim()
     const tl = newPlayer.tagLine.trim()
@@ -157,6 +178,7 @@ function App() {
         />
         <div style={{ marginTop: 8 }}>
           <button onClick={registerFromLog}>登録</button>
+          <button style={{ marginLeft: 8 }} onClick={loadDemoRoster}>サンプルで試す</button>
         </div>
       </div>
 
@@ -203,6 +225,14 @@ function App() {
       {error && <div style={{ color: 'red' }}>{error}</div>}
       {info && <div style={{ color: 'green' }}>{info}</div>}
 
+      {result?.win_predictions?.length ? (
+        <div>
+          予測勝率（ブルー / レッド）:{' '}
+          {result.win_predictions
+            .map(w => `${SPLIT_LABELS[w.split] ?? w.split} ${w.blue_win_pct}% / ${w.red_win_pct}%`)
+            .join('、')}
+        </div>
+      ) : null}
       {result && (
         <div style={{ display: 'flex', gap: 24 }}>
           <div style={{ flex: 1 }}>